@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/psexec"
+)
+
+// runAdoptMode 执行 adopt 子命令，为目标目录中已有但没有备份记录的文件补建记录
+func runAdoptMode(args []string) error {
+	fs := flag.NewFlagSet("adopt", flag.ExitOnError)
+	var deviceName, adoptConfigFile string
+	fs.StringVar(&adoptConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&adoptConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认使用配置文件中的设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.StringVar(&targetDir, "target", "", "指定备份目标目录（覆盖配置文件）")
+	fs.StringVar(&targetDir, "t", "", "指定备份目标目录（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(adoptConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	globalSourceFlags.apply(cfg)
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	psexec.Init(&cfg.PowerShell, log)
+
+	if targetDir != "" {
+		cfg.Target.BaseDirectory = targetDir
+	}
+	if deviceName != "" {
+		cfg.Source.DeviceName = deviceName
+	}
+
+	dev, err := detectSourceDevice(cfg)
+	if err != nil {
+		return fmt.Errorf("设备检测失败: %w", err)
+	}
+	mtpInterface, release, err := device.SharedPool(log).AcquireDevice(dev)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	manager := backup.NewManager(cfg, log, true, verbose, false)
+	defer manager.Close()
+	manager.SetMTPInterface(mtpInterface)
+
+	result, err := manager.Adopt(mtpInterface.GetDeviceInfo())
+	if err != nil {
+		return fmt.Errorf("收养已有文件失败: %w", err)
+	}
+
+	fmt.Printf("目标目录中未登记的文件: %d 个\n", result.Unrecorded)
+	fmt.Printf("已收养（补建备份记录）: %d 个\n", result.Adopted)
+	if result.Mismatched > 0 {
+		fmt.Printf("同名同大小但内容不同: %d 个（未收养）\n", result.Mismatched)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runArchiveMode 执行 archive 子命令，把备份目录中的散文件按录音日期合并为每天一个zip
+// 用法: archive --group-by day [--out 目录] [--delete [--yes]] [--dry-run]
+func runArchiveMode(args []string) error {
+	fs := flag.NewFlagSet("archive", flag.ExitOnError)
+	var archiveConfigFile, groupBy, outDir string
+	var deleteSources, yes, dryRun bool
+	fs.StringVar(&archiveConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&archiveConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&groupBy, "group-by", "", "分组方式，目前支持 day（按录音日期，每天一个 YYYY-MM-DD.zip）")
+	fs.StringVar(&outDir, "out", "", "归档输出目录，默认为目标目录下的 archive")
+	fs.BoolVar(&deleteSources, "delete", false, "归档并校验后删除原散文件（安全模式下移入回收站），备份记录改为指向归档条目")
+	fs.BoolVar(&yes, "yes", false, "删除原散文件时不再确认")
+	fs.BoolVar(&dryRun, "dry-run", false, "只列出分组结果，不生成归档")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	if groupBy != backup.GroupByDay {
+		return fmt.Errorf("请指定分组方式: --group-by %s", backup.GroupByDay)
+	}
+
+	cfg, err := config.LoadConfig(archiveConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	if outDir == "" {
+		outDir = filepath.Join(cfg.Target.BaseDirectory, "archive")
+	}
+
+	log := newReportLogger(cfg)
+	defer log.Close()
+
+	tracker, err := loadTracker(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	archiver := backup.NewDayArchiver(tracker, outDir, log)
+	groups := archiver.Plan()
+	if len(groups) == 0 {
+		fmt.Println("没有需要归档的备份文件")
+		return nil
+	}
+	printArchiveGroups(groups)
+	if dryRun {
+		return nil
+	}
+
+	if deleteSources {
+		action := "删除"
+		if cfg.Backup.SafeMode {
+			action = "移入回收站"
+		}
+		total := 0
+		for _, group := range groups {
+			total += len(group.Records)
+		}
+		if !yes && !confirm(fmt.Sprintf("归档后将%s %d 个原散文件，确定吗？[y/N] ", action, total)) {
+			fmt.Println("已取消")
+			return nil
+		}
+		archiver.SetDeleteSources(true, cfg.Backup.SafeMode, backup.NewTrashFromConfig(cfg, log))
+	}
+
+	archived, removed, archiveErr := archiver.Archive()
+	for _, group := range archived {
+		fmt.Printf("已生成: %s（%d 个文件）\n", group.ArchivePath, len(group.Records))
+	}
+	if deleteSources {
+		if err := tracker.Save(); err != nil {
+			return fmt.Errorf("保存备份记录失败: %w", err)
+		}
+		fmt.Printf("已处理原散文件: %d 个\n", removed)
+	}
+	return archiveErr
+}
+
+// printArchiveGroups 输出按日分组的结果
+func printArchiveGroups(groups []backup.DayArchiveGroup) {
+	fmt.Printf("%-10s %6s %10s\n", "日期", "文件数", "大小")
+	for _, group := range groups {
+		fmt.Printf("%-10s %6d %10s\n", group.Date, len(group.Records), utils.FormatBytes(group.Size))
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/allanpk716/record_center/internal/benchmark"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runBenchmarkMode 执行 benchmark 子命令，测量设备的MTP读取速度（只读取不写盘）
+func runBenchmarkMode(args []string) error {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	var deviceName, benchConfigFile, sizeStr string
+	var runs int
+	fs.StringVar(&benchConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&benchConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认使用配置文件中的设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.StringVar(&sizeStr, "size", "50MB", "测试文件的目标大小，选择设备上最接近该大小的文件")
+	fs.IntVar(&runs, "runs", 3, "读取次数，结果取平均")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	targetSize, err := utils.ParseByteSize(sizeStr)
+	if err != nil {
+		return fmt.Errorf("无效的文件大小 %q: %w", sizeStr, err)
+	}
+
+	cfg, err := config.LoadConfig(benchConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	globalSourceFlags.apply(cfg)
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+
+	if deviceName != "" {
+		cfg.Source.DeviceName = deviceName
+	}
+
+	dev, err := detectSourceDevice(cfg)
+	if err != nil {
+		return fmt.Errorf("设备检测失败: %w", err)
+	}
+	mtpInterface, release, err := device.SharedPool(log).AcquireDevice(dev)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	mtpFiles, err := device.ListFilesInStorages(mtpInterface, cfg.Source.BasePath, cfg.Source.Storage, log)
+	if err != nil {
+		return fmt.Errorf("枚举设备文件失败: %w", err)
+	}
+
+	files := make([]*utils.FileInfo, 0, len(mtpFiles))
+	for _, mtpFile := range mtpFiles {
+		files = append(files, &utils.FileInfo{
+			Path:         mtpFile.Path,
+			RelativePath: mtpFile.RelativePath,
+			Name:         mtpFile.Name,
+			Size:         mtpFile.Size,
+		})
+	}
+
+	file := benchmark.SelectFile(files, targetSize)
+	if file == nil {
+		return fmt.Errorf("设备上没有可用于测试的文件")
+	}
+
+	fmt.Printf("测试文件: %s (%s)，读取 %d 次\n", file.Name, utils.FormatBytes(file.Size), runs)
+
+	result, err := benchmark.Measure(func() (io.ReadCloser, error) {
+		return mtpInterface.GetFileStream(file.Path)
+	}, runs)
+	if err != nil {
+		return fmt.Errorf("测速失败: %w", err)
+	}
+
+	for i, run := range result.Runs {
+		fmt.Printf("  第 %d 次: %s, 耗时 %s, %.2f MB/s, 首字节延迟 %d ms\n",
+			i+1, utils.FormatBytes(run.Bytes), utils.FormatDurationPrecise(run.Duration),
+			run.Throughput()/1024/1024, run.Latency.Milliseconds())
+	}
+	fmt.Printf("\n平均吞吐: %.2f MB/s（最慢 %.2f MB/s，最快 %.2f MB/s）\n",
+		result.MBps(), result.MinThroughput/1024/1024, result.MaxThroughput/1024/1024)
+	fmt.Printf("平均首字节延迟: %d ms\n", result.AvgLatency.Milliseconds())
+	return nil
+}
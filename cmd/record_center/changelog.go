@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/backup"
+)
+
+// runChangelogMode 执行 changelog 子命令，查看最近几次备份新增的文件
+func runChangelogMode(args []string) error {
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	var last int
+	var task, deviceName string
+	fs.IntVar(&last, "last", 5, "查看最近N次备份，0表示全部")
+	fs.IntVar(&last, "n", 5, "查看最近N次备份（短格式）")
+	fs.StringVar(&task, "task", "", "查看配置文件中指定任务的变更日志（默认为主命令的变更日志）")
+	fs.StringVar(&deviceName, "device", "", "只查看名称包含该值的设备")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	if last < 0 {
+		return fmt.Errorf("last 必须大于等于0: %d", last)
+	}
+
+	path := backup.ChangelogPath
+	if task != "" {
+		path = filepath.Join(backup.TaskDataDir(task), filepath.Base(backup.ChangelogPath))
+	}
+
+	// 按设备筛选时先读取全部，再取最近N条
+	readLast := last
+	if deviceName != "" {
+		readLast = 0
+	}
+	entries, err := backup.ReadChangelog(path, readLast)
+	if err != nil {
+		return err
+	}
+	if deviceName != "" {
+		filtered := entries[:0]
+		for _, entry := range entries {
+			if strings.Contains(strings.ToUpper(entry.DeviceName), strings.ToUpper(deviceName)) {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+		if last > 0 && len(entries) > last {
+			entries = entries[len(entries)-last:]
+		}
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("没有变更记录: %s\n", path)
+		return nil
+	}
+	backup.WriteChangelog(os.Stdout, entries)
+	return nil
+}
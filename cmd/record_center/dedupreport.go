@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runDedupReportMode 执行 dedup-report 子命令，按内容哈希统计重复的备份文件，只报告不删除
+func runDedupReportMode(args []string) error {
+	fs := flag.NewFlagSet("dedup-report", flag.ExitOnError)
+	var dedupConfigFile string
+	var merge bool
+	var top int
+	fs.StringVar(&dedupConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&dedupConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.BoolVar(&merge, "merge", false, "合并统计其后列出的多个备份记录文件，如 --merge a.json b.json")
+	fs.IntVar(&top, "top", 10, "列出可节省空间最多的前N个重复项，0表示全部")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	paths := []string{backup.RecordsPath}
+	if merge {
+		paths = fs.Args()
+		if len(paths) == 0 {
+			return fmt.Errorf("--merge 需要指定至少一个备份记录文件")
+		}
+	} else if fs.NArg() > 0 {
+		return fmt.Errorf("多余的参数: %v（合并多个记录文件请使用 --merge）", fs.Args())
+	}
+	if top < 0 {
+		return fmt.Errorf("top 必须大于等于0: %d", top)
+	}
+
+	cfg, err := config.LoadConfig(dedupConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	// 只读加载，不会改写其他机器的记录文件
+	passphrase := cfg.Storage.ResolveEncryptionKey()
+	sets := make([]storage.RecordSet, 0, len(paths))
+	for _, path := range paths {
+		records, err := storage.ReadRecordsFile(path, passphrase)
+		if err != nil {
+			return fmt.Errorf("加载 %s 失败: %w", path, err)
+		}
+		sets = append(sets, storage.RecordSet{Name: path, Records: records})
+	}
+
+	printDuplicateReport(storage.BuildDuplicateReport(sets), top, len(sets) > 1)
+	return nil
+}
+
+// printDuplicateReport 输出重复统计和可节省空间最多的重复项
+func printDuplicateReport(report *storage.DuplicateReport, top int, showSource bool) {
+	fmt.Printf("备份记录: %d 条（有内容哈希 %d 条）\n", report.TotalFiles, report.HashedFiles)
+	if len(report.Groups) == 0 {
+		fmt.Println("没有发现重复的备份文件")
+		return
+	}
+	fmt.Printf("重复文件: %d 组，多余副本 %d 个，可节省 %s\n",
+		len(report.Groups), report.DuplicateFiles, utils.FormatBytes(report.SavableBytes))
+
+	groups := report.Groups
+	if top > 0 && len(groups) > top {
+		groups = groups[:top]
+	}
+	fmt.Printf("\n可节省空间最多的 %d 个重复项:\n", len(groups))
+	for i, group := range groups {
+		hash := group.Hash
+		if len(hash) > 16 {
+			hash = hash[:16]
+		}
+		fmt.Printf("%3d. %s  %d 份 × %s，可节省 %s\n",
+			i+1, hash, len(group.Entries), utils.FormatBytes(group.Size), utils.FormatBytes(group.Savable()))
+		for _, entry := range group.Entries {
+			if showSource {
+				fmt.Printf("       [%s] %s\n", entry.Source, entry.Record.TargetPath)
+			} else {
+				fmt.Printf("       %s\n", entry.Record.TargetPath)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/doctor"
+	"github.com/allanpk716/record_center/internal/psexec"
+)
+
+// runDoctorMode 执行 doctor 子命令，逐项检查运行环境并给出修复建议
+func runDoctorMode(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	var doctorConfigFile, doctorTarget string
+	fs.StringVar(&doctorConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&doctorConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&doctorTarget, "target", "", "检查的备份目标目录（默认使用配置文件中的目录）")
+	fs.StringVar(&doctorTarget, "t", "", "检查的备份目标目录（短格式）")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	// 配置文件有问题时仍用默认配置完成其余检查
+	cfg, err := config.LoadConfig(doctorConfigFile)
+	if err != nil {
+		printDoctorResult(doctor.Result{
+			Name:   "配置文件",
+			Status: doctor.StatusFail,
+			Detail: err.Error(),
+			Advice: "请修正配置文件，或执行 record_center init 重新生成",
+		})
+		cfg = config.DefaultConfig()
+	} else {
+		printDoctorResult(doctor.Result{Name: "配置文件", Detail: doctorConfigFile})
+	}
+	globalSourceFlags.apply(cfg)
+	if doctorTarget == "" {
+		doctorTarget = cfg.Target.BaseDirectory
+	}
+
+	log := newLogger(cfg, globalLogFlags, false, true)
+	defer log.Close()
+	psexec.Init(&cfg.PowerShell, log)
+
+	results := doctor.Run(doctor.Options{
+		Runner:               doctor.ExecRunner{},
+		PowerShellCandidates: cfg.PowerShell.FallbackOrder,
+		TargetDirectory:      doctorTarget,
+		DeviceName:           cfg.Source.DeviceName,
+		VID:                  cfg.Source.VID,
+		PID:                  cfg.Source.PID,
+		QuickStat: func() (int, int64, error) {
+			dev, err := detectSourceDevice(cfg)
+			if err != nil {
+				return 0, 0, err
+			}
+			mtp, release, err := device.SharedPool(log).AcquireDevice(dev)
+			if err != nil {
+				return 0, 0, err
+			}
+			defer release()
+			return mtp.QuickStat()
+		},
+	})
+	for _, result := range results {
+		printDoctorResult(result)
+	}
+
+	conclusion := doctor.Conclusion(results)
+	if err != nil {
+		conclusion = doctor.StatusFail
+	}
+	switch conclusion {
+	case doctor.StatusOK:
+		fmt.Println("结论: 环境正常，可以开始备份")
+	case doctor.StatusWarn:
+		fmt.Println("结论: 可以备份，但请留意上面的警告")
+	default:
+		fmt.Println("结论: 请按上面的建议修复失败项后重试")
+		return fmt.Errorf("环境诊断未通过")
+	}
+	return nil
+}
+
+// printDoctorResult 输出一项检查的结论和修复建议
+func printDoctorResult(result doctor.Result) {
+	fmt.Printf("  [%s] %s: %s\n", result.Status, result.Name, result.Detail)
+	if result.Advice != "" {
+		fmt.Printf("      建议: %s\n", result.Advice)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// runImportMode 执行 import 子命令，把其他工具的备份清单或日志导入备份记录，之后的备份跳过这些文件
+func runImportMode(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var format, file, destDir, deviceID, strategyName, importConfigFile string
+	fs.StringVar(&format, "format", "", "外部记录格式: csv（source,target 清单）、rsync（--itemize-changes 或 --log-file 日志）、records（另一台机器导出的备份记录文件）")
+	fs.StringVar(&file, "file", "", "外部记录文件路径")
+	fs.StringVar(&destDir, "dest", "", "外部工具写入的本地目录，相对路径相对于它（默认使用配置的备份目标目录）")
+	fs.StringVar(&deviceID, "device-id", "", "导入记录所属的设备ID（默认使用配置中的设备名称）")
+	fs.StringVar(&strategyName, "strategy", string(storage.MergeNewerWins), "records 格式与已有记录冲突时的合并策略: skip-existing、overwrite、newer-wins")
+	fs.StringVar(&importConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&importConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	if format == "" || file == "" {
+		return fmt.Errorf("请使用 --format 和 --file 指定外部记录的格式和文件")
+	}
+
+	cfg, err := config.LoadConfig(importConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+
+	if format == "records" {
+		return mergeRecordsFile(cfg, log, file, strategyName)
+	}
+
+	if deviceID == "" {
+		deviceID = cfg.Source.DeviceName
+	}
+
+	input, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("打开外部记录失败: %w", err)
+	}
+	defer input.Close()
+
+	manager := backup.NewManager(cfg, log, true, verbose, false)
+	defer manager.Close()
+
+	result, err := manager.ImportRecords(input, format, destDir, deviceID)
+	if err != nil {
+		return fmt.Errorf("导入备份记录失败: %w", err)
+	}
+
+	fmt.Printf("新导入的备份记录: %d 条\n", result.Imported)
+	if result.Existing > 0 {
+		fmt.Printf("已有备份记录: %d 条\n", result.Existing)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("无法导入: %d 条\n", len(result.Skipped))
+		for _, skip := range result.Skipped {
+			fmt.Printf("  第 %d 行 %s: %s\n", skip.Line, skip.Entry, skip.Reason)
+		}
+	}
+	return nil
+}
+
+// mergeRecordsFile 把另一台机器导出的备份记录文件按合并策略并入本机记录
+func mergeRecordsFile(cfg *config.Config, log *logger.Logger, file, strategyName string) error {
+	strategy, err := storage.ParseMergeStrategy(strategyName)
+	if err != nil {
+		return err
+	}
+
+	tracker, err := loadTracker(cfg, log)
+	if err != nil {
+		return err
+	}
+	added, updated, skipped, err := tracker.ImportRecords(file, strategy)
+	if err != nil {
+		return fmt.Errorf("导入备份记录失败: %w", err)
+	}
+	if err := tracker.Save(); err != nil {
+		return fmt.Errorf("保存备份记录失败: %w", err)
+	}
+
+	fmt.Printf("合并备份记录（%s）: 新增 %d 条，更新 %d 条，跳过 %d 条\n", strategy, added, updated, skipped)
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/index"
+)
+
+// runIndexMode 执行 index 子命令，从备份记录生成可浏览、可播放的静态 HTML 索引页
+func runIndexMode(args []string) error {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	var deviceName, indexConfigFile, outPath string
+	fs.StringVar(&indexConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&indexConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&outPath, "out", "index.html", "索引页输出路径，备份文件链接相对于该文件所在目录")
+	fs.StringVar(&outPath, "o", "index.html", "索引页输出路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认列出所有设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(indexConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newReportLogger(cfg)
+	defer log.Close()
+
+	tracker, err := loadTracker(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	page, err := index.Generate(tracker.GetStorage(), deviceName, outPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("索引页已生成: %s（%d 个设备，%d 个文件）\n", outPath, len(page.Devices), page.Total)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+)
+
+// runInitMode 执行 init 子命令，按设备型号生成预填参数的配置文件
+func runInitMode(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	var model, initConfigFile string
+	var overwrite bool
+	fs.StringVar(&model, "model", "", "设备型号，如 SR302")
+	fs.StringVar(&initConfigFile, "config", "configs/backup.yaml", "生成的配置文件路径")
+	fs.StringVar(&initConfigFile, "c", "configs/backup.yaml", "生成的配置文件路径（短格式）")
+	fs.BoolVar(&overwrite, "force", false, "覆盖已存在的配置文件")
+	fs.Parse(args)
+
+	if model == "" {
+		return fmt.Errorf("请使用 --model 指定设备型号")
+	}
+
+	if _, err := os.Stat(initConfigFile); err == nil && !overwrite {
+		return fmt.Errorf("配置文件已存在: %s（使用 --force 覆盖）", initConfigFile)
+	}
+
+	profile, known := device.LookupModel(model)
+	if !known {
+		profile = device.ModelDefaults(model)
+	}
+
+	cfg := config.DefaultConfig()
+	profile.ApplyTo(cfg)
+
+	if err := config.SaveConfig(cfg, initConfigFile); err != nil {
+		return err
+	}
+
+	if known {
+		fmt.Printf("已按型号 %s 的内置参数生成配置文件: %s\n", profile.Model, initConfigFile)
+	} else {
+		fmt.Printf("未知型号 %s，已使用通用默认参数生成配置文件: %s\n", model, initConfigFile)
+		fmt.Println("请运行 record_center --detect 获取设备的VID/PID并补充到配置文件中")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runListMode 执行 list 子命令，分页列出备份记录
+func runListMode(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	var listConfigFile, sortBy string
+	var page, size int
+	var desc, includeArchived bool
+	fs.StringVar(&listConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&listConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.IntVar(&page, "page", 1, "页码，从1开始")
+	fs.IntVar(&size, "size", 50, "每页记录数")
+	fs.StringVar(&sortBy, "sort", storage.SortByTime, "排序字段: time、size、name")
+	fs.BoolVar(&desc, "desc", false, "降序排列")
+	fs.BoolVar(&includeArchived, "include-archived", false, "同时列出已归档的旧记录（records_archive_YYYY.json）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	if page < 1 {
+		return fmt.Errorf("页码必须大于等于1: %d", page)
+	}
+	if size < 1 {
+		return fmt.Errorf("每页记录数必须大于等于1: %d", size)
+	}
+	field, fieldDesc, err := storage.ParseSort(sortBy)
+	if err != nil {
+		return err
+	}
+	// --sort -time 与 --desc 均表示降序
+	if desc || fieldDesc {
+		field = "-" + field
+	}
+
+	cfg, err := config.LoadConfig(listConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newReportLogger(cfg)
+	defer log.Close()
+
+	tracker, err := loadTracker(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	var records []storage.BackupRecord
+	var total int
+	if includeArchived {
+		records, total = tracker.ListRecordsIncludingArchived((page-1)*size, size, field)
+	} else {
+		records, total = tracker.ListRecords((page-1)*size, size, field)
+	}
+	printRecords(records, total, page, size)
+	return nil
+}
+
+// printRecords 输出一页备份记录
+func printRecords(records []storage.BackupRecord, total, page, size int) {
+	pages := (total + size - 1) / size
+	if len(records) == 0 {
+		if total == 0 {
+			fmt.Println("暂无备份记录")
+		} else {
+			fmt.Printf("第 %d 页没有记录，共 %d 页 %d 条记录\n", page, pages, total)
+		}
+		return
+	}
+
+	fmt.Printf("%-6s %-19s %10s  %s\n", "序号", "备份时间", "大小", "文件")
+	for i, record := range records {
+		status := ""
+		if !record.Success {
+			status = " (失败)"
+		}
+		fmt.Printf("%-6d %-19s %10s  %s%s\n",
+			(page-1)*size+i+1,
+			record.BackupTime.Format("2006-01-02 15:04:05"),
+			utils.FormatBytes(record.FileSize),
+			storage.RecordName(record.SourcePath),
+			status)
+	}
+	fmt.Printf("第 %d/%d 页，共 %d 条记录\n", page, pages, total)
+}
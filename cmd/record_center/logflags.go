@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// logFlags 全局日志标志，优先于配置文件中的 logging 配置
+type logFlags struct {
+	file   string
+	level  string
+	format string
+}
+
+// globalLogFlags 主命令与各子命令共用的日志标志
+var globalLogFlags logFlags
+
+// register 在 FlagSet 上注册日志标志
+func (f *logFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.file, "log-file", "", "日志文件路径（覆盖配置文件）")
+	fs.StringVar(&f.level, "log-level", "", "日志级别: debug、info、warn、error（覆盖配置文件）")
+	fs.StringVar(&f.format, "log-format", "", "日志格式: text、json（覆盖配置文件）")
+}
+
+// newLogger 合并配置文件与命令行标志创建日志器，cfg 为 nil 时使用默认配置
+func newLogger(cfg *config.Config, flags logFlags, verbose, quiet bool) *logger.Logger {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+
+	base := logger.Options{
+		Level:    cfg.Logging.Level,
+		File:     cfg.Logging.FilePath(),
+		Format:   cfg.Logging.Format,
+		Console:  cfg.Logging.Console,
+		RingSize: cfg.Logging.RingSize,
+		Redact:   cfg.Logging.Redact,
+	}
+	overrides := logger.Options{Level: flags.level, File: flags.file, Format: flags.format}
+	opts, warnings := logger.ResolveOptions(base, overrides, verbose, quiet)
+
+	log, err := logger.New(opts)
+	if err != nil {
+		fmt.Printf("初始化日志失败，使用默认日志: %v\n", err)
+		log = logger.InitLogger(verbose)
+	}
+	for _, w := range warnings {
+		log.Warn("%s", w)
+	}
+	return log
+}
+
+// newReportLogger 为只输出报告的子命令（status、list）创建日志器
+// 未要求详细模式或指定日志标志时丢弃日志，避免干扰报告输出
+func newReportLogger(cfg *config.Config) *logger.Logger {
+	if verbose || globalLogFlags != (logFlags{}) {
+		return newLogger(cfg, globalLogFlags, verbose, false)
+	}
+	log := logger.NewLogger(false)
+	log.SetOutput(io.Discard)
+	return log
+}
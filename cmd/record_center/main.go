@@ -2,15 +2,22 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/allanpk716/record_center/internal/config"
 	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/i18n"
 	"github.com/allanpk716/record_center/internal/backup"
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/metrics"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
 )
 
 var (
@@ -22,10 +29,182 @@ var (
 	targetDir      string
 	cleanEmpty     bool
 	detectMode     bool // detect 模式标志
+	fromStdin      bool // 从标准输入读取要备份的文件列表
 	interactiveMode bool // 交互模式标志（双击运行时启用）
 )
 
 func main() {
+	// 子命令: tree
+	if len(os.Args) > 1 && os.Args[1] == "tree" {
+		if err := runTreeMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: benchmark
+	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+		if err := runBenchmarkMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: init
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		if err := runInitMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: migrate-config
+	if len(os.Args) > 1 && os.Args[1] == "migrate-config" {
+		if err := runMigrateConfigMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: status
+	if len(os.Args) > 1 && os.Args[1] == "status" {
+		if err := runStatusMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: list
+	if len(os.Args) > 1 && os.Args[1] == "list" {
+		if err := runListMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: schedule
+	if len(os.Args) > 1 && os.Args[1] == "schedule" {
+		if err := runScheduleMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: run
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		if err := runRunMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: adopt
+	if len(os.Args) > 1 && os.Args[1] == "adopt" {
+		if err := runAdoptMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: index
+	if len(os.Args) > 1 && os.Args[1] == "index" {
+		if err := runIndexMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: dedup-report
+	if len(os.Args) > 1 && os.Args[1] == "dedup-report" {
+		if err := runDedupReportMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: trash
+	if len(os.Args) > 1 && os.Args[1] == "trash" {
+		if err := runTrashMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: changelog
+	if len(os.Args) > 1 && os.Args[1] == "changelog" {
+		if err := runChangelogMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: import
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImportMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: doctor
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		if err := runDoctorMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: archive
+	if len(os.Args) > 1 && os.Args[1] == "archive" {
+		if err := runArchiveMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: preview-audio
+	if len(os.Args) > 1 && os.Args[1] == "preview-audio" {
+		if err := runPreviewAudioMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 子命令: resume
+	if len(os.Args) > 1 && os.Args[1] == "resume" {
+		if err := runResumeMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	// 子命令: selftest
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		if err := runSelfTestMode(os.Args[2:]); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 定义命令行参数（同时支持长短格式）
 	flag.StringVar(&configFile, "config", "configs/backup.yaml", "配置文件路径")
 	flag.StringVar(&configFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
@@ -41,12 +220,22 @@ func main() {
 	flag.StringVar(&targetDir, "t", "", "指定备份目标目录（短格式）")
 	flag.BoolVar(&cleanEmpty, "clean-empty", true, "自动清理空文件夹")
 	flag.BoolVar(&cleanEmpty, "e", true, "自动清理空文件夹（短格式）")
+	flag.BoolVar(&fromStdin, "from-stdin", false, "从标准输入逐行读取设备文件的相对路径，只备份这些文件")
 
 	// detect 模式参数
 	flag.BoolVar(&detectMode, "detect", false, "检测并列出所有可用的录音笔设备")
 
+	// 源设备参数
+	globalSourceFlags.register(flag.CommandLine)
+
+	// 日志参数
+	globalLogFlags.register(flag.CommandLine)
+
 	flag.Parse()
 
+	// 界面语言先按 RC_LANG 环境变量选择，加载配置后再按 language 配置调整
+	i18n.SetLanguage(i18n.Resolve(""))
+
 	// 检测是否为双击运行
 	if isDoubleClickRun() {
 		interactiveMode = true
@@ -61,12 +250,23 @@ func main() {
 
 	// 执行主备份逻辑
 	if err := runMainMode(); err != nil {
-		fmt.Printf("错误: %v\n", err)
+		fmt.Println(i18n.T("common.error", err))
 		if interactiveMode {
-			waitForKeyPress("程序执行出错！")
+			waitForKeyPress(i18n.T("main.run_failed_wait"))
 		}
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitDeviceDisconnected 备份中途设备断开时的退出码，进度已保存，重新连接设备后再次运行即可继续
+const exitDeviceDisconnected = 3
+
+// exitCode 按错误类型选择退出码，设备断开时使用单独的退出码便于脚本区分
+func exitCode(err error) int {
+	if errors.Is(err, device.ErrDeviceNotConnected) {
+		return exitDeviceDisconnected
 	}
+	return 1
 }
 
 // runMainMode 执行主备份逻辑
@@ -74,125 +274,230 @@ func runMainMode() error {
 	// 检测是否为双击运行，显示欢迎界面
 	if interactiveMode {
 		fmt.Println("============================================================")
-		fmt.Println("         录音笔备份工具 - SR302 自动备份")
+		fmt.Println(i18n.T("main.banner"))
 		fmt.Println("============================================================")
 		fmt.Println()
 	}
 
-	// 初始化日志
-	log := logger.InitLogger(verbose)
+	// 加载配置，日志按配置与命令行标志初始化；配置加载失败时使用默认日志配置
+	cfg, err := config.LoadConfig(configFile)
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
 	defer log.Close()
-	log.Info("录音笔备份工具启动")
+	defer log.RecoverPanic()
+	log.Info("%s", i18n.T("main.start"))
 
-	// 加载配置
-	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
-		log.Error("配置加载失败: %v", err)
+		log.Error("%s", i18n.T("main.config_failed", err))
 		if interactiveMode {
-			waitForKeyPress("配置加载失败，请检查配置文件！")
+			waitForKeyPress(i18n.T("main.config_failed_wait"))
 		}
 		return fmt.Errorf("配置加载失败: %w", err)
 	}
+	i18n.SetLanguage(i18n.Resolve(cfg.Language))
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+	defer startMetrics(cfg, log)()
 
 	// 如果命令行指定了目标目录，覆盖配置文件中的设置
 	if targetDir != "" {
 		cfg.Target.BaseDirectory = targetDir
-		log.Info("使用命令行指定的目标目录: %s", targetDir)
+		log.Info("%s", i18n.T("main.target_override", targetDir))
+	}
+
+	// 命令行指定的设备名称、VID/PID覆盖配置文件中的源设备
+	if globalSourceFlags.apply(cfg) {
+		log.Info("%s", i18n.T("main.source_override", cfg.Source.DeviceName, cfg.Source.VID, cfg.Source.PID))
+	}
+
+	// 从管道读取文件列表时跳过设备枚举，只处理列出的文件
+	var fileList []string
+	if fromStdin {
+		fileList, err = backup.ReadFileList(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if len(fileList) == 0 {
+			return fmt.Errorf("标准输入中没有文件路径")
+		}
+		log.Info("从标准输入读取到 %d 个文件路径", len(fileList))
 	}
 
 	// 检测设备
-	log.Info("正在检测SR302录音笔设备...")
-	sr302Device, err := device.DetectSR302()
+	log.Info("%s", i18n.T("main.detecting"))
+	sr302Device, err := detectSourceDevice(cfg)
 	if err != nil {
-		log.Error("设备检测失败: %v", err)
-		fmt.Printf("错误: %v\n", err)
+		log.Error("%s", i18n.T("main.detect_failed", err))
+		fmt.Println(i18n.T("common.error", err))
 		if interactiveMode {
-			waitForKeyPress("设备检测失败，请检查设备连接！")
+			waitForKeyPress(i18n.T("main.detect_failed_wait"))
 		}
 		return fmt.Errorf("设备检测失败: %w", err)
 	}
 
-	log.Info("找到设备: %s (ID: %s)", sr302Device.Name, sr302Device.DeviceID)
-	log.Info("VID: %s, PID: %s", sr302Device.VID, sr302Device.PID)
-
-	// 创建备份管理器
-	manager := backup.NewManager(cfg, log, quiet, verbose, cleanEmpty)
+	log.Info("%s", i18n.T("main.device_found", sr302Device.DisplayName(cfg), sr302Device.DeviceID))
+	log.Info("%s", i18n.T("main.device_ids", sr302Device.VID, sr302Device.PID))
 
 	// 执行备份
 	if check {
-		log.Info("检查模式: 仅扫描文件，不执行备份")
+		log.Info("%s", i18n.T("main.check_mode"))
+		manager := backup.NewManager(cfg, log, quiet, verbose, cleanEmpty)
+		manager.SetFileList(fileList)
 		err = manager.Check(sr302Device)
+		manager.Close()
 	} else {
-		err = manager.Run(sr302Device, force)
+		// Ctrl+C 时停止开始新文件的复制，保存已完成的记录后退出
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		manager := backup.NewManager(cfg, log, quiet, verbose, cleanEmpty)
+		manager.SetFileList(fileList)
+		err = runManager(ctx, manager, log, sr302Device, force)
+		manager.Close()
+		stop()
 	}
 
 	if err != nil {
-		log.Error("操作失败: %v", err)
-		fmt.Printf("错误: %v\n", err)
+		log.Error("%s", i18n.T("main.op_failed", err))
+		fmt.Println(i18n.T("common.error", err))
 		if interactiveMode {
-			waitForKeyPress("备份操作失败！")
+			waitForKeyPress(i18n.T("main.op_failed_wait"))
 		}
 		return fmt.Errorf("操作失败: %w", err)
 	}
 
-	log.Info("操作完成")
+	log.Info("%s", i18n.T("main.done"))
 
 	// 双击运行时显示完成信息并等待
 	if interactiveMode {
-		waitForKeyPress("备份操作完成！")
+		waitForKeyPress(i18n.T("main.done_wait"))
 	}
 
 	return nil
 }
 
+// runBackupOnce 对设备执行一次完整备份，供手动、定时和热插拔触发共用
+// ctx 取消时打印已备份的文件数，返回 context.Canceled
+func runBackupOnce(ctx context.Context, cfg *config.Config, log *logger.Logger, dev *device.DeviceInfo, force bool) error {
+	manager := backup.NewManager(cfg, log, quiet, verbose, cleanEmpty)
+	defer manager.Close()
+	return runManager(ctx, manager, log, dev, force)
+}
+
+// runManager 用给定的备份管理器执行一次备份，ctx 取消或设备断开时打印已备份的文件数
+func runManager(ctx context.Context, manager *backup.BackupManager, log *logger.Logger, dev *device.DeviceInfo, force bool) error {
+	summary, err := manager.Run(ctx, dev, force)
+	if errors.Is(err, context.Canceled) {
+		succeeded := 0
+		if summary != nil {
+			succeeded = summary.Succeeded
+		}
+		log.Warn("%s", i18n.T("main.interrupted", succeeded))
+		fmt.Println(i18n.T("main.interrupted", succeeded))
+	} else if errors.Is(err, device.ErrDeviceNotConnected) {
+		succeeded := 0
+		if summary != nil {
+			succeeded = summary.Succeeded
+		}
+		log.Warn("%s", i18n.T("main.disconnected", succeeded))
+		fmt.Println(i18n.T("main.disconnected", succeeded))
+	}
+	return err
+}
+
+// setupTempDir 设置统一的临时文件目录，并清理异常退出残留的旧临时文件
+func setupTempDir(cfg *config.Config, log *logger.Logger) {
+	if err := utils.SetTempDir(cfg.Backup.TempDir); err != nil {
+		log.Warn("设置临时目录失败，使用系统临时目录: %v", err)
+	}
+
+	// 旧版本的临时文件写在系统临时目录，一并清理
+	dirs := []string{utils.TempDir()}
+	if utils.TempDir() != os.TempDir() {
+		dirs = append(dirs, os.TempDir())
+	}
+	for _, dir := range dirs {
+		if removed, err := utils.CleanupTempFiles(dir, utils.StaleTempFileAge); err != nil {
+			log.Debug("清理临时文件失败: %v", err)
+		} else if removed > 0 {
+			log.Info("已清理 %d 个残留临时文件: %s", removed, dir)
+		}
+	}
+}
+
+// startMetrics 配置了 metrics.listen_addr 时启动 Prometheus 指标端点，返回关闭端点的函数
+func startMetrics(cfg *config.Config, log *logger.Logger) func() {
+	if cfg.Metrics.ListenAddr == "" {
+		return func() {}
+	}
+	server, err := metrics.Serve(cfg.Metrics.ListenAddr, metrics.Default)
+	if err != nil {
+		log.Warn("启动指标端点失败: %v", err)
+		return func() {}
+	}
+	log.Info("Prometheus 指标端点: http://%s%s", cfg.Metrics.ListenAddr, metrics.Path)
+	return func() { server.Close() }
+}
+
 // runDetectMode 执行设备检测逻辑
 func runDetectMode() {
 	// 检测是否为双击运行
 	isInteractive := isDoubleClickRun()
 	if isInteractive {
 		fmt.Println("============================================================")
-		fmt.Println("         录音笔设备检测")
+		fmt.Println(i18n.T("detect.banner"))
 		fmt.Println("============================================================")
 		fmt.Println()
 	}
 
 	// 初始化日志
-	log := logger.InitLogger(verbose)
+	log := newLogger(nil, globalLogFlags, verbose, quiet)
 	defer log.Close()
-	log.Info("开始检测录音笔设备...")
+	log.Info("%s", i18n.T("detect.start"))
+
+	// 配置文件不可用时按默认的源设备检测，命令行指定的设备同样生效
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	globalSourceFlags.apply(cfg)
 
 	// 检测所有录音笔相关设备
-	devices := detectAllRecordingDevices(log)
+	devices := detectAllRecordingDevices(cfg, log)
 
 	if len(devices) == 0 {
-		fmt.Println("未找到任何录音笔设备")
-		fmt.Println("请确保：")
-		fmt.Println("1. 录音笔已连接到电脑")
-		fmt.Println("2. 设备驱动程序已正确安装")
-		fmt.Println("3. 设备处于可访问状态")
+		fmt.Println(i18n.T("detect.none"))
+		fmt.Println(i18n.T("detect.none_hint"))
 		if isInteractive {
-			waitForKeyPress("未找到设备！")
+			waitForKeyPress(i18n.T("detect.none_wait"))
 		}
 		os.Exit(1)
 	}
 
-	fmt.Println("\n检测到的录音笔设备：")
+	fmt.Println("\n" + i18n.T("detect.list_title"))
 	fmt.Println("=" + strings.Repeat("=", 60))
 
 	// 显示所有检测到的设备
 	for i, dev := range devices {
-		fmt.Printf("\n设备 #%d\n", i+1)
-		fmt.Printf("   名称: %s\n", dev.Name)
+		fmt.Println("\n" + i18n.T("detect.device_index", i+1))
+		fmt.Println(i18n.T("detect.device_name", dev.Name))
 		fmt.Printf("   VID:  %s\n", dev.VID)
 		fmt.Printf("   PID:  %s\n", dev.PID)
 		fmt.Printf("   ID:   %s\n", dev.DeviceID)
 
+		// 详细模式下连接设备读取型号、固件版本等信息
+		if verbose {
+			printDeviceDetails(dev, log)
+		}
+
 		// 生成配置片段
-		fmt.Printf("\n   配置片段：\n")
+		fmt.Println("\n" + i18n.T("detect.config_snippet"))
 		fmt.Printf("   source:\n")
 		fmt.Printf("     device_name: \"%s\"\n", dev.Name)
 		fmt.Printf("     vid: \"%s\"\n", dev.VID)
 		fmt.Printf("     pid: \"%s\"\n", dev.PID)
+
+		if profile, ok := device.MatchModel(dev.Name, dev.VID, dev.PID); ok {
+			fmt.Println("\n" + i18n.T("detect.known_model", profile.Model))
+			fmt.Printf("   record_center init --model %s\n", profile.Model)
+		}
 		fmt.Println()
 	}
 
@@ -202,8 +507,8 @@ func runDetectMode() {
 		if strings.Contains(strings.ToUpper(dev.Name), "SR302") ||
 			(dev.VID == "2207" && dev.PID == "0011") {
 			sr302Found = true
-			fmt.Println("检测到SR302设备！")
-			fmt.Println("   您可以使用以下配置：")
+			fmt.Println(i18n.T("detect.sr302_found"))
+			fmt.Println(i18n.T("detect.sr302_use"))
 			fmt.Printf("   device_name: \"%s\"\n", dev.Name)
 			fmt.Printf("   vid: \"%s\"\n", dev.VID)
 			fmt.Printf("   pid: \"%s\"\n", dev.PID)
@@ -212,30 +517,45 @@ func runDetectMode() {
 	}
 
 	if !sr302Found {
-		fmt.Println("未检测到SR302设备，但找到了其他录音设备")
-		fmt.Println("   您可以尝试使用上述设备配置")
+		fmt.Println(i18n.T("detect.sr302_not_found"))
 	}
 
 	fmt.Println("\n" + strings.Repeat("=", 64))
-	fmt.Println("提示：")
-	fmt.Println("   - 复制上述配置片段到 configs/backup.yaml 文件中")
-	fmt.Println("   - 然后运行 record_center --check 测试配置")
-	fmt.Println("   - 使用 record_center --verbose 查看详细日志")
+	fmt.Println(i18n.T("detect.tips"))
 
 	if isInteractive {
-		waitForKeyPress("设备检测完成！")
+		waitForKeyPress(i18n.T("detect.done_wait"))
 	}
 }
 
+// printDeviceDetails 连接设备并以表格输出详细信息
+func printDeviceDetails(dev *device.DeviceInfo, log *logger.Logger) {
+	fmt.Println("\n" + i18n.T("detect.details_title"))
+
+	mtpInterface, release, err := device.SharedPool(log).AcquireDevice(dev)
+	if err != nil {
+		fmt.Println(i18n.T("detect.details_failed", err))
+		return
+	}
+	defer release()
+
+	details, err := mtpInterface.GetDeviceDetails()
+	if err != nil {
+		fmt.Println(i18n.T("detect.details_failed", err))
+		return
+	}
+	device.WriteDeviceDetails(os.Stdout, details)
+}
+
 // detectAllRecordingDevices 检测所有录音笔相关设备
-func detectAllRecordingDevices(log *logger.Logger) []*device.DeviceInfo {
+func detectAllRecordingDevices(cfg *config.Config, log *logger.Logger) []*device.DeviceInfo {
 	var allDevices []*device.DeviceInfo
 
-	// 方法1: 使用现有的SR302检测功能
-	log.Debug("尝试检测SR302设备...")
-	if sr302Device, err := device.DetectSR302(); err == nil {
-		allDevices = append(allDevices, sr302Device)
-		log.Debug("找到SR302设备: %s", sr302Device.Name)
+	// 方法1: 检测配置（或命令行）指定的源设备
+	log.Debug("尝试检测设备 %s...", cfg.Source.DeviceName)
+	if sourceDevice, err := detectSourceDevice(cfg); err == nil {
+		allDevices = append(allDevices, sourceDevice)
+		log.Debug("找到设备: %s", sourceDevice.Name)
 	}
 
 	// 方法2: 扫描其他可能的录音设备
@@ -247,77 +567,13 @@ func detectAllRecordingDevices(log *logger.Logger) []*device.DeviceInfo {
 	return removeDuplicateDevices(allDevices)
 }
 
-// scanForRecordingDevices 扫描其他录音设备
+// scanForRecordingDevices 扫描其他录音设备，按USB描述符识别便携媒体设备
 func scanForRecordingDevices(log *logger.Logger) []*device.DeviceInfo {
-	var devices []*device.DeviceInfo
-
-	// 获取所有USB设备
-	usbDevices, err := device.ScanAllUSBDevices()
+	devices, err := device.ScanRecordingDevices(log)
 	if err != nil {
 		log.Warn("扫描USB设备失败: %v", err)
-		return devices
-	}
-
-	// 筛选可能是录音笔的设备
-	for _, usbDevice := range usbDevices {
-		// 检查设备名称是否包含录音相关的关键词
-		deviceName := strings.ToUpper(usbDevice.Name)
-
-		// 录音设备关键词
-		recordingKeywords := []string{
-			"录音", "RECORD", "VOICE", "RECORDER", "音频", "AUDIO",
-			"便携式", "PORTABLE", "存储", "STORAGE",
-			"SR", "IC", "OLYMPUS", "SONY", "ZOOM",
-			"MTP", "USB", "DEVICE",
-		}
-
-		isRecordingDevice := false
-		for _, keyword := range recordingKeywords {
-			if strings.Contains(deviceName, keyword) {
-				isRecordingDevice = true
-				break
-			}
-		}
-
-		// 也检查VID是否来自已知的音频设备制造商
-		audioVendors := []string{
-			"2207", // SR302的制造商
-			"046D", "0483", "0582", "0951", "10D6", "12BA", "13D3",
-			"18D1", "1B71", "2006", "2109", "2207", "2341", "2478",
-			"2717", "2886", "2A03", "2C99", "3282", "33F3", "3742",
-			"3823", "4035", "4097", "4348", "49E3", "4A66", "5051",
-			"5740", "59E3", "612B", "6868", "7881", "7904", "79A5",
-			"8087", "8564", "9DA2", "A0A2", "A5C2", "AC8F", "B58E",
-			"C251", "CA01", "CAFE", "DECA", "E401", "E856", "FCEA",
-		}
-
-		isAudioVendor := false
-		for _, vendor := range audioVendors {
-			if strings.EqualFold(usbDevice.VID, vendor) {
-				isAudioVendor = true
-				break
-			}
-		}
-
-		// 如果是录音设备或音频制造商设备，加入列表
-		if isRecordingDevice || isAudioVendor {
-			// 检查是否已存在（避免重复）
-			exists := false
-			for _, existing := range devices {
-				if existing.VID == usbDevice.VID && existing.PID == usbDevice.PID {
-					exists = true
-					break
-				}
-			}
-
-			if !exists {
-				log.Debug("发现潜在的录音设备: %s (VID:%s, PID:%s)",
-					usbDevice.Name, usbDevice.VID, usbDevice.PID)
-				devices = append(devices, usbDevice)
-			}
-		}
+		return nil
 	}
-
 	return devices
 }
 
@@ -342,7 +598,7 @@ func waitForKeyPress(prompt string) {
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println(prompt)
-	fmt.Println("按任意键关闭窗口...")
+	fmt.Println(i18n.T("common.press_any_key"))
 
 	bufio.NewReader(os.Stdin).ReadBytes('\n')
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/config"
+)
+
+// configTemplate 带注释的配置模板，迁移时为补齐的配置项加上其中的说明
+//
+//go:embed backup.yaml
+var configTemplate []byte
+
+// runMigrateConfigMode 执行 migrate-config 子命令，为旧版配置文件补齐新增的配置项并带注释写回
+// 用法: migrate-config [--config backup.yaml]
+func runMigrateConfigMode(args []string) error {
+	fs := flag.NewFlagSet("migrate-config", flag.ExitOnError)
+	var migrateConfigFile string
+	fs.StringVar(&migrateConfigFile, "config", "configs/backup.yaml", "要升级的配置文件路径")
+	fs.StringVar(&migrateConfigFile, "c", "configs/backup.yaml", "要升级的配置文件路径（短格式）")
+	fs.Parse(args)
+
+	result, err := config.MigrateConfig(migrateConfigFile, configTemplate)
+	if err != nil {
+		return err
+	}
+	if len(result.Added) == 0 {
+		fmt.Printf("配置文件已包含全部配置项，无需迁移: %s\n", migrateConfigFile)
+		return nil
+	}
+
+	fmt.Printf("已为 %s 补齐 %d 个配置项（取默认值，已有的设置保持不变）:\n", migrateConfigFile, len(result.Added))
+	for _, key := range result.Added {
+		fmt.Printf("  + %s\n", key)
+	}
+	fmt.Printf("原配置文件已备份到: %s\n", result.BackupPath)
+	return nil
+}
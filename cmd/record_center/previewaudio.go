@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runPreviewAudioMode 执行 preview-audio 子命令，从设备流式读取录音开头几秒保存为可播放的片段
+// 用法: preview-audio <设备文件> [--seconds 5] [--out sample.opus]
+func runPreviewAudioMode(args []string) error {
+	// 设备文件可以写在标志之前
+	var devicePath string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		devicePath, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("preview-audio", flag.ExitOnError)
+	var previewConfigFile, deviceName, outPath string
+	var seconds int
+	fs.StringVar(&previewConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&previewConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认使用配置文件中的设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.IntVar(&seconds, "seconds", 5, "截取开头的秒数，在Ogg页边界截断")
+	fs.StringVar(&outPath, "out", "", "片段保存路径，默认为当前目录下的 <文件名>_preview.opus")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	if devicePath == "" && fs.NArg() == 1 {
+		devicePath = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		return fmt.Errorf("多余的参数: %v", fs.Args())
+	}
+	if devicePath == "" {
+		return fmt.Errorf("请指定设备文件: preview-audio <设备文件> [--seconds 5] [--out sample.opus]")
+	}
+	if seconds <= 0 {
+		return fmt.Errorf("截取秒数必须大于0: %d", seconds)
+	}
+
+	cfg, err := config.LoadConfig(previewConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+
+	if deviceName != "" {
+		cfg.Source.DeviceName = deviceName
+	}
+
+	dev, err := detectSourceDevice(cfg)
+	if err != nil {
+		return fmt.Errorf("设备检测失败: %w", err)
+	}
+	mtpInterface, release, err := device.SharedPool(log).AcquireDevice(dev)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	mtpFiles, err := device.ListFilesInStorages(mtpInterface, cfg.Source.BasePath, cfg.Source.Storage, log)
+	if err != nil {
+		return fmt.Errorf("枚举设备文件失败: %w", err)
+	}
+	file := findDeviceFile(mtpFiles, devicePath)
+	if file == nil {
+		return fmt.Errorf("设备上没有找到文件: %s", devicePath)
+	}
+
+	if outPath == "" {
+		outPath = strings.TrimSuffix(file.Name, filepath.Ext(file.Name)) + "_preview.opus"
+	}
+
+	stream, err := mtpInterface.GetFileStream(file.Path)
+	if err != nil {
+		return fmt.Errorf("打开设备文件失败: %w", err)
+	}
+	defer stream.Close()
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("创建片段文件失败: %w", err)
+	}
+	clipped, written, err := utils.ClipOpus(out, stream, time.Duration(seconds)*time.Second)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("保存片段文件失败: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(outPath)
+		return fmt.Errorf("截取 %s 失败: %w", file.Name, err)
+	}
+
+	fmt.Printf("已保存片段: %s（%s，%s，原文件 %s）\n",
+		outPath, utils.FormatDurationPrecise(clipped), utils.FormatBytes(written), utils.FormatBytes(file.Size))
+	return nil
+}
+
+// findDeviceFile 按完整路径、相对路径或文件名（不区分大小写）查找设备文件
+func findDeviceFile(files []*device.FileInfo, name string) *device.FileInfo {
+	name = strings.ReplaceAll(name, "/", "\\")
+	for _, file := range files {
+		if strings.EqualFold(file.Path, name) || strings.EqualFold(file.RelativePath, name) {
+			return file
+		}
+	}
+	for _, file := range files {
+		if strings.EqualFold(file.Name, name) {
+			return file
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/i18n"
+	"github.com/allanpk716/record_center/internal/psexec"
+)
+
+// runResumeMode 执行 resume 子命令，只续跑上次未完成（有断点）或失败的文件，不重新枚举整个设备
+// 用法: resume [--device SR302]
+func runResumeMode(args []string) error {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	var resumeConfigFile, deviceName string
+	fs.StringVar(&resumeConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&resumeConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认使用配置文件中的设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	fs.BoolVar(&quiet, "quiet", false, "静默模式，不显示实时进度")
+	fs.BoolVar(&quiet, "q", false, "静默模式（短格式）")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(resumeConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	globalSourceFlags.apply(cfg)
+	if deviceName != "" {
+		cfg.Source.DeviceName = deviceName
+	}
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	defer log.RecoverPanic()
+	i18n.SetLanguage(i18n.Resolve(cfg.Language))
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+	defer startMetrics(cfg, log)()
+
+	dev, err := detectSourceDevice(cfg)
+	if err != nil {
+		return fmt.Errorf("设备检测失败: %w", err)
+	}
+
+	manager := backup.NewManager(cfg, log, quiet, verbose, cleanEmpty)
+	defer manager.Close()
+
+	resumeManager := backup.NewResumeManager(backup.ResumePath, cfg.Backup.TempDir, log)
+	fileList, err := manager.ResumeFileList(dev, resumeManager)
+	if err != nil {
+		return err
+	}
+	if len(fileList) == 0 {
+		fmt.Printf("设备 %s 没有上次未完成或失败的文件\n", dev.DisplayName(cfg))
+		return nil
+	}
+	fmt.Printf("续跑设备 %s 上次未完成或失败的 %d 个文件\n", dev.DisplayName(cfg), len(fileList))
+
+	// Ctrl+C 时停止开始新文件的复制，保存已完成的记录后退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	manager.SetFileList(fileList)
+	return runManager(ctx, manager, log, dev, false)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/i18n"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
+)
+
+// runRunMode 执行 run 子命令，按配置文件中的 tasks 执行指定任务或所有启用的任务
+func runRunMode(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	var taskName, runConfigFile string
+	fs.StringVar(&taskName, "task", "", "只执行指定名称的任务，不指定时执行所有启用的任务")
+	fs.StringVar(&runConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&runConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	fs.BoolVar(&quiet, "quiet", false, "静默模式，不显示实时进度")
+	fs.BoolVar(&quiet, "q", false, "静默模式（短格式）")
+	fs.BoolVar(&force, "force", false, "强制重新备份，忽略已备份记录")
+	fs.BoolVar(&force, "f", false, "强制重新备份（短格式）")
+	fs.BoolVar(&cleanEmpty, "clean-empty", true, "自动清理空文件夹")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(runConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	defer log.RecoverPanic()
+	i18n.SetLanguage(i18n.Resolve(cfg.Language))
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+	defer startMetrics(cfg, log)()
+
+	tasks, err := cfg.SelectTasks(taskName)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C 时进行中的任务保存已完成的记录后退出，尚未开始的任务不再执行
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	errs := make([]error, len(tasks))
+	if cfg.RunTasksParallel && len(tasks) > 1 {
+		// 不同设备并行、单设备内文件串行；多个进度条会互相覆盖，只输出日志和整体进度
+		log.Info("并行执行 %d 个任务", len(tasks))
+		var jobs []backup.DeviceJob
+		var jobTasks []int
+		for i := range tasks {
+			dev, manager, err := prepareTask(cfg, tasks[i], log, true)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			defer manager.Close()
+			jobs = append(jobs, backup.DeviceJob{Name: tasks[i].Name, Device: dev, Manager: manager})
+			jobTasks = append(jobTasks, i)
+		}
+		results := backup.NewDeviceScheduler(cfg, log).Run(ctx, jobs, force)
+		for j, result := range results {
+			errs[jobTasks[j]] = result.Err
+		}
+	} else {
+		for i := range tasks {
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				continue
+			}
+			errs[i] = runTask(ctx, cfg, tasks[i], log, quiet)
+		}
+	}
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			log.Error("任务 %s 失败: %v", tasks[i].Name, err)
+			fmt.Printf("任务 %s 失败: %v\n", tasks[i].Name, err)
+			continue
+		}
+		fmt.Printf("任务 %s 完成\n", tasks[i].Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个任务失败", failed, len(tasks))
+	}
+	return nil
+}
+
+// runTask 检测任务的设备并使用任务自己的 source/target/backup 配置执行一次备份
+func runTask(ctx context.Context, cfg *config.Config, task config.TaskConfig, log *logger.Logger, quiet bool) error {
+	dev, manager, err := prepareTask(cfg, task, log, quiet)
+	if err != nil {
+		return err
+	}
+	defer manager.Close()
+	return runManager(ctx, manager, log, dev, force)
+}
+
+// prepareTask 按任务的 source 配置检测设备，并创建使用任务配置的备份管理器
+func prepareTask(cfg *config.Config, task config.TaskConfig, log *logger.Logger, quiet bool) (*device.DeviceInfo, *backup.BackupManager, error) {
+	taskConfig := cfg.ForTask(task)
+	// 命令行指定的源设备覆盖任务自己的 source 配置
+	globalSourceFlags.apply(taskConfig)
+
+	dev, err := detectSourceDevice(taskConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("设备未连接: %s: %w", taskConfig.Source.DeviceName, err)
+	}
+
+	log.Info("任务 %s: 开始备份设备 %s 到 %s", task.Name, dev.DisplayName(taskConfig), taskConfig.Target.BaseDirectory)
+	manager := backup.NewTaskManager(taskConfig, task.Name, log, quiet, verbose, cleanEmpty)
+	return dev, manager, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/metrics"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/internal/schedule"
+)
+
+// runScheduleMode 执行 schedule 子命令，按cron表达式定时备份，同时响应设备插入
+func runScheduleMode(args []string) error {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	var cronExpr, scheduleConfigFile string
+	var pollInterval time.Duration
+	fs.StringVar(&cronExpr, "cron", "", "cron表达式（分 时 日 月 周），如 \"0 */2 * * *\"")
+	fs.StringVar(&scheduleConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&scheduleConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.DurationVar(&pollInterval, "poll", 10*time.Second, "设备插入检测间隔，0表示不检测")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	fs.BoolVar(&quiet, "quiet", true, "静默模式，不显示实时进度")
+	fs.BoolVar(&quiet, "q", true, "静默模式（短格式）")
+	fs.BoolVar(&cleanEmpty, "clean-empty", true, "自动清理空文件夹")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	if cronExpr == "" {
+		return fmt.Errorf("请使用 --cron 指定调度表达式")
+	}
+	cronSchedule, err := schedule.ParseCron(cronExpr)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(scheduleConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	globalSourceFlags.apply(cfg)
+
+	// schedule 默认开启 --quiet 只为隐藏进度，不参与日志级别的合并
+	log := newLogger(cfg, globalLogFlags, verbose, false)
+	defer log.Close()
+	defer log.RecoverPanic()
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+	defer startMetrics(cfg, log)()
+
+	// 定时触发与设备插入可能同时发生，同一时间只执行一次备份
+	var backupMutex sync.Mutex
+	var lastDevice string // 最近检测到的设备名称，设备拔出后在指标中标记为离线
+	backupIfOnline := func(ctx context.Context, trigger string) error {
+		backupMutex.Lock()
+		defer backupMutex.Unlock()
+
+		dev, err := detectSourceDevice(cfg)
+		if err != nil {
+			log.Warn("设备未连接，跳过%s: %v", trigger, err)
+			if lastDevice != "" {
+				metrics.Default.SetOnline(lastDevice, false)
+			}
+			return nil
+		}
+		lastDevice = dev.DisplayName(cfg)
+
+		log.Info("%s: 开始备份设备 %s", trigger, dev.DisplayName(cfg))
+		return runBackupOnce(ctx, cfg, log, dev, false)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if pollInterval > 0 {
+		go watchDeviceArrival(ctx, pollInterval, cfg, log, func() {
+			if err := backupIfOnline(ctx, "设备插入备份"); err != nil {
+				log.Error("设备插入备份失败: %v", err)
+			}
+		})
+	}
+
+	log.Info("定时备份已启动，cron: %s（按 Ctrl+C 退出）", cronSchedule)
+	scheduler := schedule.NewScheduler(cronSchedule, func(ctx context.Context) error {
+		return backupIfOnline(ctx, "定时备份")
+	}, log)
+
+	if err := scheduler.Run(ctx); err != nil {
+		return err
+	}
+
+	log.Info("定时备份已退出")
+	return nil
+}
+
+// watchDeviceArrival 轮询设备连接状态，设备从断开变为连接时调用 onArrival
+func watchDeviceArrival(ctx context.Context, interval time.Duration, cfg *config.Config, log *logger.Logger, onArrival func()) {
+	_, err := detectSourceDevice(cfg)
+	online := err == nil
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := detectSourceDevice(cfg)
+			connected := err == nil
+			if connected && !online {
+				log.Info("检测到设备插入")
+				onArrival()
+			} else if !connected && online {
+				log.Info("检测到设备拔出")
+			}
+			online = connected
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/psexec"
+)
+
+// runSelfTestMode 执行 selftest 子命令，用设备上最小的一个录音检查整条备份链路
+func runSelfTestMode(args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	var deviceName, selfTestConfigFile string
+	fs.StringVar(&selfTestConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&selfTestConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认使用配置文件中的设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(selfTestConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	globalSourceFlags.apply(cfg)
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	psexec.Init(&cfg.PowerShell, log)
+	setupTempDir(cfg, log)
+
+	if deviceName != "" {
+		cfg.Source.DeviceName = deviceName
+	}
+
+	fmt.Printf("自检设备: %s\n", cfg.Source.DeviceName)
+	dev, err := detectSourceDevice(cfg)
+	if err != nil {
+		fmt.Println("结论: 在检测设备阶段失败")
+		return fmt.Errorf("设备检测失败: %w", err)
+	}
+	mtpInterface, release, err := device.SharedPool(log).AcquireDevice(dev)
+	if err != nil {
+		fmt.Println("结论: 在连接设备阶段失败")
+		return err
+	}
+	defer release()
+
+	manager := backup.NewManager(cfg, log, true, verbose, false)
+	defer manager.Close()
+	manager.SetMTPInterface(mtpInterface)
+
+	result, err := manager.SelfTest(mtpInterface.GetDeviceInfo())
+	if err != nil {
+		return err
+	}
+
+	for _, stage := range result.Stages {
+		switch {
+		case stage.Skipped:
+			fmt.Printf("  [跳过] %s\n", stage.Name)
+		case stage.Err != nil:
+			fmt.Printf("  [失败] %s (%s): %v\n", stage.Name, stage.Duration.Round(time.Millisecond), stage.Err)
+		default:
+			fmt.Printf("  [通过] %s (%s): %s\n", stage.Name, stage.Duration.Round(time.Millisecond), stage.Detail)
+		}
+	}
+	fmt.Printf("结论: %s\n", result.Conclusion())
+
+	if result.FailedStage() != nil {
+		return fmt.Errorf("自检未通过")
+	}
+	return nil
+}
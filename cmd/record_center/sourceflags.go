@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+)
+
+// sourceFlags 临时覆盖配置文件中源设备的标志，便于试用配置里没有的新设备
+type sourceFlags struct {
+	vid        string
+	pid        string
+	deviceName string
+}
+
+// globalSourceFlags 主命令和各子命令共用的源设备标志
+var globalSourceFlags sourceFlags
+
+// detectDevice 按源设备配置检测设备，测试中替换为不依赖真实设备的实现
+var detectDevice = device.DetectDevice
+
+// detectSourceDevice 按配置（已应用命令行覆盖）中的源设备检测设备
+func detectSourceDevice(cfg *config.Config) (*device.DeviceInfo, error) {
+	return detectDevice(cfg.Source.DeviceName, cfg.Source.VID, cfg.Source.PID)
+}
+
+// register 在 FlagSet 上注册源设备标志
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.vid, "vid", "", "设备USB厂商ID（覆盖配置文件中的 source.vid）")
+	fs.StringVar(&f.pid, "pid", "", "设备USB产品ID（覆盖配置文件中的 source.pid）")
+	fs.StringVar(&f.deviceName, "device-name", "", "设备名称（覆盖配置文件中的 source.device_name）")
+}
+
+// apply 用非空的标志覆盖配置，返回是否有覆盖
+func (f *sourceFlags) apply(cfg *config.Config) bool {
+	if f.vid != "" {
+		cfg.Source.VID = f.vid
+	}
+	if f.pid != "" {
+		cfg.Source.PID = f.pid
+	}
+	if f.deviceName != "" {
+		cfg.Source.DeviceName = f.deviceName
+	}
+	return *f != (sourceFlags{})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+)
+
+// TestSourceFlags 测试提供 --vid/--pid/--device-name 时检测使用命令行值而非配置值
+func TestSourceFlags(t *testing.T) {
+	devices := []*device.USBDevice{
+		{DeviceID: "USB\\VID_2207&PID_0011\\SR302", Name: "SR302", VID: "2207", PID: "0011", DeviceType: "MTP"},
+		{DeviceID: "USB\\VID_1A2B&PID_3C4D\\X100", Name: "X100 Recorder", VID: "1A2B", PID: "3C4D", DeviceType: "MTP"},
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		wantID   string
+		wantVID  string
+		override bool
+	}{
+		{"未提供标志时使用配置", nil, "USB\\VID_2207&PID_0011\\SR302", "2207", false},
+		{"命令行指定新设备", []string{"--vid", "1a2b", "--pid", "3c4d", "--device-name", "X100"}, "USB\\VID_1A2B&PID_3C4D\\X100", "1a2b", true},
+		{"只覆盖VID时其余沿用配置", []string{"--vid", "1A2B"}, "", "1A2B", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var flags sourceFlags
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.register(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("解析标志失败: %v", err)
+			}
+
+			cfg := config.DefaultConfig()
+			if got := flags.apply(cfg); got != tc.override {
+				t.Errorf("覆盖 = %v，期望 %v", got, tc.override)
+			}
+			if cfg.Source.VID != tc.wantVID {
+				t.Errorf("source.vid = %s，期望 %s", cfg.Source.VID, tc.wantVID)
+			}
+
+			dev, err := device.FindDevice(devices, cfg.Source.DeviceName, cfg.Source.VID, cfg.Source.PID)
+			if tc.wantID == "" {
+				if err == nil {
+					t.Errorf("VID与设备名称不一致时不应找到设备: %+v", dev)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("检测设备失败: %v", err)
+			}
+			if dev.DeviceID != tc.wantID {
+				t.Errorf("检测到 %s，期望 %s", dev.DeviceID, tc.wantID)
+			}
+		})
+	}
+}
+
+// TestSourceFlags_Subcommands 测试子命令注册并应用 --vid/--pid/--device-name，检测设备时使用命令行值
+func TestSourceFlags_Subcommands(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Logging.File = filepath.Join(dir, "record_center.log")
+	cfg.Logging.Console = false
+	cfg.Backup.TempDir = filepath.Join(dir, "temp")
+	cfg.Target.BaseDirectory = filepath.Join(dir, "backups")
+	cfg.Tasks = []config.TaskConfig{{Name: "main", Enabled: true, Source: cfg.Source, Target: cfg.Target, Backup: cfg.Backup}}
+	configPath := filepath.Join(dir, "backup.yaml")
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+
+	errNoDevice := errors.New("测试中没有设备")
+	var detected [3]string
+	original := detectDevice
+	detectDevice = func(name, vid, pid string) (*device.DeviceInfo, error) {
+		detected = [3]string{name, vid, pid}
+		return nil, errNoDevice
+	}
+	t.Cleanup(func() {
+		detectDevice = original
+		globalSourceFlags = sourceFlags{}
+	})
+
+	args := []string{"--config", configPath, "--vid", "1A2B", "--pid", "3C4D", "--device-name", "X100"}
+	subcommands := []struct {
+		name string
+		run  func(args []string) error
+	}{
+		{"resume", runResumeMode},
+		{"run", runRunMode},
+	}
+
+	for _, sc := range subcommands {
+		t.Run(sc.name, func(t *testing.T) {
+			detected = [3]string{}
+			globalSourceFlags = sourceFlags{}
+			if err := sc.run(args); err == nil {
+				t.Fatal("没有设备时子命令应返回错误")
+			}
+			if want := [3]string{"X100", "1A2B", "3C4D"}; detected != want {
+				t.Errorf("检测设备使用 %v，期望 %v", detected, want)
+			}
+		})
+	}
+}
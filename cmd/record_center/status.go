@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runStatusMode 执行 status 子命令，显示上次备份概况与设备在线状态
+func runStatusMode(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	var deviceName, statusConfigFile string
+	fs.StringVar(&statusConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&statusConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认汇总所有设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(statusConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newReportLogger(cfg)
+	defer log.Close()
+
+	tracker, err := loadTracker(cfg, log)
+	if err != nil {
+		return err
+	}
+
+	overview := tracker.Overview(deviceName)
+	printOverview(overview)
+
+	onlineName := deviceName
+	if onlineName == "" {
+		onlineName = cfg.Source.DeviceName
+	}
+	online, err := isDeviceOnline(onlineName)
+	if err != nil {
+		fmt.Printf("设备状态: 检测失败 (%v)\n", err)
+	} else if online {
+		fmt.Printf("设备状态: %s 在线\n", onlineName)
+	} else {
+		fmt.Printf("设备状态: %s 离线\n", onlineName)
+	}
+	return nil
+}
+
+// loadTracker 加载备份记录
+func loadTracker(cfg *config.Config, log *logger.Logger) (*storage.BackupTracker, error) {
+	tracker := storage.NewBackupTracker(backup.RecordsPath, log)
+	tracker.SetEncryptionKey(cfg.Storage.ResolveEncryptionKey())
+	tracker.SetArchiveAfterDays(cfg.Storage.ArchiveAfterDays)
+	if err := tracker.Load(); err != nil {
+		return nil, fmt.Errorf("加载备份记录失败: %w", err)
+	}
+	return tracker, nil
+}
+
+// printOverview 输出备份概况
+func printOverview(overview storage.Overview) {
+	if overview.DeviceName != "" {
+		fmt.Printf("设备: %s\n", overview.DeviceName)
+	} else {
+		fmt.Println("设备: 全部")
+	}
+
+	if overview.LastBackup.IsZero() {
+		fmt.Println("上次备份: 从未备份")
+	} else {
+		fmt.Printf("上次备份: %s\n", overview.LastBackup.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("累计备份: %d 个文件, %s\n", overview.TotalFiles, utils.FormatBytes(overview.TotalSize))
+
+	run := overview.LastRun
+	if run == nil {
+		fmt.Println("最近运行: 无记录")
+		return
+	}
+	fmt.Printf("最近运行: %s (%s), 耗时 %s\n",
+		run.StartTime.Format("2006-01-02 15:04:05"), run.DeviceName, utils.FormatDuration(run.Duration))
+	fmt.Printf("  扫描 %d, 成功 %d, 失败 %d, 跳过 %d, 复制 %s\n",
+		run.Scanned, run.Succeeded, run.Failed, run.Skipped, utils.FormatBytes(run.Bytes))
+}
+
+// isDeviceOnline 判断指定名称的设备当前是否连接
+func isDeviceOnline(deviceName string) (bool, error) {
+	devices, err := device.ScanAllUSBDevices()
+	if err != nil {
+		return false, err
+	}
+	for _, dev := range devices {
+		if strings.Contains(strings.ToLower(dev.Name), strings.ToLower(deviceName)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
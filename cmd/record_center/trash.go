@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/backup"
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// runTrashMode 执行 trash 子命令，管理镜像清理等删除的备份所在的回收站
+// 用法: trash list | trash restore <条目ID或批次> | trash empty [--yes]
+func runTrashMode(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("请指定操作: list、restore 或 empty")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("trash "+action, flag.ExitOnError)
+	var trashConfigFile string
+	var yes bool
+	fs.StringVar(&trashConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&trashConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.BoolVar(&yes, "yes", false, "清空回收站时不再确认")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalLogFlags.register(fs)
+	fs.Parse(args[1:])
+
+	cfg, err := config.LoadConfig(trashConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+
+	log := newReportLogger(cfg)
+	defer log.Close()
+
+	trash := backup.NewTrashFromConfig(cfg, log)
+
+	switch action {
+	case "list":
+		items, err := trash.List()
+		if err != nil {
+			return err
+		}
+		printTrashItems(trash.Dir(), items)
+		return nil
+
+	case "restore":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("请指定要恢复的条目ID或批次，可用 trash list 查看")
+		}
+		restored, restoreErr := trash.Restore(fs.Arg(0))
+		if len(restored) > 0 {
+			// 重新登记恢复的备份，避免下次备份重复复制
+			tracker, err := loadTracker(cfg, log)
+			if err != nil {
+				return err
+			}
+			for _, item := range restored {
+				fmt.Printf("已恢复: %s\n", item.OriginalPath)
+				if record := item.Record; record != nil {
+					if err := tracker.AddRecordWithVerify(record.SourcePath, item.OriginalPath, record.DeviceID,
+						record.FileSize, record.FileHash, record.IntegrityCheck, record.HashAlgorithm); err != nil {
+						fmt.Printf("重新登记备份记录失败: %s, %v\n", record.SourcePath, err)
+					}
+				}
+			}
+			if err := tracker.Save(); err != nil {
+				return fmt.Errorf("保存备份记录失败: %w", err)
+			}
+		}
+		return restoreErr
+
+	case "empty":
+		items, err := trash.List()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Println("回收站为空")
+			return nil
+		}
+		if !yes && !confirm(fmt.Sprintf("将永久删除回收站中的 %d 个文件，确定吗？[y/N] ", len(items))) {
+			fmt.Println("已取消")
+			return nil
+		}
+		removed, err := trash.Empty()
+		fmt.Printf("已永久删除 %d 个文件\n", removed)
+		return err
+
+	default:
+		return fmt.Errorf("不支持的操作: %s（可选 list、restore、empty）", action)
+	}
+}
+
+// printTrashItems 输出回收站中的文件
+func printTrashItems(dir string, items []backup.TrashItem) {
+	fmt.Printf("回收站: %s\n", dir)
+	if len(items) == 0 {
+		fmt.Println("回收站为空")
+		return
+	}
+
+	var total int64
+	fmt.Printf("%-19s %10s  %s\n", "删除时间", "大小", "条目ID -> 原路径")
+	for _, item := range items {
+		total += item.Size
+		fmt.Printf("%-19s %10s  %s -> %s\n",
+			item.DeletedAt.Format("2006-01-02 15:04:05"),
+			utils.FormatBytes(item.Size),
+			item.ID,
+			item.OriginalPath)
+	}
+	fmt.Printf("共 %d 个文件, %s\n", len(items), utils.FormatBytes(total))
+}
+
+// confirm 显示提示并读取用户输入，输入 y 或 yes 时返回 true
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
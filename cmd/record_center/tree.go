@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// treeExcludeDirs 树形输出时不展开的系统目录
+var treeExcludeDirs = []string{"Android", "LOST.DIR", ".thumbnails", "System Volume Information"}
+
+// runTreeMode 执行 tree 子命令，打印设备的目录树
+func runTreeMode(args []string) error {
+	fs := flag.NewFlagSet("tree", flag.ExitOnError)
+	var deviceName, treeConfigFile string
+	var depth int
+	fs.StringVar(&treeConfigFile, "config", "configs/backup.yaml", "配置文件路径")
+	fs.StringVar(&treeConfigFile, "c", "configs/backup.yaml", "配置文件路径（短格式）")
+	fs.StringVar(&deviceName, "device", "", "设备名称（默认使用配置文件中的设备）")
+	fs.StringVar(&deviceName, "d", "", "设备名称（短格式）")
+	fs.IntVar(&depth, "depth", 0, "最大显示深度，0表示不限制")
+	fs.BoolVar(&verbose, "verbose", false, "详细模式，显示更多信息")
+	fs.BoolVar(&verbose, "v", false, "详细模式（短格式）")
+	globalSourceFlags.register(fs)
+	globalLogFlags.register(fs)
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(treeConfigFile)
+	if err != nil {
+		return fmt.Errorf("配置加载失败: %w", err)
+	}
+	globalSourceFlags.apply(cfg)
+
+	log := newLogger(cfg, globalLogFlags, verbose, quiet)
+	defer log.Close()
+	psexec.Init(&cfg.PowerShell, log)
+
+	if deviceName != "" {
+		cfg.Source.DeviceName = deviceName
+	}
+
+	// 使用访问器递归枚举设备文件
+	dev, err := detectSourceDevice(cfg)
+	if err != nil {
+		return fmt.Errorf("设备检测失败: %w", err)
+	}
+	mtpInterface, release, err := device.SharedPool(log).AcquireDevice(dev)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	mtpFiles, err := device.ListFilesInStorages(mtpInterface, cfg.Source.BasePath, cfg.Source.Storage, log)
+	if err != nil {
+		return fmt.Errorf("枚举设备文件失败: %w", err)
+	}
+
+	files := make([]*utils.FileInfo, 0, len(mtpFiles))
+	for _, mtpFile := range mtpFiles {
+		relativePath := mtpFile.RelativePath
+		if relativePath == "" {
+			relativePath = mtpFile.Path
+		}
+		files = append(files, &utils.FileInfo{
+			Path:         mtpFile.Path,
+			RelativePath: relativePath,
+			Name:         mtpFile.Name,
+			Size:         mtpFile.Size,
+		})
+	}
+
+	root := utils.BuildTree(cfg.Source.DeviceName, files, treeExcludeDirs)
+	utils.RenderTree(os.Stdout, root, depth)
+	fmt.Printf("\n共 %d 个文件, %s\n", len(files), utils.FormatBytes(root.Size))
+	return nil
+}
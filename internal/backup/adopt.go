@@ -0,0 +1,194 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/store"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// AdoptResult 收养目标目录中已有文件的结果
+type AdoptResult struct {
+	Unrecorded int // 目标目录中没有备份记录的文件数
+	Adopted    int // 与设备文件匹配并补建了记录的文件数
+	Mismatched int // 文件名和大小相同但哈希不一致的文件数
+}
+
+// Adopt 收养目标目录中已存在但没有备份记录的文件（如用户手动拷入的录音）
+// 文件名（不区分大小写）、大小和内容哈希都与设备上某个未备份的文件一致时，补建该设备文件的备份记录，
+// 之后的备份会跳过它，不再重复复制
+func (bm *BackupManager) Adopt(device *device.DeviceInfo) (*AdoptResult, error) {
+	if bm.config.Target.Type == store.TypeS3 {
+		return nil, fmt.Errorf("收养只支持本地目标目录")
+	}
+
+	fileChecker := bm.createFileChecker(device)
+	allFiles, err := bm.scanDeviceFiles(fileChecker, device)
+	if err != nil {
+		return nil, fmt.Errorf("扫描设备文件失败: %w", err)
+	}
+	pending, err := fileChecker.FilterFilesToBackup(bm.applyIgnoreRules(allFiles), device.DeviceID, false)
+	if err != nil {
+		return nil, fmt.Errorf("过滤备份文件失败: %w", err)
+	}
+
+	// 按文件名和大小索引设备上尚未备份的文件
+	candidates := make(map[string][]*utils.FileInfo)
+	for _, file := range pending {
+		key := adoptKey(file.Name, file.Size)
+		candidates[key] = append(candidates[key], file)
+	}
+
+	localFiles, err := bm.unrecordedTargetFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AdoptResult{Unrecorded: len(localFiles)}
+	if len(localFiles) == 0 || len(candidates) == 0 {
+		bm.log.Info("收养完成: 目标目录中 %d 个未登记文件，没有可匹配的设备文件", result.Unrecorded)
+		return result, nil
+	}
+
+	deviceHashes := make(map[string]string) // 已计算的设备文件哈希，键为设备路径
+	adopted := make(map[string]bool)        // 已被收养的设备文件，避免重复登记
+	for _, localPath := range localFiles {
+		info, err := os.Stat(localPath)
+		if err != nil {
+			bm.log.Warn("读取目标文件信息失败: %s, %v", localPath, err)
+			continue
+		}
+		matches := candidates[adoptKey(filepath.Base(localPath), info.Size())]
+		if len(matches) == 0 {
+			continue
+		}
+
+		localHash, err := bm.calculateHash(localPath)
+		if err != nil {
+			bm.log.Warn("计算目标文件哈希失败: %s, %v", localPath, err)
+			continue
+		}
+
+		matched := false
+		for _, file := range matches {
+			if adopted[file.Path] {
+				continue
+			}
+			deviceHash, ok := deviceHashes[file.Path]
+			if !ok {
+				if deviceHash, err = bm.hashDeviceFile(file); err != nil {
+					bm.log.Warn("计算设备文件哈希失败: %s, %v", file.RelativePath, err)
+					continue
+				}
+				deviceHashes[file.Path] = deviceHash
+			}
+			if deviceHash != localHash {
+				continue
+			}
+
+			if err := bm.tracker.AddRecordWithVerify(file.Path, localPath, device.DeviceID, file.Size, localHash,
+				bm.config.Backup.IntegrityCheck, bm.config.Backup.HashAlgorithm); err != nil {
+				return result, fmt.Errorf("添加备份记录失败: %w", err)
+			}
+			adopted[file.Path] = true
+			matched = true
+			result.Adopted++
+			bm.log.Info("收养已有文件: %s -> %s", file.RelativePath, localPath)
+			break
+		}
+		if !matched {
+			result.Mismatched++
+			bm.log.Warn("目标文件与设备文件同名同大小但内容不同，未收养: %s", localPath)
+		}
+	}
+
+	if result.Adopted > 0 {
+		if err := bm.tracker.Save(); err != nil {
+			return result, fmt.Errorf("保存备份记录失败: %w", err)
+		}
+	}
+
+	bm.log.Info("收养完成: 目标目录中 %d 个未登记文件，收养 %d 个，内容不一致 %d 个",
+		result.Unrecorded, result.Adopted, result.Mismatched)
+	return result, nil
+}
+
+// unrecordedTargetFiles 遍历目标目录，返回没有任何备份记录指向的文件，跳过回收目录和元数据文件
+func (bm *BackupManager) unrecordedTargetFiles() ([]string, error) {
+	recorded := make(map[string]bool)
+	for _, record := range bm.tracker.GetStorage().Records {
+		recorded[adoptPathKey(record.TargetPath)] = true
+	}
+
+	baseDir := bm.config.Target.BaseDirectory
+	var files []string
+	err := filepath.WalkDir(baseDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == baseDir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == TrashDirName && path != baseDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || IsSidecarFile(entry.Name()) {
+			return nil
+		}
+		if !recorded[adoptPathKey(path)] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历目标目录失败: %w", err)
+	}
+	return files, nil
+}
+
+// hashDeviceFile 读取设备文件并按写入备份记录时相同的算法计算哈希
+func (bm *BackupManager) hashDeviceFile(file *utils.FileInfo) (string, error) {
+	var stream io.ReadCloser
+	var err error
+	switch {
+	case bm.mtp != nil:
+		stream, err = bm.mtp.GetFileStream(file.Path)
+	case isDriveLetterPath(file.Path):
+		return bm.calculateHash(file.Path)
+	default:
+		stream, err = device.NewPowerShellMTPAccessor(bm.log).OpenFileStream(file.Path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("打开设备文件失败: %w", err)
+	}
+	defer stream.Close()
+
+	return NewIntegrityVerifier(bm.log, bm.deviceHashAlgorithm()).CalculateReaderHash(stream)
+}
+
+// deviceHashAlgorithm 比对设备文件内容使用的哈希算法，未开启完整性校验时使用 sha256
+func (bm *BackupManager) deviceHashAlgorithm() string {
+	if bm.config.Backup.IntegrityCheck {
+		return bm.config.Backup.HashAlgorithm
+	}
+	return "sha256"
+}
+
+// adoptKey 按文件名（不区分大小写）和大小生成匹配键
+func adoptKey(name string, size int64) string {
+	return fmt.Sprintf("%s|%d", strings.ToLower(name), size)
+}
+
+// adoptPathKey 规范化目标路径，Windows 路径不区分大小写
+func adoptPathKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// TestBackupManager_Adopt 测试目标目录中手动拷入的文件被收养后，备份时不再重复复制
+func TestBackupManager_Adopt(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+
+	modTime := time.Now().Add(-time.Hour)
+	contentA := bytes.Repeat([]byte("a"), 2048)
+	contentB := bytes.Repeat([]byte("b"), 1024)
+	contentC := bytes.Repeat([]byte("c"), 512)
+	pathA := "内部共享存储空间\\录音笔文件\\a.opus"
+	pathB := "内部共享存储空间\\录音笔文件\\b.opus"
+	pathC := "内部共享存储空间\\录音笔文件\\c.opus"
+	fake.AddFile(pathA, contentA, modTime)
+	fake.AddFile(pathB, contentB, modTime)
+	fake.AddFile(pathC, contentC, modTime)
+
+	// 手动拷入的文件：A.OPUS 与设备上的 a.opus 内容一致（文件名大小写不同），
+	// b.opus 同名同大小但内容不同，other.opus 在设备上没有对应文件
+	manualDir := filepath.Join(cfg.Target.BaseDirectory, "手动拷贝")
+	writeFiles := map[string][]byte{
+		"A.OPUS":     contentA,
+		"b.opus":     bytes.Repeat([]byte("x"), len(contentB)),
+		"other.opus": []byte("其他录音"),
+	}
+	if err := os.MkdirAll(manualDir, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	for name, content := range writeFiles {
+		if err := os.WriteFile(filepath.Join(manualDir, name), content, 0644); err != nil {
+			t.Fatalf("创建目标文件失败: %v", err)
+		}
+	}
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: tracker,
+		quiet:   true,
+	}
+	bm.SetMTPInterface(fake)
+
+	result, err := bm.Adopt(deviceInfo)
+	if err != nil {
+		t.Fatalf("收养失败: %v", err)
+	}
+	if result.Unrecorded != 3 || result.Adopted != 1 || result.Mismatched != 1 {
+		t.Errorf("收养结果 = %+v, 期望 未登记3 收养1 不一致1", *result)
+	}
+
+	backedUp, record, _ := tracker.IsFileBackedUp(pathA)
+	if !backedUp {
+		t.Fatal("a.opus 应补建备份记录")
+	}
+	if record.TargetPath != filepath.Join(manualDir, "A.OPUS") || record.FileSize != int64(len(contentA)) {
+		t.Errorf("a.opus 的备份记录不正确: %+v", record)
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp(pathB); backedUp {
+		t.Error("内容不一致的 b.opus 不应被收养")
+	}
+
+	// 再次收养不会重复登记
+	if result, err := bm.Adopt(deviceInfo); err != nil || result.Adopted != 0 || result.Unrecorded != 2 {
+		t.Errorf("再次收养结果 = %+v, %v, 期望 未登记2 收养0", result, err)
+	}
+
+	// 备份时跳过已收养的 a.opus，只复制其余文件
+	opensA := fake.StreamOpens(pathA)
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if opens := fake.StreamOpens(pathA); opens != opensA {
+		t.Errorf("已收养的 a.opus 不应再次复制，读取次数 %d -> %d", opensA, opens)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Target.BaseDirectory, "a.opus")); !os.IsNotExist(err) {
+		t.Error("已收养的 a.opus 不应被复制到目标目录")
+	}
+	for path, content := range map[string][]byte{"b.opus": contentB, "c.opus": contentC} {
+		if data, err := os.ReadFile(filepath.Join(cfg.Target.BaseDirectory, path)); err != nil || !bytes.Equal(data, content) {
+			t.Errorf("%s 应被复制且内容一致: %v", path, err)
+		}
+	}
+}
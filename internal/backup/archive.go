@@ -0,0 +1,193 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+const (
+	// ArchiveNone 不打包，复制为松散文件
+	ArchiveNone = "none"
+	// ArchiveZip 打包为zip归档
+	ArchiveZip = "zip"
+)
+
+// ArchiveWriter zip归档写入器
+// 文件内容以流的方式直接写入zip条目；设置分卷大小后，当前分卷超过该大小时
+// 后续条目写入新的分卷（单个条目不会跨分卷拆分）
+type ArchiveWriter struct {
+	baseDir   string
+	name      string
+	splitSize int64
+	log       *logger.Logger
+
+	mutex      sync.Mutex
+	volumes    []string
+	file       *os.File
+	writer     *zip.Writer
+	counter    *countingWriter
+	entryNames map[string]bool
+}
+
+// countingWriter 统计写入分卷文件的字节数
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.count += int64(n)
+	return n, err
+}
+
+// NewArchiveWriter 创建zip归档写入器，splitSize <= 0 表示不分卷
+func NewArchiveWriter(baseDir, name string, splitSize int64, log *logger.Logger) *ArchiveWriter {
+	return &ArchiveWriter{
+		baseDir:    baseDir,
+		name:       name,
+		splitSize:  splitSize,
+		log:        log,
+		entryNames: make(map[string]bool),
+	}
+}
+
+// WriteEntry 将数据流写入zip条目，返回写入的字节数、内容的SHA256哈希和条目引用路径
+// 条目引用路径格式为 "<分卷路径>!/<条目名>"，用于备份记录
+func (aw *ArchiveWriter) WriteEntry(relativePath string, r io.Reader, modTime time.Time) (int64, string, string, error) {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+
+	if err := aw.ensureVolume(); err != nil {
+		return 0, "", "", err
+	}
+
+	// 录音本身已是压缩格式，使用存储方式避免无效的再压缩
+	entryName := aw.uniqueEntryName(relativePath)
+	header := &zip.FileHeader{
+		Name:     entryName,
+		Method:   zip.Store,
+		Modified: modTime,
+	}
+
+	entry, err := aw.writer.CreateHeader(header)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("创建zip条目失败: %w", err)
+	}
+
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(entry, hasher), r)
+	if err != nil {
+		return written, "", "", fmt.Errorf("写入zip条目失败: %w", err)
+	}
+
+	// 刷新缓冲数据以便准确统计分卷大小
+	if err := aw.writer.Flush(); err != nil {
+		return written, "", "", fmt.Errorf("刷新zip数据失败: %w", err)
+	}
+
+	volumePath := aw.volumes[len(aw.volumes)-1]
+	aw.log.Debug("写入zip条目: %s -> %s (%s)", relativePath, volumePath, utils.FormatBytes(written))
+	return written, fmt.Sprintf("%x", hasher.Sum(nil)), volumePath + "!/" + entryName, nil
+}
+
+// Volumes 返回已创建的分卷文件路径
+func (aw *ArchiveWriter) Volumes() []string {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+
+	volumes := make([]string, len(aw.volumes))
+	copy(volumes, aw.volumes)
+	return volumes
+}
+
+// Close 完成并关闭当前分卷
+func (aw *ArchiveWriter) Close() error {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+
+	return aw.closeVolume()
+}
+
+// ensureVolume 确保有可写入的分卷，当前分卷超过分卷大小时切换到新分卷
+func (aw *ArchiveWriter) ensureVolume() error {
+	if aw.writer != nil && (aw.splitSize <= 0 || aw.counter.count < aw.splitSize) {
+		return nil
+	}
+
+	if err := aw.closeVolume(); err != nil {
+		return err
+	}
+
+	if err := utils.EnsureDir(aw.baseDir); err != nil {
+		return fmt.Errorf("创建归档目录失败: %w", err)
+	}
+
+	volumePath := filepath.Join(aw.baseDir, aw.volumeName(len(aw.volumes)+1))
+	file, err := os.Create(volumePath)
+	if err != nil {
+		return fmt.Errorf("创建zip文件失败: %w", err)
+	}
+
+	aw.file = file
+	aw.counter = &countingWriter{w: file}
+	aw.writer = zip.NewWriter(aw.counter)
+	aw.volumes = append(aw.volumes, volumePath)
+	aw.entryNames = make(map[string]bool)
+	aw.log.Info("创建备份归档: %s", volumePath)
+	return nil
+}
+
+// closeVolume 关闭当前分卷
+func (aw *ArchiveWriter) closeVolume() error {
+	if aw.writer == nil {
+		return nil
+	}
+
+	writerErr := aw.writer.Close()
+	fileErr := aw.file.Close()
+	aw.writer = nil
+	aw.file = nil
+
+	if writerErr != nil {
+		return fmt.Errorf("完成zip文件失败: %w", writerErr)
+	}
+	if fileErr != nil {
+		return fmt.Errorf("关闭zip文件失败: %w", fileErr)
+	}
+	return nil
+}
+
+// volumeName 获取分卷文件名，不分卷时为 name.zip，分卷时为 name.001.zip、name.002.zip ...
+func (aw *ArchiveWriter) volumeName(index int) string {
+	if aw.splitSize <= 0 {
+		return aw.name + ".zip"
+	}
+	return fmt.Sprintf("%s.%03d.zip", aw.name, index)
+}
+
+// uniqueEntryName 规范化条目名称，同一分卷内重名时追加序号
+func (aw *ArchiveWriter) uniqueEntryName(relativePath string) string {
+	name := strings.TrimLeft(strings.ReplaceAll(relativePath, "\\", "/"), "/")
+	if name == "" {
+		name = "unnamed_file"
+	}
+
+	unique := name
+	ext := filepath.Ext(name)
+	for i := 1; aw.entryNames[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	aw.entryNames[unique] = true
+	return unique
+}
@@ -0,0 +1,205 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// readZipEntries 读取zip文件中所有条目的内容
+func readZipEntries(t *testing.T, zipPath string) map[string][]byte {
+	t.Helper()
+
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("打开zip失败: %v", err)
+	}
+	defer reader.Close()
+
+	entries := make(map[string][]byte)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("打开条目 %s 失败: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("读取条目 %s 失败: %v", f.Name, err)
+		}
+		entries[f.Name] = data
+	}
+	return entries
+}
+
+// TestArchiveWriter_WriteEntry 测试写入条目后zip可解压且路径、内容正确
+func TestArchiveWriter_WriteEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	aw := NewArchiveWriter(tempDir, "backup_test", 0, logger.NewLogger(false))
+
+	files := map[string]string{
+		"录音笔文件/2024/会议.opus":   "meeting content",
+		"录音笔文件\\2023\\访谈.opus": "interview content",
+		"根目录.opus":             "root content",
+	}
+
+	for relativePath, content := range files {
+		written, hash, entryPath, err := aw.WriteEntry(relativePath, strings.NewReader(content), time.Now())
+		if err != nil {
+			t.Fatalf("写入条目失败: %v", err)
+		}
+		if written != int64(len(content)) {
+			t.Errorf("写入字节数错误，期望 %d，实际 %d", len(content), written)
+		}
+		if expected := fmt.Sprintf("%x", sha256.Sum256([]byte(content))); hash != expected {
+			t.Errorf("条目哈希错误: %s", relativePath)
+		}
+		if !strings.HasPrefix(entryPath, filepath.Join(tempDir, "backup_test.zip")+"!/") {
+			t.Errorf("条目引用路径错误: %s", entryPath)
+		}
+	}
+
+	if err := aw.Close(); err != nil {
+		t.Fatalf("关闭归档失败: %v", err)
+	}
+
+	volumes := aw.Volumes()
+	if len(volumes) != 1 {
+		t.Fatalf("不分卷时应只有一个zip，实际 %d", len(volumes))
+	}
+
+	entries := readZipEntries(t, volumes[0])
+	expected := map[string]string{
+		"录音笔文件/2024/会议.opus": "meeting content",
+		"录音笔文件/2023/访谈.opus": "interview content",
+		"根目录.opus":           "root content",
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("条目数量错误，期望 %d，实际 %d", len(expected), len(entries))
+	}
+	for name, content := range expected {
+		if data, ok := entries[name]; !ok || string(data) != content {
+			t.Errorf("条目 %s 内容错误: %q", name, data)
+		}
+	}
+}
+
+// TestArchiveWriter_Split 测试超过分卷大小后写入新分卷
+func TestArchiveWriter_Split(t *testing.T) {
+	tempDir := t.TempDir()
+	aw := NewArchiveWriter(tempDir, "backup_split", 1024, logger.NewLogger(false))
+
+	// 每个条目都超过分卷大小，写入后都应切换分卷
+	payload := bytes.Repeat([]byte("opus"), 512)
+
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("dir/file_%d.opus", i)
+		if _, _, _, err := aw.WriteEntry(name, bytes.NewReader(payload), time.Now()); err != nil {
+			t.Fatalf("写入条目失败: %v", err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("关闭归档失败: %v", err)
+	}
+
+	volumes := aw.Volumes()
+	if len(volumes) != 3 {
+		t.Fatalf("应生成 3 个分卷，实际 %d", len(volumes))
+	}
+
+	for i, volume := range volumes {
+		if filepath.Base(volume) != fmt.Sprintf("backup_split.%03d.zip", i+1) {
+			t.Errorf("分卷文件名错误: %s", volume)
+		}
+		entries := readZipEntries(t, volume)
+		name := fmt.Sprintf("dir/file_%d.opus", i)
+		if data, ok := entries[name]; !ok || !bytes.Equal(data, payload) {
+			t.Errorf("分卷 %s 中的条目 %s 内容错误", volume, name)
+		}
+	}
+}
+
+// TestFileCopier_CopyToArchive 测试归档模式下复制器写入zip条目并记录条目路径
+func TestFileCopier_CopyToArchive(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "device")
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			FileExtensions:    []string{".opus"},
+			PreserveStructure: true,
+			IntegrityCheck:    true,
+		},
+		Target: config.TargetConfig{
+			BaseDirectory: filepath.Join(tempDir, "backups"),
+			Archive:       ArchiveZip,
+		},
+	}
+
+	tracker := NewMockTracker()
+	copier := NewFileCopier(cfg, logger.NewLogger(false), tracker, &device.DeviceInfo{DeviceID: "test"})
+	copier.openStream = func(file *utils.FileInfo) (io.ReadCloser, error) {
+		return os.Open(file.Path)
+	}
+
+	aw := NewArchiveWriter(cfg.Target.BaseDirectory, "backup_copier", 0, logger.NewLogger(false))
+	copier.SetArchiveWriter(aw)
+
+	content := []byte("opus audio data")
+	sourcePath := filepath.Join(sourceDir, "2024", "会议.opus")
+	if err := os.MkdirAll(filepath.Dir(sourcePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sourcePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file := &utils.FileInfo{
+		Path:         sourcePath,
+		RelativePath: "2024\\会议.opus",
+		Name:         "会议.opus",
+		Size:         int64(len(content)),
+	}
+
+	result := copier.CopyFile(file, true)
+	if !result.Success {
+		t.Fatalf("写入归档应成功: %v", result.Error)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("关闭归档失败: %v", err)
+	}
+
+	// 归档模式不应产生松散文件
+	if _, err := os.Stat(filepath.Join(cfg.Target.BaseDirectory, "2024")); !os.IsNotExist(err) {
+		t.Error("归档模式下不应创建松散文件目录")
+	}
+
+	zipPath := filepath.Join(cfg.Target.BaseDirectory, "backup_copier.zip")
+	entries := readZipEntries(t, zipPath)
+	if data, ok := entries["2024/会议.opus"]; !ok || !bytes.Equal(data, content) {
+		t.Errorf("zip条目内容错误: %v", entries)
+	}
+
+	record := tracker.records[sourcePath]
+	if record == nil {
+		t.Fatal("应添加备份记录")
+	}
+	if record.TargetPath != zipPath+"!/2024/会议.opus" {
+		t.Errorf("备份记录应指向zip条目，实际: %s", record.TargetPath)
+	}
+	if record.FileHash != fmt.Sprintf("%x", sha256.Sum256(content)) {
+		t.Errorf("备份记录哈希应为条目内容的哈希，实际: %s", record.FileHash)
+	}
+}
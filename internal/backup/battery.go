@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/device"
+)
+
+// 电量不足时的处理方式
+const (
+	LowBatteryRefuse = "refuse" // 拒绝开始备份
+	LowBatteryWarn   = "warn"   // 只警告，继续备份
+)
+
+// DefaultBatteryCheckInterval 备份过程中检查设备电量的间隔
+const DefaultBatteryCheckInterval = 30 * time.Second
+
+// ErrLowBattery 设备电量不足，备份被拒绝或中途停止
+var ErrLowBattery = errors.New("设备电量不足")
+
+// readBatteryLevel 读取设备电量百分比，读取不到时返回 device.BatteryUnknown
+func readBatteryLevel(mtp device.MTPInterface) int {
+	details, err := mtp.GetDeviceDetails()
+	if err != nil || details == nil {
+		return device.BatteryUnknown
+	}
+	return details.BatteryLevel
+}
+
+// checkBattery 备份前检查设备电量，低于 source.min_battery_percent 时按 low_battery_action 拒绝开始或只警告
+// 未开启电量检查或读取不到电量时不做限制
+func (bm *BackupManager) checkBattery(fileChecker *FileChecker, dev *device.DeviceInfo) error {
+	minPercent := bm.config.Source.MinBatteryPercent
+	if minPercent <= 0 {
+		return nil
+	}
+
+	mtpInterface, release, err := fileChecker.acquireDevice(dev)
+	if err != nil {
+		// 无法访问设备时由后续的枚举报告错误
+		return nil
+	}
+	defer release()
+
+	level := readBatteryLevel(mtpInterface)
+	if level == device.BatteryUnknown {
+		bm.log.Info("无法读取设备电量，跳过电量检查")
+		return nil
+	}
+	if level >= minPercent {
+		bm.log.Info("设备电量: %d%%", level)
+		return nil
+	}
+
+	if bm.config.Source.LowBatteryAction == LowBatteryWarn {
+		bm.log.Warn("设备电量 %d%% 低于 %d%%，备份中途断电可能损坏正在写入的文件，建议充电后再备份", level, minPercent)
+		return nil
+	}
+	return fmt.Errorf("%w: 当前 %d%%，低于 source.min_battery_percent（%d%%），请充电后再备份", ErrLowBattery, level, minPercent)
+}
+
+// watchBattery 备份过程中按 bm.batteryInterval 定期检查设备电量，降到 source.critical_battery_percent 及以下时取消返回的 ctx，
+// 不再开始新文件的复制，进行中的文件完成后由 Run 保存备份记录和断点信息。
+// 返回的 stop 结束检查，电量过低而停止时返回包装了 ErrLowBattery 的错误；未开启电量检查时 ctx 原样返回
+func (bm *BackupManager) watchBattery(parent context.Context, fileChecker *FileChecker, dev *device.DeviceInfo) (context.Context, func() error) {
+	critical := bm.config.Source.CriticalBatteryPercent
+	if bm.config.Source.MinBatteryPercent <= 0 || critical <= 0 {
+		return parent, func() error { return nil }
+	}
+
+	mtpInterface, release, err := fileChecker.acquireDevice(dev)
+	if err != nil {
+		return parent, func() error { return nil }
+	}
+
+	interval := bm.batteryInterval
+	if interval <= 0 {
+		interval = DefaultBatteryCheckInterval
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var lowErr error
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			level := readBatteryLevel(mtpInterface)
+			if level == device.BatteryUnknown || level > critical {
+				continue
+			}
+			lowErr = fmt.Errorf("%w: 备份过程中电量降到 %d%%（危险值 %d%%），已停止复制", ErrLowBattery, level, critical)
+			bm.log.Warn("设备电量降到 %d%%，停止开始新文件的复制并保存进度，请充电后用 resume 继续", level)
+			cancel()
+			return
+		}
+	}()
+
+	return ctx, func() error {
+		close(done)
+		wg.Wait()
+		cancel()
+		release()
+		return lowErr
+	}
+}
@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// newBatteryFixture 创建开启电量检查的备份管理器和有 fileCount 个录音的虚拟设备
+func newBatteryFixture(t *testing.T, fileCount int, action string) (*BackupManager, *device.FakeMTPAccessor, *device.DeviceInfo, string) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Source.MinBatteryPercent = 20
+	cfg.Source.LowBatteryAction = action
+	cfg.Source.CriticalBatteryPercent = 5
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.CommitInterval = 0
+	cfg.Backup.EnableResume = false
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	recordsPath := filepath.Join(t.TempDir(), "backup_records.json")
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	modTime := time.Now().Add(-time.Hour)
+	for i := 0; i < fileCount; i++ {
+		fake.AddFile(fmt.Sprintf("内部共享存储空间\\录音笔文件\\file%d.opus", i), []byte(fmt.Sprintf("录音%d", i)), modTime)
+	}
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: storage.NewBackupTracker(recordsPath, log),
+		quiet:   true,
+	}
+	bm.SetMTPInterface(fake)
+	return bm, fake, deviceInfo, recordsPath
+}
+
+// TestBackupManager_BatteryCheck 测试备份前的电量检查：低电量时按配置拒绝开始或只警告，电量充足或读取不到时正常备份
+func TestBackupManager_BatteryCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		level   int
+		refused bool
+	}{
+		{"低电量拒绝", LowBatteryRefuse, 10, true},
+		{"低电量只警告", LowBatteryWarn, 10, false},
+		{"电量充足", LowBatteryRefuse, 80, false},
+		{"读取不到电量", LowBatteryRefuse, device.BatteryUnknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bm, fake, deviceInfo, _ := newBatteryFixture(t, 3, tt.action)
+			fake.SetBatteryLevel(tt.level)
+
+			summary, err := bm.Run(context.Background(), deviceInfo, false)
+			if tt.refused {
+				if !errors.Is(err, ErrLowBattery) {
+					t.Fatalf("低电量时应拒绝备份，实际 %v", err)
+				}
+				if summary != nil || fake.StreamOpens("内部共享存储空间\\录音笔文件\\file0.opus") != 0 {
+					t.Error("拒绝备份时不应开始复制")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("备份失败: %v", err)
+			}
+			if summary.Succeeded != 3 {
+				t.Errorf("成功 %d 个文件，期望 3", summary.Succeeded)
+			}
+		})
+	}
+}
+
+// TestBackupManager_BatteryDropDuringBackup 测试备份中电量骤降到危险值时停止开始新文件的复制，并保存已完成的记录
+func TestBackupManager_BatteryDropDuringBackup(t *testing.T) {
+	const fileCount = 10
+	bm, fake, deviceInfo, recordsPath := newBatteryFixture(t, fileCount, LowBatteryRefuse)
+	bm.batteryInterval = 5 * time.Millisecond
+	fake.SetBatteryLevel(80)
+	fake.SetOpenDelay(30 * time.Millisecond)
+	// 开始复制第 3 个文件时电量骤降
+	fake.BatteryDropAt(3, 3)
+
+	summary, err := bm.Run(context.Background(), deviceInfo, false)
+	if !errors.Is(err, ErrLowBattery) {
+		t.Fatalf("电量骤降后应返回 ErrLowBattery，实际 %v", err)
+	}
+	if summary == nil {
+		t.Fatal("电量过低停止后应返回运行概况")
+	}
+	if summary.Succeeded < 2 || summary.Succeeded >= fileCount || summary.Failed != 0 {
+		t.Errorf("运行概况 = 成功 %d 失败 %d，期望停止前完成的文件成功、其余未复制", summary.Succeeded, summary.Failed)
+	}
+
+	// 从磁盘重新加载，停止前完成的记录必须已持久化
+	reloaded := storage.NewBackupTracker(recordsPath, logger.NewLogger(false))
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载备份记录失败: %v", err)
+	}
+	if records := len(reloaded.GetStorage().Records); records != summary.Succeeded {
+		t.Errorf("已持久化的备份记录 = %d，期望 %d", records, summary.Succeeded)
+	}
+}
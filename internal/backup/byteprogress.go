@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"sync"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// byteProgress 汇总并发复制中各文件已写入的字节数，得到整体进度
+// 单个文件的进度只增不减：重试或重新打开文件流从头写入时不会让进度条倒退或重复计数
+type byteProgress struct {
+	mu     sync.Mutex
+	files  map[string]int64
+	total  int64
+	update func(total int64)
+}
+
+// newByteProgress 创建字节进度汇总，整体进度变化时调用 update
+func newByteProgress(update func(total int64)) *byteProgress {
+	return &byteProgress{
+		files:  make(map[string]int64),
+		update: update,
+	}
+}
+
+// report 记录文件累计已写入的字节数，不超过文件大小
+func (p *byteProgress) report(file *utils.FileInfo, copied int64) {
+	if file.Size > 0 && copied > file.Size {
+		copied = file.Size
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := p.files[file.Path]
+	if copied <= previous {
+		return
+	}
+	p.files[file.Path] = copied
+	p.total += copied - previous
+	// 持锁调用，保证并发上报时整体进度按顺序递增
+	p.update(p.total)
+}
+
+// complete 文件复制成功，按文件大小计入进度（未逐字节上报的复制方式只在完成时计入）
+func (p *byteProgress) complete(file *utils.FileInfo) {
+	p.report(file, file.Size)
+}
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_ResumeProgress 测试断点续传的进度从断点字节开始，单调递增到文件大小
+func TestFileCopier_ResumeProgress(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\resume.opus"
+	content := make([]byte, 300*1024+17)
+	for i := range content {
+		content[i] = byte(i * 13)
+	}
+	const resumeAt = 100 * 1024
+
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddFile(devicePath, content, time.Now().Add(-time.Hour))
+
+	copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+	copier.SetMTPInterface(fake)
+	cfg.Backup.EnableResume = true
+	resumeDir := t.TempDir()
+	copier.resumeManager = NewResumeManager(filepath.Join(resumeDir, "resume"), filepath.Join(resumeDir, "temp"), log)
+
+	// 上次中断时已写入前 resumeAt 字节
+	file := &utils.FileInfo{Path: devicePath, RelativePath: "resume.opus", Name: "resume.opus", Size: int64(len(content))}
+	info := &ResumeInfo{
+		FilePath:    devicePath,
+		TempPath:    copier.resumeManager.GetTempPath(devicePath),
+		CopiedBytes: resumeAt,
+		TotalBytes:  file.Size,
+		Metadata:    make(map[string]string),
+	}
+	if err := os.WriteFile(info.TempPath, content[:resumeAt], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := copier.resumeManager.SaveResumeInfo(info); err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []int64
+	copier.SetProgressFunc(func(f *utils.FileInfo, copied int64) {
+		if f.Path != devicePath {
+			t.Errorf("上报了其他文件的进度: %s", f.Path)
+		}
+		reports = append(reports, copied)
+	})
+
+	result := copier.CopyFile(file, true)
+	if !result.Success {
+		t.Fatalf("复制失败: %v", result.Error)
+	}
+	copied, err := os.ReadFile(result.TargetPath)
+	if err != nil || !bytes.Equal(copied, content) {
+		t.Fatalf("续传后的文件内容与设备文件不一致: %v", err)
+	}
+
+	if len(reports) < 3 {
+		t.Fatalf("进度上报次数过少: %v", reports)
+	}
+	if reports[0] != resumeAt {
+		t.Errorf("进度起始值 = %d，期望断点字节 %d", reports[0], resumeAt)
+	}
+	if last := reports[len(reports)-1]; last != file.Size {
+		t.Errorf("进度结束值 = %d，期望文件大小 %d", last, file.Size)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i] < reports[i-1] {
+			t.Fatalf("进度在第 %d 次上报时倒退: %d -> %d", i, reports[i-1], reports[i])
+		}
+	}
+}
+
+// TestByteProgress 测试多个文件并发上报时整体进度单调递增，重复或倒退的上报不重复计数
+func TestByteProgress(t *testing.T) {
+	var mu sync.Mutex
+	var totals []int64
+	progress := newByteProgress(func(total int64) {
+		mu.Lock()
+		totals = append(totals, total)
+		mu.Unlock()
+	})
+
+	const fileCount = 8
+	const fileSize = 64 * 1024
+	files := make([]*utils.FileInfo, fileCount)
+	for i := range files {
+		files[i] = &utils.FileInfo{Path: fmt.Sprintf("device\\%d.opus", i), Size: fileSize}
+	}
+
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file *utils.FileInfo) {
+			defer wg.Done()
+			// 偶数文件从断点续传，奇数文件中途重新打开文件流从头写入
+			start := int64(0)
+			if i%2 == 0 {
+				start = fileSize / 2
+			}
+			for copied := start; copied <= fileSize; copied += 4096 {
+				progress.report(file, copied)
+			}
+			if i%2 == 1 {
+				for copied := int64(0); copied <= fileSize; copied += 8192 {
+					progress.report(file, copied)
+				}
+			}
+			progress.complete(file)
+		}(i, file)
+	}
+	wg.Wait()
+
+	if len(totals) == 0 {
+		t.Fatal("没有上报整体进度")
+	}
+	for i := 1; i < len(totals); i++ {
+		if totals[i] <= totals[i-1] {
+			t.Fatalf("整体进度在第 %d 次上报时没有递增: %d -> %d", i, totals[i-1], totals[i])
+		}
+	}
+	if last := totals[len(totals)-1]; last != fileCount*fileSize {
+		t.Errorf("整体进度结束值 = %d，期望 %d", last, fileCount*fileSize)
+	}
+
+	// 完成后再次上报或超出文件大小的上报不影响整体进度
+	progress.report(files[0], fileSize*2)
+	progress.complete(files[1])
+	if last := totals[len(totals)-1]; last != fileCount*fileSize {
+		t.Errorf("重复上报后整体进度 = %d，期望 %d", last, fileCount*fileSize)
+	}
+}
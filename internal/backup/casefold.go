@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// foldTargetCase 开启 target.case_insensitive 时，把目标路径中只有大小写不同的已存在目录或文件替换为已有的名称，
+// 使区分大小写的目标上 A.opus 与 a.opus 视为同一个目标，再按 skip_existing / keep_versions 处理
+// root 为目标根目录，只规范化其下的部分；未开启或路径不在根目录下时原样返回
+func (fc *FileCopier) foldTargetCase(root, targetPath string) string {
+	if !fc.config.Target.CaseInsensitive {
+		return targetPath
+	}
+	rel, err := filepath.Rel(root, targetPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return targetPath
+	}
+
+	folded := root
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		name, ok := existingName(folded, part)
+		if !ok {
+			// 其后的部分尚不存在，保持原样
+			return filepath.Join(append([]string{folded}, parts[i:]...)...)
+		}
+		if name != part {
+			fc.log.Debug("目标中已存在只有大小写不同的路径，视为同一个目标: %s -> %s", part, name)
+		}
+		folded = filepath.Join(folded, name)
+	}
+	return folded
+}
+
+// existingName 在目录 dir 中查找与 name 忽略大小写相同的项，名称完全相同的优先
+// 通过列出目录比较名称，在不区分大小写的文件系统上同样能取得已有项的实际大小写
+func existingName(dir, name string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	match := ""
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return name, true
+		}
+		if match == "" && strings.EqualFold(entry.Name(), name) {
+			match = entry.Name()
+		}
+	}
+	return match, match != ""
+}
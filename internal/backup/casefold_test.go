@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_CaseInsensitiveTarget 测试目标中已有 Rec/A.opus 时复制设备上的 rec/a.opus：
+// 开启 case_insensitive 时视为冲突，沿用已有名称并按 keep_versions 保留旧版本；关闭时视为两个独立文件
+func TestFileCopier_CaseInsensitiveTarget(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\rec\\a.opus"
+	file := &utils.FileInfo{Path: devicePath, RelativePath: "rec\\a.opus", Name: "a.opus", Size: int64(len("新录音"))}
+
+	newCopier := func(t *testing.T, caseInsensitive bool) (*FileCopier, string) {
+		cfg := config.DefaultConfig()
+		cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+		cfg.Target.CaseInsensitive = caseInsensitive
+		cfg.Backup.EnableResume = false
+		cfg.Backup.RangeDownload.Enabled = false
+		cfg.Backup.KeepVersions = 2
+
+		existing := filepath.Join(cfg.Target.BaseDirectory, "Rec", "A.opus")
+		if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(existing, []byte("旧录音"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+		fake := device.NewFakeMTPAccessor(deviceInfo)
+		fake.AddFile(devicePath, []byte("新录音"), time.Now().Add(-time.Hour))
+		copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), deviceInfo)
+		copier.SetMTPInterface(fake)
+		return copier, cfg.Target.BaseDirectory
+	}
+
+	t.Run("开启", func(t *testing.T) {
+		copier, base := newCopier(t, true)
+		result := copier.CopyFile(file, false)
+		if !result.Success {
+			t.Fatalf("复制失败: %v", result.Error)
+		}
+
+		existing := filepath.Join(base, "Rec", "A.opus")
+		if result.TargetPath != existing {
+			t.Errorf("目标路径 = %s，期望沿用已有的 %s", result.TargetPath, existing)
+		}
+		if data, _ := os.ReadFile(existing); string(data) != "新录音" {
+			t.Errorf("目标内容 = %q，期望新录音", data)
+		}
+		if data, _ := os.ReadFile(versionPath(existing, 1)); string(data) != "旧录音" {
+			t.Errorf("旧录音应按 keep_versions 保留为第1版，实际 %q", data)
+		}
+
+		entries, err := os.ReadDir(base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "Rec" {
+			t.Errorf("不应创建只有大小写不同的目录: %v", entries)
+		}
+		entries, err = os.ReadDir(filepath.Join(base, "Rec"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		if len(names) != 2 || names[0] != "A.opus" || names[1] != "A.v1.opus" {
+			t.Errorf("目标目录 = %v，期望只有 A.opus 和 A.v1.opus", names)
+		}
+	})
+
+	t.Run("关闭", func(t *testing.T) {
+		copier, base := newCopier(t, false)
+		targetPath, err := copier.getTargetPath(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(base, "rec", "a.opus"); targetPath != want {
+			t.Errorf("目标路径 = %s，期望 %s", targetPath, want)
+		}
+
+		// 只有区分大小写的文件系统上两者才是不同的文件
+		if _, err := os.Stat(filepath.Join(base, "rec", "a.opus")); err == nil {
+			t.Skip("当前文件系统不区分大小写")
+		}
+		result := copier.CopyFile(file, false)
+		if !result.Success {
+			t.Fatalf("复制失败: %v", result.Error)
+		}
+		if data, _ := os.ReadFile(filepath.Join(base, "Rec", "A.opus")); string(data) != "旧录音" {
+			t.Errorf("关闭时已有的 A.opus 不应被改动，实际 %q", data)
+		}
+		if data, _ := os.ReadFile(targetPath); string(data) != "新录音" {
+			t.Errorf("a.opus 应作为独立文件写入，实际 %q", data)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// ChangelogPath 变更日志文件路径，每次备份新增的文件追加为一行 JSON
+const ChangelogPath = "data/changelog.jsonl"
+
+// ChangelogEntry 一次备份运行的变更条目
+type ChangelogEntry struct {
+	SessionID  string          `json:"session_id"`
+	DeviceName string          `json:"device_name"`
+	DeviceID   string          `json:"device_id"`
+	StartTime  time.Time       `json:"start_time"`
+	EndTime    time.Time       `json:"end_time"`
+	Files      []ChangelogFile `json:"files"`
+}
+
+// ChangelogFile 本次新备份的文件
+type ChangelogFile struct {
+	Name         string    `json:"name"`
+	RelativePath string    `json:"relative_path"`
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"mod_time,omitempty"` // 设备上的修改时间
+	TargetPath   string    `json:"target_path"`
+}
+
+// TotalSize 本次新备份文件的总大小
+func (e *ChangelogEntry) TotalSize() int64 {
+	var total int64
+	for _, file := range e.Files {
+		total += file.Size
+	}
+	return total
+}
+
+// newChangelogEntry 从复制结果中收集复制成功的文件，没有新备份的文件时返回 nil
+func newChangelogEntry(sessionID string, dev *device.DeviceInfo, startTime, endTime time.Time, results []*CopyResult) *ChangelogEntry {
+	entry := &ChangelogEntry{
+		SessionID:  sessionID,
+		DeviceName: dev.Name,
+		DeviceID:   dev.DeviceID,
+		StartTime:  startTime,
+		EndTime:    endTime,
+	}
+	for _, result := range results {
+		if !result.Success || result.File == nil {
+			continue
+		}
+		entry.Files = append(entry.Files, ChangelogFile{
+			Name:         result.File.Name,
+			RelativePath: result.File.RelativePath,
+			Size:         result.File.Size,
+			ModTime:      result.File.ModTime,
+			TargetPath:   result.TargetPath,
+		})
+	}
+	if len(entry.Files) == 0 {
+		return nil
+	}
+	return entry
+}
+
+// AppendChangelog 把变更条目追加到变更日志末尾
+func AppendChangelog(path string, entry *ChangelogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化变更条目失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建变更日志目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开变更日志失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入变更日志失败: %w", err)
+	}
+	return nil
+}
+
+// ReadChangelog 读取变更日志中最近的 last 条，last<=0 时返回全部，按时间先后排列
+// 文件不存在时返回空列表，无法解析的行被跳过
+func ReadChangelog(path string, last int) ([]ChangelogEntry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开变更日志失败: %w", err)
+	}
+	defer file.Close()
+
+	var entries []ChangelogEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry ChangelogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取变更日志失败: %w", err)
+	}
+
+	if last > 0 && len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+	return entries, nil
+}
+
+// WriteChangelog 以 Markdown 格式输出变更条目
+func WriteChangelog(w io.Writer, entries []ChangelogEntry) {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s %s（会话 %s）\n\n", entry.StartTime.Format("2006-01-02 15:04:05"), entry.DeviceName, entry.SessionID)
+		fmt.Fprintf(w, "新增 %d 个文件，共 %s\n\n", len(entry.Files), utils.FormatBytes(entry.TotalSize()))
+		for _, file := range entry.Files {
+			modTime := "-"
+			if !file.ModTime.IsZero() {
+				modTime = file.ModTime.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "- %s  %s  %s -> %s\n", file.RelativePath, utils.FormatBytes(file.Size), modTime, file.TargetPath)
+		}
+	}
+}
+
+// appendChangelog 把本次新备份的文件追加到数据目录下的变更日志，失败不影响备份结果
+func (bm *BackupManager) appendChangelog(sessionID string, dev *device.DeviceInfo, startTime time.Time, results []*CopyResult) {
+	if bm.dataDir == "" {
+		return
+	}
+	entry := newChangelogEntry(sessionID, dev, startTime, time.Now(), results)
+	if entry == nil {
+		return
+	}
+	entry.DeviceName = dev.DisplayName(bm.config)
+	path := filepath.Join(bm.dataDir, filepath.Base(ChangelogPath))
+	if err := AppendChangelog(path, entry); err != nil {
+		bm.log.Warn("写入变更日志失败: %v", err)
+		return
+	}
+	bm.log.Debug("变更日志已追加 %d 个文件: %s", len(entry.Files), path)
+}
@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// TestBackupManager_Changelog 测试两次备份各追加一条变更条目，只包含该次新备份的文件
+func TestBackupManager_Changelog(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件\\"
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	dataDir := t.TempDir()
+	tracker := storage.NewBackupTracker(filepath.Join(dataDir, "backup_records.json"), log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+	modTime := time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local)
+	fake.AddFile(base+"a.opus", bytes.Repeat([]byte("a"), 1024), modTime)
+	fake.AddFile(base+"b.opus", bytes.Repeat([]byte("b"), 512), modTime)
+
+	bm := &BackupManager{config: cfg, log: log, tracker: tracker, dataDir: dataDir, quiet: true}
+	bm.SetMTPInterface(fake)
+
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("第一次备份失败: %v", err)
+	}
+	fake.AddFile(base+"c.opus", bytes.Repeat([]byte("c"), 256), modTime.Add(time.Hour))
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("第二次备份失败: %v", err)
+	}
+	// 没有新文件的备份不追加条目
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("第三次备份失败: %v", err)
+	}
+
+	path := filepath.Join(dataDir, filepath.Base(ChangelogPath))
+	entries, err := ReadChangelog(path, 0)
+	if err != nil {
+		t.Fatalf("读取变更日志失败: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("期望 2 条变更条目，实际 %d 条", len(entries))
+	}
+
+	wantFiles := [][]string{{"a.opus", "b.opus"}, {"c.opus"}}
+	for i, entry := range entries {
+		var names []string
+		for _, file := range entry.Files {
+			names = append(names, file.Name)
+			if file.TargetPath != filepath.Join(cfg.Target.BaseDirectory, file.Name) {
+				t.Errorf("%s 的目标路径 = %s", file.Name, file.TargetPath)
+			}
+			if file.Size == 0 || file.ModTime.IsZero() {
+				t.Errorf("%s 缺少大小或修改时间: %+v", file.Name, file)
+			}
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, wantFiles[i]) {
+			t.Errorf("第 %d 次备份的文件 = %v，期望 %v", i+1, names, wantFiles[i])
+		}
+		if len(entry.SessionID) != 8 || entry.DeviceID != deviceInfo.DeviceID || entry.StartTime.IsZero() {
+			t.Errorf("第 %d 条变更条目缺少会话或设备信息: %+v", i+1, entry)
+		}
+	}
+	if entries[0].SessionID == entries[1].SessionID {
+		t.Error("不同备份的会话ID应不同")
+	}
+
+	// 只查看最近一次
+	last, err := ReadChangelog(path, 1)
+	if err != nil || len(last) != 1 || last[0].SessionID != entries[1].SessionID {
+		t.Fatalf("最近一次变更条目 = %+v, %v", last, err)
+	}
+	var out bytes.Buffer
+	WriteChangelog(&out, last)
+	if !strings.Contains(out.String(), "新增 1 个文件") || !strings.Contains(out.String(), "c.opus") {
+		t.Errorf("变更日志输出不正确:\n%s", out.String())
+	}
+}
+
+// TestReadChangelog_Missing 测试变更日志不存在时返回空列表
+func TestReadChangelog_Missing(t *testing.T) {
+	entries, err := ReadChangelog(filepath.Join(t.TempDir(), "changelog.jsonl"), 5)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("期望空列表，实际 %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/internal/store"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// IncomingDirName 内容寻址布局下复制中文件的暂存目录，位于目标根目录下，校验通过后按哈希移入分桶目录
+const IncomingDirName = ".incoming"
+
+// contentAddressed 目标是否使用内容寻址布局
+func (fc *FileCopier) contentAddressed() bool {
+	return fc.config.Target.Layout == config.LayoutContentAddressed
+}
+
+// targetBaseDir 本地目标的根目录
+func (fc *FileCopier) targetBaseDir() string {
+	if localStore, ok := fc.store.(store.LocalPather); ok {
+		return localStore.LocalPath("")
+	}
+	return fc.config.Target.BaseDirectory
+}
+
+// stagingPath 内容寻址布局下文件复制时的暂存路径
+// 由设备ID和源路径决定，同一文件中断后再次复制时仍写入同一路径，断点续传可以继续
+func (fc *FileCopier) stagingPath(file *utils.FileInfo) string {
+	sum := sha256.Sum256([]byte(fc.device.DeviceID + "\x00" + file.Path))
+	name := fmt.Sprintf("%x", sum[:8]) + strings.ToLower(filepath.Ext(file.Name))
+	return filepath.Join(fc.targetBaseDir(), IncomingDirName, name)
+}
+
+// ContentRelativePath 内容哈希对应的相对路径，按哈希前两级各两个字符分桶，如 ab/cd/abcd....opus
+func ContentRelativePath(contentHash, ext string) string {
+	return filepath.Join(contentHash[:2], contentHash[2:4], contentHash+strings.ToLower(ext))
+}
+
+// storeByContent 计算暂存文件的内容哈希并移入分桶目录，返回最终路径和哈希
+// 相同内容的文件已存在时删除暂存文件，只保留一份
+func (fc *FileCopier) storeByContent(stagingPath string, file *utils.FileInfo) (string, string, error) {
+	contentHash, err := utils.CalculateFileHash(stagingPath)
+	if err != nil {
+		return "", "", err
+	}
+	targetPath := filepath.Join(fc.targetBaseDir(), ContentRelativePath(contentHash, filepath.Ext(file.Name)))
+	if _, err := os.Stat(targetPath); err == nil {
+		if err := os.Remove(stagingPath); err != nil {
+			fc.log.Warn("删除暂存文件失败: %s, %v", stagingPath, err)
+		}
+		fc.log.Debug("内容已存在，只保留一份: %s -> %s", file.RelativePath, targetPath)
+		return targetPath, contentHash, nil
+	}
+	if err := fc.importTarget(targetPath, stagingPath); err != nil {
+		return "", "", fmt.Errorf("移入分桶目录失败: %w", err)
+	}
+	return targetPath, contentHash, nil
+}
+
+// releaseContent 记录改指向 contentHash 后，原来的内容留待本轮复制结束后回收
+// 复制进行中不能判断旧内容是否还被引用：其他 worker 可能刚复用了同一内容、尚未写入记录
+func (fc *FileCopier) releaseContent(previous *storage.BackupRecord, contentHash string) {
+	if previous == nil || previous.ContentHash == "" || previous.ContentHash == contentHash {
+		return
+	}
+	fc.released.Store(previous.ContentHash, previous.TargetPath)
+}
+
+// collectReleasedContent 在所有复制结束后回收不再被任何记录引用的旧内容，移入回收站而不是直接删除
+func (fc *FileCopier) collectReleasedContent() {
+	var trash *Trash
+	fc.released.Range(func(key, value any) bool {
+		fc.released.Delete(key)
+		contentHash, targetPath := key.(string), value.(string)
+		if len(fc.tracker.RecordsByContent(contentHash)) > 0 {
+			return true
+		}
+		if trash == nil {
+			trash = NewTrashFromConfig(fc.config, fc.log)
+		}
+		if _, err := trash.Move(targetPath, fc.clock.Now(), nil); err != nil {
+			fc.log.Warn("回收不再被引用的内容失败: %s, %v", targetPath, err)
+			return true
+		}
+		fc.log.Debug("不再被引用的内容已移入回收站: %s", targetPath)
+		return true
+	})
+}
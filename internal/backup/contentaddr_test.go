@@ -0,0 +1,224 @@
+package backup
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_ContentAddressed 测试内容寻址布局下内容相同的两个文件只存一个物理文件，备份记录能还原各自的原名
+func TestFileCopier_ContentAddressed(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Target.Layout = config.LayoutContentAddressed
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+
+	content := []byte("同一段录音")
+	names := []string{"会议.opus", "会议副本.opus"}
+	var files []*utils.FileInfo
+	for _, name := range names {
+		path := "内部共享存储空间\\录音笔文件\\" + name
+		fake.AddFile(path, content, time.Now().Add(-time.Hour))
+		files = append(files, &utils.FileInfo{Path: path, RelativePath: name, Name: name, Size: int64(len(content))})
+	}
+
+	copier := NewFileCopier(cfg, log, tracker, deviceInfo)
+	copier.SetMTPInterface(fake)
+	var targets []string
+	for _, file := range files {
+		result := copier.CopyFile(file, false)
+		if !result.Success {
+			t.Fatalf("复制 %s 失败: %v", file.Name, result.Error)
+		}
+		targets = append(targets, result.TargetPath)
+	}
+
+	if targets[0] != targets[1] {
+		t.Errorf("内容相同的文件应指向同一目标: %v", targets)
+	}
+	var stored []string
+	err := filepath.WalkDir(cfg.Target.BaseDirectory, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			stored = append(stored, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0] != targets[0] {
+		t.Fatalf("目标中应只有一个物理文件 %s，实际 %v", targets[0], stored)
+	}
+
+	contentHash, err := utils.CalculateFileHash(stored[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(cfg.Target.BaseDirectory, contentHash[:2], contentHash[2:4], contentHash+".opus")
+	if stored[0] != want {
+		t.Errorf("物理文件路径 = %s，期望按哈希分桶 %s", stored[0], want)
+	}
+
+	var restored []string
+	for _, record := range tracker.RecordsByContent(contentHash) {
+		restored = append(restored, storage.RecordName(record.SourcePath))
+	}
+	sort.Strings(restored)
+	if strings.Join(restored, ",") != strings.Join(names, ",") {
+		t.Errorf("由哈希还原的原名 = %v，期望 %v", restored, names)
+	}
+}
+
+// TestMirrorCleaner_SharedContent 测试内容寻址布局下设备删除其中一个原名时只移除记录，文件仍被另一记录引用而保留
+func TestMirrorCleaner_SharedContent(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), log)
+
+	const contentHash = "abcd0123"
+	targetPath := filepath.Join(baseDir, ContentRelativePath(contentHash, ".opus"))
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targetPath, []byte("录音"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.opus", "b.opus"} {
+		if err := tracker.AddRecord("device\\"+name, targetPath, "test_device", 6, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := tracker.SetContentHash("device\\"+name, contentHash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleaner := NewMirrorCleaner(baseDir, tracker, false, log)
+	if _, err := cleaner.Clean("test_device", deviceFiles("b.opus")); err != nil {
+		t.Fatalf("镜像清理失败: %v", err)
+	}
+	if _, err := os.Stat(targetPath); err != nil {
+		t.Errorf("仍被引用的文件不应移入回收站: %v", err)
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp("device\\a.opus"); backedUp {
+		t.Error("设备上已删除的原名应移除记录")
+	}
+
+	// 最后一个引用也被删除时文件移入回收站
+	if _, err := cleaner.Clean("test_device", deviceFiles("c.opus")); err != nil {
+		t.Fatalf("镜像清理失败: %v", err)
+	}
+	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+		t.Error("没有引用的文件应移入回收站")
+	}
+}
+
+// TestFileCopier_ContentAddressedReleasesOldContent 测试源文件内容变化重新复制后，不再被引用的旧内容在复制结束后移入回收站，仍被引用的保留
+func TestFileCopier_ContentAddressedReleasesOldContent(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Target.Layout = config.LayoutContentAddressed
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	copier := NewFileCopier(cfg, log, tracker, deviceInfo)
+	copier.SetMTPInterface(fake)
+
+	copyContent := func(name, content string) string {
+		path := "内部共享存储空间\\录音笔文件\\" + name
+		fake.AddFile(path, []byte(content), time.Now().Add(-time.Hour))
+		result := copier.CopyFile(&utils.FileInfo{Path: path, RelativePath: name, Name: name, Size: int64(len(content))}, true)
+		if !result.Success {
+			t.Fatalf("复制 %s 失败: %v", name, result.Error)
+		}
+		return result.TargetPath
+	}
+
+	shared := copyContent("a.opus", "共用的录音")
+	copyContent("b.opus", "共用的录音")
+	copyContent("a.opus", "a 被修改后的录音")
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("仍被 b.opus 引用的内容不应删除: %v", err)
+	}
+
+	copier.collectReleasedContent()
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("仍被 b.opus 引用的内容不应回收: %v", err)
+	}
+
+	copyContent("b.opus", "b 被修改后的录音")
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("复制结束前不应回收旧内容: %v", err)
+	}
+	copier.collectReleasedContent()
+	if _, err := os.Stat(shared); !os.IsNotExist(err) {
+		t.Errorf("不再被引用的旧内容应移出目标目录: %v", err)
+	}
+	items, err := NewTrashFromConfig(cfg, log).List()
+	if err != nil || len(items) != 1 || items[0].OriginalPath != shared {
+		t.Errorf("不再被引用的旧内容应移入回收站: %+v, %v", items, err)
+	}
+}
+
+// TestDayArchiver_SharedContent 测试内容寻址布局下多条记录共用的文件只归档和删除一次，各记录都指向同一条目
+func TestDayArchiver_SharedContent(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), log)
+
+	const contentHash = "abcd0123"
+	targetPath := filepath.Join(baseDir, ContentRelativePath(contentHash, ".opus"))
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targetPath, []byte("录音"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"20240501_090000.opus", "20240502_090000.opus"} {
+		if err := tracker.AddRecord("device\\"+name, targetPath, "test_device", 6, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := tracker.SetContentHash("device\\"+name, contentHash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outDir := filepath.Join(t.TempDir(), "daily")
+	archiver := NewDayArchiver(tracker, outDir, log)
+	archiver.SetDeleteSources(true, false, nil)
+	groups, removed, err := archiver.Archive()
+	if err != nil {
+		t.Fatalf("按日归档失败: %v", err)
+	}
+	if len(groups) != 1 || removed != 1 {
+		t.Fatalf("共用的文件应只归档和删除一次: %d 个归档，删除 %d 个", len(groups), removed)
+	}
+	if entries := zipEntries(t, groups[0].ArchivePath); len(entries) != 1 {
+		t.Errorf("归档中应只有一个条目，实际 %d 个", len(entries))
+	}
+	want := groups[0].ArchivePath + "!/" + filepath.Base(targetPath)
+	for _, name := range []string{"20240501_090000.opus", "20240502_090000.opus"} {
+		if record, err := tracker.GetRecordByPath("device\\" + name); err != nil || record.TargetPath != want {
+			t.Errorf("%s 的目标路径 = %+v, %v，期望 %s", name, record, err, want)
+		}
+	}
+}
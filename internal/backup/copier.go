@@ -2,6 +2,8 @@ package backup
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,12 +16,23 @@ import (
 	"github.com/allanpk716/record_center/internal/device"
 	"github.com/allanpk716/record_center/internal/logger"
 	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/internal/store"
 	"github.com/allanpk716/record_center/pkg/utils"
 )
 
 const (
 	// DefaultBufferSize 默认文件复制缓冲区大小 (64KB)
 	DefaultBufferSize = 64 * 1024
+	// ResumeSaveEvery 断点信息至少每隔该时长保存一次，慢速设备上未达到 resume_interval 时也能记录进度
+	ResumeSaveEvery = 10 * time.Second
+	// SkipReasonEncrypted 加密录音的跳过原因
+	SkipReasonEncrypted = "加密文件"
+	// SkipReasonTargetExists 目标存储中已存在同样大小文件的跳过原因
+	SkipReasonTargetExists = "目标文件已存在"
+	// DeepVerifyBlockSize 深度校验时逐块比对的块大小 (1MB)
+	DeepVerifyBlockSize = 1024 * 1024
+	// DeepVerifyMaxRetries 深度校验不一致时重新复制的最大次数
+	DeepVerifyMaxRetries = 2
 )
 
 // CopyResult 复制结果
@@ -34,20 +47,61 @@ type CopyResult struct {
 	SkipReason    string
 }
 
+// BackupRecorder 备份记录接口，FileCopier 通过它查询和写入备份记录
+type BackupRecorder interface {
+	IsFileBackedUp(sourcePath string) (bool, *storage.BackupRecord, error)
+	IsHashBackedUp(fileHash string) (bool, *storage.BackupRecord)
+	AddRecord(sourcePath, targetPath, deviceID string, fileSize int64, fileHash string) error
+	AddRecordWithVerify(sourcePath, targetPath, deviceID string, fileSize int64, fileHash string, integrityCheck bool, hashAlgorithm string) error
+	SetRecordMetadata(sourcePath string, meta *utils.OpusMeta) error
+	SetRecordVersions(sourcePath string, versions []string) error
+	SetTargetHash(sourcePath, fileHash string, modTime time.Time, size int64) error
+	SetContentHash(sourcePath, contentHash string) error
+	RecordsByContent(contentHash string) []storage.BackupRecord
+	GetRecordByPath(sourcePath string) (*storage.BackupRecord, error)
+}
+
 // FileCopier 文件复制器
 type FileCopier struct {
 	config        *config.Config
 	log           *logger.Logger
-	tracker       *storage.BackupTracker
+	tracker       BackupRecorder
 	device        *device.DeviceInfo
-	semaphore     chan struct{} // 用于限制并发数
+	semaphore     chan struct{} // 用于限制单设备并发数
+	globalSem     *SharedSemaphore // 多设备共享的全局并发资源池，nil表示不限制
+	copyFunc      func(file *utils.FileInfo, force bool) *CopyResult // 实际执行单文件复制的函数
+	hashFile      func(path string) (string, error) // 计算目标文件哈希的函数
 	resumeManager *ResumeManager // 断点续传管理器
 	mtpAccessor   *device.MTPAccessor // MTP设备访问器
 	psAccessor    *device.PowerShellMTPAccessor // PowerShell MTP访问器
+	deviceAccessor device.MTPInterface // 注入的设备访问接口，设置后只通过它读取设备文件
+	archive       *ArchiveWriter // zip归档写入器，nil表示复制为松散文件
+	store         store.TargetStore // 备份目标存储
+	pathTemplate  *PathTemplate // 按录音时间分类的目录模板，nil表示不分类
+	openStream    func(file *utils.FileInfo) (io.ReadCloser, error) // 打开设备文件流，用于归档模式
+	batchCopier   device.BatchCopier // 批量复制器，batch_copy 开启时在一个PowerShell会话中预先复制
+	prefetched    map[string]prefetchedFile // 批量复制已完成的文件（源路径 -> 暂存文件）
+	speedBaseline float64            // 历史平均复制速度（字节/秒），0表示不检测速度异常
+	prefetchMutex sync.Mutex
+	consecutiveFailures int        // 连续复制失败的文件数，达到 reset_after_failures 时复位设备
+	quota         *QuotaTracker // 每日配额，nil表示不限制
+	limiter       *RateLimiter  // 时段限速，nil表示不限速
+	progress      func(file *utils.FileInfo, copied int64) // 上报单个文件已写入的字节数（含续传前已有的部分），nil表示不上报
+	fileTimeout   FileTimeout // 单文件复制超时，零值表示不限制
+	timeoutGrace  time.Duration // 超时取消后等待复制退出的时长，超过后放弃该复制
+	preCopy       *PreCopyCheck // 复制前校验命令，nil表示不校验
+	fileContexts  sync.Map    // 处于超时控制下的文件（源路径 -> context），打开的文件流在超时后被关闭
+	checked       sync.Map    // 通过复制前校验的文件（源路径 -> 临时导出文件），复制时从该文件读取，不再从设备下载
+	released      sync.Map    // 内容寻址布局下不再被记录指向的旧内容（内容哈希 -> 路径），复制结束后回收
+	clock         utils.Clock  // 复制耗时、断点保存间隔取自该时钟
+	random        utils.Random // 生成临时文件名的随机源
+	resetMutex    sync.Mutex
+	deviceUse     sync.RWMutex // 复制文件期间持有读锁，复位设备时持有写锁，等待进行中的复制结束
+	reconnect     func() (device.MTPInterface, error) // 访问接口来自连接池时，复位设备通过连接池换用新连接
 }
 
 // NewFileCopier 创建新的文件复制器
-func NewFileCopier(cfg *config.Config, log *logger.Logger, tracker *storage.BackupTracker, deviceInfo *device.DeviceInfo) *FileCopier {
+func NewFileCopier(cfg *config.Config, log *logger.Logger, tracker BackupRecorder, deviceInfo *device.DeviceInfo) *FileCopier {
 	maxConcurrent := cfg.Backup.MaxConcurrent
 	if maxConcurrent <= 0 {
 		maxConcurrent = 1
@@ -56,8 +110,7 @@ func NewFileCopier(cfg *config.Config, log *logger.Logger, tracker *storage.Back
 	var resumeManager *ResumeManager
 	if cfg.Backup.EnableResume {
 		// 初始化断点续传管理器
-		resumePath := filepath.Join("data", "resume")
-		resumeManager = NewResumeManager(resumePath, cfg.Backup.TempDir, log)
+		resumeManager = NewResumeManager(ResumePath, cfg.Backup.TempDir, log)
 
 		// 清理过期的断点信息
 		if maxAge, err := utils.ParseDuration(cfg.Backup.ResumeMaxAge); err == nil {
@@ -77,7 +130,7 @@ func NewFileCopier(cfg *config.Config, log *logger.Logger, tracker *storage.Back
 		log.Warn("PowerShell MTP访问器创建失败，将使用基本MTP访问器")
 	}
 
-	return &FileCopier{
+	fc := &FileCopier{
 		config:        cfg,
 		log:           log,
 		tracker:       tracker,
@@ -86,7 +139,108 @@ func NewFileCopier(cfg *config.Config, log *logger.Logger, tracker *storage.Back
 		resumeManager: resumeManager,
 		mtpAccessor:   mtpAccessor,
 		psAccessor:    psAccessor,
+		clock:         utils.SystemClock,
+		random:        utils.SystemRandom,
+	}
+	fc.copyFunc = fc.CopyFile
+	fc.hashFile = fc.calculateTargetHash
+	fc.openStream = fc.openDeviceStream
+
+	fileTimeout, err := ParseFileTimeout(&cfg.Backup)
+	if err != nil {
+		log.Error("解析单文件超时失败，不限制单文件复制时间: %v", err)
+	}
+	fc.fileTimeout = fileTimeout
+	fc.timeoutGrace = DefaultFileTimeoutGrace
+
+	preCopy, err := NewPreCopyCheck(&cfg.Backup)
+	if err != nil {
+		log.Error("解析复制前校验命令失败，不执行校验: %v", err)
+	}
+	fc.preCopy = preCopy
+
+	targetStore, err := store.New(&cfg.Target)
+	if err != nil {
+		log.Error("创建目标存储失败，使用本地目录: %v", err)
+		targetStore = store.NewLocalStore(cfg.Target.BaseDirectory)
 	}
+	fc.store = targetStore
+
+	if cfg.Target.PathTemplate != "" {
+		pathTemplate, err := NewPathTemplate(cfg.Target.PathTemplate, cfg.Target.DayParts)
+		if err != nil {
+			log.Error("解析目录模板失败，不按时间分类: %v", err)
+		} else {
+			if deviceInfo != nil {
+				pathTemplate.SetDevice(deviceInfo.DisplayName(cfg))
+			}
+			fc.pathTemplate = pathTemplate
+		}
+	}
+	if psAccessor != nil {
+		fc.batchCopier = psAccessor
+	}
+
+	return fc
+}
+
+// SetMaxConcurrent 设置单设备并发复制数，小于1时按1处理，需在 CopyFiles 之前调用
+func (fc *FileCopier) SetMaxConcurrent(n int) {
+	if n < 1 {
+		n = 1
+	}
+	fc.semaphore = make(chan struct{}, n)
+}
+
+// SetGlobalSemaphore 注入多设备共享的全局并发资源池
+func (fc *FileCopier) SetGlobalSemaphore(sem *SharedSemaphore) {
+	fc.globalSem = sem
+}
+
+// SetMTPInterface 设置设备访问接口，设置后文件流只从该接口读取，不使用批量复制和模拟复制
+func (fc *FileCopier) SetMTPInterface(mtp device.MTPInterface) {
+	fc.deviceAccessor = mtp
+	fc.openStream = func(file *utils.FileInfo) (io.ReadCloser, error) {
+		return mtp.GetFileStream(file.Path)
+	}
+	fc.batchCopier = nil
+}
+
+// SetDeviceReconnect 设置复位设备时获取新访问接口的函数，访问接口来自连接池时使用，避免断开其他备份共用的连接
+func (fc *FileCopier) SetDeviceReconnect(reconnect func() (device.MTPInterface, error)) {
+	fc.reconnect = reconnect
+}
+
+// SetClock 替换复制器和断点续传管理器的时钟与随机源，测试时注入假时钟和固定种子的随机源
+func (fc *FileCopier) SetClock(clock utils.Clock, random utils.Random) {
+	fc.clock = clock
+	fc.random = random
+	if fc.resumeManager != nil {
+		fc.resumeManager.SetClock(clock, random)
+	}
+}
+
+// SetProgressFunc 设置字节进度回调，copied 为该文件累计已写入的字节数，断点续传时从断点处开始
+// 多个文件并发复制时会从不同 goroutine 调用
+func (fc *FileCopier) SetProgressFunc(progress func(file *utils.FileInfo, copied int64)) {
+	fc.progress = progress
+}
+
+// reportProgress 上报文件的字节进度
+func (fc *FileCopier) reportProgress(file *utils.FileInfo, copied int64) {
+	if fc.progress != nil {
+		fc.progress(file, copied)
+	}
+}
+
+// SetArchiveWriter 设置zip归档写入器，设置后文件写入归档条目而非松散文件
+func (fc *FileCopier) SetArchiveWriter(archive *ArchiveWriter) {
+	fc.archive = archive
+}
+
+// SetTargetStore 设置备份目标存储
+func (fc *FileCopier) SetTargetStore(targetStore store.TargetStore) {
+	fc.store = targetStore
 }
 
 // CopyFiles 复制多个文件（支持取消操作）
@@ -94,17 +248,51 @@ func (fc *FileCopier) CopyFiles(ctx context.Context, files []*utils.FileInfo, fo
 	resultChan := make(chan *CopyResult, len(files))
 
 	go func() {
+		// 工作 goroutine 中的 panic 同样转储崩溃日志
+		defer fc.log.RecoverPanic()
+
+		// 批处理模式下先用单个会话复制所有文件，失败的文件再逐个复制
+		if fc.config.Backup.BatchCopy {
+			fc.prefetchBatch(ctx, files, force)
+		}
+
+		// 按 copy_order 排序，每个文件轮到自己后才争取名额，排在前面的文件先开始复制
+		ordered := SortForCopy(files, fc.config.Backup.CopyOrder)
+		turns := make([]chan struct{}, len(ordered)+1)
+		for i := range turns {
+			turns[i] = make(chan struct{})
+		}
+		close(turns[0])
+
 		var wg sync.WaitGroup
-		wg.Add(len(files))
+		wg.Add(len(ordered))
 
-		for _, file := range files {
-			go func(f *utils.FileInfo) {
+		for i, file := range ordered {
+			go func(i int, f *utils.FileInfo) {
+				defer fc.log.RecoverPanic()
 				defer wg.Done()
 
+				select {
+				case <-turns[i]:
+				case <-ctx.Done():
+				}
+
 				// 检查 context 是否已取消
 				select {
 				case fc.semaphore <- struct{}{}:
 					defer func() { <-fc.semaphore }()
+					close(turns[i+1])
+
+					// 单设备名额之外，还需获取全局名额
+					if err := fc.globalSem.Acquire(ctx); err != nil {
+						resultChan <- &CopyResult{
+							File:    f,
+							Success: false,
+							Error:   err,
+						}
+						return
+					}
+					defer fc.globalSem.Release()
 
 					select {
 					case <-ctx.Done():
@@ -116,8 +304,22 @@ func (fc *FileCopier) CopyFiles(ctx context.Context, files []*utils.FileInfo, fo
 						}
 						return
 					default:
-						// 正常执行复制
-						result := fc.CopyFile(f, force)
+						// 当日配额用完时延后到次日
+						if err := fc.quota.Wait(ctx); err != nil {
+							resultChan <- &CopyResult{
+								File:    f,
+								Success: false,
+								Error:   err,
+							}
+							return
+						}
+
+						// 正常执行复制，复位设备期间等待复位完成
+						fc.deviceUse.RLock()
+						result := fc.copyWithTimeout(f, force)
+						fc.deviceUse.RUnlock()
+						fc.quota.Add(result.BytesCopied)
+						fc.trackDeviceFailures(result)
 						resultChan <- result
 					}
 				case <-ctx.Done():
@@ -129,10 +331,11 @@ func (fc *FileCopier) CopyFiles(ctx context.Context, files []*utils.FileInfo, fo
 					}
 					return
 				}
-			}(file)
+			}(i, file)
 		}
 
 		wg.Wait()
+		fc.collectReleasedContent()
 		close(resultChan)
 	}()
 
@@ -141,7 +344,7 @@ func (fc *FileCopier) CopyFiles(ctx context.Context, files []*utils.FileInfo, fo
 
 // CopyFile 复制单个文件
 func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
-	startTime := time.Now()
+	startTime := fc.clock.Now()
 	result := &CopyResult{
 		File:        file,
 		Success:     false,
@@ -149,6 +352,22 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		Duration:    0,
 	}
 
+	// 加密录音无法使用，默认跳过
+	if file != nil && file.Encrypted {
+		result.Skipped = true
+		result.SkipReason = SkipReasonEncrypted
+		fc.log.Debug("跳过加密文件: %s", file.RelativePath)
+		return result
+	}
+
+	// 设备生成的垃圾文件即使扩展名匹配也不复制
+	if pattern, ok := matchStripPattern(fc.config.Backup.StripPatterns, file); ok {
+		result.Skipped = true
+		result.SkipReason = SkipReasonStripped
+		fc.log.Debug("跳过设备垃圾文件: %s, 匹配规则 %s", file.RelativePath, pattern)
+		return result
+	}
+
 	// 验证文件
 	if err := fc.validateFile(file); err != nil {
 		result.Error = fmt.Errorf("文件验证失败: %w", err)
@@ -166,6 +385,35 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		}
 	}
 
+	// 复制前确认源文件仍在设备上，复制失败时区分源文件在复制中被删除和普通失败
+	if fc.sourceVanished(file, false) {
+		return fc.skipVanished(result)
+	}
+
+	// 配置了复制前校验命令时，先临时导出文件交给命令检查，被拒绝的文件不复制
+	if !fc.checkBeforeCopy(file, result) {
+		return result
+	}
+	defer fc.releaseChecked(file)
+	// 复制失败时放回暂存的旧版本，在清理源文件消失留下的不完整目标之后执行
+	var previousVersion string
+	defer func() {
+		if !result.Success {
+			fc.restorePreviousVersion(previousVersion, result.TargetPath)
+		}
+	}()
+	defer fc.classifyVanished(result)
+
+	// 归档模式下直接写入zip条目
+	if fc.archive != nil {
+		return fc.copyToArchive(file, result, startTime)
+	}
+
+	// 非本地目标（如S3）通过存储接口流式写入
+	if fc.isRemoteStore() {
+		return fc.copyToStore(file, force, result, startTime)
+	}
+
 	// 获取目标路径
 	targetPath, err := fc.getTargetPath(file)
 	if err != nil {
@@ -180,10 +428,19 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		return result
 	}
 
+	// 开启保留版本时先暂存已存在的旧目标，复制成功后按内容决定是否保留为历史版本
+	// 内容寻址布局下内容不同即是不同的文件，不需要保留版本
+	if !fc.contentAddressed() {
+		previousVersion = fc.holdPreviousVersion(targetPath)
+	}
+
+	// 已存在的目标可能被上次备份设为只读，覆盖前先清除
+	fc.clearTargetReadOnly(targetPath)
+
 	// 执行复制
 	copiedBytes, err := fc.copyFileInternal(file, targetPath)
 	result.BytesCopied = copiedBytes
-	result.Duration = time.Since(startTime)
+	result.Duration = fc.clock.Now().Sub(startTime)
 
 	if err != nil {
 		result.Error = fmt.Errorf("文件复制失败: %w", err)
@@ -198,15 +455,41 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		return result
 	}
 
+	// 深度校验：从设备重新读取源文件与本地目标逐块比对
+	if fc.config.Backup.DeepVerify {
+		copiedBytes, err = fc.deepVerifyWithRetry(file, targetPath, copiedBytes)
+		result.BytesCopied = copiedBytes
+		result.Duration = fc.clock.Now().Sub(startTime)
+		if err != nil {
+			result.Error = fmt.Errorf("深度校验失败: %w", err)
+			fc.log.Error("深度校验失败: %s, %v", file.RelativePath, err)
+			return result
+		}
+	}
+
+	// 内容寻址布局下把暂存文件按内容哈希移入分桶目录，相同内容只保留一份
+	contentHash := ""
+	if fc.contentAddressed() {
+		stagingPath := targetPath
+		targetPath, contentHash, err = fc.storeByContent(stagingPath, file)
+		if err != nil {
+			result.Error = fmt.Errorf("按内容存放失败: %w", err)
+			fc.log.Error("按内容存放失败: %s, %v", file.RelativePath, err)
+			return result
+		}
+		result.TargetPath = targetPath
+	}
+
+	// 新目标已就绪，暂存的旧目标内容不同时保留为历史版本
+	versions := fc.rotateVersions(previousVersion, targetPath)
+	previousVersion = ""
+
 	// 计算文件哈希并验证完整性
 	fileHash := ""
 	integrityVerified := false
 	if fc.config.Backup.IntegrityCheck {
-		// 创建完整性验证器
-		verifier := NewIntegrityVerifier(fc.log, fc.config.Backup.HashAlgorithm)
-
 		// 计算目标文件哈希
-		hash, err := verifier.CalculateFileHash(targetPath)
+		hash, err := fc.hashFile(targetPath)
 		if err != nil {
 			fc.log.Warn("计算文件哈希失败: %s, %v", targetPath, err)
 		} else {
@@ -217,7 +500,7 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		}
 	} else if fc.config.Backup.SkipExisting {
 		// 保留原有的哈希计算逻辑（向后兼容）
-		hash, err := utils.CalculateFileHash(targetPath)
+		hash, err := fc.hashFile(targetPath)
 		if err != nil {
 			fc.log.Warn("计算文件哈希失败: %s, %v", targetPath, err)
 		} else {
@@ -225,6 +508,12 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		}
 	}
 
+	// 内容寻址布局下记录改指向新内容前取出旧记录，旧内容在复制结束后不再被引用时回收
+	var previousRecord *storage.BackupRecord
+	if contentHash != "" {
+		previousRecord, _ = fc.tracker.GetRecordByPath(file.Path)
+	}
+
 	// 添加备份记录
 	if fc.config.Backup.IntegrityCheck {
 		if err := fc.tracker.AddRecordWithVerify(file.Path, targetPath, fc.device.DeviceID, file.Size, fileHash, integrityVerified, fc.config.Backup.HashAlgorithm); err != nil {
@@ -236,6 +525,31 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 		}
 	}
 
+	if contentHash != "" {
+		if err := fc.tracker.SetContentHash(file.Path, contentHash); err != nil {
+			fc.log.Warn("记录内容哈希失败: %s, %v", file.RelativePath, err)
+		} else {
+			fc.releaseContent(previousRecord, contentHash)
+		}
+	}
+
+	if fc.config.Backup.KeepVersions > 0 {
+		if err := fc.tracker.SetRecordVersions(file.Path, versions); err != nil {
+			fc.log.Warn("记录历史版本失败: %s, %v", file.RelativePath, err)
+		}
+	}
+
+	// 提取音频元数据，失败不影响备份结果
+	fc.recordAudioMetadata(file, targetPath)
+
+	// 在目标文件旁写入元数据文件，失败不影响备份结果；内容寻址布局下多个原名共用一个文件，元数据只保存在备份记录中
+	if fc.config.Backup.WriteSidecar && !fc.contentAddressed() {
+		fc.writeSidecar(file, targetPath)
+	}
+
+	// 按配置设置目标文件权限和只读属性，失败不影响备份结果
+	fc.applyTargetAttributes(targetPath)
+
 	result.Success = true
 	result.BytesCopied = copiedBytes
 
@@ -255,6 +569,281 @@ func (fc *FileCopier) CopyFile(file *utils.FileInfo, force bool) *CopyResult {
 	return result
 }
 
+// deepVerifyWithRetry 深度校验目标文件，不一致时重新复制，返回最终复制的字节数
+func (fc *FileCopier) deepVerifyWithRetry(file *utils.FileInfo, targetPath string, copiedBytes int64) (int64, error) {
+	for attempt := 0; ; attempt++ {
+		err := fc.deepVerify(file, targetPath)
+		if err == nil {
+			fc.log.Debug("深度校验通过: %s", file.RelativePath)
+			return copiedBytes, nil
+		}
+		if attempt >= DeepVerifyMaxRetries {
+			return copiedBytes, err
+		}
+
+		fc.log.Warn("深度校验不一致，重新复制 (%d/%d): %s, %v", attempt+1, DeepVerifyMaxRetries, file.RelativePath, err)
+		os.Remove(targetPath)
+
+		copiedBytes, err = fc.copyFileInternal(file, targetPath)
+		if err != nil {
+			return copiedBytes, fmt.Errorf("重新复制失败: %w", err)
+		}
+		if err := fc.verifyCopy(file, targetPath, copiedBytes); err != nil {
+			return copiedBytes, fmt.Errorf("重新复制后验证失败: %w", err)
+		}
+	}
+}
+
+// deepVerify 从设备重新流式读取源文件，与本地目标逐块比对哈希
+func (fc *FileCopier) deepVerify(file *utils.FileInfo, targetPath string) error {
+	source, err := fc.openFileStream(file)
+	if err != nil {
+		return fmt.Errorf("打开设备文件流失败: %w", err)
+	}
+	defer source.Close()
+
+	target, err := os.Open(targetPath)
+	if err != nil {
+		return fmt.Errorf("打开目标文件失败: %w", err)
+	}
+	defer target.Close()
+
+	sourceBlock := make([]byte, DeepVerifyBlockSize)
+	targetBlock := make([]byte, DeepVerifyBlockSize)
+	for block := 0; ; block++ {
+		sourceN, sourceErr := io.ReadFull(source, sourceBlock)
+		targetN, targetErr := io.ReadFull(target, targetBlock)
+
+		if sourceErr != nil && sourceErr != io.EOF && sourceErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("读取设备文件失败: %w", sourceErr)
+		}
+		if targetErr != nil && targetErr != io.EOF && targetErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("读取目标文件失败: %w", targetErr)
+		}
+
+		if sourceN != targetN {
+			return fmt.Errorf("第 %d 块长度不一致: 设备 %d 字节, 本地 %d 字节", block+1, sourceN, targetN)
+		}
+		if sha256.Sum256(sourceBlock[:sourceN]) != sha256.Sum256(targetBlock[:targetN]) {
+			return fmt.Errorf("第 %d 块内容不一致", block+1)
+		}
+
+		// 两侧同时读完
+		if sourceErr != nil {
+			return nil
+		}
+	}
+}
+
+// recordAudioMetadata 从已复制的本地文件提取Opus元数据并写入备份记录
+func (fc *FileCopier) recordAudioMetadata(file *utils.FileInfo, targetPath string) {
+	if !utils.IsOpusFile(targetPath) {
+		return
+	}
+
+	meta, err := utils.ExtractOpusMetadata(targetPath)
+	if err != nil {
+		fc.log.Debug("提取音频元数据失败: %s, %v", file.RelativePath, err)
+		return
+	}
+
+	if err := fc.tracker.SetRecordMetadata(file.Path, meta); err != nil {
+		fc.log.Debug("保存音频元数据失败: %s, %v", file.RelativePath, err)
+		return
+	}
+	fc.log.Debug("音频元数据: %s, %s, %d Hz", file.RelativePath, meta.ChannelLayout(), meta.InputSampleRate)
+}
+
+// copyToArchive 将设备文件流直接写入zip归档条目，不落临时文件
+func (fc *FileCopier) copyToArchive(file *utils.FileInfo, result *CopyResult, startTime time.Time) *CopyResult {
+	stream, err := fc.openFileStream(file)
+	if err != nil {
+		result.Error = fmt.Errorf("打开设备文件流失败: %w", err)
+		fc.log.Error("打开设备文件流失败: %s, %v", file.RelativePath, err)
+		return result
+	}
+	defer stream.Close()
+	stream = fc.monitorStream(file, stream, false)
+
+	copiedBytes, fileHash, entryPath, err := fc.archive.WriteEntry(fc.getArchiveEntryName(file), stream, file.ModTime)
+	result.BytesCopied = copiedBytes
+	result.Duration = fc.clock.Now().Sub(startTime)
+	result.TargetPath = entryPath
+
+	if err != nil {
+		result.Error = fmt.Errorf("写入归档失败: %w", err)
+		fc.log.Error("写入归档失败: %s, %v", file.RelativePath, err)
+		return result
+	}
+
+	if file.Size > 0 && copiedBytes != file.Size {
+		result.Error = fmt.Errorf("复制验证失败: 文件大小不匹配: 期望 %d, 实际 %d", file.Size, copiedBytes)
+		fc.log.Error("复制验证失败: %s, %v", file.RelativePath, result.Error)
+		return result
+	}
+
+	// 归档条目的哈希在写入时以SHA256计算
+	if fc.config.Backup.IntegrityCheck {
+		if err := fc.tracker.AddRecordWithVerify(file.Path, entryPath, fc.device.DeviceID, file.Size, fileHash, true, "sha256"); err != nil {
+			fc.log.Warn("添加备份记录失败: %s, %v", file.RelativePath, err)
+		}
+	} else {
+		if err := fc.tracker.AddRecord(file.Path, entryPath, fc.device.DeviceID, file.Size, fileHash); err != nil {
+			fc.log.Warn("添加备份记录失败: %s, %v", file.RelativePath, err)
+		}
+	}
+
+	result.Success = true
+	fc.log.Info("文件已写入归档: %s -> %s (%s, 耗时: %s)",
+		file.RelativePath, entryPath,
+		utils.FormatBytes(copiedBytes),
+		utils.FormatDuration(result.Duration))
+	return result
+}
+
+// copyToStore 将设备文件流通过存储接口写入非本地目标
+// 此类目标不支持断点续传和批量复制，已存在同样大小的文件时跳过
+func (fc *FileCopier) copyToStore(file *utils.FileInfo, force bool, result *CopyResult, startTime time.Time) *CopyResult {
+	relPath := fc.getRelativeTargetPath(file)
+	location := fc.store.Location(relPath)
+	result.TargetPath = location
+
+	if !force && fc.config.Backup.SkipExisting {
+		if stat, err := fc.store.Stat(relPath); err == nil && (file.Size <= 0 || stat.Size == file.Size) {
+			result.Skipped = true
+			result.SkipReason = SkipReasonTargetExists
+			fc.log.Debug("跳过文件: %s, 原因: %s", file.RelativePath, SkipReasonTargetExists)
+			return result
+		}
+	}
+
+	stream, err := fc.openFileStream(file)
+	if err != nil {
+		result.Error = fmt.Errorf("打开设备文件流失败: %w", err)
+		fc.log.Error("打开设备文件流失败: %s, %v", file.RelativePath, err)
+		return result
+	}
+	defer stream.Close()
+	stream = fc.monitorStream(file, stream, false)
+
+	hasher := sha256.New()
+	counter := &countingReader{r: io.TeeReader(stream, hasher)}
+	err = fc.store.Write(relPath, counter)
+	result.BytesCopied = counter.count
+	result.Duration = fc.clock.Now().Sub(startTime)
+
+	if err != nil {
+		result.Error = fmt.Errorf("写入目标存储失败: %w", err)
+		fc.log.Error("写入目标存储失败: %s -> %s, %v", file.RelativePath, location, err)
+		return result
+	}
+
+	if file.Size > 0 && counter.count != file.Size {
+		result.Error = fmt.Errorf("复制验证失败: 文件大小不匹配: 期望 %d, 实际 %d", file.Size, counter.count)
+		fc.log.Error("复制验证失败: %s, %v", file.RelativePath, result.Error)
+		return result
+	}
+
+	// 与归档模式相同，哈希在写入时以SHA256计算
+	fileHash := fmt.Sprintf("%x", hasher.Sum(nil))
+	if fc.config.Backup.IntegrityCheck {
+		if err := fc.tracker.AddRecordWithVerify(file.Path, location, fc.device.DeviceID, file.Size, fileHash, true, "sha256"); err != nil {
+			fc.log.Warn("添加备份记录失败: %s, %v", file.RelativePath, err)
+		}
+	} else {
+		if err := fc.tracker.AddRecord(file.Path, location, fc.device.DeviceID, file.Size, fileHash); err != nil {
+			fc.log.Warn("添加备份记录失败: %s, %v", file.RelativePath, err)
+		}
+	}
+
+	result.Success = true
+	fc.log.Info("文件已写入目标存储: %s -> %s (%s, 耗时: %s)",
+		file.RelativePath, location,
+		utils.FormatBytes(counter.count),
+		utils.FormatDuration(result.Duration))
+	return result
+}
+
+// isRemoteStore 目标存储是否不在本地文件系统上
+func (fc *FileCopier) isRemoteStore() bool {
+	if fc.store == nil {
+		return false
+	}
+	_, ok := fc.store.(store.LocalPather)
+	return !ok
+}
+
+// targetRelPath 本地目标路径相对目标存储根目录的路径
+func (fc *FileCopier) targetRelPath(targetPath string) string {
+	if relPath, err := filepath.Rel(fc.targetBaseDir(), targetPath); err == nil {
+		return relPath
+	}
+	return filepath.Base(targetPath)
+}
+
+// writeTarget 通过目标存储把 r 的全部内容写入目标路径，写入失败时不留下写了一半的目标
+func (fc *FileCopier) writeTarget(targetPath string, r io.Reader) error {
+	return fc.store.Write(fc.targetRelPath(targetPath), r)
+}
+
+// importTarget 通过目标存储把写好的本地暂存文件存入目标路径，成功后暂存文件不再存在
+func (fc *FileCopier) importTarget(targetPath, localPath string) error {
+	return store.Put(fc.store, fc.targetRelPath(targetPath), localPath)
+}
+
+// stagingFile 需要先写成本地文件（按偏移写入、批量复制等）时使用的暂存路径，写完后通过 importTarget 存入目标
+func (fc *FileCopier) stagingFile(file *utils.FileInfo) string {
+	return utils.TempFilePath(utils.UniqueName(fc.clock, fc.random, file.Name))
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r     io.Reader
+	count int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.count += int64(n)
+	return n, err
+}
+
+// getArchiveEntryName 获取文件在归档中的条目名称
+func (fc *FileCopier) getArchiveEntryName(file *utils.FileInfo) string {
+	return fc.getRelativeTargetPath(file)
+}
+
+// getRelativeTargetPath 获取文件相对目标根目录的路径，本地、归档和存储目标共用
+// 按扩展名的规则：先按模板重命名，子目录放在最外层，目录模板的分类目录位于其下
+func (fc *FileCopier) getRelativeTargetPath(file *utils.FileInfo) string {
+	relativePath := file.Name
+	if fc.config.Backup.PreserveStructure && file.RelativePath != "" {
+		// 保留目录结构
+		relativePath = file.RelativePath
+	}
+	rule, hasRule := fc.config.Backup.TypeRuleFor(file.Name)
+	if hasRule {
+		relativePath = renameByTypeRule(rule, file, relativePath)
+	}
+	relativePath = fc.withTemplateDir(file, relativePath)
+	if hasRule && rule.Directory != "" {
+		relativePath = strings.Trim(rule.Directory, "/\\") + "/" + relativePath
+	}
+	return relativePath
+}
+
+// openDeviceStream 通过PowerShell访问器打开设备文件流，文件处于单文件超时控制下时超时会结束PowerShell进程
+func (fc *FileCopier) openDeviceStream(file *utils.FileInfo) (io.ReadCloser, error) {
+	if fc.psAccessor == nil {
+		return nil, fmt.Errorf("PowerShell MTP访问器不可用")
+	}
+	stream, err := fc.psAccessor.OpenFileStreamContext(fc.fileContext(file), file.Path)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
 // validateFile 验证文件
 func (fc *FileCopier) validateFile(file *utils.FileInfo) error {
 	if file == nil {
@@ -287,9 +876,23 @@ func (fc *FileCopier) shouldSkipFile(file *utils.FileInfo) (bool, string) {
 			return false, ""
 		}
 
+		if backedUp && record.SourceChanged(file) {
+			fc.log.Info("文件在上次备份后被修改，重新复制: %s", file.RelativePath)
+			return false, ""
+		}
 		if backedUp && record != nil {
+			// 开启完整性验证时确认备份文件未被损坏，损坏则重新复制
+			if fc.config.Backup.IntegrityCheck && !fc.verifyBackedUpTarget(record) {
+				return false, ""
+			}
 			return true, "文件已备份"
 		}
+
+		// 枚举阶段预计算了哈希时，内容相同的文件即使改名也视为已备份
+		if backedUp, record := fc.tracker.IsHashBackedUp(file.Hash); backedUp && record != nil {
+			fc.log.Debug("文件内容已备份: %s (与 %s 相同)", file.RelativePath, record.SourcePath)
+			return true, "文件内容已备份"
+		}
 	}
 
 	return false, ""
@@ -297,27 +900,163 @@ func (fc *FileCopier) shouldSkipFile(file *utils.FileInfo) (bool, string) {
 
 // getTargetPath 获取目标路径
 func (fc *FileCopier) getTargetPath(file *utils.FileInfo) (string, error) {
-	if !fc.config.Backup.PreserveStructure {
-		return filepath.Join(fc.config.Target.BaseDirectory, file.Name), nil
+	// 内容寻址布局下先复制到暂存目录，内容哈希确定后才知道最终路径
+	if fc.contentAddressed() {
+		return fc.stagingPath(file), nil
 	}
+	relativePath := fc.getRelativeTargetPath(file)
 
-	// 保留目录结构
-	relativePath := strings.ReplaceAll(file.RelativePath, "\\", string(filepath.Separator))
+	if localStore, ok := fc.store.(store.LocalPather); ok {
+		return fc.foldTargetCase(localStore.LocalPath(""), localStore.LocalPath(relativePath)), nil
+	}
+	relativePath = strings.ReplaceAll(relativePath, "\\", string(filepath.Separator))
 	targetPath := filepath.Join(fc.config.Target.BaseDirectory, relativePath)
-	return targetPath, nil
+	return fc.foldTargetCase(fc.config.Target.BaseDirectory, targetPath), nil
+}
+
+// withTemplateDir 在相对路径前加上目录模板展开后的分类目录
+func (fc *FileCopier) withTemplateDir(file *utils.FileInfo, relativePath string) string {
+	dir := fc.pathTemplate.Expand(file)
+	if dir == "" {
+		return relativePath
+	}
+	return dir + "/" + relativePath
 }
 
 // ensureTargetDirectory 确保目标目录存在
 func (fc *FileCopier) ensureTargetDirectory(targetPath string) error {
-	if fc.config.Target.CreateSubdirs {
+	if fc.config.Target.CreateSubdirs || fc.contentAddressed() {
 		dir := filepath.Dir(targetPath)
 		return utils.EnsureDir(dir)
 	}
 	return utils.EnsureDir(fc.config.Target.BaseDirectory)
 }
 
+// applyTargetAttributes 按 target.file_mode 和 target.read_only 设置复制完成的目标文件
+func (fc *FileCopier) applyTargetAttributes(targetPath string) {
+	if fc.config.Target.FileMode == "" && !fc.config.Target.ReadOnly {
+		return
+	}
+	mode, err := utils.ParseFileMode(fc.config.Target.FileMode)
+	if err != nil {
+		fc.log.Warn("%v", err)
+		return
+	}
+	if err := utils.ApplyFileAttributes(targetPath, mode, fc.config.Target.ReadOnly); err != nil {
+		fc.log.Warn("设置目标文件属性失败: %s, %v", targetPath, err)
+	}
+}
+
+// clearTargetReadOnly 清除已存在目标文件的只读属性，使其可以被覆盖
+func (fc *FileCopier) clearTargetReadOnly(targetPath string) {
+	if err := utils.ClearReadOnly(targetPath); err != nil {
+		fc.log.Warn("清除目标文件只读属性失败: %s, %v", targetPath, err)
+	}
+}
+
+// prefetchBatch 通过批量复制器把需要复制的文件一次性复制到目标路径
+func (fc *FileCopier) prefetchBatch(ctx context.Context, files []*utils.FileInfo, force bool) {
+	if fc.batchCopier == nil || fc.archive != nil {
+		return
+	}
+	if fc.isRemoteStore() {
+		return
+	}
+	// 批量复制直接写入目标，绕过复制前校验，配置了校验命令时逐个复制
+	if fc.preCopy != nil {
+		return
+	}
+
+	var items []device.CopyItem
+	for _, file := range files {
+		if file == nil || file.Encrypted || fc.validateFile(file) != nil {
+			continue
+		}
+		if !force {
+			if skip, _ := fc.shouldSkipFile(file); skip {
+				continue
+			}
+		}
+
+		// 批量复制写入暂存文件，逐个处理时再通过目标存储移入目标，保留版本等逻辑照常生效
+		items = append(items, device.CopyItem{SourcePath: file.Path, TargetPath: fc.stagingFile(file), Size: file.Size})
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	// 批量复制占用一个全局名额
+	if err := fc.globalSem.Acquire(ctx); err != nil {
+		return
+	}
+	defer fc.globalSem.Release()
+
+	fc.log.Info("批量复制 %d 个文件...", len(items))
+	results := fc.batchCopier.CopyFilesToLocal(items)
+
+	fc.prefetchMutex.Lock()
+	defer fc.prefetchMutex.Unlock()
+	if fc.prefetched == nil {
+		fc.prefetched = make(map[string]prefetchedFile)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+			os.Remove(result.Item.TargetPath)
+			fc.log.Debug("批量复制失败，稍后逐个复制: %s, %v", result.Item.SourcePath, result.Error)
+			continue
+		}
+		fc.prefetched[result.Item.SourcePath] = prefetchedFile{size: result.Size, path: result.Item.TargetPath}
+	}
+	if failed > 0 {
+		fc.log.Warn("批量复制有 %d 个文件失败，将逐个重新复制", failed)
+	}
+}
+
+// prefetchedFile 批量复制写好的暂存文件
+type prefetchedFile struct {
+	size int64
+	path string
+}
+
+// takePrefetched 取出批量复制已完成的文件，每个文件只使用一次
+func (fc *FileCopier) takePrefetched(sourcePath string) (prefetchedFile, bool) {
+	fc.prefetchMutex.Lock()
+	defer fc.prefetchMutex.Unlock()
+
+	staged, ok := fc.prefetched[sourcePath]
+	if ok {
+		delete(fc.prefetched, sourcePath)
+	}
+	return staged, ok
+}
+
 // copyFileInternal 内部复制方法
 func (fc *FileCopier) copyFileInternal(file *utils.FileInfo, targetPath string) (int64, error) {
+	// 批量复制已完成的文件直接进入校验流程；通过复制前校验的文件改从校验过的临时文件复制，
+	// 批量复制得到的内容不再使用
+	staged, prefetched := fc.takePrefetched(file.Path)
+	if _, checked := fc.checked.Load(file.Path); checked {
+		if prefetched {
+			os.Remove(staged.path)
+		}
+	} else {
+		if prefetched {
+			if err := fc.importTarget(targetPath, staged.path); err != nil {
+				os.Remove(staged.path)
+				return 0, fmt.Errorf("存入批量复制的文件失败: %w", err)
+			}
+			return staged.size, nil
+		}
+
+		// 大文件且设备支持按偏移读取时分片并行下载
+		if reader, ok := fc.rangeReaderFor(file); ok {
+			return fc.copyWithRanges(file, targetPath, reader)
+		}
+	}
+
 	// 如果启用了断点续传，使用支持断点续传的复制方法
 	if fc.config.Backup.EnableResume && fc.resumeManager != nil {
 		return fc.copyWithResume(file, targetPath)
@@ -329,6 +1068,11 @@ func (fc *FileCopier) copyFileInternal(file *utils.FileInfo, targetPath string)
 
 // copyWithNoResume 不支持断点续传的复制方法
 func (fc *FileCopier) copyWithNoResume(file *utils.FileInfo, targetPath string) (int64, error) {
+	// 注入了设备访问接口时只通过它读取，失败即返回错误
+	if fc.deviceAccessor != nil {
+		return fc.copyWithPowerShell(file, targetPath)
+	}
+
 	// 首先尝试使用PowerShell访问器
 	if fc.psAccessor != nil {
 		fc.log.Debug("尝试使用PowerShell从MTP设备复制文件: %s", file.Path)
@@ -343,7 +1087,9 @@ func (fc *FileCopier) copyWithNoResume(file *utils.FileInfo, targetPath string)
 	// 如果PowerShell不可用或失败，尝试基本MTP访问器
 	if fc.mtpAccessor != nil {
 		fc.log.Debug("尝试使用基本MTP访问器复制文件: %s", file.Path)
-		err := fc.mtpAccessor.CopyFromMTPDevice(file.Path, targetPath)
+		stagingPath := fc.stagingFile(file)
+		defer os.Remove(stagingPath)
+		err := fc.mtpAccessor.CopyFromMTPDevice(file.Path, stagingPath)
 		if err != nil {
 			fc.log.Warn("无法直接从MTP设备复制文件，使用模拟复制: %v", err)
 			// 如果无法直接从MTP设备复制，使用模拟复制
@@ -351,11 +1097,14 @@ func (fc *FileCopier) copyWithNoResume(file *utils.FileInfo, targetPath string)
 		}
 
 		// 获取复制后的文件大小以验证
-		if fileInfo, err := os.Stat(targetPath); err == nil {
-			return fileInfo.Size(), nil
+		fileInfo, err := os.Stat(stagingPath)
+		if err != nil {
+			return 0, fmt.Errorf("无法验证复制结果")
 		}
-
-		return 0, fmt.Errorf("无法验证复制结果")
+		if err := fc.importTarget(targetPath, stagingPath); err != nil {
+			return 0, err
+		}
+		return fileInfo.Size(), nil
 	}
 
 	// 如果所有访问器都不可用，使用模拟复制
@@ -363,59 +1112,40 @@ func (fc *FileCopier) copyWithNoResume(file *utils.FileInfo, targetPath string)
 	return fc.mockCopyFromDevice(file, targetPath)
 }
 
-// copyWithPowerShell 使用PowerShell从MTP设备复制文件
+// copyWithPowerShell 使用PowerShell从MTP设备复制文件，内容通过目标存储写入
+// 速度持续偏低且开启了 slow_reconnect 时，重新打开文件流从头复制
 func (fc *FileCopier) copyWithPowerShell(file *utils.FileInfo, targetPath string) (int64, error) {
-	// 打开PowerShell文件流
-	mtpStream, err := fc.psAccessor.OpenFileStream(file.Path)
+	// 打开设备文件流
+	mtpStream, err := fc.openFileStream(file)
 	if err != nil {
 		return 0, fmt.Errorf("打开PowerShell文件流失败: %w", err)
 	}
-	defer mtpStream.Close()
-
-	// 确保目标目录存在
-	targetDir := filepath.Dir(targetPath)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return 0, fmt.Errorf("创建目标目录失败: %w", err)
-	}
-
-	// 创建目标文件
-	targetFile, err := os.Create(targetPath)
-	if err != nil {
-		return 0, fmt.Errorf("创建目标文件失败: %w", err)
-	}
-	defer targetFile.Close()
-
-	// 复制文件内容
-	buffer := make([]byte, DefaultBufferSize) // 64KB缓冲区
-	var copied int64
-
-	for {
-		n, err := mtpStream.Read(buffer)
-		if n > 0 {
-			written, writeErr := targetFile.Write(buffer[:n])
-			copied += int64(written)
-
-			if writeErr != nil {
-				return copied, fmt.Errorf("写入目标文件失败: %w", writeErr)
-			}
-
-			// 确保写入的字节数等于读取的字节数
-			if written != n {
-				return copied, fmt.Errorf("写入字节数不匹配: 期望 %d, 实际 %d", n, written)
-			}
+	defer func() {
+		// 重连时会替换文件流，关闭最后打开的那个
+		if mtpStream != nil {
+			mtpStream.Close()
 		}
+	}()
 
-		if err == io.EOF {
-			break
+	for attempt := 0; ; attempt++ {
+		counter := &countingReader{r: fc.monitorStream(file, mtpStream, true)}
+		err := fc.writeTarget(targetPath, counter)
+		if err == nil {
+			fc.log.Debug("PowerShell复制完成: %s -> %s (%.2f MB)", file.Path, targetPath, float64(counter.count)/1024/1024)
+			return counter.count, nil
+		}
+		if !errors.Is(err, errSlowTransfer) || attempt >= SlowReconnectMaxAttempts {
+			return counter.count, err
 		}
 
-		if err != nil {
-			return copied, fmt.Errorf("从MTP流读取数据失败: %w", err)
+		// 写入失败时目标存储不会留下写了一半的文件，重新打开文件流后从头写入
+		fc.log.Warn("复制速度持续偏低，重新打开设备文件流 (%d/%d): %s", attempt+1, SlowReconnectMaxAttempts, file.RelativePath)
+		mtpStream.Close()
+		if mtpStream, err = fc.openFileStream(file); err != nil {
+			mtpStream = nil
+			return counter.count, fmt.Errorf("重新打开PowerShell文件流失败: %w", err)
 		}
 	}
-
-	fc.log.Debug("PowerShell复制完成: %s -> %s (%.2f MB)", file.Path, targetPath, float64(copied)/1024/1024)
-	return copied, nil
 }
 
 // copyWithResume 支持断点续传的复制方法
@@ -433,6 +1163,10 @@ func (fc *FileCopier) copyWithResume(file *utils.FileInfo, targetPath string) (i
 		fc.log.Warn("解析保存间隔失败，使用默认值5MB: %v", err)
 	}
 
+	// 复制期间标记为活跃，避免过期清理删除正在使用的断点
+	fc.resumeManager.MarkActive(file.Path)
+	defer fc.resumeManager.MarkInactive(file.Path)
+
 	// 获取断点信息
 	resumeInfo, err := fc.resumeManager.GetResumeInfo(file.Path)
 	if err != nil {
@@ -444,12 +1178,18 @@ func (fc *FileCopier) copyWithResume(file *utils.FileInfo, targetPath string) (i
 			CopiedBytes: 0,
 			TotalBytes:  file.Size,
 			ChunkSize:   chunkSize,
-			Metadata:    make(map[string]string),
+			Metadata:    map[string]string{ResumeMetaRelativePath: file.RelativePath},
+		}
+		if fc.device != nil {
+			resumeInfo.Metadata[ResumeMetaDeviceID] = fc.device.DeviceID
 		}
 	} else {
 		fc.log.Info("发现断点信息，从 %d 字节处继续: %s", resumeInfo.CopiedBytes, file.RelativePath)
 	}
 
+	// 断点前已复制的部分计入进度，进度不从0开始
+	fc.reportProgress(file, resumeInfo.CopiedBytes)
+
 	// 检查是否已经完成
 	if resumeInfo.CopiedBytes >= file.Size {
 		fc.log.Debug("文件已经完整复制: %s", file.RelativePath)
@@ -457,13 +1197,17 @@ func (fc *FileCopier) copyWithResume(file *utils.FileInfo, targetPath string) (i
 		if err := fc.finalizeResumeFile(resumeInfo, targetPath); err != nil {
 			return 0, fmt.Errorf("完成文件复制失败: %w", err)
 		}
+		fc.reportProgress(file, file.Size)
 		return file.Size, nil
 	}
 
 	// 执行断点续传复制
 	copiedBytes, err := fc.doResumeCopy(file, resumeInfo, targetPath, chunkSize, resumeInterval)
 	if err != nil {
-		// 保存当前进度
+		// 保存当前进度，设备中途断开时从已写入的位置继续
+		if copiedBytes > resumeInfo.CopiedBytes {
+			resumeInfo.CopiedBytes = copiedBytes
+		}
 		if saveErr := fc.resumeManager.SaveResumeInfo(resumeInfo); saveErr != nil {
 			fc.log.Error("保存断点信息失败: %v", saveErr)
 		}
@@ -481,7 +1225,7 @@ func (fc *FileCopier) copyWithResume(file *utils.FileInfo, targetPath string) (i
 // mockCopyFromDevice 模拟从设备复制文件（实际项目中需要替换为MTP实现）
 func (fc *FileCopier) mockCopyFromDevice(file *utils.FileInfo, targetPath string) (int64, error) {
 	// 创建一个临时源文件来模拟MTP设备的文件
-	tempFile := filepath.Join(os.TempDir(), "rec_temp_"+file.Name)
+	tempFile := fc.stagingFile(file)
 	defer os.Remove(tempFile)
 
 	// 创建模拟数据
@@ -494,70 +1238,23 @@ func (fc *FileCopier) mockCopyFromDevice(file *utils.FileInfo, targetPath string
 		return 0, fmt.Errorf("创建临时文件失败: %w", err)
 	}
 
-	// 复制文件
-	return fc.copyRegularFile(tempFile, targetPath)
-}
-
-// copyRegularFile 复制常规文件
-func (fc *FileCopier) copyRegularFile(srcPath, dstPath string) (int64, error) {
-	// 打开源文件
-	srcFile, err := os.Open(srcPath)
-	if err != nil {
-		return 0, fmt.Errorf("打开源文件失败: %w", err)
-	}
-	defer srcFile.Close()
-
-	// 创建目标文件
-	dstFile, err := os.Create(dstPath)
-	if err != nil {
-		return 0, fmt.Errorf("创建目标文件失败: %w", err)
+	// 存入目标
+	if err := fc.importTarget(targetPath, tempFile); err != nil {
+		return 0, err
 	}
-	defer dstFile.Close()
-
-	// 复制内容，同时更新进度
-	var copied int64
-	buffer := make([]byte, DefaultBufferSize) // 64KB缓冲区
-	updateInterval := int64(1024 * 1024) // 每MB更新一次进度
-	lastUpdate := int64(0)
-
-	for {
-		n, err := srcFile.Read(buffer)
-		if n > 0 {
-			written, writeErr := dstFile.Write(buffer[:n])
-			copied += int64(written)
-
-			if writeErr != nil {
-				return copied, fmt.Errorf("写入目标文件失败: %w", writeErr)
-			}
-
-			// 定期更新进度（这里可以添加进度回调）
-			if copied-lastUpdate >= updateInterval {
-				lastUpdate = copied
-				fc.log.Debug("复制进度: %s/%s (%.1f%%)",
-					utils.FormatBytes(copied),
-					utils.FormatBytes(fc.getFileSize(srcPath)),
-					float64(copied)/float64(fc.getFileSize(srcPath))*100)
-			}
-		}
-
-		if err == io.EOF {
-			break
-		}
-
-		if err != nil {
-			return copied, fmt.Errorf("复制文件内容失败: %w", err)
-		}
-	}
-
-	return copied, nil
+	return int64(len(tempData)), nil
 }
 
 // doResumeCopy 执行实际的断点续传复制
 func (fc *FileCopier) doResumeCopy(file *utils.FileInfo, resumeInfo *ResumeInfo, targetPath string, chunkSize, resumeInterval int64) (int64, error) {
+	if fc.deviceAccessor != nil {
+		return fc.doResumeCopyFromStream(file, resumeInfo, targetPath, chunkSize, resumeInterval)
+	}
+
 	// 首先尝试使用PowerShell进行断点续传复制
 	if fc.psAccessor != nil {
 		fc.log.Debug("尝试使用PowerShell进行断点续传复制: %s", file.Path)
-		if copiedBytes, err := fc.doResumeCopyWithPowerShell(file, resumeInfo, targetPath, chunkSize, resumeInterval); err == nil {
+		if copiedBytes, err := fc.doResumeCopyFromStream(file, resumeInfo, targetPath, chunkSize, resumeInterval); err == nil {
 			fc.log.Debug("PowerShell断点续传复制成功: %s, 复制字节数: %d", file.RelativePath, copiedBytes)
 			return copiedBytes, nil
 		} else {
@@ -566,7 +1263,7 @@ func (fc *FileCopier) doResumeCopy(file *utils.FileInfo, resumeInfo *ResumeInfo,
 	}
 
 	// 模拟实现，我们创建一个大的临时文件来模拟MTP设备
-	tempFile := filepath.Join(os.TempDir(), "rec_temp_"+file.Name)
+	tempFile := utils.TempFilePath(utils.UniqueName(fc.clock, fc.random, file.Name))
 	defer os.Remove(tempFile)
 
 	// 创建模拟数据（如果临时文件不存在）
@@ -613,6 +1310,7 @@ func (fc *FileCopier) doResumeCopy(file *utils.FileInfo, resumeInfo *ResumeInfo,
 	buffer := make([]byte, DefaultBufferSize) // 64KB缓冲区
 	totalCopied := resumeInfo.CopiedBytes
 	lastSave := totalCopied
+	lastSaveTime := fc.clock.Now()
 
 	for totalCopied < file.Size {
 		// 计算本次要读取的大小
@@ -635,14 +1333,16 @@ func (fc *FileCopier) doResumeCopy(file *utils.FileInfo, resumeInfo *ResumeInfo,
 		}
 
 		totalCopied += int64(written)
+		fc.reportProgress(file, totalCopied)
 
 		// 定期保存断点信息
-		if totalCopied-lastSave >= resumeInterval || totalCopied >= file.Size {
+		if totalCopied-lastSave >= resumeInterval || totalCopied >= file.Size || fc.clock.Now().Sub(lastSaveTime) >= ResumeSaveEvery {
 			resumeInfo.CopiedBytes = totalCopied
 			if saveErr := fc.resumeManager.SaveResumeInfo(resumeInfo); saveErr != nil {
 				fc.log.Warn("保存断点信息失败: %v", saveErr)
 			}
 			lastSave = totalCopied
+			lastSaveTime = fc.clock.Now()
 			fc.log.Debug("保存断点: %d/%d (%.1f%%)", totalCopied, file.Size, float64(totalCopied)/float64(file.Size)*100)
 		}
 
@@ -664,15 +1364,38 @@ func (fc *FileCopier) doResumeCopy(file *utils.FileInfo, resumeInfo *ResumeInfo,
 	return totalCopied, nil
 }
 
-// doResumeCopyWithPowerShell 使用PowerShell进行断点续传复制
-func (fc *FileCopier) doResumeCopyWithPowerShell(file *utils.FileInfo, resumeInfo *ResumeInfo, targetPath string, chunkSize, resumeInterval int64) (int64, error) {
-	// 打开PowerShell文件流
-	mtpStream, err := fc.psAccessor.OpenFileStream(file.Path)
+// skipStream 把设备文件流定位到 offset：流支持 Seek 时直接定位，不支持或定位失败时读取并丢弃
+// 文件比 offset 短时读到末尾即止
+func skipStream(stream io.Reader, offset int64) error {
+	if offset <= 0 {
+		return nil
+	}
+	if seeker, ok := stream.(io.Seeker); ok {
+		if _, err := seeker.Seek(offset, io.SeekStart); err == nil {
+			return nil
+		}
+	}
+	if _, err := io.CopyN(io.Discard, stream, offset); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// doResumeCopyFromStream 从设备文件流（PowerShell或WPD）断点续传复制，流支持Seek时直接定位到续传位置
+func (fc *FileCopier) doResumeCopyFromStream(file *utils.FileInfo, resumeInfo *ResumeInfo, targetPath string, chunkSize, resumeInterval int64) (int64, error) {
+	// 打开设备文件流
+	mtpStream, err := fc.openFileStream(file)
 	if err != nil {
-		return 0, fmt.Errorf("打开PowerShell文件流失败: %w", err)
+		return 0, fmt.Errorf("打开设备文件流失败: %w", err)
 	}
 	defer mtpStream.Close()
 
+	// 定位到断点位置，在套上限速和测速之前进行，丢弃的数据不计入复制速度
+	if err := skipStream(mtpStream, resumeInfo.CopiedBytes); err != nil {
+		return resumeInfo.CopiedBytes, fmt.Errorf("定位到断点位置失败: %w", err)
+	}
+	mtpStream = fc.monitorStream(file, mtpStream, false)
+
 	// 创建临时目标文件（用于断点续传）
 	var dst *os.File
 	if resumeInfo.CopiedBytes == 0 {
@@ -690,30 +1413,11 @@ func (fc *FileCopier) doResumeCopyWithPowerShell(file *utils.FileInfo, resumeInf
 	}
 	defer dst.Close()
 
-	// 定位到断点位置（MTP流可能不支持Seek，需要读取并丢弃）
-	if resumeInfo.CopiedBytes > 0 {
-		discardBuffer := make([]byte, DefaultBufferSize)
-		remaining := resumeInfo.CopiedBytes
-		for remaining > 0 {
-			toRead := int64(len(discardBuffer))
-			if toRead > remaining {
-				toRead = remaining
-			}
-			n, err := mtpStream.Read(discardBuffer[:toRead])
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				return resumeInfo.CopiedBytes, fmt.Errorf("定位到断点位置失败: %w", err)
-			}
-			remaining -= int64(n)
-		}
-	}
-
 	// 执行复制
 	buffer := make([]byte, DefaultBufferSize) // 64KB缓冲区
 	totalCopied := resumeInfo.CopiedBytes
 	lastSave := totalCopied
+	lastSaveTime := fc.clock.Now()
 
 	for totalCopied < file.Size {
 		// 计算本次要读取的大小
@@ -736,14 +1440,16 @@ func (fc *FileCopier) doResumeCopyWithPowerShell(file *utils.FileInfo, resumeInf
 		}
 
 		totalCopied += int64(written)
+		fc.reportProgress(file, totalCopied)
 
 		// 定期保存断点信息
-		if totalCopied-lastSave >= resumeInterval || totalCopied >= file.Size {
+		if totalCopied-lastSave >= resumeInterval || totalCopied >= file.Size || fc.clock.Now().Sub(lastSaveTime) >= ResumeSaveEvery {
 			resumeInfo.CopiedBytes = totalCopied
 			if saveErr := fc.resumeManager.SaveResumeInfo(resumeInfo); saveErr != nil {
 				fc.log.Warn("保存断点信息失败: %v", saveErr)
 			}
 			lastSave = totalCopied
+			lastSaveTime = fc.clock.Now()
 			fc.log.Debug("保存断点: %d/%d (%.1f%%)", totalCopied, file.Size, float64(totalCopied)/float64(file.Size)*100)
 		}
 
@@ -762,13 +1468,8 @@ func (fc *FileCopier) doResumeCopyWithPowerShell(file *utils.FileInfo, resumeInf
 
 // finalizeResumeFile 完成断点续传文件的最终处理
 func (fc *FileCopier) finalizeResumeFile(resumeInfo *ResumeInfo, targetPath string) error {
-	// 确保目标目录存在
-	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-		return fmt.Errorf("创建目标目录失败: %w", err)
-	}
-
-	// 原子性重命名临时文件到最终位置
-	if err := os.Rename(resumeInfo.TempPath, targetPath); err != nil {
+	// 通过目标存储移入最终位置，临时目录与目标不在同一磁盘卷时复制后删除
+	if err := fc.importTarget(targetPath, resumeInfo.TempPath); err != nil {
 		return fmt.Errorf("移动文件失败: %w", err)
 	}
 
@@ -776,14 +1477,6 @@ func (fc *FileCopier) finalizeResumeFile(resumeInfo *ResumeInfo, targetPath stri
 	return nil
 }
 
-// getFileSize 获取文件大小
-func (fc *FileCopier) getFileSize(filePath string) int64 {
-	if info, err := os.Stat(filePath); err == nil {
-		return info.Size()
-	}
-	return 0
-}
-
 // verifyCopy 验证复制结果
 func (fc *FileCopier) verifyCopy(file *utils.FileInfo, targetPath string, copiedBytes int64) error {
 	// 检查目标文件是否存在
@@ -812,6 +1505,10 @@ func (fc *FileCopier) verifyCopy(file *utils.FileInfo, targetPath string, copied
 
 // isSupportedFileType 检查是否为支持的文件类型
 func (fc *FileCopier) isSupportedFileType(filename string) bool {
+	// 备份生成的元数据文件不作为备份源
+	if IsSidecarFile(filename) {
+		return false
+	}
 	for _, ext := range fc.config.Backup.FileExtensions {
 		if strings.ToLower(filepath.Ext(filename)) == strings.ToLower(ext) {
 			return true
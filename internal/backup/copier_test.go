@@ -1,10 +1,16 @@
 package backup
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,6 +18,7 @@ import (
 	"github.com/allanpk716/record_center/internal/device"
 	"github.com/allanpk716/record_center/internal/logger"
 	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/internal/store"
 	"github.com/allanpk716/record_center/pkg/utils"
 )
 
@@ -43,6 +50,18 @@ func (m *MockTracker) IsFileBackedUp(sourcePath string) (bool, *storage.BackupRe
 	return false, nil, nil
 }
 
+func (m *MockTracker) IsHashBackedUp(fileHash string) (bool, *storage.BackupRecord) {
+	if fileHash == "" {
+		return false, nil
+	}
+	for _, record := range m.records {
+		if record.FileHash == fileHash {
+			return true, record
+		}
+	}
+	return false, nil
+}
+
 func (m *MockTracker) AddRecord(sourcePath, targetPath, deviceID string, fileSize int64, fileHash string) error {
 	m.backedUp[sourcePath] = true
 	m.records[sourcePath] = &storage.BackupRecord{
@@ -60,6 +79,63 @@ func (m *MockTracker) AddRecordWithVerify(sourcePath, targetPath, deviceID strin
 	return m.AddRecord(sourcePath, targetPath, deviceID, fileSize, fileHash)
 }
 
+func (m *MockTracker) SetTargetHash(sourcePath, fileHash string, modTime time.Time, size int64) error {
+	record, ok := m.records[sourcePath]
+	if !ok {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	record.FileHash = fileHash
+	record.TargetModTime = modTime
+	record.TargetSize = size
+	return nil
+}
+
+func (m *MockTracker) GetRecordByPath(sourcePath string) (*storage.BackupRecord, error) {
+	record, ok := m.records[sourcePath]
+	if !ok {
+		return nil, fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (m *MockTracker) SetRecordMetadata(sourcePath string, meta *utils.OpusMeta) error {
+	record, ok := m.records[sourcePath]
+	if !ok {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	record.AudioMeta = meta
+	return nil
+}
+
+func (m *MockTracker) SetContentHash(sourcePath, contentHash string) error {
+	record, ok := m.records[sourcePath]
+	if !ok {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	record.ContentHash = contentHash
+	return nil
+}
+
+func (m *MockTracker) RecordsByContent(contentHash string) []storage.BackupRecord {
+	var records []storage.BackupRecord
+	for _, record := range m.records {
+		if record.Success && record.ContentHash == contentHash {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
+
+func (m *MockTracker) SetRecordVersions(sourcePath string, versions []string) error {
+	record, ok := m.records[sourcePath]
+	if !ok {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	record.Versions = append([]string(nil), versions...)
+	return nil
+}
+
 // TestFileCopier_NewFileCopier 测试创建文件复制器
 func TestFileCopier_NewFileCopier(t *testing.T) {
 	// 创建临时目录
@@ -117,7 +193,7 @@ func TestFileCopier_NewFileCopier(t *testing.T) {
 
 // TestFileCopier_ValidateFile 测试文件验证
 func TestFileCopier_ValidateFile(t *testing.T) {
-	tempDir := t.TempDir()
+	_ = t.TempDir()
 
 	cfg := &config.Config{
 		Backup: config.BackupConfig{
@@ -199,7 +275,7 @@ func TestFileCopier_ValidateFile(t *testing.T) {
 			if tc.expectError {
 				if err == nil {
 					t.Errorf("期望返回错误: %s", tc.errorMsg)
-				} else if tc.errorMsg != "" && !contains(err.Error(), tc.errorMsg) {
+				} else if tc.errorMsg != "" && !strings.Contains(err.Error(), tc.errorMsg) {
 					t.Errorf("错误消息不匹配，期望包含 '%s'，实际为 '%s'", tc.errorMsg, err.Error())
 				}
 			} else {
@@ -213,7 +289,7 @@ func TestFileCopier_ValidateFile(t *testing.T) {
 
 // TestFileCopier_ShouldSkipFile 测试是否应该跳过文件
 func TestFileCopier_ShouldSkipFile(t *testing.T) {
-	tempDir := t.TempDir()
+	_ = t.TempDir()
 
 	cfg := &config.Config{
 		Backup: config.BackupConfig{
@@ -247,6 +323,18 @@ func TestFileCopier_ShouldSkipFile(t *testing.T) {
 			expectSkip: true,
 			skipReason: "文件已备份",
 		},
+		{
+			name: "改名但内容已备份的文件",
+			file: &utils.FileInfo{
+				Path:         "/test/renamed.opus",
+				RelativePath: "renamed.opus",
+				Name:         "renamed.opus",
+				Size:         1024,
+				Hash:         "hash123",
+			},
+			expectSkip: true,
+			skipReason: "文件内容已备份",
+		},
 		{
 			name: "未备份的文件",
 			file: &utils.FileInfo{
@@ -282,6 +370,7 @@ func TestFileCopier_GetTargetPath(t *testing.T) {
 		name              string
 		preserveStructure bool
 		baseDirectory     string
+		pathTemplate      string
 		file              *utils.FileInfo
 		expectedPath      string
 	}{
@@ -318,6 +407,30 @@ func TestFileCopier_GetTargetPath(t *testing.T) {
 			},
 			expectedPath: filepath.Join(tempDir, "backups", "subdir", "file.opus"),
 		},
+		{
+			name:              "目录模板按录音时间分类",
+			preserveStructure: true,
+			baseDirectory:     filepath.Join(tempDir, "backups"),
+			pathTemplate:      "{weektype}/{daypart}",
+			file: &utils.FileInfo{
+				Path:         "/source/subdir/REC_20240316_201500.opus",
+				RelativePath: "subdir\\REC_20240316_201500.opus",
+				Name:         "REC_20240316_201500.opus",
+			},
+			expectedPath: filepath.Join(tempDir, "backups", "weekend", "evening", "subdir", "REC_20240316_201500.opus"),
+		},
+		{
+			name:              "目录模板-无可用时间",
+			preserveStructure: false,
+			baseDirectory:     filepath.Join(tempDir, "backups"),
+			pathTemplate:      "{weekday}",
+			file: &utils.FileInfo{
+				Path:         "/source/subdir/file.opus",
+				RelativePath: "subdir/file.opus",
+				Name:         "file.opus",
+			},
+			expectedPath: filepath.Join(tempDir, "backups", "unknown", "file.opus"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -328,6 +441,8 @@ func TestFileCopier_GetTargetPath(t *testing.T) {
 				},
 				Target: config.TargetConfig{
 					BaseDirectory: tc.baseDirectory,
+					PathTemplate:  tc.pathTemplate,
+					DayParts:      config.DefaultConfig().Target.DayParts,
 				},
 			}
 
@@ -485,7 +600,7 @@ func TestFileCopier_CopyFile_WithForce(t *testing.T) {
 	}
 
 	// 添加已备份记录（模拟文件已备份）
-	tracker.AddRecord(sourceFile, filepath.Join(backupDir, "test.opus"), "test_device", 1024, "oldhash")
+	tracker.AddRecord(sourceFile, filepath.Join(backupDir, "test.opus"), "test_device", int64(len(testData)), "oldhash")
 
 	// 不强制复制（应该跳过）
 	result1 := copier.CopyFile(fileInfo, false)
@@ -503,6 +618,53 @@ func TestFileCopier_CopyFile_WithForce(t *testing.T) {
 	}
 }
 
+// TestFileCopier_TargetAttributes 测试复制完成后按配置设置目标文件权限和只读属性，且只读的目标可以被强制重新备份覆盖
+func TestFileCopier_TargetAttributes(t *testing.T) {
+	tempDir := t.TempDir()
+	backupDir := filepath.Join(tempDir, "backups")
+	sourceFile := filepath.Join(tempDir, "test.opus")
+	testData := []byte("test audio data")
+	if err := os.WriteFile(sourceFile, testData, 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			FileExtensions: []string{".opus"},
+		},
+		Target: config.TargetConfig{
+			BaseDirectory: backupDir,
+			CreateSubdirs: true,
+			FileMode:      "0640",
+			ReadOnly:      true,
+		},
+	}
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test_device"})
+	fileInfo := &utils.FileInfo{Path: sourceFile, RelativePath: "test.opus", Name: "test.opus", Size: int64(len(testData))}
+	targetFile := filepath.Join(backupDir, "test.opus")
+
+	if result := copier.CopyFile(fileInfo, true); !result.Success {
+		t.Fatalf("文件复制失败: %v", result.Error)
+	}
+	if readOnly, err := utils.IsReadOnly(targetFile); err != nil || !readOnly {
+		t.Errorf("目标文件应为只读: %v", err)
+	}
+	// Windows 只有只读属性，其余平台权限为 file_mode 去掉写权限
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(targetFile); err != nil || info.Mode().Perm() != 0440 {
+			t.Errorf("目标文件权限应为 0440: %v", err)
+		}
+	}
+
+	// 再次备份时覆盖只读的目标文件
+	if result := copier.CopyFile(fileInfo, true); !result.Success {
+		t.Fatalf("覆盖只读目标文件失败: %v", result.Error)
+	}
+	if readOnly, err := utils.IsReadOnly(targetFile); err != nil || !readOnly {
+		t.Errorf("覆盖后目标文件仍应为只读: %v", err)
+	}
+}
+
 // TestFileCopier_CopyFiles 测试并发复制多个文件
 func TestFileCopier_CopyFiles(t *testing.T) {
 	// 创建临时目录
@@ -652,7 +814,7 @@ func TestFileCopier_VerifyCopy(t *testing.T) {
 
 // TestFileCopier_GetCopyStatistics 测试获取复制统计信息
 func TestFileCopier_GetCopyStatistics(t *testing.T) {
-	tempDir := t.TempDir()
+	_ = t.TempDir()
 
 	cfg := &config.Config{
 		Backup: config.BackupConfig{
@@ -719,4 +881,550 @@ func TestFileCopier_GetCopyStatistics(t *testing.T) {
 	if avgSpeed, ok := stats["average_speed"].(float64); ok && avgSpeed <= 0 {
 		t.Error("平均速度应该大于0")
 	}
-}
\ No newline at end of file
+}
+// TestFileCopier_GlobalSemaphore 测试多个复制器共享全局并发上限
+func TestFileCopier_GlobalSemaphore(t *testing.T) {
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			MaxConcurrent:       3,
+			GlobalMaxConcurrent: 4,
+			FileExtensions:      []string{".opus"},
+		},
+	}
+
+	log := logger.NewLogger(false)
+	globalSem := NewSharedSemaphore(cfg.Backup.GlobalMaxConcurrent)
+
+	var running, maxRunning int32
+	slowCopy := func(file *utils.FileInfo, force bool) *CopyResult {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &CopyResult{File: file, Success: true}
+	}
+
+	var copiers []*FileCopier
+	for i := 0; i < 2; i++ {
+		copier := NewFileCopier(cfg, log, NewMockTracker(), &device.DeviceInfo{DeviceID: fmt.Sprintf("device_%d", i)})
+		copier.SetGlobalSemaphore(globalSem)
+		copier.copyFunc = slowCopy
+		copiers = append(copiers, copier)
+	}
+
+	var wg sync.WaitGroup
+	for _, copier := range copiers {
+		var files []*utils.FileInfo
+		for i := 0; i < 10; i++ {
+			files = append(files, &utils.FileInfo{Name: fmt.Sprintf("file%d.opus", i), Path: fmt.Sprintf("file%d.opus", i)})
+		}
+
+		wg.Add(1)
+		go func(c *FileCopier, fs []*utils.FileInfo) {
+			defer wg.Done()
+			for result := range c.CopyFiles(context.Background(), fs, false) {
+				if !result.Success {
+					t.Errorf("复制失败: %v", result.Error)
+				}
+			}
+		}(copier, files)
+	}
+	wg.Wait()
+
+	if maxRunning > 4 {
+		t.Errorf("同时进行的复制数超过全局上限，期望不超过 4，实际 %d", maxRunning)
+	}
+}
+
+// TestSharedSemaphore_Unlimited 测试未配置全局上限时不限制
+func TestSharedSemaphore_Unlimited(t *testing.T) {
+	sem := NewSharedSemaphore(0)
+	if sem != nil {
+		t.Fatal("全局上限为0时应返回nil")
+	}
+	if err := sem.Acquire(context.Background()); err != nil {
+		t.Errorf("nil资源池获取名额不应失败: %v", err)
+	}
+	sem.Release()
+	if sem.Capacity() != 0 {
+		t.Errorf("nil资源池容量应为0，实际 %d", sem.Capacity())
+	}
+}
+
+// TestFileCopier_SkipEncrypted 测试加密文件被跳过
+func TestFileCopier_SkipEncrypted(t *testing.T) {
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			FileExtensions: []string{".opus"},
+		},
+	}
+
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	file := &utils.FileInfo{Path: "encrypted.opus", RelativePath: "encrypted.opus", Name: "encrypted.opus", Encrypted: true}
+
+	result := copier.CopyFile(file, true)
+	if !result.Skipped || result.SkipReason != SkipReasonEncrypted {
+		t.Errorf("加密文件应被跳过，实际: skipped=%v, reason=%s", result.Skipped, result.SkipReason)
+	}
+}
+
+// TestFileCopier_DeepVerify 测试深度校验能检出复制后内容与源不符
+func TestFileCopier_DeepVerify(t *testing.T) {
+	source := []byte(strings.Repeat("opus audio frame ", 1000))
+	corrupted := append([]byte(nil), source...)
+	corrupted[len(corrupted)/2] ^= 0xFF
+
+	testCases := []struct {
+		name          string
+		deepVerify    bool
+		corruptCopy   bool
+		expectSuccess bool
+		expectOpens   int
+	}{
+		{"内容一致时通过", true, false, true, 2},
+		{"内容不符时重试后报失败", true, true, false, 2 * (DeepVerifyMaxRetries + 1)},
+		{"未开启时无法发现内容错误", false, true, true, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Backup: config.BackupConfig{
+					FileExtensions:    []string{".opus"},
+					PreserveStructure: true,
+					DeepVerify:        tc.deepVerify,
+				},
+				Target: config.TargetConfig{
+					BaseDirectory: filepath.Join(t.TempDir(), "backups"),
+					CreateSubdirs: true,
+				},
+			}
+
+			tracker := NewMockTracker()
+			copier := NewFileCopier(cfg, logger.NewLogger(false), tracker, &device.DeviceInfo{DeviceID: "test"})
+
+			// 奇数次打开用于复制，偶数次打开用于深度校验读取源文件
+			opens := 0
+			copier.openStream = func(file *utils.FileInfo) (io.ReadCloser, error) {
+				opens++
+				if opens%2 == 1 && tc.corruptCopy {
+					return io.NopCloser(bytes.NewReader(corrupted)), nil
+				}
+				return io.NopCloser(bytes.NewReader(source)), nil
+			}
+
+			file := &utils.FileInfo{
+				Path:         "device\\录音.opus",
+				RelativePath: "录音.opus",
+				Name:         "录音.opus",
+				Size:         int64(len(source)),
+			}
+
+			result := copier.CopyFile(file, true)
+			if result.Success != tc.expectSuccess {
+				t.Fatalf("复制结果错误，期望成功=%v，实际=%v, 错误: %v", tc.expectSuccess, result.Success, result.Error)
+			}
+			if !tc.expectSuccess {
+				if result.Error == nil || !strings.Contains(result.Error.Error(), "深度校验失败") {
+					t.Errorf("应报告深度校验失败，实际: %v", result.Error)
+				}
+				if _, ok := tracker.records[file.Path]; ok {
+					t.Error("深度校验失败时不应添加备份记录")
+				}
+			}
+			if opens != tc.expectOpens {
+				t.Errorf("期望打开设备文件流 %d 次，实际 %d 次", tc.expectOpens, opens)
+			}
+		})
+	}
+}
+
+// mockBatchCopier 记录调用次数的模拟批量复制器，按 fail 中的源路径返回失败
+type mockBatchCopier struct {
+	calls int
+	items []device.CopyItem
+	data  []byte
+	fail  map[string]bool
+}
+
+func (m *mockBatchCopier) CopyFilesToLocal(items []device.CopyItem) []device.CopyItemResult {
+	m.calls++
+	m.items = append(m.items, items...)
+
+	results := make([]device.CopyItemResult, len(items))
+	for i, item := range items {
+		results[i].Item = item
+		if m.fail[item.SourcePath] {
+			results[i].Error = fmt.Errorf("模拟复制失败")
+			continue
+		}
+		os.MkdirAll(filepath.Dir(item.TargetPath), 0755)
+		if err := os.WriteFile(item.TargetPath, m.data, 0644); err != nil {
+			results[i].Error = err
+			continue
+		}
+		results[i].Size = int64(len(m.data))
+	}
+	return results
+}
+
+// TestFileCopier_BatchCopy 测试批处理模式下N个文件只调用一次批量复制，失败的文件逐个重试
+func TestFileCopier_BatchCopy(t *testing.T) {
+	data := []byte(strings.Repeat("opus", 256))
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			FileExtensions:    []string{".opus"},
+			MaxConcurrent:     2,
+			PreserveStructure: true,
+			BatchCopy:         true,
+		},
+		Target: config.TargetConfig{
+			BaseDirectory: filepath.Join(t.TempDir(), "backups"),
+			CreateSubdirs: true,
+		},
+	}
+
+	var files []*utils.FileInfo
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("录音_%d.opus", i)
+		files = append(files, &utils.FileInfo{
+			Path:         "device\\" + name,
+			RelativePath: name,
+			Name:         name,
+			Size:         int64(len(data)),
+		})
+	}
+
+	batch := &mockBatchCopier{data: data, fail: map[string]bool{files[3].Path: true}}
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	copier.batchCopier = batch
+
+	var streamOpens int32
+	copier.openStream = func(file *utils.FileInfo) (io.ReadCloser, error) {
+		atomic.AddInt32(&streamOpens, 1)
+		if file.Path != files[3].Path {
+			t.Errorf("批量复制成功的文件不应再逐个复制: %s", file.Path)
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	successCount := 0
+	for result := range copier.CopyFiles(context.Background(), files, false) {
+		if result.Success {
+			successCount++
+		} else {
+			t.Errorf("复制失败: %s, %v", result.File.Name, result.Error)
+		}
+	}
+
+	if batch.calls != 1 {
+		t.Errorf("批量复制应只调用一次，实际 %d 次", batch.calls)
+	}
+	if len(batch.items) != len(files) {
+		t.Errorf("批量复制应包含 %d 个文件，实际 %d 个", len(files), len(batch.items))
+	}
+	if successCount != len(files) {
+		t.Errorf("期望 %d 个文件复制成功，实际 %d 个", len(files), successCount)
+	}
+	if streamOpens != 1 {
+		t.Errorf("只有批量复制失败的文件应逐个复制，实际打开流 %d 次", streamOpens)
+	}
+}
+
+// TestFileCopier_TempFileCleanupOnError 测试复制异常时临时文件被清理
+func TestFileCopier_TempFileCleanupOnError(t *testing.T) {
+	tempDir := filepath.Join(t.TempDir(), "temp")
+	if err := utils.SetTempDir(tempDir); err != nil {
+		t.Fatalf("设置临时目录失败: %v", err)
+	}
+	defer utils.SetTempDir("")
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{FileExtensions: []string{".opus"}, TempDir: tempDir},
+		Target: config.TargetConfig{BaseDirectory: t.TempDir()},
+	}
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	file := &utils.FileInfo{Path: "device\\a.opus", RelativePath: "a.opus", Name: "a.opus", Size: 1024}
+
+	// 目标目录不存在，写入目标文件失败
+	targetPath := filepath.Join(t.TempDir(), "missing", "a.opus")
+	if _, err := copier.mockCopyFromDevice(file, targetPath); err == nil {
+		t.Fatal("目标目录不存在时复制应失败")
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("读取临时目录失败: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("复制失败后应清理临时文件，残留 %d 个", len(entries))
+	}
+}
+
+// memStore 内存中的目标存储，模拟S3等非本地目标
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	writes  int
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (m *memStore) Write(relPath string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[relPath] = data
+	m.writes++
+	return nil
+}
+
+func (m *memStore) Exists(relPath string) (bool, error) {
+	_, err := m.Stat(relPath)
+	return err == nil, nil
+}
+
+func (m *memStore) Stat(relPath string) (store.FileStat, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[relPath]
+	if !ok {
+		return store.FileStat{}, store.ErrNotExist
+	}
+	return store.FileStat{Size: int64(len(data))}, nil
+}
+
+func (m *memStore) Location(relPath string) string {
+	return "mem://" + relPath
+}
+
+// TestFileCopier_TargetStore 测试通过存储接口写入非本地目标
+func TestFileCopier_TargetStore(t *testing.T) {
+	data := []byte(strings.Repeat("opus", 300))
+	existing := []byte(strings.Repeat("old!", 300))
+
+	tests := []struct {
+		name        string
+		preExisting []byte
+		force       bool
+		wantSkipped bool
+		wantWrites  int
+	}{
+		{name: "写入新文件", wantWrites: 1},
+		{name: "目标已存在同样大小的文件时跳过", preExisting: existing, wantSkipped: true},
+		{name: "目标文件大小不同时覆盖", preExisting: []byte("short"), wantWrites: 1},
+		{name: "强制模式覆盖已存在文件", preExisting: existing, force: true, wantWrites: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Backup: config.BackupConfig{
+					FileExtensions:    []string{".opus"},
+					MaxConcurrent:     1,
+					PreserveStructure: true,
+					SkipExisting:      true,
+				},
+				Target: config.TargetConfig{
+					Type:          store.TypeS3,
+					BaseDirectory: t.TempDir(),
+				},
+			}
+
+			tracker := NewMockTracker()
+			copier := NewFileCopier(cfg, logger.NewLogger(false), tracker, &device.DeviceInfo{DeviceID: "test"})
+			mem := newMemStore()
+			if tt.preExisting != nil {
+				mem.objects["录音\\test.opus"] = tt.preExisting
+			}
+			copier.SetTargetStore(mem)
+			copier.openStream = func(file *utils.FileInfo) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			}
+
+			file := &utils.FileInfo{
+				Path:         "device\\录音\\test.opus",
+				RelativePath: "录音\\test.opus",
+				Name:         "test.opus",
+				Size:         int64(len(data)),
+			}
+			result := copier.CopyFile(file, tt.force)
+
+			if result.Error != nil {
+				t.Fatalf("复制失败: %v", result.Error)
+			}
+			if result.Skipped != tt.wantSkipped {
+				t.Fatalf("Skipped = %v, 期望 %v", result.Skipped, tt.wantSkipped)
+			}
+			if mem.writes != tt.wantWrites {
+				t.Errorf("写入次数 = %d, 期望 %d", mem.writes, tt.wantWrites)
+			}
+			if tt.wantSkipped {
+				if result.SkipReason != SkipReasonTargetExists {
+					t.Errorf("SkipReason = %q, 期望 %q", result.SkipReason, SkipReasonTargetExists)
+				}
+				return
+			}
+
+			if !result.Success {
+				t.Fatal("复制应成功")
+			}
+			if result.BytesCopied != int64(len(data)) {
+				t.Errorf("BytesCopied = %d, 期望 %d", result.BytesCopied, len(data))
+			}
+			if !bytes.Equal(mem.objects["录音\\test.opus"], data) {
+				t.Error("目标存储中的内容不一致")
+			}
+			record := tracker.records[file.Path]
+			if record == nil || record.TargetPath != "mem://录音\\test.opus" {
+				t.Errorf("备份记录的目标位置不正确: %+v", record)
+			}
+			if result.TargetPath != "mem://录音\\test.opus" {
+				t.Errorf("TargetPath = %s", result.TargetPath)
+			}
+		})
+	}
+}
+
+// recordingLocalStore 统计写入本地存储的次数
+type recordingLocalStore struct {
+	*store.LocalStore
+	writes  atomic.Int32
+	imports atomic.Int32
+}
+
+func (r *recordingLocalStore) Write(relPath string, reader io.Reader) error {
+	r.writes.Add(1)
+	return r.LocalStore.Write(relPath, reader)
+}
+
+func (r *recordingLocalStore) Import(relPath, localPath string) error {
+	r.imports.Add(1)
+	return r.LocalStore.Import(relPath, localPath)
+}
+
+// TestFileCopier_LocalWritesThroughStore 测试本地目标同样通过存储接口写入，不绕过存储直接写目标文件
+func TestFileCopier_LocalWritesThroughStore(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\a.opus"
+	content := bytes.Repeat([]byte("opus"), 1024)
+
+	tests := []struct {
+		name   string
+		resume bool
+		ranges bool
+	}{
+		{"顺序复制", false, false},
+		{"断点续传", true, false},
+		{"分片下载", false, true},
+		{"分片下载并断点续传", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(false)
+			cfg := config.DefaultConfig()
+			cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+			cfg.Backup.EnableResume = tt.resume
+			cfg.Backup.RangeDownload = config.RangeDownloadConfig{Enabled: tt.ranges, Threshold: "1KB", Workers: 2, ChunkSize: "1KB"}
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			fake.AddFile(devicePath, content, time.Now().Add(-time.Hour))
+
+			copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+			if tt.resume {
+				resumeDir := t.TempDir()
+				copier.resumeManager = NewResumeManager(filepath.Join(resumeDir, "resume"), filepath.Join(resumeDir, "temp"), log)
+			}
+			recorder := &recordingLocalStore{LocalStore: store.NewLocalStore(cfg.Target.BaseDirectory)}
+			copier.SetTargetStore(recorder)
+			copier.SetMTPInterface(fake)
+
+			file := &utils.FileInfo{Path: devicePath, RelativePath: "a.opus", Name: "a.opus", Size: int64(len(content))}
+			result := copier.CopyFile(file, true)
+			if !result.Success {
+				t.Fatalf("复制失败: %v", result.Error)
+			}
+			if got := recorder.writes.Load() + recorder.imports.Load(); got != 1 {
+				t.Errorf("应通过存储写入一次，实际写入 %d 次、移入 %d 次", recorder.writes.Load(), recorder.imports.Load())
+			}
+			copied, err := os.ReadFile(result.TargetPath)
+			if err != nil || !bytes.Equal(copied, content) {
+				t.Errorf("目标文件内容与设备文件不一致: %v", err)
+			}
+		})
+	}
+}
+
+// readCounter 统计读取的字节数，不提供 Seek
+type readCounter struct {
+	reader *bytes.Reader
+	read   int64
+}
+
+func (r *readCounter) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+// TestSkipStream 测试断点续传定位：支持 Seek 的流直接定位，不支持或定位失败时读取丢弃
+func TestSkipStream(t *testing.T) {
+	data := []byte("0123456789abcdef")
+	tests := []struct {
+		name     string
+		seekable bool
+		failSeek bool
+		offset   int64
+		wantRead int64
+		wantNext string
+	}{
+		{"直接定位", true, false, 10, 0, "a"},
+		{"不支持定位时读取丢弃", false, false, 10, 10, "a"},
+		{"定位失败时读取丢弃", true, true, 4, 4, "4"},
+		{"超出文件长度", false, false, 100, int64(len(data)), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &readCounter{reader: bytes.NewReader(data)}
+			var stream io.Reader = counter
+			if tt.seekable {
+				stream = &seekableCounter{readCounter: counter, fail: tt.failSeek}
+			}
+			if err := skipStream(stream, tt.offset); err != nil {
+				t.Fatalf("定位失败: %v", err)
+			}
+			if counter.read != tt.wantRead {
+				t.Errorf("定位时读取了 %d 字节，期望 %d", counter.read, tt.wantRead)
+			}
+			next := make([]byte, 1)
+			n, _ := stream.Read(next)
+			if got := string(next[:n]); got != tt.wantNext {
+				t.Errorf("定位后读到 %q，期望 %q", got, tt.wantNext)
+			}
+		})
+	}
+}
+
+// seekableCounter 为 readCounter 提供 Seek，fail 为 true 时模拟设备驱动不支持定位
+type seekableCounter struct {
+	*readCounter
+	fail bool
+}
+
+func (r *seekableCounter) Seek(offset int64, whence int) (int64, error) {
+	if r.fail {
+		return 0, fmt.Errorf("模拟不支持定位")
+	}
+	return r.reader.Seek(offset, whence)
+}
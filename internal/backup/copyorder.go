@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 复制队列的顺序（backup.copy_order），中途中断时排在前面的文件已先备份
+const (
+	CopyOrderNewest   = "newest"   // 录音时间最新的优先
+	CopyOrderSmallest = "smallest" // 小文件优先
+	CopyOrderLargest  = "largest"  // 大文件优先
+	CopyOrderName     = "name"     // 按相对路径排序
+)
+
+// SortForCopy 按复制顺序返回排序后的文件列表副本，order 为空或未知时保持原顺序
+// 排序是稳定的，优先级相同的文件保持枚举顺序
+func SortForCopy(files []*utils.FileInfo, order string) []*utils.FileInfo {
+	sorted := append([]*utils.FileInfo(nil), files...)
+
+	var less func(a, b *utils.FileInfo) bool
+	switch order {
+	case CopyOrderNewest:
+		less = func(a, b *utils.FileInfo) bool { return RecordingTime(a).After(RecordingTime(b)) }
+	case CopyOrderSmallest:
+		less = func(a, b *utils.FileInfo) bool { return a.Size < b.Size }
+	case CopyOrderLargest:
+		less = func(a, b *utils.FileInfo) bool { return a.Size > b.Size }
+	case CopyOrderName:
+		less = func(a, b *utils.FileInfo) bool {
+			return strings.ToLower(a.RelativePath) < strings.ToLower(b.RelativePath)
+		}
+	default:
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		// 无效的条目放在最后，由复制时的验证报告错误
+		if sorted[i] == nil || sorted[j] == nil {
+			return sorted[j] == nil && sorted[i] != nil
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// copyOrderFiles 一组大小、修改时间各不相同的文件，c 的文件名时间戳最新
+func copyOrderFiles() []*utils.FileInfo {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	return []*utils.FileInfo{
+		{Path: "dev\\b.opus", RelativePath: "b.opus", Name: "b.opus", Size: 300, ModTime: base.Add(2 * time.Hour)},
+		{Path: "dev\\a.opus", RelativePath: "a.opus", Name: "a.opus", Size: 100, ModTime: base},
+		{Path: "dev\\20240503_080000_c.opus", RelativePath: "20240503_080000_c.opus", Name: "20240503_080000_c.opus", Size: 200, ModTime: base.Add(time.Hour)},
+		{Path: "dev\\d.opus", RelativePath: "d.opus", Name: "d.opus", Size: 400, ModTime: base.Add(3 * time.Hour)},
+	}
+}
+
+// TestSortForCopy 测试各复制顺序的排序结果，未设置时保持枚举顺序且不修改原列表
+func TestSortForCopy(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"b.opus", "a.opus", "20240503_080000_c.opus", "d.opus"}},
+		{CopyOrderNewest, []string{"20240503_080000_c.opus", "d.opus", "b.opus", "a.opus"}},
+		{CopyOrderSmallest, []string{"a.opus", "20240503_080000_c.opus", "b.opus", "d.opus"}},
+		{CopyOrderLargest, []string{"d.opus", "b.opus", "20240503_080000_c.opus", "a.opus"}},
+		{CopyOrderName, []string{"20240503_080000_c.opus", "a.opus", "b.opus", "d.opus"}},
+	}
+
+	for _, tt := range tests {
+		files := copyOrderFiles()
+		var got []string
+		for _, file := range SortForCopy(files, tt.order) {
+			got = append(got, file.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("顺序 %q = %v，期望 %v", tt.order, got, tt.want)
+		}
+		if files[0].Name != "b.opus" {
+			t.Errorf("顺序 %q 修改了原列表", tt.order)
+		}
+	}
+}
+
+// TestFileCopier_CopyOrderNewest 测试 newest 顺序下最新的文件最先提交复制
+func TestFileCopier_CopyOrderNewest(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = t.TempDir()
+	cfg.Backup.EnableResume = false
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.CopyOrder = CopyOrderNewest
+
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"})
+	var mu sync.Mutex
+	var submitted []string
+	copier.copyFunc = func(file *utils.FileInfo, force bool) *CopyResult {
+		mu.Lock()
+		submitted = append(submitted, file.Name)
+		mu.Unlock()
+		return &CopyResult{File: file, Success: true, BytesCopied: file.Size}
+	}
+
+	for range copier.CopyFiles(context.Background(), copyOrderFiles(), true) {
+	}
+
+	want := []string{"20240503_080000_c.opus", "d.opus", "b.opus", "a.opus"}
+	if !reflect.DeepEqual(submitted, want) {
+		t.Errorf("提交顺序 = %v，期望 %v", submitted, want)
+	}
+}
@@ -0,0 +1,290 @@
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// GroupByDay archive 子命令按录音日期分组，每天打一个 YYYY-MM-DD.zip
+const GroupByDay = "day"
+
+// DayArchiveDateLayout 按日归档的日期格式，也是归档文件名
+const DayArchiveDateLayout = "2006-01-02"
+
+// DayArchiveRecorder 按日归档所需的备份记录操作
+type DayArchiveRecorder interface {
+	GetStorage() *storage.BackupStorage
+	SetRecordTarget(sourcePath, targetPath string) error
+}
+
+// DayArchiveGroup 同一录音日期的备份文件
+type DayArchiveGroup struct {
+	Date        string                 // 录音日期，YYYY-MM-DD
+	ArchivePath string                 // 生成的zip文件路径
+	Records     []storage.BackupRecord // 并入归档的备份记录
+	Size        int64                  // 归档文件内容的总大小
+}
+
+// DayArchiver 把备份目录中的散文件按录音日期分组打包
+// 录音日期优先取文件名中的时间戳，其次为备份文件的修改时间；已在归档中的备份不再处理
+// 开启删除时，归档写完并逐条校验后才删除原散文件，并把记录的目标路径改为归档条目；
+// 安全模式下原散文件移入回收站而不是直接删除
+type DayArchiver struct {
+	tracker       DayArchiveRecorder
+	outDir        string
+	deleteSources bool
+	safeMode      bool
+	trash         *Trash
+	log           *logger.Logger
+	now           func() time.Time
+}
+
+// NewDayArchiver 创建按日归档器，归档写入 outDir
+func NewDayArchiver(tracker DayArchiveRecorder, outDir string, log *logger.Logger) *DayArchiver {
+	return &DayArchiver{
+		tracker: tracker,
+		outDir:  outDir,
+		log:     log,
+		now:     time.Now,
+	}
+}
+
+// SetDeleteSources 设置归档后是否删除原散文件，安全模式下移入 trash 而不是直接删除
+func (da *DayArchiver) SetDeleteSources(deleteSources, safeMode bool, trash *Trash) {
+	da.deleteSources = deleteSources
+	da.safeMode = safeMode
+	da.trash = trash
+}
+
+// Plan 按录音日期分组尚未归档的备份散文件，按日期升序返回，不写入任何文件
+func (da *DayArchiver) Plan() []DayArchiveGroup {
+	groups := make(map[string]*DayArchiveGroup)
+	byContent := make(map[string]*DayArchiveGroup) // 内容寻址布局下多条记录共用一个文件，归入第一条记录所在的日期
+	for _, record := range da.tracker.GetStorage().Records {
+		if !record.Success || strings.Contains(record.TargetPath, "!/") {
+			continue
+		}
+		if group, ok := byContent[contentKey(record)]; ok {
+			group.Records = append(group.Records, record)
+			continue
+		}
+		info, err := os.Stat(record.TargetPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		date := RecordingTime(&utils.FileInfo{
+			Name:    filepath.Base(record.SourcePath),
+			ModTime: info.ModTime(),
+		}).Format(DayArchiveDateLayout)
+		group, ok := groups[date]
+		if !ok {
+			group = &DayArchiveGroup{Date: date}
+			groups[date] = group
+		}
+		group.Records = append(group.Records, record)
+		group.Size += info.Size()
+		byContent[contentKey(record)] = group
+	}
+
+	result := make([]DayArchiveGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Records, func(i, j int) bool {
+			return group.Records[i].TargetPath < group.Records[j].TargetPath
+		})
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result
+}
+
+// Archive 为每个录音日期生成一个zip，返回生成的归档和删除（或移入回收站）的散文件数
+// 某一天归档失败时跳过该天继续其他日期，最后返回第一个错误
+func (da *DayArchiver) Archive() ([]DayArchiveGroup, int, error) {
+	var archived []DayArchiveGroup
+	var firstErr error
+	removed := 0
+
+	for _, group := range da.Plan() {
+		entries, err := da.writeGroup(&group)
+		if err != nil {
+			da.log.Error("归档 %s 失败: %v", group.Date, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("归档 %s 失败: %w", group.Date, err)
+			}
+			continue
+		}
+		archived = append(archived, group)
+		da.log.Info("已归档 %s: %d 个文件 -> %s", group.Date, len(group.Records), group.ArchivePath)
+
+		if da.deleteSources {
+			removed += da.removeSources(group, entries)
+		}
+	}
+
+	return archived, removed, firstErr
+}
+
+// archiveEntry 写入归档的一个条目
+type archiveEntry struct {
+	ref  string // 条目引用 "<zip路径>!/<条目名>"
+	hash string // 写入内容的SHA256
+	size int64
+}
+
+// writeGroup 把一组文件写入该日期的zip并校验，返回与 group.Records 一一对应的条目
+func (da *DayArchiver) writeGroup(group *DayArchiveGroup) ([]archiveEntry, error) {
+	name := da.archiveName(group.Date)
+	writer := NewArchiveWriter(da.outDir, name, 0, da.log)
+	group.ArchivePath = filepath.Join(da.outDir, name+".zip")
+
+	entries := make([]archiveEntry, 0, len(group.Records))
+	written := make(map[string]archiveEntry) // 共用同一文件的记录只写入一个条目
+	for _, record := range group.Records {
+		entry, ok := written[record.TargetPath]
+		if !ok {
+			var err error
+			if entry, err = da.writeEntry(writer, record.TargetPath); err != nil {
+				writer.Close()
+				os.Remove(group.ArchivePath)
+				return nil, err
+			}
+			written[record.TargetPath] = entry
+		}
+		entries = append(entries, entry)
+	}
+	if err := writer.Close(); err != nil {
+		os.Remove(group.ArchivePath)
+		return nil, err
+	}
+
+	if err := verifyArchiveEntries(entries); err != nil {
+		return nil, fmt.Errorf("校验归档失败: %w", err)
+	}
+	return entries, nil
+}
+
+// writeEntry 把一个散文件写入归档，保留修改时间
+func (da *DayArchiver) writeEntry(writer *ArchiveWriter, path string) (archiveEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return archiveEntry{}, fmt.Errorf("打开备份文件失败: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return archiveEntry{}, fmt.Errorf("读取备份文件信息失败: %w", err)
+	}
+	size, hash, ref, err := writer.WriteEntry(filepath.Base(path), file, info.ModTime())
+	if err != nil {
+		return archiveEntry{}, err
+	}
+	return archiveEntry{ref: ref, hash: hash, size: size}, nil
+}
+
+// archiveName 选择归档文件名（不含扩展名），同名归档已存在时（如之前已归档过该日）追加序号
+func (da *DayArchiver) archiveName(date string) string {
+	name := date
+	for i := 2; utils.FileExists(filepath.Join(da.outDir, name+".zip")); i++ {
+		name = fmt.Sprintf("%s_%d", date, i)
+	}
+	return name
+}
+
+// removeSources 删除已归档的散文件并把记录指向归档条目，返回处理的文件数
+// 共用同一文件的记录只删除一次，删除失败时这些记录都保持原目标
+func (da *DayArchiver) removeSources(group DayArchiveGroup, entries []archiveEntry) int {
+	deletedAt := da.now()
+	removed := 0
+	handled := make(map[string]bool) // 目标路径 -> 是否已删除
+	for i, record := range group.Records {
+		if ok, seen := handled[record.TargetPath]; seen {
+			if ok {
+				da.setArchivedTarget(record, entries[i])
+			}
+			continue
+		}
+		handled[record.TargetPath] = false
+		if da.safeMode && da.trash != nil {
+			record := record
+			if _, err := da.trash.Move(record.TargetPath, deletedAt, &record); err != nil {
+				da.log.Warn("移入回收站失败: %s, %v", record.TargetPath, err)
+				continue
+			}
+		} else if err := os.Remove(record.TargetPath); err != nil {
+			da.log.Warn("删除已归档的文件失败: %s, %v", record.TargetPath, err)
+			continue
+		}
+		removed++
+		handled[record.TargetPath] = true
+
+		// 元数据文件随散文件一起失效
+		os.Remove(SidecarPath(record.TargetPath))
+		da.setArchivedTarget(record, entries[i])
+	}
+	return removed
+}
+
+// setArchivedTarget 把记录的目标路径改为归档条目
+func (da *DayArchiver) setArchivedTarget(record storage.BackupRecord, entry archiveEntry) {
+	if err := da.tracker.SetRecordTarget(record.SourcePath, entry.ref); err != nil {
+		da.log.Warn("更新备份记录失败: %s, %v", record.SourcePath, err)
+	}
+}
+
+// contentKey 判断多条记录是否共用同一个备份文件的键，有内容哈希时取内容哈希，否则取目标路径
+func contentKey(record storage.BackupRecord) string {
+	if record.ContentHash != "" {
+		return record.ContentHash
+	}
+	return record.TargetPath
+}
+
+// verifyArchiveEntries 重新读取归档中的条目，确认大小和哈希与写入时一致
+func verifyArchiveEntries(entries []archiveEntry) error {
+	readers := make(map[string]*zip.ReadCloser)
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+
+	for _, entry := range entries {
+		archivePath, entryName, _ := strings.Cut(entry.ref, "!/")
+		reader, ok := readers[archivePath]
+		if !ok {
+			var err error
+			if reader, err = zip.OpenReader(archivePath); err != nil {
+				return fmt.Errorf("打开归档失败: %w", err)
+			}
+			readers[archivePath] = reader
+		}
+
+		file, err := reader.Open(entryName)
+		if err != nil {
+			return fmt.Errorf("归档中不存在条目 %s: %w", entryName, err)
+		}
+		hasher := sha256.New()
+		size, err := io.Copy(hasher, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("读取条目 %s 失败: %w", entryName, err)
+		}
+		if size != entry.size || fmt.Sprintf("%x", hasher.Sum(nil)) != entry.hash {
+			return fmt.Errorf("条目 %s 内容不一致", entryName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package backup
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// newDayArchiveFixture 创建跨两天的备份散文件与备份记录：两个文件名带时间戳，一个按修改时间归入第二天
+func newDayArchiveFixture(t *testing.T) (string, *storage.BackupTracker) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), logger.NewLogger(false))
+
+	files := map[string]time.Time{
+		"20240501_090000.opus": time.Date(2024, 5, 3, 12, 0, 0, 0, time.Local),
+		"20240501_233000.opus": time.Date(2024, 5, 3, 12, 0, 0, 0, time.Local),
+		"20240502_080000.opus": time.Date(2024, 5, 3, 12, 0, 0, 0, time.Local),
+		"memo.opus":            time.Date(2024, 5, 2, 18, 0, 0, 0, time.Local),
+	}
+	for name, modTime := range files {
+		targetPath := filepath.Join(baseDir, "录音笔文件", name)
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(targetPath, []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("创建备份文件失败: %v", err)
+		}
+		if err := os.Chtimes(targetPath, modTime, modTime); err != nil {
+			t.Fatalf("设置修改时间失败: %v", err)
+		}
+		if err := tracker.AddRecord("device\\"+name, targetPath, "test_device", int64(len("content of "+name)), ""); err != nil {
+			t.Fatalf("添加备份记录失败: %v", err)
+		}
+	}
+	return baseDir, tracker
+}
+
+// zipEntries 读取zip中的条目名和内容
+func zipEntries(t *testing.T, path string) map[string]string {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("打开归档 %s 失败: %v", path, err)
+	}
+	defer reader.Close()
+
+	entries := make(map[string]string)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("打开条目失败: %v", err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("读取条目失败: %v", err)
+		}
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+// TestDayArchiver_GroupsByDay 测试跨两天的备份生成两个按日zip，各含当天的文件，未开启删除时保留散文件
+func TestDayArchiver_GroupsByDay(t *testing.T) {
+	baseDir, tracker := newDayArchiveFixture(t)
+	outDir := filepath.Join(t.TempDir(), "daily")
+
+	archiver := NewDayArchiver(tracker, outDir, logger.NewLogger(false))
+	groups, removed, err := archiver.Archive()
+	if err != nil {
+		t.Fatalf("按日归档失败: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("未开启删除时不应删除散文件，实际删除 %d 个", removed)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("期望生成 2 个归档，实际 %d 个", len(groups))
+	}
+
+	want := map[string][]string{
+		"2024-05-01.zip": {"20240501_090000.opus", "20240501_233000.opus"},
+		"2024-05-02.zip": {"20240502_080000.opus", "memo.opus"},
+	}
+	for name, wantFiles := range want {
+		entries := zipEntries(t, filepath.Join(outDir, name))
+		var got []string
+		for entry, content := range entries {
+			got = append(got, entry)
+			if content != "content of "+entry {
+				t.Errorf("%s 中 %s 的内容 = %q", name, entry, content)
+			}
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, wantFiles) {
+			t.Errorf("%s 的条目 = %v，期望 %v", name, got, wantFiles)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(baseDir, "录音笔文件", "memo.opus")); err != nil {
+		t.Errorf("未开启删除时散文件应保留: %v", err)
+	}
+
+	// 散文件仍在时再次归档，同名归档已存在则追加序号而不是覆盖
+	groups, _, err = archiver.Archive()
+	if err != nil {
+		t.Fatalf("再次归档失败: %v", err)
+	}
+	if len(groups) != 2 || filepath.Base(groups[0].ArchivePath) != "2024-05-01_2.zip" {
+		t.Errorf("再次归档应使用新文件名: %+v", groups)
+	}
+}
+
+// TestDayArchiver_DeleteSources 测试开启删除后散文件被删除（安全模式下移入回收站），记录指向归档条目且不再重复归档
+func TestDayArchiver_DeleteSources(t *testing.T) {
+	for _, safeMode := range []bool{false, true} {
+		name := "直接删除"
+		if safeMode {
+			name = "安全模式移入回收站"
+		}
+		t.Run(name, func(t *testing.T) {
+			baseDir, tracker := newDayArchiveFixture(t)
+			outDir := filepath.Join(t.TempDir(), "daily")
+			log := logger.NewLogger(false)
+			trash := NewTrash("", baseDir, 0, log)
+
+			archiver := NewDayArchiver(tracker, outDir, log)
+			archiver.SetDeleteSources(true, safeMode, trash)
+			_, removed, err := archiver.Archive()
+			if err != nil {
+				t.Fatalf("按日归档失败: %v", err)
+			}
+			if removed != 4 {
+				t.Errorf("期望删除 4 个散文件，实际 %d 个", removed)
+			}
+
+			if _, err := os.Stat(filepath.Join(baseDir, "录音笔文件", "memo.opus")); !os.IsNotExist(err) {
+				t.Errorf("已归档的散文件应被删除: %v", err)
+			}
+			items, err := trash.List()
+			if err != nil {
+				t.Fatalf("读取回收站失败: %v", err)
+			}
+			if safeMode && len(items) != 4 {
+				t.Errorf("安全模式下散文件应移入回收站，回收站中有 %d 个", len(items))
+			}
+			if !safeMode && len(items) != 0 {
+				t.Errorf("非安全模式下不应使用回收站，回收站中有 %d 个", len(items))
+			}
+
+			record, err := tracker.GetRecordByPath("device\\memo.opus")
+			if err != nil {
+				t.Fatalf("获取记录失败: %v", err)
+			}
+			if want := filepath.Join(outDir, "2024-05-02.zip") + "!/memo.opus"; record.TargetPath != want {
+				t.Errorf("记录的目标路径 = %s，期望 %s", record.TargetPath, want)
+			}
+
+			if groups := archiver.Plan(); len(groups) != 0 {
+				t.Errorf("已归档的备份不应再次分组: %+v", groups)
+			}
+		})
+	}
+}
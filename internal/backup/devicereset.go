@@ -0,0 +1,72 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDeviceResetFailed 连续复制失败后复位设备会话失败，stop-on-fatal 策略视为致命错误
+var ErrDeviceResetFailed = errors.New("设备复位失败")
+
+// trackDeviceFailures 统计连续复制失败的次数，达到 reset_after_failures 时复位设备会话
+// 复位失败的错误附加到当前结果上，交由错误策略决定是否停止剩余复制
+func (fc *FileCopier) trackDeviceFailures(result *CopyResult) {
+	threshold := fc.config.Backup.ResetAfterFailures
+	if threshold <= 0 || result.Skipped {
+		return
+	}
+
+	fc.resetMutex.Lock()
+	defer fc.resetMutex.Unlock()
+
+	if result.Success {
+		fc.consecutiveFailures = 0
+		return
+	}
+
+	fc.consecutiveFailures++
+	if fc.consecutiveFailures < threshold {
+		return
+	}
+	fc.consecutiveFailures = 0
+
+	fc.log.Warn("连续 %d 个文件复制失败，断开并重新连接设备", threshold)
+	if err := fc.resetDevice(); err != nil {
+		fc.log.Error("%v", err)
+		result.Error = errors.Join(result.Error, err)
+		return
+	}
+	fc.log.Info("设备已重新连接，继续复制剩余文件")
+}
+
+// resetDevice 等待其他 worker 进行中的复制结束后复位设备会话，复位期间不开始新的复制
+//   - 访问接口来自连接池时丢弃池中的旧连接并重新获取，其他仍在使用旧连接的备份不受影响
+//   - 独占的访问接口直接断开后重新连接
+//   - PowerShell 访问器每次读取都启动新进程，没有可复位的会话，重新查找设备路径确认设备仍可访问
+func (fc *FileCopier) resetDevice() error {
+	fc.deviceUse.Lock()
+	defer fc.deviceUse.Unlock()
+
+	switch {
+	case fc.reconnect != nil:
+		mtp, err := fc.reconnect()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDeviceResetFailed, err)
+		}
+		fc.SetMTPInterface(mtp)
+	case fc.deviceAccessor != nil:
+		if err := fc.deviceAccessor.Close(); err != nil {
+			fc.log.Warn("断开设备连接失败: %v", err)
+		}
+		if err := fc.deviceAccessor.ConnectToDevice(fc.device.Name, fc.device.VID, fc.device.PID); err != nil {
+			return fmt.Errorf("%w: %v", ErrDeviceResetFailed, err)
+		}
+	case fc.psAccessor != nil:
+		if _, err := fc.psAccessor.GetMTPDevicePath(fc.device.Name); err != nil {
+			return fmt.Errorf("%w: %v", ErrDeviceResetFailed, err)
+		}
+	default:
+		fc.log.Debug("没有可复位的设备访问接口，跳过设备复位")
+	}
+	return nil
+}
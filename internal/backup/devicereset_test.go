@@ -0,0 +1,219 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// wedgedAccessor 模拟连续读取多个文件后进入错误态的设备：第 failFrom 次读取起失败，复位（重新连接）后恢复
+type wedgedAccessor struct {
+	*device.FakeMTPAccessor
+	mu         sync.Mutex
+	failFrom   int
+	reads      int
+	resets     int
+	connectErr error // 重新连接时返回的错误，模拟复位失败
+}
+
+func (w *wedgedAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
+	w.mu.Lock()
+	w.reads++
+	wedged := w.resets == 0 && w.reads >= w.failFrom
+	w.mu.Unlock()
+
+	if wedged {
+		return nil, device.NewRetryableMTPError(device.ERROR_DEVICE_BUSY, "设备无响应", nil)
+	}
+	return w.FakeMTPAccessor.GetFileStream(filePath)
+}
+
+func (w *wedgedAccessor) ConnectToDevice(deviceName, vid, pid string) error {
+	if w.connectErr != nil {
+		return w.connectErr
+	}
+	w.mu.Lock()
+	w.resets++
+	w.mu.Unlock()
+	return w.FakeMTPAccessor.ConnectToDevice(deviceName, vid, pid)
+}
+
+// TestFileCopier_ResetAfterFailures 测试连续失败达到阈值后复位设备，以及复位失败时交由错误策略处理
+func TestFileCopier_ResetAfterFailures(t *testing.T) {
+	const fileCount = 6
+
+	tests := []struct {
+		name        string
+		threshold   int
+		connectErr  error
+		wantResets  int
+		wantSuccess int
+		wantFatal   int // 附加了复位失败错误的结果数
+	}{
+		{"第3次起失败复位后恢复", 2, nil, 1, 4, 0},
+		{"未开启复位", 0, nil, 0, 2, 0},
+		{"复位失败", 2, errors.New("重连超时"), 0, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+			cfg.Backup.MaxConcurrent = 1
+			cfg.Backup.EnableResume = false
+			cfg.Backup.ResetAfterFailures = tt.threshold
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			accessor := &wedgedAccessor{FakeMTPAccessor: fake, failFrom: 3, connectErr: tt.connectErr}
+
+			modTime := time.Now().Add(-time.Hour)
+			var files []*utils.FileInfo
+			for i := 0; i < fileCount; i++ {
+				name := fmt.Sprintf("%d.opus", i)
+				path := "内部共享存储空间\\录音笔文件\\" + name
+				content := []byte(fmt.Sprintf("录音内容 %d", i))
+				fake.AddFile(path, content, modTime)
+				files = append(files, &utils.FileInfo{Path: path, RelativePath: name, Name: name, Size: int64(len(content))})
+			}
+
+			copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), deviceInfo)
+			copier.SetMTPInterface(accessor)
+
+			success, fatal := 0, 0
+			for result := range copier.CopyFiles(context.Background(), files, false) {
+				if result.Success {
+					success++
+				}
+				if errors.Is(result.Error, ErrDeviceResetFailed) {
+					fatal++
+					if !IsFatalCopyError(result.Error) {
+						t.Errorf("复位失败应视为致命错误: %v", result.Error)
+					}
+				}
+			}
+
+			if accessor.resets != tt.wantResets {
+				t.Errorf("复位次数 = %d, 期望 %d", accessor.resets, tt.wantResets)
+			}
+			if success != tt.wantSuccess {
+				t.Errorf("成功文件数 = %d, 期望 %d", success, tt.wantSuccess)
+			}
+			if fatal != tt.wantFatal {
+				t.Errorf("复位失败的结果数 = %d, 期望 %d", fatal, tt.wantFatal)
+			}
+		})
+	}
+}
+
+// slowAccessor 读取 slow 中的文件时每次 Read 都等待一段时间，并统计打开中的文件流
+type slowAccessor struct {
+	*device.FakeMTPAccessor
+	slow   map[string]bool
+	failed map[string]bool
+	active atomic.Int32
+}
+
+func (s *slowAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
+	if s.failed[filePath] {
+		return nil, device.NewRetryableMTPError(device.ERROR_DEVICE_BUSY, "设备无响应", nil)
+	}
+	stream, err := s.FakeMTPAccessor.GetFileStream(filePath)
+	if err != nil {
+		return nil, err
+	}
+	s.active.Add(1)
+	return &trackedStream{ReadCloser: stream, owner: s, slow: s.slow[filePath]}, nil
+}
+
+type trackedStream struct {
+	io.ReadCloser
+	owner  *slowAccessor
+	slow   bool
+	closed bool
+}
+
+func (t *trackedStream) Read(p []byte) (int, error) {
+	if t.slow {
+		time.Sleep(20 * time.Millisecond)
+		p = p[:min(len(p), 4)]
+	}
+	return t.ReadCloser.Read(p)
+}
+
+func (t *trackedStream) Close() error {
+	if !t.closed {
+		t.closed = true
+		t.owner.active.Add(-1)
+	}
+	return t.ReadCloser.Close()
+}
+
+// TestFileCopier_ResetWaitsForCopies 测试复位设备前等待其他 worker 进行中的复制结束，并通过复位函数换用新连接
+func TestFileCopier_ResetWaitsForCopies(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.MaxConcurrent = 3
+	cfg.Backup.EnableResume = false
+	cfg.Backup.CopyOrder = ""
+	cfg.Backup.ResetAfterFailures = 2
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	accessor := &slowAccessor{FakeMTPAccessor: fake, slow: map[string]bool{}, failed: map[string]bool{}}
+	fresh := device.NewFakeMTPAccessor(deviceInfo)
+
+	modTime := time.Now().Add(-time.Hour)
+	var files []*utils.FileInfo
+	for i, kind := range []string{"slow", "bad", "bad", "ok", "ok"} {
+		name := fmt.Sprintf("%d_%s.opus", i, kind)
+		path := "内部共享存储空间\\录音笔文件\\" + name
+		content := []byte(fmt.Sprintf("录音内容 %d", i))
+		fake.AddFile(path, content, modTime)
+		fresh.AddFile(path, content, modTime)
+		accessor.slow[path] = kind == "slow"
+		accessor.failed[path] = kind == "bad"
+		files = append(files, &utils.FileInfo{Path: path, RelativePath: name, Name: name, Size: int64(len(content))})
+	}
+
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), deviceInfo)
+	copier.SetMTPInterface(accessor)
+	var resets int
+	var activeAtReset int32 = -1
+	copier.SetDeviceReconnect(func() (device.MTPInterface, error) {
+		resets++
+		activeAtReset = accessor.active.Load()
+		return fresh, nil
+	})
+
+	success := 0
+	for result := range copier.CopyFiles(context.Background(), files, false) {
+		if result.Success {
+			success++
+		}
+	}
+
+	if resets != 1 {
+		t.Fatalf("复位次数 = %d, 期望 1", resets)
+	}
+	if activeAtReset != 0 {
+		t.Errorf("复位时仍有 %d 个打开的文件流，应等待进行中的复制结束", activeAtReset)
+	}
+	if success != 3 {
+		t.Errorf("成功文件数 = %d, 期望 3", success)
+	}
+	if !fake.IsConnected() {
+		t.Error("通过复位函数换用新连接时不应断开旧连接")
+	}
+}
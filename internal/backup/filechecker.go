@@ -2,6 +2,7 @@ package backup
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,6 +20,8 @@ type FileChecker struct {
 	config    *config.Config
 	log       *logger.Logger
 	tracker   *storage.BackupTracker
+	mtp       device.MTPInterface // 设备访问接口，为nil时通过设备桥接器连接
+	snapshotDir string            // 增量枚举快照所在目录，为空时不做增量枚举
 }
 
 // NewFileChecker 创建新的文件检查器
@@ -30,15 +33,36 @@ func NewFileChecker(cfg *config.Config, log *logger.Logger, tracker *storage.Bac
 	}
 }
 
+// SetMTPInterface 设置设备访问接口，设置后扫描时不再检测和连接设备
+func (fc *FileChecker) SetMTPInterface(mtp device.MTPInterface) {
+	fc.mtp = mtp
+}
+
+// SetSnapshotDir 设置增量枚举快照所在目录，设备访问接口支持逐层列出目录时只重新列出变化的目录
+func (fc *FileChecker) SetSnapshotDir(dir string) {
+	fc.snapshotDir = dir
+}
+
 // ScanDeviceFiles 扫描设备中的文件
 func (fc *FileChecker) ScanDeviceFiles(deviceInfo *device.DeviceInfo) ([]*utils.FileInfo, error) {
 	fc.log.Info("开始扫描设备文件: %s", deviceInfo.Name)
 
-	// 创建设备桥接器
-	bridge := device.NewDeviceBridge(fc.log, nil)
+	mtpInterface, release, err := fc.acquireDevice(deviceInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	// 使用设备桥接器连接和扫描
-	mtpInterface, err := bridge.DetectAndBridge(deviceInfo.Name)
+	return fc.scanMTPFiles(mtpInterface, deviceInfo.DeviceID)
+}
+
+// acquireDevice 获取设备访问接口，未设置时通过共享连接池获取，定时扫描时复用尚未空闲关闭的连接
+func (fc *FileChecker) acquireDevice(deviceInfo *device.DeviceInfo) (device.MTPInterface, func(), error) {
+	if fc.mtp != nil {
+		return fc.mtp, func() {}, nil
+	}
+
+	mtpInterface, release, err := device.SharedPool(fc.log).AcquireDevice(deviceInfo)
 	if err != nil {
 		fc.log.Error("无法访问MTP设备，扫描失败")
 		fc.log.Error("错误详情: %v", err)
@@ -48,53 +72,125 @@ func (fc *FileChecker) ScanDeviceFiles(deviceInfo *device.DeviceInfo) ([]*utils.
 		fc.log.Error("3. 设备是否已被其他程序占用")
 		fc.log.Error("4. Windows MTP协议支持是否正常")
 		fc.log.Error("5. PowerShell执行策略是否正确设置")
-		bridge.Close()
-		return nil, fmt.Errorf("设备桥接失败: %w", err)
+		return nil, nil, fmt.Errorf("设备桥接失败: %w", err)
+	}
+	return mtpInterface, release, nil
+}
+
+// scanMTPFiles 通过MTP接口列出录音文件，访问接口能给出可靠的子目录签名时基于上次的快照增量枚举
+func (fc *FileChecker) scanMTPFiles(mtpInterface device.MTPInterface, deviceID string) ([]*utils.FileInfo, error) {
+	lister := mtpInterface
+	var enumerator *device.IncrementalEnumerator
+	if fc.snapshotDir != "" {
+		if e, ok := device.NewIncrementalEnumerator(mtpInterface, device.EnumSnapshotPath(fc.snapshotDir, deviceID), fc.log); ok {
+			enumerator = e
+			lister = e
+		} else {
+			fc.log.Debug("访问接口无法给出可靠的目录签名，全量枚举")
+		}
 	}
-	defer mtpInterface.Close()
-	defer bridge.Close()
 
 	// 使用桥接的MTP接口扫描文件
-	mtpFiles, err := mtpInterface.ListFiles(fc.config.Source.BasePath)
+	mtpFiles, err := device.ListFilesInStorages(lister, fc.config.Source.BasePath, fc.config.Source.Storage, fc.log)
 	if err != nil {
 		return nil, fmt.Errorf("扫描MTP设备文件失败: %w", err)
 	}
+	if enumerator != nil {
+		stats := enumerator.Stats()
+		fc.log.Info("增量枚举: 列出 %d 个目录，%d 个目录未变化沿用上次结果", stats.ListedDirs, stats.ReusedDirs)
+	}
 
 	// 转换为utils.FileInfo格式
 	var files []*utils.FileInfo
+	encryptedCount := 0
+	attrSkipped := 0
 	for _, mtpFile := range mtpFiles {
-		// 检查文件是否为.opus格式
-		if !utils.IsOpusFile(mtpFile.Name) {
+		if fc.skipByAttributes(mtpFile) {
+			attrSkipped++
 			continue
 		}
-
-		fileInfo := &utils.FileInfo{
-			Path:         mtpFile.Path,
-			RelativePath: mtpFile.RelativePath,
-			Name:         mtpFile.Name,
-			Size:         mtpFile.Size,
-			IsOpus:       true,
+		fileInfo := fc.toFileInfo(mtpInterface, mtpFile)
+		if fileInfo == nil {
+			continue
 		}
-
-		// 处理ModTime字段
-		if modTime, ok := mtpFile.ModTime.(interface{}); ok {
-			if t, ok := modTime.(interface{ UnixNano() int64 }); ok {
-				fileInfo.ModTime = time.Unix(0, t.UnixNano())
-			} else {
-				fileInfo.ModTime = time.Now()
-			}
-		} else {
-			fileInfo.ModTime = time.Now()
+		if fileInfo.Encrypted {
+			encryptedCount++
 		}
-
 		files = append(files, fileInfo)
 		fc.log.Debug("发现文件: %s (%.2f MB)", fileInfo.RelativePath, float64(fileInfo.Size)/1024/1024)
 	}
 
 	fc.log.Info("扫描完成，发现 %d 个.opus文件", len(files))
+	if encryptedCount > 0 {
+		fc.log.Info("其中 %d 个为加密录音，将被跳过", encryptedCount)
+	}
+	if attrSkipped > 0 {
+		fc.log.Info("跳过 %d 个设备上的隐藏或系统文件", attrSkipped)
+	}
 	return files, nil
 }
 
+// skipByAttributes 判断设备文件是否因隐藏/系统属性被跳过，由 IncludeHidden/IncludeSystem 控制
+func (fc *FileChecker) skipByAttributes(mtpFile *device.FileInfo) bool {
+	attrs := mtpFile.Attributes
+	switch {
+	case attrs.System() && !fc.config.Source.IncludeSystem:
+		fc.log.Debug("跳过系统文件: %s", mtpFile.RelativePath)
+		return true
+	case attrs.Hidden() && !fc.config.Source.IncludeHidden:
+		fc.log.Debug("跳过隐藏文件: %s", mtpFile.RelativePath)
+		return true
+	}
+	if attrs.ReadOnly() {
+		fc.log.Debug("设备上的只读文件: %s", mtpFile.RelativePath)
+	}
+	return false
+}
+
+// toFileInfo 把设备文件转换为备份使用的文件信息，元数据文件和非录音文件返回 nil
+func (fc *FileChecker) toFileInfo(mtpInterface device.MTPInterface, mtpFile *device.FileInfo) *utils.FileInfo {
+	// 跳过备份生成的元数据文件
+	if IsSidecarFile(mtpFile.Name) {
+		return nil
+	}
+
+	// 检查文件是否为.opus格式或加密录音
+	isOpus := utils.IsOpusFile(mtpFile.Name)
+	if !isOpus && !utils.IsEncryptedExtension(mtpFile.Name, fc.config.Source.EncryptedExtensions) {
+		return nil
+	}
+
+	fileInfo := &utils.FileInfo{
+		Path:         mtpFile.Path,
+		RelativePath: mtpFile.RelativePath,
+		Name:         mtpFile.Name,
+		Size:         mtpFile.Size,
+		IsOpus:       isOpus,
+	}
+
+	// 识别加密录音（读取文件头仅在 DetectEncrypted 开启时进行）
+	path := mtpFile.Path
+	fileInfo.Encrypted = utils.DetectEncrypted(mtpFile.Name, fc.config.Source.EncryptedExtensions,
+		fc.config.Source.DetectEncrypted, func() (io.ReadCloser, error) {
+			return mtpInterface.GetFileStream(path)
+		})
+	if fileInfo.Encrypted {
+		fc.log.Debug("识别为加密录音: %s", fileInfo.RelativePath)
+	}
+
+	// 处理ModTime字段
+	if modTime, ok := mtpFile.ModTime.(interface{}); ok {
+		if t, ok := modTime.(interface{ UnixNano() int64 }); ok {
+			fileInfo.ModTime = time.Unix(0, t.UnixNano())
+		} else {
+			fileInfo.ModTime = time.Now()
+		}
+	} else {
+		fileInfo.ModTime = time.Now()
+	}
+	return fileInfo
+}
+
 // FilterFilesToBackup 过滤需要备份的文件
 func (fc *FileChecker) FilterFilesToBackup(allFiles []*utils.FileInfo, deviceID string, force bool) ([]*utils.FileInfo, error) {
 	if force {
@@ -0,0 +1,70 @@
+package backup
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// TestFileChecker_SkipByAttributes 测试默认跳过设备上的隐藏和系统文件，开启配置后包含
+func TestFileChecker_SkipByAttributes(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件\\"
+	tests := []struct {
+		name          string
+		includeHidden bool
+		includeSystem bool
+		want          []string
+	}{
+		{"默认跳过隐藏和系统文件", false, false, []string{"normal.opus", "readonly.opus"}},
+		{"包含隐藏文件", true, false, []string{"hidden.opus", "normal.opus", "readonly.opus"}},
+		{"包含系统文件", false, true, []string{"normal.opus", "readonly.opus", "system.opus"}},
+		{"全部包含", true, true, []string{"hidden.opus", "hidden_system.opus", "normal.opus", "readonly.opus", "system.opus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Source.IncludeHidden = tt.includeHidden
+			cfg.Source.IncludeSystem = tt.includeSystem
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			modTime := time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local)
+			attrs := map[string]device.FileAttributes{
+				"normal.opus":        0,
+				"readonly.opus":      device.AttrReadOnly,
+				"hidden.opus":        device.AttrHidden,
+				"system.opus":        device.AttrSystem | device.AttrReadOnly,
+				"hidden_system.opus": device.AttrHidden | device.AttrSystem,
+			}
+			for name, attr := range attrs {
+				fake.AddFile(base+name, []byte(name), modTime).Attributes = attr
+			}
+
+			checker := NewFileChecker(cfg, logger.NewLogger(false), nil)
+			checker.SetMTPInterface(fake)
+			checker.SetSnapshotDir(t.TempDir())
+
+			// 第二次扫描沿用增量枚举快照，属性同样生效
+			for round := 1; round <= 2; round++ {
+				files, err := checker.ScanDeviceFiles(deviceInfo)
+				if err != nil {
+					t.Fatalf("第 %d 次扫描失败: %v", round, err)
+				}
+				var names []string
+				for _, file := range files {
+					names = append(names, file.Name)
+				}
+				sort.Strings(names)
+				if !reflect.DeepEqual(names, tt.want) {
+					t.Errorf("第 %d 次扫描的文件 = %v，期望 %v", round, names, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 文件列表中的路径无法备份的原因
+var (
+	errFileNotOnDevice = errors.New("设备上不存在该文件")
+	errNotRecording    = errors.New("不是录音文件")
+)
+
+// ReadFileList 逐行读取设备文件的相对路径（相对于 source.base_path），
+// 忽略空行和 # 开头的注释，统一使用 \ 分隔并去掉重复的路径
+func ReadFileList(r io.Reader) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		path := normalizeListPath(line)
+		if path == "" || seen[strings.ToLower(path)] {
+			continue
+		}
+		seen[strings.ToLower(path)] = true
+		paths = append(paths, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件列表失败: %w", err)
+	}
+	return paths, nil
+}
+
+// normalizeListPath 把 / 换成 \ 并去掉首尾的分隔符
+func normalizeListPath(path string) string {
+	return strings.Trim(strings.ReplaceAll(path, "/", "\\"), "\\")
+}
+
+// ResolveFileList 按相对路径定位设备上的文件，只列出这些文件所在的目录，不枚举整个设备
+// 返回找到的录音文件，设备上不存在或不是录音文件的路径作为失败的复制结果返回
+func (fc *FileChecker) ResolveFileList(deviceInfo *device.DeviceInfo, paths []string) ([]*utils.FileInfo, []*CopyResult, error) {
+	mtpInterface, release, err := fc.acquireDevice(deviceInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	// 同一目录下的文件只列出一次
+	listings := make(map[string][]*device.FileInfo)
+	listErrs := make(map[string]error)
+
+	var files []*utils.FileInfo
+	var invalid []*CopyResult
+	reject := func(path, name string, err error) {
+		fc.log.Error("文件列表中的路径无效: %s, %v", path, err)
+		invalid = append(invalid, &CopyResult{
+			File:  &utils.FileInfo{Path: path, RelativePath: path, Name: name},
+			Error: fmt.Errorf("%s: %w", path, err),
+		})
+	}
+	for _, path := range paths {
+		dir, name := "", path
+		if i := strings.LastIndex(path, "\\"); i >= 0 {
+			dir, name = path[:i], path[i+1:]
+		}
+
+		if _, ok := listings[dir]; !ok && listErrs[dir] == nil {
+			dirPath := fc.config.Source.BasePath
+			if dir != "" {
+				dirPath = strings.TrimRight(dirPath, "\\") + "\\" + dir
+			}
+			listing, err := device.ListFilesInStorages(mtpInterface, dirPath, fc.config.Source.Storage, fc.log)
+			if err != nil {
+				listErrs[dir] = err
+			} else {
+				listings[dir] = listing
+			}
+		}
+		if err := listErrs[dir]; err != nil {
+			reject(path, name, fmt.Errorf("无法列出设备目录: %w", err))
+			continue
+		}
+
+		var found *device.FileInfo
+		for _, mtpFile := range listings[dir] {
+			if strings.EqualFold(normalizeListPath(mtpFile.RelativePath), name) {
+				found = mtpFile
+				break
+			}
+		}
+		if found == nil {
+			reject(path, name, errFileNotOnDevice)
+			continue
+		}
+
+		fileInfo := fc.toFileInfo(mtpInterface, found)
+		if fileInfo == nil {
+			reject(path, name, errNotRecording)
+			continue
+		}
+		fileInfo.RelativePath = path
+		files = append(files, fileInfo)
+	}
+
+	fc.log.Info("按文件列表定位到 %d 个文件，%d 个路径无效", len(files), len(invalid))
+	return files, invalid, nil
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// TestReadFileList 测试读取文件列表时的空行、注释、分隔符和重复路径处理
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"逐行读取", "a.opus\nb.opus\n", []string{"a.opus", "b.opus"}},
+		{"忽略空行和注释", "\n# 今天的会议\n  a.opus  \n\r\n", []string{"a.opus"}},
+		{"统一分隔符", "2024/03/a.opus\n\\2024\\03\\b.opus\r\n", []string{"2024\\03\\a.opus", "2024\\03\\b.opus"}},
+		{"去掉重复路径", "a.opus\nA.OPUS\n/a.opus\n", []string{"a.opus"}},
+		{"带BOM", "\ufeffa.opus\n", []string{"a.opus"}},
+		{"空输入", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFileList(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("读取失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFileList() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBackupManager_FileList 测试按标准输入中的文件列表备份：只复制列出的文件，无效路径计为失败但不中断
+func TestBackupManager_FileList(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+	cfg.Backup.SyncMode = SyncModeMirror
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+
+	modTime := time.Now().Add(-time.Hour)
+	contents := map[string][]byte{
+		"a.opus":         bytes.Repeat([]byte("a"), 1024),
+		"b.opus":         bytes.Repeat([]byte("b"), 1024),
+		"子目录\\c.opus":    bytes.Repeat([]byte("c"), 512),
+		"子目录\\notes.txt": []byte("备注"),
+	}
+	for path, content := range contents {
+		fake.AddFile("内部共享存储空间\\录音笔文件\\"+path, content, modTime)
+	}
+
+	// 已备份的 b.opus 不在列表中，镜像模式下也不应被清理
+	bTarget := filepath.Join(cfg.Target.BaseDirectory, "b.opus")
+	if err := os.MkdirAll(cfg.Target.BaseDirectory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bTarget, contents["b.opus"], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.AddRecord("内部共享存储空间\\录音笔文件\\b.opus", bTarget, deviceInfo.DeviceID, 1024, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := "a.opus\n子目录/c.opus\nmissing.opus\n子目录/notes.txt\n"
+	paths, err := ReadFileList(strings.NewReader(stdin))
+	if err != nil {
+		t.Fatalf("读取文件列表失败: %v", err)
+	}
+
+	bm := &BackupManager{config: cfg, log: log, tracker: tracker, quiet: true}
+	bm.SetMTPInterface(fake)
+	bm.SetFileList(paths)
+
+	summary, err := bm.Run(context.Background(), deviceInfo, false)
+	if err == nil {
+		t.Error("存在无效路径时应返回错误")
+	}
+	if summary == nil || summary.Succeeded != 2 || summary.Failed != 2 {
+		t.Fatalf("运行概况 = %+v, 期望成功2 失败2", summary)
+	}
+
+	for path, name := range map[string]string{"a.opus": "a.opus", "子目录\\c.opus": filepath.Join("子目录", "c.opus")} {
+		data, err := os.ReadFile(filepath.Join(cfg.Target.BaseDirectory, name))
+		if err != nil || !bytes.Equal(data, contents[path]) {
+			t.Errorf("%s 应被复制且内容一致: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Target.BaseDirectory, "子目录", "notes.txt")); !os.IsNotExist(err) {
+		t.Error("非录音文件不应被复制")
+	}
+	if _, err := os.Stat(bTarget); err != nil {
+		t.Errorf("列表外的备份不应被镜像清理: %v", err)
+	}
+
+	// 无效路径以失败结果报告
+	_, invalid, err := bm.createFileChecker(deviceInfo).ResolveFileList(deviceInfo, paths)
+	if err != nil {
+		t.Fatalf("定位文件失败: %v", err)
+	}
+	if len(invalid) != 2 {
+		t.Fatalf("期望 2 个无效路径，实际 %d", len(invalid))
+	}
+	if invalid[0].File.RelativePath != "missing.opus" || !errors.Is(invalid[0].Error, errFileNotOnDevice) {
+		t.Errorf("missing.opus 应报告为设备上不存在: %v", invalid[0].Error)
+	}
+	if invalid[1].File.RelativePath != "子目录\\notes.txt" || !errors.Is(invalid[1].Error, errNotRecording) {
+		t.Errorf("notes.txt 应报告为不是录音文件: %v", invalid[1].Error)
+	}
+}
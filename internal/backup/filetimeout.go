@@ -0,0 +1,226 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/store"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// errFileTimeout 单个文件复制超过 per_file_timeout 时的错误，该文件标记失败，其余文件继续复制
+var errFileTimeout = errors.New("单文件复制超时")
+
+// DefaultFileTimeoutGrace 超时取消后等待复制退出的时长，不响应取消的复制（如阻塞在设备读取中）超过该时长后被放弃
+const DefaultFileTimeoutGrace = 30 * time.Second
+
+// 超时控制下复制 goroutine 的结束方式，先到者生效
+const (
+	copyRunning int32 = iota
+	copyReturned
+	copyAbandoned
+)
+
+// FileTimeout 单文件复制超时：基础超时加上按最低速度传完文件所需的时间
+type FileTimeout struct {
+	Base     time.Duration // 基础超时，0表示不限制
+	MinSpeed int64         // 假定的最低速度（字节/秒），0表示不按大小延长
+}
+
+// ParseFileTimeout 解析 backup.per_file_timeout 和 backup.per_file_min_speed
+func ParseFileTimeout(cfg *config.BackupConfig) (FileTimeout, error) {
+	var timeout FileTimeout
+	if cfg.PerFileTimeout != "" {
+		base, err := utils.ParseDuration(cfg.PerFileTimeout)
+		if err != nil {
+			return timeout, fmt.Errorf("解析单文件超时失败: %w", err)
+		}
+		timeout.Base = base
+	}
+	if cfg.PerFileMinSpeed != "" {
+		speed, err := utils.ParseByteSize(cfg.PerFileMinSpeed)
+		if err != nil {
+			return timeout, fmt.Errorf("解析单文件超时最低速度失败: %w", err)
+		}
+		timeout.MinSpeed = speed
+	}
+	return timeout, nil
+}
+
+// For 返回复制 size 字节的文件允许的时长，未开启时返回0
+func (t FileTimeout) For(size int64) time.Duration {
+	if t.Base <= 0 {
+		return 0
+	}
+	if t.MinSpeed <= 0 || size <= 0 {
+		return t.Base
+	}
+	return t.Base + time.Duration(float64(size)/float64(t.MinSpeed)*float64(time.Second))
+}
+
+// copyWithTimeout 在单文件超时内执行复制，超时后结束该文件的PowerShell复制进程、关闭已打开的设备文件流，
+// 最多再等 timeoutGrace 让复制退出，退出后返回失败结果，此前并发名额和目标文件仍由该文件占用；
+// 仍未退出（如阻塞在不响应关闭的设备调用中）时放弃它：删除写了一半的目标临时文件并返回失败，不再卡住整批复制
+// 超时与 Ctrl+C 无关：取消备份只是不再开始新文件，已开始的文件仍按超时处理
+func (fc *FileCopier) copyWithTimeout(file *utils.FileInfo, force bool) *CopyResult {
+	timeout := fc.fileTimeout.For(file.Size)
+	if timeout <= 0 {
+		return fc.copyFunc(file, force)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(context.Background(), timeout, errFileTimeout)
+	defer cancel()
+	fc.fileContexts.Store(file.Path, ctx)
+
+	var state atomic.Int32
+	done := make(chan *CopyResult, 1)
+	go func() {
+		defer fc.log.RecoverPanic()
+		defer fc.fileContexts.CompareAndDelete(file.Path, ctx)
+		result := fc.copyFunc(file, force)
+		if state.CompareAndSwap(copyRunning, copyReturned) {
+			done <- result
+			return
+		}
+		if result.Success {
+			fc.log.Warn("已放弃的文件稍后复制完成，下次备份时视为已备份: %s", file.RelativePath)
+		} else {
+			fc.log.Debug("已放弃的文件复制结束: %s, %v", file.RelativePath, result.Error)
+		}
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-ctx.Done():
+	}
+
+	fc.log.Error("复制文件超时（%s），取消该文件并继续: %s", utils.FormatDuration(timeout), file.RelativePath)
+	timeoutErr := fmt.Errorf("%w（%s）: %s", errFileTimeout, utils.FormatDuration(timeout), file.RelativePath)
+
+	var result *CopyResult
+	select {
+	case result = <-done:
+	case <-time.After(fc.timeoutGrace):
+		if state.CompareAndSwap(copyRunning, copyAbandoned) {
+			fc.log.Error("取消后 %s 内复制仍未退出，放弃该文件: %s", utils.FormatDuration(fc.timeoutGrace), file.RelativePath)
+			fc.discardAbandonedTarget(file)
+			return &CopyResult{File: file, Error: timeoutErr}
+		}
+		// 放弃前复制刚好退出
+		result = <-done
+	}
+
+	if result.Success {
+		// 取消生效前复制已经完成，以实际结果为准
+		fc.log.Warn("文件在超时取消前已复制完成: %s", file.RelativePath)
+		return result
+	}
+	result.Error = timeoutErr
+	result.Skipped = false
+	return result
+}
+
+// discardAbandonedTarget 删除被放弃的复制写了一半的本地目标临时文件
+// 目标文件写完后才由临时文件重命名而来，已有的目标文件不会被改动，保留
+func (fc *FileCopier) discardAbandonedTarget(file *utils.FileInfo) {
+	if fc.archive != nil || fc.isRemoteStore() {
+		return
+	}
+	targetPath, err := fc.getTargetPath(file)
+	if err != nil {
+		return
+	}
+	partPath := targetPath + store.PartSuffix
+	if err := os.Remove(partPath); err != nil {
+		if !os.IsNotExist(err) {
+			fc.log.Warn("删除被放弃的目标临时文件失败: %s, %v", partPath, err)
+		}
+		return
+	}
+	fc.log.Info("已删除被放弃的目标临时文件: %s", partPath)
+}
+
+// fileContext 返回文件的单文件超时 context，未处于超时控制下时返回 context.Background()
+func (fc *FileCopier) fileContext(file *utils.FileInfo) context.Context {
+	if value, ok := fc.fileContexts.Load(file.Path); ok {
+		return value.(context.Context)
+	}
+	return context.Background()
+}
+
+// openFileStream 打开设备文件流，文件处于单文件超时控制下时，超时会关闭文件流以中断阻塞的读取
+// 文件已通过复制前校验时打开校验过的临时文件，保存的内容就是校验过的内容
+func (fc *FileCopier) openFileStream(file *utils.FileInfo) (io.ReadCloser, error) {
+	if value, ok := fc.checked.Load(file.Path); ok {
+		return os.Open(value.(string))
+	}
+	stream, err := fc.openStream(file)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := fc.fileContexts.Load(file.Path); ok {
+		return newCancelableStream(value.(context.Context), stream), nil
+	}
+	return stream, nil
+}
+
+// cancelableStream context 结束时关闭底层文件流，之后的读取返回 context 的原因
+type cancelableStream struct {
+	io.ReadCloser
+	ctx       context.Context
+	stop      func() bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newCancelableStream 包装文件流，ctx 结束时关闭它
+func newCancelableStream(ctx context.Context, stream io.ReadCloser) *cancelableStream {
+	s := &cancelableStream{ReadCloser: stream, ctx: ctx}
+	s.stop = context.AfterFunc(ctx, func() { s.closeStream() })
+	return s
+}
+
+// Seek 底层文件流支持定位时转发，否则返回 errors.ErrUnsupported
+func (s *cancelableStream) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := s.ReadCloser.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("文件流不支持定位: %w", errors.ErrUnsupported)
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// Read 读取底层文件流，context 已结束时返回其原因
+func (s *cancelableStream) Read(p []byte) (int, error) {
+	if err := context.Cause(s.ctx); err != nil {
+		return 0, err
+	}
+	n, err := s.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		if cause := context.Cause(s.ctx); cause != nil {
+			return n, cause
+		}
+	}
+	return n, err
+}
+
+// Close 关闭文件流并停止监听 context
+func (s *cancelableStream) Close() error {
+	s.stop()
+	return s.closeStream()
+}
+
+// closeStream 只关闭底层文件流一次
+func (s *cancelableStream) closeStream() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.ReadCloser.Close()
+	})
+	return s.closeErr
+}
@@ -0,0 +1,170 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/store"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileTimeout_For 测试超时随文件大小按最低速度延长
+func TestFileTimeout_For(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout FileTimeout
+		size    int64
+		want    time.Duration
+	}{
+		{"未开启", FileTimeout{MinSpeed: 1024}, 1 << 20, 0},
+		{"不按大小延长", FileTimeout{Base: time.Minute}, 1 << 30, time.Minute},
+		{"小文件", FileTimeout{Base: time.Minute, MinSpeed: 1024}, 10 * 1024, time.Minute + 10*time.Second},
+		{"大文件更长", FileTimeout{Base: time.Minute, MinSpeed: 64 * 1024}, 64 << 20, time.Minute + 1024*time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.timeout.For(tt.size); got != tt.want {
+				t.Errorf("超时 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	timeout, err := ParseFileTimeout(&config.BackupConfig{PerFileTimeout: "10m", PerFileMinSpeed: "64KB"})
+	if err != nil || timeout.Base != 10*time.Minute || timeout.MinSpeed != 64*1024 {
+		t.Errorf("解析配置 = %+v, %v", timeout, err)
+	}
+}
+
+// TestFileCopier_PerFileTimeout 测试读取卡住的文件超时后被取消并标记失败，其余文件正常复制
+func TestFileCopier_PerFileTimeout(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件\\"
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+	cfg.Backup.MaxConcurrent = 1
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	modTime := time.Now().Add(-time.Hour)
+	names := []string{"a.opus", "broken.opus", "c.opus"}
+	var files []*utils.FileInfo
+	for _, name := range names {
+		fake.AddFile(base+name, []byte("content of "+name), modTime)
+		files = append(files, &utils.FileInfo{Path: base + name, RelativePath: name, Name: name, Size: int64(len("content of " + name))})
+	}
+	fake.HangStream(base + "broken.opus")
+
+	copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+	copier.SetMTPInterface(fake)
+	copier.fileTimeout = FileTimeout{Base: 200 * time.Millisecond}
+
+	start := time.Now()
+	results := make(map[string]*CopyResult)
+	for result := range copier.CopyFiles(context.Background(), files, true) {
+		results[result.File.Name] = result
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("卡住的文件拖慢了整批复制: %v", elapsed)
+	}
+
+	if broken := results["broken.opus"]; broken == nil || broken.Success || !errors.Is(broken.Error, errFileTimeout) {
+		t.Errorf("卡住的文件应因超时失败: %+v", broken)
+	}
+	for _, name := range []string{"a.opus", "c.opus"} {
+		if result := results[name]; result == nil || !result.Success {
+			t.Errorf("文件 %s 应正常复制: %+v", name, result)
+		}
+	}
+
+	// 超时后关闭文件流，中断阻塞的读取
+	deadline := time.Now().Add(time.Second)
+	for !fake.HungStreamClosed(base+"broken.opus") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !fake.HungStreamClosed(base + "broken.opus") {
+		t.Error("超时后应关闭卡住的文件流")
+	}
+}
+
+// TestFileCopier_TimeoutWaitsForCopy 测试超时后等被取消的复制退出才返回，之后不会再有写入
+func TestFileCopier_TimeoutWaitsForCopy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	copier.fileTimeout = FileTimeout{Base: 50 * time.Millisecond}
+
+	var exited atomic.Bool
+	copier.copyFunc = func(file *utils.FileInfo, force bool) *CopyResult {
+		// 模拟在设备调用中阻塞，直到超时 context 结束才退出
+		<-copier.fileContext(file).Done()
+		time.Sleep(50 * time.Millisecond)
+		exited.Store(true)
+		return &CopyResult{File: file, Error: context.Cause(copier.fileContext(file))}
+	}
+
+	file := &utils.FileInfo{Path: "device\\slow.opus", RelativePath: "slow.opus", Name: "slow.opus", Size: 10}
+	result := copier.copyWithTimeout(file, true)
+	if !exited.Load() {
+		t.Error("超时后应等被取消的复制退出再返回")
+	}
+	if result.Success || !errors.Is(result.Error, errFileTimeout) {
+		t.Errorf("应返回超时失败: %+v", result)
+	}
+}
+
+// TestFileCopier_TimeoutAbandonsStuckCopy 测试取消后仍不退出的复制在宽限时间后被放弃，删除写了一半的目标临时文件，已有的目标文件保留
+func TestFileCopier_TimeoutAbandonsStuckCopy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	copier.fileTimeout = FileTimeout{Base: 50 * time.Millisecond}
+	copier.timeoutGrace = 50 * time.Millisecond
+
+	file := &utils.FileInfo{Path: "device\\stuck.opus", RelativePath: "stuck.opus", Name: "stuck.opus", Size: 10}
+	targetPath, err := copier.getTargetPath(file)
+	if err != nil {
+		t.Fatalf("获取目标路径失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targetPath, []byte("上次备份"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	partPath := targetPath + store.PartSuffix
+
+	release := make(chan struct{})
+	defer close(release)
+	copier.copyFunc = func(file *utils.FileInfo, force bool) *CopyResult {
+		// 写入部分内容后阻塞在不响应取消的调用中
+		os.WriteFile(partPath, []byte("part"), 0644)
+		<-release
+		return &CopyResult{File: file, Success: true}
+	}
+
+	start := time.Now()
+	result := copier.copyWithTimeout(file, true)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("不退出的复制应在宽限时间后被放弃，实际等待 %v", elapsed)
+	}
+	if result.Success || !errors.Is(result.Error, errFileTimeout) {
+		t.Errorf("应返回超时失败: %+v", result)
+	}
+	if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+		t.Errorf("被放弃的复制写了一半的临时文件应被删除: %v", err)
+	}
+	if data, err := os.ReadFile(targetPath); err != nil || string(data) != "上次备份" {
+		t.Errorf("已有的目标文件应保留: %q, %v", data, err)
+	}
+}
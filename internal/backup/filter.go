@@ -0,0 +1,228 @@
+package backup
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// FileFilter 文件过滤器，拒绝时返回跳过原因
+type FileFilter interface {
+	Keep(file *utils.FileInfo) (keep bool, reason string)
+}
+
+// FileFilterFunc 把函数用作过滤器，便于组合自定义逻辑
+type FileFilterFunc func(file *utils.FileInfo) (bool, string)
+
+// Keep 调用函数本身
+func (f FileFilterFunc) Keep(file *utils.FileInfo) (bool, string) {
+	return f(file)
+}
+
+// FilterRejection 被过滤器跳过的文件及原因
+type FilterRejection struct {
+	File   *utils.FileInfo
+	Reason string
+}
+
+// FilterChain 按顺序应用的过滤器，任一过滤器拒绝即跳过文件，不再询问后面的过滤器
+type FilterChain []FileFilter
+
+// Apply 依次应用过滤器，返回保留的文件和被跳过的文件
+func (c FilterChain) Apply(files []*utils.FileInfo) ([]*utils.FileInfo, []FilterRejection) {
+	if len(c) == 0 {
+		return files, nil
+	}
+
+	kept := make([]*utils.FileInfo, 0, len(files))
+	var rejected []FilterRejection
+	for _, file := range files {
+		if ok, reason := c.Keep(file); !ok {
+			rejected = append(rejected, FilterRejection{File: file, Reason: reason})
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept, rejected
+}
+
+// Keep 过滤链本身也是过滤器，可以嵌套组合
+func (c FilterChain) Keep(file *utils.FileInfo) (bool, string) {
+	for _, filter := range c {
+		if ok, reason := filter.Keep(file); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+// NewConfigFilterChain 按 backup.filter 配置组装过滤链，now 用于计算 max_age 的起始时间
+func NewConfigFilterChain(cfg *config.FilterConfig, now time.Time) (FilterChain, error) {
+	var chain FilterChain
+
+	size := &SizeFilter{}
+	var err error
+	if cfg.MinSize != "" {
+		if size.Min, err = utils.ParseByteSize(cfg.MinSize); err != nil {
+			return nil, fmt.Errorf("解析过滤最小大小失败: %w", err)
+		}
+	}
+	if cfg.MaxSize != "" {
+		if size.Max, err = utils.ParseByteSize(cfg.MaxSize); err != nil {
+			return nil, fmt.Errorf("解析过滤最大大小失败: %w", err)
+		}
+	}
+	if size.Min > 0 || size.Max > 0 {
+		chain = append(chain, size)
+	}
+
+	modified := &TimeFilter{}
+	if cfg.ModifiedAfter != "" {
+		if modified.After, err = time.ParseInLocation(config.FilterDateLayout, cfg.ModifiedAfter, time.Local); err != nil {
+			return nil, fmt.Errorf("解析过滤起始日期失败: %w", err)
+		}
+	}
+	if cfg.MaxAge != "" {
+		maxAge, err := utils.ParseDuration(cfg.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("解析过滤最长时间失败: %w", err)
+		}
+		// 两个条件都设置时取较晚的起始时间
+		if since := now.Add(-maxAge); maxAge > 0 && since.After(modified.After) {
+			modified.After = since
+		}
+	}
+	if !modified.After.IsZero() {
+		chain = append(chain, modified)
+	}
+
+	if len(cfg.Include) > 0 || len(cfg.Exclude) > 0 {
+		chain = append(chain, &GlobFilter{Include: cfg.Include, Exclude: cfg.Exclude})
+	}
+	return chain, nil
+}
+
+// SizeFilter 按文件大小过滤，Min/Max 为0表示不限制
+type SizeFilter struct {
+	Min int64
+	Max int64
+}
+
+// Keep 检查文件大小是否在范围内
+func (f *SizeFilter) Keep(file *utils.FileInfo) (bool, string) {
+	if f.Min > 0 && file.Size < f.Min {
+		return false, fmt.Sprintf("文件小于 %s", utils.FormatBytes(f.Min))
+	}
+	if f.Max > 0 && file.Size > f.Max {
+		return false, fmt.Sprintf("文件大于 %s", utils.FormatBytes(f.Max))
+	}
+	return true, ""
+}
+
+// TimeFilter 按修改时间过滤，零值表示不限制；修改时间未知的文件不过滤
+type TimeFilter struct {
+	After  time.Time // 早于该时间修改的文件被跳过
+	Before time.Time // 晚于该时间修改的文件被跳过
+}
+
+// Keep 检查修改时间是否在范围内
+func (f *TimeFilter) Keep(file *utils.FileInfo) (bool, string) {
+	if file.ModTime.IsZero() {
+		return true, ""
+	}
+	if !f.After.IsZero() && file.ModTime.Before(f.After) {
+		return false, fmt.Sprintf("修改时间早于 %s", f.After.Format("2006-01-02 15:04"))
+	}
+	if !f.Before.IsZero() && file.ModTime.After(f.Before) {
+		return false, fmt.Sprintf("修改时间晚于 %s", f.Before.Format("2006-01-02 15:04"))
+	}
+	return true, ""
+}
+
+// GlobFilter 按 glob 模式过滤，不区分大小写；不含 / 的模式匹配文件名，否则匹配以 / 分隔的相对路径
+type GlobFilter struct {
+	Include []string // 非空时文件须匹配其中一个模式
+	Exclude []string // 匹配其中任一模式的文件被跳过
+}
+
+// Keep 先检查排除规则，再检查包含规则
+func (f *GlobFilter) Keep(file *utils.FileInfo) (bool, string) {
+	for _, pattern := range f.Exclude {
+		if globMatch(pattern, file) {
+			return false, fmt.Sprintf("匹配排除规则 %s", pattern)
+		}
+	}
+	if len(f.Include) == 0 {
+		return true, ""
+	}
+	for _, pattern := range f.Include {
+		if globMatch(pattern, file) {
+			return true, ""
+		}
+	}
+	return false, "不匹配任何包含规则"
+}
+
+// globMatch 按模式匹配文件名或相对路径，无效的模式视为不匹配
+func globMatch(pattern string, file *utils.FileInfo) bool {
+	name := file.Name
+	if strings.Contains(pattern, "/") {
+		name = strings.Trim(strings.ReplaceAll(file.RelativePath, "\\", "/"), "/")
+		pattern = strings.Trim(pattern, "/")
+	}
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return err == nil && matched
+}
+
+// BackedUpFilter 跳过已有备份记录的文件，按源路径或枚举时预计算的内容哈希判断
+type BackedUpFilter struct {
+	tracker *storage.BackupTracker
+}
+
+// NewBackedUpFilter 创建已备份过滤器
+func NewBackedUpFilter(tracker *storage.BackupTracker) *BackedUpFilter {
+	return &BackedUpFilter{tracker: tracker}
+}
+
+// Keep 检查文件是否已经备份
+func (f *BackedUpFilter) Keep(file *utils.FileInfo) (bool, string) {
+	if backedUp, record, err := f.tracker.IsFileBackedUp(file.Path); err == nil && backedUp && !record.SourceChanged(file) {
+		return false, "已备份"
+	}
+	if file.Hash != "" {
+		if backedUp, _ := f.tracker.IsHashBackedUp(file.Hash); backedUp {
+			return false, "相同内容已备份"
+		}
+	}
+	return true, ""
+}
+
+// summarizeRejections 按原因统计被跳过的文件数，按数量降序排列，如 "已备份 12, 文件小于 10.0 KiB 3"
+func summarizeRejections(rejected []FilterRejection) string {
+	counts := make(map[string]int)
+	for _, r := range rejected {
+		counts[r.Reason]++
+	}
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%s %d", reason, counts[reason])
+	}
+	return strings.Join(parts, ", ")
+}
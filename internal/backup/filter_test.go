@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFilterChain_Apply 测试组合过滤链：文件通过或被各过滤器拒绝时记录对应原因
+func TestFilterChain_Apply(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	log := logger.NewLogger(false)
+
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), log)
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("加载备份记录失败: %v", err)
+	}
+	if err := tracker.AddRecord("device\\REC\\done.opus", "D:\\backup\\done.opus", "dev", 2048, "hash-done"); err != nil {
+		t.Fatalf("添加备份记录失败: %v", err)
+	}
+
+	chain, err := NewConfigFilterChain(&config.FilterConfig{
+		MinSize:       "1KB",
+		MaxSize:       "1MB",
+		ModifiedAfter: "2024-01-01",
+		MaxAge:        "720h",
+		Include:       []string{"*.opus", "music/*.mp3"},
+		Exclude:       []string{"*_tmp.opus"},
+	}, now)
+	if err != nil {
+		t.Fatalf("组装过滤链失败: %v", err)
+	}
+	if len(chain) != 3 {
+		t.Fatalf("期望大小、时间、glob 3 个过滤器，实际 %d 个", len(chain))
+	}
+
+	// 自定义过滤器和已备份过滤器接在配置的过滤器之后
+	chain = append(chain, FileFilterFunc(func(file *utils.FileInfo) (bool, string) {
+		if strings.HasPrefix(file.Name, "private") {
+			return false, "私密录音"
+		}
+		return true, ""
+	}), NewBackedUpFilter(tracker))
+
+	recent := now.Add(-24 * time.Hour)
+	newFile := func(relativePath string, size int64, modTime time.Time) *utils.FileInfo {
+		return &utils.FileInfo{
+			Path:         "device\\" + relativePath,
+			RelativePath: relativePath,
+			Name:         filepath.Base(strings.ReplaceAll(relativePath, "\\", "/")),
+			Size:         size,
+			ModTime:      modTime,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		file       *utils.FileInfo
+		wantKeep   bool
+		wantReason string
+	}{
+		{"满足所有条件", newFile("REC\\meeting.opus", 4096, recent), true, ""},
+		{"包含规则按相对路径匹配", newFile("MUSIC\\song.mp3", 4096, recent), true, ""},
+		{"修改时间未知时不按时间过滤", newFile("REC\\unknown.opus", 4096, time.Time{}), true, ""},
+		{"文件过小", newFile("REC\\tiny.opus", 100, recent), false, "文件小于 1.0 KiB"},
+		{"文件过大", newFile("REC\\huge.opus", 2<<20, recent), false, "文件大于 1.0 MiB"},
+		{"超过最长时间", newFile("REC\\old.opus", 4096, now.AddDate(0, 0, -40)), false, "修改时间早于 2024-05-02 12:00"},
+		{"匹配排除规则", newFile("REC\\draft_tmp.opus", 4096, recent), false, "匹配排除规则 *_tmp.opus"},
+		{"不匹配包含规则", newFile("REC\\note.txt", 4096, recent), false, "不匹配任何包含规则"},
+		{"自定义过滤器拒绝", newFile("REC\\private_call.opus", 4096, recent), false, "私密录音"},
+		{"已备份", newFile("REC\\done.opus", 2048, recent), false, "已备份"},
+		{"靠前的过滤器先拒绝", newFile("REC\\private_tmp.opus", 10, recent), false, "文件小于 1.0 KiB"},
+	}
+
+	files := make([]*utils.FileInfo, len(tests))
+	for i, tt := range tests {
+		files[i] = tt.file
+	}
+	kept, rejected := chain.Apply(files)
+
+	reasons := make(map[*utils.FileInfo]string)
+	for _, r := range rejected {
+		reasons[r.File] = r.Reason
+	}
+	keptSet := make(map[*utils.FileInfo]bool)
+	for _, file := range kept {
+		keptSet[file] = true
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if keptSet[tt.file] != tt.wantKeep {
+				t.Errorf("保留 = %v，期望 %v（原因: %s）", keptSet[tt.file], tt.wantKeep, reasons[tt.file])
+			}
+			if reasons[tt.file] != tt.wantReason {
+				t.Errorf("跳过原因 = %q，期望 %q", reasons[tt.file], tt.wantReason)
+			}
+		})
+	}
+
+	if len(kept)+len(rejected) != len(files) {
+		t.Errorf("保留 %d 个、跳过 %d 个，总数应为 %d", len(kept), len(rejected), len(files))
+	}
+}
+
+// TestFilterChain_Hash 测试预计算的内容哈希已备份时跳过改名的文件
+func TestFilterChain_Hash(t *testing.T) {
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), logger.NewLogger(false))
+	if err := tracker.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.AddRecord("device\\a.opus", "D:\\backup\\a.opus", "dev", 10, "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	chain := FilterChain{NewBackedUpFilter(tracker)}
+	renamed := &utils.FileInfo{Path: "device\\b.opus", RelativePath: "b.opus", Name: "b.opus", Hash: "abc"}
+	fresh := &utils.FileInfo{Path: "device\\c.opus", RelativePath: "c.opus", Name: "c.opus", Hash: "def"}
+
+	kept, rejected := chain.Apply([]*utils.FileInfo{renamed, fresh})
+	if len(kept) != 1 || kept[0] != fresh {
+		t.Errorf("只应保留未备份的文件: %+v", kept)
+	}
+	if len(rejected) != 1 || rejected[0].Reason != "相同内容已备份" {
+		t.Errorf("跳过原因不正确: %+v", rejected)
+	}
+	if got := summarizeRejections(rejected); got != "相同内容已备份 1" {
+		t.Errorf("原因统计 = %q", got)
+	}
+}
+
+// TestNewConfigFilterChain_Empty 测试未配置过滤条件时过滤链为空，所有文件通过
+func TestNewConfigFilterChain_Empty(t *testing.T) {
+	chain, err := NewConfigFilterChain(&config.FilterConfig{}, time.Now())
+	if err != nil || len(chain) != 0 {
+		t.Fatalf("期望空过滤链，实际 %d 个过滤器, %v", len(chain), err)
+	}
+	files := []*utils.FileInfo{{Name: "a.opus", Size: 1}}
+	if kept, rejected := chain.Apply(files); len(kept) != 1 || len(rejected) != 0 {
+		t.Errorf("空过滤链应保留所有文件")
+	}
+}
@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"os"
+
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// calculateTargetHash 按配置的算法计算目标文件哈希，未开启完整性验证时使用SHA256
+func (fc *FileCopier) calculateTargetHash(path string) (string, error) {
+	if fc.config.Backup.IntegrityCheck {
+		return NewIntegrityVerifier(fc.log, fc.config.Backup.HashAlgorithm).CalculateFileHash(path)
+	}
+	return utils.CalculateFileHash(path)
+}
+
+// verifyBackedUpTarget 验证已备份文件的目标是否与记录的哈希一致
+// 目标文件的修改时间和大小与记录中缓存的一致时直接信任记录的哈希，变化后才重新计算并更新缓存
+// 目标不是本地文件（归档条目、远程对象）或记录没有哈希时无法验证，视为一致
+func (fc *FileCopier) verifyBackedUpTarget(record *storage.BackupRecord) bool {
+	if record.FileHash == "" {
+		return true
+	}
+	info, err := os.Stat(record.TargetPath)
+	if err != nil || info.IsDir() {
+		fc.log.Debug("无法读取备份文件，跳过完整性验证: %s", record.TargetPath)
+		return true
+	}
+
+	if record.TargetSize == info.Size() && record.TargetModTime.Equal(info.ModTime()) {
+		fc.log.Debug("备份文件未变化，使用缓存的哈希: %s", record.TargetPath)
+		return true
+	}
+
+	hash, err := fc.hashFile(record.TargetPath)
+	if err != nil {
+		fc.log.Warn("计算备份文件哈希失败，跳过完整性验证: %s, %v", record.TargetPath, err)
+		return true
+	}
+	if hash != record.FileHash {
+		fc.log.Warn("备份文件内容与记录不一致，重新复制: %s", record.TargetPath)
+		return false
+	}
+
+	// 内容未变（如只更新了修改时间），刷新缓存避免下次重算
+	if err := fc.tracker.SetTargetHash(record.SourcePath, hash, info.ModTime(), info.Size()); err != nil {
+		fc.log.Warn("更新哈希缓存失败: %s, %v", record.SourcePath, err)
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_TargetHashCache 测试已备份文件的完整性验证复用缓存的哈希，目标变化后才重算
+func TestFileCopier_TargetHashCache(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.IntegrityCheck = true
+	cfg.Backup.SkipExisting = true
+	cfg.Backup.EnableResume = false
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	content := bytes.Repeat([]byte("a"), 4096)
+	path := "内部共享存储空间\\录音笔文件\\a.opus"
+	fake.AddFile(path, content, time.Now().Add(-time.Hour))
+	file := &utils.FileInfo{Path: path, RelativePath: "a.opus", Name: "a.opus", Size: int64(len(content))}
+
+	copier := NewFileCopier(cfg, log, tracker, deviceInfo)
+	copier.SetMTPInterface(fake)
+	hashes := 0
+	copier.hashFile = func(path string) (string, error) {
+		hashes++
+		return copier.calculateTargetHash(path)
+	}
+
+	targetPath := filepath.Join(cfg.Target.BaseDirectory, "a.opus")
+	steps := []struct {
+		name       string
+		modify     func(t *testing.T) // 复制前对目标文件的修改
+		wantSkip   bool
+		wantHashes int // 累计哈希计算次数
+	}{
+		{"首次复制计算哈希", nil, false, 1},
+		{"目标未变使用缓存", nil, true, 1},
+		{"只改修改时间重算后信任", func(t *testing.T) {
+			touch(t, targetPath, time.Now().Add(-time.Minute))
+		}, true, 2},
+		{"重算后刷新缓存", nil, true, 2},
+		{"内容被改重算并重新复制", func(t *testing.T) {
+			if err := os.WriteFile(targetPath, bytes.Repeat([]byte("x"), len(content)), 0644); err != nil {
+				t.Fatalf("修改目标文件失败: %v", err)
+			}
+			touch(t, targetPath, time.Now().Add(-2*time.Minute))
+		}, false, 4},
+		{"重新复制后使用新缓存", nil, true, 4},
+	}
+
+	for _, step := range steps {
+		if step.modify != nil {
+			step.modify(t)
+		}
+		result := copier.CopyFile(file, false)
+		if result.Error != nil {
+			t.Fatalf("%s: 复制失败: %v", step.name, result.Error)
+		}
+		if result.Skipped != step.wantSkip {
+			t.Errorf("%s: 跳过 = %v, 期望 %v", step.name, result.Skipped, step.wantSkip)
+		}
+		if hashes != step.wantHashes {
+			t.Errorf("%s: 累计哈希计算 %d 次, 期望 %d 次", step.name, hashes, step.wantHashes)
+		}
+	}
+
+	if data, err := os.ReadFile(targetPath); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("被修改的备份应重新复制为设备上的内容: %v", err)
+	}
+}
+
+// touch 设置文件的修改时间
+func touch(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("修改文件时间失败: %v", err)
+	}
+}
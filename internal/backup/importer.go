@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 支持导入的外部备份记录格式
+const (
+	// ImportFormatCSV 每行 "设备源路径,本地目标路径" 的清单，首行可为表头，目标路径可省略
+	ImportFormatCSV = "csv"
+	// ImportFormatRsync rsync --itemize-changes 或 --log-file 输出的日志
+	ImportFormatRsync = "rsync"
+)
+
+// ImportEntry 外部记录中的一个已备份文件
+type ImportEntry struct {
+	Line   int    // 在外部记录中的行号
+	Source string // 设备上的路径，可以是完整路径或相对于 source.base_path 的路径
+	Target string // 本地备份路径，相对路径相对于导入目录，为空时按源路径的相对部分推算
+}
+
+// ImportSkip 无法导入的条目及原因
+type ImportSkip struct {
+	Line   int
+	Entry  string
+	Reason string
+}
+
+// ImportResult 导入外部备份记录的结果
+type ImportResult struct {
+	Imported int          // 新导入的记录数
+	Existing int          // 已有备份记录、无需导入的条目数
+	Skipped  []ImportSkip // 无法对应到设备源路径或本地目标的条目
+}
+
+// rsyncItemizePattern 匹配 rsync 的逐项变更行，如 ">f+++++++++ 录音笔文件/a.opus"，
+// --log-file 输出的行首带有时间和进程号
+var rsyncItemizePattern = regexp.MustCompile(`^(?:\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[\d+\] )?([<>ch.][fdLDS].{9}) (.+)$`)
+
+// ParseImportEntries 按格式解析外部备份记录，返回可尝试导入的条目和格式不正确的条目
+func ParseImportEntries(r io.Reader, format string) ([]ImportEntry, []ImportSkip, error) {
+	switch strings.ToLower(format) {
+	case ImportFormatCSV:
+		return parseCSVEntries(r)
+	case ImportFormatRsync:
+		return parseRsyncEntries(r)
+	default:
+		return nil, nil, fmt.Errorf("不支持的导入格式: %s，有效值: csv, rsync", format)
+	}
+}
+
+// parseCSVEntries 解析 "source,target" 清单，# 开头的行为注释，首行为 source 表头时跳过
+func parseCSVEntries(r io.Reader) ([]ImportEntry, []ImportSkip, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []ImportEntry
+	var skipped []ImportSkip
+	first := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				skipped = append(skipped, ImportSkip{Line: parseErr.Line, Reason: fmt.Sprintf("CSV格式错误: %v", parseErr.Err)})
+				continue
+			}
+			return nil, nil, fmt.Errorf("读取CSV失败: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		isHeader := first && strings.EqualFold(strings.TrimSpace(record[0]), "source")
+		first = false
+		if isHeader {
+			continue
+		}
+
+		entry := ImportEntry{Line: line, Source: strings.TrimSpace(record[0])}
+		if len(record) > 1 {
+			entry.Target = strings.TrimSpace(record[1])
+		}
+		if entry.Source == "" {
+			skipped = append(skipped, ImportSkip{Line: line, Entry: strings.Join(record, ","), Reason: "缺少设备源路径"})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped, nil
+}
+
+// parseRsyncEntries 解析 rsync 日志中接收或已是最新的文件，路径相对于同步根目录；
+// 目录、删除和统计信息等其他行被忽略
+func parseRsyncEntries(r io.Reader) ([]ImportEntry, []ImportSkip, error) {
+	var entries []ImportEntry
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		match := rsyncItemizePattern.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if match == nil || match[1][1] != 'f' || match[1][0] == '<' {
+			continue
+		}
+		entries = append(entries, ImportEntry{Line: line, Source: match[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("读取rsync日志失败: %w", err)
+	}
+	return entries, nil, nil
+}
+
+// ImportRecords 把外部工具的备份记录导入备份记录，之后的备份会跳过这些文件
+// destDir 为外部工具写入的本地目录，相对的目标路径相对于它，为空时使用 target.base_directory；
+// 本地目标文件必须存在，按目标文件计算哈希后登记为 deviceID 的备份
+func (bm *BackupManager) ImportRecords(r io.Reader, format, destDir, deviceID string) (*ImportResult, error) {
+	entries, skipped, err := ParseImportEntries(r, format)
+	if err != nil {
+		return nil, err
+	}
+	if destDir == "" {
+		destDir = bm.config.Target.BaseDirectory
+	}
+
+	result := &ImportResult{Skipped: skipped}
+	skip := func(entry ImportEntry, reason string) {
+		result.Skipped = append(result.Skipped, ImportSkip{Line: entry.Line, Entry: entry.Source, Reason: reason})
+		bm.log.Warn("第 %d 行无法导入: %s, %s", entry.Line, entry.Source, reason)
+	}
+
+	for _, entry := range entries {
+		sourcePath, relativePath := bm.importSourcePath(entry.Source)
+		if relativePath == "" {
+			skip(entry, "无法对应到设备源路径")
+			continue
+		}
+
+		targetPath := entry.Target
+		if targetPath == "" {
+			targetPath = filepath.FromSlash(strings.ReplaceAll(relativePath, "\\", "/"))
+		}
+		if !filepath.IsAbs(targetPath) {
+			targetPath = filepath.Join(destDir, targetPath)
+		}
+		info, err := os.Stat(targetPath)
+		if err != nil || !info.Mode().IsRegular() {
+			skip(entry, fmt.Sprintf("本地目标文件不存在: %s", targetPath))
+			continue
+		}
+
+		if backedUp, _, _ := bm.tracker.IsFileBackedUp(sourcePath); backedUp {
+			result.Existing++
+			continue
+		}
+
+		hash, err := bm.calculateHash(targetPath)
+		if err != nil {
+			skip(entry, fmt.Sprintf("计算目标文件哈希失败: %v", err))
+			continue
+		}
+		if err := bm.tracker.AddRecordWithVerify(sourcePath, targetPath, deviceID, info.Size(), hash,
+			bm.config.Backup.IntegrityCheck, bm.config.Backup.HashAlgorithm); err != nil {
+			return result, fmt.Errorf("添加备份记录失败: %w", err)
+		}
+		result.Imported++
+		bm.log.Debug("导入备份记录: %s -> %s", sourcePath, targetPath)
+	}
+
+	if result.Imported > 0 {
+		if err := bm.tracker.Save(); err != nil {
+			return result, fmt.Errorf("保存备份记录失败: %w", err)
+		}
+	}
+	bm.log.Info("导入完成: 新增 %d 条记录，%d 条已有记录，%d 条无法导入",
+		result.Imported, result.Existing, len(result.Skipped))
+	return result, nil
+}
+
+// importSourcePath 把外部记录中的路径规范化为设备源路径，返回完整路径和相对于 source.base_path 的部分
+// 已以 base_path 开头的路径保持不变，其余视为相对于 base_path
+func (bm *BackupManager) importSourcePath(path string) (string, string) {
+	path = strings.Trim(strings.ReplaceAll(strings.TrimSpace(path), "/", "\\"), "\\")
+	if path == "" {
+		return "", ""
+	}
+	for _, part := range strings.Split(path, "\\") {
+		if part == ".." {
+			return "", ""
+		}
+	}
+
+	base := strings.Trim(strings.ReplaceAll(bm.config.Source.BasePath, "/", "\\"), "\\")
+	if base == "" {
+		return path, path
+	}
+	if len(path) > len(base) && strings.EqualFold(path[:len(base)+1], base+"\\") {
+		return base + path[len(base):], path[len(base)+1:]
+	}
+	return base + "\\" + path, path
+}
@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// TestBackupManager_ImportRecords 测试导入 CSV 清单后，对应的设备文件被认为已备份，无法对应的条目被跳过
+func TestBackupManager_ImportRecords(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件"
+	destDir := t.TempDir()
+	for _, name := range []string{"a.opus", "sub/b.opus", "renamed.opus"} {
+		path := filepath.Join(destDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content of "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	log := logger.NewLogger(false)
+	dataDir := t.TempDir()
+	tracker := storage.NewBackupTracker(filepath.Join(dataDir, "backup_records.json"), log)
+	bm := &BackupManager{config: cfg, log: log, tracker: tracker, dataDir: dataDir, quiet: true}
+
+	csvData := strings.Join([]string{
+		"source,target",
+		"# 旧工具导出的清单",
+		"a.opus,",          // 相对于 base_path，目标按相对路径推算
+		"录音笔文件/sub/b.opus", // 不以 base_path 开头时整体视为相对路径，对应的本地文件不存在
+		base + "\\c.opus," + filepath.Join(destDir, "renamed.opus"), // 完整设备路径和绝对目标路径
+		"missing.opus,missing.opus",                                 // 本地文件不存在
+		",orphan.opus",                                              // 缺少设备源路径
+		"../escape.opus,a.opus",                                     // 无法对应到设备路径
+	}, "\n")
+
+	result, err := bm.ImportRecords(strings.NewReader(csvData), ImportFormatCSV, destDir, "fake_sr302")
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.Imported != 2 || len(result.Skipped) != 4 {
+		t.Fatalf("导入结果 = %+v", result)
+	}
+
+	for _, source := range []string{base + "\\a.opus", base + "\\c.opus"} {
+		if backedUp, record, _ := tracker.IsFileBackedUp(source); !backedUp || record.FileHash == "" || record.DeviceID != "fake_sr302" {
+			t.Errorf("%s 应被认为已备份: %+v", source, record)
+		}
+	}
+	if _, record, _ := tracker.IsFileBackedUp(base + "\\c.opus"); record != nil && record.TargetPath != filepath.Join(destDir, "renamed.opus") {
+		t.Errorf("目标路径 = %s", record.TargetPath)
+	}
+	for _, source := range []string{base + "\\missing.opus", base + "\\录音笔文件\\sub\\b.opus"} {
+		if backedUp, _, _ := tracker.IsFileBackedUp(source); backedUp {
+			t.Errorf("%s 不应被导入", source)
+		}
+	}
+
+	var lines []int
+	for _, skip := range result.Skipped {
+		lines = append(lines, skip.Line)
+	}
+	if want := []int{7, 4, 6, 8}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("跳过的行 = %v，期望 %v", lines, want)
+	}
+
+	// 重复导入时已有记录不再新增
+	again, err := bm.ImportRecords(strings.NewReader(csvData), ImportFormatCSV, destDir, "fake_sr302")
+	if err != nil || again.Imported != 0 || again.Existing != 2 {
+		t.Errorf("重复导入结果 = %+v, %v", again, err)
+	}
+}
+
+// TestParseImportEntries_Rsync 测试只解析 rsync 日志中接收或已是最新的文件
+func TestParseImportEntries_Rsync(t *testing.T) {
+	log := strings.Join([]string{
+		"receiving incremental file list",
+		"cd+++++++++ sub/",
+		">f+++++++++ a.opus",
+		"2024/05/01 09:30:00 [1234] >f.st...... sub/b.opus",
+		".f          c.opus",
+		"*deleting   old.opus",
+		"<f+++++++++ uploaded.opus",
+		"sent 1,234 bytes  received 5,678 bytes",
+	}, "\n")
+
+	entries, skipped, err := ParseImportEntries(strings.NewReader(log), ImportFormatRsync)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("解析失败: %v, %+v", err, skipped)
+	}
+	var sources []string
+	for _, entry := range entries {
+		sources = append(sources, entry.Source)
+	}
+	if want := []string{"a.opus", "sub/b.opus", "c.opus"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("解析的文件 = %v，期望 %v", sources, want)
+	}
+
+	if _, _, err := ParseImportEntries(strings.NewReader(""), "xml"); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
@@ -56,6 +56,11 @@ func (iv *IntegrityVerifier) CalculateFileHash(filePath string) (string, error)
 	}
 	defer file.Close()
 
+	return iv.CalculateReaderHash(file)
+}
+
+// CalculateReaderHash 计算数据流的哈希，用于无法直接按路径打开的设备文件
+func (iv *IntegrityVerifier) CalculateReaderHash(r io.Reader) (string, error) {
 	var hasher hash.Hash
 	switch iv.hashAlgorithm {
 	case "md5":
@@ -70,7 +75,7 @@ func (iv *IntegrityVerifier) CalculateFileHash(filePath string) (string, error)
 		iv.log.Warn("未知的哈希算法: %s，使用默认的SHA256", iv.hashAlgorithm)
 	}
 
-	if _, err := io.Copy(hasher, file); err != nil {
+	if _, err := io.Copy(hasher, r); err != nil {
 		return "", fmt.Errorf("读取文件失败: %w", err)
 	}
 
@@ -2,15 +2,23 @@ package backup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/allanpk716/record_center/internal/config"
 	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/i18n"
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/metrics"
+	"github.com/allanpk716/record_center/internal/notify"
 	"github.com/allanpk716/record_center/internal/progress"
+	"github.com/allanpk716/record_center/internal/recordsync"
 	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/internal/store"
 	"github.com/allanpk716/record_center/pkg/utils"
 )
 
@@ -19,61 +27,174 @@ type BackupManager struct {
 	config         *config.Config
 	log            *logger.Logger
 	tracker        *storage.BackupTracker
+	globalSem      *SharedSemaphore
+	quota          *QuotaTracker     // 每日配额，未配置时为nil
+	limiter        *RateLimiter      // 时段限速，未配置时为nil
+	scanner        DeviceScanner     // 设备文件枚举器，为nil时使用FileChecker
+	mtp            device.MTPInterface // 设备访问接口，为nil时通过设备桥接器和PowerShell访问设备
+	fileList       []string          // 只备份这些相对路径的文件，为nil时枚举整个设备
+	filters        []FileFilter      // 自定义过滤器，在配置的过滤器之后、已备份检查之前应用
+	dataDir        string            // 备份记录所在目录，增量枚举快照也保存在这里，为空时不做增量枚举
+	syncer         *recordsync.Syncer // 远程同步器，未配置端点时为nil
+	notifier       *notify.EmailNotifier // 备份结果邮件通知器，未配置SMTP服务器时为nil
+	observer       ProgressObserver  // 跨设备汇总进度的观察者，由设备调度器设置，为nil时不汇总
+	metrics        *metrics.Metrics  // 实时指标，为nil时不上报
+	serialCopy     bool              // 单设备内文件串行复制，由设备调度器设置
+	batteryInterval time.Duration    // 备份过程中检查设备电量的间隔，为0时使用 DefaultBatteryCheckInterval
+	syncWG         sync.WaitGroup
 	quiet          bool
 	verbose        bool
 	cleanEmpty     bool
 }
 
+// RecordsPath 备份记录文件路径
+const RecordsPath = "data/backup_records.json"
+
+// SkipReasonCanceled 备份被取消（如 Ctrl+C）后，未开始复制的文件的跳过原因
+const SkipReasonCanceled = "备份已取消"
+
+// TaskDataDir 任务的备份记录所在目录
+func TaskDataDir(task string) string {
+	return filepath.Join("data", "tasks", task)
+}
+
 // NewManager 创建新的备份管理器
 func NewManager(cfg *config.Config, log *logger.Logger, quiet, verbose, cleanEmpty bool) *BackupManager {
+	return newManager(cfg, RecordsPath, log, quiet, verbose, cleanEmpty)
+}
+
+// NewTaskManager 为配置文件中的任务创建备份管理器
+// 备份记录保存在 TaskDataDir(task) 下，同一设备备份到不同目的地的任务互不影响，并行执行时也不会互相覆盖；
+// 每日配额是整台机器的限制，用量仍记在全局的 QuotaPath，并行执行时由设备调度器注入共享的配额和限速器
+func NewTaskManager(cfg *config.Config, task string, log *logger.Logger, quiet, verbose, cleanEmpty bool) *BackupManager {
+	return newManager(cfg, filepath.Join(TaskDataDir(task), filepath.Base(RecordsPath)), log, quiet, verbose, cleanEmpty)
+}
+
+// newManager 使用指定的备份记录文件创建备份管理器
+func newManager(cfg *config.Config, recordsPath string, log *logger.Logger, quiet, verbose, cleanEmpty bool) *BackupManager {
 	// 初始化备份跟踪器
-	tracker := storage.NewBackupTracker("data/backup_records.json", log)
+	tracker := storage.NewBackupTracker(recordsPath, log)
+	tracker.SetEncryptionKey(cfg.Storage.ResolveEncryptionKey())
+	tracker.SetArchiveAfterDays(cfg.Storage.ArchiveAfterDays)
 	if err := tracker.Load(); err != nil {
-		log.Warn("加载备份记录失败，将创建新记录: %v", err)
+		if errors.Is(err, storage.ErrKeyRequired) || errors.Is(err, storage.ErrWrongKey) {
+			// 加密记录无法读取时不会覆盖原文件，Run 和 Check 扫描设备前返回该错误
+			log.Error("加载备份记录失败: %v", err)
+		} else {
+			log.Warn("加载备份记录失败，将创建新记录: %v", err)
+		}
 	}
 
 	return &BackupManager{
 		config:      cfg,
 		log:         log,
 		tracker:     tracker,
+		dataDir:     filepath.Dir(recordsPath),
+		globalSem:   NewSharedSemaphore(cfg.Backup.GlobalMaxConcurrent),
+		quota:       NewQuotaTracker(QuotaPath, cfg.Backup.DailyQuota, log),
+		limiter:     NewRateLimiter(cfg.Backup.Schedule, log),
+		syncer:      recordsync.NewSyncer(&cfg.Sync, tracker, log),
+		notifier:    notify.NewEmailNotifier(&cfg.Notify, log),
+		metrics:     metrics.Default,
 		quiet:       quiet,
 		verbose:     verbose,
 		cleanEmpty:  cleanEmpty,
 	}
 }
 
-// Run 执行备份
-func (bm *BackupManager) Run(device *device.DeviceInfo, force bool) error {
+// Run 执行备份，结束后按通知配置发送结果邮件
+// ctx 取消后不再开始新文件的复制，等进行中的文件完成并保存已完成的备份记录后，
+// 返回包含已完成结果的运行概况和 ctx 的错误（如 context.Canceled）
+func (bm *BackupManager) Run(ctx context.Context, device *device.DeviceInfo, force bool) (summary *storage.RunSummary, err error) {
 	startTime := time.Now()
-	bm.log.Info("开始备份操作，设备: %s (VID:%s, PID:%s)", device.Name, device.VID, device.PID)
+
+	// 本次备份的所有日志带上同一个会话ID，便于在混合的日志中区分
+	sessionID := logger.NewSessionID()
+	baseLog := bm.log
+	bm.log = baseLog.WithSession(sessionID)
+	trackerLog := bm.tracker.SetLogger(bm.log)
+	defer func() {
+		bm.log = baseLog
+		bm.tracker.SetLogger(trackerLog)
+	}()
+
+	defer func() {
+		bm.notifyResult(device, startTime, summary, err)
+	}()
+
+	// 按周期滚动时本次备份写入当前周期目录
+	defer bm.rolloverTarget(startTime)()
+
+	// 记录了运行概况（备份记录已提交）后，无论有没有新文件、是否失败或中断，都在后台同步备份记录，
+	// 上次推送失败的记录随之重试
+	defer func() {
+		if summary != nil {
+			bm.startSync()
+		}
+	}()
+
+	bm.log.Info("%s", i18n.T("backup.start", device.DisplayName(bm.config), device.VID, device.PID))
+	bm.metrics.RunStarted(device.DisplayName(bm.config))
+	defer bm.metrics.RunEnded(device.DisplayName(bm.config))
+
+	// 备份记录无法读取时所有文件都会被当作新文件重复复制，且结果无法记录，不开始备份
+	if err := bm.recordsError(); err != nil {
+		return nil, err
+	}
 
 	// 创建文件检查器
 	fileChecker := bm.createFileChecker(device)
 
-	// 扫描设备文件
-	bm.log.Info("正在扫描设备文件...")
-	allFiles, err := fileChecker.ScanDeviceFiles(device)
+	// 电量不足时按配置拒绝开始或只警告，避免备份中途断电
+	if err := bm.checkBattery(fileChecker, device); err != nil {
+		return nil, err
+	}
+
+	// 扫描设备文件，指定了文件列表时只定位列表中的文件
+	allFiles, invalidResults, err := bm.collectDeviceFiles(fileChecker, device)
 	if err != nil {
-		return fmt.Errorf("扫描设备文件失败: %w", err)
+		return nil, fmt.Errorf("扫描设备文件失败: %w", err)
 	}
+	scanned := len(allFiles) + len(invalidResults)
+	bm.observeResults(device, invalidResults)
 
 	if len(allFiles) == 0 {
-		bm.log.Info("没有发现.opus文件，备份完成")
-		return nil
+		bm.log.Info("%s", i18n.T("backup.no_files"))
+		bm.mirrorDevice(device, allFiles)
+		summary = bm.recordRun(device, startTime, scanned, invalidResults)
+		if len(invalidResults) > 0 {
+			return summary, fmt.Errorf("文件列表中的 %d 个路径均无法备份", len(invalidResults))
+		}
+		return summary, nil
 	}
 
-	bm.log.Info("扫描完成，发现 %d 个文件", len(allFiles))
+	bm.log.Info("%s", i18n.T("backup.scan_done", len(allFiles)))
+
+	// 按忽略规则排除文件；镜像清理仍使用完整的枚举结果，避免清理被忽略文件的已有备份
+	candidates := bm.applyIgnoreRules(allFiles)
+	bm.logSimilarRecordings(bm.findSimilarRecordings(candidates))
+
+	// 设备以盘符挂载时预计算哈希，改名的文件也能按内容跳过
+	bm.prehashFiles(candidates)
+
+	// 依次应用过滤链，任一过滤器拒绝即跳过
+	candidates = bm.applyFilters(candidates, force)
 
 	// 过滤需要备份的文件
-	filesToBackup, err := fileChecker.FilterFilesToBackup(allFiles, device.DeviceID, force)
+	filesToBackup, err := fileChecker.FilterFilesToBackup(candidates, device.DeviceID, force)
 	if err != nil {
-		return fmt.Errorf("过滤备份文件失败: %w", err)
+		return nil, fmt.Errorf("过滤备份文件失败: %w", err)
 	}
 
+	// 跳过仍在写入的文件，下次备份时再复制；文件列表中的无效路径计为失败
+	filesToBackup, unstableResults := bm.filterUnstableFiles(fileChecker, device, filesToBackup)
+	bm.observeResults(device, unstableResults)
+	uncopiedResults := append(invalidResults, unstableResults...)
+
 	// 生成备份预览
 	preview, err := bm.GeneratePreview(device, allFiles, filesToBackup)
 	if err != nil {
-		return fmt.Errorf("生成预览失败: %w", err)
+		return nil, fmt.Errorf("生成预览失败: %w", err)
 	}
 
 	// 显示预览信息
@@ -81,8 +202,9 @@ func (bm *BackupManager) Run(device *device.DeviceInfo, force bool) error {
 	bm.DisplayPreviewSummary(preview)
 
 	if len(filesToBackup) == 0 {
-		bm.log.Info("没有需要备份的新文件")
-		return nil
+		bm.log.Info("%s", i18n.T("backup.no_new_files"))
+		bm.mirrorDevice(device, allFiles)
+		return bm.recordRun(device, startTime, scanned, uncopiedResults), nil
 	}
 
 	// 创建进度组件（在确定需要备份后才创建）
@@ -91,7 +213,7 @@ func (bm *BackupManager) Run(device *device.DeviceInfo, force bool) error {
 
 	// 开始进度跟踪
 	if err := progressTracker.StartWithParams(len(filesToBackup), utils.CalculateTotalSize(filesToBackup)); err != nil {
-		return fmt.Errorf("启动进度跟踪失败: %w", err)
+		return nil, fmt.Errorf("启动进度跟踪失败: %w", err)
 	}
 
 	// 启动进度显示（使用延迟启动方式）
@@ -99,39 +221,97 @@ func (bm *BackupManager) Run(device *device.DeviceInfo, force bool) error {
 		bm.log.Warn("启动进度显示失败: %v", err)
 	}
 	defer progressDisplay.Stop()
+	if bm.observer != nil {
+		bm.observer.Start(len(filesToBackup), utils.CalculateTotalSize(filesToBackup))
+	}
 
 	// 检查磁盘空间
 	if err := fileChecker.CheckDiskSpace(filesToBackup); err != nil {
 		bm.log.Warn("磁盘空间检查失败: %v", err)
 	}
 
-	// 创建文件复制器
+	// 创建文件复制器，以本次运行前的历史平均速度作为速度异常检测的基准
 	copier := bm.createFileCopier(device)
+	defer bm.useNativeAccessor(copier, device)()
+	speedBaseline := bm.tracker.HistorySpeed(device.Name)
+	copier.SetSpeedBaseline(speedBaseline)
+
+	// zip归档模式下，本次备份写入同一个归档
+	archive := bm.createArchiveWriter(startTime)
+	if archive != nil {
+		copier.SetArchiveWriter(archive)
+	}
 
 	// 执行文件复制
-	bm.log.Info("开始复制 %d 个文件...", len(filesToBackup))
-	results := bm.copyFilesWithProgress(copier, filesToBackup, progressTracker, progressDisplay, force)
+	bm.log.Info("%s", i18n.T("backup.copying", len(filesToBackup)))
+	// 复制期间定期检查电量，降到危险值时停止开始新文件的复制
+	copyCtx, stopBatteryWatch := bm.watchBattery(ctx, fileChecker, device)
+	results := bm.copyFilesWithProgress(copyCtx, copier, filesToBackup, progressTracker, progressDisplay, force)
+	batteryErr := stopBatteryWatch()
+	results = append(results, uncopiedResults...)
+
+	if archive != nil {
+		if err := archive.Close(); err != nil {
+			return nil, fmt.Errorf("完成备份归档失败: %w", err)
+		}
+		bm.log.Info("备份归档已生成: %s", strings.Join(archive.Volumes(), ", "))
+	}
+
+	// 记录本次运行概况，供 status 子命令查看
+	summary = bm.recordRun(device, startTime, scanned, results)
+
+	// 本次新备份的文件追加到变更日志，供 changelog 子命令审计
+	bm.appendChangelog(sessionID, device, startTime, results)
 
 	// 处理结果
-	if err := bm.processCopyResults(results, progressDisplay); err != nil {
-		return err
+	copyErr := bm.processCopyResults(results, progressDisplay)
+
+	// 被取消时只保存已完成的记录，不做镜像清理和空文件夹清理
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if err := bm.tracker.Save(); err != nil {
+			bm.log.Warn("保存备份记录失败: %v", err)
+		}
+		bm.log.Warn("备份被中断: 已完成 %d 个文件，备份记录已保存", summary.Succeeded)
+		return summary, ctxErr
+	}
+	// 电量过低停止时同样只保存已完成的记录，未完成文件的断点信息已由复制器保存，充电后可继续
+	if batteryErr != nil {
+		if err := bm.tracker.Save(); err != nil {
+			bm.log.Warn("保存备份记录失败: %v", err)
+		}
+		bm.log.Warn("设备电量过低，已保存进度: 已完成 %d 个文件", summary.Succeeded)
+		return summary, batteryErr
+	}
+	// 设备断开时同样只保存已完成的记录，断点信息已由复制器保存，重新连接后可继续
+	if disconnectErr := disconnectedError(results); disconnectErr != nil {
+		if err := bm.tracker.Save(); err != nil {
+			bm.log.Warn("保存备份记录失败: %v", err)
+		}
+		bm.log.Warn("设备已断开，已保存进度: 已完成 %d 个文件", summary.Succeeded)
+		return summary, disconnectErr
+	}
+	if copyErr != nil {
+		return summary, copyErr
 	}
 
+	// 镜像模式下清理设备上已删除的文件
+	bm.mirrorDevice(device, allFiles)
+
 	// 保存备份记录
 	if err := bm.tracker.Save(); err != nil {
 		bm.log.Warn("保存备份记录失败: %v", err)
 	}
 
 	// 显示统计信息
-	bm.showBackupStatistics(startTime, len(allFiles), len(filesToBackup), results)
+	bm.showBackupStatistics(startTime, len(allFiles), len(filesToBackup), results, speedBaseline)
 
 	progressDisplay.ShowCompletion()
-	bm.log.Info("备份操作完成")
+	bm.log.Info("%s", i18n.T("backup.done"))
 
 	// 清理空文件夹
-	if bm.cleanEmpty && bm.config.Backup.CleanEmptyFolders {
+	if bm.cleanEmpty && bm.config.Backup.CleanEmptyFolders && bm.config.Target.Type != store.TypeS3 {
 		bm.log.Info("开始清理空文件夹...")
-		cleaned, err := utils.RemoveEmptyDirectories(bm.config.Target.BaseDirectory, bm.log, false)
+		cleaned, err := utils.RemoveEmptyDirectories(bm.config.Target.BaseDirectory, bm.log, false, bm.config.Backup.FollowSymlinks)
 		if err != nil {
 			bm.log.Warn("清理空文件夹时出错: %v", err)
 		} else if cleaned > 0 {
@@ -141,17 +321,26 @@ func (bm *BackupManager) Run(device *device.DeviceInfo, force bool) error {
 		}
 	}
 
-	return nil
+	return summary, nil
 }
 
 // Check 检查设备文件（不执行备份）
 func (bm *BackupManager) Check(device *device.DeviceInfo) error {
 	bm.log.Info("检查模式: 仅扫描文件，不执行备份")
 
+	if err := bm.recordsError(); err != nil {
+		return err
+	}
+
 	fileChecker := bm.createFileChecker(device)
 
-	// 扫描设备文件
-	allFiles, err := fileChecker.ScanDeviceFiles(device)
+	// 完整枚举前先快速估算，让用户对耗时有个预期
+	if bm.fileList == nil {
+		bm.showQuickStat(fileChecker, device)
+	}
+
+	// 扫描设备文件，指定了文件列表时只定位列表中的文件
+	allFiles, _, err := bm.collectDeviceFiles(fileChecker, device)
 	if err != nil {
 		return fmt.Errorf("扫描设备文件失败: %w", err)
 	}
@@ -162,7 +351,8 @@ func (bm *BackupManager) Check(device *device.DeviceInfo) error {
 	}
 
 	// 过滤需要备份的文件
-	filesToBackup, err := fileChecker.FilterFilesToBackup(allFiles, device.DeviceID, false)
+	candidates := bm.applyFilters(bm.applyIgnoreRules(allFiles), false)
+	filesToBackup, err := fileChecker.FilterFilesToBackup(candidates, device.DeviceID, false)
 	if err != nil {
 		return fmt.Errorf("过滤备份文件失败: %w", err)
 	}
@@ -180,47 +370,439 @@ func (bm *BackupManager) Check(device *device.DeviceInfo) error {
 	return nil
 }
 
+// SetFileList 只备份设备上这些相对路径（相对于 source.base_path）的文件，跳过整个设备的枚举
+// 此时不做镜像清理，避免把列表外的备份当作设备上已删除
+func (bm *BackupManager) SetFileList(paths []string) {
+	bm.fileList = paths
+}
+
+// collectDeviceFiles 获取本次处理的设备文件：设置了文件列表时只定位列表中的文件，
+// 无效的路径作为失败结果返回；否则枚举设备
+func (bm *BackupManager) collectDeviceFiles(fileChecker *FileChecker, device *device.DeviceInfo) ([]*utils.FileInfo, []*CopyResult, error) {
+	if bm.fileList != nil {
+		bm.log.Info("按文件列表备份 %d 个文件，跳过设备枚举", len(bm.fileList))
+		return fileChecker.ResolveFileList(device, bm.fileList)
+	}
+
+	bm.log.Info("%s", i18n.T("backup.scanning"))
+	files, err := bm.scanDeviceFiles(fileChecker, device)
+	return files, nil, err
+}
+
+// DeviceScanner 设备文件枚举接口
+type DeviceScanner interface {
+	ScanDeviceFiles(deviceInfo *device.DeviceInfo) ([]*utils.FileInfo, error)
+}
+
+// scanDeviceFiles 枚举设备文件，设置了 scanner 时使用它，否则使用文件检查器
+func (bm *BackupManager) scanDeviceFiles(fileChecker *FileChecker, device *device.DeviceInfo) ([]*utils.FileInfo, error) {
+	var scanner DeviceScanner = fileChecker
+	if bm.scanner != nil {
+		scanner = bm.scanner
+	}
+	return scanner.ScanDeviceFiles(device)
+}
+
+// applyIgnoreRules 按 source.ignore_file 中的规则排除文件，规则文件无法读取时不过滤
+func (bm *BackupManager) applyIgnoreRules(files []*utils.FileInfo) []*utils.FileInfo {
+	matcher, err := utils.LoadIgnoreFile(bm.config.Source.IgnoreFile)
+	if err != nil {
+		bm.log.Warn("加载忽略规则失败，不过滤文件: %v", err)
+		return files
+	}
+
+	kept, ignored := matcher.Filter(files)
+	for _, file := range ignored {
+		bm.log.Debug("按忽略规则跳过: %s", file.RelativePath)
+	}
+	if len(ignored) > 0 {
+		bm.log.Info("按忽略规则排除了 %d 个文件", len(ignored))
+	}
+	return kept
+}
+
+// AddFileFilter 加入自定义过滤器，按加入顺序在配置的过滤器之后应用
+func (bm *BackupManager) AddFileFilter(filter FileFilter) {
+	bm.filters = append(bm.filters, filter)
+}
+
+// filterChain 组装过滤链：backup.filter 配置的过滤器、自定义过滤器，非强制模式下最后跳过已备份的文件
+func (bm *BackupManager) filterChain(force bool) FilterChain {
+	chain, err := NewConfigFilterChain(&bm.config.Backup.Filter, time.Now())
+	if err != nil {
+		bm.log.Warn("解析过滤配置失败，忽略配置的过滤条件: %v", err)
+		chain = nil
+	}
+	chain = append(chain, bm.filters...)
+	if !force {
+		chain = append(chain, NewBackedUpFilter(bm.tracker))
+	}
+	return chain
+}
+
+// applyFilters 对枚举结果应用过滤链，记录每个被跳过文件的原因
+func (bm *BackupManager) applyFilters(files []*utils.FileInfo, force bool) []*utils.FileInfo {
+	kept, rejected := bm.filterChain(force).Apply(files)
+	for _, r := range rejected {
+		bm.log.Debug("按过滤器跳过: %s (%s)", r.File.RelativePath, r.Reason)
+	}
+	if len(rejected) > 0 {
+		bm.log.Info("过滤器跳过了 %d 个文件: %s", len(rejected), summarizeRejections(rejected))
+	}
+	return kept
+}
+
+// filterUnstableFiles 检测仍在变化的文件，返回可复制的文件和被跳过文件的结果
+func (bm *BackupManager) filterUnstableFiles(fileChecker *FileChecker, device *device.DeviceInfo, files []*utils.FileInfo) ([]*utils.FileInfo, []*CopyResult) {
+	wait, window := bm.stabilitySettings()
+	checker := NewStabilityChecker(wait, window, bm.log)
+
+	stable, unstable, err := checker.Check(files, func() ([]*utils.FileInfo, error) {
+		return bm.scanDeviceFiles(fileChecker, device)
+	})
+	if err != nil {
+		bm.log.Warn("文件稳定性检测失败，跳过检测: %v", err)
+		return files, nil
+	}
+
+	results := make([]*CopyResult, 0, len(unstable))
+	for _, file := range unstable {
+		bm.log.Info("文件仍在变化，本次跳过: %s", file.RelativePath)
+		results = append(results, &CopyResult{
+			File:       file,
+			Skipped:    true,
+			SkipReason: SkipReasonUnstable,
+		})
+	}
+	return stable, results
+}
+
+// prehashFiles 开启 prehash_on_device 时为枚举结果预计算哈希
+func (bm *BackupManager) prehashFiles(files []*utils.FileInfo) {
+	if !bm.config.Backup.PrehashOnDevice {
+		return
+	}
+
+	maxSize, err := utils.ParseByteSize(bm.config.Backup.PrehashMaxSize)
+	if err != nil {
+		bm.log.Warn("解析预哈希大小上限失败，跳过哈希预计算: %v", err)
+		return
+	}
+
+	prehasher := NewPrehasher(maxSize, bm.config.Backup.MaxConcurrent, bm.calculateHash, bm.log)
+	prehasher.Run(files)
+}
+
+// calculateHash 按复制后写入备份记录时相同的算法计算文件哈希，保证两者可比较
+func (bm *BackupManager) calculateHash(path string) (string, error) {
+	if bm.config.Backup.IntegrityCheck {
+		return NewIntegrityVerifier(bm.log, bm.config.Backup.HashAlgorithm).CalculateFileHash(path)
+	}
+	return utils.CalculateFileHash(path)
+}
+
+// stabilitySettings 获取稳定性检测的等待间隔和最近修改窗口
+func (bm *BackupManager) stabilitySettings() (time.Duration, time.Duration) {
+	var wait, window time.Duration
+	if bm.config.Backup.StabilityWait != "" {
+		if d, err := utils.ParseDuration(bm.config.Backup.StabilityWait); err == nil {
+			wait = d
+		} else {
+			bm.log.Warn("解析稳定性检测间隔失败，不做检测: %v", err)
+		}
+	}
+	if bm.config.Backup.StabilityWindow != "" {
+		if d, err := utils.ParseDuration(bm.config.Backup.StabilityWindow); err == nil {
+			window = d
+		} else {
+			bm.log.Warn("解析最近修改窗口失败: %v", err)
+		}
+	}
+	return wait, window
+}
+
+// recordsError 加密的备份记录无法读取（未配置密钥或密钥错误）时返回错误
+func (bm *BackupManager) recordsError() error {
+	if err := bm.tracker.LoadError(); err != nil {
+		return fmt.Errorf("备份记录无法读取，未扫描设备: %w", err)
+	}
+	return nil
+}
+
+// startSync 在后台同步备份记录，失败只记录警告，不影响备份结果
+func (bm *BackupManager) startSync() {
+	if bm.syncer == nil {
+		return
+	}
+
+	log := bm.log
+	bm.syncWG.Add(1)
+	go func() {
+		defer bm.syncWG.Done()
+		if _, err := bm.syncer.Sync(); err != nil {
+			log.Warn("%v", err)
+		}
+	}()
+}
+
 // createFileChecker 创建文件检查器
 func (bm *BackupManager) createFileChecker(device *device.DeviceInfo) *FileChecker {
-	return NewFileChecker(bm.config, bm.log, bm.tracker)
+	fileChecker := NewFileChecker(bm.config, bm.log, bm.tracker)
+	fileChecker.SetSnapshotDir(bm.dataDir)
+	if bm.mtp != nil {
+		fileChecker.SetMTPInterface(bm.mtp)
+	}
+	return fileChecker
 }
 
 // createFileCopier 创建文件复制器
 func (bm *BackupManager) createFileCopier(device *device.DeviceInfo) *FileCopier {
-	return NewFileCopier(bm.config, bm.log, bm.tracker, device)
+	copier := NewFileCopier(bm.config, bm.log, bm.tracker, device)
+	copier.SetGlobalSemaphore(bm.globalSem)
+	copier.SetQuotaTracker(bm.quota)
+	copier.SetRateLimiter(bm.limiter)
+	if bm.serialCopy {
+		copier.SetMaxConcurrent(1)
+	}
+	if bm.mtp != nil {
+		copier.SetMTPInterface(bm.mtp)
+	}
+	return copier
+}
+
+// useNativeAccessor 连接池为设备选择了直接读取设备的WPD访问器时，复制也通过它读取文件，不依赖PowerShell；
+// 返回的函数归还连接，复制结束后调用
+func (bm *BackupManager) useNativeAccessor(copier *FileCopier, dev *device.DeviceInfo) func() {
+	if bm.mtp != nil {
+		return func() {}
+	}
+	pool := device.SharedPool(bm.log)
+	mtp, release, err := pool.AcquireDevice(dev)
+	if err != nil {
+		bm.log.Debug("获取设备连接失败，复制通过PowerShell读取: %v", err)
+		return func() {}
+	}
+	if !device.ReadsNatively(mtp) {
+		release()
+		return func() {}
+	}
+	bm.log.Info("复制通过 %T 直接读取设备文件", mtp)
+	copier.SetMTPInterface(mtp)
+
+	// 连续失败复位设备时换用池中的新连接，其他备份仍在使用的旧连接不会被断开
+	var mutex sync.Mutex
+	copier.SetDeviceReconnect(func() (device.MTPInterface, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		pool.Invalidate(dev, mtp)
+		newMTP, newRelease, err := pool.AcquireDevice(dev)
+		if err != nil {
+			return nil, err
+		}
+		release()
+		mtp, release = newMTP, newRelease
+		return mtp, nil
+	})
+	return func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		release()
+	}
+}
+
+// SetMTPInterface 设置设备访问接口，枚举和复制都通过它访问设备（如测试用的 device.FakeMTPAccessor）
+func (bm *BackupManager) SetMTPInterface(mtp device.MTPInterface) {
+	bm.mtp = mtp
+}
+
+// createArchiveWriter 按配置创建zip归档写入器，未启用归档时返回nil
+func (bm *BackupManager) createArchiveWriter(startTime time.Time) *ArchiveWriter {
+	if bm.config.Target.Archive != ArchiveZip {
+		return nil
+	}
+
+	var splitSize int64
+	if bm.config.Target.ArchiveSplitSize != "" && bm.config.Target.ArchiveSplitSize != "0" {
+		size, err := utils.ParseByteSize(bm.config.Target.ArchiveSplitSize)
+		if err != nil {
+			bm.log.Warn("解析归档分卷大小失败，不分卷: %v", err)
+		} else {
+			splitSize = size
+		}
+	}
+
+	name := "backup_" + startTime.Format("20060102_150405")
+	return NewArchiveWriter(bm.config.Target.BaseDirectory, name, splitSize, bm.log)
+}
+
+// SetSerialCopy 设置是否在单设备内串行复制文件，多设备并行时避免同一设备上的会话冲突
+func (bm *BackupManager) SetSerialCopy(serial bool) {
+	bm.serialCopy = serial
+}
+
+// SetMetrics 设置实时指标，默认上报到 metrics.Default，为nil时不上报
+func (bm *BackupManager) SetMetrics(m *metrics.Metrics) {
+	bm.metrics = m
+}
+
+// observeResults 把复制结果计入实时指标
+func (bm *BackupManager) observeResults(dev *device.DeviceInfo, results []*CopyResult) {
+	for _, result := range results {
+		switch {
+		case result.Success:
+			bm.metrics.AddFile(dev.DisplayName(bm.config), metrics.ResultSuccess)
+		case result.Skipped:
+			bm.metrics.AddFile(dev.DisplayName(bm.config), metrics.ResultSkipped)
+		default:
+			bm.metrics.AddFile(dev.DisplayName(bm.config), metrics.ResultFailed)
+		}
+	}
+}
+
+// SetProgressObserver 设置进度观察者，复制进度同时上报给它
+func (bm *BackupManager) SetProgressObserver(observer ProgressObserver) {
+	bm.observer = observer
+}
+
+// SetGlobalSemaphore 设置全局并发资源池，多设备并行备份时应让各管理器共享同一个
+func (bm *BackupManager) SetGlobalSemaphore(sem *SharedSemaphore) {
+	bm.globalSem = sem
+}
+
+// SetQuotaTracker 设置每日配额跟踪器，多设备并行备份时应让各管理器共享同一个
+func (bm *BackupManager) SetQuotaTracker(quota *QuotaTracker) {
+	bm.quota = quota
+}
+
+// SetRateLimiter 设置时段限速器，多设备并行备份时应让各管理器共享同一个
+func (bm *BackupManager) SetRateLimiter(limiter *RateLimiter) {
+	bm.limiter = limiter
 }
 
 // copyFilesWithProgress 带进度显示的文件复制
-func (bm *BackupManager) copyFilesWithProgress(copier *FileCopier, files []*utils.FileInfo,
+// parent 取消后未开始复制的文件计为跳过
+func (bm *BackupManager) copyFilesWithProgress(parent context.Context, copier *FileCopier, files []*utils.FileInfo,
 	tracker *progress.ProgressTracker, display *progress.ProgressDisplay, force bool) []*CopyResult {
 
-	resultChan := copier.CopyFiles(context.Background(), files, force)
+	// 按错误策略在复制失败后取消剩余复制，设备断开时无论错误策略如何都立即停止
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	stopped := false
+	disconnected := false
+
+	// 各文件的字节进度汇总到整体进度，断点续传的文件从断点处开始计入
+	update := tracker.UpdateProgress
+	if bm.observer != nil {
+		update = func(total int64) {
+			tracker.UpdateProgress(total)
+			bm.observer.Update(total)
+		}
+	}
+	if bm.metrics != nil && copier.device != nil {
+		// 整体进度持锁按顺序上报，超过已上报最大值的部分即为新复制的字节数；
+		// 文件重试时整体进度会回退，回退后重新复制的字节不重复计入
+		deviceName := copier.device.DisplayName(bm.config)
+		progressUpdate := update
+		var reported int64
+		update = func(total int64) {
+			progressUpdate(total)
+			if total > reported {
+				bm.metrics.AddBytes(deviceName, total-reported)
+				reported = total
+			}
+		}
+	}
+	byteTotals := newByteProgress(update)
+	copier.SetProgressFunc(byteTotals.report)
+
+	resultChan := copier.CopyFiles(ctx, files, force)
 	var results []*CopyResult
+	commitInterval := bm.config.Backup.CommitInterval
+	uncommitted := 0
 
 	// 处理复制结果
 	for result := range resultChan {
+		// 因策略停止或备份被取消而未复制的文件计为跳过，下次运行时重新复制
+		if ctxErr := ctx.Err(); ctxErr != nil && !result.Success && errors.Is(result.Error, ctxErr) {
+			result.Error = nil
+			result.Skipped = true
+			result.SkipReason = SkipReasonCanceled
+			if stopped {
+				result.SkipReason = SkipReasonStopped
+			}
+			if disconnected {
+				result.SkipReason = SkipReasonDisconnected
+			}
+		}
+		// 设备断开后仍在复制的文件同样因断开而失败，不再逐个报错
+		if disconnected && !result.Success && errors.Is(result.Error, device.ErrDeviceNotConnected) {
+			result.Error = nil
+			result.Skipped = true
+			result.SkipReason = SkipReasonDisconnected
+		}
 		results = append(results, result)
+		if copier.device != nil {
+			bm.observeResults(copier.device, []*CopyResult{result})
+		}
 
 		if result.Success {
+			byteTotals.complete(result.File)
 			tracker.CompleteFile()
+			if bm.observer != nil {
+				bm.observer.CompleteFile()
+			}
 			if !bm.quiet {
 				bm.log.Debug("文件复制完成: %s", result.File.RelativePath)
 			}
+
+			// 分批提交备份记录，崩溃后重启可跳过已提交的文件
+			uncommitted++
+			if commitInterval > 0 && uncommitted >= commitInterval {
+				if err := bm.tracker.Commit(); err != nil {
+					bm.log.Warn("提交备份记录失败: %v", err)
+				} else {
+					bm.log.Debug("已提交 %d 个备份记录", uncommitted)
+					uncommitted = 0
+				}
+			}
 		} else if result.Skipped {
 			if !bm.quiet {
 				bm.log.Debug("文件跳过: %s, 原因: %s", result.File.RelativePath, result.SkipReason)
 			}
 		} else {
 			bm.log.Error("文件复制失败: %s, %v", result.File.RelativePath, result.Error)
+			if !disconnected && errors.Is(result.Error, device.ErrDeviceNotConnected) {
+				disconnected = true
+				stopped = true
+				cancel()
+				if copier.device != nil {
+					bm.metrics.SetOnline(copier.device.DisplayName(bm.config), false)
+				}
+				bm.log.Warn("设备已断开，停止剩余文件的复制")
+			} else if !stopped && shouldStopOnError(bm.config.Backup.OnError, result.Error) {
+				stopped = true
+				cancel()
+				bm.log.Warn("按错误策略 %s 停止剩余文件的复制", bm.config.Backup.OnError)
+			}
 		}
 	}
 
 	return results
 }
 
+// disconnectedError 复制过程中设备断开时返回包装了 device.ErrDeviceNotConnected 的错误，否则返回nil
+func disconnectedError(results []*CopyResult) error {
+	for _, result := range results {
+		if !result.Success && errors.Is(result.Error, device.ErrDeviceNotConnected) {
+			return fmt.Errorf("复制 %s 失败: %w", result.File.RelativePath, result.Error)
+		}
+	}
+	return nil
+}
+
 // processCopyResults 处理复制结果
 func (bm *BackupManager) processCopyResults(results []*CopyResult, display *progress.ProgressDisplay) error {
-	var successCount, skipCount, errorCount int
+	var successCount, skipCount, encryptedCount, stoppedCount, canceledCount, disconnectedCount, vanishedCount, rejectedCount, strippedCount, errorCount int
 	var totalSize int64
 
 	for _, result := range results {
@@ -229,14 +811,53 @@ func (bm *BackupManager) processCopyResults(results []*CopyResult, display *prog
 			totalSize += result.BytesCopied
 		} else if result.Skipped {
 			skipCount++
+			switch result.SkipReason {
+			case SkipReasonEncrypted:
+				encryptedCount++
+			case SkipReasonStopped:
+				stoppedCount++
+			case SkipReasonCanceled:
+				canceledCount++
+			case SkipReasonDisconnected:
+				disconnectedCount++
+			case SkipReasonSourceVanished:
+				vanishedCount++
+			case SkipReasonStripped:
+				strippedCount++
+			default:
+				if strings.HasPrefix(result.SkipReason, SkipReasonPreCopyRejected) {
+					rejectedCount++
+				}
+			}
 		} else {
 			errorCount++
 			display.ShowError(result.Error)
 		}
 	}
 
-	bm.log.Info("复制结果: 成功 %d, 跳过 %d, 失败 %d", successCount, skipCount, errorCount)
-	bm.log.Info("总复制大小: %s", utils.FormatBytes(totalSize))
+	bm.log.Info("%s", i18n.T("backup.result", successCount, skipCount, errorCount))
+	if encryptedCount > 0 {
+		bm.log.Info("跳过的加密文件: %d 个", encryptedCount)
+	}
+	if strippedCount > 0 {
+		bm.log.Info("跳过的设备垃圾文件: %d 个", strippedCount)
+	}
+	if stoppedCount > 0 {
+		bm.log.Info("因错误策略停止而未复制: %d 个", stoppedCount)
+	}
+	if canceledCount > 0 {
+		bm.log.Info("因备份被取消而未复制: %d 个", canceledCount)
+	}
+	if disconnectedCount > 0 {
+		bm.log.Info("因设备断开而未复制: %d 个", disconnectedCount)
+	}
+	if vanishedCount > 0 {
+		bm.log.Info("源文件在复制中消失: %d 个", vanishedCount)
+	}
+	if rejectedCount > 0 {
+		bm.log.Info("复制前校验未通过: %d 个", rejectedCount)
+	}
+	bm.log.Info("%s", i18n.T("backup.total_size", utils.FormatBytes(totalSize)))
 
 	if errorCount > 0 {
 		return fmt.Errorf("有 %d 个文件复制失败", errorCount)
@@ -245,14 +866,108 @@ func (bm *BackupManager) processCopyResults(results []*CopyResult, display *prog
 	return nil
 }
 
-// showBackupStatistics 显示备份统计信息
-func (bm *BackupManager) showBackupStatistics(startTime time.Time, totalFiles, backupFiles int, results []*CopyResult) {
+// recordRun 记录并保存一次备份运行的概况，未复制且未失败的文件计为跳过
+func (bm *BackupManager) recordRun(device *device.DeviceInfo, startTime time.Time, scanned int, results []*CopyResult) *storage.RunSummary {
+	summary := storage.RunSummary{
+		DeviceName: device.Name,
+		DeviceID:   device.DeviceID,
+		StartTime:  startTime,
+		Duration:   time.Since(startTime),
+		Scanned:    scanned,
+		Speed:      copySpeed(results),
+	}
+	for _, result := range results {
+		if result.Success {
+			summary.Succeeded++
+			summary.Bytes += result.BytesCopied
+		} else if !result.Skipped {
+			summary.Failed++
+			summary.FailedFiles = append(summary.FailedFiles, result.File.RelativePath)
+		}
+	}
+	summary.Skipped = scanned - summary.Succeeded - summary.Failed
+	if summary.Skipped < 0 {
+		summary.Skipped = 0
+	}
+
+	bm.tracker.SetLastRun(summary)
+	bm.metrics.SetLastBackup(device.DisplayName(bm.config), time.Now())
+	if err := bm.tracker.Commit(); err != nil {
+		bm.log.Warn("保存备份记录失败: %v", err)
+	}
+	return &summary
+}
+
+// notifyResult 发送备份结果通知，备份在记录运行概况前出错时只包含设备和耗时
+func (bm *BackupManager) notifyResult(device *device.DeviceInfo, startTime time.Time, summary *storage.RunSummary, err error) {
+	if bm.notifier == nil {
+		return
+	}
+
+	report := &notify.Report{Err: err}
+	if summary != nil {
+		report.Summary = *summary
+	} else {
+		report.Summary = storage.RunSummary{
+			DeviceName: device.Name,
+			DeviceID:   device.DeviceID,
+			StartTime:  startTime,
+			Duration:   time.Since(startTime),
+		}
+	}
+	bm.notifier.Notify(report)
+}
+
+// mirrorDevice 镜像模式下将设备上已不存在的备份移入回收目录
+func (bm *BackupManager) mirrorDevice(device *device.DeviceInfo, deviceFiles []*utils.FileInfo) {
+	if bm.config.Backup.SyncMode != SyncModeMirror {
+		return
+	}
+	if bm.fileList != nil {
+		bm.log.Info("按文件列表备份时不做镜像清理")
+		return
+	}
+	if bm.config.Target.Archive == ArchiveZip {
+		bm.log.Warn("归档模式不支持镜像同步，跳过清理")
+		return
+	}
+	if bm.config.Target.Type == store.TypeS3 {
+		bm.log.Warn("S3目标不支持镜像同步，跳过清理")
+		return
+	}
+
+	trash := NewTrashFromConfig(bm.config, bm.log)
+	cleaner := NewMirrorCleaner(bm.config.Target.BaseDirectory, bm.tracker, bm.config.Backup.SafeMode, bm.log)
+	cleaner.SetFollowSymlinks(bm.config.Backup.FollowSymlinks)
+	cleaner.SetTrash(trash)
+	moved, err := cleaner.Clean(device.DeviceID, deviceFiles)
+	if err != nil {
+		bm.log.Warn("镜像清理未执行: %v", err)
+	} else if moved > 0 {
+		bm.log.Info("镜像清理完成，%d 个备份已移入回收站 %s", moved, trash.Dir())
+	}
+
+	// 清理目标中历史遗留的设备垃圾文件
+	if stripped, err := cleaner.CleanStripped(device.DeviceID, bm.config.Backup.StripPatterns); err != nil {
+		bm.log.Warn("清理设备垃圾文件失败: %v", err)
+	} else if stripped > 0 {
+		bm.log.Info("已将 %d 个设备垃圾文件移入回收站 %s", stripped, trash.Dir())
+	}
+
+	// 删除回收站中超过保留期的文件
+	if _, err := trash.Purge(); err != nil {
+		bm.log.Warn("清理回收站失败: %v", err)
+	}
+}
+
+// showBackupStatistics 显示备份统计信息，复制速度显著低于历史平均 speedBaseline 时提示异常
+func (bm *BackupManager) showBackupStatistics(startTime time.Time, totalFiles, backupFiles int, results []*CopyResult, speedBaseline float64) {
 	duration := time.Since(startTime)
 
-	bm.log.Info("备份统计:")
-	bm.log.Info("  扫描文件数: %d", totalFiles)
-	bm.log.Info("  备份文件数: %d", backupFiles)
-	bm.log.Info("  耗时: %s", utils.FormatDuration(duration))
+	bm.log.Info("%s", i18n.T("backup.stats"))
+	bm.log.Info("%s", i18n.T("backup.stats_scanned", totalFiles))
+	bm.log.Info("%s", i18n.T("backup.stats_backed_up", backupFiles))
+	bm.log.Info("%s", i18n.T("backup.stats_duration", utils.FormatDuration(duration)))
 
 	// 获取备份记录统计
 	totalBackedUp, totalSize, lastBackup, err := bm.tracker.GetStatistics()
@@ -266,11 +981,19 @@ func (bm *BackupManager) showBackupStatistics(startTime time.Time, totalFiles, b
 		avgSpeed := float64(backupFiles) / duration.Seconds()
 		bm.log.Info("  平均速度: %.2f 文件/秒", avgSpeed)
 	}
+
+	if speed := copySpeed(results); speed > 0 {
+		bm.log.Info("  复制速度: %s/s", utils.FormatBytes(int64(speed)))
+		if IsSlowSpeed(speedBaseline, speed, bm.config.Backup.SlowThreshold) {
+			bm.log.Warn("  本次速度异常偏低: %s/s，历史平均 %s/s，请检查设备连接",
+				utils.FormatBytes(int64(speed)), utils.FormatBytes(int64(speedBaseline)))
+		}
+	}
 }
 
-// GetDeviceInfo 获取设备信息
+// GetDeviceInfo 按配置中的源设备检测设备信息
 func (bm *BackupManager) GetDeviceInfo() (*device.DeviceInfo, error) {
-	return device.DetectSR302()
+	return device.DetectDevice(bm.config.Source.DeviceName, bm.config.Source.VID, bm.config.Source.PID)
 }
 
 // GetBackupHistory 获取备份历史
@@ -315,6 +1038,9 @@ func (bm *BackupManager) GetConfiguration() *config.Config {
 func (bm *BackupManager) Close() error {
 	bm.log.Info("关闭备份管理器...")
 
+	// 等待后台同步完成
+	bm.syncWG.Wait()
+
 	// 保存备份记录
 	if err := bm.tracker.Save(); err != nil {
 		bm.log.Warn("保存备份记录失败: %v", err)
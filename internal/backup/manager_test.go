@@ -0,0 +1,623 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/metrics"
+	"github.com/allanpk716/record_center/internal/progress"
+	"github.com/allanpk716/record_center/internal/recordsync"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// countingScanner 记录枚举次数的模拟扫描器
+type countingScanner struct {
+	calls int
+	files []*utils.FileInfo
+}
+
+func (cs *countingScanner) ScanDeviceFiles(deviceInfo *device.DeviceInfo) ([]*utils.FileInfo, error) {
+	cs.calls++
+	return cs.files, nil
+}
+
+// newTestManager 创建使用模拟扫描器的备份管理器
+func newTestManager(t *testing.T) (*BackupManager, *countingScanner) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+
+	log := logger.NewLogger(false)
+	scanner := &countingScanner{
+		// 非.opus文件会在过滤阶段被跳过，流程不会进入复制阶段
+		files: []*utils.FileInfo{
+			{Path: "内部共享存储空间\\录音笔文件\\a.wav", RelativePath: "a.wav", Name: "a.wav", Size: 1024},
+		},
+	}
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log),
+		quiet:   true,
+		scanner: scanner,
+	}
+	return bm, scanner
+}
+
+// TestBackupManager_EnumerationOnce 测试一次 run 内设备只枚举一次
+func TestBackupManager_EnumerationOnce(t *testing.T) {
+	bm, scanner := newTestManager(t)
+	deviceInfo := &device.DeviceInfo{DeviceID: "test_device", Name: "SR302"}
+
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if scanner.calls != 1 {
+		t.Errorf("期望枚举 1 次，实际 %d 次", scanner.calls)
+	}
+}
+
+// TestBackupManager_CommitInterval 测试分批提交：第N+1个文件时崩溃，前N个记录已持久化
+func TestBackupManager_CommitInterval(t *testing.T) {
+	const commitInterval = 3
+
+	recordsPath := filepath.Join(t.TempDir(), "backup_records.json")
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.CommitInterval = commitInterval
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: storage.NewBackupTracker(recordsPath, log),
+		quiet:   true,
+	}
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "test_device"}
+	copier := NewFileCopier(cfg, log, bm.tracker, deviceInfo)
+
+	// 第N+1个文件的复制一直挂起，模拟进程在此时崩溃
+	crash := make(chan struct{})
+	var calls int32
+	copier.copyFunc = func(file *utils.FileInfo, force bool) *CopyResult {
+		if atomic.AddInt32(&calls, 1) > commitInterval {
+			<-crash
+			return &CopyResult{File: file, Error: fmt.Errorf("进程已崩溃")}
+		}
+		bm.tracker.AddRecord(file.Path, "backups\\"+file.Name, deviceInfo.DeviceID, file.Size, "")
+		return &CopyResult{File: file, Success: true, BytesCopied: file.Size}
+	}
+
+	var files []*utils.FileInfo
+	for i := 0; i < commitInterval+2; i++ {
+		name := fmt.Sprintf("file%d.opus", i)
+		files = append(files, &utils.FileInfo{Path: "device\\" + name, RelativePath: name, Name: name, Size: 100})
+	}
+
+	progressTracker := progress.NewProgressTracker(log)
+	progressTracker.StartWithParams(len(files), utils.CalculateTotalSize(files))
+	display := progress.NewProgressDisplay(progressTracker, true, log)
+
+	done := make(chan struct{})
+	go func() {
+		bm.copyFilesWithProgress(context.Background(), copier, files, progressTracker, display, false)
+		close(done)
+	}()
+	defer func() {
+		close(crash)
+		<-done
+	}()
+
+	// 从磁盘重新加载，模拟重启后读取备份记录
+	var persisted int
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		reloaded := storage.NewBackupTracker(recordsPath, log)
+		if err := reloaded.Load(); err == nil {
+			persisted = len(reloaded.GetStorage().Records)
+			if persisted >= commitInterval {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if persisted != commitInterval {
+		t.Errorf("崩溃前应已持久化 %d 个记录，实际 %d 个", commitInterval, persisted)
+	}
+}
+
+// runSessionIDs 执行一次备份并返回日志中出现的会话ID
+func runSessionIDs(t *testing.T, bm *BackupManager, deviceInfo *device.DeviceInfo) []string {
+	var buf bytes.Buffer
+	bm.log.SetOutput(&buf)
+	defer bm.log.SetOutput(os.Stdout)
+
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+
+	pattern := regexp.MustCompile(`\[session=([0-9a-f]+)\]`)
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			t.Errorf("日志缺少会话ID: %s", line)
+			continue
+		}
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+// TestBackupManager_RunSessionID 测试同一次Run的日志带相同会话ID，不同Run的会话ID不同
+func TestBackupManager_RunSessionID(t *testing.T) {
+	bm, _ := newTestManager(t)
+	deviceInfo := &device.DeviceInfo{DeviceID: "test_device", Name: "SR302"}
+
+	first := runSessionIDs(t, bm, deviceInfo)
+	second := runSessionIDs(t, bm, deviceInfo)
+
+	if len(first) < 2 || len(second) < 2 {
+		t.Fatalf("每次备份应产生多条日志，实际 %d / %d 条", len(first), len(second))
+	}
+	for _, ids := range [][]string{first, second} {
+		for _, id := range ids {
+			if id != ids[0] {
+				t.Errorf("同一次备份的会话ID应一致: %s != %s", id, ids[0])
+			}
+		}
+		if len(ids[0]) != 8 {
+			t.Errorf("会话ID应为8位，实际 %q", ids[0])
+		}
+	}
+	if first[0] == second[0] {
+		t.Errorf("不同备份的会话ID应不同，均为 %s", first[0])
+	}
+	if bm.log.Session() != "" {
+		t.Errorf("备份结束后应恢复原日志器，实际会话ID %s", bm.log.Session())
+	}
+}
+
+// TestBackupManager_RunWithFakeDevice 使用虚拟MTP设备跑完整备份：复制、记录、跳过已备份和失败后重试
+func TestBackupManager_RunWithFakeDevice(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+
+	modTime := time.Now().Add(-time.Hour)
+	contentA := bytes.Repeat([]byte("a"), 2048)
+	contentB := bytes.Repeat([]byte("b"), 4096)
+	pathA := "内部共享存储空间\\录音笔文件\\a.opus"
+	pathB := "内部共享存储空间\\录音笔文件\\会议\\b.opus"
+	fake.AddFile(pathA, contentA, modTime)
+	fake.AddFile(pathB, contentB, modTime)
+	fake.AddFile("内部共享存储空间\\录音笔文件\\note.txt", []byte("不是录音"), modTime)
+	fake.FailStream(pathB, 1)
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: tracker,
+		quiet:   true,
+	}
+	bm.SetMTPInterface(fake)
+
+	// 第一次备份：b.opus 读取失败，a.opus 复制成功
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err == nil {
+		t.Fatal("有文件复制失败时应返回错误")
+	}
+
+	targetA := filepath.Join(cfg.Target.BaseDirectory, "a.opus")
+	targetB := filepath.Join(cfg.Target.BaseDirectory, "会议", "b.opus")
+	if data, err := os.ReadFile(targetA); err != nil || !bytes.Equal(data, contentA) {
+		t.Fatalf("a.opus 应被复制且内容一致: %v", err)
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp(pathA); !backedUp {
+		t.Error("a.opus 应写入备份记录")
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp(pathB); backedUp {
+		t.Error("复制失败的 b.opus 不应写入备份记录")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Target.BaseDirectory, "note.txt")); !os.IsNotExist(err) {
+		t.Error("非录音文件不应被复制")
+	}
+
+	// 第二次备份：已备份的 a.opus 被跳过，b.opus 重试成功
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("第二次备份失败: %v", err)
+	}
+	if opens := fake.StreamOpens(pathA); opens != 1 {
+		t.Errorf("已备份的 a.opus 不应再次读取，实际读取 %d 次", opens)
+	}
+	if opens := fake.StreamOpens(pathB); opens != 2 {
+		t.Errorf("b.opus 应在第二次备份时重试，实际读取 %d 次", opens)
+	}
+	if data, err := os.ReadFile(targetB); err != nil || !bytes.Equal(data, contentB) {
+		t.Fatalf("b.opus 应被复制且内容一致: %v", err)
+	}
+	if backedUp, record, _ := tracker.IsFileBackedUp(pathB); !backedUp || record.FileSize != int64(len(contentB)) {
+		t.Errorf("b.opus 的备份记录不正确: %+v", record)
+	}
+}
+
+// cancelingAccessor 打开第 cancelAt 个文件流时取消备份，模拟复制途中按下 Ctrl+C
+type cancelingAccessor struct {
+	*device.FakeMTPAccessor
+	cancel   context.CancelFunc
+	cancelAt int32
+	opens    int32
+}
+
+// GetFileStream 打开文件流，达到次数时取消 context，当前文件仍正常复制完
+func (a *cancelingAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
+	if atomic.AddInt32(&a.opens, 1) == a.cancelAt {
+		a.cancel()
+	}
+	return a.FakeMTPAccessor.GetFileStream(filePath)
+}
+
+// TestBackupManager_RunCanceled 测试备份中途取消时返回含已完成计数的运行概况，且已完成的记录已保存
+func TestBackupManager_RunCanceled(t *testing.T) {
+	const fileCount = 5
+
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.CommitInterval = 0
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	recordsPath := filepath.Join(t.TempDir(), "backup_records.json")
+	tracker := storage.NewBackupTracker(recordsPath, log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+	modTime := time.Now().Add(-time.Hour)
+	for i := 0; i < fileCount; i++ {
+		fake.AddFile(fmt.Sprintf("内部共享存储空间\\录音笔文件\\file%d.opus", i), bytes.Repeat([]byte{byte('a' + i)}, 1024), modTime)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: tracker,
+		quiet:   true,
+	}
+	bm.SetMTPInterface(&cancelingAccessor{FakeMTPAccessor: fake, cancel: cancel, cancelAt: 2})
+
+	summary, err := bm.Run(ctx, deviceInfo, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("取消后应返回 context.Canceled，实际 %v", err)
+	}
+	if summary == nil {
+		t.Fatal("取消后应返回运行概况")
+	}
+	if summary.Succeeded != 2 || summary.Failed != 0 || summary.Skipped != fileCount-2 {
+		t.Errorf("运行概况 = 成功 %d 失败 %d 跳过 %d，期望 成功2 失败0 跳过%d",
+			summary.Succeeded, summary.Failed, summary.Skipped, fileCount-2)
+	}
+
+	// 从磁盘重新加载，已完成的记录必须已持久化
+	reloaded := storage.NewBackupTracker(recordsPath, log)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载备份记录失败: %v", err)
+	}
+	if records := len(reloaded.GetStorage().Records); records != 2 {
+		t.Errorf("已持久化的备份记录 = %d，期望 2", records)
+	}
+	if lastRun := reloaded.Overview(deviceInfo.Name).LastRun; lastRun == nil || lastRun.Succeeded != 2 {
+		t.Errorf("运行概况应已保存: %+v", lastRun)
+	}
+}
+
+// TestBackupManager_RunDisconnected 测试复制途中设备断开时立即停止剩余复制，只报一次错且已完成的记录已保存
+func TestBackupManager_RunDisconnected(t *testing.T) {
+	const (
+		fileCount    = 5
+		disconnectAt = 3
+	)
+
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.CommitInterval = 0
+	cfg.Backup.EnableResume = false
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+	cfg.Backup.OnError = OnErrorContinue
+
+	log := logger.NewLogger(false)
+	recordsPath := filepath.Join(t.TempDir(), "backup_records.json")
+	tracker := storage.NewBackupTracker(recordsPath, log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+	modTime := time.Now().Add(-time.Hour)
+	var paths []string
+	for i := 0; i < fileCount; i++ {
+		path := fmt.Sprintf("内部共享存储空间\\录音笔文件\\file%d.opus", i)
+		paths = append(paths, path)
+		fake.AddFile(path, bytes.Repeat([]byte{byte('a' + i)}, 1024), modTime)
+	}
+	// 枚举之后、复制第 disconnectAt 个文件时拔出设备
+	fake.DisconnectAt(disconnectAt)
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: tracker,
+		quiet:   true,
+	}
+	bm.SetMTPInterface(fake)
+
+	summary, err := bm.Run(context.Background(), deviceInfo, false)
+	if !errors.Is(err, device.ErrDeviceNotConnected) {
+		t.Fatalf("设备断开后应返回 ErrDeviceNotConnected，实际 %v", err)
+	}
+	if summary == nil {
+		t.Fatal("设备断开后应返回运行概况")
+	}
+	if summary.Succeeded != disconnectAt-1 || summary.Failed != 1 || summary.Skipped != fileCount-disconnectAt {
+		t.Errorf("运行概况 = 成功 %d 失败 %d 跳过 %d，期望 成功%d 失败1 跳过%d",
+			summary.Succeeded, summary.Failed, summary.Skipped, disconnectAt-1, fileCount-disconnectAt)
+	}
+
+	// 断开后不再逐个尝试剩余文件
+	opens := 0
+	for _, path := range paths {
+		opens += fake.StreamOpens(path)
+	}
+	if opens >= fileCount {
+		t.Errorf("设备断开后应立即停止，实际打开了 %d 次文件流", opens)
+	}
+
+	// 从磁盘重新加载，断开前完成的记录必须已持久化
+	reloaded := storage.NewBackupTracker(recordsPath, log)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载备份记录失败: %v", err)
+	}
+	if records := len(reloaded.GetStorage().Records); records != disconnectAt-1 {
+		t.Errorf("已持久化的备份记录 = %d，期望 %d", records, disconnectAt-1)
+	}
+}
+
+// TestNewTaskManager_SeparateRecords 测试同一设备的两个任务各自使用自己的目标目录和备份记录，互不跳过
+func TestNewTaskManager_SeparateRecords(t *testing.T) {
+	t.Chdir(t.TempDir())
+	log := logger.NewLogger(false)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+	content := bytes.Repeat([]byte("a"), 1024)
+	fake.AddFile("内部共享存储空间\\录音笔文件\\a.opus", content, time.Now().Add(-time.Hour))
+
+	base := config.DefaultConfig()
+	base.Backup.StabilityWait = ""
+	base.Backup.StabilityWindow = ""
+
+	for _, name := range []string{"nightly", "office"} {
+		task := config.TaskConfig{Name: name, Enabled: true, Source: base.Source, Target: base.Target, Backup: base.Backup}
+		task.Target.BaseDirectory = filepath.Join("backups", name)
+
+		bm := NewTaskManager(base.ForTask(task), name, log, true, false, false)
+		bm.SetMTPInterface(fake)
+		summary, err := bm.Run(context.Background(), deviceInfo, false)
+		bm.Close()
+		if err != nil {
+			t.Fatalf("任务 %s 备份失败: %v", name, err)
+		}
+		if summary.Succeeded != 1 {
+			t.Errorf("任务 %s 成功 %d 个，期望 1 个（不应因其他任务的记录而跳过）", name, summary.Succeeded)
+		}
+		if data, err := os.ReadFile(filepath.Join("backups", name, "a.opus")); err != nil || !bytes.Equal(data, content) {
+			t.Errorf("任务 %s 的目标目录中应有 a.opus: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(TaskDataDir(name), "backup_records.json")); err != nil {
+			t.Errorf("任务 %s 的备份记录应单独保存: %v", name, err)
+		}
+	}
+
+	if _, err := os.Stat(RecordsPath); !os.IsNotExist(err) {
+		t.Error("任务不应写入默认的备份记录文件")
+	}
+}
+
+// TestBackupManager_Metrics 测试备份后实时指标中的字节数、文件计数、在线状态和备份时间
+func TestBackupManager_Metrics(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件\\"
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+	modTime := time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local)
+	fake.AddFile(base+"a.opus", bytes.Repeat([]byte("a"), 1024), modTime)
+	fake.AddFile(base+"b.opus", bytes.Repeat([]byte("b"), 512), modTime)
+	fake.FailStream(base+"b.opus", 10)
+
+	m := metrics.New()
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log),
+		quiet:   true,
+		metrics: m,
+	}
+	bm.SetMTPInterface(fake)
+
+	before := time.Now().Unix()
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err == nil {
+		t.Fatal("有文件复制失败时应返回错误")
+	}
+
+	expected := `
+# HELP record_center_copied_bytes_total 累计复制到目标的字节数
+# TYPE record_center_copied_bytes_total counter
+record_center_copied_bytes_total{device="SR302"} 1024
+# HELP record_center_copy_speed_bytes_per_second 最近10秒的复制速度，未在备份时为0
+# TYPE record_center_copy_speed_bytes_per_second gauge
+record_center_copy_speed_bytes_per_second{device="SR302"} 0
+# HELP record_center_device_online 设备是否在线（1在线，0离线）
+# TYPE record_center_device_online gauge
+record_center_device_online{device="SR302"} 1
+# HELP record_center_files_total 按结果统计的文件数
+# TYPE record_center_files_total counter
+record_center_files_total{device="SR302",result="failed"} 1
+record_center_files_total{device="SR302",result="skipped"} 0
+record_center_files_total{device="SR302",result="success"} 1
+`
+	if err := testutil.GatherAndCompare(m, strings.NewReader(expected),
+		"record_center_copied_bytes_total", "record_center_copy_speed_bytes_per_second",
+		"record_center_device_online", "record_center_files_total"); err != nil {
+		t.Errorf("备份后的指标不符: %v", err)
+	}
+
+	families, err := m.Gather()
+	if err != nil {
+		t.Fatalf("采集指标失败: %v", err)
+	}
+	var lastBackup float64
+	for _, family := range families {
+		if family.GetName() == "record_center_last_backup_timestamp_seconds" {
+			lastBackup = family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	if lastBackup < float64(before) {
+		t.Errorf("上次备份时间戳 = %v，应不早于 %d", lastBackup, before)
+	}
+}
+
+// TestBackupManager_SyncRetriedWithoutNewFiles 测试推送失败的备份记录在下次没有新文件的备份中重试
+func TestBackupManager_SyncRetriedWithoutNewFiles(t *testing.T) {
+	var requests atomic.Int32
+	var synced atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			http.Error(w, "暂时不可用", http.StatusServiceUnavailable)
+			return
+		}
+		var payload recordsync.SyncPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		synced.Add(int32(len(payload.Records)))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddFile("内部共享存储空间\\录音笔文件\\a.opus", bytes.Repeat([]byte("a"), 2048), time.Now().Add(-time.Hour))
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: tracker,
+		syncer:  recordsync.NewSyncer(&config.SyncConfig{Endpoint: server.URL}, tracker, log),
+		quiet:   true,
+	}
+	bm.SetMTPInterface(fake)
+
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("第一次备份失败: %v", err)
+	}
+	bm.syncWG.Wait()
+	if requests.Load() != 1 || len(tracker.GetUnsyncedRecords()) != 1 {
+		t.Fatalf("第一次推送失败后记录应保持未同步: %d 次请求, %d 个未同步", requests.Load(), len(tracker.GetUnsyncedRecords()))
+	}
+
+	// 第二次备份没有新文件，仍应重试推送
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("第二次备份失败: %v", err)
+	}
+	bm.syncWG.Wait()
+	if synced.Load() != 1 {
+		t.Errorf("没有新文件的备份应重试推送失败的记录，实际推送 %d 个", synced.Load())
+	}
+	if unsynced := tracker.GetUnsyncedRecords(); len(unsynced) != 0 {
+		t.Errorf("重试成功后不应有未同步的记录: %d 个", len(unsynced))
+	}
+}
+
+// TestBackupManager_RecordsUnreadable 测试加密的备份记录无法读取时 Run 和 Check 在扫描设备前返回错误，不重复复制
+func TestBackupManager_RecordsUnreadable(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+
+	log := logger.NewLogger(false)
+	recordsPath := filepath.Join(t.TempDir(), "backup_records.json")
+	encrypted := storage.NewBackupTracker(recordsPath, log)
+	encrypted.SetEncryptionKey("right-key")
+	encrypted.AddRecord("内部共享存储空间\\录音笔文件\\a.opus", filepath.Join(cfg.Target.BaseDirectory, "a.opus"), "fake_sr302", 2048, "")
+	if err := encrypted.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := storage.NewBackupTracker(recordsPath, log)
+	tracker.SetEncryptionKey("wrong-key")
+	tracker.Load()
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddFile("内部共享存储空间\\录音笔文件\\a.opus", bytes.Repeat([]byte("a"), 2048), time.Now().Add(-time.Hour))
+	scanner := &countingScanner{}
+
+	bm := &BackupManager{config: cfg, log: log, tracker: tracker, scanner: scanner, quiet: true}
+	bm.SetMTPInterface(fake)
+
+	if _, err := bm.Run(context.Background(), deviceInfo, false); !errors.Is(err, storage.ErrWrongKey) {
+		t.Errorf("Run 应返回密钥错误，实际: %v", err)
+	}
+	if err := bm.Check(deviceInfo); !errors.Is(err, storage.ErrWrongKey) {
+		t.Errorf("Check 应返回密钥错误，实际: %v", err)
+	}
+	if scanner.calls != 0 || fake.StreamOpens("内部共享存储空间\\录音笔文件\\a.opus") != 0 {
+		t.Errorf("记录无法读取时不应扫描或复制: 扫描 %d 次", scanner.calls)
+	}
+}
@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 同步模式
+const (
+	SyncModeIncremental = "incremental" // 只新增，不删除备份
+	SyncModeMirror      = "mirror"      // 镜像设备，清理设备上已不存在的备份
+)
+
+// TrashDirName 未配置 backup.trash_dir 时回收站的目录名（位于目标目录下）
+const TrashDirName = ".trash"
+
+// mirrorSafeRatio 安全模式下允许一次清理的备份占该设备记录的最大比例
+const mirrorSafeRatio = 0.5
+
+// ErrMirrorUnsafe 安全模式拒绝执行镜像清理
+var ErrMirrorUnsafe = errors.New("安全模式拒绝镜像清理")
+
+// MirrorRecorder 镜像清理所需的备份记录操作
+type MirrorRecorder interface {
+	GetRecordsByDevice(deviceID string) []storage.BackupRecord
+	RemoveRecord(sourcePath string) error
+	RecordsByContent(contentHash string) []storage.BackupRecord
+}
+
+// MirrorCleaner 镜像模式的清理器
+// 备份完成后把设备上已不存在的文件从目标目录移入回收站（不直接删除），并移除对应记录
+type MirrorCleaner struct {
+	baseDir        string
+	tracker        MirrorRecorder
+	trash          *Trash
+	safeMode       bool
+	followSymlinks bool // 目标路径中含符号链接时是否仍然移动
+	log            *logger.Logger
+	now            func() time.Time
+}
+
+// NewMirrorCleaner 创建镜像清理器
+func NewMirrorCleaner(baseDir string, tracker MirrorRecorder, safeMode bool, log *logger.Logger) *MirrorCleaner {
+	return &MirrorCleaner{
+		baseDir:  baseDir,
+		tracker:  tracker,
+		trash:    NewTrash("", baseDir, 0, log),
+		safeMode: safeMode,
+		log:      log,
+		now:      time.Now,
+	}
+}
+
+// SetFollowSymlinks 设置是否跟随符号链接，默认不跟随：目标路径中含链接的备份跳过并警告
+func (mc *MirrorCleaner) SetFollowSymlinks(follow bool) {
+	mc.followSymlinks = follow
+}
+
+// SetTrash 设置回收站，默认使用目标目录下的 .trash
+func (mc *MirrorCleaner) SetTrash(trash *Trash) {
+	mc.trash = trash
+}
+
+// Clean 清理设备上已不存在的备份，返回移入回收目录的文件数
+// 安全模式下设备未返回任何文件、或待清理的备份超过该设备记录的一半时，视为枚举异常，返回 ErrMirrorUnsafe
+func (mc *MirrorCleaner) Clean(deviceID string, deviceFiles []*utils.FileInfo) (int, error) {
+	present := make(map[string]bool, len(deviceFiles))
+	for _, file := range deviceFiles {
+		present[file.Path] = true
+	}
+
+	records := mc.tracker.GetRecordsByDevice(deviceID)
+	var stale []storage.BackupRecord
+	for _, record := range records {
+		if record.Success && !present[record.SourcePath] {
+			stale = append(stale, record)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	if mc.safeMode {
+		if len(deviceFiles) == 0 {
+			return 0, fmt.Errorf("%w: 设备未返回任何文件", ErrMirrorUnsafe)
+		}
+		if float64(len(stale)) > float64(len(records))*mirrorSafeRatio {
+			return 0, fmt.Errorf("%w: 待清理 %d 个，超过已备份 %d 个的一半", ErrMirrorUnsafe, len(stale), len(records))
+		}
+	}
+
+	deletedAt := mc.now()
+	moved := 0
+	for _, record := range stale {
+		// 内容寻址布局下其他记录仍引用同一文件时只移除记录
+		if mc.sharedContent(record) {
+			if err := mc.tracker.RemoveRecord(record.SourcePath); err != nil {
+				mc.log.Warn("移除备份记录失败: %s, %v", record.SourcePath, err)
+				continue
+			}
+			moved++
+			mc.log.Info("设备上已删除，移除记录，内容仍被其他记录引用: %s", record.SourcePath)
+			continue
+		}
+		if err := mc.moveToTrash(record, deletedAt); err != nil {
+			mc.log.Warn("移入回收站失败: %s, %v", record.TargetPath, err)
+			continue
+		}
+		if err := mc.tracker.RemoveRecord(record.SourcePath); err != nil {
+			mc.log.Warn("移除备份记录失败: %s, %v", record.SourcePath, err)
+		}
+		moved++
+		mc.log.Info("设备上已删除，备份移入回收站: %s", record.TargetPath)
+	}
+
+	return moved, nil
+}
+
+// sharedContent 记录的内容哈希是否还被其他记录引用
+func (mc *MirrorCleaner) sharedContent(record storage.BackupRecord) bool {
+	if record.ContentHash == "" {
+		return false
+	}
+	for _, other := range mc.tracker.RecordsByContent(record.ContentHash) {
+		if other.SourcePath != record.SourcePath {
+			return true
+		}
+	}
+	return false
+}
+
+// moveToTrash 将备份及其元数据文件移入回收站，回收站记录原路径和被移除的备份记录；文件已不存在时视为成功
+func (mc *MirrorCleaner) moveToTrash(record storage.BackupRecord, deletedAt time.Time) error {
+	// 默认不跟随符号链接，避免移动链接指向的真实目录中的文件
+	if !mc.followSymlinks {
+		if link, ok := utils.LinkInPath(mc.baseDir, record.TargetPath); ok {
+			return fmt.Errorf("路径包含符号链接，未开启 follow_symlinks 时跳过: %s", link)
+		}
+	}
+
+	if _, err := mc.trash.Move(record.TargetPath, deletedAt, &record); err != nil {
+		return err
+	}
+	// 元数据文件随备份一起移动
+	_, err := mc.trash.Move(SidecarPath(record.TargetPath), deletedAt, nil)
+	return err
+}
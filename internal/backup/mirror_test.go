@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// newMirrorFixture 创建已备份 names 中所有文件的目标目录与备份记录
+func newMirrorFixture(t *testing.T, names []string) (string, *storage.BackupTracker) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), log)
+
+	for _, name := range names {
+		targetPath := filepath.Join(baseDir, "录音笔文件", name)
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(targetPath, []byte(name), 0644); err != nil {
+			t.Fatalf("创建备份文件失败: %v", err)
+		}
+		if err := tracker.AddRecord("device\\"+name, targetPath, "test_device", int64(len(name)), ""); err != nil {
+			t.Fatalf("添加备份记录失败: %v", err)
+		}
+	}
+	return baseDir, tracker
+}
+
+// deviceFiles 构造设备上的文件列表
+func deviceFiles(names ...string) []*utils.FileInfo {
+	var files []*utils.FileInfo
+	for _, name := range names {
+		files = append(files, &utils.FileInfo{Path: "device\\" + name, RelativePath: name, Name: name})
+	}
+	return files
+}
+
+// TestMirrorCleaner_MovesDeletedToTrash 测试设备上少了一个文件时对应备份移入回收目录
+func TestMirrorCleaner_MovesDeletedToTrash(t *testing.T) {
+	baseDir, tracker := newMirrorFixture(t, []string{"a.opus", "b.opus", "c.opus"})
+
+	cleaner := NewMirrorCleaner(baseDir, tracker, true, logger.NewLogger(false))
+	cleaner.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local) }
+
+	moved, err := cleaner.Clean("test_device", deviceFiles("a.opus", "c.opus"))
+	if err != nil {
+		t.Fatalf("镜像清理失败: %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("期望移入 1 个文件，实际 %d", moved)
+	}
+
+	if _, err := os.Stat(filepath.Join(baseDir, "录音笔文件", "b.opus")); !os.IsNotExist(err) {
+		t.Error("设备上已删除的文件应从备份目录移走")
+	}
+	trashPath := filepath.Join(baseDir, TrashDirName, "20240501_100000", "录音笔文件", "b.opus")
+	if _, err := os.Stat(trashPath); err != nil {
+		t.Errorf("期望文件移入回收目录 %s: %v", trashPath, err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "录音笔文件", "a.opus")); err != nil {
+		t.Error("设备上仍存在的文件不应被移动")
+	}
+
+	if backedUp, _, _ := tracker.IsFileBackedUp("device\\b.opus"); backedUp {
+		t.Error("被清理文件的备份记录应移除")
+	}
+	if len(tracker.GetRecordsByDevice("test_device")) != 2 {
+		t.Error("其他备份记录应保留")
+	}
+}
+
+// TestMirrorCleaner_ReadOnlyBackup 测试设为只读的备份先清除只读再移入回收目录
+func TestMirrorCleaner_ReadOnlyBackup(t *testing.T) {
+	baseDir, tracker := newMirrorFixture(t, []string{"a.opus", "b.opus"})
+	targetPath := filepath.Join(baseDir, "录音笔文件", "b.opus")
+	if err := utils.ApplyFileAttributes(targetPath, 0444, true); err != nil {
+		t.Fatalf("设置只读失败: %v", err)
+	}
+
+	cleaner := NewMirrorCleaner(baseDir, tracker, false, logger.NewLogger(false))
+	cleaner.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local) }
+	if moved, err := cleaner.Clean("test_device", deviceFiles("a.opus")); err != nil || moved != 1 {
+		t.Fatalf("期望移入 1 个文件，实际 %d, %v", moved, err)
+	}
+
+	trashPath := filepath.Join(baseDir, TrashDirName, "20240501_100000", "录音笔文件", "b.opus")
+	if readOnly, err := utils.IsReadOnly(trashPath); err != nil || readOnly {
+		t.Errorf("回收目录中的文件应已清除只读: %v", err)
+	}
+	if err := os.Remove(trashPath); err != nil {
+		t.Errorf("回收目录中的文件应能删除: %v", err)
+	}
+}
+
+// TestMirrorCleaner_SafeMode 测试安全模式拒绝可疑的镜像清理
+func TestMirrorCleaner_SafeMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		safeMode  bool
+		present   []string
+		wantMoved int
+		wantErr   bool
+	}{
+		{"设备未返回文件", true, nil, 0, true},
+		{"待清理超过一半", true, []string{"a.opus"}, 0, true},
+		{"关闭安全模式时照常清理", false, []string{"a.opus"}, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDir, tracker := newMirrorFixture(t, []string{"a.opus", "b.opus", "c.opus"})
+			cleaner := NewMirrorCleaner(baseDir, tracker, tt.safeMode, logger.NewLogger(false))
+
+			moved, err := cleaner.Clean("test_device", deviceFiles(tt.present...))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("期望错误 %v，实际 %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrMirrorUnsafe) {
+				t.Errorf("期望 ErrMirrorUnsafe，实际 %v", err)
+			}
+			if moved != tt.wantMoved {
+				t.Errorf("期望移入 %d 个文件，实际 %d", tt.wantMoved, moved)
+			}
+			if tt.wantErr {
+				if len(tracker.GetRecordsByDevice("test_device")) != 3 {
+					t.Error("拒绝清理时不应移除记录")
+				}
+			}
+		})
+	}
+}
+
+// TestMirrorCleaner_Symlink 测试目标路径含符号链接时默认不移动，开启 follow_symlinks 后移动
+func TestMirrorCleaner_Symlink(t *testing.T) {
+	tests := []struct {
+		name           string
+		followSymlinks bool
+		wantMoved      int
+	}{
+		{name: "默认不跟随", followSymlinks: false, wantMoved: 0},
+		{name: "开启后跟随", followSymlinks: true, wantMoved: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDir, tracker := newMirrorFixture(t, []string{"a.opus", "b.opus", "c.opus"})
+
+			// 目标目录下的 linked 指向外部真实目录，其中的备份在设备上已删除
+			outside := filepath.Join(t.TempDir(), "real")
+			if err := os.MkdirAll(outside, 0755); err != nil {
+				t.Fatalf("创建目录失败: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(outside, "d.opus"), []byte("d.opus"), 0644); err != nil {
+				t.Fatalf("创建备份文件失败: %v", err)
+			}
+			if err := os.Symlink(outside, filepath.Join(baseDir, "linked")); err != nil {
+				t.Skipf("当前环境无法创建符号链接: %v", err)
+			}
+			linkedPath := filepath.Join(baseDir, "linked", "d.opus")
+			if err := tracker.AddRecord("device\\d.opus", linkedPath, "test_device", 6, ""); err != nil {
+				t.Fatalf("添加备份记录失败: %v", err)
+			}
+
+			cleaner := NewMirrorCleaner(baseDir, tracker, true, logger.NewLogger(false))
+			cleaner.SetFollowSymlinks(tt.followSymlinks)
+			moved, err := cleaner.Clean("test_device", deviceFiles("a.opus", "b.opus", "c.opus"))
+			if err != nil {
+				t.Fatalf("镜像清理失败: %v", err)
+			}
+			if moved != tt.wantMoved {
+				t.Errorf("移入数量 = %d, 期望 %d", moved, tt.wantMoved)
+			}
+
+			_, statErr := os.Stat(filepath.Join(outside, "d.opus"))
+			if tt.followSymlinks == (statErr == nil) {
+				t.Errorf("链接目标中的文件存在 = %v, 开启跟随 = %v", statErr == nil, tt.followSymlinks)
+			}
+			if _, err := tracker.GetRecordByPath("device\\d.opus"); (err == nil) == tt.followSymlinks {
+				t.Errorf("跳过的备份应保留记录，跟随后应移除记录")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package backup
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/allanpk716/record_center/internal/device"
+)
+
+// 复制失败时的错误策略
+const (
+	OnErrorContinue    = "continue"      // 单个文件失败不影响其他文件
+	OnErrorStop        = "stop"          // 任意文件失败即取消剩余复制
+	OnErrorStopOnFatal = "stop-on-fatal" // 只在致命错误（空间不足、设备断开）时取消剩余复制
+)
+
+// SkipReasonStopped 错误策略取消剩余复制后，未开始复制的文件的跳过原因
+const SkipReasonStopped = "错误策略已停止备份"
+
+// SkipReasonDisconnected 设备断开后停止剩余复制，未完成复制的文件的跳过原因
+const SkipReasonDisconnected = "设备已断开"
+
+// fatalMessages 致命错误的可读说明，与 device 包解析 PowerShell 输出得到的说明一致
+var fatalMessages = []string{"目标磁盘空间不足", "目标磁盘已满"}
+
+// IsFatalCopyError 判断复制错误是否致命：目标空间不足、设备已断开或复位失败，继续复制其他文件也必然失败
+func IsFatalCopyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, windows.ERROR_DISK_FULL) || errors.Is(err, windows.ERROR_HANDLE_DISK_FULL) {
+		return true
+	}
+
+	if errors.Is(err, ErrDeviceResetFailed) {
+		return true
+	}
+
+	if errors.Is(err, device.ErrDeviceNotConnected) {
+		return true
+	}
+
+	message := err.Error()
+	for _, fatal := range fatalMessages {
+		if strings.Contains(message, fatal) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldStopOnError 按错误策略判断复制失败后是否取消剩余复制
+func shouldStopOnError(policy string, err error) bool {
+	switch policy {
+	case OnErrorStop:
+		return true
+	case OnErrorStopOnFatal:
+		return IsFatalCopyError(err)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/progress"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+var (
+	// errNormal 普通的单文件错误
+	errNormal = fmt.Errorf("读取文件失败: %w", errors.New("校验和不匹配"))
+	// errDiskFull 目标磁盘已满
+	errDiskFull = &os.PathError{Op: "write", Path: "D:\\backup\\a.opus", Err: windows.ERROR_DISK_FULL}
+	// errDisconnected 设备已断开
+	errDisconnected = device.NewMTPError(device.ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+)
+
+// TestIsFatalCopyError 测试致命错误的识别
+func TestIsFatalCopyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"普通错误", errNormal, false},
+		{"磁盘已满", errDiskFull, true},
+		{"包装后的磁盘已满", fmt.Errorf("复制失败: %w", errDiskFull), true},
+		{"设备断开", fmt.Errorf("打开文件流失败: %w", errDisconnected), true},
+		{"PowerShell报告空间不足", device.NewMTPError(device.ERROR_INVALID_PARAMETER, "复制失败: 目标磁盘空间不足 (0x80070070)", nil), true},
+		{"设备忙", device.NewRetryableMTPError(device.ERROR_DEVICE_BUSY, "设备忙，请稍后重试", nil), false},
+		{"设备复位失败", errors.Join(errNormal, fmt.Errorf("%w: 重连超时", ErrDeviceResetFailed)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsFatalCopyError(tt.err); got != tt.want {
+				t.Errorf("IsFatalCopyError() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBackupManager_OnError 测试三种错误策略在首个文件失败后的停止行为，设备断开时总是停止
+func TestBackupManager_OnError(t *testing.T) {
+	const fileCount = 5
+
+	tests := []struct {
+		name     string
+		policy   string
+		err      error
+		wantStop bool
+	}{
+		{"continue-普通错误继续", OnErrorContinue, errNormal, false},
+		{"continue-致命错误也继续", OnErrorContinue, errDiskFull, false},
+		{"stop-普通错误停止", OnErrorStop, errNormal, true},
+		{"stop-on-fatal-普通错误继续", OnErrorStopOnFatal, errNormal, false},
+		{"stop-on-fatal-空间不足停止", OnErrorStopOnFatal, errDiskFull, true},
+		{"stop-on-fatal-设备断开停止", OnErrorStopOnFatal, errDisconnected, true},
+		{"continue-设备断开也停止", OnErrorContinue, errDisconnected, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(false)
+			cfg := config.DefaultConfig()
+			cfg.Backup.MaxConcurrent = 1
+			cfg.Backup.CommitInterval = 0
+			cfg.Backup.OnError = tt.policy
+
+			bm := &BackupManager{
+				config:  cfg,
+				log:     log,
+				tracker: storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log),
+				quiet:   true,
+			}
+			copier := NewFileCopier(cfg, log, bm.tracker, &device.DeviceInfo{DeviceID: "test_device"})
+
+			// 第一个开始复制的文件失败，其余文件复制成功
+			var calls int32
+			copier.copyFunc = func(file *utils.FileInfo, force bool) *CopyResult {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					return &CopyResult{File: file, Error: tt.err}
+				}
+				time.Sleep(20 * time.Millisecond)
+				return &CopyResult{File: file, Success: true, BytesCopied: file.Size}
+			}
+
+			var files []*utils.FileInfo
+			for i := 0; i < fileCount; i++ {
+				name := fmt.Sprintf("file%d.opus", i)
+				files = append(files, &utils.FileInfo{Path: "device\\" + name, RelativePath: name, Name: name, Size: 100})
+			}
+
+			progressTracker := progress.NewProgressTracker(log)
+			progressTracker.StartWithParams(len(files), utils.CalculateTotalSize(files))
+			display := progress.NewProgressDisplay(progressTracker, true, log)
+
+			results := bm.copyFilesWithProgress(context.Background(), copier, files, progressTracker, display, false)
+			if len(results) != fileCount {
+				t.Fatalf("结果数 = %d, 期望 %d", len(results), fileCount)
+			}
+
+			var succeeded, failed, stopped int
+			for _, result := range results {
+				switch {
+				case result.Success:
+					succeeded++
+				case result.Skipped && (result.SkipReason == SkipReasonStopped || result.SkipReason == SkipReasonDisconnected):
+					stopped++
+				default:
+					failed++
+				}
+			}
+
+			if failed != 1 {
+				t.Errorf("失败数 = %d, 期望 1", failed)
+			}
+			if tt.wantStop {
+				// 取消前至多有一个文件已开始复制
+				if succeeded > 1 || stopped < fileCount-2 {
+					t.Errorf("应停止剩余复制: 成功 %d, 停止 %d", succeeded, stopped)
+				}
+			} else if succeeded != fileCount-1 || stopped != 0 {
+				t.Errorf("不应停止: 成功 %d, 停止 %d", succeeded, stopped)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 目录模板占位符
+const (
+	PlaceholderDayPart  = "{daypart}"  // morning、afternoon、evening、night
+	PlaceholderWeekday  = "{weekday}"  // monday ~ sunday
+	PlaceholderWeekType = "{weektype}" // weekday、weekend
+	PlaceholderDevice   = "{device}"   // 设备别名，未配置别名时为设备名
+)
+
+// UnknownTimeDir 无法确定录音时间时使用的目录名
+const UnknownTimeDir = "unknown"
+
+// nameTimestampPattern 匹配文件名中的时间戳，如 20240315_143022、2024-03-15 14-30-22、20240315143022
+var nameTimestampPattern = regexp.MustCompile(`(\d{4})[-_]?(\d{2})[-_]?(\d{2})[ _-]?(\d{2})[-_:]?(\d{2})[-_:]?(\d{2})`)
+
+// PathTemplate 按录音时间展开的目录模板
+type PathTemplate struct {
+	template string
+	starts   [4]int // morning、afternoon、evening、night 的开始时间（当天分钟数）
+	device   string // {device} 展开的设备名称
+}
+
+// NewPathTemplate 创建目录模板，template 为空时不添加分类目录
+func NewPathTemplate(template string, dayParts config.DayPartsConfig) (*PathTemplate, error) {
+	pt := &PathTemplate{template: template}
+	for i, value := range []string{dayParts.Morning, dayParts.Afternoon, dayParts.Evening, dayParts.Night} {
+		minutes, err := config.ParseClock(value)
+		if err != nil {
+			return nil, fmt.Errorf("解析时段配置失败: %w", err)
+		}
+		pt.starts[i] = minutes
+	}
+	return pt, nil
+}
+
+// SetDevice 设置 {device} 展开的设备名称，为空时展开为 unknown
+func (pt *PathTemplate) SetDevice(name string) {
+	pt.device = name
+}
+
+// Expand 按文件的录音时间展开模板，返回相对目标根目录的分类目录
+func (pt *PathTemplate) Expand(file *utils.FileInfo) string {
+	if pt == nil || pt.template == "" {
+		return ""
+	}
+
+	deviceDir := pt.device
+	if deviceDir == "" {
+		deviceDir = UnknownTimeDir
+	}
+	recordedAt := RecordingTime(file)
+	replacer := strings.NewReplacer(
+		PlaceholderDayPart, pt.DayPart(recordedAt),
+		PlaceholderWeekday, weekdayName(recordedAt),
+		PlaceholderWeekType, weekType(recordedAt),
+		PlaceholderDevice, deviceDir,
+	)
+	return replacer.Replace(pt.template)
+}
+
+// DayPart 获取时间所属的时段，早于 morning 开始时间的归入 night
+func (pt *PathTemplate) DayPart(t time.Time) string {
+	if t.IsZero() {
+		return UnknownTimeDir
+	}
+
+	minutes := t.Hour()*60 + t.Minute()
+	switch {
+	case minutes >= pt.starts[3] || minutes < pt.starts[0]:
+		return "night"
+	case minutes >= pt.starts[2]:
+		return "evening"
+	case minutes >= pt.starts[1]:
+		return "afternoon"
+	default:
+		return "morning"
+	}
+}
+
+// RecordingTime 获取录音时间：优先使用文件名中的时间戳，其次为修改时间，都没有时返回零值
+func RecordingTime(file *utils.FileInfo) time.Time {
+	if t, ok := parseNameTimestamp(file.Name); ok {
+		return t
+	}
+	return file.ModTime
+}
+
+// parseNameTimestamp 从文件名中解析时间戳（按本地时区）
+func parseNameTimestamp(name string) (time.Time, bool) {
+	matches := nameTimestampPattern.FindStringSubmatch(name)
+	if matches == nil {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation("20060102150405", strings.Join(matches[1:], ""), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// weekdayName 获取星期名称
+func weekdayName(t time.Time) string {
+	if t.IsZero() {
+		return UnknownTimeDir
+	}
+	return strings.ToLower(t.Weekday().String())
+}
+
+// weekType 获取工作日或周末
+func weekType(t time.Time) string {
+	if t.IsZero() {
+		return UnknownTimeDir
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return "weekend"
+	}
+	return "weekday"
+}
@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestPathTemplate_Expand 测试按录音时间展开目录模板
+func TestPathTemplate_Expand(t *testing.T) {
+	dayParts := config.DefaultConfig().Target.DayParts
+
+	tests := []struct {
+		name     string
+		template string
+		file     *utils.FileInfo
+		want     string
+	}{
+		{
+			name:     "文件名时间戳-工作日上午",
+			template: "{weektype}/{daypart}",
+			file:     &utils.FileInfo{Name: "REC_20240311_093000.opus"},
+			want:     "weekday/morning",
+		},
+		{
+			name:     "文件名时间戳-周六下午",
+			template: "{weektype}/{daypart}",
+			file:     &utils.FileInfo{Name: "2024-03-16 15-20-00.opus"},
+			want:     "weekend/afternoon",
+		},
+		{
+			name:     "文件名时间戳优先于修改时间",
+			template: "{weekday}/{daypart}",
+			file: &utils.FileInfo{
+				Name:    "20240313190000.opus",
+				ModTime: time.Date(2024, 3, 17, 8, 0, 0, 0, time.Local),
+			},
+			want: "wednesday/evening",
+		},
+		{
+			name:     "无时间戳时使用修改时间",
+			template: "{weekday}/{daypart}",
+			file: &utils.FileInfo{
+				Name:    "会议记录.opus",
+				ModTime: time.Date(2024, 3, 17, 23, 30, 0, 0, time.Local),
+			},
+			want: "sunday/night",
+		},
+		{
+			name:     "凌晨归入night",
+			template: "{daypart}",
+			file:     &utils.FileInfo{Name: "REC_20240311_030000.opus"},
+			want:     "night",
+		},
+		{
+			name:     "无可用时间时归入unknown",
+			template: "{weektype}/{weekday}/{daypart}",
+			file:     &utils.FileInfo{Name: "会议记录.opus"},
+			want:     "unknown/unknown/unknown",
+		},
+		{
+			name:     "非法日期的时间戳不采用",
+			template: "{daypart}",
+			file: &utils.FileInfo{
+				Name:    "REC_20241399_250000.opus",
+				ModTime: time.Date(2024, 3, 11, 13, 0, 0, 0, time.Local),
+			},
+			want: "afternoon",
+		},
+		{
+			name:     "模板中的普通文本保留",
+			template: "录音/{weektype}",
+			file:     &utils.FileInfo{Name: "REC_20240311_093000.opus"},
+			want:     "录音/weekday",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt, err := NewPathTemplate(tt.template, dayParts)
+			if err != nil {
+				t.Fatalf("创建目录模板失败: %v", err)
+			}
+			if got := pt.Expand(tt.file); got != tt.want {
+				t.Errorf("Expand() = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPathTemplate_DayPartBoundaries 测试自定义时段边界
+func TestPathTemplate_DayPartBoundaries(t *testing.T) {
+	pt, err := NewPathTemplate("{daypart}", config.DayPartsConfig{
+		Morning:   "07:30",
+		Afternoon: "13:00",
+		Evening:   "17:30",
+		Night:     "21:00",
+	})
+	if err != nil {
+		t.Fatalf("创建目录模板失败: %v", err)
+	}
+
+	tests := []struct {
+		clock string
+		want  string
+	}{
+		{"07:29", "night"},
+		{"07:30", "morning"},
+		{"12:59", "morning"},
+		{"13:00", "afternoon"},
+		{"17:30", "evening"},
+		{"20:59", "evening"},
+		{"21:00", "night"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clock, func(t *testing.T) {
+			clock, _ := time.Parse("15:04", tt.clock)
+			at := time.Date(2024, 3, 11, clock.Hour(), clock.Minute(), 0, 0, time.Local)
+			if got := pt.DayPart(at); got != tt.want {
+				t.Errorf("DayPart(%s) = %s, 期望 %s", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPathTemplate_Empty 测试空模板不添加分类目录
+func TestPathTemplate_Empty(t *testing.T) {
+	var nilTemplate *PathTemplate
+	if got := nilTemplate.Expand(&utils.FileInfo{Name: "REC_20240311_093000.opus"}); got != "" {
+		t.Errorf("nil 模板应返回空目录，实际 %s", got)
+	}
+
+	if _, err := NewPathTemplate("{daypart}", config.DayPartsConfig{Morning: "25:00"}); err == nil {
+		t.Error("非法的时段配置应返回错误")
+	}
+}
+
+// TestFileCopier_DeviceAlias 测试 {device} 和元数据文件中的设备名优先使用别名，未配置别名时使用设备名
+func TestFileCopier_DeviceAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		want    string
+	}{
+		{"按序列号配置别名", map[string]string{"ABC123": "会议室"}, "会议室"},
+		{"按DeviceID配置别名", map[string]string{"USB\\VID_2207&PID_0011\\ABC123": "张三"}, "张三"},
+		{"其他设备的别名不生效", map[string]string{"XYZ789": "李四"}, "SR302"},
+		{"未配置别名", nil, "SR302"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+			cfg.Target.PathTemplate = "{device}"
+			cfg.Backup.EnableResume = false
+			cfg.Backup.RangeDownload.Enabled = false
+			cfg.Backup.WriteSidecar = true
+			cfg.DeviceAliases = tt.aliases
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "USB\\VID_2207&PID_0011\\ABC123", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), deviceInfo)
+			copier.SetMTPInterface(fake)
+
+			file := &utils.FileInfo{Path: "内部共享存储空间\\录音笔文件\\a.opus", RelativePath: "a.opus", Name: "a.opus", Size: 5}
+			fake.AddFile(file.Path, []byte("audio"), time.Now())
+			result := copier.CopyFile(file, true)
+			if !result.Success {
+				t.Fatalf("复制失败: %v", result.Error)
+			}
+
+			wantTarget := filepath.Join(cfg.Target.BaseDirectory, tt.want, "a.opus")
+			if result.TargetPath != wantTarget {
+				t.Errorf("目标路径 = %s，期望 %s", result.TargetPath, wantTarget)
+			}
+			data, err := os.ReadFile(SidecarPath(result.TargetPath))
+			if err != nil {
+				t.Fatalf("读取元数据文件失败: %v", err)
+			}
+			var sidecar Sidecar
+			if err := json.Unmarshal(data, &sidecar); err != nil {
+				t.Fatalf("解析元数据文件失败: %v", err)
+			}
+			if sidecar.DeviceName != tt.want || sidecar.DeviceID != deviceInfo.DeviceID {
+				t.Errorf("元数据中的设备 = %s (%s)，期望 %s", sidecar.DeviceName, sidecar.DeviceID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// SkipReasonPreCopyRejected 复制前校验命令拒绝文件的跳过原因前缀，后面附带退出码
+const SkipReasonPreCopyRejected = "复制前校验未通过"
+
+// PreCopyFilePlaceholder 校验命令中替换为临时导出文件路径的占位符，命令中没有时把路径追加为最后一个参数
+const PreCopyFilePlaceholder = "{file}"
+
+// preCopyOutputLimit 跳过原因和日志中保留的命令输出长度
+const preCopyOutputLimit = 200
+
+// errPreCopyTimeout 校验命令超过 pre_copy_timeout 时的错误，该文件标记失败
+var errPreCopyTimeout = errors.New("复制前校验命令超时")
+
+// PreCopyCheck 复制前对每个文件执行的校验命令，用于接入杀毒扫描或自定义校验
+type PreCopyCheck struct {
+	args    []string
+	timeout time.Duration // 0表示不限制
+}
+
+// NewPreCopyCheck 解析 backup.pre_copy_command 和 backup.pre_copy_timeout，未配置命令时返回nil
+func NewPreCopyCheck(cfg *config.BackupConfig) (*PreCopyCheck, error) {
+	if strings.TrimSpace(cfg.PreCopyCommand) == "" {
+		return nil, nil
+	}
+	args, err := utils.SplitCommandLine(cfg.PreCopyCommand)
+	if err != nil {
+		return nil, fmt.Errorf("解析复制前校验命令失败: %w", err)
+	}
+	check := &PreCopyCheck{args: args}
+	if cfg.PreCopyTimeout != "" {
+		timeout, err := utils.ParseDuration(cfg.PreCopyTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("解析复制前校验超时失败: %w", err)
+		}
+		check.timeout = timeout
+	}
+	return check, nil
+}
+
+// command 生成检查 path 的命令参数
+func (c *PreCopyCheck) command(path string) []string {
+	args := make([]string, 0, len(c.args)+1)
+	replaced := false
+	for _, arg := range c.args {
+		if strings.Contains(arg, PreCopyFilePlaceholder) {
+			arg = strings.ReplaceAll(arg, PreCopyFilePlaceholder, path)
+			replaced = true
+		}
+		args = append(args, arg)
+	}
+	if !replaced {
+		args = append(args, path)
+	}
+	return args
+}
+
+// Run 对 path 执行校验命令，返回退出码和命令输出
+// 命令无法启动或超时时返回错误，退出码非0本身不是错误
+func (c *PreCopyCheck) Run(path string) (int, string, error) {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, c.timeout, errPreCopyTimeout)
+		defer cancel()
+	}
+
+	args := c.command(path)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// 命令派生的子进程持有输出管道时，超时后不再无限等待
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	text := strings.TrimSpace(string(output))
+
+	if cause := context.Cause(ctx); cause != nil {
+		return -1, text, fmt.Errorf("%w（%s）", cause, utils.FormatDuration(c.timeout))
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), text, nil
+	}
+	if err != nil {
+		return -1, text, fmt.Errorf("执行复制前校验命令失败: %w", err)
+	}
+	return 0, text, nil
+}
+
+// checkBeforeCopy 把设备文件临时导出后交给校验命令，命令拒绝时将结果标记为跳过并返回 false
+// 导出或执行命令失败时记录错误并返回 false，文件不被复制；通过校验时保留临时文件，
+// 复制从它读取而不再从设备下载，调用方复制结束后用 releaseChecked 删除
+func (fc *FileCopier) checkBeforeCopy(file *utils.FileInfo, result *CopyResult) bool {
+	if fc.preCopy == nil {
+		return true
+	}
+
+	tempPath, err := fc.exportForCheck(file)
+	if err != nil {
+		result.Error = fmt.Errorf("导出待校验文件失败: %w", err)
+		fc.log.Error("导出待校验文件失败: %s, %v", file.RelativePath, err)
+		return false
+	}
+
+	exitCode, output, err := fc.preCopy.Run(tempPath)
+	if err == nil && exitCode == 0 {
+		fc.log.Debug("复制前校验通过: %s", file.RelativePath)
+		fc.checked.Store(file.Path, tempPath)
+		return true
+	}
+	os.Remove(tempPath)
+	if err != nil {
+		result.Error = err
+		fc.log.Error("复制前校验失败: %s, %v", file.RelativePath, err)
+		return false
+	}
+
+	result.Skipped = true
+	result.SkipReason = fmt.Sprintf("%s（退出码 %d）", SkipReasonPreCopyRejected, exitCode)
+	if output != "" {
+		result.SkipReason += ": " + truncateOutput(output, preCopyOutputLimit)
+	}
+	fc.log.Warn("复制前校验拒绝文件: %s, %s", file.RelativePath, result.SkipReason)
+	return false
+}
+
+// releaseChecked 删除文件通过校验时保留的临时导出文件
+func (fc *FileCopier) releaseChecked(file *utils.FileInfo) {
+	if value, ok := fc.checked.LoadAndDelete(file.Path); ok {
+		os.Remove(value.(string))
+	}
+}
+
+// exportForCheck 把设备文件导出到临时目录，临时文件名以原文件名结尾，便于扫描程序识别类型和报告
+func (fc *FileCopier) exportForCheck(file *utils.FileInfo) (string, error) {
+	source, err := fc.openFileStream(file)
+	if err != nil {
+		return "", fmt.Errorf("打开设备文件流失败: %w", err)
+	}
+	defer source.Close()
+
+	temp, err := os.CreateTemp(utils.TempDir(), utils.TempFilePrefix+"precopy_*_"+filepath.Base(file.Name))
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	_, copyErr := io.Copy(temp, source)
+	closeErr := temp.Close()
+	if copyErr != nil || closeErr != nil {
+		os.Remove(temp.Name())
+		if copyErr != nil {
+			return "", copyErr
+		}
+		return "", closeErr
+	}
+	return temp.Name(), nil
+}
+
+// truncateOutput 只保留命令输出的前 limit 个字符，多行合并为一行
+func truncateOutput(output string, limit int) string {
+	output = strings.Join(strings.Fields(output), " ")
+	runes := []rune(output)
+	if len(runes) <= limit {
+		return output
+	}
+	return string(runes[:limit]) + "..."
+}
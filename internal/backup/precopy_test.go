@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// preCopyHelperEnv 设置后测试二进制作为假的校验命令运行
+const preCopyHelperEnv = "RECORD_CENTER_PRECOPY_HELPER"
+
+// TestPreCopyHelperProcess 假的校验命令：文件名含 infected 时退出码 2，含 slow 时长时间不退出，其余通过
+// 只在作为子进程被调用时执行
+func TestPreCopyHelperProcess(t *testing.T) {
+	if os.Getenv(preCopyHelperEnv) != "1" {
+		return
+	}
+	path := os.Args[len(os.Args)-1]
+	if _, err := os.Stat(path); err != nil {
+		fmt.Println("文件不存在:", path)
+		os.Exit(3)
+	}
+	switch name := filepath.Base(path); {
+	case strings.Contains(name, "infected"):
+		fmt.Println("FOUND: Eicar-Test-Signature")
+		os.Exit(2)
+	case strings.Contains(name, "slow"):
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+// preCopyHelperCommand 返回调用 TestPreCopyHelperProcess 的校验命令模板
+func preCopyHelperCommand(t *testing.T) string {
+	t.Setenv(preCopyHelperEnv, "1")
+	return fmt.Sprintf(`"%s" -test.run=^TestPreCopyHelperProcess$ -- {file}`, os.Args[0])
+}
+
+// TestFileCopier_PreCopyCommand 测试校验通过的文件从校验过的临时文件复制、只从设备读取一次，被拒绝的文件标记跳过并记录原因，超时的文件标记失败
+func TestFileCopier_PreCopyCommand(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件\\"
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.PreCopyCommand = preCopyHelperCommand(t)
+	cfg.Backup.PreCopyTimeout = "2s"
+	if err := utils.SetTempDir(t.TempDir()); err != nil {
+		t.Fatalf("设置临时目录失败: %v", err)
+	}
+	t.Cleanup(func() { utils.SetTempDir("") })
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	modTime := time.Now().Add(-time.Hour)
+	var files []*utils.FileInfo
+	for _, name := range []string{"clean.opus", "infected.opus", "slow.opus"} {
+		content := []byte("content of " + name)
+		fake.AddFile(base+name, content, modTime)
+		files = append(files, &utils.FileInfo{Path: base + name, RelativePath: name, Name: name, Size: int64(len(content))})
+	}
+
+	copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+	copier.SetMTPInterface(fake)
+	if copier.preCopy == nil {
+		t.Fatal("配置了校验命令时应创建校验器")
+	}
+
+	results := make(map[string]*CopyResult)
+	for result := range copier.CopyFiles(context.Background(), files, true) {
+		results[result.File.Name] = result
+	}
+
+	clean := results["clean.opus"]
+	if clean == nil || !clean.Success {
+		t.Fatalf("通过校验的文件应被复制: %+v", clean)
+	}
+	if data, err := os.ReadFile(clean.TargetPath); err != nil || string(data) != "content of clean.opus" {
+		t.Errorf("通过校验的文件应写入目标: %q, %v", data, err)
+	}
+	if opens := fake.StreamOpens(base + "clean.opus"); opens != 1 {
+		t.Errorf("校验过的临时文件应直接作为复制内容，设备文件流被打开 %d 次", opens)
+	}
+
+	infected := results["infected.opus"]
+	if infected == nil || infected.Success || !infected.Skipped || infected.Error != nil {
+		t.Fatalf("被拒绝的文件应标记为跳过: %+v", infected)
+	}
+	if !strings.HasPrefix(infected.SkipReason, SkipReasonPreCopyRejected) ||
+		!strings.Contains(infected.SkipReason, "退出码 2") ||
+		!strings.Contains(infected.SkipReason, "Eicar-Test-Signature") {
+		t.Errorf("跳过原因应包含退出码和命令输出: %q", infected.SkipReason)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Target.BaseDirectory, "infected.opus")); !os.IsNotExist(err) {
+		t.Errorf("被拒绝的文件不应写入目标: %v", err)
+	}
+
+	slow := results["slow.opus"]
+	if slow == nil || slow.Success || slow.Skipped || !errors.Is(slow.Error, errPreCopyTimeout) {
+		t.Errorf("校验命令超时的文件应标记失败: %+v", slow)
+	}
+
+	// 临时导出的文件在校验后删除
+	entries, _ := os.ReadDir(utils.TempDir())
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "precopy_") {
+			t.Errorf("临时导出的文件未删除: %s", entry.Name())
+		}
+	}
+}
+
+// TestPreCopyCheck_Command 测试 {file} 占位符替换，命令中没有占位符时路径追加为最后一个参数
+func TestPreCopyCheck_Command(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{`scan.exe /quiet "{file}"`, []string{"scan.exe", "/quiet", `C:\temp\a b.opus`}},
+		{`scan.exe --path={file}`, []string{"scan.exe", `--path=C:\temp\a b.opus`}},
+		{`"C:\Program Files\AV\scan.exe" /quiet`, []string{`C:\Program Files\AV\scan.exe`, "/quiet", `C:\temp\a b.opus`}},
+	}
+
+	for _, tt := range tests {
+		check, err := NewPreCopyCheck(&config.BackupConfig{PreCopyCommand: tt.command})
+		if err != nil {
+			t.Fatalf("解析命令 %q 失败: %v", tt.command, err)
+		}
+		got := check.command(`C:\temp\a b.opus`)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("命令 %q 生成 %q，期望 %q", tt.command, got, tt.want)
+		}
+	}
+
+	if check, err := NewPreCopyCheck(&config.BackupConfig{}); check != nil || err != nil {
+		t.Errorf("未配置命令时应返回nil: %v, %v", check, err)
+	}
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// Prehasher 枚举阶段的哈希预计算
+// 设备以盘符挂载时可以直接读取设备上的文件，在复制前算出哈希，
+// 这样改名后的文件也能按内容判断是否已备份；纯MTP设备无法按路径读取，自动禁用
+type Prehasher struct {
+	maxSize     int64
+	concurrency int
+	hashFunc    func(path string) (string, error)
+	log         *logger.Logger
+}
+
+// NewPrehasher 创建哈希预计算器，只计算大小不超过 maxSize 的文件，最多 concurrency 个并发
+func NewPrehasher(maxSize int64, concurrency int, hashFunc func(path string) (string, error), log *logger.Logger) *Prehasher {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Prehasher{
+		maxSize:     maxSize,
+		concurrency: concurrency,
+		hashFunc:    hashFunc,
+		log:         log,
+	}
+}
+
+// Run 为文件填充 FileInfo.Hash，返回成功计算的数量；设备未以盘符挂载时返回0
+func (p *Prehasher) Run(files []*utils.FileInfo) int {
+	var pending []*utils.FileInfo
+	for _, file := range files {
+		if file.Hash != "" || file.Encrypted || file.Size > p.maxSize {
+			continue
+		}
+		pending = append(pending, file)
+	}
+	if len(pending) == 0 {
+		return 0
+	}
+
+	if !isDriveLetterPath(pending[0].Path) {
+		p.log.Info("设备未以盘符挂载，跳过哈希预计算")
+		return 0
+	}
+
+	p.log.Info("正在预计算 %d 个文件的哈希...", len(pending))
+
+	jobs := make(chan *utils.FileInfo)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	hashed := 0
+
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer p.log.RecoverPanic()
+			defer wg.Done()
+			for file := range jobs {
+				hash, err := p.hashFunc(file.Path)
+				if err != nil {
+					p.log.Debug("预计算哈希失败: %s, %v", file.RelativePath, err)
+					continue
+				}
+				file.Hash = hash
+
+				mu.Lock()
+				hashed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, file := range pending {
+		jobs <- file
+	}
+	close(jobs)
+	wg.Wait()
+
+	p.log.Info("哈希预计算完成: %d/%d", hashed, len(pending))
+	return hashed
+}
+
+// isDriveLetterPath 判断路径是否位于盘符下且可以直接读取
+func isDriveLetterPath(path string) bool {
+	volume := filepath.VolumeName(path)
+	if len(volume) != 2 || volume[1] != ':' {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
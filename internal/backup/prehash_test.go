@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestPrehasher_DriveLetter 测试盘符模式下为小文件预计算哈希
+func TestPrehasher_DriveLetter(t *testing.T) {
+	dir := t.TempDir()
+	var files []*utils.FileInfo
+	for _, name := range []string{"a.opus", "b.opus", "c.opus", "big.opus"} {
+		path := filepath.Join(dir, name)
+		content := []byte("content of " + name)
+		if name == "big.opus" {
+			content = make([]byte, 4096)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		files = append(files, &utils.FileInfo{Path: path, RelativePath: name, Name: name, Size: int64(len(content))})
+	}
+
+	prehasher := NewPrehasher(1024, 2, utils.CalculateFileHash, logger.NewLogger(false))
+	if hashed := prehasher.Run(files); hashed != 3 {
+		t.Errorf("期望预计算 3 个文件，实际 %d", hashed)
+	}
+
+	for _, file := range files {
+		if file.Name == "big.opus" {
+			if file.Hash != "" {
+				t.Error("超过大小上限的文件不应预计算哈希")
+			}
+			continue
+		}
+		want, _ := utils.CalculateFileHash(file.Path)
+		if file.Hash != want {
+			t.Errorf("%s 哈希不一致: 期望 %s，实际 %s", file.Name, want, file.Hash)
+		}
+	}
+}
+
+// TestPrehasher_MTPDisabled 测试纯MTP路径时自动禁用
+func TestPrehasher_MTPDisabled(t *testing.T) {
+	var calls int32
+	hashFunc := func(path string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "hash", nil
+	}
+
+	files := []*utils.FileInfo{
+		{Path: "内部共享存储空间\\录音笔文件\\a.opus", RelativePath: "a.opus", Name: "a.opus", Size: 1024},
+	}
+
+	prehasher := NewPrehasher(1024*1024, 2, hashFunc, logger.NewLogger(false))
+	if hashed := prehasher.Run(files); hashed != 0 {
+		t.Errorf("期望不预计算，实际 %d", hashed)
+	}
+	if calls != 0 || files[0].Hash != "" {
+		t.Error("纯MTP设备不应计算哈希")
+	}
+}
+
+// TestBackupManager_PrehashSkipsRenamed 测试盘符模式下改名的已备份文件按哈希跳过
+func TestBackupManager_PrehashSkipsRenamed(t *testing.T) {
+	bm, scanner := newTestManager(t)
+	bm.config.Backup.PrehashOnDevice = true
+	bm.config.Backup.StabilityWait = "0"
+
+	path := filepath.Join(t.TempDir(), "renamed.opus")
+	if err := os.WriteFile(path, []byte("recorded audio"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	hash, err := bm.calculateHash(path)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	if err := bm.tracker.AddRecord("original.opus", "backups/original.opus", "test_device", 14, hash); err != nil {
+		t.Fatalf("添加备份记录失败: %v", err)
+	}
+
+	scanner.files = []*utils.FileInfo{
+		{Path: path, RelativePath: "renamed.opus", Name: "renamed.opus", Size: 14, IsOpus: true},
+	}
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "test_device", Name: "SR302"}
+	if _, err := bm.Run(context.Background(), deviceInfo, false); err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+
+	if scanner.files[0].Hash != hash {
+		t.Errorf("期望枚举结果填充哈希 %s，实际 %s", hash, scanner.files[0].Hash)
+	}
+	run := bm.tracker.Overview("SR302").LastRun
+	if run == nil || run.Succeeded != 0 || run.Skipped != 1 {
+		t.Errorf("改名文件应按哈希跳过，实际运行概况 %+v", run)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/i18n"
 	"github.com/allanpk716/record_center/internal/storage"
 	"github.com/allanpk716/record_center/pkg/utils"
 )
@@ -21,10 +22,30 @@ type BackupPreview struct {
 	NeedBackup      int                `json:"need_backup"`
 	NeedBackupSize  int64              `json:"need_backup_size"`
 	NewFiles        []*utils.FileInfo  `json:"new_files"`
+	EncryptedFiles  []*utils.FileInfo  `json:"encrypted_files"`
+	SimilarGroups   []SimilarGroup     `json:"similar_groups,omitempty"` // 疑似重复的录音，供人工确认
 	LastBackupTime  time.Time          `json:"last_backup_time"`
+	Diff            *RunDiff           `json:"diff"` // 与上次备份快照的对比
 	Storage         *storage.BackupStorage `json:"storage"`
 }
 
+// showQuickStat 输出设备文件数和总大小的快速估算，估算失败时只记录日志
+func (bm *BackupManager) showQuickStat(fileChecker *FileChecker, dev *device.DeviceInfo) {
+	mtpInterface, release, err := fileChecker.acquireDevice(dev)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	count, size, err := mtpInterface.QuickStat()
+	if err != nil {
+		bm.log.Debug("快速估算设备文件失败: %v", err)
+		return
+	}
+	bm.log.Info("快速估算: 设备上约 %d 个文件，约 %s", count, utils.FormatBytes(size))
+	fmt.Printf("快速估算: 设备上约 %d 个文件，约 %s，开始完整枚举...\n", count, utils.FormatBytes(size))
+}
+
 // GeneratePreview 生成备份预览
 func (bm *BackupManager) GeneratePreview(deviceInfo *device.DeviceInfo, allFiles []*utils.FileInfo, filesToBackup []*utils.FileInfo) (*BackupPreview, error) {
 	// 获取备份记录
@@ -61,6 +82,14 @@ func (bm *BackupManager) GeneratePreview(deviceInfo *device.DeviceInfo, allFiles
 		needSize += file.Size
 	}
 
+	// 加密录音单独列出
+	var encryptedFiles []*utils.FileInfo
+	for _, file := range allFiles {
+		if file.Encrypted {
+			encryptedFiles = append(encryptedFiles, file)
+		}
+	}
+
 	preview := &BackupPreview{
 		DeviceInfo:      deviceInfo,
 		TotalFiles:      len(allFiles),
@@ -70,7 +99,10 @@ func (bm *BackupManager) GeneratePreview(deviceInfo *device.DeviceInfo, allFiles
 		NeedBackup:      len(filesToBackup),
 		NeedBackupSize:  needSize,
 		NewFiles:        filesToBackup,
+		EncryptedFiles:  encryptedFiles,
+		SimilarGroups:   bm.findSimilarRecordings(allFiles),
 		LastBackupTime:  backupStorage.LastBackup,
+		Diff:            NewRunDiff(backupStorage, deviceInfo, allFiles, filesToBackup),
 		Storage:         backupStorage,
 	}
 
@@ -82,6 +114,10 @@ func (bm *BackupManager) DisplayPreview(preview *BackupPreview, verbose bool) {
 	if bm.quiet {
 		// 静默模式只显示简要信息
 		bm.log.Info("总文件数: %d, 需要备份: %d", preview.TotalFiles, preview.NeedBackup)
+		if preview.Diff != nil {
+			bm.log.Info("与上次对比: %s", strings.Join(preview.Diff.Lines(), "; "))
+		}
+		bm.logSimilarRecordings(preview.SimilarGroups)
 		return
 	}
 
@@ -99,6 +135,10 @@ func (bm *BackupManager) DisplayPreview(preview *BackupPreview, verbose bool) {
 		preview.AlreadyBackedUp, utils.FormatBytes(preview.AlreadySize))
 	fmt.Printf("  新增备份: %d 个 (%s)\n",
 		preview.NeedBackup, utils.FormatBytes(preview.NeedBackupSize))
+	if len(preview.EncryptedFiles) > 0 {
+		fmt.Printf("  加密文件: %d 个 (%s, 将跳过)\n",
+			len(preview.EncryptedFiles), utils.FormatBytes(utils.CalculateTotalSize(preview.EncryptedFiles)))
+	}
 
 	// 备份历史
 	if !preview.LastBackupTime.IsZero() {
@@ -106,6 +146,15 @@ func (bm *BackupManager) DisplayPreview(preview *BackupPreview, verbose bool) {
 			preview.LastBackupTime.Format("2006-01-02 15:04:05"))
 	}
 
+	// 与上次备份快照对比，预测本次传输量
+	if preview.Diff != nil {
+		fmt.Println()
+		fmt.Println(color.YellowString("与上次对比:"))
+		for _, line := range preview.Diff.Lines() {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
 	// 详细模式
 	if verbose && len(preview.NewFiles) > 0 {
 		fmt.Println()
@@ -124,6 +173,28 @@ func (bm *BackupManager) DisplayPreview(preview *BackupPreview, verbose bool) {
 		}
 	}
 
+	// 加密文件列表
+	if verbose && len(preview.EncryptedFiles) > 0 {
+		fmt.Println()
+		fmt.Println(color.RedString("加密文件（跳过）:"))
+		for _, file := range preview.EncryptedFiles {
+			fmt.Printf("  %s\n", file.RelativePath)
+		}
+	}
+
+	// 疑似重复的录音始终列出，由用户确认
+	if len(preview.SimilarGroups) > 0 {
+		fmt.Println()
+		fmt.Println(color.YellowString("疑似重复的录音（时长和大小接近，请人工确认，不会自动删除）:"))
+		for i, group := range preview.SimilarGroups {
+			fmt.Printf("  第%d组:\n", i+1)
+			for _, file := range group.Files {
+				fmt.Printf("    %s (%s, %s)\n", file.File.RelativePath,
+					utils.FormatBytes(file.File.Size), formatSimilarDuration(file.Duration))
+			}
+		}
+	}
+
 	// 备份记录统计
 	if verbose && preview.Storage != nil {
 		fmt.Println()
@@ -138,9 +209,9 @@ func (bm *BackupManager) DisplayPreview(preview *BackupPreview, verbose bool) {
 // DisplayPreviewSummary 显示预览摘要
 func (bm *BackupManager) DisplayPreviewSummary(preview *BackupPreview) {
 	if preview.NeedBackup == 0 {
-		fmt.Println(color.GreenString("✅ 所有文件都已备份，无需操作"))
+		fmt.Println(color.GreenString(i18n.T("backup.all_backed_up")))
 	} else {
-		fmt.Printf(color.BlueString("准备备份 %d 个新文件 (%s)\n"),
-			preview.NeedBackup, utils.FormatBytes(preview.NeedBackupSize))
+		fmt.Println(color.BlueString(i18n.T("backup.ready",
+			preview.NeedBackup, utils.FormatBytes(preview.NeedBackupSize))))
 	}
 }
\ No newline at end of file
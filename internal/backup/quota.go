@@ -0,0 +1,285 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// QuotaPath 每日配额用量文件路径
+const QuotaPath = "data/quota.json"
+
+// quotaState 持久化的当日用量
+type quotaState struct {
+	Date  string `json:"date"`  // 用量所属日期（本地时间），如 2024-03-15
+	Bytes int64  `json:"bytes"` // 当日已复制的字节数
+}
+
+// QuotaTracker 跟踪当日已复制的字节数并持久化，跨运行累计，达到每日配额后新文件延后到次日
+// 为 nil 时表示不限制
+type QuotaTracker struct {
+	path  string
+	limit int64
+	log   *logger.Logger
+	mutex sync.Mutex
+	state quotaState
+
+	now   func() time.Time                                 // 当前时间，测试时替换
+	sleep func(ctx context.Context, d time.Duration) error // 等待到次日，测试时替换
+}
+
+// NewQuotaTracker 创建每日配额跟踪器并读取已有用量，quota 为空或为0时返回 nil（不限制）
+func NewQuotaTracker(path, quota string, log *logger.Logger) *QuotaTracker {
+	if quota == "" || quota == "0" {
+		return nil
+	}
+	limit, err := utils.ParseByteSize(quota)
+	if err != nil || limit <= 0 {
+		log.Warn("解析每日配额失败，不限制: %s", quota)
+		return nil
+	}
+
+	qt := &QuotaTracker{path: path, limit: limit, log: log, now: time.Now, sleep: sleepContext}
+	if err := qt.load(); err != nil {
+		log.Warn("读取每日配额用量失败，从0开始计算: %v", err)
+	}
+	return qt
+}
+
+// Used 返回当日已复制的字节数
+func (qt *QuotaTracker) Used() int64 {
+	if qt == nil {
+		return 0
+	}
+	qt.mutex.Lock()
+	defer qt.mutex.Unlock()
+	qt.rollover()
+	return qt.state.Bytes
+}
+
+// Add 累计已复制的字节数并立即保存，失败只记录警告
+func (qt *QuotaTracker) Add(bytes int64) {
+	if qt == nil || bytes <= 0 {
+		return
+	}
+	qt.mutex.Lock()
+	defer qt.mutex.Unlock()
+	qt.rollover()
+	qt.state.Bytes += bytes
+	if err := qt.save(); err != nil {
+		qt.log.Warn("保存每日配额用量失败: %v", err)
+	}
+}
+
+// Wait 当日配额已用完时等待到次日零点再返回，context 取消时返回错误
+// 只在开始复制新文件前检查，已开始的文件会复制完，因此当日用量可能略超配额
+func (qt *QuotaTracker) Wait(ctx context.Context) error {
+	if qt == nil {
+		return nil
+	}
+	for {
+		qt.mutex.Lock()
+		qt.rollover()
+		used := qt.state.Bytes
+		now := qt.now()
+		qt.mutex.Unlock()
+
+		if used < qt.limit {
+			return nil
+		}
+
+		wait := nextMidnight(now).Sub(now)
+		qt.log.Warn("今日已复制 %s，达到每日配额 %s，暂停 %s 到次日后继续",
+			utils.FormatBytes(used), utils.FormatBytes(qt.limit), utils.FormatDuration(wait))
+		if err := qt.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// rollover 日期变化时清零用量（调用方持有锁）
+func (qt *QuotaTracker) rollover() {
+	today := qt.now().Format("2006-01-02")
+	if qt.state.Date != today {
+		qt.state = quotaState{Date: today}
+	}
+}
+
+// load 读取用量文件，文件不存在时从0开始
+func (qt *QuotaTracker) load() error {
+	data, err := os.ReadFile(qt.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, &qt.state); err != nil {
+		return fmt.Errorf("解析配额用量失败: %w", err)
+	}
+	return nil
+}
+
+// save 先写临时文件再替换，避免中断时留下损坏的用量文件（调用方持有锁）
+func (qt *QuotaTracker) save() error {
+	if err := os.MkdirAll(filepath.Dir(qt.path), 0755); err != nil {
+		return fmt.Errorf("创建配额目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(qt.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化配额用量失败: %w", err)
+	}
+	tmp := qt.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入配额用量失败: %w", err)
+	}
+	return os.Rename(tmp, qt.path)
+}
+
+// nextMidnight 返回 t 之后的下一个本地零点
+func nextMidnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
+// sleepContext 等待指定时长，context 取消时提前返回错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateWindow 解析后的限速时段
+type rateWindow struct {
+	start, end int   // 开始、结束的当天分钟数，结束早于开始时跨越午夜
+	limit      int64 // 每秒字节数
+}
+
+// contains 判断当天的分钟数是否落在时段内
+func (w rateWindow) contains(minutes int) bool {
+	if w.start < w.end {
+		return minutes >= w.start && minutes < w.end
+	}
+	return minutes >= w.start || minutes < w.end
+}
+
+// RateLimiter 按当前时段限制复制速度，多个文件同时复制时共享同一个限额
+// 为 nil 时表示不限速
+type RateLimiter struct {
+	windows []rateWindow
+	mutex   sync.Mutex
+	next    time.Time // 已预约的传输量结束的时间
+
+	now   func() time.Time    // 当前时间，测试时替换
+	sleep func(time.Duration) // 限速等待，测试时替换
+}
+
+// NewRateLimiter 按时段配置创建限速器，没有有效时段时返回 nil（不限速）
+func NewRateLimiter(schedule []config.RateWindow, log *logger.Logger) *RateLimiter {
+	var windows []rateWindow
+	for _, item := range schedule {
+		start, end, err := config.ParseTimeRange(item.TimeRange)
+		if err != nil {
+			log.Warn("忽略无效的限速时段: %v", err)
+			continue
+		}
+		limit, err := utils.ParseByteSize(item.MaxBytesPerSecond)
+		if err != nil || limit <= 0 {
+			log.Warn("忽略无效的时段限速: %s %s", item.TimeRange, item.MaxBytesPerSecond)
+			continue
+		}
+		windows = append(windows, rateWindow{start: start, end: end, limit: limit})
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	return &RateLimiter{windows: windows, now: time.Now, sleep: time.Sleep}
+}
+
+// LimitAt 返回 t 时刻适用的每秒字节数，不在任何时段内时返回0；时段重叠时取第一个
+func (rl *RateLimiter) LimitAt(t time.Time) int64 {
+	if rl == nil {
+		return 0
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	for _, window := range rl.windows {
+		if window.contains(minutes) {
+			return window.limit
+		}
+	}
+	return 0
+}
+
+// Limited 当前是否处于限速时段
+func (rl *RateLimiter) Limited() bool {
+	return rl != nil && rl.LimitAt(rl.now()) > 0
+}
+
+// Wait 登记刚传输的 n 个字节，按当前时段的限速等待到这些字节允许传完的时间
+func (rl *RateLimiter) Wait(n int) {
+	if rl == nil || n <= 0 {
+		return
+	}
+
+	rl.mutex.Lock()
+	now := rl.now()
+	limit := rl.LimitAt(now)
+	if limit <= 0 {
+		rl.next = time.Time{}
+		rl.mutex.Unlock()
+		return
+	}
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	rl.next = rl.next.Add(time.Duration(float64(n) / float64(limit) * float64(time.Second)))
+	delay := rl.next.Sub(now)
+	rl.mutex.Unlock()
+
+	rl.sleep(delay)
+}
+
+// limitedReader 每次读取后按限速器等待
+type limitedReader struct {
+	io.ReadCloser
+	limiter *RateLimiter
+}
+
+// Read 读取数据并限速
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.ReadCloser.Read(p)
+	lr.limiter.Wait(n)
+	return n, err
+}
+
+// SetQuotaTracker 设置每日配额跟踪器，多设备备份时应共享同一个
+func (fc *FileCopier) SetQuotaTracker(quota *QuotaTracker) {
+	fc.quota = quota
+}
+
+// SetRateLimiter 设置时段限速器，多设备备份时应共享同一个
+func (fc *FileCopier) SetRateLimiter(limiter *RateLimiter) {
+	fc.limiter = limiter
+}
+
+// limitStream 为设备文件流加上时段限速
+func (fc *FileCopier) limitStream(stream io.ReadCloser) io.ReadCloser {
+	if fc.limiter == nil {
+		return stream
+	}
+	return &limitedReader{ReadCloser: stream, limiter: fc.limiter}
+}
@@ -0,0 +1,174 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestQuotaTracker_Persist 测试当日用量跨运行累计，日期变化后清零
+func TestQuotaTracker_Persist(t *testing.T) {
+	log := logger.NewLogger(false)
+	path := filepath.Join(t.TempDir(), "data", "quota.json")
+	today := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+
+	first := NewQuotaTracker(path, "1KB", log)
+	first.now = func() time.Time { return today }
+	first.Add(600)
+
+	// 下一次运行读取同一个文件，继续累计
+	second := NewQuotaTracker(path, "1KB", log)
+	second.now = func() time.Time { return today.Add(time.Hour) }
+	if used := second.Used(); used != 600 {
+		t.Fatalf("跨运行读取的用量 = %d, 期望 600", used)
+	}
+	second.Add(300)
+
+	third := NewQuotaTracker(path, "1KB", log)
+	third.now = func() time.Time { return today.Add(2 * time.Hour) }
+	if used := third.Used(); used != 900 {
+		t.Errorf("累计用量 = %d, 期望 900", used)
+	}
+
+	// 次日用量清零
+	third.now = func() time.Time { return today.Add(24 * time.Hour) }
+	if used := third.Used(); used != 0 {
+		t.Errorf("次日用量 = %d, 期望 0", used)
+	}
+
+	if NewQuotaTracker(path, "", log) != nil {
+		t.Error("未配置配额时应返回 nil")
+	}
+}
+
+// TestFileCopier_QuotaDefersNewFiles 测试达到每日配额后新文件延后到次日才复制
+func TestFileCopier_QuotaDefersNewFiles(t *testing.T) {
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Backup.MaxConcurrent = 1
+
+	var mutex sync.Mutex
+	now := time.Date(2024, 3, 15, 23, 0, 0, 0, time.Local)
+	var events []string
+
+	quota := NewQuotaTracker(filepath.Join(t.TempDir(), "quota.json"), "1KB", log)
+	quota.now = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return now
+	}
+	quota.sleep = func(ctx context.Context, d time.Duration) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, fmt.Sprintf("等待%s", d))
+		now = now.Add(d)
+		return nil
+	}
+	// 今天已用完配额
+	quota.Add(1024)
+
+	copier := NewFileCopier(cfg, log, NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	copier.SetQuotaTracker(quota)
+	copier.copyFunc = func(file *utils.FileInfo, force bool) *CopyResult {
+		mutex.Lock()
+		events = append(events, "复制")
+		mutex.Unlock()
+		return &CopyResult{File: file, Success: true, BytesCopied: file.Size}
+	}
+
+	files := []*utils.FileInfo{
+		{Path: "device\\a.opus", RelativePath: "a.opus", Name: "a.opus", Size: 300},
+		{Path: "device\\b.opus", RelativePath: "b.opus", Name: "b.opus", Size: 200},
+	}
+	for result := range copier.CopyFiles(context.Background(), files, false) {
+		if !result.Success {
+			t.Errorf("复制失败: %s, %v", result.File.Name, result.Error)
+		}
+	}
+
+	// 新文件等到次日零点后才复制
+	want := []string{"等待1h0m0s", "复制", "复制"}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("复制顺序 = %v, 期望 %v", events, want)
+	}
+	if used := quota.Used(); used != 500 {
+		t.Errorf("次日用量 = %d, 期望 500", used)
+	}
+}
+
+// TestFileCopier_QuotaCancel 测试等待配额期间取消备份
+func TestFileCopier_QuotaCancel(t *testing.T) {
+	log := logger.NewLogger(false)
+	quota := NewQuotaTracker(filepath.Join(t.TempDir(), "quota.json"), "1KB", log)
+	quota.Add(2048)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := quota.Wait(ctx); err != context.Canceled {
+		t.Errorf("取消后等待配额应返回 context.Canceled，实际 %v", err)
+	}
+}
+
+// TestRateLimiter_Schedule 测试按当前时段切换限速
+func TestRateLimiter_Schedule(t *testing.T) {
+	limiter := NewRateLimiter([]config.RateWindow{
+		{TimeRange: "08:00-18:00", MaxBytesPerSecond: "1MB"},
+		{TimeRange: "22:00-06:00", MaxBytesPerSecond: "100KB"},
+	}, logger.NewLogger(false))
+
+	day := func(clock string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", "2024-03-15 "+clock, time.Local)
+		return t
+	}
+
+	tests := []struct {
+		clock string
+		limit int64
+	}{
+		{"07:59", 0},
+		{"08:00", 1024 * 1024},
+		{"17:59", 1024 * 1024},
+		{"18:00", 0},
+		{"21:30", 0},
+		{"23:00", 100 * 1024},
+		{"05:59", 100 * 1024},
+		{"06:00", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clock, func(t *testing.T) {
+			if got := limiter.LimitAt(day(tt.clock)); got != tt.limit {
+				t.Errorf("LimitAt(%s) = %d, 期望 %d", tt.clock, got, tt.limit)
+			}
+		})
+	}
+
+	// 限速时段内按已传输量累计等待，离开时段后不再等待
+	now := day("09:00")
+	var slept []time.Duration
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	limiter.Wait(512 * 1024)
+	limiter.Wait(512 * 1024)
+	now = day("20:00")
+	limiter.Wait(512 * 1024)
+	now = day("23:00")
+	limiter.Wait(100 * 1024)
+
+	want := []time.Duration{500 * time.Millisecond, time.Second, time.Second}
+	if fmt.Sprint(slept) != fmt.Sprint(want) {
+		t.Errorf("限速等待 = %v, 期望 %v", slept, want)
+	}
+
+	if NewRateLimiter(nil, logger.NewLogger(false)) != nil {
+		t.Error("未配置时段时应返回 nil")
+	}
+}
@@ -0,0 +1,267 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// defaultRangeChunkSize 分片大小配置无效时使用的默认值
+const defaultRangeChunkSize = 8 * 1024 * 1024
+
+// rangeReaderFor 文件超过分片下载阈值且设备访问接口支持按偏移读取时返回该接口
+func (fc *FileCopier) rangeReaderFor(file *utils.FileInfo) (device.RangeReader, bool) {
+	rangeConfig := fc.config.Backup.RangeDownload
+	if !rangeConfig.Enabled || fc.deviceAccessor == nil {
+		return nil, false
+	}
+	threshold, err := utils.ParseByteSize(rangeConfig.Threshold)
+	if err != nil || file.Size <= threshold {
+		return nil, false
+	}
+
+	reader, ok := fc.deviceAccessor.(device.RangeReader)
+	if !ok {
+		fc.log.Debug("设备访问接口不支持按偏移读取，顺序复制: %s", file.RelativePath)
+	}
+	return reader, ok
+}
+
+// copyWithRanges 把文件切成分片，由多个 goroutine 并行读取并写入本地暂存文件的对应偏移，完成后通过目标存储存入目标
+// 暂存文件先预分配到文件大小；任一分片失败或单文件超时后停止读取剩余分片并返回错误。
+// 每个分片读取后记录哈希，全部写入后重新读取暂存文件逐片比对；开启断点续传时以断点临时文件暂存，
+// 从头开始连续完成的分片及其哈希保存在断点信息中，下次从第一个未完成的分片继续
+func (fc *FileCopier) copyWithRanges(file *utils.FileInfo, targetPath string, reader device.RangeReader) (int64, error) {
+	chunkSize, err := utils.ParseByteSize(fc.config.Backup.RangeDownload.ChunkSize)
+	if err != nil || chunkSize <= 0 {
+		chunkSize = defaultRangeChunkSize
+	}
+	chunks := int((file.Size + chunkSize - 1) / chunkSize)
+
+	var resumeInfo *ResumeInfo
+	var writePath string
+	if fc.config.Backup.EnableResume && fc.resumeManager != nil {
+		fc.resumeManager.MarkActive(file.Path)
+		defer fc.resumeManager.MarkInactive(file.Path)
+		resumeInfo = fc.rangeResumeInfo(file, chunkSize)
+		writePath = resumeInfo.TempPath
+	} else {
+		writePath = fc.stagingFile(file)
+		defer os.Remove(writePath)
+	}
+	progress := &rangeProgress{fc: fc, file: file, resume: resumeInfo, chunkSize: chunkSize, checksums: make([]string, chunks)}
+	if resumeInfo != nil {
+		progress.prefix = copy(progress.checksums, resumeInfo.Checksums)
+		progress.copied = min(int64(progress.prefix)*chunkSize, file.Size)
+	}
+
+	workers := fc.config.Backup.RangeDownload.Workers
+	if remaining := chunks - progress.prefix; workers > remaining {
+		workers = remaining
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(writePath), 0755); err != nil {
+		return 0, fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+	flags := os.O_CREATE | os.O_RDWR
+	if progress.prefix == 0 {
+		flags |= os.O_TRUNC
+	}
+	targetFile, err := os.OpenFile(writePath, flags, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("创建暂存文件失败: %w", err)
+	}
+	defer targetFile.Close()
+	if err := targetFile.Truncate(file.Size); err != nil {
+		return 0, fmt.Errorf("预分配暂存文件失败: %w", err)
+	}
+
+	if progress.prefix > 0 {
+		fc.log.Info("发现分片下载断点，从第 %d/%d 个分片继续: %s", progress.prefix+1, chunks, file.RelativePath)
+	}
+	fc.log.Debug("分片并行下载: %s (%s, %d 个分片, %d 个并发)",
+		file.RelativePath, utils.FormatBytes(file.Size), chunks, workers)
+	fc.reportProgress(file, progress.copied)
+
+	ctx := fc.fileContext(file)
+	indexes := make(chan int)
+	var failed atomic.Bool
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		failed.Store(true)
+	}
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer fc.log.RecoverPanic()
+			defer wg.Done()
+			for index := range indexes {
+				if failed.Load() {
+					continue
+				}
+				if cause := context.Cause(ctx); cause != nil {
+					fail(cause)
+					continue
+				}
+				off := int64(index) * chunkSize
+				length := min(chunkSize, file.Size-off)
+				checksum, err := fc.copyRange(file, targetFile, reader, off, length)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				progress.done(index, length, checksum)
+			}
+		}()
+	}
+
+	for index := progress.prefix; index < chunks; index++ {
+		if failed.Load() {
+			break
+		}
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return progress.copied, firstErr
+	}
+	if err := targetFile.Sync(); err != nil {
+		return progress.copied, fmt.Errorf("同步暂存文件失败: %w", err)
+	}
+	if err := verifyRanges(targetFile, file.Size, chunkSize, progress.checksums); err != nil {
+		// 断点临时文件的内容已不可信，下次从头下载
+		if resumeInfo != nil {
+			if clearErr := fc.resumeManager.ClearResumeInfo(file.Path); clearErr != nil {
+				fc.log.Warn("清理断点信息失败: %v", clearErr)
+			}
+		}
+		return progress.copied, err
+	}
+	targetFile.Close()
+	if resumeInfo == nil {
+		if err := fc.importTarget(targetPath, writePath); err != nil {
+			return progress.copied, fmt.Errorf("完成文件复制失败: %w", err)
+		}
+		return progress.copied, nil
+	}
+
+	if err := fc.finalizeResumeFile(resumeInfo, targetPath); err != nil {
+		return progress.copied, fmt.Errorf("完成文件复制失败: %w", err)
+	}
+	if err := fc.resumeManager.ClearResumeInfo(file.Path); err != nil {
+		fc.log.Warn("清理断点信息失败: %v", err)
+	}
+	return progress.copied, nil
+}
+
+// rangeResumeInfo 返回分片下载使用的断点信息：上次同样按 chunkSize 分片下载留下的断点继续使用，
+// 顺序复制或分片大小不同时留下的断点没有分片哈希，从头下载
+func (fc *FileCopier) rangeResumeInfo(file *utils.FileInfo, chunkSize int64) *ResumeInfo {
+	info, err := fc.resumeManager.GetResumeInfo(file.Path)
+	if err == nil && info.ChunkSize == chunkSize && info.TotalBytes == file.Size && len(info.Checksums) > 0 &&
+		info.CopiedBytes == min(int64(len(info.Checksums))*chunkSize, file.Size) && utils.FileExists(info.TempPath) {
+		return info
+	}
+
+	info = &ResumeInfo{
+		FilePath:   file.Path,
+		TempPath:   fc.resumeManager.GetTempPath(file.Path),
+		TotalBytes: file.Size,
+		ChunkSize:  chunkSize,
+		Metadata:   map[string]string{ResumeMetaRelativePath: file.RelativePath},
+	}
+	if fc.device != nil {
+		info.Metadata[ResumeMetaDeviceID] = fc.device.DeviceID
+	}
+	return info
+}
+
+// rangeProgress 分片下载的进度：上报已写入的字节数，并把从头连续完成的分片保存为断点
+type rangeProgress struct {
+	fc        *FileCopier
+	file      *utils.FileInfo
+	resume    *ResumeInfo // 未开启断点续传时为 nil
+	chunkSize int64
+	mutex     sync.Mutex
+	checksums []string // 各分片的哈希，未完成的为空
+	prefix    int      // 从头连续完成的分片数
+	copied    int64    // 已写入的字节数，含断点前的部分
+}
+
+// done 记录一个完成的分片，上报进度；连续完成的分片增加时保存断点
+func (p *rangeProgress) done(index int, length int64, checksum string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.checksums[index] = checksum
+	p.copied += length
+	p.fc.reportProgress(p.file, p.copied)
+
+	prefix := p.prefix
+	for prefix < len(p.checksums) && p.checksums[prefix] != "" {
+		prefix++
+	}
+	if prefix == p.prefix {
+		return
+	}
+	p.prefix = prefix
+	if p.resume == nil {
+		return
+	}
+	p.resume.Checksums = append([]string(nil), p.checksums[:prefix]...)
+	p.resume.CopiedBytes = min(int64(prefix)*p.chunkSize, p.file.Size)
+	if err := p.fc.resumeManager.SaveResumeInfo(p.resume); err != nil {
+		p.fc.log.Warn("保存断点信息失败: %v", err)
+	}
+}
+
+// copyRange 读取一个分片并写入暂存文件的对应偏移，返回分片内容的哈希，读到的数据不足时返回错误
+func (fc *FileCopier) copyRange(file *utils.FileInfo, targetFile *os.File, reader device.RangeReader, off, length int64) (string, error) {
+	data, err := reader.ReadRange(file.Path, off, length)
+	if err != nil {
+		return "", fmt.Errorf("读取分片失败 (偏移 %d): %w", off, err)
+	}
+	if int64(len(data)) != length {
+		return "", fmt.Errorf("分片读取不完整 (偏移 %d): 期望 %d 字节，实际 %d 字节", off, length, len(data))
+	}
+	fc.limiter.Wait(len(data))
+	if _, err := targetFile.WriteAt(data, off); err != nil {
+		return "", fmt.Errorf("写入分片失败 (偏移 %d): %w", off, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// verifyRanges 重新读取暂存文件，逐个分片与读取时记录的哈希比对
+func verifyRanges(targetFile *os.File, size, chunkSize int64, checksums []string) error {
+	buffer := make([]byte, chunkSize)
+	for index, want := range checksums {
+		off := int64(index) * chunkSize
+		data := buffer[:min(chunkSize, size-off)]
+		if _, err := targetFile.ReadAt(data, off); err != nil {
+			return fmt.Errorf("读取暂存文件失败 (偏移 %d): %w", off, err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != want {
+			return fmt.Errorf("第 %d 个分片校验失败 (偏移 %d)", index+1, off)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_RangeDownload 测试大文件分片并行复制的结果与顺序复制一致，不支持按偏移读取时回退到顺序复制
+func TestFileCopier_RangeDownload(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\large.opus"
+
+	// 大小不是分片大小的整数倍，最后一个分片不满
+	content := make([]byte, 10*1024+123)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+
+	tests := []struct {
+		name       string
+		size       int
+		hideRange  bool // 包装访问接口，隐藏 ReadRange
+		wantRanges int  // 期望的按偏移读取次数
+	}{
+		{"分片并行复制", len(content), false, 11},
+		{"不超过阈值时顺序复制", 1024, false, 0},
+		{"不支持按偏移读取时回退到顺序复制", len(content), true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+			cfg.Backup.EnableResume = false
+			cfg.Backup.RangeDownload = config.RangeDownloadConfig{
+				Enabled:   true,
+				Threshold: "1KB",
+				Workers:   4,
+				ChunkSize: "1KB",
+			}
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			data := content[:tt.size]
+			fake.AddFile(devicePath, data, time.Now().Add(-time.Hour))
+
+			copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), deviceInfo)
+			if tt.hideRange {
+				copier.SetMTPInterface(struct{ device.MTPInterface }{fake})
+			} else {
+				copier.SetMTPInterface(fake)
+			}
+
+			file := &utils.FileInfo{Path: devicePath, RelativePath: "large.opus", Name: "large.opus", Size: int64(len(data))}
+			result := copier.CopyFile(file, true)
+			if !result.Success || result.Error != nil {
+				t.Fatalf("复制失败: %v", result.Error)
+			}
+			if result.BytesCopied != int64(len(data)) {
+				t.Errorf("复制字节数 = %d, 期望 %d", result.BytesCopied, len(data))
+			}
+
+			copied, err := os.ReadFile(result.TargetPath)
+			if err != nil {
+				t.Fatalf("读取目标文件失败: %v", err)
+			}
+			if !bytes.Equal(copied, data) {
+				t.Error("复制的内容与设备文件不一致")
+			}
+			if got := fake.RangeReads(devicePath); got != tt.wantRanges {
+				t.Errorf("按偏移读取 %d 次, 期望 %d 次", got, tt.wantRanges)
+			}
+			if tt.wantRanges == 0 && fake.StreamOpens(devicePath) == 0 {
+				t.Error("顺序复制应打开文件流")
+			}
+		})
+	}
+}
+
+// TestFileCopier_RangeDownloadShortRead 测试分片读取不完整时复制失败
+func TestFileCopier_RangeDownloadShortRead(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\large.opus"
+
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload = config.RangeDownloadConfig{Enabled: true, Threshold: "1KB", Workers: 2, ChunkSize: "1KB"}
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddFile(devicePath, bytes.Repeat([]byte("r"), 4096), time.Now().Add(-time.Hour))
+
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), deviceInfo)
+	copier.SetMTPInterface(fake)
+
+	// 枚举大小比实际内容大，最后一个分片读不满
+	file := &utils.FileInfo{Path: devicePath, RelativePath: "large.opus", Name: "large.opus", Size: 5000}
+	if result := copier.CopyFile(file, true); result.Success || result.Error == nil {
+		t.Error("分片读取不完整时应复制失败")
+	}
+}
+
+// failingRanges 第一次读取 failAt 偏移的分片时失败，模拟中途断开的设备
+type failingRanges struct {
+	*device.FakeMTPAccessor
+	failAt int64
+	failed bool
+}
+
+func (f *failingRanges) ReadRange(filePath string, off, length int64) ([]byte, error) {
+	if off == f.failAt && !f.failed {
+		f.failed = true
+		return nil, device.NewRetryableMTPError(device.ERROR_DEVICE_BUSY, "设备无响应", nil)
+	}
+	return f.FakeMTPAccessor.ReadRange(filePath, off, length)
+}
+
+// TestFileCopier_RangeDownloadResume 测试分片下载逐片上报进度，中断后从第一个未完成的分片继续
+func TestFileCopier_RangeDownloadResume(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\large.opus"
+
+	content := make([]byte, 6*1024+100)
+	for i := range content {
+		content[i] = byte(i * 13)
+	}
+
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = true
+	cfg.Backup.RangeDownload = config.RangeDownloadConfig{Enabled: true, Threshold: "1KB", Workers: 1, ChunkSize: "1KB"}
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddFile(devicePath, content, time.Now().Add(-time.Hour))
+	accessor := &failingRanges{FakeMTPAccessor: fake, failAt: 4 * 1024}
+
+	copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+	resumeDir := t.TempDir()
+	copier.resumeManager = NewResumeManager(filepath.Join(resumeDir, "resume"), filepath.Join(resumeDir, "temp"), log)
+	copier.SetMTPInterface(accessor)
+	var reported []int64
+	copier.SetProgressFunc(func(file *utils.FileInfo, copied int64) {
+		reported = append(reported, copied)
+	})
+
+	file := &utils.FileInfo{Path: devicePath, RelativePath: "large.opus", Name: "large.opus", Size: int64(len(content))}
+	if result := copier.CopyFile(file, true); result.Success {
+		t.Fatal("分片读取失败时应复制失败")
+	}
+	info, err := copier.resumeManager.GetResumeInfo(devicePath)
+	if err != nil || info.CopiedBytes != 4*1024 || len(info.Checksums) != 4 {
+		t.Fatalf("应保存前 4 个分片的断点: %+v, %v", info, err)
+	}
+	if len(reported) == 0 || reported[len(reported)-1] != 4*1024 {
+		t.Errorf("应逐片上报进度: %v", reported)
+	}
+
+	reads := fake.RangeReads(devicePath)
+	reported = nil
+	result := copier.CopyFile(file, true)
+	if !result.Success {
+		t.Fatalf("续传失败: %v", result.Error)
+	}
+	if got := fake.RangeReads(devicePath) - reads; got != 3 {
+		t.Errorf("续传读取了 %d 个分片，期望只读取未完成的 3 个", got)
+	}
+	if len(reported) == 0 || reported[0] != 4*1024 || reported[len(reported)-1] != int64(len(content)) {
+		t.Errorf("续传进度应从断点开始并到达文件大小: %v", reported)
+	}
+	copied, err := os.ReadFile(result.TargetPath)
+	if err != nil || !bytes.Equal(copied, content) {
+		t.Errorf("续传后的内容与设备文件不一致: %v", err)
+	}
+	if _, err := copier.resumeManager.GetResumeInfo(devicePath); err == nil {
+		t.Error("复制完成后应清理断点信息")
+	}
+}
+
+// TestVerifyRanges 测试分片写入后目标文件内容与读取时的哈希不一致时校验失败
+func TestVerifyRanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.opus")
+	content := bytes.Repeat([]byte("abc"), 1000)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var checksums []string
+	for off := 0; off < len(content); off += 1024 {
+		sum := sha256.Sum256(content[off:min(off+1024, len(content))])
+		checksums = append(checksums, hex.EncodeToString(sum[:]))
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := verifyRanges(file, int64(len(content)), 1024, checksums); err != nil {
+		t.Errorf("内容一致时校验应通过: %v", err)
+	}
+	if _, err := file.WriteAt([]byte("x"), 2500); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyRanges(file, int64(len(content)), 1024, checksums); err == nil {
+		t.Error("第 3 个分片被改动时校验应失败")
+	}
+}
@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/device"
+)
+
+// ResumeFileList 找出设备上次未完成的文件：有断点信息的文件和上次运行失败的文件，
+// 返回相对于 source.base_path 的路径，可直接交给 SetFileList，不需要重新枚举整个设备
+// 断点对应的文件已备份成功时跳过；rm 为nil时只看上次失败的文件
+func (bm *BackupManager) ResumeFileList(dev *device.DeviceInfo, rm *ResumeManager) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		path = normalizeListPath(path)
+		if path == "" || seen[strings.ToLower(path)] {
+			return
+		}
+		seen[strings.ToLower(path)] = true
+		paths = append(paths, path)
+	}
+
+	if rm != nil {
+		infos, err := rm.ListResumeInfos()
+		if err != nil {
+			return nil, err
+		}
+		// 断点文件的顺序由哈希文件名决定，按设备路径排序使结果稳定
+		sort.Slice(infos, func(i, j int) bool { return infos[i].FilePath < infos[j].FilePath })
+
+		resumed := 0
+		for _, info := range infos {
+			if info.Metadata[ResumeMetaDeviceID] != dev.DeviceID {
+				continue
+			}
+			relativePath := info.Metadata[ResumeMetaRelativePath]
+			if relativePath == "" {
+				bm.log.Debug("断点信息缺少相对路径，跳过: %s", info.FilePath)
+				continue
+			}
+			if backedUp, _, _ := bm.tracker.IsFileBackedUp(info.FilePath); backedUp {
+				bm.log.Debug("断点对应的文件已备份，跳过: %s", relativePath)
+				continue
+			}
+			add(relativePath)
+			resumed++
+		}
+		bm.log.Info("上次未完成（有断点）的文件: %d 个", resumed)
+	}
+
+	failed := 0
+	if lastRun := bm.tracker.Overview(dev.Name).LastRun; lastRun != nil && lastRun.DeviceID == dev.DeviceID {
+		for _, path := range lastRun.FailedFiles {
+			add(path)
+			failed++
+		}
+	}
+	bm.log.Info("上次失败的文件: %d 个", failed)
+
+	return paths, nil
+}
@@ -10,6 +10,16 @@ import (
 	"time"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// ResumePath 断点信息文件所在目录
+var ResumePath = filepath.Join("data", "resume")
+
+// 断点信息 Metadata 中的键，resume 子命令据此找出属于设备的未完成文件
+const (
+	ResumeMetaRelativePath = "relative_path" // 相对于 source.base_path 的路径
+	ResumeMetaDeviceID     = "device_id"
 )
 
 // ResumeInfo 断点续传信息
@@ -25,12 +35,18 @@ type ResumeInfo struct {
 }
 
 // ResumeManager 断点续传管理器
+// 多个复制 goroutine 可能同时访问断点信息：同一文件的读写由按文件路径的锁串行化，
+// 缓存中保存的是副本，调用方拿到的断点信息可以自由修改而不影响其他 goroutine
 type ResumeManager struct {
 	storagePath string
 	tempDir     string
 	log         *logger.Logger
-	mu          sync.RWMutex
+	mu          sync.RWMutex           // 保护 cache 与 active
 	cache       map[string]*ResumeInfo // 内存缓存
+	active      map[string]int         // 正在复制的文件，CleanupExpired 不会清理其断点
+	fileLocks   sync.Map               // 文件路径 -> *sync.Mutex
+	clock       utils.Clock            // 断点的更新时间和过期判断取自该时钟
+	random      utils.Random           // 生成临时文件名的随机源
 }
 
 // NewResumeManager 创建断点续传管理器
@@ -40,6 +56,9 @@ func NewResumeManager(storagePath, tempDir string, log *logger.Logger) *ResumeMa
 		tempDir:     tempDir,
 		log:         log,
 		cache:       make(map[string]*ResumeInfo),
+		active:      make(map[string]int),
+		clock:       utils.SystemClock,
+		random:      utils.SystemRandom,
 	}
 
 	// 确保目录存在
@@ -53,92 +72,64 @@ func NewResumeManager(storagePath, tempDir string, log *logger.Logger) *ResumeMa
 	return rm
 }
 
+// SetClock 替换时钟和随机源，测试时注入假时钟和固定种子的随机源
+func (rm *ResumeManager) SetClock(clock utils.Clock, random utils.Random) {
+	rm.clock = clock
+	rm.random = random
+}
+
 // SaveResumeInfo 保存断点信息
 func (rm *ResumeManager) SaveResumeInfo(info *ResumeInfo) error {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-
-	// 更新时间戳
-	info.LastUpdated = time.Now()
+	lock := rm.fileLock(info.FilePath)
+	lock.Lock()
+	defer lock.Unlock()
 
-	// 保存到内存缓存
-	rm.cache[info.FilePath] = info
-
-	// 保存到文件
-	return rm.saveToFile(info)
+	return rm.saveResumeInfo(info)
 }
 
-// GetResumeInfo 获取断点信息
+// GetResumeInfo 获取断点信息，返回的是副本
 func (rm *ResumeManager) GetResumeInfo(filePath string) (*ResumeInfo, error) {
-	rm.mu.RLock()
-
-	// 先从内存缓存查找
-	if info, exists := rm.cache[filePath]; exists {
-		rm.mu.RUnlock()
-		return info, nil
-	}
-	rm.mu.RUnlock()
+	lock := rm.fileLock(filePath)
+	lock.Lock()
+	defer lock.Unlock()
 
-	// 从文件加载
-	info, err := rm.loadFromFile(filePath)
+	info, err := rm.getResumeInfo(filePath)
 	if err != nil {
 		return nil, err
 	}
-
-	// 更新内存缓存
-	rm.mu.Lock()
-	rm.cache[filePath] = info
-	rm.mu.Unlock()
-
-	return info, nil
+	return info.clone(), nil
 }
 
 // UpdateProgress 更新复制进度
 func (rm *ResumeManager) UpdateProgress(filePath string, copiedBytes int64) error {
-	info, err := rm.GetResumeInfo(filePath)
+	lock := rm.fileLock(filePath)
+	lock.Lock()
+	defer lock.Unlock()
+
+	info, err := rm.getResumeInfo(filePath)
 	if err != nil {
 		// 如果不存在，创建新的
 		info = &ResumeInfo{
-			FilePath:    filePath,
-			TempPath:    rm.getTempPath(filePath),
-			CopiedBytes: copiedBytes,
-			ChunkSize:   5 * 1024 * 1024, // 默认5MB块
-			Metadata:    make(map[string]string),
+			FilePath:  filePath,
+			TempPath:  rm.getTempPath(filePath),
+			ChunkSize: 5 * 1024 * 1024, // 默认5MB块
+			Metadata:  make(map[string]string),
 		}
+	} else {
+		info = info.clone()
 	}
 
 	info.CopiedBytes = copiedBytes
-	info.LastUpdated = time.Now()
-
-	return rm.SaveResumeInfo(info)
+	return rm.saveResumeInfo(info)
 }
 
 // ClearResumeInfo 清除断点信息
 func (rm *ResumeManager) ClearResumeInfo(filePath string) error {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-
-	// 从内存缓存删除
-	delete(rm.cache, filePath)
-
-	// 删除临时文件
-	tempPath := rm.getTempPath(filePath)
-	if _, err := os.Stat(tempPath); err == nil {
-		if removeErr := os.Remove(tempPath); removeErr != nil {
-			rm.log.Warn("删除临时文件失败: %s, %v", tempPath, removeErr)
-		}
-	}
+	lock := rm.fileLock(filePath)
+	lock.Lock()
+	defer lock.Unlock()
 
-	// 删除断点信息文件
-	resumeFilePath := rm.getResumeFilePath(filePath)
-	if _, err := os.Stat(resumeFilePath); err == nil {
-		if removeErr := os.Remove(resumeFilePath); removeErr != nil {
-			rm.log.Warn("删除断点信息文件失败: %s, %v", resumeFilePath, removeErr)
-			return removeErr
-		}
-	}
-
-	return nil
+	return rm.clearResumeInfo(filePath)
 }
 
 // GetTempPath 获取临时文件路径
@@ -146,17 +137,51 @@ func (rm *ResumeManager) GetTempPath(filePath string) string {
 	return rm.getTempPath(filePath)
 }
 
-// CleanupExpired 清理过期的断点信息
-func (rm *ResumeManager) CleanupExpired(maxAge time.Duration) error {
+// MarkActive 标记文件正在复制，复制结束后需调用 MarkInactive
+func (rm *ResumeManager) MarkActive(filePath string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.active[filePath]++
+}
+
+// MarkInactive 取消文件的复制中标记
+func (rm *ResumeManager) MarkInactive(filePath string) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
+	if rm.active[filePath] <= 1 {
+		delete(rm.active, filePath)
+	} else {
+		rm.active[filePath]--
+	}
+}
+
+// ListResumeInfos 列出所有已保存的断点信息，无法解析的断点文件跳过
+func (rm *ResumeManager) ListResumeInfos() ([]*ResumeInfo, error) {
+	files, err := filepath.Glob(filepath.Join(rm.storagePath, "*.resume"))
+	if err != nil {
+		return nil, fmt.Errorf("扫描断点信息文件失败: %w", err)
+	}
+
+	infos := make([]*ResumeInfo, 0, len(files))
+	for _, file := range files {
+		info, err := rm.loadResumeFile(file)
+		if err != nil {
+			rm.log.Warn("加载断点信息失败: %s, %v", file, err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
 
+// CleanupExpired 清理过期的断点信息，正在复制或正被访问的断点不会清理
+func (rm *ResumeManager) CleanupExpired(maxAge time.Duration) error {
 	files, err := filepath.Glob(filepath.Join(rm.storagePath, "*.resume"))
 	if err != nil {
 		return fmt.Errorf("扫描断点信息文件失败: %w", err)
 	}
 
-	cutoff := time.Now().Add(-maxAge)
+	cutoff := rm.clock.Now().Add(-maxAge)
 	cleanedCount := 0
 
 	for _, file := range files {
@@ -165,18 +190,12 @@ func (rm *ResumeManager) CleanupExpired(maxAge time.Duration) error {
 			rm.log.Warn("加载断点信息失败: %s, %v", file, err)
 			continue
 		}
+		if !info.LastUpdated.Before(cutoff) || rm.isActive(info.FilePath) {
+			continue
+		}
 
-		if info.LastUpdated.Before(cutoff) {
-			// 删除过期的断点信息
-			if err := os.Remove(file); err != nil {
-				rm.log.Warn("删除过期断点信息失败: %s, %v", file, err)
-			} else {
-				// 同时删除临时文件
-				if _, err := os.Stat(info.TempPath); err == nil {
-					os.Remove(info.TempPath)
-				}
-				cleanedCount++
-			}
+		if rm.cleanupIfExpired(info.FilePath, file, cutoff) {
+			cleanedCount++
 		}
 	}
 
@@ -187,13 +206,126 @@ func (rm *ResumeManager) CleanupExpired(maxAge time.Duration) error {
 	return nil
 }
 
+// cleanupIfExpired 持有文件锁后再次确认断点已过期且不活跃，然后删除
+func (rm *ResumeManager) cleanupIfExpired(filePath, resumeFile string, cutoff time.Time) bool {
+	lock := rm.fileLock(filePath)
+	if !lock.TryLock() {
+		// 正在被读写，说明仍在使用
+		return false
+	}
+	defer lock.Unlock()
+
+	// 等待锁期间可能已被更新或清除
+	info, err := rm.loadResumeFile(resumeFile)
+	if err != nil || !info.LastUpdated.Before(cutoff) || rm.isActive(filePath) {
+		return false
+	}
+
+	if err := rm.clearResumeInfo(filePath); err != nil {
+		rm.log.Warn("删除过期断点信息失败: %s, %v", resumeFile, err)
+		return false
+	}
+	return true
+}
+
 // 私有方法
 
+// fileLock 获取文件路径对应的锁
+func (rm *ResumeManager) fileLock(filePath string) *sync.Mutex {
+	lock, _ := rm.fileLocks.LoadOrStore(filePath, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// isActive 判断文件是否正在复制
+func (rm *ResumeManager) isActive(filePath string) bool {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return rm.active[filePath] > 0
+}
+
+// getResumeInfo 从缓存或文件获取断点信息，调用方需持有文件锁，返回值不得修改
+func (rm *ResumeManager) getResumeInfo(filePath string) (*ResumeInfo, error) {
+	rm.mu.RLock()
+	info, exists := rm.cache[filePath]
+	rm.mu.RUnlock()
+	if exists {
+		return info, nil
+	}
+
+	info, err := rm.loadFromFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rm.mu.Lock()
+	rm.cache[filePath] = info
+	rm.mu.Unlock()
+	return info, nil
+}
+
+// saveResumeInfo 保存断点信息的副本到缓存和文件，调用方需持有文件锁
+func (rm *ResumeManager) saveResumeInfo(info *ResumeInfo) error {
+	info.LastUpdated = rm.clock.Now()
+	saved := info.clone()
+
+	rm.mu.Lock()
+	rm.cache[saved.FilePath] = saved
+	rm.mu.Unlock()
+
+	return rm.saveToFile(saved)
+}
+
+// clearResumeInfo 删除缓存、临时文件和断点信息文件，调用方需持有文件锁
+func (rm *ResumeManager) clearResumeInfo(filePath string) error {
+	tempPath := ""
+	if info, err := rm.getResumeInfo(filePath); err == nil {
+		tempPath = info.TempPath
+	}
+
+	// 从内存缓存删除
+	rm.mu.Lock()
+	delete(rm.cache, filePath)
+	rm.mu.Unlock()
+
+	// 删除临时文件
+	if tempPath != "" {
+		if _, err := os.Stat(tempPath); err == nil {
+			if removeErr := os.Remove(tempPath); removeErr != nil {
+				rm.log.Warn("删除临时文件失败: %s, %v", tempPath, removeErr)
+			}
+		}
+	}
+
+	// 删除断点信息文件
+	resumeFilePath := rm.getResumeFilePath(filePath)
+	if _, err := os.Stat(resumeFilePath); err == nil {
+		if removeErr := os.Remove(resumeFilePath); removeErr != nil {
+			rm.log.Warn("删除断点信息文件失败: %s, %v", resumeFilePath, removeErr)
+			return removeErr
+		}
+	}
+
+	return nil
+}
+
+// clone 复制断点信息，切片和映射一并复制
+func (info *ResumeInfo) clone() *ResumeInfo {
+	copied := *info
+	copied.Checksums = append([]string(nil), info.Checksums...)
+	if info.Metadata != nil {
+		copied.Metadata = make(map[string]string, len(info.Metadata))
+		for k, v := range info.Metadata {
+			copied.Metadata[k] = v
+		}
+	}
+	return &copied
+}
+
 // getTempPath 获取临时文件路径
 func (rm *ResumeManager) getTempPath(filePath string) string {
-	// 使用文件路径的哈希作为临时文件名，避免路径过长
-	hash := fmt.Sprintf("%x", time.Now().UnixNano())
-	return filepath.Join(rm.tempDir, fmt.Sprintf("tmp_%s_%s", filepath.Base(filePath), hash))
+	// 时间戳加随机后缀，同一时刻为同名文件生成的临时文件也不会冲突
+	suffix := fmt.Sprintf("%x%08x", rm.clock.Now().UnixNano(), uint32(rm.random.Uint64()))
+	return filepath.Join(rm.tempDir, fmt.Sprintf("tmp_%s_%s", filepath.Base(filePath), suffix))
 }
 
 // getResumeFilePath 获取断点信息文件路径
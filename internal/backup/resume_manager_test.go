@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// newTestResumeManager 创建使用临时目录的断点续传管理器
+func newTestResumeManager(t *testing.T) *ResumeManager {
+	dir := t.TempDir()
+	return NewResumeManager(filepath.Join(dir, "resume"), filepath.Join(dir, "temp"), logger.NewLogger(false))
+}
+
+// TestResumeManager_ConcurrentSave 测试多个goroutine并发保存不同和相同文件的断点
+func TestResumeManager_ConcurrentSave(t *testing.T) {
+	rm := newTestResumeManager(t)
+
+	const workers = 8
+	const rounds = 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			own := fmt.Sprintf("device\\file_%d.opus", w)
+			for i := 1; i <= rounds; i++ {
+				// 各自的文件
+				info := &ResumeInfo{FilePath: own, CopiedBytes: int64(i), TotalBytes: rounds, Metadata: map[string]string{"worker": fmt.Sprint(w)}}
+				if err := rm.SaveResumeInfo(info); err != nil {
+					t.Errorf("保存断点失败: %v", err)
+					return
+				}
+
+				// 所有goroutine共享的文件：读取-修改-保存
+				if err := rm.UpdateProgress("device\\shared.opus", int64(i)); err != nil {
+					t.Errorf("更新共享断点失败: %v", err)
+					return
+				}
+				if shared, err := rm.GetResumeInfo("device\\shared.opus"); err == nil {
+					shared.Metadata["reader"] = fmt.Sprint(w)
+					shared.Checksums = append(shared.Checksums, "x")
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// 重新从文件加载，确认断点文件完整且数据一致
+	reloaded := NewResumeManager(rm.storagePath, rm.tempDir, logger.NewLogger(false))
+	for w := 0; w < workers; w++ {
+		info, err := reloaded.GetResumeInfo(fmt.Sprintf("device\\file_%d.opus", w))
+		if err != nil {
+			t.Fatalf("加载断点失败: %v", err)
+		}
+		if info.CopiedBytes != rounds || info.Metadata["worker"] != fmt.Sprint(w) {
+			t.Errorf("断点数据不一致: %+v", info)
+		}
+	}
+
+	shared, err := reloaded.GetResumeInfo("device\\shared.opus")
+	if err != nil {
+		t.Fatalf("加载共享断点失败: %v", err)
+	}
+	if shared.CopiedBytes < 1 || shared.CopiedBytes > rounds {
+		t.Errorf("共享断点进度异常: %d", shared.CopiedBytes)
+	}
+	if len(shared.Checksums) != 0 || shared.Metadata["reader"] != "" {
+		t.Error("修改读取到的断点副本不应影响已保存的断点")
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(rm.storagePath, "*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("不应残留临时文件: %v", leftovers)
+	}
+}
+
+// TestResumeManager_CleanupSkipsActive 测试过期清理不删除正在复制的断点
+func TestResumeManager_CleanupSkipsActive(t *testing.T) {
+	rm := newTestResumeManager(t)
+
+	for _, path := range []string{"device\\active.opus", "device\\idle.opus"} {
+		if err := rm.SaveResumeInfo(&ResumeInfo{FilePath: path, CopiedBytes: 10}); err != nil {
+			t.Fatalf("保存断点失败: %v", err)
+		}
+	}
+
+	rm.MarkActive("device\\active.opus")
+	// maxAge 为负数时所有断点都视为过期
+	if err := rm.CleanupExpired(-time.Hour); err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+
+	if _, err := os.Stat(rm.getResumeFilePath("device\\active.opus")); err != nil {
+		t.Error("正在复制的断点不应被清理")
+	}
+	if _, err := os.Stat(rm.getResumeFilePath("device\\idle.opus")); !os.IsNotExist(err) {
+		t.Error("不活跃的过期断点应被清理")
+	}
+	if _, err := rm.GetResumeInfo("device\\idle.opus"); err == nil {
+		t.Error("清理后缓存中不应再有断点")
+	}
+
+	rm.MarkInactive("device\\active.opus")
+	if err := rm.CleanupExpired(-time.Hour); err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if _, err := os.Stat(rm.getResumeFilePath("device\\active.opus")); !os.IsNotExist(err) {
+		t.Error("复制结束后的过期断点应被清理")
+	}
+}
+
+// TestResumeManager_TempPathUnique 测试时钟不变时同一文件的临时文件名也不冲突
+func TestResumeManager_TempPathUnique(t *testing.T) {
+	rm := newTestResumeManager(t)
+	rm.SetClock(utils.NewFakeClock(time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)), utils.NewSeededRandom(1))
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		path := rm.GetTempPath("device\\a.opus")
+		if seen[path] {
+			t.Fatalf("第 %d 个临时文件名重复: %s", i, path)
+		}
+		seen[path] = true
+	}
+}
+
+// TestResumeManager_CleanupExpiredClock 测试按注入的时钟判断断点是否过期
+func TestResumeManager_CleanupExpiredClock(t *testing.T) {
+	rm := newTestResumeManager(t)
+	clock := utils.NewFakeClock(time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local))
+	rm.SetClock(clock, utils.NewSeededRandom(1))
+
+	if err := rm.SaveResumeInfo(&ResumeInfo{FilePath: "device\\old.opus", CopiedBytes: 10}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	if err := rm.SaveResumeInfo(&ResumeInfo{FilePath: "device\\new.opus", CopiedBytes: 10}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Minute)
+
+	if err := rm.CleanupExpired(time.Hour); err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if _, err := os.Stat(rm.getResumeFilePath("device\\old.opus")); !os.IsNotExist(err) {
+		t.Error("超过 1 小时未更新的断点应被清理")
+	}
+	if _, err := os.Stat(rm.getResumeFilePath("device\\new.opus")); err != nil {
+		t.Error("30 分钟前更新的断点不应被清理")
+	}
+}
+
+// TestFileCopier_ResumeSaveEvery 测试未达到 resume_interval 字节时，断点也按 ResumeSaveEvery 定时保存
+func TestFileCopier_ResumeSaveEvery(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\slow.opus"
+	const chunks = 6
+	content := bytes.Repeat([]byte("r"), chunks*DefaultBufferSize)
+
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+	cfg.Backup.ResumeInterval = "100MB"
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddFile(devicePath, content, time.Now().Add(-time.Hour))
+
+	copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+	copier.SetMTPInterface(fake)
+	cfg.Backup.EnableResume = true
+	resumeDir := t.TempDir()
+	copier.resumeManager = NewResumeManager(filepath.Join(resumeDir, "resume"), filepath.Join(resumeDir, "temp"), log)
+
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	clock := utils.NewFakeClock(start)
+	copier.SetClock(clock, utils.NewSeededRandom(1))
+
+	// 每写入一块时钟前进 4 秒，记录写入该块前已保存的断点
+	var saved []int64
+	var savedAt []time.Time
+	copier.SetProgressFunc(func(f *utils.FileInfo, copied int64) {
+		if copied == 0 {
+			return
+		}
+		if info, err := copier.resumeManager.GetResumeInfo(devicePath); err == nil {
+			saved = append(saved, info.CopiedBytes)
+			savedAt = append(savedAt, info.LastUpdated)
+		} else {
+			saved = append(saved, 0)
+			savedAt = append(savedAt, time.Time{})
+		}
+		clock.Advance(4 * time.Second)
+	})
+
+	file := &utils.FileInfo{Path: devicePath, RelativePath: "slow.opus", Name: "slow.opus", Size: int64(len(content))}
+	result := copier.CopyFile(file, true)
+	if !result.Success {
+		t.Fatalf("复制失败: %v", result.Error)
+	}
+
+	// 第3块写入后经过 12 秒，超过 ResumeSaveEvery，在第4~6块写入时可以看到该断点
+	chunk := int64(DefaultBufferSize)
+	want := []int64{0, 0, 0, 3 * chunk, 3 * chunk, 3 * chunk}
+	if len(saved) != chunks {
+		t.Fatalf("进度上报 %d 次，期望 %d 次", len(saved), chunks)
+	}
+	for i := range want {
+		if saved[i] != want[i] {
+			t.Errorf("第 %d 块写入时已保存的断点 = %d，期望 %d（全部: %v）", i+1, saved[i], want[i], saved)
+		}
+	}
+	if !savedAt[3].Equal(start.Add(12 * time.Second)) {
+		t.Errorf("断点保存时间 = %v，期望 %v", savedAt[3], start.Add(12*time.Second))
+	}
+	if result.Duration != 24*time.Second {
+		t.Errorf("复制耗时 = %v，期望按假时钟计算为 24s", result.Duration)
+	}
+}
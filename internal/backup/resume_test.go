@@ -0,0 +1,113 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// TestBackupManager_ResumeFileList 测试上次有 2 个断点、1 个失败、3 个成功时，续跑只处理那 3 个未完成的文件
+func TestBackupManager_ResumeFileList(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.MaxConcurrent = 1
+	cfg.Backup.EnableResume = false
+	cfg.Backup.StabilityWait = ""
+	cfg.Backup.StabilityWindow = ""
+
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+	modTime := time.Now().Add(-time.Hour)
+	var paths []string
+	for i := 0; i < 6; i++ {
+		path := fmt.Sprintf("内部共享存储空间\\录音笔文件\\file%d.opus", i)
+		paths = append(paths, path)
+		fake.AddFile(path, bytes.Repeat([]byte{byte('a' + i)}, 1024), modTime)
+	}
+
+	// file0-2 上次已成功，file3 上次失败，file4、file5 留有断点
+	for i := 0; i < 3; i++ {
+		tracker.AddRecord(paths[i], fmt.Sprintf("file%d.opus", i), deviceInfo.DeviceID, 1024, "")
+	}
+	tracker.SetLastRun(storage.RunSummary{
+		DeviceName:  deviceInfo.Name,
+		DeviceID:    deviceInfo.DeviceID,
+		Scanned:     6,
+		Succeeded:   3,
+		Failed:      1,
+		FailedFiles: []string{"file3.opus"},
+	})
+	rm := NewResumeManager(filepath.Join(t.TempDir(), "resume"), t.TempDir(), log)
+	for i := 4; i < 6; i++ {
+		rm.SaveResumeInfo(&ResumeInfo{
+			FilePath:    paths[i],
+			CopiedBytes: 512,
+			TotalBytes:  1024,
+			Metadata: map[string]string{
+				ResumeMetaRelativePath: fmt.Sprintf("file%d.opus", i),
+				ResumeMetaDeviceID:     deviceInfo.DeviceID,
+			},
+		})
+	}
+	// 其他设备的断点不应被处理
+	rm.SaveResumeInfo(&ResumeInfo{
+		FilePath:   "内部共享存储空间\\录音笔文件\\other.opus",
+		TotalBytes: 1024,
+		Metadata: map[string]string{
+			ResumeMetaRelativePath: "other.opus",
+			ResumeMetaDeviceID:     "other_device",
+		},
+	})
+
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: tracker,
+		quiet:   true,
+	}
+	bm.SetMTPInterface(fake)
+
+	fileList, err := bm.ResumeFileList(deviceInfo, rm)
+	if err != nil {
+		t.Fatalf("获取续跑文件失败: %v", err)
+	}
+	if want := []string{"file4.opus", "file5.opus", "file3.opus"}; !reflect.DeepEqual(fileList, want) {
+		t.Fatalf("续跑文件 = %v，期望 %v", fileList, want)
+	}
+
+	bm.SetFileList(fileList)
+	summary, err := bm.Run(context.Background(), deviceInfo, false)
+	if err != nil {
+		t.Fatalf("续跑失败: %v", err)
+	}
+	if summary.Scanned != 3 || summary.Succeeded != 3 || summary.Failed != 0 {
+		t.Errorf("运行概况 = 处理 %d 成功 %d 失败 %d，期望 处理3 成功3 失败0", summary.Scanned, summary.Succeeded, summary.Failed)
+	}
+	for i, path := range paths {
+		opens := fake.StreamOpens(path)
+		if i < 3 && opens != 0 {
+			t.Errorf("已成功的 file%d 不应再读取，实际打开 %d 次", i, opens)
+		}
+		if i >= 3 && opens == 0 {
+			t.Errorf("未完成的 file%d 应被复制", i)
+		}
+	}
+
+	// 续跑全部成功后不再有上次失败的文件
+	if failed := tracker.Overview(deviceInfo.Name).LastRun.FailedFiles; len(failed) != 0 {
+		t.Errorf("续跑后上次失败的文件 = %v，期望为空", failed)
+	}
+}
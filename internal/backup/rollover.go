@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// LatestLinkName 按周期滚动时 base_directory 下指向当前周期目录的链接名称
+const LatestLinkName = "latest"
+
+// RolloverDirectory 按 target.rollover 返回 t 所在周期的备份目录，weekly 使用 ISO 周（如 2024-W18），
+// monthly 使用年月（如 2024-05）；none 或空时返回 base 本身
+func RolloverDirectory(base, rollover string, t time.Time) string {
+	switch rollover {
+	case "weekly":
+		year, week := t.ISOWeek()
+		return filepath.Join(base, fmt.Sprintf("%d-W%02d", year, week))
+	case "monthly":
+		return filepath.Join(base, t.Format("2006-01"))
+	default:
+		return base
+	}
+}
+
+// rolloverTarget 开启按周期滚动时，把本次备份的目标目录切换到 now 所在的周期目录，并更新 latest 链接指向它
+// 返回恢复原目标目录的函数；未开启时不做修改
+func (bm *BackupManager) rolloverTarget(now time.Time) func() {
+	base := bm.config.Target.BaseDirectory
+	dir := RolloverDirectory(base, bm.config.Target.Rollover, now)
+	if dir == base {
+		return func() {}
+	}
+
+	if err := utils.EnsureDir(dir); err != nil {
+		bm.log.Warn("创建周期目录失败，本次备份写入 %s: %v", base, err)
+		return func() {}
+	}
+	if err := utils.UpdateDirLink(filepath.Join(base, LatestLinkName), filepath.Base(dir)); err != nil {
+		bm.log.Warn("更新 %s 链接失败: %v", LatestLinkName, err)
+	}
+	bm.log.Info("本周期的备份目录: %s", dir)
+
+	original := bm.config
+	rolled := *original
+	rolled.Target.BaseDirectory = dir
+	bm.config = &rolled
+	return func() {
+		bm.config = original
+	}
+}
@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestRolloverDirectory 测试各滚动周期的目录名
+func TestRolloverDirectory(t *testing.T) {
+	base := filepath.Join("D:", "backups")
+	day := time.Date(2024, 12, 30, 10, 0, 0, 0, time.Local) // ISO 周属于 2025 年第 1 周
+
+	tests := []struct {
+		name     string
+		rollover string
+		want     string
+	}{
+		{"不滚动", "none", base},
+		{"空值不滚动", "", base},
+		{"按周使用ISO周", "weekly", filepath.Join(base, "2025-W01")},
+		{"按月", "monthly", filepath.Join(base, "2024-12")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RolloverDirectory(base, tt.rollover, day); got != tt.want {
+				t.Errorf("RolloverDirectory() = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBackupManager_RolloverTarget 测试跨周期时创建新的周期目录，latest 链接改为指向最新周期
+func TestBackupManager_RolloverTarget(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "backups")
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = base
+	cfg.Target.Rollover = "weekly"
+	bm := &BackupManager{config: cfg, log: logger.NewLogger(false), quiet: true}
+
+	link := filepath.Join(base, LatestLinkName)
+	weeks := []struct {
+		now  time.Time
+		name string
+	}{
+		{time.Date(2024, 4, 30, 9, 0, 0, 0, time.Local), "2024-W18"},
+		{time.Date(2024, 5, 6, 9, 0, 0, 0, time.Local), "2024-W19"},
+	}
+	for _, week := range weeks {
+		restore := bm.rolloverTarget(week.now)
+		dir := filepath.Join(base, week.name)
+		if bm.config.Target.BaseDirectory != dir {
+			t.Fatalf("本次备份的目标目录 = %s，期望 %s", bm.config.Target.BaseDirectory, dir)
+		}
+		if err := os.WriteFile(filepath.Join(dir, week.name+".opus"), []byte(week.name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		restore()
+
+		if bm.config.Target.BaseDirectory != base || cfg.Target.BaseDirectory != base {
+			t.Fatalf("备份结束后目标目录应恢复为 %s，实际 %s", base, bm.config.Target.BaseDirectory)
+		}
+		info, err := os.Lstat(link)
+		if err != nil || !utils.IsLink(info) {
+			t.Fatalf("%s 应为链接: %v", LatestLinkName, err)
+		}
+		if data, err := os.ReadFile(filepath.Join(link, week.name+".opus")); err != nil || string(data) != week.name {
+			t.Errorf("%s 应指向 %s: %v", LatestLinkName, week.name, err)
+		}
+	}
+
+	// 上一周期的目录保留
+	if _, err := os.Stat(filepath.Join(base, "2024-W18", "2024-W18.opus")); err != nil {
+		t.Errorf("上一周期的备份应保留: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// RunDiff 本次枚举结果与上次备份快照的对比，在复制前预测本次的传输量
+// 上次快照指备份记录中该设备已备份成功的文件
+type RunDiff struct {
+	LastFiles int                 `json:"last_files"`         // 上次快照中的文件数
+	LastSize  int64               `json:"last_size"`          // 上次快照中的文件总大小
+	NewFiles  int                 `json:"new_files"`          // 预计新增复制的文件数
+	NewBytes  int64               `json:"new_bytes"`          // 预计新增复制的字节数
+	SkipFiles int                 `json:"skip_files"`         // 预计跳过的文件数（已备份、加密、被过滤等）
+	LastRun   *storage.RunSummary `json:"last_run,omitempty"` // 上次运行的概况，没有时为nil
+}
+
+// NewRunDiff 对比枚举到的全部文件、本次需要复制的文件与备份记录中该设备的快照
+func NewRunDiff(backupStorage *storage.BackupStorage, deviceInfo *device.DeviceInfo, allFiles, filesToBackup []*utils.FileInfo) *RunDiff {
+	diff := &RunDiff{
+		NewFiles: len(filesToBackup),
+		NewBytes: utils.CalculateTotalSize(filesToBackup),
+	}
+	diff.SkipFiles = len(allFiles) - diff.NewFiles
+	if diff.SkipFiles < 0 {
+		diff.SkipFiles = 0
+	}
+	if backupStorage == nil {
+		return diff
+	}
+
+	for _, record := range backupStorage.Records {
+		if record.Success && record.DeviceID == deviceInfo.DeviceID {
+			diff.LastFiles++
+			diff.LastSize += record.FileSize
+		}
+	}
+	diff.LastRun = backupStorage.LastRuns[deviceInfo.Name]
+	return diff
+}
+
+// FilePercent 预计新增文件数相对上次快照的百分比，上次没有备份时返回 false
+func (d *RunDiff) FilePercent() (float64, bool) {
+	if d.LastFiles == 0 {
+		return 0, false
+	}
+	return float64(d.NewFiles) / float64(d.LastFiles) * 100, true
+}
+
+// BytePercent 预计新增字节数相对上次快照的百分比，上次快照大小为0时返回 false
+func (d *RunDiff) BytePercent() (float64, bool) {
+	if d.LastSize == 0 {
+		return 0, false
+	}
+	return float64(d.NewBytes) / float64(d.LastSize) * 100, true
+}
+
+// Lines 返回对比结果的展示文本
+func (d *RunDiff) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("上次快照: %d 个 (%s)", d.LastFiles, utils.FormatBytes(d.LastSize)),
+		fmt.Sprintf("预计新增: %d 个 (%s)，预计跳过: %d 个", d.NewFiles, utils.FormatBytes(d.NewBytes), d.SkipFiles),
+	}
+	filePercent, ok := d.FilePercent()
+	if !ok {
+		lines = append(lines, "相对上次: 首次备份")
+	} else if bytePercent, ok := d.BytePercent(); ok {
+		lines = append(lines, fmt.Sprintf("相对上次: 文件数 +%.1f%%，大小 +%.1f%%", filePercent, bytePercent))
+	} else {
+		lines = append(lines, fmt.Sprintf("相对上次: 文件数 +%.1f%%", filePercent))
+	}
+	if d.LastRun != nil {
+		lines = append(lines, fmt.Sprintf("上次运行: %s 复制了 %d 个 (%s)",
+			d.LastRun.StartTime.Format("2006-01-02 15:04:05"), d.LastRun.Succeeded, utils.FormatBytes(d.LastRun.Bytes)))
+	}
+	return lines
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestBackupManager_PreviewRunDiff 测试上次备份 5 个、本次枚举到 7 个（2 个新录音）时，预览预测新增 2 个并正确计算字节和增量百分比
+func TestBackupManager_PreviewRunDiff(t *testing.T) {
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+	bm := &BackupManager{config: config.DefaultConfig(), log: log, tracker: tracker, quiet: true}
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+
+	// 每个文件 1000 字节，两个新录音分别为 300 和 700 字节
+	var allFiles []*utils.FileInfo
+	for i := 0; i < 7; i++ {
+		size := int64(1000)
+		switch i {
+		case 5:
+			size = 300
+		case 6:
+			size = 700
+		}
+		name := fmt.Sprintf("rec%d.opus", i)
+		allFiles = append(allFiles, &utils.FileInfo{
+			Path:         "内部共享存储空间\\录音笔文件\\" + name,
+			RelativePath: name,
+			Name:         name,
+			Size:         size,
+		})
+	}
+	for _, file := range allFiles[:5] {
+		if err := tracker.AddRecord(file.Path, filepath.Join("backups", file.Name), deviceInfo.DeviceID, file.Size, ""); err != nil {
+			t.Fatalf("添加备份记录失败: %v", err)
+		}
+	}
+	tracker.SetLastRun(storage.RunSummary{DeviceName: deviceInfo.Name, DeviceID: deviceInfo.DeviceID,
+		StartTime: time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local), Scanned: 5, Succeeded: 5, Bytes: 5000})
+
+	filesToBackup, err := tracker.GetNewFiles(allFiles, deviceInfo.DeviceID)
+	if err != nil {
+		t.Fatalf("过滤需要备份的文件失败: %v", err)
+	}
+	preview, err := bm.GeneratePreview(deviceInfo, allFiles, filesToBackup)
+	if err != nil {
+		t.Fatalf("生成预览失败: %v", err)
+	}
+
+	diff := preview.Diff
+	if diff == nil {
+		t.Fatal("预览应包含与上次的对比")
+	}
+	if diff.LastFiles != 5 || diff.LastSize != 5000 {
+		t.Errorf("上次快照 = %d 个 %d 字节，期望 5 个 5000 字节", diff.LastFiles, diff.LastSize)
+	}
+	if diff.NewFiles != 2 || diff.NewBytes != 1000 {
+		t.Errorf("预计新增 = %d 个 %d 字节，期望 2 个 1000 字节", diff.NewFiles, diff.NewBytes)
+	}
+	if diff.SkipFiles != 5 {
+		t.Errorf("预计跳过 = %d，期望 5", diff.SkipFiles)
+	}
+	if percent, ok := diff.FilePercent(); !ok || math.Abs(percent-40) > 1e-9 {
+		t.Errorf("文件数增量 = %v%% (%v)，期望 40%%", percent, ok)
+	}
+	if percent, ok := diff.BytePercent(); !ok || math.Abs(percent-20) > 1e-9 {
+		t.Errorf("大小增量 = %v%% (%v)，期望 20%%", percent, ok)
+	}
+	if diff.LastRun == nil || diff.LastRun.Succeeded != 5 {
+		t.Errorf("应带上上次运行概况: %+v", diff.LastRun)
+	}
+}
+
+// TestRunDiff_FirstBackup 测试没有备份记录时不计算增量百分比
+func TestRunDiff_FirstBackup(t *testing.T) {
+	files := []*utils.FileInfo{{Path: "a.opus", Size: 10}, {Path: "b.opus", Size: 20}}
+	diff := NewRunDiff(&storage.BackupStorage{}, &device.DeviceInfo{DeviceID: "fake"}, files, files)
+	if diff.NewFiles != 2 || diff.NewBytes != 30 || diff.SkipFiles != 0 {
+		t.Errorf("对比结果 = %+v，期望新增 2 个 30 字节", diff)
+	}
+	if _, ok := diff.FilePercent(); ok {
+		t.Error("首次备份不应有文件数增量百分比")
+	}
+	if lines := diff.Lines(); lines[len(lines)-1] != "相对上次: 首次备份" {
+		t.Errorf("首次备份的展示 = %v", lines)
+	}
+}
@@ -0,0 +1,246 @@
+package backup
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// ProgressObserver 接收一次备份运行的复制进度，设备调度器用它汇总各设备的整体进度
+type ProgressObserver interface {
+	// Start 开始复制，files 和 bytes 为本次需要复制的文件数和总字节数
+	Start(files int, bytes int64)
+	// Update 本次运行累计已复制的字节数
+	Update(copied int64)
+	// CompleteFile 一个文件复制成功
+	CompleteFile()
+}
+
+// ProgressTotals 文件数和字节数进度
+type ProgressTotals struct {
+	TotalFiles     int
+	CompletedFiles int
+	TotalBytes     int64
+	CopiedBytes    int64
+}
+
+// AggregateProgress 跨设备汇总的整体进度，每个设备通过 Device 获取自己的观察者
+type AggregateProgress struct {
+	mu      sync.Mutex
+	devices map[string]*ProgressTotals
+}
+
+// NewAggregateProgress 创建整体进度
+func NewAggregateProgress() *AggregateProgress {
+	return &AggregateProgress{devices: make(map[string]*ProgressTotals)}
+}
+
+// Device 获取名为 name 的设备的进度观察者，同名重复获取时累加到同一设备
+func (p *AggregateProgress) Device(name string) ProgressObserver {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.devices[name] == nil {
+		p.devices[name] = &ProgressTotals{}
+	}
+	return &deviceProgress{parent: p, name: name}
+}
+
+// Totals 所有设备的进度之和
+func (p *AggregateProgress) Totals() ProgressTotals {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var totals ProgressTotals
+	for _, device := range p.devices {
+		totals.TotalFiles += device.TotalFiles
+		totals.CompletedFiles += device.CompletedFiles
+		totals.TotalBytes += device.TotalBytes
+		totals.CopiedBytes += device.CopiedBytes
+	}
+	return totals
+}
+
+// deviceProgress 单个设备的进度观察者，Update 上报的是当次运行的累计值，按增量计入设备进度
+type deviceProgress struct {
+	parent *AggregateProgress
+	name   string
+	copied int64 // 当次运行已计入的字节数
+}
+
+func (d *deviceProgress) Start(files int, bytes int64) {
+	d.parent.mu.Lock()
+	defer d.parent.mu.Unlock()
+	totals := d.parent.devices[d.name]
+	totals.TotalFiles += files
+	totals.TotalBytes += bytes
+	d.copied = 0
+}
+
+func (d *deviceProgress) Update(copied int64) {
+	d.parent.mu.Lock()
+	defer d.parent.mu.Unlock()
+	if copied <= d.copied {
+		return
+	}
+	d.parent.devices[d.name].CopiedBytes += copied - d.copied
+	d.copied = copied
+}
+
+func (d *deviceProgress) CompleteFile() {
+	d.parent.mu.Lock()
+	defer d.parent.mu.Unlock()
+	d.parent.devices[d.name].CompletedFiles++
+}
+
+// DeviceJob 调度的一次备份：用 Manager 备份 Device
+type DeviceJob struct {
+	Name    string // 任务名称，用于日志和进度汇总
+	Device  *device.DeviceInfo
+	Manager *BackupManager
+}
+
+// DeviceJobResult 一次备份的结果
+type DeviceJobResult struct {
+	Summary *storage.RunSummary
+	Err     error
+}
+
+// DeviceScheduler 多设备混合调度器：不同设备并行备份（各自占用不同的USB连接），
+// 同一设备上的任务依次执行，单设备内的文件串行复制，避免同一设备上的会话冲突
+type DeviceScheduler struct {
+	concurrency      int              // 同时备份的设备数，0表示不限制
+	globalSem        *SharedSemaphore // 各设备共享的全局并发资源池
+	quota            *QuotaTracker    // 各设备共享的每日配额，未配置时为nil
+	limiter          *RateLimiter     // 各设备共享的时段限速器，未配置时为nil
+	progress         *AggregateProgress
+	progressInterval time.Duration // 输出整体进度日志的间隔
+	log              *logger.Logger
+}
+
+// NewDeviceScheduler 按 backup.device_concurrency 和 backup.global_max_concurrent 创建设备调度器
+// backup.daily_quota 和 backup.schedule 是整台机器的限制，各设备共享同一个配额跟踪器和限速器
+func NewDeviceScheduler(cfg *config.Config, log *logger.Logger) *DeviceScheduler {
+	return &DeviceScheduler{
+		concurrency:      cfg.Backup.DeviceConcurrency,
+		globalSem:        NewSharedSemaphore(cfg.Backup.GlobalMaxConcurrent),
+		quota:            NewQuotaTracker(QuotaPath, cfg.Backup.DailyQuota, log),
+		limiter:          NewRateLimiter(cfg.Backup.Schedule, log),
+		progress:         NewAggregateProgress(),
+		progressInterval: 5 * time.Second,
+		log:              log,
+	}
+}
+
+// Progress 跨设备汇总的整体进度
+func (s *DeviceScheduler) Progress() *AggregateProgress {
+	return s.progress
+}
+
+// Run 执行所有任务，返回与 jobs 顺序一致的结果
+// ctx 取消后尚未开始的任务不再执行，结果中的错误为 ctx 的错误
+func (s *DeviceScheduler) Run(ctx context.Context, jobs []DeviceJob, force bool) []DeviceJobResult {
+	results := make([]DeviceJobResult, len(jobs))
+
+	// 按设备分组，同一设备的任务在同一个 goroutine 中依次执行
+	var keys []string
+	groups := make(map[string][]int)
+	for i, job := range jobs {
+		key := job.Device.DeviceID
+		if key == "" {
+			key = job.Device.Name
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	s.log.Info("调度 %d 个任务到 %d 个设备，同时备份的设备数: %s", len(jobs), len(keys), s.concurrencyText())
+
+	stopProgress := s.logProgress()
+	defer stopProgress()
+
+	deviceSem := NewSharedSemaphore(s.concurrency)
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer s.log.RecoverPanic()
+			defer wg.Done()
+			if err := deviceSem.Acquire(ctx); err != nil {
+				for _, i := range indexes {
+					results[i].Err = err
+				}
+				return
+			}
+			defer deviceSem.Release()
+
+			for _, i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i] = s.runJob(ctx, jobs[i], force)
+			}
+		}(groups[key])
+	}
+	wg.Wait()
+
+	totals := s.progress.Totals()
+	s.log.Info("所有设备备份结束: 复制 %d/%d 个文件，%s/%s", totals.CompletedFiles, totals.TotalFiles,
+		utils.FormatBytes(totals.CopiedBytes), utils.FormatBytes(totals.TotalBytes))
+	return results
+}
+
+// runJob 以串行复制和共享的全局并发资源池、每日配额、限速器执行一次备份
+func (s *DeviceScheduler) runJob(ctx context.Context, job DeviceJob, force bool) DeviceJobResult {
+	job.Manager.SetSerialCopy(true)
+	job.Manager.SetGlobalSemaphore(s.globalSem)
+	job.Manager.SetQuotaTracker(s.quota)
+	job.Manager.SetRateLimiter(s.limiter)
+	job.Manager.SetProgressObserver(s.progress.Device(job.Name))
+
+	summary, err := job.Manager.Run(ctx, job.Device, force)
+	return DeviceJobResult{Summary: summary, Err: err}
+}
+
+// logProgress 定期输出整体进度，返回停止函数
+func (s *DeviceScheduler) logProgress() func() {
+	if s.progressInterval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.progressInterval)
+		defer ticker.Stop()
+		var last ProgressTotals
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				totals := s.progress.Totals()
+				if totals == last || totals.TotalFiles == 0 {
+					continue
+				}
+				last = totals
+				s.log.Info("整体进度: %d/%d 个文件，%s/%s", totals.CompletedFiles, totals.TotalFiles,
+					utils.FormatBytes(totals.CopiedBytes), utils.FormatBytes(totals.TotalBytes))
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// concurrencyText 同时备份的设备数的描述
+func (s *DeviceScheduler) concurrencyText() string {
+	if s.concurrency <= 0 {
+		return "不限制"
+	}
+	return strconv.Itoa(s.concurrency)
+}
@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// streamCounter 统计同时打开的文件流数
+type streamCounter struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (c *streamCounter) acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active++
+	if c.active > c.maxActive {
+		c.maxActive = c.active
+	}
+}
+
+func (c *streamCounter) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+}
+
+func (c *streamCounter) max() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxActive
+}
+
+// slowMTP 打开文件流后等待 delay 模拟慢速设备，并分别统计本设备和所有设备同时打开的文件流数
+type slowMTP struct {
+	*device.FakeMTPAccessor
+	delay  time.Duration
+	local  streamCounter
+	shared *streamCounter
+}
+
+func (m *slowMTP) GetFileStream(filePath string) (io.ReadCloser, error) {
+	m.local.acquire()
+	m.shared.acquire()
+	release := func() {
+		m.local.release()
+		m.shared.release()
+	}
+	time.Sleep(m.delay)
+
+	stream, err := m.FakeMTPAccessor.GetFileStream(filePath)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &countedStream{ReadCloser: stream, release: release}, nil
+}
+
+// countedStream 关闭时释放计数
+type countedStream struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (s *countedStream) Close() error {
+	s.once.Do(s.release)
+	return s.ReadCloser.Close()
+}
+
+// TestDeviceScheduler 测试不同设备并行备份、单设备内文件串行复制，整体进度跨设备汇总
+func TestDeviceScheduler(t *testing.T) {
+	const (
+		base    = "内部共享存储空间\\录音笔文件\\"
+		devices = 3
+		files   = 4
+		delay   = 100 * time.Millisecond
+	)
+	tests := []struct {
+		name        string
+		concurrency int
+		parallel    bool
+	}{
+		{"设备间并行", 0, true},
+		{"设备并发数为1时依次执行", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(false)
+			shared := &streamCounter{}
+			var jobs []DeviceJob
+			var mtps []*slowMTP
+			for d := 0; d < devices; d++ {
+				cfg := config.DefaultConfig()
+				cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+				cfg.Backup.StabilityWait = ""
+				cfg.Backup.StabilityWindow = ""
+				cfg.Backup.EnableResume = false
+				cfg.Backup.RangeDownload.Enabled = false
+				cfg.Backup.MaxConcurrent = 3
+
+				deviceInfo := &device.DeviceInfo{DeviceID: fmt.Sprintf("fake_%d", d), Name: "SR302"}
+				fake := device.NewFakeMTPAccessor(deviceInfo)
+				modTime := time.Now().Add(-time.Hour)
+				for f := 0; f < files; f++ {
+					fake.AddFile(fmt.Sprintf("%s%d.opus", base, f), []byte(fmt.Sprintf("device %d file %d", d, f)), modTime)
+				}
+				mtp := &slowMTP{FakeMTPAccessor: fake, delay: delay, shared: shared}
+				mtps = append(mtps, mtp)
+
+				dataDir := t.TempDir()
+				tracker := storage.NewBackupTracker(filepath.Join(dataDir, "backup_records.json"), log)
+				bm := &BackupManager{config: cfg, log: log, tracker: tracker, dataDir: dataDir, quiet: true}
+				bm.SetMTPInterface(mtp)
+				jobs = append(jobs, DeviceJob{Name: fmt.Sprintf("task%d", d), Device: deviceInfo, Manager: bm})
+			}
+
+			cfg := config.DefaultConfig()
+			cfg.Backup.DeviceConcurrency = tt.concurrency
+			scheduler := NewDeviceScheduler(cfg, log)
+
+			start := time.Now()
+			results := scheduler.Run(context.Background(), jobs, false)
+			elapsed := time.Since(start)
+
+			for i, result := range results {
+				if result.Err != nil || result.Summary == nil || result.Summary.Succeeded != files {
+					t.Fatalf("任务 %d 的结果 = %+v", i, result)
+				}
+			}
+			for i, mtp := range mtps {
+				if got := mtp.local.max(); got != 1 {
+					t.Errorf("设备 %d 同时打开了 %d 个文件流，期望单设备内串行", i, got)
+				}
+			}
+
+			serial := time.Duration(devices*files) * delay
+			if tt.parallel {
+				if shared.max() < 2 {
+					t.Errorf("不同设备应并行复制，所有设备同时打开的文件流最多 %d 个", shared.max())
+				}
+				if elapsed >= serial {
+					t.Errorf("并行总耗时 %v 应小于串行耗时 %v", elapsed, serial)
+				}
+			} else if shared.max() != 1 {
+				t.Errorf("设备并发数为1时所有设备同时打开的文件流应为 1，实际 %d", shared.max())
+			}
+
+			totals := scheduler.Progress().Totals()
+			if totals.TotalFiles != devices*files || totals.CompletedFiles != devices*files ||
+				totals.TotalBytes == 0 || totals.CopiedBytes != totals.TotalBytes {
+				t.Errorf("整体进度 = %+v", totals)
+			}
+		})
+	}
+}
+
+// TestDeviceScheduler_SharedQuota 测试并行备份的各设备共享同一个每日配额和限速器，用量累计到全局的配额文件
+func TestDeviceScheduler_SharedQuota(t *testing.T) {
+	t.Chdir(t.TempDir())
+	log := logger.NewLogger(false)
+	content := bytes.Repeat([]byte("q"), 512)
+
+	var jobs []DeviceJob
+	for d := 0; d < 2; d++ {
+		cfg := config.DefaultConfig()
+		cfg.Target.BaseDirectory = filepath.Join("backups", fmt.Sprintf("task%d", d))
+		cfg.Backup.StabilityWait = ""
+		cfg.Backup.StabilityWindow = ""
+		cfg.Backup.DailyQuota = "10MB"
+
+		deviceInfo := &device.DeviceInfo{DeviceID: fmt.Sprintf("fake_%d", d), Name: "SR302"}
+		fake := device.NewFakeMTPAccessor(deviceInfo)
+		fake.AddFile("内部共享存储空间\\录音笔文件\\a.opus", content, time.Now().Add(-time.Hour))
+
+		bm := NewTaskManager(cfg, fmt.Sprintf("task%d", d), log, true, false, false)
+		bm.SetMTPInterface(fake)
+		jobs = append(jobs, DeviceJob{Name: fmt.Sprintf("task%d", d), Device: deviceInfo, Manager: bm})
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Backup.DailyQuota = "10MB"
+	cfg.Backup.Schedule = []config.RateWindow{{TimeRange: "00:00-23:59", MaxBytesPerSecond: "100MB"}}
+	scheduler := NewDeviceScheduler(cfg, log)
+	for i, result := range scheduler.Run(context.Background(), jobs, false) {
+		if result.Err != nil {
+			t.Fatalf("任务 %d 失败: %v", i, result.Err)
+		}
+	}
+
+	for i, job := range jobs {
+		job.Manager.Close()
+		if job.Manager.quota != scheduler.quota || job.Manager.limiter != scheduler.limiter {
+			t.Errorf("任务 %d 应使用调度器共享的配额和限速器", i)
+		}
+	}
+	if used := scheduler.quota.Used(); used != int64(2*len(content)) {
+		t.Errorf("共享配额的用量 = %d，期望两个设备之和 %d", used, 2*len(content))
+	}
+	if reloaded := NewQuotaTracker(QuotaPath, "10MB", log); reloaded.Used() != int64(2*len(content)) {
+		t.Errorf("用量应保存在全局配额文件中: %d", reloaded.Used())
+	}
+}
@@ -0,0 +1,233 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/internal/store"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 自检的各个阶段，按执行顺序排列
+const (
+	SelfTestEnumerate = "枚举"
+	SelfTestRead      = "读流"
+	SelfTestCopy      = "复制"
+	SelfTestVerify    = "校验"
+	SelfTestRecord    = "记录"
+)
+
+// errNoSelfTestFile 设备上没有可用于自检的文件
+var errNoSelfTestFile = errors.New("设备上没有可用于自检的录音文件")
+
+// SelfTestStage 自检一个阶段的结果
+type SelfTestStage struct {
+	Name     string
+	Duration time.Duration
+	Detail   string // 阶段完成情况，如选中的文件、复制的字节数
+	Err      error
+	Skipped  bool // 前面的阶段失败，本阶段未执行
+}
+
+// SelfTestResult 一次自检的结果
+type SelfTestResult struct {
+	File    *utils.FileInfo // 选中的测试文件，枚举失败时为nil
+	TempDir string          // 复制目标所在的临时目录，自检结束后已删除
+	Stages  []SelfTestStage
+}
+
+// FailedStage 返回第一个失败的阶段，链路正常时返回nil
+func (r *SelfTestResult) FailedStage() *SelfTestStage {
+	for i := range r.Stages {
+		if r.Stages[i].Err != nil {
+			return &r.Stages[i]
+		}
+	}
+	return nil
+}
+
+// Conclusion 自检结论
+func (r *SelfTestResult) Conclusion() string {
+	if failed := r.FailedStage(); failed != nil {
+		return fmt.Sprintf("在%s阶段失败: %v", failed.Name, failed.Err)
+	}
+	return "链路正常"
+}
+
+// selfTest 一次自检的状态，各阶段依次读写
+type selfTest struct {
+	bm         *BackupManager
+	device     *device.DeviceInfo
+	tempDir    string
+	tracker    *storage.BackupTracker // 记录写入临时目录，不影响正式的备份记录
+	file       *utils.FileInfo
+	sourceHash string
+	targetPath string
+}
+
+// stages 按顺序返回各阶段的名称和执行函数，执行函数返回阶段完成情况
+func (st *selfTest) stages() []struct {
+	name string
+	run  func() (string, error)
+} {
+	return []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{SelfTestEnumerate, st.enumerate},
+		{SelfTestRead, st.read},
+		{SelfTestCopy, st.copy},
+		{SelfTestVerify, st.verify},
+		{SelfTestRecord, st.record},
+	}
+}
+
+// SelfTest 选设备上最小的一个录音完整跑一遍备份流程（枚举→读流→复制→校验→记录），
+// 复制到临时目录并在结束后清理，不写入正式的备份目录和备份记录
+// 某一阶段失败后，后续阶段标记为未执行
+func (bm *BackupManager) SelfTest(device *device.DeviceInfo) (*SelfTestResult, error) {
+	tempDir, err := os.MkdirTemp(utils.TempDir(), utils.TempFilePrefix+"selftest_")
+	if err != nil {
+		return nil, fmt.Errorf("创建自检临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tracker := storage.NewBackupTracker(filepath.Join(tempDir, "backup_records.json"), bm.log)
+	tracker.SetEncryptionKey(bm.config.Storage.ResolveEncryptionKey())
+
+	st := &selfTest{bm: bm, device: device, tempDir: tempDir, tracker: tracker}
+	result := &SelfTestResult{TempDir: tempDir}
+
+	failed := false
+	for _, stage := range st.stages() {
+		if failed {
+			result.Stages = append(result.Stages, SelfTestStage{Name: stage.name, Skipped: true})
+			continue
+		}
+
+		start := time.Now()
+		detail, err := stage.run()
+		result.Stages = append(result.Stages, SelfTestStage{Name: stage.name, Duration: time.Since(start), Detail: detail, Err: err})
+		if err != nil {
+			failed = true
+			bm.log.Error("自检%s阶段失败: %v", stage.name, err)
+			continue
+		}
+		bm.log.Info("自检%s阶段通过: %s", stage.name, detail)
+	}
+
+	result.File = st.file
+	return result, nil
+}
+
+// enumerate 枚举设备文件并选出最小的录音作为测试文件
+func (st *selfTest) enumerate() (string, error) {
+	files, err := st.bm.createFileChecker(st.device).ScanDeviceFiles(st.device)
+	if err != nil {
+		return "", err
+	}
+	st.file = selectSelfTestFile(files)
+	if st.file == nil {
+		return "", errNoSelfTestFile
+	}
+	return fmt.Sprintf("%d 个文件，选中 %s (%s)", len(files), st.file.RelativePath, utils.FormatBytes(st.file.Size)), nil
+}
+
+// read 从设备读取测试文件的完整内容并计算哈希
+func (st *selfTest) read() (string, error) {
+	hash, err := st.bm.hashDeviceFile(st.file)
+	if err != nil {
+		return "", err
+	}
+	st.sourceHash = hash
+	return fmt.Sprintf("读取 %s", utils.FormatBytes(st.file.Size)), nil
+}
+
+// copy 按当前配置把测试文件复制到临时目录，忽略已有的备份记录
+func (st *selfTest) copy() (string, error) {
+	cfg := *st.bm.config
+	cfg.Target.BaseDirectory = filepath.Join(st.tempDir, "backups")
+	cfg.Target.Type = store.TypeLocal
+	cfg.Target.Archive = ArchiveNone
+
+	copier := NewFileCopier(&cfg, st.bm.log, st.tracker, st.device)
+	if st.bm.mtp != nil {
+		copier.SetMTPInterface(st.bm.mtp)
+	}
+	result := copier.CopyFile(st.file, true)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if !result.Success {
+		return "", fmt.Errorf("文件未复制: %s", result.SkipReason)
+	}
+	st.targetPath = result.TargetPath
+	return fmt.Sprintf("复制 %s", utils.FormatBytes(result.BytesCopied)), nil
+}
+
+// verify 比对目标文件与设备文件的大小和哈希
+func (st *selfTest) verify() (string, error) {
+	info, err := os.Stat(st.targetPath)
+	if err != nil {
+		return "", fmt.Errorf("读取目标文件失败: %w", err)
+	}
+	if info.Size() != st.file.Size {
+		return "", fmt.Errorf("文件大小不一致: 设备 %d 字节，目标 %d 字节", st.file.Size, info.Size())
+	}
+
+	algorithm := st.bm.deviceHashAlgorithm()
+	hash, err := NewIntegrityVerifier(st.bm.log, algorithm).CalculateFileHash(st.targetPath)
+	if err != nil {
+		return "", fmt.Errorf("计算目标文件哈希失败: %w", err)
+	}
+	if hash != st.sourceHash {
+		return "", fmt.Errorf("文件内容不一致: 设备 %s，目标 %s", st.sourceHash, hash)
+	}
+	return fmt.Sprintf("%s 一致", algorithm), nil
+}
+
+// record 确认复制时写入了备份记录，并能保存和重新加载
+func (st *selfTest) record() (string, error) {
+	if backedUp, record, _ := st.tracker.IsFileBackedUp(st.file.Path); !backedUp || record.TargetPath != st.targetPath {
+		return "", fmt.Errorf("复制后没有写入备份记录")
+	}
+	if err := st.tracker.Save(); err != nil {
+		return "", fmt.Errorf("保存备份记录失败: %w", err)
+	}
+
+	reloaded := storage.NewBackupTracker(filepath.Join(st.tempDir, "backup_records.json"), st.bm.log)
+	reloaded.SetEncryptionKey(st.bm.config.Storage.ResolveEncryptionKey())
+	if err := reloaded.Load(); err != nil {
+		return "", fmt.Errorf("重新加载备份记录失败: %w", err)
+	}
+	if backedUp, _, _ := reloaded.IsFileBackedUp(st.file.Path); !backedUp {
+		return "", fmt.Errorf("重新加载后找不到备份记录")
+	}
+	return "保存并重新加载成功", nil
+}
+
+// selectSelfTestFile 选出最小的非空、未加密的文件，大小相同时按路径排序
+func selectSelfTestFile(files []*utils.FileInfo) *utils.FileInfo {
+	var candidates []*utils.FileInfo
+	for _, file := range files {
+		if file.Size > 0 && !file.Encrypted {
+			candidates = append(candidates, file)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Size != candidates[j].Size {
+			return candidates[i].Size < candidates[j].Size
+		}
+		return candidates[i].Path < candidates[j].Path
+	})
+	return candidates[0]
+}
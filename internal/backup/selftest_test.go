@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// corruptingAccessor 模拟读取不稳定的设备：第一次读取正常，之后读到的内容被篡改
+type corruptingAccessor struct {
+	*device.FakeMTPAccessor
+	mu    sync.Mutex
+	reads int
+}
+
+func (c *corruptingAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
+	c.mu.Lock()
+	c.reads++
+	reads := c.reads
+	c.mu.Unlock()
+
+	stream, err := c.FakeMTPAccessor.GetFileStream(filePath)
+	if err != nil || reads == 1 {
+		return stream, err
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, err
+	}
+	data[0] ^= 0xff
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// TestBackupManager_SelfTest 测试自检各阶段结果的汇总
+func TestBackupManager_SelfTest(t *testing.T) {
+	const (
+		smallPath = "内部共享存储空间\\录音笔文件\\small.opus"
+		largePath = "内部共享存储空间\\录音笔文件\\large.opus"
+	)
+
+	tests := []struct {
+		name       string
+		empty      bool                                                   // 设备上没有录音
+		setup      func(fake *device.FakeMTPAccessor) device.MTPInterface // 返回注入管理器的访问接口
+		wantFailed string                                                 // 期望失败的阶段，空表示链路正常
+	}{
+		{
+			name:  "链路正常",
+			setup: func(fake *device.FakeMTPAccessor) device.MTPInterface { return fake },
+		},
+		{
+			name:       "设备上没有录音",
+			empty:      true,
+			setup:      func(fake *device.FakeMTPAccessor) device.MTPInterface { return fake },
+			wantFailed: SelfTestEnumerate,
+		},
+		{
+			name: "读取文件流失败",
+			setup: func(fake *device.FakeMTPAccessor) device.MTPInterface {
+				fake.FailStream(smallPath, 100)
+				return fake
+			},
+			wantFailed: SelfTestRead,
+		},
+		{
+			name: "复制的字节数与枚举大小不一致",
+			setup: func(fake *device.FakeMTPAccessor) device.MTPInterface {
+				fake.AddFile(smallPath, []byte("small"), time.Now().Add(-time.Hour)).Size = 6
+				return fake
+			},
+			wantFailed: SelfTestCopy,
+		},
+		{
+			name: "复制的内容与设备不一致",
+			setup: func(fake *device.FakeMTPAccessor) device.MTPInterface {
+				return &corruptingAccessor{FakeMTPAccessor: fake}
+			},
+			wantFailed: SelfTestVerify,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+			cfg.Backup.EnableResume = false
+			cfg.Backup.StabilityWait = ""
+			cfg.Backup.StabilityWindow = ""
+
+			log := logger.NewLogger(false)
+			recordsPath := filepath.Join(t.TempDir(), "backup_records.json")
+			tracker := storage.NewBackupTracker(recordsPath, log)
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			fake.AddStorage(device.StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 20})
+			if !tt.empty {
+				modTime := time.Now().Add(-time.Hour)
+				fake.AddFile(smallPath, []byte("small"), modTime)
+				fake.AddFile(largePath, bytes.Repeat([]byte("l"), 4096), modTime)
+			}
+
+			bm := &BackupManager{config: cfg, log: log, tracker: tracker, quiet: true}
+			bm.SetMTPInterface(tt.setup(fake))
+
+			result, err := bm.SelfTest(deviceInfo)
+			if err != nil {
+				t.Fatalf("自检失败: %v", err)
+			}
+
+			names := []string{SelfTestEnumerate, SelfTestRead, SelfTestCopy, SelfTestVerify, SelfTestRecord}
+			if len(result.Stages) != len(names) {
+				t.Fatalf("期望 %d 个阶段，实际 %d 个", len(names), len(result.Stages))
+			}
+			reached := tt.wantFailed == ""
+			for i, stage := range result.Stages {
+				if stage.Name != names[i] {
+					t.Errorf("第 %d 个阶段 = %s, 期望 %s", i+1, stage.Name, names[i])
+				}
+				switch {
+				case stage.Name == tt.wantFailed:
+					reached = true
+					if stage.Err == nil || stage.Skipped {
+						t.Errorf("%s 阶段应失败: %+v", stage.Name, stage)
+					}
+				case reached && tt.wantFailed != "":
+					if !stage.Skipped {
+						t.Errorf("失败后的 %s 阶段应标记为未执行", stage.Name)
+					}
+				default:
+					if stage.Err != nil || stage.Skipped {
+						t.Errorf("%s 阶段应通过: %+v", stage.Name, stage)
+					}
+				}
+			}
+
+			if tt.wantFailed == "" {
+				if result.FailedStage() != nil || result.Conclusion() != "链路正常" {
+					t.Errorf("结论 = %s, 期望链路正常", result.Conclusion())
+				}
+				if result.File == nil || result.File.Path != smallPath {
+					t.Errorf("应选中最小的文件 %s: %+v", smallPath, result.File)
+				}
+			} else if failed := result.FailedStage(); failed == nil || failed.Name != tt.wantFailed {
+				t.Errorf("结论 = %s, 期望在%s阶段失败", result.Conclusion(), tt.wantFailed)
+			}
+
+			// 自检不写入正式的备份目录和备份记录，临时目录已清理
+			if _, err := os.Stat(result.TempDir); !os.IsNotExist(err) {
+				t.Errorf("临时目录应已删除: %s", result.TempDir)
+			}
+			if _, err := os.Stat(cfg.Target.BaseDirectory); !os.IsNotExist(err) {
+				t.Error("自检不应写入备份目录")
+			}
+			if len(tracker.GetRecordsByDevice(deviceInfo.DeviceID)) != 0 || utils.FileExists(recordsPath) {
+				t.Error("自检不应写入正式的备份记录")
+			}
+		})
+	}
+}
+
+// TestSelectSelfTestFile 测试选出最小的非空、未加密文件
+func TestSelectSelfTestFile(t *testing.T) {
+	files := []*utils.FileInfo{
+		{Path: "b.opus", Size: 300},
+		{Path: "empty.opus", Size: 0},
+		{Path: "secret.opus", Size: 10, Encrypted: true},
+		{Path: "a.opus", Size: 300},
+		{Path: "c.opus", Size: 900},
+	}
+	if got := selectSelfTestFile(files); got == nil || got.Path != "a.opus" {
+		t.Errorf("selectSelfTestFile() = %+v, 期望 a.opus", got)
+	}
+	if got := selectSelfTestFile(files[1:3]); got != nil {
+		t.Errorf("没有可用文件时应返回 nil: %+v", got)
+	}
+}
@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"context"
+)
+
+// SharedSemaphore 多个 FileCopier 共享的全局并发资源池
+// 为 nil 时表示不限制全局并发
+type SharedSemaphore struct {
+	slots chan struct{}
+}
+
+// NewSharedSemaphore 创建全局并发资源池，size <= 0 时返回 nil（不限制）
+func NewSharedSemaphore(size int) *SharedSemaphore {
+	if size <= 0 {
+		return nil
+	}
+	return &SharedSemaphore{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Acquire 获取一个并发名额，context 取消时返回错误
+func (s *SharedSemaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release 释放一个并发名额
+func (s *SharedSemaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
+
+// Capacity 返回全局并发上限，不限制时返回0
+func (s *SharedSemaphore) Capacity() int {
+	if s == nil {
+		return 0
+	}
+	return cap(s.slots)
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// SidecarSuffix 元数据文件的后缀，写在目标文件名之后，如 a.opus.json
+const SidecarSuffix = ".json"
+
+// Sidecar 备份文件旁的元数据文件内容
+type Sidecar struct {
+	DeviceID        string          `json:"device_id"`
+	DeviceName      string          `json:"device_name"`
+	SourcePath      string          `json:"source_path"`
+	RelativePath    string          `json:"relative_path"`
+	FileSize        int64           `json:"file_size"`
+	FileHash        string          `json:"file_hash,omitempty"`
+	HashAlgorithm   string          `json:"hash_algorithm,omitempty"`
+	ModTime         time.Time       `json:"mod_time"`
+	BackupTime      time.Time       `json:"backup_time"`
+	DurationSeconds float64         `json:"duration_seconds,omitempty"` // 录音时长，无法确定时省略
+	AudioMeta       *utils.OpusMeta `json:"audio_meta,omitempty"`
+}
+
+// SidecarPath 获取目标文件对应的元数据文件路径
+func SidecarPath(targetPath string) string {
+	return targetPath + SidecarSuffix
+}
+
+// IsSidecarFile 判断文件名是否为元数据文件（如 a.opus.json），这类文件不作为备份源
+func IsSidecarFile(name string) bool {
+	lower := strings.ToLower(name)
+	if !strings.HasSuffix(lower, SidecarSuffix) {
+		return false
+	}
+	return filepath.Ext(strings.TrimSuffix(lower, SidecarSuffix)) != ""
+}
+
+// NewSidecar 根据备份记录生成元数据
+func NewSidecar(record *storage.BackupRecord, file *utils.FileInfo, deviceName string) *Sidecar {
+	sidecar := &Sidecar{
+		DeviceID:      record.DeviceID,
+		DeviceName:    deviceName,
+		SourcePath:    record.SourcePath,
+		RelativePath:  file.RelativePath,
+		FileSize:      record.FileSize,
+		FileHash:      record.FileHash,
+		HashAlgorithm: record.HashAlgorithm,
+		ModTime:       file.ModTime,
+		BackupTime:    record.BackupTime,
+		AudioMeta:     record.AudioMeta,
+	}
+	// 未开启完整性验证时记录中的哈希以SHA256计算
+	if sidecar.FileHash != "" && sidecar.HashAlgorithm == "" {
+		sidecar.HashAlgorithm = "sha256"
+	}
+	if record.AudioMeta != nil && record.AudioMeta.Duration > 0 {
+		sidecar.DurationSeconds = record.AudioMeta.Duration.Seconds()
+	}
+	return sidecar
+}
+
+// writeSidecar 按备份记录通过目标存储写入目标文件旁的 .json 元数据文件
+func (fc *FileCopier) writeSidecar(file *utils.FileInfo, targetPath string) {
+	record, err := fc.tracker.GetRecordByPath(file.Path)
+	if err != nil {
+		fc.log.Warn("写入元数据文件失败: %s, %v", file.RelativePath, err)
+		return
+	}
+
+	data, err := json.MarshalIndent(NewSidecar(record, file, fc.device.DisplayName(fc.config)), "", "  ")
+	if err != nil {
+		fc.log.Warn("序列化元数据失败: %s, %v", file.RelativePath, err)
+		return
+	}
+	if err := fc.writeTarget(SidecarPath(targetPath), bytes.NewReader(data)); err != nil {
+		fc.log.Warn("写入元数据文件失败: %s, %v", file.RelativePath, err)
+		return
+	}
+	fc.log.Debug("已写入元数据文件: %s", SidecarPath(targetPath))
+}
@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestIsSidecarFile 测试识别元数据文件
+func TestIsSidecarFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"a.opus.json", true},
+		{"会议.OPUS.JSON", true},
+		{"a.opus", false},
+		{"config.json", false},
+		{".json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSidecarFile(tt.name); got != tt.want {
+				t.Errorf("IsSidecarFile(%s) = %v, 期望 %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFileCopier_WriteSidecar 测试复制成功的文件旁生成元数据文件，跳过的文件不生成
+func TestFileCopier_WriteSidecar(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			FileExtensions:    []string{".opus"},
+			MaxConcurrent:     1,
+			SkipExisting:      true,
+			PreserveStructure: true,
+			IntegrityCheck:    true,
+			HashAlgorithm:     "sha256",
+			WriteSidecar:      true,
+		},
+		Target: config.TargetConfig{
+			BaseDirectory: baseDir,
+			CreateSubdirs: true,
+		},
+	}
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "sr302_001", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	modTime := time.Date(2024, 3, 11, 9, 30, 0, 0, time.UTC)
+	entries := []struct {
+		rel, name, target string
+		content           []byte
+	}{
+		{"a.opus", "a.opus", filepath.Join(baseDir, "a.opus"), []byte("new recording a")},
+		{"会议\\b.opus", "b.opus", filepath.Join(baseDir, "会议", "b.opus"), []byte("new recording b")},
+		{"old.opus", "old.opus", filepath.Join(baseDir, "old.opus"), []byte("already backed up")},
+	}
+
+	tracker := NewMockTracker()
+	copier := NewFileCopier(cfg, logger.NewLogger(false), tracker, deviceInfo)
+	copier.SetMTPInterface(fake)
+
+	for i, entry := range entries {
+		file := &utils.FileInfo{
+			Path:         "内部共享存储空间\\录音笔文件\\" + entry.rel,
+			RelativePath: entry.rel,
+			Name:         entry.name,
+			Size:         int64(len(entry.content)),
+			ModTime:      modTime,
+		}
+		fake.AddFile(file.Path, entry.content, modTime)
+		if i == 2 {
+			tracker.AddRecord(file.Path, entry.target, deviceInfo.DeviceID, file.Size, "")
+		}
+
+		result := copier.CopyFile(file, false)
+		if result.Error != nil {
+			t.Fatalf("复制失败: %s, %v", file.RelativePath, result.Error)
+		}
+
+		data, err := os.ReadFile(SidecarPath(entry.target))
+		if result.Skipped {
+			if !os.IsNotExist(err) {
+				t.Errorf("跳过的文件不应生成元数据文件: %s", file.RelativePath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("复制成功的文件应生成元数据文件: %s, %v", file.RelativePath, err)
+		}
+		if !tracker.backedUp[file.Path] {
+			t.Errorf("复制成功的文件应写入备份记录: %s", file.RelativePath)
+		}
+
+		var sidecar Sidecar
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			t.Fatalf("解析元数据文件失败: %v", err)
+		}
+		wantHash := fmt.Sprintf("%x", sha256.Sum256(entry.content))
+		if sidecar.DeviceID != deviceInfo.DeviceID || sidecar.DeviceName != deviceInfo.Name {
+			t.Errorf("设备信息不正确: %+v", sidecar)
+		}
+		if sidecar.SourcePath != file.Path || sidecar.RelativePath != file.RelativePath {
+			t.Errorf("原路径不正确: %+v", sidecar)
+		}
+		if sidecar.FileSize != file.Size || sidecar.FileHash != wantHash || sidecar.HashAlgorithm != "sha256" {
+			t.Errorf("大小或哈希不正确: %+v, 期望哈希 %s", sidecar, wantHash)
+		}
+		if !sidecar.ModTime.Equal(modTime) {
+			t.Errorf("修改时间 = %v, 期望 %v", sidecar.ModTime, modTime)
+		}
+		if sidecar.DurationSeconds != 0 || sidecar.AudioMeta != nil {
+			t.Errorf("无法解析的音频不应写入时长: %+v", sidecar)
+		}
+	}
+
+	if copier.isSupportedFileType("a.opus.json") {
+		t.Error("元数据文件不应作为备份源")
+	}
+}
+
+// TestNewSidecar_Duration 测试从音频元数据生成时长
+func TestNewSidecar_Duration(t *testing.T) {
+	record := &storage.BackupRecord{
+		SourcePath: "device\\a.opus",
+		DeviceID:   "sr302_001",
+		FileSize:   1024,
+		FileHash:   "abc",
+		BackupTime: time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC),
+		AudioMeta:  &utils.OpusMeta{Channels: 1, Duration: 90 * time.Second},
+	}
+
+	sidecar := NewSidecar(record, &utils.FileInfo{RelativePath: "a.opus"}, "SR302")
+	if sidecar.DurationSeconds != 90 {
+		t.Errorf("DurationSeconds = %v, 期望 90", sidecar.DurationSeconds)
+	}
+	if sidecar.HashAlgorithm != "sha256" {
+		t.Errorf("未记录算法时应为 sha256，实际 %s", sidecar.HashAlgorithm)
+	}
+	if !sidecar.BackupTime.Equal(record.BackupTime) {
+		t.Errorf("备份时间 = %v", sidecar.BackupTime)
+	}
+}
+
+// TestMirrorCleaner_MovesSidecar 测试镜像清理时元数据文件随备份移入回收目录
+func TestMirrorCleaner_MovesSidecar(t *testing.T) {
+	baseDir, tracker := newMirrorFixture(t, []string{"a.opus", "b.opus", "c.opus"})
+	sidecarPath := SidecarPath(filepath.Join(baseDir, "录音笔文件", "b.opus"))
+	if err := os.WriteFile(sidecarPath, []byte("{}"), 0644); err != nil {
+		t.Fatalf("创建元数据文件失败: %v", err)
+	}
+
+	cleaner := NewMirrorCleaner(baseDir, tracker, true, logger.NewLogger(false))
+	cleaner.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local) }
+	if _, err := cleaner.Clean("test_device", deviceFiles("a.opus", "c.opus")); err != nil {
+		t.Fatalf("镜像清理失败: %v", err)
+	}
+
+	if _, err := os.Stat(sidecarPath); !os.IsNotExist(err) {
+		t.Error("元数据文件应随备份移走")
+	}
+	trashPath := filepath.Join(baseDir, TrashDirName, "20240501_100000", "录音笔文件", "b.opus.json")
+	if _, err := os.Stat(trashPath); err != nil {
+		t.Errorf("期望元数据文件移入回收目录 %s: %v", trashPath, err)
+	}
+}
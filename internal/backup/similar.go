@@ -0,0 +1,192 @@
+package backup
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// SimilarFile 参与疑似重复检测的录音
+type SimilarFile struct {
+	File     *utils.FileInfo `json:"file"`
+	Duration time.Duration   `json:"duration"` // 录音时长，无法获取时为0
+}
+
+// SimilarGroup 一组时长、大小都接近的疑似重复录音，只供人工确认
+type SimilarGroup struct {
+	Files []SimilarFile `json:"files"`
+}
+
+// SimilarDetector 按时长、大小和文件名前缀判断录音是否疑似重复
+// 同一场会议录两遍或分段录制时，录音的时长和大小通常非常接近
+type SimilarDetector struct {
+	durationTolerance time.Duration
+	sizeTolerance     float64
+	namePrefix        int
+}
+
+// NewSimilarDetector 按配置创建疑似重复检测器，未启用时返回 nil
+func NewSimilarDetector(cfg config.SimilarConfig) *SimilarDetector {
+	if !cfg.Enabled {
+		return nil
+	}
+	detector := &SimilarDetector{sizeTolerance: cfg.SizeTolerance, namePrefix: cfg.NamePrefix}
+	if cfg.DurationTolerance != "" {
+		if d, err := utils.ParseDuration(cfg.DurationTolerance); err == nil {
+			detector.durationTolerance = d
+		}
+	}
+	return detector
+}
+
+// Similar 判断两个录音是否疑似重复：大小接近、时长接近（任一时长未知时只比较大小），
+// 配置了文件名前缀时前缀还需相同
+func (d *SimilarDetector) Similar(a, b SimilarFile) bool {
+	if a.File.Path == b.File.Path || a.File.Size <= 0 || b.File.Size <= 0 {
+		return false
+	}
+
+	larger, diff := a.File.Size, a.File.Size-b.File.Size
+	if b.File.Size > larger {
+		larger = b.File.Size
+	}
+	if diff < 0 {
+		diff = -diff
+	}
+	if float64(diff) > d.sizeTolerance*float64(larger) {
+		return false
+	}
+
+	if a.Duration > 0 && b.Duration > 0 {
+		gap := a.Duration - b.Duration
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap > d.durationTolerance {
+			return false
+		}
+	}
+
+	return d.namePrefix <= 0 || namePrefix(a.File.Name, d.namePrefix) == namePrefix(b.File.Name, d.namePrefix)
+}
+
+// Group 把疑似重复的录音归组，只返回包含至少两个文件的组
+// 相似关系可传递：A 与 B、B 与 C 相似时三者归为一组
+func (d *SimilarDetector) Group(files []SimilarFile) []SimilarGroup {
+	if d == nil || len(files) < 2 {
+		return nil
+	}
+
+	sorted := make([]SimilarFile, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].File.Size < sorted[j].File.Size })
+
+	parent := make([]int, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// 按大小排序后，与当前文件大小相差超出阈值的后续文件不可能再相似
+	for i := range sorted {
+		for j := i + 1; j < len(sorted); j++ {
+			if float64(sorted[j].File.Size-sorted[i].File.Size) > d.sizeTolerance*float64(sorted[j].File.Size) {
+				break
+			}
+			if d.Similar(sorted[i], sorted[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]SimilarFile)
+	for i := range sorted {
+		root := find(i)
+		members[root] = append(members[root], sorted[i])
+	}
+
+	var groups []SimilarGroup
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].File.Path < group[j].File.Path })
+		groups = append(groups, SimilarGroup{Files: group})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Files[0].File.Path < groups[j].Files[0].File.Path })
+	return groups
+}
+
+// namePrefix 取不含扩展名的文件名前 n 个字符，不区分大小写
+func namePrefix(name string, n int) string {
+	base := []rune(strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))))
+	if len(base) > n {
+		base = base[:n]
+	}
+	return string(base)
+}
+
+// findSimilarRecordings 在 opus 文件中查找疑似重复的录音
+// 时长优先取备份记录中的音频元数据，设备以盘符挂载时直接解析设备上的文件，都无法获取时为0
+func (bm *BackupManager) findSimilarRecordings(files []*utils.FileInfo) []SimilarGroup {
+	detector := NewSimilarDetector(bm.config.Backup.Similar)
+	if detector == nil {
+		return nil
+	}
+
+	durations := make(map[string]time.Duration)
+	if backupStorage := bm.tracker.GetStorage(); backupStorage != nil {
+		for _, record := range backupStorage.Records {
+			if record.AudioMeta != nil && record.AudioMeta.Duration > 0 {
+				durations[record.SourcePath] = record.AudioMeta.Duration
+			}
+		}
+	}
+
+	var candidates []SimilarFile
+	for _, file := range files {
+		if !utils.IsOpusFile(file.Name) {
+			continue
+		}
+		duration, ok := durations[file.Path]
+		if !ok && isDriveLetterPath(file.Path) {
+			if meta, err := utils.ExtractOpusMetadata(file.Path); err == nil {
+				duration = meta.Duration
+			}
+		}
+		candidates = append(candidates, SimilarFile{File: file, Duration: duration})
+	}
+	return detector.Group(candidates)
+}
+
+// logSimilarRecordings 在日志中提示疑似重复的录音，不做任何处理
+func (bm *BackupManager) logSimilarRecordings(groups []SimilarGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	bm.log.Warn("发现 %d 组疑似重复的录音（时长和大小接近），请人工确认，不会自动删除", len(groups))
+	for i, group := range groups {
+		for _, file := range group.Files {
+			bm.log.Info("  第%d组: %s (%s, %s)", i+1, file.File.RelativePath,
+				utils.FormatBytes(file.File.Size), formatSimilarDuration(file.Duration))
+		}
+	}
+}
+
+// formatSimilarDuration 显示录音时长，未知时显示"时长未知"
+func formatSimilarDuration(d time.Duration) string {
+	if d <= 0 {
+		return "时长未知"
+	}
+	return utils.FormatDuration(d)
+}
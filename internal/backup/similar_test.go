@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// similarFile 构造参与检测的录音，duration 为0表示时长未知
+func similarFile(name string, size int64, duration time.Duration) SimilarFile {
+	return SimilarFile{
+		File:     &utils.FileInfo{Path: "录音笔文件\\" + name, RelativePath: name, Name: name, Size: size},
+		Duration: duration,
+	}
+}
+
+// groupNames 把分组结果转成文件名列表便于比较
+func groupNames(groups []SimilarGroup) [][]string {
+	var names [][]string
+	for _, group := range groups {
+		var files []string
+		for _, file := range group.Files {
+			files = append(files, file.File.Name)
+		}
+		names = append(names, files)
+	}
+	return names
+}
+
+// TestSimilarDetector_Group 测试按时长和大小接近分组
+func TestSimilarDetector_Group(t *testing.T) {
+	const mb = 1024 * 1024
+	minute := time.Minute
+
+	tests := []struct {
+		name   string
+		config config.SimilarConfig
+		files  []SimilarFile
+		want   [][]string
+	}{
+		{
+			name:   "同一会议录两遍",
+			config: config.SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 0.05},
+			files: []SimilarFile{
+				similarFile("会议_1.opus", 30*mb, 60*minute),
+				similarFile("会议_2.opus", 30*mb+200*1024, 60*minute+4*time.Second),
+				similarFile("访谈.opus", 12*mb, 25*minute),
+			},
+			want: [][]string{{"会议_1.opus", "会议_2.opus"}},
+		},
+		{
+			name:   "大小接近但时长相差远",
+			config: config.SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 0.05},
+			files: []SimilarFile{
+				similarFile("a.opus", 10*mb, 20*minute),
+				similarFile("b.opus", 10*mb, 40*minute),
+			},
+			want: nil,
+		},
+		{
+			name:   "时长接近但大小相差远",
+			config: config.SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 0.05},
+			files: []SimilarFile{
+				similarFile("a.opus", 10*mb, 20*minute),
+				similarFile("b.opus", 20*mb, 20*minute),
+			},
+			want: nil,
+		},
+		{
+			name:   "时长未知时只比较大小",
+			config: config.SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 0.05},
+			files: []SimilarFile{
+				similarFile("a.opus", 10*mb, 0),
+				similarFile("b.opus", 10*mb+100*1024, 20*minute),
+				similarFile("c.opus", 15*mb, 0),
+			},
+			want: [][]string{{"a.opus", "b.opus"}},
+		},
+		{
+			name:   "多组且相似关系可传递",
+			config: config.SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 0.05},
+			files: []SimilarFile{
+				similarFile("x3.opus", 20*mb+1800*1024, 40*minute+16*time.Second),
+				similarFile("y1.opus", 5*mb, 10*minute),
+				similarFile("x1.opus", 20*mb, 40*minute),
+				similarFile("x2.opus", 20*mb+900*1024, 40*minute+8*time.Second),
+				similarFile("y2.opus", 5*mb+50*1024, 10*minute+2*time.Second),
+			},
+			want: [][]string{{"x1.opus", "x2.opus", "x3.opus"}, {"y1.opus", "y2.opus"}},
+		},
+		{
+			name:   "要求文件名前缀相同",
+			config: config.SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 0.05, NamePrefix: 8},
+			files: []SimilarFile{
+				similarFile("20240315_0900.opus", 10*mb, 20*minute),
+				similarFile("20240315_0930.opus", 10*mb, 20*minute),
+				similarFile("20240316_0900.opus", 10*mb, 20*minute),
+			},
+			want: [][]string{{"20240315_0900.opus", "20240315_0930.opus"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := NewSimilarDetector(tt.config).Group(tt.files)
+			if got := groupNames(groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("分组 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if NewSimilarDetector(config.SimilarConfig{Enabled: false}) != nil {
+		t.Error("未启用时应返回 nil")
+	}
+}
+
+// TestBackupManager_FindSimilarRecordings 测试时长取自备份记录的音频元数据，且只检测 opus 文件
+func TestBackupManager_FindSimilarRecordings(t *testing.T) {
+	log := logger.NewLogger(false)
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+	bm := &BackupManager{config: config.DefaultConfig(), log: log, tracker: tracker}
+
+	files := []*utils.FileInfo{
+		{Path: "录音笔文件\\a.opus", RelativePath: "a.opus", Name: "a.opus", Size: 1000},
+		{Path: "录音笔文件\\b.opus", RelativePath: "b.opus", Name: "b.opus", Size: 1010},
+		{Path: "录音笔文件\\c.wav", RelativePath: "c.wav", Name: "c.wav", Size: 1000},
+	}
+
+	// 没有时长信息时只按大小判断
+	if got := fmt.Sprint(groupNames(bm.findSimilarRecordings(files))); got != "[[a.opus b.opus]]" {
+		t.Errorf("分组 = %s, 期望只包含 opus 文件", got)
+	}
+
+	// 备份记录中的时长相差很远，不再归为一组
+	for path, duration := range map[string]time.Duration{files[0].Path: 20 * time.Minute, files[1].Path: 40 * time.Minute} {
+		if err := tracker.AddRecord(path, path, "test", 1000, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := tracker.SetRecordMetadata(path, &utils.OpusMeta{Duration: duration}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if groups := bm.findSimilarRecordings(files); len(groups) != 0 {
+		t.Errorf("时长相差很远的录音不应归为一组: %v", groupNames(groups))
+	}
+
+	bm.config.Backup.Similar.Enabled = false
+	if groups := bm.findSimilarRecordings(files[:2]); groups != nil {
+		t.Error("关闭检测后不应返回分组")
+	}
+}
@@ -0,0 +1,142 @@
+package backup
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+const (
+	// SlowSpeedWindow 速度低于阈值持续该时长才告警，避免瞬时波动误报
+	SlowSpeedWindow = 30 * time.Second
+	// SlowReconnectMaxAttempts 速度持续偏低时重新打开设备文件流的最大次数
+	SlowReconnectMaxAttempts = 1
+	// speedSampleInterval 实时速度的采样间隔
+	speedSampleInterval = time.Second
+)
+
+// errSlowTransfer 速度持续偏低且开启了重连时，由监控读取器返回以中断当前读取
+var errSlowTransfer = errors.New("复制速度持续偏低")
+
+// IsSlowSpeed 判断速度是否低于历史平均的 threshold 比例，没有历史或未开启检测时返回false
+func IsSlowSpeed(baseline, current, threshold float64) bool {
+	if baseline <= 0 || threshold <= 0 {
+		return false
+	}
+	return current < baseline*threshold
+}
+
+// SpeedMonitor 按采样间隔统计实时速度，速度持续偏低达到窗口时长时告警
+type SpeedMonitor struct {
+	baseline  float64       // 历史平均速度（字节/秒）
+	threshold float64       // 告警比例
+	window    time.Duration // 持续时长
+	lastTime  time.Time     // 上次采样时间
+	lastBytes int64         // 上次采样时的累计字节数
+	slowSince time.Time     // 开始偏低的时间，零值表示当前不偏低
+}
+
+// NewSpeedMonitor 创建速度监控器
+func NewSpeedMonitor(baseline, threshold float64, window time.Duration) *SpeedMonitor {
+	return &SpeedMonitor{baseline: baseline, threshold: threshold, window: window}
+}
+
+// Observe 记录 now 时刻的累计字节数，返回最近一次采样的速度，以及是否应当告警
+// 同一段持续偏低只告警一次，告警后重新计时，速度恢复后清除
+func (m *SpeedMonitor) Observe(now time.Time, totalBytes int64) (float64, bool) {
+	if m.lastTime.IsZero() {
+		m.lastTime = now
+		m.lastBytes = totalBytes
+		return 0, false
+	}
+
+	elapsed := now.Sub(m.lastTime)
+	if elapsed < speedSampleInterval {
+		return 0, false
+	}
+
+	speed := float64(totalBytes-m.lastBytes) / elapsed.Seconds()
+	sampleStart := m.lastTime
+	m.lastTime = now
+	m.lastBytes = totalBytes
+
+	if !IsSlowSpeed(m.baseline, speed, m.threshold) {
+		m.slowSince = time.Time{}
+		return speed, false
+	}
+
+	if m.slowSince.IsZero() {
+		m.slowSince = sampleStart
+	}
+	if now.Sub(m.slowSince) < m.window {
+		return speed, false
+	}
+	m.slowSince = now
+	return speed, true
+}
+
+// speedReader 统计读取的字节数并交给速度监控器，告警时调用 onSlow，onSlow 返回错误时中断读取
+type speedReader struct {
+	io.ReadCloser
+	monitor *SpeedMonitor
+	onSlow  func(speed float64) error
+	total   int64
+}
+
+// Read 读取数据并检查速度
+func (sr *speedReader) Read(p []byte) (int, error) {
+	n, err := sr.ReadCloser.Read(p)
+	sr.total += int64(n)
+	if speed, slow := sr.monitor.Observe(time.Now(), sr.total); slow {
+		if slowErr := sr.onSlow(speed); slowErr != nil {
+			return n, slowErr
+		}
+	}
+	return n, err
+}
+
+// SetSpeedBaseline 设置历史平均复制速度（字节/秒），为0时不检测速度异常
+func (fc *FileCopier) SetSpeedBaseline(baseline float64) {
+	fc.speedBaseline = baseline
+}
+
+// monitorStream 为设备文件流加上时段限速和速度监控，速度持续偏低时记录告警，限速时段内不检测速度
+// reconnect 为true且开启了 slow_reconnect 时，读取返回 errSlowTransfer 以便调用方重新打开文件流
+func (fc *FileCopier) monitorStream(file *utils.FileInfo, stream io.ReadCloser, reconnect bool) io.ReadCloser {
+	stream = fc.limitStream(stream)
+	threshold := fc.config.Backup.SlowThreshold
+	if fc.speedBaseline <= 0 || threshold <= 0 || fc.limiter.Limited() {
+		return stream
+	}
+
+	return &speedReader{
+		ReadCloser: stream,
+		monitor:    NewSpeedMonitor(fc.speedBaseline, threshold, SlowSpeedWindow),
+		onSlow: func(speed float64) error {
+			fc.log.Warn("复制速度异常偏低: %s, 当前 %s/s, 历史平均 %s/s",
+				file.RelativePath, utils.FormatBytes(int64(speed)), utils.FormatBytes(int64(fc.speedBaseline)))
+			if reconnect && fc.config.Backup.SlowReconnect {
+				return errSlowTransfer
+			}
+			return nil
+		},
+	}
+}
+
+// copySpeed 计算复制成功的文件的速度（字节/秒）：复制字节数之和除以各文件耗时之和
+func copySpeed(results []*CopyResult) float64 {
+	var bytes int64
+	var duration time.Duration
+	for _, result := range results {
+		if result.Success && result.Duration > 0 {
+			bytes += result.BytesCopied
+			duration += result.Duration
+		}
+	}
+	if duration <= 0 {
+		return 0
+	}
+	return float64(bytes) / duration.Seconds()
+}
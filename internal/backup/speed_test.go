@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestIsSlowSpeed 测试速度偏低的判断
+func TestIsSlowSpeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseline  float64
+		current   float64
+		threshold float64
+		want      bool
+	}{
+		{"低于阈值", 1000, 200, 0.3, true},
+		{"等于阈值不告警", 1000, 300, 0.3, false},
+		{"高于阈值", 1000, 800, 0.3, false},
+		{"没有历史", 0, 10, 0.3, false},
+		{"未开启检测", 1000, 10, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSlowSpeed(tt.baseline, tt.current, tt.threshold); got != tt.want {
+				t.Errorf("IsSlowSpeed() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSpeedMonitor_Observe 测试给定历史均值与逐秒速度序列时的告警时机
+func TestSpeedMonitor_Observe(t *testing.T) {
+	tests := []struct {
+		name       string
+		baseline   float64
+		threshold  float64
+		speeds     []int64 // 第 i 秒（从1开始）内复制的字节数
+		wantAlerts []int   // 应当告警的秒数
+	}{
+		{
+			name:       "持续偏低达到窗口告警",
+			baseline:   1000,
+			threshold:  0.3,
+			speeds:     []int64{1000, 200, 200, 200, 200},
+			wantAlerts: []int{4},
+		},
+		{
+			name:       "短暂偏低不告警",
+			baseline:   1000,
+			threshold:  0.3,
+			speeds:     []int64{1000, 200, 200, 1000, 200, 200, 1000},
+			wantAlerts: nil,
+		},
+		{
+			name:       "持续偏低每个窗口告警一次",
+			baseline:   1000,
+			threshold:  0.3,
+			speeds:     []int64{100, 100, 100, 100, 100, 100, 100},
+			wantAlerts: []int{3, 6},
+		},
+		{
+			name:       "速度恢复后重新计时",
+			baseline:   1000,
+			threshold:  0.3,
+			speeds:     []int64{1000, 200, 200, 200, 200, 1000, 100, 100, 100, 100, 100, 100, 100},
+			wantAlerts: []int{4, 9, 12},
+		},
+		{
+			name:       "没有历史均值不告警",
+			baseline:   0,
+			threshold:  0.3,
+			speeds:     []int64{1, 1, 1, 1, 1},
+			wantAlerts: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := NewSpeedMonitor(tt.baseline, tt.threshold, 3*time.Second)
+			start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+			monitor.Observe(start, 0)
+
+			var total int64
+			var alerts []int
+			for i, bytes := range tt.speeds {
+				// 采样间隔内的读取不计算速度
+				if _, slow := monitor.Observe(start.Add(time.Duration(i)*time.Second+500*time.Millisecond), total+bytes/2); slow {
+					t.Fatalf("第 %d 秒: 采样间隔内不应告警", i+1)
+				}
+
+				total += bytes
+				speed, slow := monitor.Observe(start.Add(time.Duration(i+1)*time.Second), total)
+				if speed != float64(bytes) {
+					t.Errorf("第 %d 秒: 速度 = %v, 期望 %d", i+1, speed, bytes)
+				}
+				if slow {
+					alerts = append(alerts, i+1)
+				}
+			}
+
+			if fmt.Sprint(alerts) != fmt.Sprint(tt.wantAlerts) {
+				t.Errorf("告警时机 = %v, 期望 %v", alerts, tt.wantAlerts)
+			}
+		})
+	}
+}
+
+// TestCopySpeed 测试按成功文件的字节数与耗时计算复制速度
+func TestCopySpeed(t *testing.T) {
+	results := []*CopyResult{
+		{Success: true, BytesCopied: 3000, Duration: 2 * time.Second},
+		{Success: true, BytesCopied: 1000, Duration: 2 * time.Second},
+		{Success: false, BytesCopied: 500, Duration: time.Second},
+		{Skipped: true},
+	}
+	if got := copySpeed(results); got != 1000 {
+		t.Errorf("copySpeed() = %v, 期望 1000", got)
+	}
+	if got := copySpeed(nil); got != 0 {
+		t.Errorf("没有结果时 copySpeed() = %v, 期望 0", got)
+	}
+}
@@ -0,0 +1,85 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// SkipReasonUnstable 文件仍在写入（如正在录音）时的跳过原因
+const SkipReasonUnstable = "文件仍在变化"
+
+// StabilityChecker 复制前的文件稳定性检测
+// 间隔 wait 重新读取一次文件大小和修改时间，两次不一致的文件判定为正在写入；
+// MTP 无法提供可靠大小（大小为0）时，修改时间在最近 window 内的文件也判定为正在写入
+type StabilityChecker struct {
+	wait   time.Duration
+	window time.Duration
+	log    *logger.Logger
+	sleep  func(time.Duration)
+	now    func() time.Time
+}
+
+// NewStabilityChecker 创建稳定性检测器，wait <= 0 时不做检测
+func NewStabilityChecker(wait, window time.Duration, log *logger.Logger) *StabilityChecker {
+	return &StabilityChecker{
+		wait:   wait,
+		window: window,
+		log:    log,
+		sleep:  time.Sleep,
+		now:    time.Now,
+	}
+}
+
+// Check 将文件分为稳定和仍在变化两组，rescan 用于重新读取设备上的文件信息
+func (sc *StabilityChecker) Check(files []*utils.FileInfo, rescan func() ([]*utils.FileInfo, error)) ([]*utils.FileInfo, []*utils.FileInfo, error) {
+	if sc.wait <= 0 || len(files) == 0 {
+		return files, nil, nil
+	}
+
+	sc.log.Debug("等待 %s 后检测文件是否仍在变化...", sc.wait)
+	sc.sleep(sc.wait)
+
+	latestFiles, err := rescan()
+	if err != nil {
+		return files, nil, fmt.Errorf("重新读取文件信息失败: %w", err)
+	}
+
+	latest := make(map[string]*utils.FileInfo, len(latestFiles))
+	for _, file := range latestFiles {
+		latest[file.Path] = file
+	}
+
+	now := sc.now()
+	var stable, unstable []*utils.FileInfo
+	for _, file := range files {
+		if sc.isChanging(file, latest[file.Path], now) {
+			unstable = append(unstable, file)
+		} else {
+			stable = append(stable, file)
+		}
+	}
+
+	return stable, unstable, nil
+}
+
+// isChanging 判断文件在两次读取之间是否发生变化
+func (sc *StabilityChecker) isChanging(before, after *utils.FileInfo, now time.Time) bool {
+	// 第二次读取时文件消失，可能正在被重命名或删除
+	if after == nil {
+		return true
+	}
+
+	if before.Size != after.Size || !before.ModTime.Equal(after.ModTime) {
+		return true
+	}
+
+	// 大小不可靠时，以最近是否被修改判断
+	if after.Size <= 0 && sc.window > 0 && !after.ModTime.IsZero() {
+		return now.Sub(after.ModTime) < sc.window
+	}
+
+	return false
+}
@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// sequenceScanner 按调用次数依次返回不同枚举结果的模拟扫描器
+type sequenceScanner struct {
+	calls   int
+	results [][]*utils.FileInfo
+}
+
+func (ss *sequenceScanner) ScanDeviceFiles(deviceInfo *device.DeviceInfo) ([]*utils.FileInfo, error) {
+	result := ss.results[ss.calls]
+	ss.calls++
+	return result, nil
+}
+
+// TestStabilityChecker_Check 测试两次读取结果不同的文件被判定为仍在变化
+func TestStabilityChecker_Check(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	old := now.Add(-time.Hour)
+
+	testCases := []struct {
+		name     string
+		before   *utils.FileInfo
+		after    *utils.FileInfo
+		unstable bool
+	}{
+		{"大小相同", &utils.FileInfo{Path: "a", Size: 100, ModTime: old}, &utils.FileInfo{Path: "a", Size: 100, ModTime: old}, false},
+		{"大小变化", &utils.FileInfo{Path: "a", Size: 100, ModTime: old}, &utils.FileInfo{Path: "a", Size: 200, ModTime: old}, true},
+		{"修改时间变化", &utils.FileInfo{Path: "a", Size: 100, ModTime: old}, &utils.FileInfo{Path: "a", Size: 100, ModTime: now}, true},
+		{"文件消失", &utils.FileInfo{Path: "a", Size: 100, ModTime: old}, nil, true},
+		{"大小不可靠且最近修改", &utils.FileInfo{Path: "a", ModTime: now.Add(-3 * time.Second)}, &utils.FileInfo{Path: "a", ModTime: now.Add(-3 * time.Second)}, true},
+		{"大小不可靠但早已修改", &utils.FileInfo{Path: "a", ModTime: old}, &utils.FileInfo{Path: "a", ModTime: old}, false},
+		{"大小可靠时不看修改时间", &utils.FileInfo{Path: "a", Size: 100, ModTime: now.Add(-time.Second)}, &utils.FileInfo{Path: "a", Size: 100, ModTime: now.Add(-time.Second)}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewStabilityChecker(2*time.Second, 10*time.Second, logger.NewLogger(false))
+			var slept time.Duration
+			checker.sleep = func(d time.Duration) { slept = d }
+			checker.now = func() time.Time { return now }
+
+			stable, unstable, err := checker.Check([]*utils.FileInfo{tc.before}, func() ([]*utils.FileInfo, error) {
+				if tc.after == nil {
+					return nil, nil
+				}
+				return []*utils.FileInfo{tc.after}, nil
+			})
+			if err != nil {
+				t.Fatalf("检测失败: %v", err)
+			}
+			if slept != 2*time.Second {
+				t.Errorf("应等待 2s 后再次读取，实际 %s", slept)
+			}
+			if tc.unstable && (len(unstable) != 1 || len(stable) != 0) {
+				t.Errorf("文件应判定为仍在变化")
+			}
+			if !tc.unstable && (len(stable) != 1 || len(unstable) != 0) {
+				t.Errorf("文件应判定为稳定")
+			}
+		})
+	}
+}
+
+// TestStabilityChecker_Disabled 测试等待间隔为0时不做检测
+func TestStabilityChecker_Disabled(t *testing.T) {
+	checker := NewStabilityChecker(0, 10*time.Second, logger.NewLogger(false))
+	files := []*utils.FileInfo{{Path: "a", Size: 100}}
+
+	stable, unstable, err := checker.Check(files, func() ([]*utils.FileInfo, error) {
+		t.Fatal("未启用检测时不应重新读取")
+		return nil, nil
+	})
+	if err != nil || len(stable) != 1 || len(unstable) != 0 {
+		t.Errorf("未启用检测时所有文件应视为稳定")
+	}
+}
+
+// TestBackupManager_FilterUnstableFiles 测试管理器跳过仍在变化的文件并正常保留稳定文件
+func TestBackupManager_FilterUnstableFiles(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour)
+	first := []*utils.FileInfo{
+		{Path: "device\\recording.opus", RelativePath: "recording.opus", Name: "recording.opus", Size: 100, ModTime: modTime},
+		{Path: "device\\done.opus", RelativePath: "done.opus", Name: "done.opus", Size: 300, ModTime: modTime},
+	}
+	second := []*utils.FileInfo{
+		{Path: "device\\recording.opus", RelativePath: "recording.opus", Name: "recording.opus", Size: 180, ModTime: modTime},
+		{Path: "device\\done.opus", RelativePath: "done.opus", Name: "done.opus", Size: 300, ModTime: modTime},
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Backup.StabilityWait = "10ms"
+	log := logger.NewLogger(false)
+	scanner := &sequenceScanner{results: [][]*utils.FileInfo{first, second}}
+	bm := &BackupManager{
+		config:  cfg,
+		log:     log,
+		tracker: storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log),
+		quiet:   true,
+		scanner: scanner,
+	}
+	deviceInfo := &device.DeviceInfo{DeviceID: "test_device"}
+
+	files, err := bm.scanDeviceFiles(nil, deviceInfo)
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	stable, skipped := bm.filterUnstableFiles(nil, deviceInfo, files)
+	if scanner.calls != 2 {
+		t.Errorf("稳定性检测应重新枚举一次，实际枚举 %d 次", scanner.calls)
+	}
+	if len(stable) != 1 || stable[0].Name != "done.opus" {
+		t.Errorf("大小未变化的文件应正常复制，实际: %v", fileNames(stable))
+	}
+	if len(skipped) != 1 || skipped[0].File.Name != "recording.opus" {
+		t.Fatalf("大小变化的文件应被跳过，实际 %d 个", len(skipped))
+	}
+	if !skipped[0].Skipped || skipped[0].SkipReason != SkipReasonUnstable {
+		t.Errorf("跳过原因错误: %s", skipped[0].SkipReason)
+	}
+}
+
+// fileNames 获取文件名列表，用于错误输出
+func fileNames(files []*utils.FileInfo) string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return fmt.Sprint(names)
+}
@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// SkipReasonStripped 匹配 backup.strip_patterns 的设备垃圾文件的跳过原因
+const SkipReasonStripped = "设备垃圾文件"
+
+// matchStripPattern 检查文件是否匹配任一垃圾文件规则，返回匹配的规则
+// 不含 / 的规则匹配文件名，否则匹配相对路径，不区分大小写
+func matchStripPattern(patterns []string, file *utils.FileInfo) (string, bool) {
+	if file == nil {
+		return "", false
+	}
+	for _, pattern := range patterns {
+		if pattern != "" && globMatch(pattern, file) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// CleanStripped 把目标目录中匹配垃圾文件规则的历史遗留文件移入回收站，返回移动的文件数
+// 文件有该设备的备份记录时一并移除记录；回收站目录不参与匹配，遍历不进入符号链接指向的目录
+func (mc *MirrorCleaner) CleanStripped(deviceID string, patterns []string) (int, error) {
+	if len(patterns) == 0 {
+		return 0, nil
+	}
+	if _, err := os.Stat(mc.baseDir); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	byTarget := make(map[string]storage.BackupRecord)
+	for _, record := range mc.tracker.GetRecordsByDevice(deviceID) {
+		byTarget[filepath.Clean(record.TargetPath)] = record
+	}
+
+	trashDir := filepath.Clean(mc.trash.Dir())
+	var stripped []string
+	err := filepath.WalkDir(mc.baseDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if filepath.Clean(path) == trashDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(mc.baseDir, path)
+		if err != nil {
+			return nil
+		}
+		file := &utils.FileInfo{Name: entry.Name(), RelativePath: strings.ReplaceAll(rel, string(filepath.Separator), "/")}
+		if _, ok := matchStripPattern(patterns, file); ok {
+			stripped = append(stripped, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deletedAt := mc.now()
+	moved := 0
+	for _, path := range stripped {
+		if record, ok := byTarget[filepath.Clean(path)]; ok {
+			if err := mc.moveToTrash(record, deletedAt); err != nil {
+				mc.log.Warn("移入回收站失败: %s, %v", path, err)
+				continue
+			}
+			if err := mc.tracker.RemoveRecord(record.SourcePath); err != nil {
+				mc.log.Warn("移除备份记录失败: %s, %v", record.SourcePath, err)
+			}
+		} else if _, err := mc.trash.Move(path, deletedAt, nil); err != nil {
+			mc.log.Warn("移入回收站失败: %s, %v", path, err)
+			continue
+		}
+		moved++
+		mc.log.Info("设备垃圾文件移入回收站: %s", path)
+	}
+	return moved, nil
+}
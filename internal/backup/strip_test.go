@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_SkipStripped 测试匹配垃圾文件规则的文件即使扩展名匹配、强制复制也被跳过
+func TestFileCopier_SkipStripped(t *testing.T) {
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			FileExtensions: []string{".opus", ".db"},
+			StripPatterns:  []string{"._*", "*.DB", "SYSTEM/*"},
+		},
+	}
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+
+	tests := []struct {
+		name     string
+		relative string
+		stripped bool
+	}{
+		{"资源分叉文件", "录音笔文件/._rec.opus", true},
+		{"索引数据库不区分大小写", "录音笔文件/index.db", true},
+		{"按相对路径匹配", "SYSTEM/cache.opus", true},
+		{"普通录音", "录音笔文件/rec.opus", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &utils.FileInfo{Path: "device\\" + tt.relative, RelativePath: tt.relative, Name: filepath.Base(tt.relative)}
+			if _, ok := matchStripPattern(cfg.Backup.StripPatterns, file); ok != tt.stripped {
+				t.Fatalf("匹配结果 = %v，期望 %v", ok, tt.stripped)
+			}
+			if !tt.stripped {
+				return
+			}
+			result := copier.CopyFile(file, true)
+			if !result.Skipped || result.SkipReason != SkipReasonStripped {
+				t.Errorf("垃圾文件应被跳过，实际: skipped=%v, reason=%s", result.Skipped, result.SkipReason)
+			}
+		})
+	}
+}
+
+// TestMirrorCleaner_CleanStripped 测试镜像清理把目标中已存在的垃圾文件移入回收站，并移除对应的备份记录
+func TestMirrorCleaner_CleanStripped(t *testing.T) {
+	baseDir, tracker := newMirrorFixture(t, []string{"a.opus", "._a.opus"})
+	// 没有备份记录的历史遗留文件
+	untracked := filepath.Join(baseDir, "录音笔文件", "Thumbs.db")
+	if err := os.WriteFile(untracked, []byte("thumbs"), 0644); err != nil {
+		t.Fatalf("创建垃圾文件失败: %v", err)
+	}
+
+	cleaner := NewMirrorCleaner(baseDir, tracker, true, logger.NewLogger(false))
+	cleaner.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local) }
+
+	moved, err := cleaner.CleanStripped("test_device", []string{"._*", "*.db"})
+	if err != nil {
+		t.Fatalf("清理垃圾文件失败: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("期望移入 2 个垃圾文件，实际 %d", moved)
+	}
+
+	for _, name := range []string{"._a.opus", "Thumbs.db"} {
+		if _, err := os.Stat(filepath.Join(baseDir, "录音笔文件", name)); !os.IsNotExist(err) {
+			t.Errorf("垃圾文件 %s 应已移出目标目录", name)
+		}
+		if _, err := os.Stat(filepath.Join(baseDir, TrashDirName, "20240501_100000", "录音笔文件", name)); err != nil {
+			t.Errorf("垃圾文件 %s 应在回收站中: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "录音笔文件", "a.opus")); err != nil {
+		t.Errorf("普通备份不应被清理: %v", err)
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp("device\\._a.opus"); backedUp {
+		t.Error("垃圾文件的备份记录应被移除")
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp("device\\a.opus"); !backedUp {
+		t.Error("普通备份的记录不应被移除")
+	}
+
+	// 再次清理时回收站中的文件不参与匹配
+	if moved, err := cleaner.CleanStripped("test_device", []string{"._*", "*.db"}); err != nil || moved != 0 {
+		t.Errorf("再次清理应没有可移动的文件，实际 %d, %v", moved, err)
+	}
+}
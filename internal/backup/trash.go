@@ -0,0 +1,370 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// trashBatchLayout 回收站批次目录名的时间格式，同一次清理移入的文件放在同一批次
+const trashBatchLayout = "20060102_150405"
+
+// trashManifestName 批次目录中记录原路径等元数据的文件
+const trashManifestName = ".trash.json"
+
+// ErrTrashItemNotFound 回收站中没有指定的条目
+var ErrTrashItemNotFound = errors.New("回收站中没有该条目")
+
+// TrashItem 回收站中的一个文件
+type TrashItem struct {
+	ID           string                `json:"id"`            // 批次/批次内相对路径（以 / 分隔），用于恢复
+	Batch        string                `json:"batch"`         // 批次目录名
+	OriginalPath string                `json:"original_path"` // 删除前的路径，恢复时移回此处
+	DeletedAt    time.Time             `json:"deleted_at"`
+	Size         int64                 `json:"size"`
+	Record       *storage.BackupRecord `json:"record,omitempty"` // 删除时移除的备份记录，恢复时重新登记
+}
+
+// Trash 本地回收站：删除的备份先移入回收目录，保留原路径和删除时间，可恢复，超过保留期自动清理
+// 目录结构为 <dir>/<批次>/<相对 baseDir 的路径>，批次目录中的 .trash.json 记录各文件的元数据
+type Trash struct {
+	dir       string
+	baseDir   string        // 计算文件在批次内相对路径的基准目录
+	retention time.Duration // 保留期，0 表示不自动清理
+	log       *logger.Logger
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewTrash 创建回收站，dir 为空时使用 baseDir 下的 .trash
+func NewTrash(dir, baseDir string, retention time.Duration, log *logger.Logger) *Trash {
+	if dir == "" {
+		dir = filepath.Join(baseDir, TrashDirName)
+	}
+	return &Trash{dir: dir, baseDir: baseDir, retention: retention, log: log, now: time.Now}
+}
+
+// NewTrashFromConfig 按 backup.trash_dir / backup.trash_retention 创建目标目录的回收站
+func NewTrashFromConfig(cfg *config.Config, log *logger.Logger) *Trash {
+	retention, err := utils.ParseDuration(cfg.Backup.TrashRetention)
+	if err != nil {
+		retention = 0
+	}
+	return NewTrash(cfg.Backup.TrashDir, cfg.Target.BaseDirectory, retention, log)
+}
+
+// Dir 回收站目录
+func (t *Trash) Dir() string {
+	return t.dir
+}
+
+// Move 将文件移入 deletedAt 对应的批次并记录原路径，文件已不存在时返回 nil
+// record 为删除时移除的备份记录，可为 nil
+func (t *Trash) Move(path string, deletedAt time.Time, record *storage.BackupRecord) (*TrashItem, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+
+	relativePath, err := filepath.Rel(t.baseDir, path)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		// 不在目标目录内的文件只保留文件名
+		relativePath = filepath.Base(path)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	batch := deletedAt.Format(trashBatchLayout)
+	batchDir := filepath.Join(t.dir, batch)
+	trashPath := filepath.Join(batchDir, relativePath)
+	if err := os.MkdirAll(filepath.Dir(trashPath), 0755); err != nil {
+		return nil, fmt.Errorf("创建回收目录失败: %w", err)
+	}
+	// 设为只读的备份先清除只读，跨卷移动时才能删除源文件，回收站中的文件也能被清理
+	if err := utils.ClearReadOnly(path); err != nil {
+		return nil, err
+	}
+	if err := utils.MoveFile(path, trashPath); err != nil {
+		return nil, fmt.Errorf("移动文件失败: %w", err)
+	}
+
+	item := TrashItem{
+		ID:           batch + "/" + filepath.ToSlash(relativePath),
+		Batch:        batch,
+		OriginalPath: path,
+		DeletedAt:    deletedAt,
+		Size:         info.Size(),
+		Record:       record,
+	}
+	items, err := t.loadBatch(batch)
+	if err != nil {
+		return nil, err
+	}
+	items = append(removeTrashItem(items, item.ID), item)
+	if err := t.saveBatch(batch, items); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// List 列出回收站中的文件，按删除时间排序
+func (t *Trash) List() ([]TrashItem, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	batches, err := t.batches()
+	if err != nil {
+		return nil, err
+	}
+	var all []TrashItem
+	for _, batch := range batches {
+		items, err := t.loadBatch(batch)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].DeletedAt.Equal(all[j].DeletedAt) {
+			return all[i].DeletedAt.Before(all[j].DeletedAt)
+		}
+		return all[i].ID < all[j].ID
+	})
+	return all, nil
+}
+
+// Restore 将条目移回原路径，id 为批次名时恢复整个批次；恢复备份文件时同时恢复其元数据文件
+// 原路径已存在文件时不覆盖，返回错误；返回成功恢复的条目
+func (t *Trash) Restore(id string) ([]TrashItem, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id = strings.Trim(filepath.ToSlash(id), "/")
+	batch, _, _ := strings.Cut(id, "/")
+	items, err := t.loadBatch(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []TrashItem
+	for _, item := range items {
+		if item.ID == id || batch == id || item.ID == id+SidecarSuffix {
+			selected = append(selected, item)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTrashItemNotFound, id)
+	}
+
+	var restored []TrashItem
+	var errs []error
+	for _, item := range selected {
+		if err := t.restoreItem(item); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.ID, err))
+			continue
+		}
+		restored = append(restored, item)
+		items = removeTrashItem(items, item.ID)
+	}
+	if err := t.saveBatch(batch, items); err != nil {
+		errs = append(errs, err)
+	}
+	return restored, errors.Join(errs...)
+}
+
+// restoreItem 将一个条目移回原路径
+func (t *Trash) restoreItem(item TrashItem) error {
+	if _, err := os.Lstat(item.OriginalPath); err == nil {
+		return fmt.Errorf("原路径已存在文件，未覆盖: %s", item.OriginalPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := utils.MoveFile(t.itemPath(item), item.OriginalPath); err != nil {
+		return fmt.Errorf("移动文件失败: %w", err)
+	}
+	return nil
+}
+
+// Empty 清空回收站，返回删除的文件数
+func (t *Trash) Empty() (int, error) {
+	return t.remove(func(TrashItem) bool { return true })
+}
+
+// Purge 删除超过保留期的文件，返回删除的文件数；保留期为 0 时不清理
+func (t *Trash) Purge() (int, error) {
+	if t.retention <= 0 {
+		return 0, nil
+	}
+	cutoff := t.now().Add(-t.retention)
+	removed, err := t.remove(func(item TrashItem) bool { return item.DeletedAt.Before(cutoff) })
+	if removed > 0 {
+		t.log.Info("回收站清理了 %d 个超过保留期 %s 的文件", removed, t.retention)
+	}
+	return removed, err
+}
+
+// remove 删除满足 match 的条目，批次中没有剩余条目时删除批次目录
+func (t *Trash) remove(match func(TrashItem) bool) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	batches, err := t.batches()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var errs []error
+	for _, batch := range batches {
+		items, err := t.loadBatch(batch)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var kept []TrashItem
+		for _, item := range items {
+			if !match(item) {
+				kept = append(kept, item)
+				continue
+			}
+			if err := os.Remove(t.itemPath(item)); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("删除 %s 失败: %w", item.ID, err))
+				kept = append(kept, item)
+				continue
+			}
+			removed++
+		}
+		if err := t.saveBatch(batch, kept); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// batches 列出批次目录，回收站目录不存在时返回空
+func (t *Trash) batches() ([]string, error) {
+	entries, err := os.ReadDir(t.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取回收站失败: %w", err)
+	}
+	var batches []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			batches = append(batches, entry.Name())
+		}
+	}
+	return batches, nil
+}
+
+// itemPath 条目在回收站中的路径
+func (t *Trash) itemPath(item TrashItem) string {
+	return filepath.Join(t.dir, filepath.FromSlash(item.ID))
+}
+
+// loadBatch 读取批次的元数据；没有元数据的旧批次按目录内的文件生成条目，原路径按 baseDir 推断
+func (t *Trash) loadBatch(batch string) ([]TrashItem, error) {
+	if batch == "" || batch == "." || batch == ".." || strings.ContainsAny(batch, `/\`) {
+		return nil, fmt.Errorf("%w: %s", ErrTrashItemNotFound, batch)
+	}
+	batchDir := filepath.Join(t.dir, batch)
+	data, err := os.ReadFile(filepath.Join(batchDir, trashManifestName))
+	if err == nil {
+		var items []TrashItem
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("解析回收站元数据失败 (%s): %w", batch, err)
+		}
+		return items, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取回收站元数据失败 (%s): %w", batch, err)
+	}
+
+	deletedAt, parseErr := time.ParseInLocation(trashBatchLayout, batch, time.Local)
+	var items []TrashItem
+	walkErr := filepath.WalkDir(batchDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(batchDir, path)
+		if err != nil {
+			return err
+		}
+		item := TrashItem{
+			ID:           batch + "/" + filepath.ToSlash(relativePath),
+			Batch:        batch,
+			OriginalPath: filepath.Join(t.baseDir, relativePath),
+			DeletedAt:    deletedAt,
+		}
+		if parseErr != nil {
+			if info, err := entry.Info(); err == nil {
+				item.DeletedAt = info.ModTime()
+			}
+		}
+		if info, err := entry.Info(); err == nil {
+			item.Size = info.Size()
+		}
+		items = append(items, item)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("读取回收站失败 (%s): %w", batch, walkErr)
+	}
+	return items, nil
+}
+
+// saveBatch 保存批次的元数据，没有剩余条目时删除批次目录
+func (t *Trash) saveBatch(batch string, items []TrashItem) error {
+	batchDir := filepath.Join(t.dir, batch)
+	if len(items) == 0 {
+		if err := os.RemoveAll(batchDir); err != nil {
+			return fmt.Errorf("删除回收站批次失败 (%s): %w", batch, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化回收站元数据失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(batchDir, trashManifestName), data, 0644); err != nil {
+		return fmt.Errorf("写入回收站元数据失败 (%s): %w", batch, err)
+	}
+	return nil
+}
+
+// removeTrashItem 返回去掉 id 对应条目后的列表
+func removeTrashItem(items []TrashItem, id string) []TrashItem {
+	kept := items[:0:0]
+	for _, item := range items {
+		if item.ID != id {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// newTrashFixture 创建目标目录和其中的备份文件，返回目标目录与回收站
+func newTrashFixture(t *testing.T, names ...string) (string, *Trash) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	for _, name := range names {
+		path := filepath.Join(baseDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return baseDir, NewTrash("", baseDir, 0, logger.NewLogger(false))
+}
+
+// TestTrash_MoveAndRestore 测试删除进回收站并记录原路径，restore 恢复到原路径
+func TestTrash_MoveAndRestore(t *testing.T) {
+	baseDir, trash := newTrashFixture(t, filepath.Join("2024", "a.opus"), filepath.Join("2024", "a.opus.json"), "b.opus")
+	deletedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	aPath := filepath.Join(baseDir, "2024", "a.opus")
+	record := &storage.BackupRecord{SourcePath: "device\\a.opus", TargetPath: aPath, FileSize: 11, Success: true}
+
+	item, err := trash.Move(aPath, deletedAt, record)
+	if err != nil {
+		t.Fatalf("移入回收站失败: %v", err)
+	}
+	if item.ID != "20240501_100000/2024/a.opus" || item.OriginalPath != aPath || item.Size != int64(len("2024/a.opus")) {
+		t.Errorf("回收站条目 = %+v", item)
+	}
+	if _, err := trash.Move(SidecarPath(aPath), deletedAt, nil); err != nil {
+		t.Fatalf("移入回收站失败: %v", err)
+	}
+	if _, err := trash.Move(filepath.Join(baseDir, "b.opus"), deletedAt.Add(time.Hour), nil); err != nil {
+		t.Fatalf("移入回收站失败: %v", err)
+	}
+	if item, err := trash.Move(filepath.Join(baseDir, "missing.opus"), deletedAt, nil); err != nil || item != nil {
+		t.Errorf("不存在的文件应视为成功: %+v, %v", item, err)
+	}
+
+	if _, err := os.Stat(aPath); !os.IsNotExist(err) {
+		t.Error("文件应从原路径移走")
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, TrashDirName, "20240501_100000", "2024", "a.opus")); err != nil {
+		t.Errorf("文件应移入回收站: %v", err)
+	}
+
+	items, err := trash.List()
+	if err != nil {
+		t.Fatalf("列出回收站失败: %v", err)
+	}
+	if len(items) != 3 || items[2].ID != "20240501_110000/b.opus" {
+		t.Fatalf("回收站条目 = %+v", items)
+	}
+	if items[0].Record == nil || items[0].Record.SourcePath != "device\\a.opus" {
+		t.Errorf("回收站应保存被移除的备份记录: %+v", items[0])
+	}
+
+	// 恢复备份文件时同时恢复元数据文件
+	restored, err := trash.Restore("20240501_100000/2024/a.opus")
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Errorf("期望恢复 2 个文件，实际 %d 个", len(restored))
+	}
+	for _, path := range []string{aPath, SidecarPath(aPath)} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("文件应恢复到原路径 %s: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, TrashDirName, "20240501_100000")); !os.IsNotExist(err) {
+		t.Error("恢复完的批次目录应删除")
+	}
+	if _, err := trash.Restore("20240501_100000/2024/a.opus"); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Errorf("已恢复的条目应不存在: %v", err)
+	}
+
+	// 原路径已有文件时不覆盖
+	if err := os.WriteFile(filepath.Join(baseDir, "b.opus"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trash.Restore("20240501_110000"); err == nil {
+		t.Error("原路径已存在文件时应拒绝恢复")
+	}
+	if data, _ := os.ReadFile(filepath.Join(baseDir, "b.opus")); string(data) != "new" {
+		t.Error("不应覆盖原路径上的文件")
+	}
+}
+
+// TestTrash_Empty 测试清空回收站
+func TestTrash_Empty(t *testing.T) {
+	baseDir, trash := newTrashFixture(t, "a.opus", "b.opus")
+	deletedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	for i, name := range []string{"a.opus", "b.opus"} {
+		if _, err := trash.Move(filepath.Join(baseDir, name), deletedAt.Add(time.Duration(i)*time.Hour), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := trash.Empty()
+	if err != nil || removed != 2 {
+		t.Fatalf("清空回收站 = %d, %v，期望删除 2 个", removed, err)
+	}
+	if items, _ := trash.List(); len(items) != 0 {
+		t.Errorf("清空后回收站应为空: %+v", items)
+	}
+	if entries, _ := os.ReadDir(trash.Dir()); len(entries) != 0 {
+		t.Errorf("清空后不应留下批次目录: %d 个", len(entries))
+	}
+}
+
+// TestTrash_Purge 测试超过保留期的文件自动清理，旧版本没有元数据的批次按目录名推断删除时间
+func TestTrash_Purge(t *testing.T) {
+	baseDir, _ := newTrashFixture(t, "old.opus", "new.opus")
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)
+	trash := NewTrash(filepath.Join(t.TempDir(), "trash"), baseDir, 7*24*time.Hour, logger.NewLogger(false))
+	trash.now = func() time.Time { return now }
+
+	if _, err := trash.Move(filepath.Join(baseDir, "old.opus"), now.AddDate(0, 0, -8), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trash.Move(filepath.Join(baseDir, "new.opus"), now.AddDate(0, 0, -1), nil); err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(trash.Dir(), now.AddDate(0, 0, -30).Format(trashBatchLayout), "录音笔文件", "legacy.opus")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("legacy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := trash.List()
+	if err != nil || len(items) != 3 {
+		t.Fatalf("回收站条目 = %+v, %v", items, err)
+	}
+	if items[0].OriginalPath != filepath.Join(baseDir, "录音笔文件", "legacy.opus") {
+		t.Errorf("旧批次的原路径应按目标目录推断: %s", items[0].OriginalPath)
+	}
+
+	removed, err := trash.Purge()
+	if err != nil || removed != 2 {
+		t.Fatalf("清理过期文件 = %d, %v，期望删除 2 个", removed, err)
+	}
+	items, _ = trash.List()
+	if len(items) != 1 || items[0].OriginalPath != filepath.Join(baseDir, "new.opus") {
+		t.Errorf("未过期的文件应保留: %+v", items)
+	}
+
+	// 保留期为 0 时不清理
+	trash.retention = 0
+	if removed, _ := trash.Purge(); removed != 0 {
+		t.Errorf("保留期为 0 时不应清理，实际删除 %d 个", removed)
+	}
+}
@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 文件名模板占位符
+const (
+	PlaceholderName = "{name}" // 原文件名，不含扩展名
+	PlaceholderExt  = "{ext}"  // 扩展名，不含点号
+	PlaceholderDate = "{date}" // 录音日期，如 20240315
+	PlaceholderTime = "{time}" // 录音时间，如 143022
+)
+
+// renameByTypeRule 按扩展名规则的文件名模板重命名相对路径中的文件名，模板为空时保持不变
+func renameByTypeRule(rule config.TypeRule, file *utils.FileInfo, relativePath string) string {
+	if rule.Rename == "" {
+		return relativePath
+	}
+	dir := ""
+	if i := strings.LastIndexAny(relativePath, "/\\"); i >= 0 {
+		dir = relativePath[:i+1]
+	}
+	return dir + expandRename(rule.Rename, file)
+}
+
+// expandRename 按文件名和录音时间展开文件名模板，录音时间未知时日期和时间使用 unknown
+func expandRename(template string, file *utils.FileInfo) string {
+	ext := filepath.Ext(file.Name)
+	date, clock := UnknownTimeDir, UnknownTimeDir
+	if recordedAt := RecordingTime(file); !recordedAt.IsZero() {
+		date = recordedAt.Format("20060102")
+		clock = recordedAt.Format("150405")
+	}
+
+	replacer := strings.NewReplacer(
+		PlaceholderName, strings.TrimSuffix(file.Name, ext),
+		PlaceholderExt, strings.TrimPrefix(ext, "."),
+		PlaceholderDate, date,
+		PlaceholderTime, clock,
+	)
+	return replacer.Replace(template)
+}
@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_GetTargetPath_PerType 测试不同扩展名按各自规则落到不同目录，未配置的类型走全局规则
+func TestFileCopier_GetTargetPath_PerType(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), "backups")
+	modTime := time.Date(2024, 3, 15, 9, 30, 0, 0, time.Local)
+
+	testCases := []struct {
+		name         string
+		pathTemplate string
+		file         *utils.FileInfo
+		expectedPath string
+	}{
+		{
+			name:         "opus进入独立目录",
+			file:         &utils.FileInfo{RelativePath: "录音笔文件\\REC001.opus", Name: "REC001.opus"},
+			expectedPath: filepath.Join(baseDir, "A", "录音笔文件", "REC001.opus"),
+		},
+		{
+			name:         "wav进入独立目录并按模板重命名",
+			file:         &utils.FileInfo{RelativePath: "录音笔文件\\REC_20240316_201500.WAV", Name: "REC_20240316_201500.WAV"},
+			expectedPath: filepath.Join(baseDir, "B", "录音笔文件", "20240316_201500_REC_20240316_201500.WAV"),
+		},
+		{
+			name:         "文件名无时间戳时使用修改时间",
+			file:         &utils.FileInfo{RelativePath: "memo.wav", Name: "memo.wav", ModTime: modTime},
+			expectedPath: filepath.Join(baseDir, "B", "20240315_093000_memo.wav"),
+		},
+		{
+			name:         "录音时间未知",
+			file:         &utils.FileInfo{RelativePath: "memo.wav", Name: "memo.wav"},
+			expectedPath: filepath.Join(baseDir, "B", "unknown_unknown_memo.wav"),
+		},
+		{
+			name:         "未配置的类型走全局规则",
+			file:         &utils.FileInfo{RelativePath: "录音笔文件\\song.mp3", Name: "song.mp3"},
+			expectedPath: filepath.Join(baseDir, "录音笔文件", "song.mp3"),
+		},
+		{
+			name:         "未启用的规则走全局规则",
+			file:         &utils.FileInfo{RelativePath: "录音笔文件\\call.m4a", Name: "call.m4a"},
+			expectedPath: filepath.Join(baseDir, "录音笔文件", "call.m4a"),
+		},
+		{
+			name:         "类型目录位于目录模板之外",
+			pathTemplate: "{weektype}",
+			file:         &utils.FileInfo{RelativePath: "REC_20240316_201500.opus", Name: "REC_20240316_201500.opus"},
+			expectedPath: filepath.Join(baseDir, "A", "weekend", "REC_20240316_201500.opus"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Backup: config.BackupConfig{
+					PreserveStructure: true,
+					PerType: map[string]config.TypeRule{
+						".opus": {Enabled: true, Directory: "A"},
+						".wav":  {Enabled: true, Directory: "B", Rename: "{date}_{time}_{name}.{ext}"},
+						".m4a":  {Enabled: false, Directory: "C"},
+					},
+				},
+				Target: config.TargetConfig{
+					BaseDirectory: baseDir,
+					PathTemplate:  tc.pathTemplate,
+					DayParts:      config.DefaultConfig().Target.DayParts,
+				},
+			}
+
+			copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+			targetPath, err := copier.getTargetPath(tc.file)
+			if err != nil {
+				t.Fatalf("获取目标路径失败: %v", err)
+			}
+			if targetPath != tc.expectedPath {
+				t.Errorf("期望目标路径为 '%s'，实际为 '%s'", tc.expectedPath, targetPath)
+			}
+		})
+	}
+}
+
+// TestFileCopier_ArchiveEntryName_PerType 测试归档条目与本地目标使用相同的按类型目录和重命名规则
+func TestFileCopier_ArchiveEntryName_PerType(t *testing.T) {
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			PreserveStructure: true,
+			PerType: map[string]config.TypeRule{
+				".wav": {Enabled: true, Directory: "B", Rename: "{date}_{time}_{name}.{ext}"},
+			},
+		},
+		Target: config.TargetConfig{
+			BaseDirectory: filepath.Join(t.TempDir(), "backups"),
+			DayParts:      config.DefaultConfig().Target.DayParts,
+		},
+	}
+	copier := NewFileCopier(cfg, logger.NewLogger(false), NewMockTracker(), &device.DeviceInfo{DeviceID: "test"})
+	file := &utils.FileInfo{RelativePath: "录音笔文件\\REC_20240316_201500.WAV", Name: "REC_20240316_201500.WAV"}
+
+	entryName := strings.ReplaceAll(copier.getArchiveEntryName(file), "\\", "/")
+	if expected := "B/录音笔文件/20240316_201500_REC_20240316_201500.WAV"; entryName != expected {
+		t.Errorf("期望归档条目为 '%s'，实际为 '%s'", expected, entryName)
+	}
+
+	targetPath, err := copier.getTargetPath(file)
+	if err != nil {
+		t.Fatalf("获取目标路径失败: %v", err)
+	}
+	expectedTarget := filepath.Join(cfg.Target.BaseDirectory, "B", "录音笔文件", "20240316_201500_REC_20240316_201500.WAV")
+	if targetPath != expectedTarget {
+		t.Errorf("期望目标路径为 '%s'，实际为 '%s'", expectedTarget, targetPath)
+	}
+}
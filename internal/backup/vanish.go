@@ -0,0 +1,58 @@
+package backup
+
+import (
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// SkipReasonSourceVanished 复制前或复制中源文件已从设备上删除的跳过原因，不计为复制失败
+const SkipReasonSourceVanished = "源文件在复制中消失"
+
+// sourceVanished 通过设备访问器确认源文件已从设备上消失，访问器不支持检查或检查失败时返回 false，复制错误本身不作为依据
+// 注入了设备访问接口时只使用它；否则 allowPowerShell 为 true 时启动PowerShell检查，只用于复制失败后的确认
+func (fc *FileCopier) sourceVanished(file *utils.FileInfo, allowPowerShell bool) bool {
+	var exister device.FileExister
+	if fc.deviceAccessor != nil {
+		exister, _ = fc.deviceAccessor.(device.FileExister)
+	} else if allowPowerShell && fc.psAccessor != nil {
+		exister = fc.psAccessor
+	}
+	if exister == nil {
+		return false
+	}
+
+	exists, err := exister.Exists(file.Path)
+	if err != nil {
+		fc.log.Debug("检查源文件是否存在失败: %s, %v", file.RelativePath, err)
+		return false
+	}
+	return !exists
+}
+
+// skipVanished 将结果标记为源文件消失而跳过
+func (fc *FileCopier) skipVanished(result *CopyResult) *CopyResult {
+	result.Skipped = true
+	result.SkipReason = SkipReasonSourceVanished
+	fc.log.Warn("源文件已不在设备上，跳过: %s", result.File.RelativePath)
+	return result
+}
+
+// classifyVanished 复制失败后确认源文件是否已被删除，是则清理断点，改为跳过
+func (fc *FileCopier) classifyVanished(result *CopyResult) {
+	if result.Success || result.Skipped || result.Error == nil {
+		return
+	}
+	if !fc.sourceVanished(result.File, true) {
+		return
+	}
+
+	if fc.resumeManager != nil {
+		if err := fc.resumeManager.ClearResumeInfo(result.File.Path); err != nil {
+			fc.log.Warn("清理断点信息失败: %v", err)
+		}
+	}
+
+	fc.log.Debug("源文件在复制中消失，原错误: %v", result.Error)
+	result.Error = nil
+	fc.skipVanished(result)
+}
@@ -0,0 +1,101 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestFileCopier_SourceVanished 测试源文件在复制前或复制中被删除、且访问器确认文件已不存在时标记为源文件消失并跳过，
+// 其他读取失败或访问器无法确认时仍计为失败
+func TestFileCopier_SourceVanished(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件\\"
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+
+	tests := []struct {
+		name        string
+		resume      bool
+		hideExists  bool                               // 访问器不支持 Exists，无法确认源文件消失
+		setup       func(fake *device.FakeMTPAccessor) // 在 b.opus 上模拟的情况
+		wantSkipped bool
+	}{
+		{"复制中被删", false, false, func(fake *device.FakeMTPAccessor) { fake.VanishAt(base+"b.opus", 4096) }, true},
+		{"断点续传时复制中被删", true, false, func(fake *device.FakeMTPAccessor) { fake.VanishAt(base+"b.opus", 4096) }, true},
+		{"不支持Exists时计为失败", false, true, func(fake *device.FakeMTPAccessor) { fake.VanishAt(base+"b.opus", 4096) }, false},
+		{"复制前已删除", false, false, func(fake *device.FakeMTPAccessor) { fake.DeleteFile(base + "b.opus") }, true},
+		{"普通读取失败", false, false, func(fake *device.FakeMTPAccessor) { fake.FailStream(base+"b.opus", 10) }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.NewLogger(false)
+			cfg := config.DefaultConfig()
+			cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+			cfg.Backup.EnableResume = false
+			cfg.Backup.RangeDownload.Enabled = false
+			cfg.Backup.MaxConcurrent = 1
+
+			deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+			fake := device.NewFakeMTPAccessor(deviceInfo)
+			modTime := time.Now().Add(-time.Hour)
+			var files []*utils.FileInfo
+			for _, name := range []string{"a.opus", "b.opus"} {
+				fake.AddFile(base+name, content, modTime)
+				files = append(files, &utils.FileInfo{Path: base + name, RelativePath: name, Name: name, Size: int64(len(content))})
+			}
+			tt.setup(fake)
+
+			copier := NewFileCopier(cfg, log, NewMockTracker(), deviceInfo)
+			if tt.hideExists {
+				copier.SetMTPInterface(struct{ device.MTPInterface }{fake})
+			} else {
+				copier.SetMTPInterface(fake)
+			}
+			if tt.resume {
+				cfg.Backup.EnableResume = true
+				resumeDir := t.TempDir()
+				copier.resumeManager = NewResumeManager(filepath.Join(resumeDir, "resume"), filepath.Join(resumeDir, "temp"), log)
+			}
+
+			results := make(map[string]*CopyResult)
+			for result := range copier.CopyFiles(context.Background(), files, true) {
+				results[result.File.Name] = result
+			}
+
+			if a := results["a.opus"]; a == nil || !a.Success {
+				t.Errorf("a.opus 应正常复制: %+v", a)
+			}
+			b := results["b.opus"]
+			if b == nil {
+				t.Fatal("缺少 b.opus 的复制结果")
+			}
+			if !tt.wantSkipped {
+				if b.Success || b.Skipped || b.Error == nil {
+					t.Errorf("无法确认源文件消失时应计为失败: %+v", b)
+				}
+				return
+			}
+			if !b.Skipped || b.SkipReason != SkipReasonSourceVanished || b.Error != nil {
+				t.Errorf("b.opus 应标记为源文件消失: skipped=%v reason=%q err=%v", b.Skipped, b.SkipReason, b.Error)
+			}
+			if b.TargetPath != "" {
+				if _, err := os.Stat(b.TargetPath); !os.IsNotExist(err) {
+					t.Errorf("不应留下写了一半的目标文件: %s", b.TargetPath)
+				}
+			}
+			if copier.resumeManager != nil {
+				if _, err := copier.resumeManager.GetResumeInfo(base + "b.opus"); err == nil {
+					t.Error("源文件消失后应清理断点信息")
+				}
+			}
+		})
+	}
+}
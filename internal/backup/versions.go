@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// heldVersionSuffix 复制期间暂存旧目标的文件名后缀
+const heldVersionSuffix = ".prev"
+
+// versionPath 目标文件第 n 个历史版本的路径，如 a.opus 的第1版为 a.v1.opus，n 越大越旧
+func versionPath(targetPath string, n int) string {
+	ext := filepath.Ext(targetPath)
+	return fmt.Sprintf("%s.v%d%s", strings.TrimSuffix(targetPath, ext), n, ext)
+}
+
+// holdPreviousVersion 开启保留版本时，复制前把已存在的目标移到暂存路径，返回暂存路径
+// 未开启或目标不存在时返回空，复制直接覆盖
+func (fc *FileCopier) holdPreviousVersion(targetPath string) string {
+	if fc.config.Backup.KeepVersions <= 0 {
+		return ""
+	}
+	if _, err := os.Stat(targetPath); err != nil {
+		return ""
+	}
+
+	held := targetPath + heldVersionSuffix
+	fc.removeVersionFile(held)
+	if err := os.Rename(targetPath, held); err != nil {
+		fc.log.Warn("暂存旧版本失败，直接覆盖: %s, %v", targetPath, err)
+		return ""
+	}
+	return held
+}
+
+// restorePreviousVersion 复制失败时把暂存的旧目标放回原处，替换写了一半的文件
+func (fc *FileCopier) restorePreviousVersion(held, targetPath string) {
+	if held == "" {
+		return
+	}
+	fc.removeVersionFile(targetPath)
+	if err := os.Rename(held, targetPath); err != nil {
+		fc.log.Warn("恢复旧版本失败: %s, %v", held, err)
+	}
+}
+
+// rotateVersions 复制成功后处理暂存的旧目标：内容与新目标相同时丢弃，不同时作为第1版，原有历史版本依次后移，
+// 超出保留数的最旧版本删除。返回仍保留的历史版本路径，最近的在前
+func (fc *FileCopier) rotateVersions(held, targetPath string) []string {
+	if fc.config.Backup.KeepVersions <= 0 {
+		return nil
+	}
+	keep := fc.config.Backup.KeepVersions - 1 // 保留数包含最新版
+	if held != "" {
+		if keep <= 0 || fc.sameContent(held, targetPath) {
+			fc.removeVersionFile(held)
+		} else {
+			fc.removeVersionFile(versionPath(targetPath, keep))
+			for n := keep - 1; n >= 1; n-- {
+				if _, err := os.Stat(versionPath(targetPath, n)); err == nil {
+					if err := os.Rename(versionPath(targetPath, n), versionPath(targetPath, n+1)); err != nil {
+						fc.log.Warn("移动历史版本失败: %s, %v", versionPath(targetPath, n), err)
+					}
+				}
+			}
+			if err := os.Rename(held, versionPath(targetPath, 1)); err != nil {
+				fc.log.Warn("保留旧版本失败: %s, %v", held, err)
+			} else {
+				fc.log.Info("目标内容已变化，旧版本保留为: %s", versionPath(targetPath, 1))
+			}
+		}
+	}
+
+	// 保留数调小后清理超出的旧版本
+	for n := keep + 1; ; n++ {
+		path := versionPath(targetPath, n)
+		if _, err := os.Stat(path); err != nil {
+			break
+		}
+		fc.log.Info("删除超出保留数的旧版本: %s", path)
+		fc.removeVersionFile(path)
+	}
+
+	var versions []string
+	for n := 1; n <= keep; n++ {
+		if _, err := os.Stat(versionPath(targetPath, n)); err == nil {
+			versions = append(versions, versionPath(targetPath, n))
+		}
+	}
+	return versions
+}
+
+// sameContent 比较两个文件的大小和哈希，无法计算哈希时视为不同
+func (fc *FileCopier) sameContent(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil || infoA.Size() != infoB.Size() {
+		return false
+	}
+	hashA, errA := fc.hashFile(a)
+	hashB, errB := fc.hashFile(b)
+	return errA == nil && errB == nil && hashA == hashB
+}
+
+// removeVersionFile 删除版本文件，先清除上次备份设置的只读属性
+func (fc *FileCopier) removeVersionFile(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	fc.clearTargetReadOnly(path)
+	if err := os.Remove(path); err != nil {
+		fc.log.Warn("删除文件失败: %s, %v", path, err)
+	}
+}
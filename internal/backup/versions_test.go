@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/device"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestVersionPath 测试历史版本的命名
+func TestVersionPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		n      int
+		want   string
+	}{
+		{"第1版", filepath.Join("backup", "a.opus"), 1, filepath.Join("backup", "a.v1.opus")},
+		{"多个点号", filepath.Join("backup", "2024.05.01.opus"), 2, filepath.Join("backup", "2024.05.01.v2.opus")},
+		{"无扩展名", filepath.Join("backup", "note"), 1, filepath.Join("backup", "note.v1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionPath(tt.target, tt.n); got != tt.want {
+				t.Errorf("versionPath() = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFileCopier_KeepVersions 测试同一目标连续复制不同内容时保留最新版和前一版，更早的版本被清理，
+// 内容相同不产生新版本，复制失败时旧目标保持不变
+func TestFileCopier_KeepVersions(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\a.opus"
+	log := logger.NewLogger(false)
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+	cfg.Backup.KeepVersions = 2
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	tracker := NewMockTracker()
+	copier := NewFileCopier(cfg, log, tracker, deviceInfo)
+	copier.SetMTPInterface(fake)
+
+	copyContent := func(content string) *CopyResult {
+		fake.AddFile(devicePath, []byte(content), time.Now().Add(-time.Hour))
+		file := &utils.FileInfo{Path: devicePath, RelativePath: "a.opus", Name: "a.opus", Size: int64(len(content))}
+		return copier.CopyFile(file, true)
+	}
+	readFile := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	var targetPath string
+	for _, content := range []string{"第一次录音", "第二次录音内容", "第三次录音的内容"} {
+		result := copyContent(content)
+		if !result.Success {
+			t.Fatalf("复制 %q 失败: %v", content, result.Error)
+		}
+		targetPath = result.TargetPath
+	}
+
+	v1, v2 := versionPath(targetPath, 1), versionPath(targetPath, 2)
+	if got := readFile(targetPath); got != "第三次录音的内容" {
+		t.Errorf("目标文件内容 = %q，期望最新版", got)
+	}
+	if got := readFile(v1); got != "第二次录音内容" {
+		t.Errorf("第1版内容 = %q，期望前一版", got)
+	}
+	if _, err := os.Stat(v2); !os.IsNotExist(err) {
+		t.Errorf("超出保留数的最旧版本应被删除: %s", v2)
+	}
+	record, err := tracker.GetRecordByPath(devicePath)
+	if err != nil || len(record.Versions) != 1 || record.Versions[0] != v1 {
+		t.Errorf("记录的历史版本 = %+v, %v，期望 [%s]", record, err, v1)
+	}
+
+	// 内容相同时不产生新版本
+	if result := copyContent("第三次录音的内容"); !result.Success {
+		t.Fatalf("重复复制失败: %v", result.Error)
+	}
+	if got := readFile(v1); got != "第二次录音内容" {
+		t.Errorf("内容未变时第1版不应改变，实际 %q", got)
+	}
+
+	// 复制失败时放回旧目标，历史版本不变
+	fake.FailStream(devicePath, 10)
+	if result := copyContent("第四次录音"); result.Success {
+		t.Fatal("打开文件流失败时复制应失败")
+	}
+	if got := readFile(targetPath); got != "第三次录音的内容" {
+		t.Errorf("复制失败后目标文件内容 = %q，期望保持原样", got)
+	}
+	if got := readFile(v1); got != "第二次录音内容" {
+		t.Errorf("复制失败后第1版内容 = %q", got)
+	}
+	if _, err := os.Stat(targetPath + heldVersionSuffix); !os.IsNotExist(err) {
+		t.Error("不应留下暂存的旧版本")
+	}
+}
+
+// TestFileCopier_KeepVersionsChangedSource 测试不加 --force 时大小变化的已备份文件重新复制并保留旧版本，大小未变时跳过
+func TestFileCopier_KeepVersionsChangedSource(t *testing.T) {
+	const devicePath = "内部共享存储空间\\录音笔文件\\a.opus"
+	cfg := config.DefaultConfig()
+	cfg.Target.BaseDirectory = filepath.Join(t.TempDir(), "backups")
+	cfg.Backup.EnableResume = false
+	cfg.Backup.RangeDownload.Enabled = false
+	cfg.Backup.SkipExisting = true
+	cfg.Backup.KeepVersions = 2
+
+	deviceInfo := &device.DeviceInfo{DeviceID: "fake_sr302", Name: "SR302"}
+	fake := device.NewFakeMTPAccessor(deviceInfo)
+	tracker := NewMockTracker()
+	copier := NewFileCopier(cfg, logger.NewLogger(false), tracker, deviceInfo)
+	copier.SetMTPInterface(fake)
+
+	copyContent := func(content string) *CopyResult {
+		fake.AddFile(devicePath, []byte(content), time.Now().Add(-time.Hour))
+		file := &utils.FileInfo{Path: devicePath, RelativePath: "a.opus", Name: "a.opus", Size: int64(len(content))}
+		return copier.CopyFile(file, false)
+	}
+
+	first := copyContent("第一次录音")
+	if !first.Success {
+		t.Fatalf("首次复制失败: %v", first.Error)
+	}
+	if result := copyContent("第一次录音"); !result.Skipped {
+		t.Error("大小未变的已备份文件应跳过")
+	}
+	if result := copyContent("录音被续写后变长了"); !result.Success || result.Skipped {
+		t.Fatalf("大小变化的文件应重新复制: %+v", result)
+	}
+	if data, err := os.ReadFile(versionPath(first.TargetPath, 1)); err != nil || string(data) != "第一次录音" {
+		t.Errorf("旧内容应保留为第1版: %q, %v", data, err)
+	}
+}
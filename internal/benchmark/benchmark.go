@@ -0,0 +1,144 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// readBufferSize 读取缓冲区大小，与复制时一致
+const readBufferSize = 64 * 1024
+
+// OpenFunc 打开待测数据流
+type OpenFunc func() (io.ReadCloser, error)
+
+// RunResult 单次读取的测量结果
+type RunResult struct {
+	Bytes    int64
+	Duration time.Duration // 从打开到读完的总耗时
+	Latency  time.Duration // 从打开到读到首字节的耗时
+}
+
+// Throughput 单次读取的吞吐量（字节/秒）
+func (r RunResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Duration.Seconds()
+}
+
+// Result 多次读取的汇总结果
+type Result struct {
+	Runs       []RunResult
+	AvgLatency time.Duration
+	// 平均吞吐量（字节/秒），按总字节数除以总耗时计算
+	AvgThroughput float64
+	MinThroughput float64
+	MaxThroughput float64
+}
+
+// MBps 平均吞吐量（MB/s）
+func (r *Result) MBps() float64 {
+	return r.AvgThroughput / 1024 / 1024
+}
+
+// Measure 多次流式读取数据到 io.Discard，测量吞吐量和首字节延迟
+func Measure(open OpenFunc, runs int) (*Result, error) {
+	return measure(open, runs, time.Now)
+}
+
+// measure 使用指定时钟进行测量
+func measure(open OpenFunc, runs int, now func() time.Time) (*Result, error) {
+	if runs <= 0 {
+		runs = 1
+	}
+
+	result := &Result{}
+	var totalBytes int64
+	var totalDuration, totalLatency time.Duration
+
+	for i := 0; i < runs; i++ {
+		run, err := measureOnce(open, now)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 次读取失败: %w", i+1, err)
+		}
+
+		result.Runs = append(result.Runs, run)
+		totalBytes += run.Bytes
+		totalDuration += run.Duration
+		totalLatency += run.Latency
+
+		throughput := run.Throughput()
+		if i == 0 || throughput < result.MinThroughput {
+			result.MinThroughput = throughput
+		}
+		if throughput > result.MaxThroughput {
+			result.MaxThroughput = throughput
+		}
+	}
+
+	if totalDuration > 0 {
+		result.AvgThroughput = float64(totalBytes) / totalDuration.Seconds()
+	}
+	result.AvgLatency = totalLatency / time.Duration(runs)
+	return result, nil
+}
+
+// measureOnce 执行一次读取
+func measureOnce(open OpenFunc, now func() time.Time) (RunResult, error) {
+	var run RunResult
+	start := now()
+
+	stream, err := open()
+	if err != nil {
+		return run, fmt.Errorf("打开数据流失败: %w", err)
+	}
+	defer stream.Close()
+
+	buffer := make([]byte, readBufferSize)
+	firstByte := true
+	for {
+		n, err := stream.Read(buffer)
+		if n > 0 {
+			if firstByte {
+				run.Latency = now().Sub(start)
+				firstByte = false
+			}
+			written, _ := io.Discard.Write(buffer[:n])
+			run.Bytes += int64(written)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return run, fmt.Errorf("读取数据失败: %w", err)
+		}
+	}
+
+	run.Duration = now().Sub(start)
+	return run, nil
+}
+
+// SelectFile 选择大小最接近目标大小的文件，没有可用文件时返回nil
+func SelectFile(files []*utils.FileInfo, targetSize int64) *utils.FileInfo {
+	var selected *utils.FileInfo
+	var bestDiff int64
+
+	for _, file := range files {
+		if file.Size <= 0 {
+			continue
+		}
+		diff := file.Size - targetSize
+		if diff < 0 {
+			diff = -diff
+		}
+		if selected == nil || diff < bestDiff {
+			selected = file
+			bestDiff = diff
+		}
+	}
+
+	return selected
+}
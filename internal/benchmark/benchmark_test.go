@@ -0,0 +1,160 @@
+package benchmark
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// fakeClock 手动推进的假时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// fakeStream 以固定速率返回数据的假流，每次读取按速率推进假时钟
+type fakeStream struct {
+	clock     *fakeClock
+	remaining int64
+	chunk     int64
+	rate      float64 // 字节/秒
+	latency   time.Duration
+	started   bool
+}
+
+func (s *fakeStream) Read(p []byte) (int, error) {
+	if !s.started {
+		s.clock.now = s.clock.now.Add(s.latency)
+		s.started = true
+	}
+	if s.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	n := s.chunk
+	if n > int64(len(p)) {
+		n = int64(len(p))
+	}
+	if n > s.remaining {
+		n = s.remaining
+	}
+	s.remaining -= n
+	s.clock.now = s.clock.now.Add(time.Duration(float64(n) / s.rate * float64(time.Second)))
+	return int(n), nil
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+// TestMeasure 测试已知速率的假流吞吐量计算
+func TestMeasure(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	const size = 10 * 1024 * 1024
+	const rate = 2 * 1024 * 1024 // 2 MB/s
+
+	opens := 0
+	open := func() (io.ReadCloser, error) {
+		opens++
+		return &fakeStream{clock: clock, remaining: size, chunk: 32 * 1024, rate: rate}, nil
+	}
+
+	result, err := measure(open, 3, clock.Now)
+	if err != nil {
+		t.Fatalf("测量失败: %v", err)
+	}
+
+	if opens != 3 || len(result.Runs) != 3 {
+		t.Fatalf("应读取 3 次，实际打开 %d 次，结果 %d 条", opens, len(result.Runs))
+	}
+	for i, run := range result.Runs {
+		if run.Bytes != size {
+			t.Errorf("第 %d 次读取字节数错误: %d", i+1, run.Bytes)
+		}
+		if run.Duration != 5*time.Second {
+			t.Errorf("第 %d 次读取耗时错误: %s", i+1, run.Duration)
+		}
+	}
+	if math.Abs(result.MBps()-2) > 0.001 {
+		t.Errorf("平均吞吐量应为 2 MB/s，实际 %.3f MB/s", result.MBps())
+	}
+}
+
+// TestMeasure_Latency 测试首字节延迟与最快/最慢吞吐量
+func TestMeasure_Latency(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rates := []float64{1024 * 1024, 4 * 1024 * 1024}
+	run := 0
+
+	open := func() (io.ReadCloser, error) {
+		stream := &fakeStream{clock: clock, remaining: 4 * 1024 * 1024, chunk: 64 * 1024, rate: rates[run], latency: 200 * time.Millisecond}
+		run++
+		return stream, nil
+	}
+
+	result, err := measure(open, 2, clock.Now)
+	if err != nil {
+		t.Fatalf("测量失败: %v", err)
+	}
+
+	// 首个数据块读完时才记录首字节，延迟包含首块传输时间
+	expectedLatency := 200*time.Millisecond + time.Duration(float64(64*1024)/rates[0]*float64(time.Second))
+	if result.Runs[0].Latency != expectedLatency {
+		t.Errorf("首字节延迟错误，期望 %s，实际 %s", expectedLatency, result.Runs[0].Latency)
+	}
+	if result.MinThroughput >= result.MaxThroughput {
+		t.Errorf("最慢吞吐量应小于最快吞吐量: %.0f >= %.0f", result.MinThroughput, result.MaxThroughput)
+	}
+
+	// 总计 8MB，总耗时 4.2s + 1.2s
+	expected := float64(8*1024*1024) / 5.4
+	if math.Abs(result.AvgThroughput-expected) > 1 {
+		t.Errorf("平均吞吐量错误，期望 %.0f，实际 %.0f", expected, result.AvgThroughput)
+	}
+}
+
+// TestMeasure_OpenError 测试打开失败时返回错误
+func TestMeasure_OpenError(t *testing.T) {
+	_, err := Measure(func() (io.ReadCloser, error) {
+		return nil, errors.New("设备未连接")
+	}, 1)
+	if err == nil {
+		t.Error("打开失败时应返回错误")
+	}
+}
+
+// TestSelectFile 测试选择大小最接近的文件
+func TestSelectFile(t *testing.T) {
+	files := []*utils.FileInfo{
+		{Name: "empty.opus", Size: 0},
+		{Name: "small.opus", Size: 10 * 1024 * 1024},
+		{Name: "medium.opus", Size: 45 * 1024 * 1024},
+		{Name: "large.opus", Size: 200 * 1024 * 1024},
+	}
+
+	testCases := []struct {
+		target   int64
+		expected string
+	}{
+		{50 * 1024 * 1024, "medium.opus"},
+		{1, "small.opus"},
+		{1024 * 1024 * 1024, "large.opus"},
+	}
+
+	for _, tc := range testCases {
+		if selected := SelectFile(files, tc.target); selected == nil || selected.Name != tc.expected {
+			t.Errorf("目标大小 %d 应选择 %s，实际 %v", tc.target, tc.expected, selected)
+		}
+	}
+
+	if SelectFile([]*utils.FileInfo{{Name: "empty.opus"}}, 100) != nil {
+		t.Error("没有可用文件时应返回nil")
+	}
+}
@@ -2,11 +2,16 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/allanpk716/record_center/pkg/utils"
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 )
 
 // 配置文件结构
@@ -16,6 +21,14 @@ type Config struct {
 	Backup     BackupConfig     `mapstructure:"backup" yaml:"backup" json:"backup"`
 	Logging    LoggingConfig    `mapstructure:"logging" yaml:"logging" json:"logging"`
 	PowerShell PowerShellConfig `mapstructure:"powershell" yaml:"powershell" json:"powershell"`
+	Sync       SyncConfig       `mapstructure:"sync" yaml:"sync" json:"sync"`
+	Storage    StorageConfig    `mapstructure:"storage" yaml:"storage" json:"storage"`
+	Notify     NotifyConfig     `mapstructure:"notify" yaml:"notify" json:"notify"`
+	Metrics    MetricsConfig    `mapstructure:"metrics" yaml:"metrics" json:"metrics"` // Prometheus 指标导出
+	Language   string           `mapstructure:"language" yaml:"language" json:"language"` // 界面语言: zh、en，为空时读取 RC_LANG 环境变量
+	DeviceAliases map[string]string `mapstructure:"device_aliases" yaml:"device_aliases,omitempty" json:"device_aliases,omitempty"` // 设备别名（DeviceID 或序列号 -> 别名），用于目录模板的 {device} 和日志
+	Tasks            []TaskConfig `mapstructure:"-" yaml:"tasks,omitempty" json:"tasks,omitempty"`                   // run 子命令执行的备份任务，任务中未设置的项沿用顶层的 source/target/backup
+	RunTasksParallel bool         `mapstructure:"run_tasks_parallel" yaml:"run_tasks_parallel" json:"run_tasks_parallel"` // 多个任务并行执行，默认依次执行
 }
 
 // 源设备配置
@@ -24,12 +37,79 @@ type SourceConfig struct {
 	BasePath   string `mapstructure:"base_path" yaml:"base_path" json:"base_path"`
 	VID        string `mapstructure:"vid" yaml:"vid" json:"vid"`
 	PID        string `mapstructure:"pid" yaml:"pid" json:"pid"`
+	Storage    string `mapstructure:"storage" yaml:"storage" json:"storage"` // "internal", "sd", "all"
+	EncryptedExtensions []string `mapstructure:"encrypted_extensions" yaml:"encrypted_extensions" json:"encrypted_extensions"` // 加密录音的扩展名
+	DetectEncrypted     bool     `mapstructure:"detect_encrypted" yaml:"detect_encrypted" json:"detect_encrypted"`             // 是否读取文件头识别加密录音
+	IgnoreFile          string   `mapstructure:"ignore_file" yaml:"ignore_file" json:"ignore_file"`                            // .gitignore 风格的忽略规则文件，不存在时不过滤
+	IncludeHidden       bool     `mapstructure:"include_hidden" yaml:"include_hidden" json:"include_hidden"`                   // 是否备份设备上的隐藏文件
+	IncludeSystem       bool     `mapstructure:"include_system" yaml:"include_system" json:"include_system"`                   // 是否备份设备上的系统文件
+	MinBatteryPercent      int    `mapstructure:"min_battery_percent" yaml:"min_battery_percent" json:"min_battery_percent"`                // 备份前设备电量低于该百分比时按 low_battery_action 处理，0表示不检查电量
+	LowBatteryAction       string `mapstructure:"low_battery_action" yaml:"low_battery_action" json:"low_battery_action"`                   // 电量不足时的处理: refuse（拒绝开始备份）、warn（只警告）
+	CriticalBatteryPercent int    `mapstructure:"critical_battery_percent" yaml:"critical_battery_percent" json:"critical_battery_percent"` // 备份中电量降到该百分比及以下时保存进度并停止，仅 min_battery_percent 大于0时检查
 }
 
 // 目标备份配置
 type TargetConfig struct {
-	BaseDirectory string `mapstructure:"base_directory" yaml:"base_directory" json:"base_directory"`
-	CreateSubdirs bool   `mapstructure:"create_subdirs" yaml:"create_subdirs" json:"create_subdirs"`
+	BaseDirectory    string `mapstructure:"base_directory" yaml:"base_directory" json:"base_directory"`
+	CreateSubdirs    bool   `mapstructure:"create_subdirs" yaml:"create_subdirs" json:"create_subdirs"`
+	Archive          string `mapstructure:"archive" yaml:"archive" json:"archive"`                                  // 归档模式: none（松散文件）、zip
+	ArchiveSplitSize string `mapstructure:"archive_split_size" yaml:"archive_split_size" json:"archive_split_size"` // zip分卷大小，如 "2GB"，"0"或空表示不分卷
+	Type             string   `mapstructure:"type" yaml:"type" json:"type"` // 目标存储类型: local（本地目录）、smb（UNC共享路径）、s3
+	S3               S3Config `mapstructure:"s3" yaml:"s3" json:"s3"`       // type 为 s3 时的连接配置
+	PathTemplate     string         `mapstructure:"path_template" yaml:"path_template" json:"path_template"` // 目录模板，支持 {daypart}、{weekday}、{weektype}、{device} 占位符，如 "{weektype}/{daypart}"，空表示不分类
+	DayParts         DayPartsConfig `mapstructure:"day_parts" yaml:"day_parts" json:"day_parts"`             // {daypart} 各时段的开始时间
+	FileMode         string `mapstructure:"file_mode" yaml:"file_mode" json:"file_mode"` // 复制完成后设置的目标文件权限（八进制），如 "0444"，空表示不修改
+	ReadOnly         bool   `mapstructure:"read_only" yaml:"read_only" json:"read_only"` // 复制完成后把目标文件设为只读，防止误删误改
+	Rollover         string `mapstructure:"rollover" yaml:"rollover" json:"rollover"`    // 按周期滚动的备份目录: none、weekly（如 2024-W18）、monthly（如 2024-05），latest 链接指向当前周期
+	CaseInsensitive  bool   `mapstructure:"case_insensitive" yaml:"case_insensitive" json:"case_insensitive"` // 目标为区分大小写的文件系统（如 Linux NAS）时开启，只有大小写不同的路径视为同一个目标（仅本地和SMB目标）
+	Layout           string `mapstructure:"layout" yaml:"layout" json:"layout"` // 目标文件布局: structured（按目录结构和文件名）、content-addressed（按内容哈希命名并分桶存放，相同内容只存一份）
+}
+
+// 时段划分配置，各时段的开始时间（HH:MM），需按时间先后排列
+type DayPartsConfig struct {
+	Morning   string `mapstructure:"morning" yaml:"morning" json:"morning"`
+	Afternoon string `mapstructure:"afternoon" yaml:"afternoon" json:"afternoon"`
+	Evening   string `mapstructure:"evening" yaml:"evening" json:"evening"`
+	Night     string `mapstructure:"night" yaml:"night" json:"night"`
+}
+
+// ParseClock 将 HH:MM 解析为当天的分钟数
+func ParseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间: %s，格式应为 HH:MM", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// ParseTimeRange 将 HH:MM-HH:MM 解析为开始和结束的当天分钟数
+func ParseTimeRange(value string) (int, int, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("无效的时段: %s，格式应为 HH:MM-HH:MM", value)
+	}
+	start, err := ParseClock(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := ParseClock(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("无效的时段: %s，开始和结束时间不能相同", value)
+	}
+	return start, end, nil
+}
+
+// S3兼容对象存储配置
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint" yaml:"endpoint" json:"endpoint"`       // 服务地址，如 "https://s3.amazonaws.com" 或 MinIO 地址
+	Region    string `mapstructure:"region" yaml:"region" json:"region"`             // 区域，MinIO 通常为 "us-east-1"
+	Bucket    string `mapstructure:"bucket" yaml:"bucket" json:"bucket"`             // 存储桶
+	Prefix    string `mapstructure:"prefix" yaml:"prefix" json:"prefix"`             // 对象键前缀，如 "recordings/"
+	AccessKey string `mapstructure:"access_key" yaml:"access_key" json:"access_key"` // 访问密钥ID
+	SecretKey string `mapstructure:"secret_key" yaml:"secret_key" json:"secret_key"` // 访问密钥
 }
 
 // 备份配置
@@ -37,10 +117,30 @@ type BackupConfig struct {
 	FileExtensions    []string `mapstructure:"file_extensions" yaml:"file_extensions" json:"file_extensions"`
 	SkipExisting      bool     `mapstructure:"skip_existing" yaml:"skip_existing" json:"skip_existing"`
 	PreserveStructure bool     `mapstructure:"preserve_structure" yaml:"preserve_structure" json:"preserve_structure"`
+	SyncMode          string   `mapstructure:"sync_mode" yaml:"sync_mode" json:"sync_mode"` // 同步模式: incremental（只新增）、mirror（镜像，清理设备上已删除的备份）
+	SafeMode          bool     `mapstructure:"safe_mode" yaml:"safe_mode" json:"safe_mode"` // 安全模式：设备枚举结果异常时拒绝执行镜像清理
+	OnError           string   `mapstructure:"on_error" yaml:"on_error" json:"on_error"`    // 复制失败时的策略: continue（继续）、stop（任意失败即停止）、stop-on-fatal（空间不足、设备断开时停止）
+	ResetAfterFailures int     `mapstructure:"reset_after_failures" yaml:"reset_after_failures" json:"reset_after_failures"` // 连续复制失败达到N次时断开并重连设备后继续，0表示不复位
 	MaxConcurrent     int      `mapstructure:"max_concurrent" yaml:"max_concurrent" json:"max_concurrent"`
+	CopyOrder         string   `mapstructure:"copy_order" yaml:"copy_order" json:"copy_order"` // 复制队列的顺序: newest（最新录音优先）、smallest、largest、name，空表示按枚举顺序
+	GlobalMaxConcurrent int    `mapstructure:"global_max_concurrent" yaml:"global_max_concurrent" json:"global_max_concurrent"` // 所有设备共享的并发上限，0表示不限制
+	DeviceConcurrency int      `mapstructure:"device_concurrency" yaml:"device_concurrency" json:"device_concurrency"` // 并行执行任务时同时备份的设备数，单设备内文件串行复制，0表示不限制
+	CommitInterval    int      `mapstructure:"commit_interval" yaml:"commit_interval" json:"commit_interval"` // 每复制完N个文件保存一次备份记录，0表示完成后才保存
+	DailyQuota        string       `mapstructure:"daily_quota" yaml:"daily_quota" json:"daily_quota"` // 每天最多复制的数据量，如 "20GB"，达到后新文件延后到次日，空表示不限制
+	Schedule          []RateWindow `mapstructure:"schedule" yaml:"schedule" json:"schedule"`          // 按时段限速，不在任何时段内时不限速
+	SlowThreshold     float64  `mapstructure:"slow_threshold" yaml:"slow_threshold" json:"slow_threshold"` // 复制速度低于历史平均的该比例并持续一段时间时告警，0表示不检测
+	SlowReconnect     bool     `mapstructure:"slow_reconnect" yaml:"slow_reconnect" json:"slow_reconnect"` // 速度持续偏低时重新打开设备文件流
+	BatchCopy         bool     `mapstructure:"batch_copy" yaml:"batch_copy" json:"batch_copy"` // 使用单个长驻PowerShell会话批量复制，减少进程启动开销
+	PrehashOnDevice   bool     `mapstructure:"prehash_on_device" yaml:"prehash_on_device" json:"prehash_on_device"` // 枚举时直接在设备上预计算哈希，仅设备以盘符挂载时可用
+	PrehashMaxSize    string   `mapstructure:"prehash_max_size" yaml:"prehash_max_size" json:"prehash_max_size"`    // 只预计算小于该大小的文件，如 "50MB"
+	StabilityWait     string   `mapstructure:"stability_wait" yaml:"stability_wait" json:"stability_wait"`       // 复制前两次读取文件信息的间隔，如 "2s"，"0"表示不检测
+	StabilityWindow   string   `mapstructure:"stability_window" yaml:"stability_window" json:"stability_window"` // 大小不可靠时，修改时间在该时长内视为仍在写入
 	// 新增完整性验证配置
 	IntegrityCheck    bool     `mapstructure:"integrity_check" yaml:"integrity_check" json:"integrity_check" default:"true"`
 	HashAlgorithm     string   `mapstructure:"hash_algorithm" yaml:"hash_algorithm" json:"hash_algorithm" default:"sha256"`
+	DeepVerify        bool     `mapstructure:"deep_verify" yaml:"deep_verify" json:"deep_verify"` // 复制后从设备重新读取源文件逐块比对，成本高，默认关闭
+	WriteSidecar      bool     `mapstructure:"write_sidecar" yaml:"write_sidecar" json:"write_sidecar"` // 复制成功后在目标文件旁写入 <name>.json 元数据文件（仅松散文件）
+	KeepVersions      int      `mapstructure:"keep_versions" yaml:"keep_versions" json:"keep_versions"` // 目标已存在且内容不同时保留的版本数（含最新版），旧版本改名为 <name>.v1.opus 等，0表示直接覆盖（仅松散文件）
 	// 新增断点续传配置
 	EnableResume      bool     `mapstructure:"enable_resume" yaml:"enable_resume" json:"enable_resume" default:"true"`
 	ChunkSize         string   `mapstructure:"chunk_size" yaml:"chunk_size" json:"chunk_size" default:"5MB"`
@@ -49,15 +149,100 @@ type BackupConfig struct {
 	ResumeMaxAge      string   `mapstructure:"resume_max_age" yaml:"resume_max_age" json:"resume_max_age" default:"24h"`
 	// 新增清理空文件夹配置
 	CleanEmptyFolders bool     `mapstructure:"clean_empty_folders" yaml:"clean_empty_folders" json:"clean_empty_folders" default:"true"`
+	FollowSymlinks    bool     `mapstructure:"follow_symlinks" yaml:"follow_symlinks" json:"follow_symlinks"` // 清空文件夹、镜像清理等遍历是否跟随符号链接/目录联接，默认不跟随
+	PerType           map[string]TypeRule `mapstructure:"per_type" yaml:"per_type" json:"per_type"` // 按扩展名（写作 wav，不带点号）指定独立的目标子目录和重命名规则，未配置的类型使用全局规则
+	Similar           SimilarConfig       `mapstructure:"similar" yaml:"similar" json:"similar"`    // 疑似重复录音检测，只在预览和日志中提示，不会删除文件
+	RangeDownload     RangeDownloadConfig `mapstructure:"range_download" yaml:"range_download" json:"range_download"` // 大文件分片并行下载，只对支持按偏移读取的设备访问器生效
+	Filter            FilterConfig        `mapstructure:"filter" yaml:"filter" json:"filter"`                         // 枚举后按大小、修改时间、文件名过滤，任一条件不满足即跳过
+	TrashDir          string   `mapstructure:"trash_dir" yaml:"trash_dir" json:"trash_dir"`                   // 回收站目录，镜像清理等删除的备份先移入此处，空表示目标目录下的 .trash
+	TrashRetention    string   `mapstructure:"trash_retention" yaml:"trash_retention" json:"trash_retention"` // 回收站中文件的保留期，如 "720h"，过期后在备份结束时自动清理，"0"表示不自动清理
+	PerFileTimeout    string   `mapstructure:"per_file_timeout" yaml:"per_file_timeout" json:"per_file_timeout"`       // 单个文件复制的基础超时，如 "10m"，超时后取消该文件并标记失败，"0"表示不限制
+	PerFileMinSpeed   string   `mapstructure:"per_file_min_speed" yaml:"per_file_min_speed" json:"per_file_min_speed"` // 计算超时时假定的最低速度（每秒），如 "64KB"，超时 = 基础超时 + 文件大小/最低速度，空表示不按大小延长
+	PreCopyCommand    string   `mapstructure:"pre_copy_command" yaml:"pre_copy_command" json:"pre_copy_command"` // 复制前对每个文件执行的校验命令（如杀毒扫描），{file} 替换为从设备临时导出的文件路径，退出码非0时拒绝该文件，空表示不校验
+	PreCopyTimeout    string   `mapstructure:"pre_copy_timeout" yaml:"pre_copy_timeout" json:"pre_copy_timeout"` // 单次校验命令的超时，如 "2m"，超时的文件标记失败
+	StripPatterns     []string `mapstructure:"strip_patterns" yaml:"strip_patterns" json:"strip_patterns"` // 设备生成的垃圾文件 glob（如 "._*"、"*.db"），匹配的文件即使扩展名符合也不复制，镜像模式下从目标清理
+}
+
+// RateWindow 一个时段的复制限速
+type RateWindow struct {
+	TimeRange         string `mapstructure:"time_range" yaml:"time_range" json:"time_range"`                               // 时段，如 "08:00-18:00"，结束早于开始时跨越午夜
+	MaxBytesPerSecond string `mapstructure:"max_bytes_per_second" yaml:"max_bytes_per_second" json:"max_bytes_per_second"` // 该时段每秒最多复制的数据量，如 "2MB"
+}
+
+// TypeRule 单个扩展名的备份规则
+type TypeRule struct {
+	Enabled   bool   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`       // 是否启用该规则，未启用时按全局规则处理
+	Directory string `mapstructure:"directory" yaml:"directory" json:"directory"` // 相对目标根目录的子目录，为空时不单独分目录
+	Rename    string `mapstructure:"rename" yaml:"rename" json:"rename"`          // 文件名模板，支持 {name}、{ext}、{date}、{time} 占位符，如 "{date}_{name}.{ext}"，为空时保留原名
+}
+
+// SimilarConfig 疑似重复录音的判断阈值：时长和大小都接近（且文件名前缀相同）的 opus 文件归为一组
+type SimilarConfig struct {
+	Enabled           bool    `mapstructure:"enabled" yaml:"enabled" json:"enabled"`                                  // 是否检测疑似重复的录音
+	DurationTolerance string  `mapstructure:"duration_tolerance" yaml:"duration_tolerance" json:"duration_tolerance"` // 时长相差不超过该值视为接近，如 "10s"
+	SizeTolerance     float64 `mapstructure:"size_tolerance" yaml:"size_tolerance" json:"size_tolerance"`             // 大小相差不超过较大文件的该比例视为接近，如 0.05
+	NamePrefix        int     `mapstructure:"name_prefix" yaml:"name_prefix" json:"name_prefix"`                      // 还要求文件名前N个字符相同，0表示不比较文件名
+}
+
+// FilterConfig 枚举后依次应用的文件过滤条件，未设置的条件不过滤
+type FilterConfig struct {
+	MinSize       string   `mapstructure:"min_size" yaml:"min_size" json:"min_size"`                   // 小于该大小的文件不备份，如 "10KB"
+	MaxSize       string   `mapstructure:"max_size" yaml:"max_size" json:"max_size"`                   // 大于该大小的文件不备份，如 "2GB"
+	ModifiedAfter string   `mapstructure:"modified_after" yaml:"modified_after" json:"modified_after"` // 只备份该日期（YYYY-MM-DD）及之后修改的文件
+	MaxAge        string   `mapstructure:"max_age" yaml:"max_age" json:"max_age"`                      // 只备份最近该时长内修改的文件，如 "720h"
+	Include       []string `mapstructure:"include" yaml:"include" json:"include"`                      // 文件须匹配其中一个 glob，不含 / 的模式匹配文件名，否则匹配相对路径
+	Exclude       []string `mapstructure:"exclude" yaml:"exclude" json:"exclude"`                      // 匹配其中任一 glob 的文件不备份
+}
+
+// FilterDateLayout modified_after 的日期格式
+const FilterDateLayout = "2006-01-02"
+
+// target.layout 的取值
+const (
+	LayoutStructured       = "structured"        // 按目录结构和文件名存放
+	LayoutContentAddressed = "content-addressed" // 按内容哈希命名并分桶存放
+)
+
+// RangeDownloadConfig 大文件分片并行下载：多个分片同时读取并写入目标文件的对应偏移
+type RangeDownloadConfig struct {
+	Enabled   bool   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`          // 是否启用，设备不支持按偏移读取时自动使用顺序复制
+	Threshold string `mapstructure:"threshold" yaml:"threshold" json:"threshold"`    // 大于该大小的文件才分片下载，如 "64MB"
+	Workers   int    `mapstructure:"workers" yaml:"workers" json:"workers"`          // 同时读取的分片数
+	ChunkSize string `mapstructure:"chunk_size" yaml:"chunk_size" json:"chunk_size"` // 每个分片的大小，如 "8MB"
+}
+
+// TypeRuleFor 获取文件扩展名对应的已启用规则，没有时返回 false
+func (b BackupConfig) TypeRuleFor(filename string) (TypeRule, bool) {
+	rule, ok := b.PerType[strings.ToLower(filepath.Ext(filename))]
+	if !ok || !rule.Enabled {
+		return TypeRule{}, false
+	}
+	return rule, true
+}
+
+// DeviceAlias 返回 keys（DeviceID、序列号）中第一个配置了别名的别名，都没有配置时返回空字符串
+func (c *Config) DeviceAlias(keys ...string) string {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if alias, ok := c.DeviceAliases[strings.ToUpper(strings.TrimSpace(key))]; ok {
+			return alias
+		}
+	}
+	return ""
 }
 
 // 日志配置
 type LoggingConfig struct {
 	Level       string `mapstructure:"level" yaml:"level" json:"level"`
-	File        string `mapstructure:"file" yaml:"file" json:"file"`
+	File        string `mapstructure:"file" yaml:"file" json:"file"` // 日志文件，只写文件名时位于 logs 目录
+	Format      string `mapstructure:"format" yaml:"format" json:"format"` // 日志格式: text, json
 	Console     bool   `mapstructure:"console" yaml:"console" json:"console"`
 	RotateHours int    `mapstructure:"rotate_hours" yaml:"rotate_hours" json:"rotate_hours"`
 	MaxDays     int    `mapstructure:"max_days" yaml:"max_days" json:"max_days"`
+	RingSize    int    `mapstructure:"ring_size" yaml:"ring_size" json:"ring_size"` // 内存中保留的最近日志条数（含debug），出错或崩溃时转储到 crash_<时间>.log，0表示不开启（默认）
+	Redact      bool   `mapstructure:"redact" yaml:"redact" json:"redact"` // 对日志中的文件名和路径脱敏（保留目录层次、扩展名和长度，主干用哈希前缀和星号替换）
 }
 
 // PowerShell配置
@@ -71,6 +256,61 @@ type PowerShellConfig struct {
 	RetryDelaySeconds  int      `mapstructure:"retry_delay_seconds" yaml:"retry_delay_seconds" json:"retry_delay_seconds"`   // 重试延迟
 }
 
+// 远程同步配置
+type SyncConfig struct {
+	Endpoint       string `mapstructure:"endpoint" yaml:"endpoint" json:"endpoint"`                      // 接收备份记录的HTTP端点，为空时不同步
+	APIKey         string `mapstructure:"api_key" yaml:"api_key" json:"api_key"`                         // 鉴权密钥
+	TimeoutSeconds int    `mapstructure:"timeout_seconds" yaml:"timeout_seconds" json:"timeout_seconds"` // 请求超时时间
+}
+
+// 备份结果通知配置
+type NotifyConfig struct {
+	On    string      `mapstructure:"on" yaml:"on" json:"on"` // 发送时机: always（每次备份后）、failure（有文件失败或备份出错时）、never（不发送）
+	Email EmailConfig `mapstructure:"email" yaml:"email" json:"email"`
+}
+
+// 指标导出配置
+type MetricsConfig struct {
+	ListenAddr string `mapstructure:"listen_addr" yaml:"listen_addr" json:"listen_addr"` // /metrics 端点的监听地址，如 ":9102"，为空时不启动
+}
+
+// 邮件通知配置
+type EmailConfig struct {
+	Host       string   `mapstructure:"host" yaml:"host" json:"host"`                      // SMTP服务器，为空时不发送邮件
+	Port       int      `mapstructure:"port" yaml:"port" json:"port"`                      // SMTP端口
+	Username   string   `mapstructure:"username" yaml:"username" json:"username"`          // 登录账号，为空时不认证
+	Password   string   `mapstructure:"password" yaml:"password" json:"password"`          // 登录密码或授权码
+	From       string   `mapstructure:"from" yaml:"from" json:"from"`                      // 发件人，为空时使用登录账号
+	To         []string `mapstructure:"to" yaml:"to" json:"to"`                            // 收件人列表
+	TLS        string   `mapstructure:"tls" yaml:"tls" json:"tls"`                         // 加密方式: starttls（明文连接后升级）、tls（直接TLS连接，通常为465端口）、none（不加密）
+	SkipVerify bool     `mapstructure:"skip_verify" yaml:"skip_verify" json:"skip_verify"` // 不校验服务器证书，仅用于自签名证书的内网服务器
+}
+
+// StorageKeyEnv 备份记录加密密钥的环境变量，配置文件未设置密钥时使用
+const StorageKeyEnv = "RECORD_CENTER_STORAGE_KEY"
+
+// 备份记录存储配置
+type StorageConfig struct {
+	EncryptionKey    string `mapstructure:"encryption_key" yaml:"encryption_key" json:"encryption_key"`             // 备份记录加密密钥，为空时读取环境变量，均为空则明文存储
+	ArchiveAfterDays int    `mapstructure:"archive_after_days" yaml:"archive_after_days" json:"archive_after_days"` // 超过该天数的备份记录移到 records_archive_YYYY.json，主文件只保留近期记录，0表示不归档
+}
+
+// ResolveEncryptionKey 获取备份记录加密密钥，配置优先，其次为环境变量
+func (s StorageConfig) ResolveEncryptionKey() string {
+	if s.EncryptionKey != "" {
+		return s.EncryptionKey
+	}
+	return os.Getenv(StorageKeyEnv)
+}
+
+// FilePath 获取日志文件路径，只写文件名时放在 logs 目录下
+func (l LoggingConfig) FilePath() string {
+	if l.File == "" || filepath.Base(l.File) != l.File {
+		return l.File
+	}
+	return filepath.Join("logs", l.File)
+}
+
 // 默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -79,23 +319,75 @@ func DefaultConfig() *Config {
 			BasePath:   "内部共享存储空间\\录音笔文件",
 			VID:        "2207",
 			PID:        "0011",
+			Storage:    "internal",
+			EncryptedExtensions: []string{},
+			DetectEncrypted:     false,
+			IgnoreFile:          ".recignore",
+			IncludeHidden:       false,
+			IncludeSystem:       false,
+			MinBatteryPercent:      0,
+			LowBatteryAction:       "refuse",
+			CriticalBatteryPercent: 5,
 		},
 		Target: TargetConfig{
-			BaseDirectory: "./backups",
-			CreateSubdirs: true,
+			BaseDirectory:    "./backups",
+			CreateSubdirs:    true,
+			Archive:          "none",
+			ArchiveSplitSize: "0",
+			Type:             "local",
+			S3: S3Config{
+				Region: "us-east-1",
+			},
+			PathTemplate: "",
+			DayParts: DayPartsConfig{
+				Morning:   "05:00",
+				Afternoon: "12:00",
+				Evening:   "18:00",
+				Night:     "22:00",
+			},
+			FileMode: "",
+			ReadOnly: false,
+			Rollover: "none",
+			Layout:   LayoutStructured,
 		},
 		Backup: BackupConfig{
 			FileExtensions:   []string{".opus"},
 			SkipExisting:     true,
 			PreserveStructure: true,
+			SyncMode:         "incremental",
+			SafeMode:         true,
+			OnError:          "continue",
 			MaxConcurrent:    3,
+			DeviceConcurrency: 0,
+			CommitInterval:   20,
+			SlowThreshold:    0.3,
+			PrehashMaxSize:   "50MB",
+			StabilityWait:    "2s",
+			StabilityWindow:  "10s",
+			Similar: SimilarConfig{
+				Enabled:           true,
+				DurationTolerance: "10s",
+				SizeTolerance:     0.05,
+			},
+			RangeDownload: RangeDownloadConfig{
+				Enabled:   true,
+				Threshold: "64MB",
+				Workers:   4,
+				ChunkSize: "8MB",
+			},
+			TrashRetention: "720h",
+			PerFileTimeout:  "10m",
+			PerFileMinSpeed: "64KB",
+			PreCopyTimeout:  "2m",
 		},
 		Logging: LoggingConfig{
 			Level:       "info",
 			File:        "record_center.log",
+			Format:      "text",
 			Console:     true,
 			RotateHours: 24,
 			MaxDays:     7,
+			RingSize:    0,
 		},
 		PowerShell: PowerShellConfig{
 			PreferredVersion:  "auto",
@@ -106,10 +398,20 @@ func DefaultConfig() *Config {
 			MaxRetries:        3,
 			RetryDelaySeconds: 1,
 		},
+		Sync: SyncConfig{
+			TimeoutSeconds: 30,
+		},
+		Notify: NotifyConfig{
+			On: "failure",
+			Email: EmailConfig{
+				Port: 587,
+				TLS:  "starttls",
+			},
+		},
 	}
 }
 
-// 加载配置文件
+// 加载配置文件，按扩展名选择 YAML/JSON/TOML 解析
 func LoadConfig(configPath string) (*Config, error) {
 	// 如果配置文件不存在，创建默认配置
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -121,7 +423,7 @@ func LoadConfig(configPath string) (*Config, error) {
 
 	// 设置配置文件路径和格式
 	viper.SetConfigFile(configPath)
-	viper.SetConfigType("yaml")
+	viper.SetConfigType(ConfigFormat(configPath))
 
 	// 设置默认值
 	defaultConfig := DefaultConfig()
@@ -129,17 +431,89 @@ func LoadConfig(configPath string) (*Config, error) {
 	viper.SetDefault("source.base_path", defaultConfig.Source.BasePath)
 	viper.SetDefault("source.vid", defaultConfig.Source.VID)
 	viper.SetDefault("source.pid", defaultConfig.Source.PID)
+	viper.SetDefault("source.storage", defaultConfig.Source.Storage)
+	viper.SetDefault("source.encrypted_extensions", defaultConfig.Source.EncryptedExtensions)
+	viper.SetDefault("source.detect_encrypted", defaultConfig.Source.DetectEncrypted)
+	viper.SetDefault("source.ignore_file", defaultConfig.Source.IgnoreFile)
+	viper.SetDefault("source.include_hidden", defaultConfig.Source.IncludeHidden)
+	viper.SetDefault("source.include_system", defaultConfig.Source.IncludeSystem)
+	viper.SetDefault("source.min_battery_percent", defaultConfig.Source.MinBatteryPercent)
+	viper.SetDefault("source.low_battery_action", defaultConfig.Source.LowBatteryAction)
+	viper.SetDefault("source.critical_battery_percent", defaultConfig.Source.CriticalBatteryPercent)
 	viper.SetDefault("target.base_directory", defaultConfig.Target.BaseDirectory)
 	viper.SetDefault("target.create_subdirs", defaultConfig.Target.CreateSubdirs)
+	viper.SetDefault("target.archive", defaultConfig.Target.Archive)
+	viper.SetDefault("target.archive_split_size", defaultConfig.Target.ArchiveSplitSize)
+	viper.SetDefault("target.type", defaultConfig.Target.Type)
+	viper.SetDefault("target.s3.endpoint", defaultConfig.Target.S3.Endpoint)
+	viper.SetDefault("target.s3.region", defaultConfig.Target.S3.Region)
+	viper.SetDefault("target.s3.bucket", defaultConfig.Target.S3.Bucket)
+	viper.SetDefault("target.s3.prefix", defaultConfig.Target.S3.Prefix)
+	viper.SetDefault("target.s3.access_key", defaultConfig.Target.S3.AccessKey)
+	viper.SetDefault("target.s3.secret_key", defaultConfig.Target.S3.SecretKey)
+	viper.SetDefault("target.path_template", defaultConfig.Target.PathTemplate)
+	viper.SetDefault("target.day_parts.morning", defaultConfig.Target.DayParts.Morning)
+	viper.SetDefault("target.day_parts.afternoon", defaultConfig.Target.DayParts.Afternoon)
+	viper.SetDefault("target.day_parts.evening", defaultConfig.Target.DayParts.Evening)
+	viper.SetDefault("target.day_parts.night", defaultConfig.Target.DayParts.Night)
+	viper.SetDefault("target.file_mode", defaultConfig.Target.FileMode)
+	viper.SetDefault("target.read_only", defaultConfig.Target.ReadOnly)
+	viper.SetDefault("target.rollover", defaultConfig.Target.Rollover)
+	viper.SetDefault("target.case_insensitive", defaultConfig.Target.CaseInsensitive)
+	viper.SetDefault("target.layout", defaultConfig.Target.Layout)
 	viper.SetDefault("backup.file_extensions", defaultConfig.Backup.FileExtensions)
 	viper.SetDefault("backup.skip_existing", defaultConfig.Backup.SkipExisting)
 	viper.SetDefault("backup.preserve_structure", defaultConfig.Backup.PreserveStructure)
+	viper.SetDefault("backup.sync_mode", defaultConfig.Backup.SyncMode)
+	viper.SetDefault("backup.on_error", defaultConfig.Backup.OnError)
+	viper.SetDefault("backup.copy_order", defaultConfig.Backup.CopyOrder)
+	viper.SetDefault("backup.reset_after_failures", defaultConfig.Backup.ResetAfterFailures)
+	viper.SetDefault("backup.safe_mode", defaultConfig.Backup.SafeMode)
 	viper.SetDefault("backup.max_concurrent", defaultConfig.Backup.MaxConcurrent)
+	viper.SetDefault("backup.global_max_concurrent", defaultConfig.Backup.GlobalMaxConcurrent)
+	viper.SetDefault("backup.device_concurrency", defaultConfig.Backup.DeviceConcurrency)
+	viper.SetDefault("backup.commit_interval", defaultConfig.Backup.CommitInterval)
+	viper.SetDefault("backup.daily_quota", defaultConfig.Backup.DailyQuota)
+	viper.SetDefault("backup.slow_threshold", defaultConfig.Backup.SlowThreshold)
+	viper.SetDefault("backup.slow_reconnect", defaultConfig.Backup.SlowReconnect)
+	viper.SetDefault("backup.batch_copy", defaultConfig.Backup.BatchCopy)
+	viper.SetDefault("backup.prehash_on_device", defaultConfig.Backup.PrehashOnDevice)
+	viper.SetDefault("backup.prehash_max_size", defaultConfig.Backup.PrehashMaxSize)
+	viper.SetDefault("backup.stability_wait", defaultConfig.Backup.StabilityWait)
+	viper.SetDefault("backup.stability_window", defaultConfig.Backup.StabilityWindow)
+	viper.SetDefault("backup.deep_verify", defaultConfig.Backup.DeepVerify)
+	viper.SetDefault("backup.write_sidecar", defaultConfig.Backup.WriteSidecar)
+	viper.SetDefault("backup.keep_versions", defaultConfig.Backup.KeepVersions)
+	viper.SetDefault("backup.follow_symlinks", defaultConfig.Backup.FollowSymlinks)
+	viper.SetDefault("backup.similar.enabled", defaultConfig.Backup.Similar.Enabled)
+	viper.SetDefault("backup.similar.duration_tolerance", defaultConfig.Backup.Similar.DurationTolerance)
+	viper.SetDefault("backup.similar.size_tolerance", defaultConfig.Backup.Similar.SizeTolerance)
+	viper.SetDefault("backup.similar.name_prefix", defaultConfig.Backup.Similar.NamePrefix)
+	viper.SetDefault("backup.range_download.enabled", defaultConfig.Backup.RangeDownload.Enabled)
+	viper.SetDefault("backup.range_download.threshold", defaultConfig.Backup.RangeDownload.Threshold)
+	viper.SetDefault("backup.range_download.workers", defaultConfig.Backup.RangeDownload.Workers)
+	viper.SetDefault("backup.range_download.chunk_size", defaultConfig.Backup.RangeDownload.ChunkSize)
+	viper.SetDefault("backup.filter.min_size", defaultConfig.Backup.Filter.MinSize)
+	viper.SetDefault("backup.filter.max_size", defaultConfig.Backup.Filter.MaxSize)
+	viper.SetDefault("backup.filter.modified_after", defaultConfig.Backup.Filter.ModifiedAfter)
+	viper.SetDefault("backup.filter.max_age", defaultConfig.Backup.Filter.MaxAge)
+	viper.SetDefault("backup.filter.include", defaultConfig.Backup.Filter.Include)
+	viper.SetDefault("backup.filter.exclude", defaultConfig.Backup.Filter.Exclude)
+	viper.SetDefault("backup.trash_dir", defaultConfig.Backup.TrashDir)
+	viper.SetDefault("backup.trash_retention", defaultConfig.Backup.TrashRetention)
+	viper.SetDefault("backup.per_file_timeout", defaultConfig.Backup.PerFileTimeout)
+	viper.SetDefault("backup.per_file_min_speed", defaultConfig.Backup.PerFileMinSpeed)
+	viper.SetDefault("backup.pre_copy_command", defaultConfig.Backup.PreCopyCommand)
+	viper.SetDefault("backup.pre_copy_timeout", defaultConfig.Backup.PreCopyTimeout)
+	viper.SetDefault("backup.strip_patterns", defaultConfig.Backup.StripPatterns)
 	viper.SetDefault("logging.level", defaultConfig.Logging.Level)
 	viper.SetDefault("logging.file", defaultConfig.Logging.File)
+	viper.SetDefault("logging.format", defaultConfig.Logging.Format)
 	viper.SetDefault("logging.console", defaultConfig.Logging.Console)
 	viper.SetDefault("logging.rotate_hours", defaultConfig.Logging.RotateHours)
 	viper.SetDefault("logging.max_days", defaultConfig.Logging.MaxDays)
+	viper.SetDefault("logging.ring_size", defaultConfig.Logging.RingSize)
+	viper.SetDefault("logging.redact", defaultConfig.Logging.Redact)
 
 	// PowerShell配置默认值
 	viper.SetDefault("powershell.preferred_version", defaultConfig.PowerShell.PreferredVersion)
@@ -149,6 +523,23 @@ func LoadConfig(configPath string) (*Config, error) {
 	viper.SetDefault("powershell.compatibility_mode", defaultConfig.PowerShell.CompatibilityMode)
 	viper.SetDefault("powershell.max_retries", defaultConfig.PowerShell.MaxRetries)
 	viper.SetDefault("powershell.retry_delay_seconds", defaultConfig.PowerShell.RetryDelaySeconds)
+	viper.SetDefault("sync.endpoint", defaultConfig.Sync.Endpoint)
+	viper.SetDefault("sync.api_key", defaultConfig.Sync.APIKey)
+	viper.SetDefault("sync.timeout_seconds", defaultConfig.Sync.TimeoutSeconds)
+	viper.SetDefault("storage.encryption_key", defaultConfig.Storage.EncryptionKey)
+	viper.SetDefault("storage.archive_after_days", defaultConfig.Storage.ArchiveAfterDays)
+	viper.SetDefault("notify.on", defaultConfig.Notify.On)
+	viper.SetDefault("notify.email.host", defaultConfig.Notify.Email.Host)
+	viper.SetDefault("notify.email.port", defaultConfig.Notify.Email.Port)
+	viper.SetDefault("notify.email.username", defaultConfig.Notify.Email.Username)
+	viper.SetDefault("notify.email.password", defaultConfig.Notify.Email.Password)
+	viper.SetDefault("notify.email.from", defaultConfig.Notify.Email.From)
+	viper.SetDefault("notify.email.to", defaultConfig.Notify.Email.To)
+	viper.SetDefault("notify.email.tls", defaultConfig.Notify.Email.TLS)
+	viper.SetDefault("notify.email.skip_verify", defaultConfig.Notify.Email.SkipVerify)
+	viper.SetDefault("metrics.listen_addr", defaultConfig.Metrics.ListenAddr)
+	viper.SetDefault("language", defaultConfig.Language)
+	viper.SetDefault("run_tasks_parallel", defaultConfig.RunTasksParallel)
 
 	// 打印调试信息
 	fmt.Printf("配置文件路径: %s\n", configPath)
@@ -174,6 +565,11 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
+	tasks, err := loadTasks(viper.GetViper())
+	if err != nil {
+		return nil, fmt.Errorf("解析任务配置失败: %w", err)
+	}
+	config.Tasks = tasks
 
 	// 打印配置调试信息
 	fmt.Printf("解析后的配置:\n")
@@ -186,8 +582,21 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("配置验证失败: %w", err)
 	}
 
-	// 处理相对路径
+	// 展开 ~ 和环境变量并处理相对路径
 	config.Target.BaseDirectory = resolvePath(config.Target.BaseDirectory)
+	if config.Backup.TempDir != "" {
+		config.Backup.TempDir = resolvePath(config.Backup.TempDir)
+	}
+	if config.Logging.File != "" {
+		config.Logging.File = resolvePath(config.Logging.FilePath())
+	}
+	for i := range config.Tasks {
+		task := &config.Tasks[i]
+		task.Target.BaseDirectory = resolvePath(task.Target.BaseDirectory)
+		if task.Backup.TempDir != "" {
+			task.Backup.TempDir = resolvePath(task.Backup.TempDir)
+		}
+	}
 
 	return &config, nil
 }
@@ -203,8 +612,8 @@ func createDefaultConfig(configPath string) error {
 	// 获取默认配置
 	config := DefaultConfig()
 
-	// 转换为YAML
-	data, err := yaml.Marshal(config)
+	// 按扩展名序列化
+	data, err := marshalConfig(config, ConfigFormat(configPath))
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
@@ -226,11 +635,88 @@ func validateConfig(config *Config) error {
 	if config.Source.BasePath == "" {
 		return fmt.Errorf("源路径不能为空")
 	}
+	if config.Source.Storage == "" {
+		config.Source.Storage = "internal"
+	}
+	validStorages := []string{"internal", "sd", "all"}
+	storageValid := false
+	for _, storage := range validStorages {
+		if config.Source.Storage == storage {
+			storageValid = true
+			break
+		}
+	}
+	if !storageValid {
+		return fmt.Errorf("无效的存储选择: %s，有效值: internal, sd, all", config.Source.Storage)
+	}
+	if err := validateBatteryConfig(&config.Source); err != nil {
+		return err
+	}
 
 	// 验证目标目录配置
 	if config.Target.BaseDirectory == "" {
 		return fmt.Errorf("目标目录不能为空")
 	}
+	if config.Target.Archive == "" {
+		config.Target.Archive = "none"
+	}
+	if config.Target.Archive != "none" && config.Target.Archive != "zip" {
+		return fmt.Errorf("无效的归档模式: %s，有效值: none, zip", config.Target.Archive)
+	}
+	if config.Target.Type == "" {
+		config.Target.Type = "local"
+	}
+	switch config.Target.Type {
+	case "local":
+	case "smb":
+		if !strings.HasPrefix(config.Target.BaseDirectory, `\\`) && !strings.HasPrefix(config.Target.BaseDirectory, "//") {
+			return fmt.Errorf("smb 目标的 base_directory 必须是UNC路径，如 \\\\nas\\share\\recordings")
+		}
+	case "s3":
+		if config.Target.S3.Endpoint == "" || config.Target.S3.Bucket == "" {
+			return fmt.Errorf("s3 目标必须配置 endpoint 和 bucket")
+		}
+		if config.Target.Archive == "zip" {
+			return fmt.Errorf("s3 目标不支持 zip 归档模式")
+		}
+	default:
+		return fmt.Errorf("无效的目标存储类型: %s，有效值: local, smb, s3", config.Target.Type)
+	}
+	if err := validatePathTemplate(config.Target.PathTemplate); err != nil {
+		return err
+	}
+	if err := validateDayParts(&config.Target.DayParts); err != nil {
+		return err
+	}
+	if _, err := utils.ParseFileMode(config.Target.FileMode); err != nil {
+		return err
+	}
+	if config.Target.Rollover == "" {
+		config.Target.Rollover = "none"
+	}
+	switch config.Target.Rollover {
+	case "none", "weekly", "monthly":
+	default:
+		return fmt.Errorf("无效的目录滚动周期: %s，有效值: none, weekly, monthly", config.Target.Rollover)
+	}
+	if config.Target.Type == "s3" && config.Target.Rollover != "none" {
+		return fmt.Errorf("s3 目标不支持按周期滚动目录")
+	}
+	if config.Target.Layout == "" {
+		config.Target.Layout = LayoutStructured
+	}
+	switch config.Target.Layout {
+	case LayoutStructured:
+	case LayoutContentAddressed:
+		if config.Target.Archive == "zip" || config.Target.Type == "s3" {
+			return fmt.Errorf("content-addressed 布局只支持本地和 smb 目标的松散文件，不支持 zip 归档和 s3 目标")
+		}
+		if config.Target.Rollover != "none" || config.Target.PathTemplate != "" {
+			return fmt.Errorf("content-addressed 布局按内容哈希分桶存放，不支持 rollover 和 path_template")
+		}
+	default:
+		return fmt.Errorf("无效的目标文件布局: %s，有效值: structured, content-addressed", config.Target.Layout)
+	}
 
 	// 验证备份配置
 	if len(config.Backup.FileExtensions) == 0 {
@@ -239,6 +725,102 @@ func validateConfig(config *Config) error {
 	if config.Backup.MaxConcurrent <= 0 {
 		config.Backup.MaxConcurrent = 1
 	}
+	if config.Backup.GlobalMaxConcurrent < 0 {
+		config.Backup.GlobalMaxConcurrent = 0
+	}
+	if config.Backup.DeviceConcurrency < 0 {
+		config.Backup.DeviceConcurrency = 0
+	}
+	if config.Backup.ResetAfterFailures < 0 {
+		config.Backup.ResetAfterFailures = 0
+	}
+	if err := validateRateLimits(&config.Backup); err != nil {
+		return err
+	}
+	perType, err := validateTypeRules(config.Backup.PerType)
+	if err != nil {
+		return err
+	}
+	config.Backup.PerType = perType
+	if config.Backup.CommitInterval < 0 {
+		config.Backup.CommitInterval = 0
+	}
+	if config.Backup.KeepVersions < 0 {
+		config.Backup.KeepVersions = 0
+	}
+	if config.Storage.ArchiveAfterDays < 0 {
+		config.Storage.ArchiveAfterDays = 0
+	}
+	if err := validateSimilarConfig(&config.Backup.Similar); err != nil {
+		return err
+	}
+	if err := validateRangeDownloadConfig(&config.Backup.RangeDownload); err != nil {
+		return err
+	}
+	if err := validateFilterConfig(&config.Backup.Filter); err != nil {
+		return err
+	}
+	if config.Backup.TrashRetention != "" {
+		if d, err := utils.ParseDuration(config.Backup.TrashRetention); err != nil || d < 0 {
+			return fmt.Errorf("无效的回收站保留期: %s", config.Backup.TrashRetention)
+		}
+	}
+	if config.Backup.PerFileTimeout != "" {
+		if d, err := utils.ParseDuration(config.Backup.PerFileTimeout); err != nil || d < 0 {
+			return fmt.Errorf("无效的单文件超时: %s", config.Backup.PerFileTimeout)
+		}
+	}
+	if config.Backup.PerFileMinSpeed != "" {
+		if _, err := utils.ParseByteSize(config.Backup.PerFileMinSpeed); err != nil {
+			return fmt.Errorf("无效的单文件超时最低速度: %s", config.Backup.PerFileMinSpeed)
+		}
+	}
+	if config.Backup.PreCopyCommand != "" {
+		if _, err := utils.SplitCommandLine(config.Backup.PreCopyCommand); err != nil {
+			return fmt.Errorf("无效的复制前校验命令: %v", err)
+		}
+	}
+	if config.Backup.PreCopyTimeout != "" {
+		if d, err := utils.ParseDuration(config.Backup.PreCopyTimeout); err != nil || d < 0 {
+			return fmt.Errorf("无效的复制前校验超时: %s", config.Backup.PreCopyTimeout)
+		}
+	}
+	for _, pattern := range config.Backup.StripPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("无效的垃圾文件规则: %s", pattern)
+		}
+	}
+	if config.Backup.SlowThreshold < 0 || config.Backup.SlowThreshold >= 1 {
+		return fmt.Errorf("无效的慢速告警阈值: %v，有效范围: 0 到 1（不含1），0表示不检测", config.Backup.SlowThreshold)
+	}
+	if config.Backup.SyncMode == "" {
+		config.Backup.SyncMode = "incremental"
+	}
+	if config.Backup.SyncMode != "incremental" && config.Backup.SyncMode != "mirror" {
+		return fmt.Errorf("无效的同步模式: %s，有效值: incremental, mirror", config.Backup.SyncMode)
+	}
+	if config.Backup.OnError == "" {
+		config.Backup.OnError = "continue"
+	}
+	if config.Backup.OnError != "continue" && config.Backup.OnError != "stop" && config.Backup.OnError != "stop-on-fatal" {
+		return fmt.Errorf("无效的错误策略: %s，有效值: continue, stop, stop-on-fatal", config.Backup.OnError)
+	}
+	switch config.Backup.CopyOrder {
+	case "", "newest", "smallest", "largest", "name":
+	default:
+		return fmt.Errorf("无效的复制顺序: %s，有效值: newest, smallest, largest, name", config.Backup.CopyOrder)
+	}
+
+	aliases, err := validateDeviceAliases(config.DeviceAliases)
+	if err != nil {
+		return err
+	}
+	config.DeviceAliases = aliases
+
+	// 验证界面语言
+	if config.Language != "" && config.Language != "zh" && config.Language != "en" {
+		return fmt.Errorf("无效的界面语言: %s，有效值: zh, en", config.Language)
+	}
 
 	// 验证日志配置
 	validLogLevels := []string{"debug", "info", "warn", "error"}
@@ -252,6 +834,12 @@ func validateConfig(config *Config) error {
 	if !levelValid {
 		return fmt.Errorf("无效的日志级别: %s", config.Logging.Level)
 	}
+	if config.Logging.Format == "" {
+		config.Logging.Format = "text"
+	}
+	if config.Logging.Format != "text" && config.Logging.Format != "json" {
+		return fmt.Errorf("无效的日志格式: %s，有效值: text, json", config.Logging.Format)
+	}
 
 	if config.Logging.RotateHours <= 0 {
 		config.Logging.RotateHours = 24
@@ -259,17 +847,38 @@ func validateConfig(config *Config) error {
 	if config.Logging.MaxDays <= 0 {
 		config.Logging.MaxDays = 7
 	}
+	if config.Logging.RingSize < 0 {
+		config.Logging.RingSize = 0
+	}
 
 	// 验证PowerShell配置
 	if err := validatePowerShellConfig(&config.PowerShell); err != nil {
 		return fmt.Errorf("PowerShell配置验证失败: %w", err)
 	}
 
+	// 验证通知配置
+	if err := validateNotifyConfig(&config.Notify); err != nil {
+		return fmt.Errorf("通知配置验证失败: %w", err)
+	}
+
+	// 验证指标导出地址
+	if addr := config.Metrics.ListenAddr; addr != "" {
+		if _, port, err := net.SplitHostPort(addr); err != nil || port == "" {
+			return fmt.Errorf("无效的指标监听地址: %s，应为 host:port 或 :port", addr)
+		}
+	}
+
+	// 验证任务配置
+	if err := validateTasks(config); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // 解析路径（处理相对路径）
 func resolvePath(path string) string {
+	path = expandPath(path)
 	if filepath.IsAbs(path) {
 		return path
 	}
@@ -283,8 +892,254 @@ func resolvePath(path string) string {
 	return absPath
 }
 
+var (
+	// windowsEnvPattern 匹配 %VAR% 形式的环境变量
+	windowsEnvPattern = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
+	// unixEnvPattern 匹配 $VAR 和 ${VAR} 形式的环境变量
+	unixEnvPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+	// pathPlaceholderPattern 匹配目录模板中的 {name} 占位符
+	pathPlaceholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+)
+
+// expandPath 展开路径开头的 ~ 为用户主目录，并展开 %VAR%、$VAR、${VAR} 形式的环境变量
+// 未定义的环境变量保持原样
+func expandPath(path string) string {
+	path = windowsEnvPattern.ReplaceAllStringFunc(path, func(match string) string {
+		if value, ok := os.LookupEnv(match[1 : len(match)-1]); ok {
+			return value
+		}
+		return match
+	})
+
+	path = unixEnvPattern.ReplaceAllStringFunc(path, func(match string) string {
+		name := strings.Trim(match, "${}")
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return match
+	})
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~\\") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+
+	return path
+}
+
+// validatePathTemplate 检查目录模板中的占位符是否受支持
+func validatePathTemplate(template string) error {
+	for _, match := range pathPlaceholderPattern.FindAllString(template, -1) {
+		switch match {
+		case "{daypart}", "{weekday}", "{weektype}", "{device}":
+		default:
+			return fmt.Errorf("无效的目录模板占位符: %s，有效值: {daypart}, {weekday}, {weektype}, {device}", match)
+		}
+	}
+	return nil
+}
+
+// validateRateLimits 检查每日配额和时段限速的格式
+func validateRateLimits(backup *BackupConfig) error {
+	if backup.DailyQuota != "" && backup.DailyQuota != "0" {
+		if _, err := utils.ParseByteSize(backup.DailyQuota); err != nil {
+			return fmt.Errorf("无效的每日配额: %s，格式如 \"20GB\"", backup.DailyQuota)
+		}
+	}
+	for _, window := range backup.Schedule {
+		if _, _, err := ParseTimeRange(window.TimeRange); err != nil {
+			return fmt.Errorf("无效的限速时段: %w", err)
+		}
+		if _, err := utils.ParseByteSize(window.MaxBytesPerSecond); err != nil {
+			return fmt.Errorf("无效的时段限速: %s，格式如 \"2MB\"", window.MaxBytesPerSecond)
+		}
+	}
+	return nil
+}
+
+// validateFilterConfig 验证文件过滤条件的大小、日期、时长和 glob 格式
+func validateFilterConfig(filter *FilterConfig) error {
+	var minSize, maxSize int64
+	var err error
+	if filter.MinSize != "" {
+		if minSize, err = utils.ParseByteSize(filter.MinSize); err != nil {
+			return fmt.Errorf("无效的过滤最小大小: %s，格式如 \"10KB\"", filter.MinSize)
+		}
+	}
+	if filter.MaxSize != "" {
+		if maxSize, err = utils.ParseByteSize(filter.MaxSize); err != nil {
+			return fmt.Errorf("无效的过滤最大大小: %s，格式如 \"2GB\"", filter.MaxSize)
+		}
+	}
+	if minSize > 0 && maxSize > 0 && minSize > maxSize {
+		return fmt.Errorf("过滤最小大小 %s 大于最大大小 %s", filter.MinSize, filter.MaxSize)
+	}
+	if filter.ModifiedAfter != "" {
+		if _, err := time.ParseInLocation(FilterDateLayout, filter.ModifiedAfter, time.Local); err != nil {
+			return fmt.Errorf("无效的过滤起始日期: %s，格式如 \"2024-01-01\"", filter.ModifiedAfter)
+		}
+	}
+	if filter.MaxAge != "" {
+		if d, err := utils.ParseDuration(filter.MaxAge); err != nil || d < 0 {
+			return fmt.Errorf("无效的过滤最长时间: %s", filter.MaxAge)
+		}
+	}
+	for _, pattern := range append(append([]string(nil), filter.Include...), filter.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("无效的过滤规则: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// validateRangeDownloadConfig 验证分片并行下载的阈值、分片大小和并行数
+func validateRangeDownloadConfig(rangeDownload *RangeDownloadConfig) error {
+	if !rangeDownload.Enabled {
+		return nil
+	}
+	if size, err := utils.ParseByteSize(rangeDownload.Threshold); err != nil || size <= 0 {
+		return fmt.Errorf("无效的分片下载阈值: %s", rangeDownload.Threshold)
+	}
+	if size, err := utils.ParseByteSize(rangeDownload.ChunkSize); err != nil || size <= 0 {
+		return fmt.Errorf("无效的分片大小: %s", rangeDownload.ChunkSize)
+	}
+	if rangeDownload.Workers < 1 {
+		return fmt.Errorf("无效的分片并行数: %d，至少为1", rangeDownload.Workers)
+	}
+	return nil
+}
+
+// validateSimilarConfig 验证疑似重复检测的阈值
+func validateSimilarConfig(similar *SimilarConfig) error {
+	if !similar.Enabled {
+		return nil
+	}
+	if similar.DurationTolerance != "" {
+		if d, err := utils.ParseDuration(similar.DurationTolerance); err != nil || d < 0 {
+			return fmt.Errorf("无效的重复检测时长阈值: %s", similar.DurationTolerance)
+		}
+	}
+	if similar.SizeTolerance < 0 || similar.SizeTolerance >= 1 {
+		return fmt.Errorf("无效的重复检测大小阈值: %v，有效范围: 0 到 1（不含1）", similar.SizeTolerance)
+	}
+	if similar.NamePrefix < 0 {
+		similar.NamePrefix = 0
+	}
+	return nil
+}
+
+// validateDeviceAliases 检查设备别名，键统一为大写（viper 读取时会转为小写），别名会用作目录名，不能为空或包含路径非法字符
+func validateDeviceAliases(aliases map[string]string) (map[string]string, error) {
+	if len(aliases) == 0 {
+		return aliases, nil
+	}
+
+	normalized := make(map[string]string, len(aliases))
+	for key, alias := range aliases {
+		id := strings.ToUpper(strings.TrimSpace(key))
+		if id == "" {
+			return nil, fmt.Errorf("设备别名的 DeviceID 或序列号不能为空")
+		}
+		alias = strings.TrimSpace(alias)
+		if alias == "" || alias == "." || alias == ".." || strings.ContainsAny(alias, `\/:*?"<>|`) {
+			return nil, fmt.Errorf("设备 %s 的别名无效: %q，不能为空或包含 \\ / : * ? \" < > |", key, alias)
+		}
+		if _, exists := normalized[id]; exists {
+			return nil, fmt.Errorf("设备 %s 的别名重复", key)
+		}
+		normalized[id] = alias
+	}
+	return normalized, nil
+}
+
+// validateTypeRules 检查按扩展名配置的规则，扩展名统一为小写并以 . 开头
+func validateTypeRules(rules map[string]TypeRule) (map[string]TypeRule, error) {
+	if len(rules) == 0 {
+		return rules, nil
+	}
+
+	normalized := make(map[string]TypeRule, len(rules))
+	for ext, rule := range rules {
+		key := strings.ToLower(strings.TrimSpace(ext))
+		if key == "" || key == "." {
+			return nil, fmt.Errorf("按类型规则的扩展名不能为空")
+		}
+		if !strings.HasPrefix(key, ".") {
+			key = "." + key
+		}
+		if _, exists := normalized[key]; exists {
+			return nil, fmt.Errorf("扩展名 %s 的规则重复", key)
+		}
+
+		dir := strings.ReplaceAll(rule.Directory, "\\", "/")
+		if filepath.IsAbs(rule.Directory) || strings.HasPrefix(dir, "/") || filepath.VolumeName(rule.Directory) != "" {
+			return nil, fmt.Errorf("扩展名 %s 的目标子目录必须是相对路径: %s", key, rule.Directory)
+		}
+		for _, part := range strings.Split(dir, "/") {
+			if part == ".." {
+				return nil, fmt.Errorf("扩展名 %s 的目标子目录不能包含 ..: %s", key, rule.Directory)
+			}
+		}
+
+		if strings.ContainsAny(rule.Rename, "/\\") {
+			return nil, fmt.Errorf("扩展名 %s 的文件名模板不能包含路径分隔符: %s", key, rule.Rename)
+		}
+		for _, match := range pathPlaceholderPattern.FindAllString(rule.Rename, -1) {
+			switch match {
+			case "{name}", "{ext}", "{date}", "{time}":
+			default:
+				return nil, fmt.Errorf("扩展名 %s 的文件名模板占位符无效: %s，有效值: {name}, {ext}, {date}, {time}", key, match)
+			}
+		}
+		normalized[key] = rule
+	}
+	return normalized, nil
+}
+
+// validateDayParts 检查时段开始时间，空值使用默认值，且必须按时间先后排列
+func validateDayParts(dayParts *DayPartsConfig) error {
+	defaults := DefaultConfig().Target.DayParts
+	fields := []struct {
+		value    *string
+		fallback string
+	}{
+		{&dayParts.Morning, defaults.Morning},
+		{&dayParts.Afternoon, defaults.Afternoon},
+		{&dayParts.Evening, defaults.Evening},
+		{&dayParts.Night, defaults.Night},
+	}
+
+	previous := -1
+	for _, field := range fields {
+		if *field.value == "" {
+			*field.value = field.fallback
+		}
+		minutes, err := ParseClock(*field.value)
+		if err != nil {
+			return fmt.Errorf("无效的时段配置: %w", err)
+		}
+		if minutes <= previous {
+			return fmt.Errorf("无效的时段配置: 时段开始时间必须按 morning、afternoon、evening、night 的顺序递增")
+		}
+		previous = minutes
+	}
+	return nil
+}
+
 // 验证PowerShell配置
 func validatePowerShellConfig(config *PowerShellConfig) error {
+	// 未配置的项使用默认值
+	if config.PreferredVersion == "" {
+		config.PreferredVersion = "auto"
+	}
+	if config.ExecutionPolicy == "" {
+		config.ExecutionPolicy = "Bypass"
+	}
+	if config.CompatibilityMode == "" {
+		config.CompatibilityMode = "strict"
+	}
+
 	// 验证首选版本
 	validVersions := []string{"auto", "5.1", "7.x", "5", "7"}
 	versionValid := false
@@ -348,8 +1203,8 @@ func validatePowerShellConfig(config *PowerShellConfig) error {
 
 // 保存配置
 func SaveConfig(config *Config, configPath string) error {
-	// 序列化配置
-	data, err := yaml.Marshal(config)
+	// 按扩展名序列化配置
+	data, err := marshalConfig(config, ConfigFormat(configPath))
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
@@ -366,4 +1221,53 @@ func SaveConfig(config *Config, configPath string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// 验证设备电量检查配置
+func validateBatteryConfig(source *SourceConfig) error {
+	if source.MinBatteryPercent < 0 || source.MinBatteryPercent > 100 {
+		return fmt.Errorf("无效的最低电量: %d，应在 0-100 之间", source.MinBatteryPercent)
+	}
+	if source.CriticalBatteryPercent < 0 || source.CriticalBatteryPercent > 100 {
+		return fmt.Errorf("无效的危险电量: %d，应在 0-100 之间", source.CriticalBatteryPercent)
+	}
+	if source.LowBatteryAction == "" {
+		source.LowBatteryAction = "refuse"
+	}
+	if source.LowBatteryAction != "refuse" && source.LowBatteryAction != "warn" {
+		return fmt.Errorf("无效的低电量处理方式: %s，有效值: refuse, warn", source.LowBatteryAction)
+	}
+	return nil
+}
+
+// 验证通知配置
+func validateNotifyConfig(config *NotifyConfig) error {
+	if config.On == "" {
+		config.On = "failure"
+	}
+	if config.On != "always" && config.On != "failure" && config.On != "never" {
+		return fmt.Errorf("无效的通知时机: %s，有效值: always, failure, never", config.On)
+	}
+
+	email := &config.Email
+	if email.TLS == "" {
+		email.TLS = "starttls"
+	}
+	if email.TLS != "starttls" && email.TLS != "tls" && email.TLS != "none" {
+		return fmt.Errorf("无效的邮件加密方式: %s，有效值: starttls, tls, none", email.TLS)
+	}
+	if email.Port < 0 || email.Port > 65535 {
+		return fmt.Errorf("无效的SMTP端口: %d", email.Port)
+	}
+	if email.Port == 0 {
+		email.Port = 587
+		if email.TLS == "tls" {
+			email.Port = 465
+		}
+	}
+	if email.Host != "" && len(email.To) == 0 {
+		return fmt.Errorf("已配置SMTP服务器但未配置收件人")
+	}
+
+	return nil
+}
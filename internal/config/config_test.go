@@ -29,6 +29,9 @@ func TestDefaultConfig(t *testing.T) {
 	if config.Source.PID != "0011" {
 		t.Errorf("期望PID为 '0011'，实际为 '%s'", config.Source.PID)
 	}
+	if config.Source.IncludeHidden || config.Source.IncludeSystem {
+		t.Error("期望默认跳过设备上的隐藏和系统文件")
+	}
 
 	// 验证目标配置
 	if config.Target.BaseDirectory != "./backups" {
@@ -71,6 +74,9 @@ func TestDefaultConfig(t *testing.T) {
 	if config.Logging.MaxDays != 7 {
 		t.Errorf("期望保留天数为 7，实际为 %d", config.Logging.MaxDays)
 	}
+	if config.Logging.RingSize != 0 {
+		t.Errorf("期望默认不开启环形缓冲，实际保留 %d 条", config.Logging.RingSize)
+	}
 }
 
 // TestLoadConfig_CreateDefault 测试创建默认配置文件
@@ -138,6 +144,9 @@ func TestLoadConfig_ExistingFile(t *testing.T) {
 		Backup: BackupConfig{
 			FileExtensions: []string{".mp3", ".wav"},
 			MaxConcurrent:  5,
+			PerType: map[string]TypeRule{
+				"wav": {Enabled: true, Directory: "wav", Rename: "{date}_{name}.{ext}"},
+			},
 		},
 		Logging: LoggingConfig{
 			Level: "debug",
@@ -175,6 +184,9 @@ func TestLoadConfig_ExistingFile(t *testing.T) {
 	if config.Backup.MaxConcurrent != 5 {
 		t.Errorf("期望最大并发数为 5，实际为 %d", config.Backup.MaxConcurrent)
 	}
+	if rule, ok := config.Backup.TypeRuleFor("REC001.wav"); !ok || rule.Directory != "wav" || rule.Rename != "{date}_{name}.{ext}" {
+		t.Errorf("wav 的按类型规则加载不正确: %+v, %v", rule, ok)
+	}
 }
 
 // TestLoadConfig_InvalidYAML 测试加载无效的YAML文件
@@ -353,6 +365,431 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectError: false, // 应该被修正为1
 		},
+		{
+			name: "无效的目录模板占位符",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+					PathTemplate:  "{weektype}/{hour}",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的目录模板占位符",
+		},
+		{
+			name: "时段开始时间未递增",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+					PathTemplate:  "{daypart}",
+					DayParts: DayPartsConfig{
+						Morning:   "09:00",
+						Afternoon: "08:00",
+					},
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的时段配置",
+		},
+		{
+			name: "无效的错误策略",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					OnError:        "abort",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的错误策略",
+		},
+		{
+			name: "无效的慢速告警阈值",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					SlowThreshold:  1.5,
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的慢速告警阈值",
+		},
+		{
+			name: "无效的通知时机",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+				Notify: NotifyConfig{On: "sometimes"},
+			},
+			expectError: true,
+			errorMsg:    "无效的通知时机",
+		},
+		{
+			name: "邮件通知缺少收件人",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+				Notify: NotifyConfig{Email: EmailConfig{Host: "smtp.example.com"}},
+			},
+			expectError: true,
+			errorMsg:    "未配置收件人",
+		},
+		{
+			name: "按类型规则的文件名模板占位符无效",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					PerType: map[string]TypeRule{
+						".wav": {Enabled: true, Rename: "{month}_{name}.{ext}"},
+					},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "文件名模板占位符无效",
+		},
+		{
+			name: "按类型规则的子目录不能跳出目标目录",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					PerType: map[string]TypeRule{
+						".wav": {Enabled: true, Directory: "../wav"},
+					},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "不能包含 ..",
+		},
+		{
+			name: "无效的每日配额",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					DailyQuota:     "很多",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的每日配额",
+		},
+		{
+			name: "无效的限速时段",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					Schedule:       []RateWindow{{TimeRange: "08:00", MaxBytesPerSecond: "2MB"}},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的限速时段",
+		},
+		{
+			name: "有效的配额和跨午夜限速时段",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					DailyQuota:     "20GB",
+					Schedule:       []RateWindow{{TimeRange: "22:00-06:00", MaxBytesPerSecond: "512KB"}},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "无效的重复检测时长阈值",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					Similar:        SimilarConfig{Enabled: true, DurationTolerance: "很久"},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的重复检测时长阈值",
+		},
+		{
+			name: "无效的重复检测大小阈值",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					Similar:        SimilarConfig{Enabled: true, DurationTolerance: "10s", SizeTolerance: 1.5},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的重复检测大小阈值",
+		},
+		{
+			name: "关闭重复检测时不验证阈值",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					Similar:        SimilarConfig{Enabled: false, SizeTolerance: 1.5},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "无效的分片并行数",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					RangeDownload:  RangeDownloadConfig{Enabled: true, Threshold: "64MB", ChunkSize: "8MB", Workers: 0},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的分片并行数",
+		},
+		{
+			name: "过滤最小大小大于最大大小",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					Filter:         FilterConfig{MinSize: "10MB", MaxSize: "1MB"},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "大于最大大小",
+		},
+		{
+			name: "无效的过滤规则",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					Filter:         FilterConfig{Exclude: []string{"[会议"}},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的过滤规则",
+		},
+		{
+			name: "无效的回收站保留期",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					TrashRetention: "30天",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的回收站保留期",
+		},
+		{
+			name: "无效的单文件超时",
+			config: Config{
+				Source: SourceConfig{
+					DeviceName: "SR302",
+					BasePath:   "/test/path",
+				},
+				Target: TargetConfig{
+					BaseDirectory: "/backup",
+				},
+				Backup: BackupConfig{
+					FileExtensions: []string{".opus"},
+					MaxConcurrent:  3,
+					PerFileTimeout: "十分钟",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorMsg:    "无效的单文件超时",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -377,6 +814,214 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+// TestValidateConfig_DeviceConcurrency 测试设备并发数为负时按不限制处理
+func TestValidateConfig_DeviceConcurrency(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Backup.DeviceConcurrency = -1
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("验证配置失败: %v", err)
+	}
+	if cfg.Backup.DeviceConcurrency != 0 {
+		t.Errorf("设备并发数 = %d，期望 0", cfg.Backup.DeviceConcurrency)
+	}
+}
+
+// TestValidateConfig_Rollover 测试目录滚动周期的默认值与校验
+func TestValidateConfig_Rollover(t *testing.T) {
+	tests := []struct {
+		name       string
+		targetType string
+		rollover   string
+		want       string
+		wantErr    bool
+	}{
+		{"空值默认不滚动", "local", "", "none", false},
+		{"按周", "local", "weekly", "weekly", false},
+		{"按月", "local", "monthly", "monthly", false},
+		{"无效周期", "local", "daily", "", true},
+		{"s3不支持滚动", "s3", "weekly", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Target.Type = tt.targetType
+			cfg.Target.S3.Endpoint = "http://nas:9000"
+			cfg.Target.S3.Bucket = "recordings"
+			cfg.Target.Rollover = tt.rollover
+			err := validateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfig() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.Target.Rollover != tt.want {
+				t.Errorf("滚动周期 = %s，期望 %s", cfg.Target.Rollover, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_KeepVersions 测试负数的保留版本数按不保留处理
+func TestValidateConfig_KeepVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		keep int
+		want int
+	}{
+		{"默认不保留", 0, 0},
+		{"保留两版", 2, 2},
+		{"负数按不保留", -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Backup.KeepVersions = tt.keep
+			if err := validateConfig(cfg); err != nil {
+				t.Fatalf("validateConfig() 错误 = %v", err)
+			}
+			if cfg.Backup.KeepVersions != tt.want {
+				t.Errorf("保留版本数 = %d，期望 %d", cfg.Backup.KeepVersions, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_Layout 测试内容寻址布局不能与 zip 归档、s3、按周期滚动和目录模板同时使用
+func TestValidateConfig_Layout(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr bool
+	}{
+		{"默认按目录结构", func(cfg *Config) { cfg.Target.Layout = "" }, false},
+		{"内容寻址", func(cfg *Config) {}, false},
+		{"内容寻址不支持zip", func(cfg *Config) { cfg.Target.Archive = "zip" }, true},
+		{"内容寻址不支持滚动目录", func(cfg *Config) { cfg.Target.Rollover = "weekly" }, true},
+		{"内容寻址不支持目录模板", func(cfg *Config) { cfg.Target.PathTemplate = "{daypart}" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Target.Layout = LayoutContentAddressed
+			tt.modify(cfg)
+			if err := validateConfig(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_PreCopyCommand 测试复制前校验命令的引号必须闭合，超时必须能解析
+func TestValidateConfig_PreCopyCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		timeout string
+		wantErr bool
+	}{
+		{"未配置", "", "2m", false},
+		{"带引号的路径", `"C:\Program Files\AV\scan.exe" /quiet "{file}"`, "2m", false},
+		{"引号未闭合", `"C:\Program Files\AV\scan.exe /quiet {file}`, "2m", true},
+		{"超时无效", "scan.exe {file}", "两分钟", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Backup.PreCopyCommand = tt.command
+			cfg.Backup.PreCopyTimeout = tt.timeout
+			err := validateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_DeviceAliases 测试设备别名的键不区分大小写，别名不能用作目录名时报错
+func TestValidateConfig_DeviceAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		keys    []string
+		want    string
+		wantErr bool
+	}{
+		{"未配置别名", nil, []string{"USB\\VID_2207&PID_0011\\ABC123"}, "", false},
+		{"按DeviceID匹配（viper读取后为小写）", map[string]string{"usb\\vid_2207&pid_0011\\abc123": "会议室"}, []string{"USB\\VID_2207&PID_0011\\ABC123", "ABC123"}, "会议室", false},
+		{"按序列号匹配", map[string]string{"abc123": " 张三 "}, []string{"USB\\VID_2207&PID_0011\\ABC123", "ABC123"}, "张三", false},
+		{"都不匹配", map[string]string{"xyz789": "李四"}, []string{"USB\\VID_2207&PID_0011\\ABC123", "ABC123"}, "", false},
+		{"别名为空", map[string]string{"abc123": " "}, nil, "", true},
+		{"别名包含路径分隔符", map[string]string{"abc123": "会议/室"}, nil, "", true},
+		{"别名为..", map[string]string{"abc123": ".."}, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.DeviceAliases = tt.aliases
+			err := validateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfig() 错误 = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := cfg.DeviceAlias(tt.keys...); got != tt.want {
+				t.Errorf("DeviceAlias() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_MetricsListenAddr 测试指标监听地址的格式
+func TestValidateConfig_MetricsListenAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr bool
+	}{
+		{"未配置", "", false},
+		{"只有端口", ":9102", false},
+		{"指定地址", "127.0.0.1:9102", false},
+		{"缺少端口", "127.0.0.1", true},
+		{"端口为空", "127.0.0.1:", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Metrics.ListenAddr = tt.addr
+			if err := validateConfig(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() 错误 = %v, 期望错误 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_TypeRules 测试按类型规则的扩展名统一为小写并补全点号
+func TestValidateConfig_TypeRules(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Backup.PerType = map[string]TypeRule{
+		"WAV":   {Enabled: true, Directory: "wav", Rename: "{date}_{name}.{ext}"},
+		".opus": {Enabled: false, Directory: "opus"},
+	}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("验证配置失败: %v", err)
+	}
+
+	if rule, ok := cfg.Backup.TypeRuleFor("REC001.Wav"); !ok || rule.Directory != "wav" {
+		t.Errorf("REC001.Wav 应匹配 .wav 规则，实际 %+v, %v", rule, ok)
+	}
+	if _, ok := cfg.Backup.TypeRuleFor("REC001.opus"); ok {
+		t.Error("未启用的 .opus 规则不应生效")
+	}
+	if _, ok := cfg.Backup.TypeRuleFor("REC001.mp3"); ok {
+		t.Error("未配置的 .mp3 不应匹配任何规则")
+	}
+}
+
 // TestResolvePath 测试路径解析
 func TestResolvePath(t *testing.T) {
 	testCases := []struct {
@@ -420,6 +1065,78 @@ func TestResolvePath(t *testing.T) {
 	}
 }
 
+// TestExpandPath 测试 ~ 和环境变量展开
+func TestExpandPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("无法获取用户主目录: %v", err)
+	}
+	t.Setenv("RC_TEST_DIR", "/data/rec")
+	os.Unsetenv("RC_TEST_UNDEFINED")
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"主目录", "~", home},
+		{"主目录子路径", "~/recordings", filepath.Join(home, "recordings")},
+		{"主目录反斜杠", "~\\recordings", filepath.Join(home, "\\recordings")},
+		{"Windows风格变量", "%RC_TEST_DIR%/backups", "/data/rec/backups"},
+		{"Unix风格变量", "$RC_TEST_DIR/backups", "/data/rec/backups"},
+		{"Unix风格花括号变量", "${RC_TEST_DIR}_old", "/data/rec_old"},
+		{"未定义变量保持原样", "%RC_TEST_UNDEFINED%/$RC_TEST_UNDEFINED", "%RC_TEST_UNDEFINED%/$RC_TEST_UNDEFINED"},
+		{"中间的波浪号不展开", "backup~1", "backup~1"},
+		{"普通路径", "./backups", "./backups"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := expandPath(tc.input); result != tc.expected {
+				t.Errorf("expandPath(%q) = %q，期望 %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+// TestLoadConfig_ExpandPaths 测试加载配置时展开路径字段
+func TestLoadConfig_ExpandPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("RC_TEST_ROOT", tempDir)
+
+	configContent := `source:
+  device_name: "SR302"
+  base_path: "录音笔文件"
+target:
+  base_directory: "$RC_TEST_ROOT/backups"
+backup:
+  file_extensions: [".opus"]
+  temp_dir: "%RC_TEST_ROOT%/temp"
+logging:
+  level: "info"
+  file: "${RC_TEST_ROOT}/logs/backup.log"
+`
+	configPath := filepath.Join(tempDir, "backup.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	if cfg.Target.BaseDirectory != filepath.Join(tempDir, "backups") {
+		t.Errorf("目标目录未展开: %s", cfg.Target.BaseDirectory)
+	}
+	if cfg.Backup.TempDir != filepath.Join(tempDir, "temp") {
+		t.Errorf("临时目录未展开: %s", cfg.Backup.TempDir)
+	}
+	if cfg.Logging.File != filepath.Join(tempDir, "logs", "backup.log") {
+		t.Errorf("日志文件路径未展开: %s", cfg.Logging.File)
+	}
+}
+
 // TestSaveConfig 测试保存配置
 func TestSaveConfig(t *testing.T) {
 	// 创建临时目录
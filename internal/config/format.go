@@ -0,0 +1,55 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的配置文件格式
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// ConfigFormat 根据配置文件扩展名（.yaml/.yml/.json/.toml）返回格式，其他扩展名按 YAML 处理
+func ConfigFormat(configPath string) string {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// marshalConfig 按格式序列化配置，键名与 YAML 配置文件一致
+func marshalConfig(config *Config, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FormatTOML:
+		// TOML 编码器不识别 yaml 标签，先转成以配置键名为键的 map
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		var settings map[string]interface{}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("转换配置失败: %w", err)
+		}
+		return toml.Marshal(settings)
+	default:
+		return yaml.Marshal(config)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// TestConfigFormat 测试根据扩展名选择配置格式
+func TestConfigFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"backup.yaml", FormatYAML},
+		{"backup.yml", FormatYAML},
+		{"backup.JSON", FormatJSON},
+		{filepath.Join("configs", "backup.toml"), FormatTOML},
+		{"backup", FormatYAML},
+	}
+	for _, tt := range tests {
+		if got := ConfigFormat(tt.path); got != tt.want {
+			t.Errorf("ConfigFormat(%q) = %s, 期望 %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestSaveConfig_Formats 测试同样的配置以 YAML/JSON/TOML 保存后加载得到等价的配置
+func TestSaveConfig_Formats(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := DefaultConfig()
+	cfg.Source.DeviceName = "SR502"
+	cfg.Source.EncryptedExtensions = []string{".enc", ".lock"}
+	cfg.Target.BaseDirectory = filepath.Join(tempDir, "backups")
+	cfg.Backup.MaxConcurrent = 5
+	cfg.Backup.FileExtensions = []string{".opus", ".mp3"}
+	cfg.Backup.RangeDownload.Workers = 8
+
+	loaded := make(map[string]*Config)
+	for _, name := range []string{"backup.yaml", "backup.json", "backup.toml"} {
+		configPath := filepath.Join(tempDir, name)
+		if err := SaveConfig(cfg, configPath); err != nil {
+			t.Fatalf("保存 %s 失败: %v", name, err)
+		}
+		got, err := LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("加载 %s 失败: %v", name, err)
+		}
+		if got.Source.DeviceName != "SR502" || got.Backup.MaxConcurrent != 5 || got.Backup.RangeDownload.Workers != 8 {
+			t.Errorf("%s 加载的配置与保存的不一致: %+v", name, got)
+		}
+		loaded[ConfigFormat(name)] = got
+	}
+
+	// 空列表在 JSON 中可能解析为 nil，按序列化结果比较
+	want, err := yaml.Marshal(loaded[FormatYAML])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{FormatJSON, FormatTOML} {
+		got, err := yaml.Marshal(loaded[format])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s 加载的配置与 YAML 不等价:\n%s\n%s", format, got, want)
+		}
+	}
+}
+
+// TestLoadConfig_CreateDefaultFormats 测试按目标扩展名生成对应格式的默认配置
+func TestLoadConfig_CreateDefaultFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		unmarshal func(data []byte, v interface{}) error
+	}{
+		{"backup.json", json.Unmarshal},
+		{"backup.toml", toml.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.name)
+			if _, err := LoadConfig(configPath); err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatalf("读取配置文件失败: %v", err)
+			}
+			var settings map[string]interface{}
+			if err := tt.unmarshal(data, &settings); err != nil {
+				t.Fatalf("默认配置不是 %s 格式: %v", ConfigFormat(tt.name), err)
+			}
+			source, ok := settings["source"].(map[string]interface{})
+			if !ok || source["device_name"] != "SR302" {
+				t.Errorf("默认配置中的设备名称错误: %v", settings["source"])
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MigrateResult 配置迁移的结果
+type MigrateResult struct {
+	Added      []string // 补齐的配置项（如 powershell.timeout_seconds），按写入顺序排列
+	BackupPath string   // 原配置文件的备份，没有写回时为空
+}
+
+// MigrateConfig 升级旧版 YAML 配置文件：补齐当前版本新增的配置项（取默认值），保留用户已设置的值、注释和未知项，
+// 写回前把原文件备份为 <配置文件>.<时间>.bak。
+// template 为带注释的配置模板，新增项的注释取自模板中同名项的注释，模板中没有的项不加注释。
+// 没有需要补齐的项时不改动文件
+func MigrateConfig(configPath string, template []byte) (*MigrateResult, error) {
+	if format := ConfigFormat(configPath); format != FormatYAML {
+		return nil, fmt.Errorf("只支持迁移 YAML 配置文件，当前为 %s: %s", format, configPath)
+	}
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if doc.Kind == 0 {
+		// 空文件
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("配置文件顶层不是映射: %s", configPath)
+	}
+
+	defaults, err := defaultsNode()
+	if err != nil {
+		return nil, err
+	}
+	comments, err := templateComments(template)
+	if err != nil {
+		return nil, fmt.Errorf("解析配置模板失败: %w", err)
+	}
+
+	result := &MigrateResult{}
+	mergeDefaults(root, defaults, "", comments, &result.Added)
+	if len(result.Added) == 0 {
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	result.BackupPath = fmt.Sprintf("%s.%s.bak", configPath, time.Now().Format("20060102_150405"))
+	if err := os.WriteFile(result.BackupPath, original, 0644); err != nil {
+		return nil, fmt.Errorf("备份原配置文件失败: %w", err)
+	}
+	if err := os.WriteFile(configPath, buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return result, nil
+}
+
+// defaultsNode 返回默认配置序列化后的顶层映射节点，键的顺序与结构体字段一致
+func defaultsNode() (*yaml.Node, error) {
+	data, err := yaml.Marshal(DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("序列化默认配置失败: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析默认配置失败: %w", err)
+	}
+	return doc.Content[0], nil
+}
+
+// templateComments 收集模板中每个配置项（以点号连接的键路径）的行尾注释，配置段没有行尾注释时取上方的注释
+func templateComments(template []byte) (map[string]string, error) {
+	comments := make(map[string]string)
+	if len(template) == 0 {
+		return comments, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(template, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return comments, nil
+	}
+
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := prefix + key.Value
+			comment := firstNonEmpty(value.LineComment, key.LineComment)
+			if comment == "" && value.Kind == yaml.MappingNode {
+				// 配置段的说明写在段名上方，其他项上方的注释可能是被注释掉的示例
+				comment = key.HeadComment
+			}
+			if comment != "" {
+				comments[path] = strings.TrimSpace(strings.TrimPrefix(lastLine(comment), "#"))
+			}
+			if value.Kind == yaml.MappingNode {
+				walk(value, path+".")
+			}
+		}
+	}
+	walk(doc.Content[0], "")
+	return comments, nil
+}
+
+// mergeDefaults 把 defaults 中 node 缺少的键（连同默认值）追加到 node，两者都是映射时递归补齐；
+// 已有的值保持不变，补齐的键路径追加到 added
+func mergeDefaults(node, defaults *yaml.Node, prefix string, comments map[string]string, added *[]string) {
+	existing := make(map[string]*yaml.Node, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		existing[node.Content[i].Value] = node.Content[i+1]
+	}
+
+	for i := 0; i+1 < len(defaults.Content); i += 2 {
+		key, value := defaults.Content[i], defaults.Content[i+1]
+		path := prefix + key.Value
+		if current, ok := existing[key.Value]; ok {
+			if current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+				mergeDefaults(current, value, path+".", comments, added)
+			}
+			continue
+		}
+
+		newKey, newValue := copyNode(key), copyNode(value)
+		annotate(newKey, newValue, path, comments)
+		node.Content = append(node.Content, newKey, newValue)
+		*added = append(*added, path)
+	}
+}
+
+// annotate 为补齐的配置项及其子项加上模板中的注释
+func annotate(key, value *yaml.Node, path string, comments map[string]string) {
+	if comment, ok := comments[path]; ok {
+		if value.Kind == yaml.MappingNode {
+			key.LineComment = "# " + comment
+		} else {
+			value.LineComment = "# " + comment
+		}
+	}
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			annotate(value.Content[i], value.Content[i+1], path+"."+value.Content[i].Value, comments)
+		}
+	}
+}
+
+// copyNode 深拷贝节点
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+	return &copied
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// lastLine 返回多行注释的最后一行，即紧挨配置项的那一行
+func lastLine(comment string) string {
+	lines := strings.Split(strings.TrimRight(comment, "\n"), "\n")
+	return lines[len(lines)-1]
+}
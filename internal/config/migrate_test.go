@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// oldConfig 早期版本的配置文件，没有 powershell 段和断点续传等后来新增的配置项
+const oldConfig = `# 我的录音笔配置
+source:
+  device_name: "SR999"   # 自己的设备
+  base_path: "内部共享存储空间\\录音笔文件"
+  vid: "1234"
+  pid: "5678"
+target:
+  base_directory: "D:\\录音备份"
+backup:
+  file_extensions: [".opus", ".wav"]
+  max_concurrent: 1
+logging:
+  level: debug
+`
+
+// migrateTemplate 带注释的配置模板片段
+const migrateTemplate = `
+backup:
+  enable_resume: true                      # 启用断点续传功能
+# PowerShell 兼容性配置
+powershell:
+  timeout_seconds: 30                      # 命令执行超时时间（秒）
+`
+
+// TestMigrateConfig 测试旧配置迁移后包含全部配置项、原有设置和注释不变、原文件已备份，再次迁移不做改动
+func TestMigrateConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "backup.yaml")
+	if err := os.WriteFile(configPath, []byte(oldConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := MigrateConfig(configPath, []byte(migrateTemplate))
+	if err != nil {
+		t.Fatalf("迁移配置失败: %v", err)
+	}
+	for _, key := range []string{"powershell", "backup.enable_resume", "backup.resume_max_age", "source.storage", "storage"} {
+		if !containsString(result.Added, key) {
+			t.Errorf("补齐的配置项中缺少 %s: %v", key, result.Added)
+		}
+	}
+	if containsString(result.Added, "source.device_name") || containsString(result.Added, "backup.max_concurrent") {
+		t.Errorf("已设置的配置项不应被补齐: %v", result.Added)
+	}
+
+	// 原文件原样备份
+	backup, err := os.ReadFile(result.BackupPath)
+	if err != nil || string(backup) != oldConfig {
+		t.Errorf("原配置文件备份 = %q, %v", backup, err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrated := string(data)
+
+	// 包含默认配置的全部配置项
+	var got, want map[string]interface{}
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("迁移后的配置无法解析: %v", err)
+	}
+	defaults, err := yaml.Marshal(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(defaults, &want); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range settingKeys(want, "") {
+		if !containsString(settingKeys(got, ""), key) {
+			t.Errorf("迁移后缺少配置项 %s", key)
+		}
+	}
+
+	// 原有的设置和注释不变
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Source.DeviceName != "SR999" || cfg.Source.VID != "1234" || cfg.Target.BaseDirectory != `D:\录音备份` ||
+		cfg.Backup.MaxConcurrent != 1 || len(cfg.Backup.FileExtensions) != 2 || cfg.Logging.Level != "debug" {
+		t.Errorf("原有设置被改动: %+v", cfg)
+	}
+	if cfg.PowerShell.TimeoutSeconds != 30 || cfg.Logging.RotateHours != 24 {
+		t.Errorf("新增项应取默认值: powershell.timeout_seconds=%d, logging.rotate_hours=%d", cfg.PowerShell.TimeoutSeconds, cfg.Logging.RotateHours)
+	}
+	for _, comment := range []string{"# 我的录音笔配置", "# 自己的设备", "# 启用断点续传功能", "# 命令执行超时时间（秒）", "# PowerShell 兼容性配置"} {
+		if !strings.Contains(migrated, comment) {
+			t.Errorf("迁移后的配置缺少注释 %q", comment)
+		}
+	}
+
+	// 已是最新时不再改写
+	again, err := MigrateConfig(configPath, []byte(migrateTemplate))
+	if err != nil {
+		t.Fatalf("再次迁移失败: %v", err)
+	}
+	if len(again.Added) != 0 || again.BackupPath != "" {
+		t.Errorf("再次迁移 = %+v，期望无改动", again)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != migrated {
+		t.Error("已是最新的配置文件不应被改写")
+	}
+}
+
+// settingKeys 返回配置中全部以点号连接的键路径
+func settingKeys(settings map[string]interface{}, prefix string) []string {
+	var keys []string
+	for key, value := range settings {
+		keys = append(keys, prefix+key)
+		if child, ok := value.(map[string]interface{}); ok {
+			keys = append(keys, settingKeys(child, prefix+key+".")...)
+		}
+	}
+	return keys
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/viper"
+)
+
+// TaskConfig 一个备份任务：把某个设备备份到某个目的地，可使用与顶层不同的规则
+type TaskConfig struct {
+	Name    string       `mapstructure:"name" yaml:"name" json:"name"`          // 任务名称，用于 run --task 选择，只能包含字母、数字、- 和 _
+	Enabled bool         `mapstructure:"enabled" yaml:"enabled" json:"enabled"` // 不带 --task 运行时是否执行，默认 true
+	Source  SourceConfig `mapstructure:"source" yaml:"source" json:"source"`
+	Target  TargetConfig `mapstructure:"target" yaml:"target" json:"target"`
+	Backup  BackupConfig `mapstructure:"backup" yaml:"backup" json:"backup"`
+}
+
+// taskNamePattern 任务名称同时用作任务数据目录名，只允许安全的字符
+var taskNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// loadTasks 解析 tasks 列表，每个任务以顶层的 source/target/backup（含默认值）为基础，
+// 再合并任务中设置的项
+func loadTasks(v *viper.Viper) ([]TaskConfig, error) {
+	raw := v.Get("tasks")
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("tasks 必须是列表")
+	}
+
+	settings := v.AllSettings()
+	tasks := make([]TaskConfig, 0, len(items))
+	for i, item := range items {
+		overrides, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第 %d 个任务格式错误", i+1)
+		}
+
+		tv := viper.New()
+		tv.SetDefault("enabled", true)
+		base := map[string]interface{}{
+			"source": settings["source"],
+			"target": settings["target"],
+			"backup": settings["backup"],
+		}
+		if err := tv.MergeConfigMap(base); err != nil {
+			return nil, fmt.Errorf("第 %d 个任务合并顶层配置失败: %w", i+1, err)
+		}
+		if err := tv.MergeConfigMap(overrides); err != nil {
+			return nil, fmt.Errorf("第 %d 个任务合并配置失败: %w", i+1, err)
+		}
+
+		var task TaskConfig
+		if err := tv.Unmarshal(&task); err != nil {
+			return nil, fmt.Errorf("第 %d 个任务解析失败: %w", i+1, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// validateTasks 检查任务名称，并按完整配置验证每个任务的 source/target/backup
+func validateTasks(config *Config) error {
+	names := make(map[string]bool, len(config.Tasks))
+	for i := range config.Tasks {
+		task := &config.Tasks[i]
+		if task.Name == "" {
+			return fmt.Errorf("第 %d 个任务缺少名称", i+1)
+		}
+		if !taskNamePattern.MatchString(task.Name) {
+			return fmt.Errorf("无效的任务名称: %s，只能包含字母、数字、- 和 _", task.Name)
+		}
+		if names[task.Name] {
+			return fmt.Errorf("任务名称重复: %s", task.Name)
+		}
+		names[task.Name] = true
+
+		taskConfig := config.ForTask(*task)
+		if err := validateConfig(taskConfig); err != nil {
+			return fmt.Errorf("任务 %s 配置验证失败: %w", task.Name, err)
+		}
+		// 验证时补全的默认值和规范化结果写回任务
+		task.Source = taskConfig.Source
+		task.Target = taskConfig.Target
+		task.Backup = taskConfig.Backup
+	}
+	return nil
+}
+
+// ForTask 返回执行该任务使用的完整配置：source/target/backup 取自任务，其余沿用当前配置
+func (c *Config) ForTask(task TaskConfig) *Config {
+	taskConfig := *c
+	taskConfig.Source = task.Source
+	taskConfig.Target = task.Target
+	taskConfig.Backup = task.Backup
+	taskConfig.Tasks = nil
+	return &taskConfig
+}
+
+// SelectTasks 选择要执行的任务：name 为空时返回所有启用的任务，否则返回同名任务（即使未启用）
+func (c *Config) SelectTasks(name string) ([]TaskConfig, error) {
+	if len(c.Tasks) == 0 {
+		return nil, fmt.Errorf("配置文件中没有定义任务 (tasks)")
+	}
+
+	if name != "" {
+		for _, task := range c.Tasks {
+			if task.Name == name {
+				return []TaskConfig{task}, nil
+			}
+		}
+		return nil, fmt.Errorf("未找到任务: %s", name)
+	}
+
+	var tasks []TaskConfig
+	for _, task := range c.Tasks {
+		if task.Enabled {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("没有启用的任务")
+	}
+	return tasks, nil
+}
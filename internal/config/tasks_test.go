@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tasksConfigContent = `source:
+  device_name: "SR302"
+  base_path: "录音笔文件"
+target:
+  base_directory: "/backup/default"
+backup:
+  file_extensions: [".opus"]
+  max_concurrent: 2
+run_tasks_parallel: true
+tasks:
+  - name: nightly
+    target:
+      base_directory: "/backup/nightly"
+    backup:
+      sync_mode: "mirror"
+  - name: office
+    source:
+      device_name: "SR502"
+      storage: "sd"
+    target:
+      base_directory: "/backup/office"
+    backup:
+      file_extensions: [".wav", ".mp3"]
+      max_concurrent: 4
+  - name: archive
+    enabled: false
+    target:
+      base_directory: "/backup/archive"
+      archive: "zip"
+`
+
+// loadTasksConfig 写入多任务配置文件并加载
+func loadTasksConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "backup.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadConfig(configPath)
+}
+
+// TestLoadConfig_Tasks 测试解析多任务，未设置的项沿用顶层配置
+func TestLoadConfig_Tasks(t *testing.T) {
+	cfg, err := loadTasksConfig(t, tasksConfigContent)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	if !cfg.RunTasksParallel {
+		t.Error("run_tasks_parallel 应为 true")
+	}
+	if len(cfg.Tasks) != 3 {
+		t.Fatalf("任务数 = %d, 期望 3", len(cfg.Tasks))
+	}
+
+	nightly, office, archive := cfg.Tasks[0], cfg.Tasks[1], cfg.Tasks[2]
+	if nightly.Name != "nightly" || !nightly.Enabled {
+		t.Errorf("nightly 任务解析错误: %+v", nightly)
+	}
+	if nightly.Source.DeviceName != "SR302" || nightly.Source.BasePath != "录音笔文件" {
+		t.Errorf("nightly 应沿用顶层 source: %+v", nightly.Source)
+	}
+	if !reflect.DeepEqual(nightly.Backup.FileExtensions, []string{".opus"}) || nightly.Backup.MaxConcurrent != 2 {
+		t.Errorf("nightly 应沿用顶层 backup: %v, %d", nightly.Backup.FileExtensions, nightly.Backup.MaxConcurrent)
+	}
+	if nightly.Backup.SyncMode != "mirror" {
+		t.Errorf("nightly sync_mode = %s, 期望 mirror", nightly.Backup.SyncMode)
+	}
+	if !strings.HasSuffix(filepath.ToSlash(nightly.Target.BaseDirectory), "/backup/nightly") {
+		t.Errorf("nightly 目标目录 = %s", nightly.Target.BaseDirectory)
+	}
+	// 顶层未配置的项使用默认值
+	if nightly.Backup.HashAlgorithm != DefaultConfig().Backup.HashAlgorithm {
+		t.Errorf("nightly 应使用默认的哈希算法，实际 %s", nightly.Backup.HashAlgorithm)
+	}
+
+	if office.Source.DeviceName != "SR502" || office.Source.Storage != "sd" || office.Source.BasePath != "录音笔文件" {
+		t.Errorf("office source 解析错误: %+v", office.Source)
+	}
+	if !reflect.DeepEqual(office.Backup.FileExtensions, []string{".wav", ".mp3"}) || office.Backup.MaxConcurrent != 4 {
+		t.Errorf("office backup 解析错误: %v, %d", office.Backup.FileExtensions, office.Backup.MaxConcurrent)
+	}
+
+	if archive.Enabled || archive.Target.Archive != "zip" {
+		t.Errorf("archive 任务解析错误: %+v", archive)
+	}
+
+	// 顶层配置不受任务影响
+	if cfg.Source.DeviceName != "SR302" || cfg.Backup.MaxConcurrent != 2 || cfg.Backup.SyncMode == "mirror" {
+		t.Errorf("顶层配置被任务修改: %+v", cfg.Backup)
+	}
+}
+
+// TestConfig_SelectTasks 测试按名称选择任务和选择所有启用的任务
+func TestConfig_SelectTasks(t *testing.T) {
+	cfg, err := loadTasksConfig(t, tasksConfigContent)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		task    string
+		want    []string
+		wantErr bool
+	}{
+		{"全部启用的任务", "", []string{"nightly", "office"}, false},
+		{"指定任务", "office", []string{"office"}, false},
+		{"指定未启用的任务", "archive", []string{"archive"}, false},
+		{"未知任务", "weekly", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := cfg.SelectTasks(tt.task)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectTasks(%q) 错误 = %v, 期望出错 %v", tt.task, err, tt.wantErr)
+			}
+			var names []string
+			for _, task := range tasks {
+				names = append(names, task.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("SelectTasks(%q) = %v, 期望 %v", tt.task, names, tt.want)
+			}
+		})
+	}
+
+	if _, err := DefaultConfig().SelectTasks(""); err == nil {
+		t.Error("未定义任务时应返回错误")
+	}
+}
+
+// TestConfig_ForTask 测试每个任务使用各自的 source/target/backup，其余配置共享
+func TestConfig_ForTask(t *testing.T) {
+	cfg, err := loadTasksConfig(t, tasksConfigContent)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	tasks, err := cfg.SelectTasks("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, task := range tasks {
+		taskConfig := cfg.ForTask(task)
+		if taskConfig.Source.DeviceName != task.Source.DeviceName ||
+			taskConfig.Target.BaseDirectory != task.Target.BaseDirectory ||
+			taskConfig.Backup.MaxConcurrent != task.Backup.MaxConcurrent {
+			t.Errorf("任务 %s 未使用自己的配置", task.Name)
+		}
+		if taskConfig.Logging != cfg.Logging || taskConfig.Tasks != nil {
+			t.Errorf("任务 %s 应沿用顶层其余配置且不含任务列表", task.Name)
+		}
+		seen[taskConfig.Target.BaseDirectory] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("两个任务应备份到不同目录: %v", seen)
+	}
+}
+
+// TestLoadConfig_InvalidTasks 测试任务名称和任务配置的验证
+func TestLoadConfig_InvalidTasks(t *testing.T) {
+	header := `source:
+  device_name: "SR302"
+  base_path: "录音笔文件"
+target:
+  base_directory: "/backup"
+tasks:
+`
+	tests := []struct {
+		name  string
+		tasks string
+		want  string
+	}{
+		{"缺少名称", "  - target:\n      base_directory: \"/a\"\n", "缺少名称"},
+		{"名称含路径分隔符", "  - name: \"a/b\"\n", "无效的任务名称"},
+		{"名称重复", "  - name: a\n  - name: a\n", "任务名称重复"},
+		{"任务配置无效", "  - name: a\n    source:\n      storage: \"usb\"\n", "任务 a 配置验证失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTasksConfig(t, header+tt.tasks)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("期望错误包含 %q，实际 %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package device
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// USB 设备类别，根据描述符（类代码、驱动服务、设备安装类）判断
+const (
+	ClassPortableMedia = "PORTABLE_MEDIA" // MTP/PTP 便携媒体设备，暴露 WPD 接口
+	ClassMassStorage   = "MASS_STORAGE"   // USB 大容量存储（U 盘、读卡器等）
+	ClassAudio         = "AUDIO"          // USB 音频设备（麦克风、声卡等）
+	ClassOther         = "OTHER"          // 描述符表明是其他类别（集线器、HID 等）
+	ClassUnknown       = "UNKNOWN"        // 没有可用的描述符信息
+)
+
+// USB 接口类代码
+const (
+	usbClassAudio       = "01"
+	usbClassStillImage  = "06" // PTP/MTP 使用静态图像类
+	usbClassMassStorage = "08"
+)
+
+// recordingKeywords 名称中明确表示录音设备的关键词，只在描述符无法判断类别时作为辅助依据
+var recordingKeywords = []string{"录音", "RECORDER", "VOICE", "IC RECORDER", "DICTAPHONE"}
+
+// queryPnPDescriptors 查询 USB 即插即用设备的描述信息（wmic list 格式），测试中替换
+var queryPnPDescriptors = func() (string, error) {
+	cmd := exec.Command("wmic", "path", "win32_pnpentity", "where", `(deviceid like 'USB%')`,
+		"get", "deviceid,name,pnpclass,service,compatibleid", "/format:list")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("执行WMI查询失败: %w", err)
+	}
+	return string(output), nil
+}
+
+// PnPEntity 一个即插即用设备实例的描述信息，复合设备的每个接口是一个实例
+type PnPEntity struct {
+	DeviceID      string
+	Name          string
+	PNPClass      string   // 设备安装类，如 WPD、DiskDrive、USB
+	Service       string   // 驱动服务，如 WUDFWpdMtp、USBSTOR
+	CompatibleIDs []string // 兼容ID，包含 USB 类代码，如 USB\Class_06&SubClass_01&Prot_01
+}
+
+// parsePnPDescriptors 解析 wmic /format:list 输出，实例之间以空行分隔
+func parsePnPDescriptors(output string) []*PnPEntity {
+	var entities []*PnPEntity
+	current := &PnPEntity{}
+	flush := func() {
+		if current.DeviceID != "" {
+			entities = append(entities, current)
+		}
+		current = &PnPEntity{}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "deviceid":
+			if current.DeviceID != "" {
+				flush()
+			}
+			current.DeviceID = value
+		case "name":
+			current.Name = value
+		case "pnpclass":
+			current.PNPClass = value
+		case "service":
+			current.Service = value
+		case "compatibleid":
+			current.CompatibleIDs = parseWMIArray(value)
+		}
+	}
+	flush()
+	return entities
+}
+
+// parseWMIArray 解析 wmic 输出的数组值，如 {"a","b"}
+func parseWMIArray(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}")
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.Trim(strings.TrimSpace(item), `"`); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// usbClassCodes 从兼容ID中提取 USB 接口类代码（大写十六进制）
+func usbClassCodes(compatibleIDs []string) []string {
+	var codes []string
+	for _, id := range compatibleIDs {
+		upper := strings.ToUpper(id)
+		i := strings.Index(upper, "CLASS_")
+		if i < 0 || i+len("CLASS_")+2 > len(upper) {
+			continue
+		}
+		// 排除 SubClass_
+		if i > 0 && upper[i-1] != '\\' && upper[i-1] != '&' {
+			continue
+		}
+		codes = append(codes, upper[i+len("CLASS_"):i+len("CLASS_")+2])
+	}
+	return codes
+}
+
+// ClassifyPnPEntity 根据描述符判断设备实例的类别：
+// 设备安装类为 WPD、驱动为 WPD MTP 驱动、声明了 MTP 兼容ID或静态图像类（PTP/MTP）时为便携媒体设备
+func ClassifyPnPEntity(entity *PnPEntity) string {
+	pnpClass := strings.ToUpper(entity.PNPClass)
+	service := strings.ToUpper(entity.Service)
+
+	if pnpClass == "WPD" || strings.HasPrefix(service, "WUDFWPDMTP") {
+		return ClassPortableMedia
+	}
+	for _, id := range entity.CompatibleIDs {
+		if strings.Contains(strings.ToUpper(id), "MS_COMP_MTP") {
+			return ClassPortableMedia
+		}
+	}
+	if service == "USBSTOR" || service == "UASPSTOR" || pnpClass == "DISKDRIVE" {
+		return ClassMassStorage
+	}
+
+	codes := usbClassCodes(entity.CompatibleIDs)
+	for _, code := range codes {
+		switch code {
+		case usbClassStillImage:
+			return ClassPortableMedia
+		case usbClassMassStorage:
+			return ClassMassStorage
+		case usbClassAudio:
+			return ClassAudio
+		}
+	}
+	if len(codes) > 0 || pnpClass != "" || service != "" {
+		return ClassOther
+	}
+	return ClassUnknown
+}
+
+// hasRecordingKeyword 名称是否包含明确表示录音设备的关键词
+func hasRecordingKeyword(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, keyword := range recordingKeywords {
+		if strings.Contains(upper, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// usbDeviceGroup 同一 VID/PID 的设备实例（复合设备的各个接口）
+type usbDeviceGroup struct {
+	vid, pid string
+	entities []*PnPEntity
+}
+
+// classify 返回设备的类别和用于展示的实例：任一接口是便携媒体设备即视为便携媒体设备
+func (g *usbDeviceGroup) classify() (string, *PnPEntity) {
+	priority := map[string]int{ClassPortableMedia: 4, ClassAudio: 3, ClassMassStorage: 2, ClassOther: 1, ClassUnknown: 0}
+	class, display := ClassUnknown, g.entities[0]
+	for _, entity := range g.entities {
+		if c := ClassifyPnPEntity(entity); priority[c] > priority[class] {
+			class, display = c, entity
+		}
+	}
+	return class, display
+}
+
+// ScanRecordingDevices 扫描 USB 设备，按描述符筛选可能的录音设备：
+// 便携媒体设备（MTP/PTP，暴露 WPD 接口）视为录音设备；大容量存储和音频设备只有名称明确含录音关键词时才算；
+// 没有描述符信息的设备退回关键词匹配
+func ScanRecordingDevices(log *logger.Logger) ([]*DeviceInfo, error) {
+	output, err := queryPnPDescriptors()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*usbDeviceGroup)
+	var keys []string
+	for _, entity := range parsePnPDescriptors(output) {
+		vid, pid := extractVIDPID(entity.DeviceID)
+		if vid == "" || pid == "" {
+			continue
+		}
+		key := vid + ":" + pid
+		if groups[key] == nil {
+			groups[key] = &usbDeviceGroup{vid: vid, pid: pid}
+			keys = append(keys, key)
+		}
+		groups[key].entities = append(groups[key].entities, entity)
+	}
+	sort.Strings(keys)
+
+	var devices []*DeviceInfo
+	for _, key := range keys {
+		group := groups[key]
+		class, display := group.classify()
+
+		recording := false
+		switch class {
+		case ClassPortableMedia:
+			recording = true
+		case ClassMassStorage, ClassAudio, ClassUnknown:
+			for _, entity := range group.entities {
+				if hasRecordingKeyword(entity.Name) {
+					recording = true
+					break
+				}
+			}
+		}
+		if !recording {
+			log.Debug("忽略USB设备: %s (VID:%s, PID:%s, 类别:%s)", display.Name, group.vid, group.pid, class)
+			continue
+		}
+
+		log.Debug("发现潜在的录音设备: %s (VID:%s, PID:%s, 类别:%s)", display.Name, group.vid, group.pid, class)
+		devices = append(devices, &DeviceInfo{
+			DeviceID:    display.DeviceID,
+			Name:        display.Name,
+			VID:         group.vid,
+			PID:         group.pid,
+			IsMTP:       class == ClassPortableMedia,
+			ConnectedAt: time.Now(),
+		})
+	}
+	return devices, nil
+}
@@ -0,0 +1,129 @@
+package device
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// mockPnPOutput 模拟 wmic /format:list 的输出：SR302（MTP，复合设备）、U 盘、USB 麦克风、集线器、
+// 名称含录音关键词的大容量存储录音笔
+const mockPnPOutput = "\r\r\n\r\r\n" +
+	"CompatibleID={\"USB\\DevClass_00&SubClass_00&Prot_00\",\"USB\\COMPOSITE\"}\r\r\n" +
+	"DeviceID=USB\\VID_2207&PID_0011\\0123456789ABCDEF\r\r\n" +
+	"Name=USB Composite Device\r\r\n" +
+	"PNPClass=USB\r\r\n" +
+	"Service=usbccgp\r\r\n" +
+	"\r\r\n" +
+	"CompatibleID={\"USB\\MS_COMP_MTP\",\"USB\\Class_06&SubClass_01&Prot_01\",\"USB\\Class_06&SubClass_01\",\"USB\\Class_06\"}\r\r\n" +
+	"DeviceID=USB\\VID_2207&PID_0011&MI_00\\6&1A2B3C4D&0&0000\r\r\n" +
+	"Name=SR302\r\r\n" +
+	"PNPClass=WPD\r\r\n" +
+	"Service=WUDFWpdMtp\r\r\n" +
+	"\r\r\n" +
+	"CompatibleID={\"USB\\Class_08&SubClass_06&Prot_50\",\"USB\\Class_08&SubClass_06\",\"USB\\Class_08\"}\r\r\n" +
+	"DeviceID=USB\\VID_0951&PID_1666\\E0D55EA574B1F8B0\r\r\n" +
+	"Name=USB Mass Storage Device\r\r\n" +
+	"PNPClass=USB\r\r\n" +
+	"Service=USBSTOR\r\r\n" +
+	"\r\r\n" +
+	"CompatibleID={\"USB\\Class_01&SubClass_01&Prot_00\",\"USB\\Class_01&SubClass_01\",\"USB\\Class_01\"}\r\r\n" +
+	"DeviceID=USB\\VID_046D&PID_0A44&MI_00\\7&2B3C4D5E&0&0000\r\r\n" +
+	"Name=USB Audio Device\r\r\n" +
+	"PNPClass=MEDIA\r\r\n" +
+	"Service=usbaudio\r\r\n" +
+	"\r\r\n" +
+	"CompatibleID={\"USB\\Class_09&SubClass_00&Prot_00\",\"USB\\Class_09\"}\r\r\n" +
+	"DeviceID=USB\\VID_2109&PID_2817\\000000000\r\r\n" +
+	"Name=Generic USB Hub\r\r\n" +
+	"PNPClass=USB\r\r\n" +
+	"Service=USBHUB3\r\r\n" +
+	"\r\r\n" +
+	"CompatibleID={\"USB\\Class_08&SubClass_06&Prot_50\",\"USB\\Class_08\"}\r\r\n" +
+	"DeviceID=USB\\VID_054C&PID_0387\\5A4F8E\r\r\n" +
+	"Name=Sony IC Recorder\r\r\n" +
+	"PNPClass=USB\r\r\n" +
+	"Service=USBSTOR\r\r\n" +
+	"\r\r\n"
+
+// TestParsePnPDescriptors 测试解析 wmic list 格式的描述信息
+func TestParsePnPDescriptors(t *testing.T) {
+	entities := parsePnPDescriptors(mockPnPOutput)
+	if len(entities) != 6 {
+		t.Fatalf("期望 6 个设备实例，实际 %d 个", len(entities))
+	}
+
+	sr302 := entities[1]
+	if sr302.Name != "SR302" || sr302.PNPClass != "WPD" || sr302.Service != "WUDFWpdMtp" {
+		t.Errorf("SR302 实例解析错误: %+v", sr302)
+	}
+	wantIDs := []string{"USB\\MS_COMP_MTP", "USB\\Class_06&SubClass_01&Prot_01", "USB\\Class_06&SubClass_01", "USB\\Class_06"}
+	if !reflect.DeepEqual(sr302.CompatibleIDs, wantIDs) {
+		t.Errorf("兼容ID = %q, 期望 %q", sr302.CompatibleIDs, wantIDs)
+	}
+}
+
+// TestClassifyPnPEntity 测试根据描述符判断设备类别
+func TestClassifyPnPEntity(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity PnPEntity
+		want   string
+	}{
+		{"WPD设备安装类", PnPEntity{PNPClass: "WPD"}, ClassPortableMedia},
+		{"WPD MTP驱动", PnPEntity{Service: "WUDFWpdMtp"}, ClassPortableMedia},
+		{"MTP兼容ID", PnPEntity{CompatibleIDs: []string{"USB\\MS_COMP_MTP"}}, ClassPortableMedia},
+		{"PTP静态图像类", PnPEntity{CompatibleIDs: []string{"USB\\Class_06&SubClass_01&Prot_01"}}, ClassPortableMedia},
+		{"大容量存储驱动", PnPEntity{PNPClass: "USB", Service: "USBSTOR"}, ClassMassStorage},
+		{"大容量存储类", PnPEntity{CompatibleIDs: []string{"USB\\Class_08&SubClass_06&Prot_50"}}, ClassMassStorage},
+		{"音频类", PnPEntity{CompatibleIDs: []string{"USB\\Class_01&SubClass_01"}}, ClassAudio},
+		{"集线器", PnPEntity{CompatibleIDs: []string{"USB\\Class_09"}}, ClassOther},
+		{"子类代码不当作类代码", PnPEntity{CompatibleIDs: []string{"USB\\DevClass_00&SubClass_06"}}, ClassUnknown},
+		{"没有描述符", PnPEntity{Name: "MTP USB Device"}, ClassUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyPnPEntity(&tt.entity); got != tt.want {
+				t.Errorf("ClassifyPnPEntity() = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScanRecordingDevices 测试 MTP 类设备被识别，普通大容量存储、音频设备和集线器不被误判
+func TestScanRecordingDevices(t *testing.T) {
+	original := queryPnPDescriptors
+	defer func() { queryPnPDescriptors = original }()
+
+	queryPnPDescriptors = func() (string, error) { return mockPnPOutput, nil }
+	devices, err := ScanRecordingDevices(logger.NewLogger(false))
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	var found []string
+	for _, dev := range devices {
+		found = append(found, dev.VID+":"+dev.PID)
+	}
+	// 054C 为名称含录音关键词的大容量存储录音笔，关键词作为辅助依据
+	if want := []string{"054C:0387", "2207:0011"}; !reflect.DeepEqual(found, want) {
+		t.Fatalf("识别出的录音设备 = %v, 期望 %v", found, want)
+	}
+
+	sr302 := devices[1]
+	if sr302.Name != "SR302" || !sr302.IsMTP || !strings.Contains(sr302.DeviceID, "MI_00") {
+		t.Errorf("SR302 应以 MTP 接口实例展示: %+v", sr302)
+	}
+	if devices[0].IsMTP {
+		t.Error("大容量存储录音笔不应标记为 MTP")
+	}
+
+	queryPnPDescriptors = func() (string, error) { return "", errors.New("wmic 不可用") }
+	if _, err := ScanRecordingDevices(logger.NewLogger(false)); err == nil {
+		t.Error("查询失败时应返回错误")
+	}
+}
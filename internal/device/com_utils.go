@@ -305,36 +305,6 @@ func GetObjectPropertiesMultiple(properties interface{}, objectID string, proper
 	return result, nil
 }
 
-// EstimateFileSizeFromName 基于文件名智能估算文件大小
-// 这个函数作为WPD API获取失败时的降级方案
-func EstimateFileSizeFromName(filename string) int64 {
-	filename = strings.ToLower(filename)
-
-	// 基于常见的录音笔文件命名模式进行估算
-	// 例如：REC20240101080000.opus 表示2024年1月1日8点的录音
-
-	// 短录音（1-5分钟）：1-5MB
-	if strings.Contains(filename, "short") || strings.Contains(filename, "brief") {
-		return 3 * 1024 * 1024 // 3MB
-	}
-
-	// 中等录音（5-30分钟）：5-30MB
-	if strings.Contains(filename, "medium") || strings.Contains(filename, "normal") {
-		return 15 * 1024 * 1024 // 15MB
-	}
-
-	// 长录音（30分钟以上）：30-500MB
-	if strings.Contains(filename, "long") || strings.Contains(filename, "meeting") {
-		return 100 * 1024 * 1024 // 100MB
-	}
-
-	// 基于文件大小的通用估算
-	// 对于.opus音频文件，使用平均码率进行估算
-	// .opus典型码率：32-160 kbps，取中间值80 kbps
-	// 假设平均录音时长为10分钟
-	return 6 * 1024 * 1024 // 6MB作为保守估算
-}
-
 // IsWPDPropertySupported 检查设备是否支持特定属性
 func IsWPDPropertySupported(properties interface{}, propertyKey PROPERTYKEY) bool {
 	// 在实际实现中，应该调用IPortableDeviceProperties::GetPropertyAttributes
@@ -0,0 +1,172 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// BatteryUnknown 无法读取电量时的取值
+const BatteryUnknown = -1
+
+// DeviceDetails 设备详细信息，读取不到的字段保持为空
+type DeviceDetails struct {
+	Model           string        `json:"model"`            // 型号
+	FirmwareVersion string        `json:"firmware_version"` // 固件版本
+	SerialNumber    string        `json:"serial_number"`    // 序列号
+	Manufacturer    string        `json:"manufacturer"`     // 制造商
+	BatteryLevel    int           `json:"battery_level"`    // 电量百分比，未知时为 BatteryUnknown
+	Storages        []StorageInfo `json:"storages"`         // 存储列表
+}
+
+// NewDeviceDetails 根据设备基本信息创建详细信息，型号取已知型号或设备名称，序列号从设备ID中解析
+func NewDeviceDetails(info *DeviceInfo, storages []StorageInfo) *DeviceDetails {
+	details := &DeviceDetails{BatteryLevel: BatteryUnknown, Storages: storages}
+	if info == nil {
+		return details
+	}
+
+	details.Model = info.Name
+	if profile, ok := MatchModel(info.Name, info.VID, info.PID); ok {
+		details.Model = profile.Model
+	}
+	details.SerialNumber = serialFromDeviceID(info.DeviceID)
+	return details
+}
+
+// serialFromDeviceID 从设备实例ID中解析序列号
+// 如 USB\VID_2207&PID_0011\0123456789ABCDEF 或 \\?\usb#vid_2207&pid_0011#0123456789abcdef#{...}，
+// 紧跟在 VID/PID 段之后且不含 & 的段为序列号（含 & 的是系统生成的实例号）
+func serialFromDeviceID(deviceID string) string {
+	segments := strings.FieldsFunc(deviceID, func(r rune) bool { return r == '\\' || r == '#' })
+	for i := 0; i+1 < len(segments); i++ {
+		if !strings.Contains(strings.ToUpper(segments[i]), "VID_") {
+			continue
+		}
+		serial := segments[i+1]
+		if strings.Contains(serial, "&") || strings.HasPrefix(serial, "{") {
+			return ""
+		}
+		return serial
+	}
+	return ""
+}
+
+// DisplayName 返回目标目录和日志中使用的设备名称：按 DeviceID 或序列号配置了别名时使用别名，否则为设备名
+func (info *DeviceInfo) DisplayName(cfg *config.Config) string {
+	if cfg != nil {
+		if alias := cfg.DeviceAlias(info.DeviceID, serialFromDeviceID(info.DeviceID)); alias != "" {
+			return alias
+		}
+	}
+	return info.Name
+}
+
+// buildDeviceDetailsScript 构建读取设备属性的PowerShell脚本
+// 同时尝试 Shell 属性名与 WPD 属性键（{26D4979A-...} 3/4/7/8/9 分别为固件版本、电量、制造商、型号、序列号），读取不到的属性不输出
+func buildDeviceDetailsScript(deviceName string) string {
+	return fmt.Sprintf(`
+[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+$shell = New-Object -ComObject Shell.Application
+$portable = $shell.NameSpace(17)
+if ($portable) {
+    $device = $portable.Items() | Where-Object { $_.Name -like "*%s*" } | Select-Object -First 1
+    if ($device) {
+        $wpd = "{26D4979A-E643-4626-9E2B-736DC0C92FDC}"
+        $properties = @(
+            @("model", "System.Devices.ModelName", "$wpd 8"),
+            @("firmware", "System.Devices.FirmwareVersion", "$wpd 3"),
+            @("serial", "System.Devices.SerialNumber", "$wpd 9"),
+            @("manufacturer", "System.Devices.Manufacturer", "$wpd 7"),
+            @("battery", "System.Devices.BatteryLife", "$wpd 4")
+        )
+        foreach ($property in $properties) {
+            foreach ($name in $property[1..2]) {
+                $value = $null
+                try { $value = $device.ExtendedProperty($name) } catch {}
+                if ($value -ne $null -and "$value" -ne "") {
+                    Write-Output "DETAIL|$($property[0])|$value"
+                    break
+                }
+            }
+        }
+    }
+}
+`, sanitizeDeviceName(deviceName))
+}
+
+// parseDeviceDetailsOutput 解析设备属性脚本的输出并合并到 details，读取到的值覆盖推断值
+func parseDeviceDetailsOutput(output string, details *DeviceDetails) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "DETAIL|") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		value := strings.TrimSpace(parts[2])
+		if value == "" {
+			continue
+		}
+
+		switch parts[1] {
+		case "model":
+			details.Model = value
+		case "firmware":
+			details.FirmwareVersion = value
+		case "serial":
+			details.SerialNumber = value
+		case "manufacturer":
+			details.Manufacturer = value
+		case "battery":
+			if level, err := strconv.Atoi(value); err == nil && level >= 0 && level <= 100 {
+				details.BatteryLevel = level
+			}
+		}
+	}
+}
+
+// WriteDeviceDetails 以表格形式输出设备详细信息，读取不到的字段留空
+func WriteDeviceDetails(w io.Writer, details *DeviceDetails) {
+	battery := ""
+	if details.BatteryLevel != BatteryUnknown {
+		battery = fmt.Sprintf("%d%%", details.BatteryLevel)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "   型号:\t%s\n", details.Model)
+	fmt.Fprintf(tw, "   固件版本:\t%s\n", details.FirmwareVersion)
+	fmt.Fprintf(tw, "   序列号:\t%s\n", details.SerialNumber)
+	fmt.Fprintf(tw, "   制造商:\t%s\n", details.Manufacturer)
+	fmt.Fprintf(tw, "   电量:\t%s\n", battery)
+	tw.Flush()
+
+	if len(details.Storages) == 0 {
+		return
+	}
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "   存储\t类型\t容量\t可用\n")
+	for _, storage := range details.Storages {
+		fmt.Fprintf(tw, "   %s\t%s\t%s\t%s\n", storage.Name, storage.Type, formatStorageSize(storage.Capacity), formatStorageSize(storage.FreeSpace))
+	}
+	tw.Flush()
+}
+
+// formatStorageSize 格式化存储容量，未知时留空
+func formatStorageSize(size int64) string {
+	if size <= 0 {
+		return ""
+	}
+	return utils.FormatBytes(size)
+}
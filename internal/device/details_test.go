@@ -0,0 +1,109 @@
+//go:build windows
+
+package device
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestSerialFromDeviceID 测试从设备实例ID解析序列号
+func TestSerialFromDeviceID(t *testing.T) {
+	tests := []struct {
+		name     string
+		deviceID string
+		want     string
+	}{
+		{"USB实例ID", "USB\\VID_2207&PID_0011\\0123456789ABCDEF", "0123456789ABCDEF"},
+		{"设备接口路径", "\\\\?\\usb#vid_2207&pid_0011#0123456789abcdef#{6ac27878-a6fa-4155-ba85-f98f491d4f33}", "0123456789abcdef"},
+		{"系统生成的实例号", "USB\\VID_2207&PID_0011\\6&2A3B4C5D&0&1", ""},
+		{"WPD枚举ID", "SWD\\WPDBUSENUM\\{12345678-1234-1234-1234-123456789012}", ""},
+		{"空ID", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serialFromDeviceID(tt.deviceID); got != tt.want {
+				t.Errorf("serialFromDeviceID() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseDeviceDetailsOutput 测试设备属性脚本输出的解析
+func TestParseDeviceDetailsOutput(t *testing.T) {
+	details := NewDeviceDetails(&DeviceInfo{Name: "SR302", DeviceID: "USB\\VID_2207&PID_0011\\SN001"}, nil)
+	output := "DETAIL|firmware|V1.2.3\r\nDETAIL|manufacturer|Rockchip\r\nDETAIL|battery|85\r\nDETAIL|serial|\r\n噪声行\r\nDETAIL|unknown|x\r\n"
+	parseDeviceDetailsOutput(output, details)
+
+	if details.FirmwareVersion != "V1.2.3" || details.Manufacturer != "Rockchip" || details.BatteryLevel != 85 {
+		t.Errorf("解析结果不符: %+v", details)
+	}
+	// 脚本未读到的属性保留推断值
+	if details.SerialNumber != "SN001" {
+		t.Errorf("序列号 = %q, 期望 SN001", details.SerialNumber)
+	}
+
+	parseDeviceDetailsOutput("DETAIL|battery|无效", details)
+	if details.BatteryLevel != 85 {
+		t.Errorf("无效电量不应覆盖: %d", details.BatteryLevel)
+	}
+}
+
+// TestWriteDeviceDetails 测试通过访问器读取详细信息并展示
+func TestWriteDeviceDetails(t *testing.T) {
+	tests := []struct {
+		name        string
+		details     *DeviceDetails
+		wantParts   []string
+		unwantParts []string
+	}{
+		{
+			name: "字段完整",
+			details: &DeviceDetails{
+				Model: "SR302", FirmwareVersion: "V1.2.3", SerialNumber: "SN001",
+				Manufacturer: "Rockchip", BatteryLevel: 85,
+			},
+			wantParts: []string{"SR302", "V1.2.3", "SN001", "Rockchip", "85%", "内部共享存储空间", "1.0 MiB"},
+		},
+		{
+			name:        "读取不到的字段留空",
+			wantParts:   []string{"型号:", "固件版本:", "电量:"},
+			unwantParts: []string{"-1", "V1.2.3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeMTPAccessor(&DeviceInfo{DeviceID: "fake_device", Name: "SR302"})
+			fake.AddStorage(StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1024 * 1024})
+			if tt.details != nil {
+				fake.SetDetails(*tt.details)
+			}
+
+			var mtp MTPInterface = fake
+			details, err := mtp.GetDeviceDetails()
+			if err != nil {
+				t.Fatalf("获取设备详细信息失败: %v", err)
+			}
+			if len(details.Storages) != 1 {
+				t.Fatalf("存储数 = %d, 期望 1", len(details.Storages))
+			}
+
+			var buf bytes.Buffer
+			WriteDeviceDetails(&buf, details)
+			output := buf.String()
+			for _, want := range tt.wantParts {
+				if !strings.Contains(output, want) {
+					t.Errorf("输出缺少 %q:\n%s", want, output)
+				}
+			}
+			for _, unwant := range tt.unwantParts {
+				if strings.Contains(output, unwant) {
+					t.Errorf("输出不应包含 %q:\n%s", unwant, output)
+				}
+			}
+		})
+	}
+}
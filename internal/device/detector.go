@@ -38,17 +38,33 @@ type USBDevice struct {
 
 // DetectSR302 检测SR302设备
 func DetectSR302() (*DeviceInfo, error) {
-	// 1. 通过WMI查询USB设备
+	return DetectDevice(SR302_NAME, SR302_VID, SR302_PID)
+}
+
+// DetectDevice 按设备名称和VID/PID检测设备，参数为空时不按该项匹配
+func DetectDevice(name, vid, pid string) (*DeviceInfo, error) {
+	// 1. 通过WMI查询USB设备，wmic不可用时改用WPD设备管理器
 	devices, err := enumerateUSBDevices()
 	if err != nil {
-		return nil, fmt.Errorf("枚举USB设备失败: %w", err)
+		wpdDevices, wpdErr := EnumerateWPDDevices()
+		if wpdErr != nil {
+			return nil, fmt.Errorf("枚举USB设备失败: %w（WPD枚举也失败: %v）", err, wpdErr)
+		}
+		devices = wpdDevices
 	}
 
-	// 2. 查找SR302设备
+	// 2. 查找匹配的设备
+	return FindDevice(devices, name, vid, pid)
+}
+
+// FindDevice 在已枚举的USB设备中查找名称包含 name 且VID/PID一致的设备
+func FindDevice(devices []*USBDevice, name, vid, pid string) (*DeviceInfo, error) {
+	vid = strings.ToUpper(strings.TrimSpace(vid))
+	pid = strings.ToUpper(strings.TrimSpace(pid))
 	for _, device := range devices {
-		if strings.Contains(strings.ToUpper(device.Name), strings.ToUpper(SR302_NAME)) &&
-			device.VID == SR302_VID &&
-			device.PID == SR302_PID {
+		if strings.Contains(strings.ToUpper(device.Name), strings.ToUpper(name)) &&
+			(vid == "" || device.VID == vid) &&
+			(pid == "" || device.PID == pid) {
 
 			// 创建设备信息
 			deviceInfo := &DeviceInfo{
@@ -65,7 +81,7 @@ func DetectSR302() (*DeviceInfo, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("未找到SR302设备 (VID:%s, PID:%s)", SR302_VID, SR302_PID)
+	return nil, fmt.Errorf("未找到%s设备 (VID:%s, PID:%s)", name, vid, pid)
 }
 
 // enumerateUSBDevices 通过WMI枚举USB设备
@@ -257,7 +257,7 @@ func TestIsDeviceConnected(t *testing.T) {
 	}
 
 	// 测试空设备信息
-	connected, err := IsDeviceConnected(nil)
+	_, err := IsDeviceConnected(nil)
 	if err == nil {
 		t.Error("空设备信息应该返回错误")
 	}
@@ -47,6 +47,7 @@ func (db *DeviceBridgeImpl) initResolvers() {
 	db.resolvers = []PathResolver{
 		NewPowerShellEnhancedResolver(db.log), // 最高优先级，使用增强的PowerShell
 		NewPowerShellResolver(db.log),         // 标准PowerShell方案
+		NewWPDNativeResolver(db.log),          // 纯Go WPD方案，PowerShell不可用时仍可使用
 		NewWMIResolver(db.log),                // 备选方案
 		NewDirectFileResolver(db.log),         // 最低优先级
 	}
@@ -79,6 +80,11 @@ func (db *DeviceBridgeImpl) DetectAndBridge(deviceName string) (MTPInterface, er
 	db.log.Debug("找到目标设备: %s (VID:%s, PID:%s)",
 		targetDevice.Name, targetDevice.VID, targetDevice.PID)
 
+	return db.BridgeDevice(targetDevice)
+}
+
+// BridgeDevice 为已检测到的设备创建MTP访问接口
+func (db *DeviceBridgeImpl) BridgeDevice(targetDevice *DeviceInfo) (MTPInterface, error) {
 	// 尝试不同的访问方法
 	for _, resolver := range db.resolvers {
 		if !resolver.IsAvailable() {
@@ -129,7 +135,7 @@ func (db *DeviceBridgeImpl) DetectAndBridge(deviceName string) (MTPInterface, er
 	db.printAccessSummary()
 
 	return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND,
-		fmt.Sprintf("无法通过任何方法访问设备: %s", deviceName), nil)
+		fmt.Sprintf("无法通过任何方法访问设备: %s", targetDevice.Name), nil)
 }
 
 // GetDevicePath 获取设备访问路径
@@ -206,6 +212,12 @@ func (db *DeviceBridgeImpl) createMTPInterface(resolver PathResolver, device *De
 	case *PowerShellResolver:
 		// 为PowerShellMTPAccessor添加包装器以实现MTPInterface
 		return NewPowerShellMTPWrapper(db.log), nil
+	case *WPDNativeResolver:
+		native := NewWPDNativeAccessor(db.log)
+		if err := native.ConnectToDevice(device.Name, device.VID, device.PID); err != nil {
+			return nil, fmt.Errorf("纯Go WPD访问器连接失败: %w", err)
+		}
+		return native, nil
 	case *WMIResolver:
 		return NewWMIMTPAccessor(db.log), nil
 	case *DirectFileResolver:
@@ -268,6 +280,8 @@ func (db *DeviceBridgeImpl) getMethodName(resolver PathResolver) AccessMethod {
 		return MethodWindowsShellCOM
 	case *PowerShellResolver:
 		return MethodPowerShell
+	case *WPDNativeResolver:
+		return MethodWPDNative
 	case *WMIResolver:
 		return MethodWMI
 	case *DirectFileResolver:
@@ -0,0 +1,535 @@
+//go:build windows
+
+package device
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// FakeFile 虚拟设备上的文件
+type FakeFile struct {
+	Path       string         // 设备内完整路径，如 "内部共享存储空间\\录音笔文件\\a.opus"
+	Content    []byte         // 文件内容
+	Size       int64          // 枚举时报告的大小，0表示使用内容长度
+	ModTime    time.Time      // 修改时间
+	Attributes FileAttributes // 只读/隐藏/系统属性
+}
+
+// FakeMTPAccessor 内存中的虚拟MTP设备，实现 MTPInterface
+// 用于在没有真机的环境下测试枚举、读流、按偏移读取、删除、上传和存储信息
+type FakeMTPAccessor struct {
+	mutex     sync.Mutex
+	info      *DeviceInfo
+	storages  []StorageInfo
+	files     map[string]*FakeFile // 键为规范化后的完整路径
+	connected bool
+	failures  map[string]int            // 打开文件流时剩余的失败次数
+	opens     map[string]int            // 打开文件流的次数
+	ranges    map[string]int            // 按偏移读取的次数
+	listings  map[string]int            // 逐层列出目录的次数
+	hangs     map[string]*hangingStream // 读取时一直阻塞的文件流，直到被关闭
+	unplugIn  int                       // 再打开多少次文件流时设备被拔出，0表示不模拟拔出
+	vanishes  map[string]int64          // 读取到该偏移时文件被删除，之后的读取返回文件不存在
+	details   *DeviceDetails            // 设备属性，为空时只返回基本信息
+	latency   time.Duration             // 列出每个目录的耗时，模拟真机上逐个目录枚举的开销
+	openDelay time.Duration             // 打开每个文件流的耗时，模拟真机上逐个文件传输的开销
+	dropIn    int                       // 再打开多少次文件流时电量骤降到 dropTo，0表示不模拟
+	dropTo    int
+}
+
+// NewFakeMTPAccessor 创建已连接的虚拟设备
+func NewFakeMTPAccessor(info *DeviceInfo) *FakeMTPAccessor {
+	if info == nil {
+		info = &DeviceInfo{DeviceID: "fake_device", Name: SR302_NAME, VID: SR302_VID, PID: SR302_PID, IsMTP: true}
+	}
+	return &FakeMTPAccessor{
+		info:      info,
+		files:     make(map[string]*FakeFile),
+		connected: true,
+		failures:  make(map[string]int),
+		opens:     make(map[string]int),
+		ranges:    make(map[string]int),
+		listings:  make(map[string]int),
+		hangs:     make(map[string]*hangingStream),
+		vanishes:  make(map[string]int64),
+	}
+}
+
+// AddStorage 添加存储，未添加任何存储时 ListStorages 返回空列表
+func (f *FakeMTPAccessor) AddStorage(storage StorageInfo) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if storage.Type == "" {
+		storage.Type = ClassifyStorage(storage.Name)
+	}
+	f.storages = append(f.storages, storage)
+}
+
+// SetDetails 设置设备属性，模拟访问器读取到的型号、固件版本等，Storages 字段会被忽略
+func (f *FakeMTPAccessor) SetDetails(details DeviceDetails) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.details = &details
+}
+
+// SetBatteryLevel 设置设备报告的电量百分比，BatteryUnknown 表示读取不到电量
+func (f *FakeMTPAccessor) SetBatteryLevel(level int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.details == nil {
+		f.details = NewDeviceDetails(f.info, nil)
+	}
+	f.details.BatteryLevel = level
+}
+
+// BatteryDropAt 让接下来第 n 次打开文件流时电量骤降到 level，模拟备份中途电池耗尽
+func (f *FakeMTPAccessor) BatteryDropAt(n, level int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.dropIn = n
+	f.dropTo = level
+}
+
+// SetOpenDelay 设置打开每个文件流的耗时，模拟真机上逐个文件传输的开销
+func (f *FakeMTPAccessor) SetOpenDelay(delay time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.openDelay = delay
+}
+
+// SetListLatency 设置列出每个目录的耗时，ListFiles 按列出的目录数累计，模拟真机上全量枚举的开销
+func (f *FakeMTPAccessor) SetListLatency(latency time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.latency = latency
+}
+
+// AddFile 添加文件，返回的 FakeFile 可继续修改（如设置与内容不一致的 Size）
+func (f *FakeMTPAccessor) AddFile(path string, content []byte, modTime time.Time) *FakeFile {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	file := &FakeFile{Path: normalizeFakePath(path), Content: content, ModTime: modTime}
+	f.files[file.Path] = file
+	return file
+}
+
+// FailStream 让接下来 times 次打开 path 的文件流失败
+func (f *FakeMTPAccessor) FailStream(path string, times int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures[normalizeFakePath(path)] = times
+}
+
+// DisconnectAt 让接下来第 n 次打开文件流时设备被拔出，该次及之后的所有访问都返回设备未连接，直到重新连接
+func (f *FakeMTPAccessor) DisconnectAt(n int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.unplugIn = n
+}
+
+// VanishAt 让之后打开 path 的文件流读取 offset 字节后文件被删除，之后的读取返回文件不存在，模拟复制中文件被设备端删除
+func (f *FakeMTPAccessor) VanishAt(path string, offset int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.vanishes[normalizeFakePath(path)] = offset
+}
+
+// HangStream 让之后打开 path 的文件流在读取时一直阻塞，直到文件流被关闭，模拟卡死的损坏文件
+func (f *FakeMTPAccessor) HangStream(path string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.hangs[normalizeFakePath(path)] = &hangingStream{closed: make(chan struct{})}
+}
+
+// HungStreamClosed 判断 path 卡住的文件流是否已被关闭
+func (f *FakeMTPAccessor) HungStreamClosed(path string) bool {
+	f.mutex.Lock()
+	stream := f.hangs[normalizeFakePath(path)]
+	f.mutex.Unlock()
+	if stream == nil {
+		return false
+	}
+	select {
+	case <-stream.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// StreamOpens 获取 path 的文件流被打开的次数（含失败）
+func (f *FakeMTPAccessor) StreamOpens(path string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.opens[normalizeFakePath(path)]
+}
+
+// HasFile 判断文件是否存在
+func (f *FakeMTPAccessor) HasFile(path string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	_, ok := f.files[normalizeFakePath(path)]
+	return ok
+}
+
+// ConnectToDevice 连接到设备，设备名称需与虚拟设备匹配
+func (f *FakeMTPAccessor) ConnectToDevice(deviceName, vid, pid string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if deviceName != "" && !strings.Contains(strings.ToUpper(f.info.Name), strings.ToUpper(deviceName)) {
+		return NewMTPError(ERROR_DEVICE_NOT_FOUND, fmt.Sprintf("未找到设备: %s", deviceName), nil)
+	}
+	f.connected = true
+	return nil
+}
+
+// ListFiles 递归列出 basePath 下的文件，按路径排序
+func (f *FakeMTPAccessor) ListFiles(basePath string) ([]*FileInfo, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.connected {
+		return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+	}
+
+	prefix := normalizeFakePath(basePath)
+	if prefix != "" {
+		prefix += "\\"
+	}
+
+	var files []*FileInfo
+	dirs := map[string]bool{prefix: true}
+	for path, file := range f.files {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		name := path[strings.LastIndex(path, "\\")+1:]
+		dirs[path[:len(path)-len(name)]] = true
+		files = append(files, &FileInfo{
+			Path:         path,
+			RelativePath: strings.TrimPrefix(path, prefix),
+			Name:         name,
+			Size:         file.reportedSize(),
+			IsOpus:       utils.IsOpusFile(name),
+			ModTime:      file.ModTime,
+			Attributes:   file.Attributes,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	time.Sleep(f.latency * time.Duration(len(dirs)))
+	return files, nil
+}
+
+// SubtreeSignatures 列出的子目录修改时间为其子树中最新的修改时间，可用于增量枚举，实现 SubtreeSigner
+func (f *FakeMTPAccessor) SubtreeSignatures() bool {
+	return true
+}
+
+// ListDirectory 列出目录的直接子项，实现 DirectoryLister；子目录的修改时间为其子树中最新的修改时间
+func (f *FakeMTPAccessor) ListDirectory(dirPath string) ([]DirEntry, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	dir := normalizeFakePath(dirPath)
+	f.listings[dir]++
+	time.Sleep(f.latency)
+	if !f.connected {
+		return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+	}
+
+	prefix := dir
+	if prefix != "" {
+		prefix += "\\"
+	}
+
+	entries := make(map[string]*DirEntry)
+	children := make(map[string]map[string]bool) // 子目录 -> 其直接子项名称
+	for path, file := range f.files {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(path, prefix)
+		name, below, nested := strings.Cut(rest, "\\")
+		if !nested {
+			entries[name] = &DirEntry{Name: name, Size: file.reportedSize(), ModTime: file.ModTime, Attributes: file.Attributes}
+			continue
+		}
+
+		entry := entries[name]
+		if entry == nil {
+			entry = &DirEntry{Name: name, IsDir: true}
+			entries[name] = entry
+			children[name] = make(map[string]bool)
+		}
+		if file.ModTime.After(entry.ModTime) {
+			entry.ModTime = file.ModTime
+		}
+		child, _, _ := strings.Cut(below, "\\")
+		children[name][child] = true
+	}
+
+	result := make([]DirEntry, 0, len(entries))
+	for name, entry := range entries {
+		entry.Items = len(children[name])
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// DirectoryListings 获取逐层列出 dirPath 的次数
+func (f *FakeMTPAccessor) DirectoryListings(dirPath string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.listings[normalizeFakePath(dirPath)]
+}
+
+// ListStorages 列出存储，可用空间按容量减去存储上文件的大小计算
+func (f *FakeMTPAccessor) ListStorages() []StorageInfo {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	storages := make([]StorageInfo, len(f.storages))
+	for i, storage := range f.storages {
+		if storage.Capacity > 0 {
+			var used int64
+			for path, file := range f.files {
+				if strings.HasPrefix(path, storage.Name+"\\") {
+					used += file.reportedSize()
+				}
+			}
+			storage.FreeSpace = storage.Capacity - used
+			if storage.FreeSpace < 0 {
+				storage.FreeSpace = 0
+			}
+		}
+		storages[i] = storage
+	}
+	return storages
+}
+
+// GetFileStream 获取文件读取流
+func (f *FakeMTPAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
+	f.mutex.Lock()
+	if f.dropIn > 0 {
+		f.dropIn--
+		if f.dropIn == 0 {
+			if f.details == nil {
+				f.details = NewDeviceDetails(f.info, nil)
+			}
+			f.details.BatteryLevel = f.dropTo
+		}
+	}
+	delay := f.openDelay
+	f.mutex.Unlock()
+	// 传输期间不持有锁，读取电量等其他访问不受影响
+	time.Sleep(delay)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	path := normalizeFakePath(filePath)
+	f.opens[path]++
+	if f.unplugIn > 0 {
+		f.unplugIn--
+		if f.unplugIn == 0 {
+			f.connected = false
+		}
+	}
+	if !f.connected {
+		return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+	}
+	if f.failures[path] > 0 {
+		f.failures[path]--
+		return nil, NewRetryableMTPError(ERROR_DEVICE_BUSY, fmt.Sprintf("模拟读取失败: %s", filePath), nil)
+	}
+
+	file, ok := f.files[path]
+	if !ok {
+		return nil, NewMTPError(ERROR_FILE_NOT_FOUND, fmt.Sprintf("文件不存在: %s", filePath), nil)
+	}
+	if stream, ok := f.hangs[path]; ok {
+		return stream, nil
+	}
+	if offset, ok := f.vanishes[path]; ok && offset < int64(len(file.Content)) {
+		return &vanishingStream{accessor: f, path: path, remaining: file.Content[:offset]}, nil
+	}
+	return io.NopCloser(bytes.NewReader(append([]byte(nil), file.Content...))), nil
+}
+
+// ReadRange 按偏移读取文件片段，实现 RangeReader
+func (f *FakeMTPAccessor) ReadRange(filePath string, off, length int64) ([]byte, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	path := normalizeFakePath(filePath)
+	f.ranges[path]++
+	if !f.connected {
+		return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+	}
+	file, ok := f.files[path]
+	if !ok {
+		return nil, NewMTPError(ERROR_FILE_NOT_FOUND, fmt.Sprintf("文件不存在: %s", filePath), nil)
+	}
+	if off < 0 || length < 0 {
+		return nil, NewMTPError(ERROR_INVALID_PARAMETER, fmt.Sprintf("无效的读取范围: %d+%d", off, length), nil)
+	}
+
+	size := int64(len(file.Content))
+	if off >= size {
+		return []byte{}, nil
+	}
+	end := off + length
+	if end > size {
+		end = size
+	}
+	return append([]byte(nil), file.Content[off:end]...), nil
+}
+
+// Exists 判断文件是否存在，实现 FileExister
+func (f *FakeMTPAccessor) Exists(filePath string) (bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.connected {
+		return false, NewMTPError(ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+	}
+	_, ok := f.files[normalizeFakePath(filePath)]
+	return ok, nil
+}
+
+// RangeReads 获取按偏移读取 path 的次数
+func (f *FakeMTPAccessor) RangeReads(path string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.ranges[normalizeFakePath(path)]
+}
+
+// DeleteFile 删除文件
+func (f *FakeMTPAccessor) DeleteFile(filePath string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	path := normalizeFakePath(filePath)
+	if _, ok := f.files[path]; !ok {
+		return NewMTPError(ERROR_FILE_NOT_FOUND, fmt.Sprintf("文件不存在: %s", filePath), nil)
+	}
+	delete(f.files, path)
+	return nil
+}
+
+// UploadFile 将 r 的内容写入设备上的 filePath，已存在时覆盖
+func (f *FakeMTPAccessor) UploadFile(filePath string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("读取上传内容失败: %w", err)
+	}
+	f.AddFile(filePath, content, time.Now())
+	return nil
+}
+
+// Close 断开连接
+func (f *FakeMTPAccessor) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.connected = false
+	return nil
+}
+
+// IsConnected 检查是否已连接
+func (f *FakeMTPAccessor) IsConnected() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.connected
+}
+
+// GetDeviceInfo 获取设备信息
+func (f *FakeMTPAccessor) GetDeviceInfo() *DeviceInfo {
+	return f.info
+}
+
+// QuickStat 快速估算设备上的文件数和总大小
+func (f *FakeMTPAccessor) QuickStat() (int, int64, error) {
+	return EstimateFiles(f)
+}
+
+// GetDeviceDetails 获取设备详细信息，未设置属性时只返回基本信息
+func (f *FakeMTPAccessor) GetDeviceDetails() (*DeviceDetails, error) {
+	storages := f.ListStorages()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.connected {
+		return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND, "设备未连接", nil)
+	}
+
+	if f.details == nil {
+		return NewDeviceDetails(f.info, storages), nil
+	}
+	details := *f.details
+	details.Storages = storages
+	return &details, nil
+}
+
+// reportedSize 获取枚举时报告的大小
+func (file *FakeFile) reportedSize() int64 {
+	if file.Size > 0 {
+		return file.Size
+	}
+	return int64(len(file.Content))
+}
+
+// normalizeFakePath 统一使用 \ 分隔并去掉首尾分隔符
+func normalizeFakePath(path string) string {
+	return strings.Trim(strings.ReplaceAll(path, "/", "\\"), "\\")
+}
+
+// hangingStream 读取时一直阻塞直到被关闭的文件流
+type hangingStream struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Read 阻塞到文件流被关闭
+func (s *hangingStream) Read(p []byte) (int, error) {
+	<-s.closed
+	return 0, fmt.Errorf("文件流已关闭")
+}
+
+// Close 关闭文件流，唤醒阻塞的读取
+func (s *hangingStream) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+// vanishingStream 读完 remaining 后文件被删除的文件流
+type vanishingStream struct {
+	accessor  *FakeMTPAccessor
+	path      string
+	remaining []byte
+}
+
+// Read 先返回删除前的内容，读完后从虚拟设备删除文件并返回文件不存在
+func (s *vanishingStream) Read(p []byte) (int, error) {
+	if len(s.remaining) > 0 {
+		n := copy(p, s.remaining)
+		s.remaining = s.remaining[n:]
+		return n, nil
+	}
+	s.accessor.mutex.Lock()
+	delete(s.accessor.files, s.path)
+	s.accessor.mutex.Unlock()
+	return 0, NewMTPError(ERROR_FILE_NOT_FOUND, fmt.Sprintf("文件不存在: %s", s.path), nil)
+}
+
+// Close 关闭文件流
+func (s *vanishingStream) Close() error {
+	return nil
+}
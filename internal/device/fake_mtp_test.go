@@ -0,0 +1,143 @@
+//go:build windows
+
+package device
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// TestFakeMTPAccessor_ListFiles 测试虚拟设备的枚举
+func TestFakeMTPAccessor_ListFiles(t *testing.T) {
+	fake := NewFakeMTPAccessor(nil)
+	fake.AddStorage(StorageInfo{ID: "s1", Name: "内部共享存储空间"})
+	fake.AddStorage(StorageInfo{ID: "s2", Name: "SD卡"})
+
+	modTime := time.Date(2024, 3, 11, 9, 30, 0, 0, time.Local)
+	fake.AddFile("内部共享存储空间\\录音笔文件\\a.opus", []byte("aaaa"), modTime)
+	fake.AddFile("内部共享存储空间/录音笔文件/会议/b.opus", []byte("bb"), modTime).Size = 1024
+	fake.AddFile("SD卡\\录音笔文件\\c.opus", []byte("c"), modTime)
+	fake.AddFile("内部共享存储空间\\其他\\d.opus", []byte("d"), modTime)
+
+	tests := []struct {
+		name      string
+		selection string
+		wantPaths []string
+	}{
+		{
+			name:      "内部存储",
+			selection: StorageInternal,
+			wantPaths: []string{"内部共享存储空间\\录音笔文件\\a.opus", "内部共享存储空间\\录音笔文件\\会议\\b.opus"},
+		},
+		{
+			name:      "SD卡",
+			selection: StorageSD,
+			wantPaths: []string{"SD卡\\录音笔文件\\c.opus"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ListFilesInStorages(fake, "内部共享存储空间\\录音笔文件", tt.selection, logger.NewLogger(false))
+			if err != nil {
+				t.Fatalf("列出文件失败: %v", err)
+			}
+			if len(files) != len(tt.wantPaths) {
+				t.Fatalf("期望 %d 个文件，实际 %d 个", len(tt.wantPaths), len(files))
+			}
+			for i, file := range files {
+				if file.Path != tt.wantPaths[i] {
+					t.Errorf("第 %d 个文件路径 = %s, 期望 %s", i, file.Path, tt.wantPaths[i])
+				}
+				if !file.IsOpus || file.ModTime != modTime {
+					t.Errorf("文件信息不正确: %+v", file)
+				}
+			}
+		})
+	}
+
+	files, _ := fake.ListFiles("内部共享存储空间\\录音笔文件")
+	if files[1].RelativePath != "会议\\b.opus" || files[1].Size != 1024 {
+		t.Errorf("应使用配置的大小和相对路径: %+v", files[1])
+	}
+}
+
+// TestFakeMTPAccessor_Stream 测试读流和模拟失败
+func TestFakeMTPAccessor_Stream(t *testing.T) {
+	fake := NewFakeMTPAccessor(nil)
+	fake.AddFile("内部共享存储空间\\a.opus", []byte("opus data"), time.Now())
+	fake.FailStream("内部共享存储空间\\a.opus", 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := fake.GetFileStream("内部共享存储空间\\a.opus"); err == nil {
+			t.Fatalf("第 %d 次读取应失败", i+1)
+		} else if mtpErr, ok := err.(*MTPError); !ok || !mtpErr.IsRetryable() {
+			t.Errorf("模拟失败应为可重试的MTP错误: %v", err)
+		}
+	}
+
+	stream, err := fake.GetFileStream("内部共享存储空间\\a.opus")
+	if err != nil {
+		t.Fatalf("失败次数用完后应能读取: %v", err)
+	}
+	data, _ := io.ReadAll(stream)
+	stream.Close()
+	if string(data) != "opus data" {
+		t.Errorf("读取内容 = %q", data)
+	}
+	if opens := fake.StreamOpens("内部共享存储空间\\a.opus"); opens != 3 {
+		t.Errorf("打开次数 = %d, 期望 3", opens)
+	}
+
+	if _, err := fake.GetFileStream("内部共享存储空间\\missing.opus"); err == nil {
+		t.Error("不存在的文件应返回错误")
+	}
+
+	fake.Close()
+	if fake.IsConnected() {
+		t.Error("关闭后应为未连接")
+	}
+	if _, err := fake.ListFiles(""); err == nil {
+		t.Error("未连接时枚举应返回错误")
+	}
+	if err := fake.ConnectToDevice("sr302", "", ""); err != nil || !fake.IsConnected() {
+		t.Errorf("重新连接失败: %v", err)
+	}
+	if err := fake.ConnectToDevice("其他设备", "", ""); err == nil {
+		t.Error("设备名称不匹配时应返回错误")
+	}
+}
+
+// TestFakeMTPAccessor_DeleteUploadStorage 测试删除、上传和存储空间
+func TestFakeMTPAccessor_DeleteUploadStorage(t *testing.T) {
+	fake := NewFakeMTPAccessor(nil)
+	fake.AddStorage(StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1000})
+	fake.AddFile("内部共享存储空间\\a.opus", make([]byte, 300), time.Now())
+
+	if err := fake.UploadFile("内部共享存储空间\\b.opus", bytes.NewReader(make([]byte, 200))); err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	if free := fake.ListStorages()[0].FreeSpace; free != 500 {
+		t.Errorf("上传后可用空间 = %d, 期望 500", free)
+	}
+	if fake.ListStorages()[0].Type != StorageInternal {
+		t.Error("存储类型应按名称自动识别")
+	}
+
+	if err := fake.DeleteFile("内部共享存储空间\\a.opus"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if fake.HasFile("内部共享存储空间\\a.opus") {
+		t.Error("删除后文件不应存在")
+	}
+	if err := fake.DeleteFile("内部共享存储空间\\a.opus"); err == nil {
+		t.Error("重复删除应返回错误")
+	}
+	if free := fake.ListStorages()[0].FreeSpace; free != 800 {
+		t.Errorf("删除后可用空间 = %d, 期望 800", free)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package device
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FileAttributes 设备文件的属性标记，取值与 Windows FILE_ATTRIBUTE_* 一致
+// 通过 Shell COM 的 System.FileAttributes 读取，读取不到时为0
+type FileAttributes uint32
+
+const (
+	// AttrReadOnly 只读文件
+	AttrReadOnly FileAttributes = 0x1
+	// AttrHidden 隐藏文件
+	AttrHidden FileAttributes = 0x2
+	// AttrSystem 系统文件
+	AttrSystem FileAttributes = 0x4
+)
+
+// ReadOnly 是否为只读文件
+func (a FileAttributes) ReadOnly() bool { return a&AttrReadOnly != 0 }
+
+// Hidden 是否为隐藏文件
+func (a FileAttributes) Hidden() bool { return a&AttrHidden != 0 }
+
+// System 是否为系统文件
+func (a FileAttributes) System() bool { return a&AttrSystem != 0 }
+
+// ParseFileAttributes 解析 PowerShell 输出的属性值，无法解析时返回0
+func ParseFileAttributes(value string) FileAttributes {
+	attrs, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return FileAttributes(attrs)
+}
@@ -0,0 +1,138 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hresultInfo HRESULT对应的可读说明
+type hresultInfo struct {
+	Code        MTPErrorCode
+	Description string
+	Retryable   bool
+}
+
+// hresultDescriptions 常见MTP/Shell复制失败的HRESULT映射表
+var hresultDescriptions = map[uint32]hresultInfo{
+	0x80070005: {ERROR_ACCESS_DENIED, "权限不足，拒绝访问", false},
+	0x80070020: {ERROR_DEVICE_BUSY, "文件被其他程序占用", true},
+	0x80070021: {ERROR_DEVICE_BUSY, "文件的一部分被其他程序锁定", true},
+	0x800700AA: {ERROR_DEVICE_BUSY, "设备忙，请稍后重试", true},
+	0x80070015: {ERROR_DEVICE_BUSY, "设备未就绪", true},
+	0x80070070: {ERROR_INVALID_PARAMETER, "目标磁盘空间不足", false},
+	0x80070027: {ERROR_INVALID_PARAMETER, "目标磁盘已满", false},
+	0x80070002: {ERROR_FILE_NOT_FOUND, "找不到指定的文件", false},
+	0x80070003: {ERROR_FILE_NOT_FOUND, "找不到指定的路径", false},
+	0x8007048F: {ERROR_DEVICE_NOT_FOUND, "设备未连接", false},
+	0x8007001F: {ERROR_DEVICE_BUSY, "设备无法正常工作（一般性故障），请重新连接设备", true},
+	0x8007045D: {ERROR_DEVICE_BUSY, "设备I/O错误，请检查USB连接", true},
+	0x800705B4: {ERROR_TIMEOUT, "操作超时", true},
+	0x80070079: {ERROR_TIMEOUT, "信号灯超时，设备响应过慢", true},
+	0x80042002: {ERROR_DEVICE_NOT_FOUND, "WPD设备未打开", true},
+	0x8007000E: {ERROR_COM_ERROR, "内存不足", false},
+	0x80070057: {ERROR_INVALID_PARAMETER, "参数无效", false},
+	0x80004005: {ERROR_COM_ERROR, "未指定的错误", true},
+}
+
+// exceptionDescriptions PowerShell异常类型/消息关键词到可读说明的映射（无HRESULT时使用）
+var exceptionDescriptions = []struct {
+	Keyword string
+	Info    hresultInfo
+}{
+	{"UnauthorizedAccessException", hresultInfo{ERROR_ACCESS_DENIED, "权限不足，拒绝访问", false}},
+	{"being used by another process", hresultInfo{ERROR_DEVICE_BUSY, "文件被其他程序占用", true}},
+	{"正由另一进程使用", hresultInfo{ERROR_DEVICE_BUSY, "文件被其他程序占用", true}},
+	{"not enough space", hresultInfo{ERROR_INVALID_PARAMETER, "目标磁盘空间不足", false}},
+	{"磁盘空间不足", hresultInfo{ERROR_INVALID_PARAMETER, "目标磁盘空间不足", false}},
+	{"TimeoutException", hresultInfo{ERROR_TIMEOUT, "操作超时", true}},
+	{"FileNotFoundException", hresultInfo{ERROR_FILE_NOT_FOUND, "找不到指定的文件", false}},
+	{"DirectoryNotFoundException", hresultInfo{ERROR_FILE_NOT_FOUND, "找不到指定的路径", false}},
+}
+
+var (
+	// hexHRESULTPattern 匹配十六进制HRESULT，如 0x80070020
+	hexHRESULTPattern = regexp.MustCompile(`0[xX]([89aAbBcCdDeEfF][0-9a-fA-F]{7})`)
+	// decimalHRESULTPattern 匹配PowerShell输出的有符号十进制HResult，如 -2147024864
+	decimalHRESULTPattern = regexp.MustCompile(`-21474\d{5}`)
+)
+
+// ParsePowerShellError 从PowerShell输出中解析HRESULT或异常类型，返回可读的MTP错误
+// 无法识别时返回nil
+func ParsePowerShellError(output string) *MTPError {
+	for _, hr := range extractHRESULTs(output) {
+		if info, ok := hresultDescriptions[hr]; ok {
+			mtpErr := newMTPErrorFromInfo(info, fmt.Sprintf("%s (0x%08X)", info.Description, hr))
+			mtpErr.AddContext("hresult", fmt.Sprintf("0x%08X", hr))
+			return mtpErr
+		}
+	}
+
+	lowerOutput := strings.ToLower(output)
+	for _, exception := range exceptionDescriptions {
+		if strings.Contains(lowerOutput, strings.ToLower(exception.Keyword)) {
+			mtpErr := newMTPErrorFromInfo(exception.Info, exception.Info.Description)
+			mtpErr.AddContext("exception", exception.Keyword)
+			return mtpErr
+		}
+	}
+
+	return nil
+}
+
+// WrapPowerShellError 包装PowerShell执行失败的错误，能识别原因时附带可读说明
+func WrapPowerShellError(message, output string, err error) error {
+	if parsed := ParsePowerShellError(output); parsed != nil {
+		parsed.Message = fmt.Sprintf("%s: %s", message, parsed.Message)
+		parsed.Cause = err
+		return parsed
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return fmt.Errorf("%s: %s", message, output)
+	}
+	return fmt.Errorf("%s", message)
+}
+
+// HRESULTError 把直接调用COM接口得到的失败HRESULT转换为错误，能识别时返回带可读说明的MTP错误
+func HRESULTError(hr uint32) error {
+	if info, ok := hresultDescriptions[hr]; ok {
+		mtpErr := newMTPErrorFromInfo(info, fmt.Sprintf("%s (0x%08X)", info.Description, hr))
+		mtpErr.AddContext("hresult", fmt.Sprintf("0x%08X", hr))
+		return mtpErr
+	}
+	return NewMTPError(ERROR_COM_ERROR, fmt.Sprintf("COM调用失败 (0x%08X)", hr), nil)
+}
+
+// newMTPErrorFromInfo 根据映射信息创建MTP错误
+func newMTPErrorFromInfo(info hresultInfo, message string) *MTPError {
+	if info.Retryable {
+		return NewRetryableMTPError(info.Code, message, nil)
+	}
+	return NewMTPError(info.Code, message, nil)
+}
+
+// extractHRESULTs 提取输出中出现的所有HRESULT
+func extractHRESULTs(output string) []uint32 {
+	var results []uint32
+
+	for _, match := range hexHRESULTPattern.FindAllStringSubmatch(output, -1) {
+		if hr, err := strconv.ParseUint(match[1], 16, 32); err == nil {
+			results = append(results, uint32(hr))
+		}
+	}
+
+	for _, match := range decimalHRESULTPattern.FindAllString(output, -1) {
+		if hr, err := strconv.ParseInt(match, 10, 32); err == nil {
+			results = append(results, uint32(int32(hr)))
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,120 @@
+//go:build windows
+
+package device
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParsePowerShellError 测试从PowerShell输出解析可读错误
+func TestParsePowerShellError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		output    string
+		expected  string
+		code      MTPErrorCode
+		retryable bool
+	}{
+		{
+			name:      "文件被占用",
+			output:    "Exception calling \"CopyHere\" with \"1\" argument(s): \"Exception from HRESULT: 0x80070020\"",
+			expected:  "文件被其他程序占用",
+			code:      ERROR_DEVICE_BUSY,
+			retryable: true,
+		},
+		{
+			name:     "空间不足",
+			output:   "CopyTo failed (HRESULT: 0x80070070)",
+			expected: "目标磁盘空间不足",
+			code:     ERROR_INVALID_PARAMETER,
+		},
+		{
+			name:     "文件不存在",
+			output:   "System.IO.FileNotFoundException: Could not find file",
+			expected: "找不到指定的文件",
+			code:     ERROR_FILE_NOT_FOUND,
+		},
+		{
+			name:     "权限不足-小写十六进制",
+			output:   "error 0x80070005 access denied",
+			expected: "权限不足",
+			code:     ERROR_ACCESS_DENIED,
+		},
+		{
+			name:      "设备忙",
+			output:    "HResult : 0x800700AA",
+			expected:  "设备忙",
+			code:      ERROR_DEVICE_BUSY,
+			retryable: true,
+		},
+		{
+			name:      "十进制HResult",
+			output:    "HResult                : -2147024864",
+			expected:  "文件被其他程序占用",
+			code:      ERROR_DEVICE_BUSY,
+			retryable: true,
+		},
+		{
+			name:     "异常类型",
+			output:   "System.UnauthorizedAccessException: Access to the path is denied.",
+			expected: "权限不足",
+			code:     ERROR_ACCESS_DENIED,
+		},
+		{
+			name:      "跳过未知HRESULT取下一个",
+			output:    "0x80001234 then 0x800705B4",
+			expected:  "操作超时",
+			code:      ERROR_TIMEOUT,
+			retryable: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mtpErr := ParsePowerShellError(tc.output)
+			if mtpErr == nil {
+				t.Fatalf("应识别出错误: %s", tc.output)
+			}
+			if !strings.Contains(mtpErr.Error(), tc.expected) {
+				t.Errorf("错误消息应包含 %q，实际: %s", tc.expected, mtpErr.Error())
+			}
+			if mtpErr.Code != tc.code {
+				t.Errorf("错误码错误，期望 %d，实际 %d", tc.code, mtpErr.Code)
+			}
+			if mtpErr.Retryable != tc.retryable {
+				t.Errorf("可重试标记错误，期望 %v", tc.retryable)
+			}
+		})
+	}
+}
+
+// TestParsePowerShellError_Unknown 测试无法识别的输出
+func TestParsePowerShellError_Unknown(t *testing.T) {
+	if mtpErr := ParsePowerShellError("ERROR"); mtpErr != nil {
+		t.Errorf("无法识别的输出应返回nil，实际: %v", mtpErr)
+	}
+}
+
+// TestWrapPowerShellError 测试包装错误保留原始原因
+func TestWrapPowerShellError(t *testing.T) {
+	cause := errors.New("exit status 1")
+
+	err := WrapPowerShellError("文件复制失败", "Exception from HRESULT: 0x80070020", cause)
+	if !strings.Contains(err.Error(), "文件复制失败") || !strings.Contains(err.Error(), "文件被其他程序占用") {
+		t.Errorf("包装后的错误消息不完整: %s", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("包装后的错误应保留原始原因")
+	}
+	var mtpErr *MTPError
+	if !errors.As(err, &mtpErr) || mtpErr.Code != ERROR_DEVICE_BUSY {
+		t.Error("应返回带错误码的MTPError")
+	}
+
+	plain := WrapPowerShellError("文件复制失败", "something else", cause)
+	if !errors.Is(plain, cause) {
+		t.Error("无法识别时应使用%w包装原始错误")
+	}
+}
@@ -0,0 +1,286 @@
+//go:build windows
+
+package device
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// DirEntry 目录的一个直接子项
+type DirEntry struct {
+	Name       string
+	IsDir      bool
+	Size       int64          // 文件大小，目录为0
+	ModTime    time.Time      // 文件的修改时间；目录为其子树中最新的修改时间，访问器无法保证时为零值
+	Items      int            // 目录的直接子项数，文件为0
+	Attributes FileAttributes // 文件的只读/隐藏/系统属性
+}
+
+// DirectoryLister 支持逐层列出目录的访问器可选实现的接口，用于快速估算和增量枚举
+type DirectoryLister interface {
+	// ListDirectory 列出目录的直接子项，不递归
+	ListDirectory(dirPath string) ([]DirEntry, error)
+}
+
+// SubtreeSigner DirectoryLister 可选实现的接口，声明列出的子目录能否作为增量枚举的签名：
+// 子目录的 ModTime 为其子树中最新的修改时间时，项数和修改时间都与上次相同即可直接使用上次缓存的子树。
+// 未实现或返回 false 的访问器（如 WPD 设备，目录日期不随子树中文件的变化更新）不开启增量枚举
+type SubtreeSigner interface {
+	SubtreeSignatures() bool
+}
+
+// EnumSnapshot 上次枚举的目录快照，键为规范化后的目录完整路径
+type EnumSnapshot struct {
+	DeviceID  string                  `json:"device_id"`
+	UpdatedAt time.Time               `json:"updated_at"`
+	Dirs      map[string]*DirSnapshot `json:"dirs"`
+}
+
+// DirSnapshot 一个目录在快照中的签名和直接子项
+type DirSnapshot struct {
+	Items   int            `json:"items"`
+	ModTime time.Time      `json:"mod_time"`
+	Files   []SnapshotFile `json:"files"`
+	Subdirs []string       `json:"subdirs"` // 子目录名称
+}
+
+// SnapshotFile 快照中的文件
+type SnapshotFile struct {
+	Name       string         `json:"name"`
+	Size       int64          `json:"size"`
+	ModTime    time.Time      `json:"mod_time"`
+	Attributes FileAttributes `json:"attributes,omitempty"`
+}
+
+// EnumStats 一次增量枚举的统计
+type EnumStats struct {
+	ListedDirs int // 实际列出的目录数
+	ReusedDirs int // 直接使用快照的目录数（含子目录）
+}
+
+// EnumSnapshotPath 设备的枚举快照文件路径，如 data/enum_snapshot_<deviceid>.json
+func EnumSnapshotPath(dataDir, deviceID string) string {
+	name := strings.NewReplacer("\\", "_", "/", "_").Replace(utils.SafeFileName(deviceID))
+	return filepath.Join(dataDir, fmt.Sprintf("enum_snapshot_%s.json", name))
+}
+
+// LoadEnumSnapshot 加载枚举快照，文件不存在时返回空快照
+func LoadEnumSnapshot(path, deviceID string) (*EnumSnapshot, error) {
+	snapshot := &EnumSnapshot{DeviceID: deviceID, Dirs: make(map[string]*DirSnapshot)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot, nil
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("读取枚举快照失败: %w", err)
+	}
+
+	var loaded EnumSnapshot
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return snapshot, fmt.Errorf("解析枚举快照失败: %w", err)
+	}
+	// 快照属于其他设备时不使用
+	if loaded.DeviceID != deviceID || loaded.Dirs == nil {
+		return snapshot, nil
+	}
+	return &loaded, nil
+}
+
+// SaveEnumSnapshot 保存枚举快照
+func SaveEnumSnapshot(path string, snapshot *EnumSnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("序列化枚举快照失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入枚举快照失败: %w", err)
+	}
+	return nil
+}
+
+// IncrementalEnumerator 基于上次枚举快照的增量枚举器，ListFiles 只深入项数或最新修改时间变化的目录，
+// 其余方法直接使用被包装的访问接口
+type IncrementalEnumerator struct {
+	MTPInterface
+	lister       DirectoryLister
+	snapshotPath string
+	log          *logger.Logger
+	now          func() time.Time
+	snapshot     *EnumSnapshot
+	stats        EnumStats
+}
+
+// NewIncrementalEnumerator 包装能逐层列出目录并给出可靠子目录签名的访问接口，不支持时返回 nil 和 false
+func NewIncrementalEnumerator(mtp MTPInterface, snapshotPath string, log *logger.Logger) (*IncrementalEnumerator, bool) {
+	lister, ok := mtp.(DirectoryLister)
+	if !ok {
+		return nil, false
+	}
+	if signer, ok := mtp.(SubtreeSigner); !ok || !signer.SubtreeSignatures() {
+		return nil, false
+	}
+	return &IncrementalEnumerator{
+		MTPInterface: mtp,
+		lister:       lister,
+		snapshotPath: snapshotPath,
+		log:          log,
+		now:          time.Now,
+	}, true
+}
+
+// Stats 返回累计的枚举统计
+func (e *IncrementalEnumerator) Stats() EnumStats {
+	return e.stats
+}
+
+// ListFiles 递归列出 basePath 下的文件，未变化的子目录使用快照，完成后保存新的快照
+func (e *IncrementalEnumerator) ListFiles(basePath string) ([]*FileInfo, error) {
+	if e.snapshot == nil {
+		deviceID := ""
+		if info := e.GetDeviceInfo(); info != nil {
+			deviceID = info.DeviceID
+		}
+		snapshot, err := LoadEnumSnapshot(e.snapshotPath, deviceID)
+		if err != nil {
+			e.log.Warn("加载枚举快照失败，全量枚举: %v", err)
+		}
+		e.snapshot = snapshot
+	}
+
+	root := normalizeDirPath(basePath)
+	fresh := make(map[string]*DirSnapshot)
+	before := e.stats
+	if err := e.listDir(root, fresh); err != nil {
+		return nil, err
+	}
+
+	// 用本次结果替换 basePath 下的旧快照，已删除的目录随之移除
+	for dir := range e.snapshot.Dirs {
+		if dir == root || strings.HasPrefix(dir, root+"\\") || root == "" {
+			delete(e.snapshot.Dirs, dir)
+		}
+	}
+	for dir, snap := range fresh {
+		e.snapshot.Dirs[dir] = snap
+	}
+	e.snapshot.UpdatedAt = e.now()
+	if err := SaveEnumSnapshot(e.snapshotPath, e.snapshot); err != nil {
+		e.log.Warn("保存枚举快照失败: %v", err)
+	}
+
+	e.log.Debug("增量枚举 %s: 列出 %d 个目录，复用 %d 个未变化的目录", basePath,
+		e.stats.ListedDirs-before.ListedDirs, e.stats.ReusedDirs-before.ReusedDirs)
+	return collectSnapshotFiles(fresh, root), nil
+}
+
+// listDir 列出目录，对每个子目录比较签名，未变化时复制快照中的子树，否则或修改时间为零值时继续深入
+func (e *IncrementalEnumerator) listDir(dir string, fresh map[string]*DirSnapshot) error {
+	entries, err := e.lister.ListDirectory(dir)
+	if err != nil {
+		return fmt.Errorf("列出目录 %s 失败: %w", dir, err)
+	}
+	e.stats.ListedDirs++
+
+	snap := &DirSnapshot{Items: len(entries)}
+	for _, entry := range entries {
+		if entry.ModTime.After(snap.ModTime) {
+			snap.ModTime = entry.ModTime
+		}
+		if !entry.IsDir {
+			snap.Files = append(snap.Files, SnapshotFile{Name: entry.Name, Size: entry.Size, ModTime: entry.ModTime, Attributes: entry.Attributes})
+			continue
+		}
+
+		snap.Subdirs = append(snap.Subdirs, entry.Name)
+		child := joinDevicePath(dir, entry.Name)
+		if cached, ok := e.snapshot.Dirs[child]; ok && !entry.ModTime.IsZero() && cached.Items == entry.Items && cached.ModTime.Equal(entry.ModTime) {
+			if e.reuseSubtree(child, fresh) {
+				continue
+			}
+		}
+		if err := e.listDir(child, fresh); err != nil {
+			return err
+		}
+	}
+	fresh[dir] = snap
+	return nil
+}
+
+// reuseSubtree 把快照中 dir 的整个子树复制到本次结果，快照不完整时返回 false
+func (e *IncrementalEnumerator) reuseSubtree(dir string, fresh map[string]*DirSnapshot) bool {
+	subtree := make(map[string]*DirSnapshot)
+	var walk func(string) bool
+	walk = func(path string) bool {
+		snap, ok := e.snapshot.Dirs[path]
+		if !ok {
+			return false
+		}
+		subtree[path] = snap
+		for _, name := range snap.Subdirs {
+			if !walk(joinDevicePath(path, name)) {
+				return false
+			}
+		}
+		return true
+	}
+	if !walk(dir) {
+		return false
+	}
+
+	for path, snap := range subtree {
+		fresh[path] = snap
+	}
+	e.stats.ReusedDirs += len(subtree)
+	return true
+}
+
+// collectSnapshotFiles 把目录快照展开为文件列表，相对路径相对于 root，按路径排序
+func collectSnapshotFiles(dirs map[string]*DirSnapshot, root string) []*FileInfo {
+	var files []*FileInfo
+	for dir, snap := range dirs {
+		for _, file := range snap.Files {
+			path := joinDevicePath(dir, file.Name)
+			relativePath := path
+			if root != "" {
+				relativePath = strings.TrimPrefix(path, root+"\\")
+			}
+			files = append(files, &FileInfo{
+				Path:         path,
+				RelativePath: relativePath,
+				Name:         file.Name,
+				Size:         file.Size,
+				IsOpus:       utils.IsOpusFile(file.Name),
+				ModTime:      file.ModTime,
+				Attributes:   file.Attributes,
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// normalizeDirPath 统一使用 \ 分隔并去掉首尾分隔符
+func normalizeDirPath(path string) string {
+	return strings.Trim(strings.ReplaceAll(path, "/", "\\"), "\\")
+}
+
+// joinDevicePath 拼接设备路径
+func joinDevicePath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "\\" + name
+}
@@ -0,0 +1,215 @@
+//go:build windows
+
+package device
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// TestIncrementalEnumerator 测试第二次枚举只重新列出变化的目录，结果仍与全量枚举一致
+func TestIncrementalEnumerator(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件"
+	log := logger.NewLogger(false)
+	snapshotPath := EnumSnapshotPath(t.TempDir(), "USB\\VID_2207&PID_0011\\SR302")
+
+	fake := NewFakeMTPAccessor(nil)
+	fake.AddStorage(StorageInfo{ID: "s1", Name: "内部共享存储空间"})
+	modTime := time.Date(2024, 3, 11, 9, 30, 0, 0, time.Local)
+	fake.AddFile(base+"\\2024-01\\a.opus", []byte("aaaa"), modTime)
+	fake.AddFile(base+"\\2024-01\\b.opus", []byte("bb"), modTime.Add(time.Minute))
+	fake.AddFile(base+"\\2024-02\\c.opus", []byte("c"), modTime)
+	fake.AddFile(base+"\\2024-02\\会议\\d.opus", []byte("dd"), modTime)
+	fake.AddFile(base+"\\2024-03\\e.opus", []byte("eee"), modTime)
+
+	dirs := []string{base, base + "\\2024-01", base + "\\2024-02", base + "\\2024-02\\会议", base + "\\2024-03"}
+
+	steps := []struct {
+		name       string
+		change     func()
+		wantListed []string // 本次应重新列出的目录
+		wantReused int
+	}{
+		{
+			name:       "首次全量枚举",
+			change:     func() {},
+			wantListed: dirs,
+			wantReused: 0,
+		},
+		{
+			name: "只新增一个目录中的文件",
+			change: func() {
+				fake.AddFile(base+"\\2024-03\\f.opus", []byte("ffff"), modTime.Add(time.Hour))
+			},
+			wantListed: []string{base, base + "\\2024-03"},
+			wantReused: 3,
+		},
+		{
+			name: "深层目录中的文件被修改",
+			change: func() {
+				fake.AddFile(base+"\\2024-02\\会议\\d.opus", []byte("dddd"), modTime.Add(2*time.Hour))
+			},
+			wantListed: []string{base, base + "\\2024-02", base + "\\2024-02\\会议"},
+			wantReused: 2,
+		},
+		{
+			name: "删除文件后项数变化",
+			change: func() {
+				if err := fake.DeleteFile(base + "\\2024-01\\a.opus"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantListed: []string{base, base + "\\2024-01"},
+			wantReused: 3,
+		},
+		{
+			name:       "没有变化时只列出根目录",
+			change:     func() {},
+			wantListed: []string{base},
+			wantReused: 4,
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.change()
+			before := make(map[string]int)
+			for _, dir := range dirs {
+				before[dir] = fake.DirectoryListings(dir)
+			}
+
+			// 每次运行使用新的枚举器，从磁盘加载上次的快照
+			enumerator, ok := NewIncrementalEnumerator(fake, snapshotPath, log)
+			if !ok {
+				t.Fatal("虚拟设备应支持逐层列出目录")
+			}
+			files, err := ListFilesInStorages(enumerator, base, StorageAll, log)
+			if err != nil {
+				t.Fatalf("增量枚举失败: %v", err)
+			}
+
+			listed := make(map[string]bool)
+			for _, dir := range step.wantListed {
+				listed[dir] = true
+			}
+			for _, dir := range dirs {
+				got := fake.DirectoryListings(dir) - before[dir]
+				want := 0
+				if listed[dir] {
+					want = 1
+				}
+				if got != want {
+					t.Errorf("目录 %s 被列出 %d 次，期望 %d 次", dir, got, want)
+				}
+			}
+			if stats := enumerator.Stats(); stats.ListedDirs != len(step.wantListed) || stats.ReusedDirs != step.wantReused {
+				t.Errorf("统计 = %+v，期望列出 %d 个、复用 %d 个", stats, len(step.wantListed), step.wantReused)
+			}
+
+			// 结果与全量枚举完全一致
+			want, _ := fake.ListFiles(base)
+			if len(files) != len(want) {
+				t.Fatalf("增量枚举得到 %d 个文件，全量枚举 %d 个", len(files), len(want))
+			}
+			for i, file := range files {
+				w := want[i]
+				if file.Path != w.Path || file.RelativePath != w.RelativePath || file.Name != w.Name ||
+					file.Size != w.Size || file.IsOpus != w.IsOpus || !file.ModTime.(time.Time).Equal(w.ModTime.(time.Time)) {
+					t.Errorf("第 %d 个文件 = %+v，期望 %+v", i, file, w)
+				}
+			}
+		})
+	}
+}
+
+// unreliableDirTimes 目录修改时间不随子树变化的访问器，子目录的 ModTime 总为零值
+type unreliableDirTimes struct {
+	*FakeMTPAccessor
+}
+
+func (u unreliableDirTimes) ListDirectory(dirPath string) ([]DirEntry, error) {
+	entries, err := u.FakeMTPAccessor.ListDirectory(dirPath)
+	for i := range entries {
+		if entries[i].IsDir {
+			entries[i].ModTime = time.Time{}
+		}
+	}
+	return entries, err
+}
+
+// TestIncrementalEnumerator_UnreliableDirTimes 测试目录修改时间不可靠时总是全量列出，深层的修改不会被漏掉
+func TestIncrementalEnumerator_UnreliableDirTimes(t *testing.T) {
+	const base = "内部共享存储空间\\录音笔文件"
+	log := logger.NewLogger(false)
+	snapshotPath := EnumSnapshotPath(t.TempDir(), "dev1")
+
+	fake := NewFakeMTPAccessor(nil)
+	modTime := time.Date(2024, 3, 11, 9, 30, 0, 0, time.Local)
+	fake.AddFile(base+"\\2024-02\\会议\\d.opus", []byte("dd"), modTime)
+	accessor := unreliableDirTimes{fake}
+
+	for i, want := range []int{1, 2} {
+		if i == 1 {
+			fake.AddFile(base+"\\2024-02\\会议\\e.opus", []byte("e"), modTime)
+		}
+		enumerator, ok := NewIncrementalEnumerator(accessor, snapshotPath, log)
+		if !ok {
+			t.Fatal("应支持逐层列出目录")
+		}
+		files, err := enumerator.ListFiles(base)
+		if err != nil {
+			t.Fatalf("增量枚举失败: %v", err)
+		}
+		if len(files) != want {
+			t.Errorf("第 %d 次枚举得到 %d 个文件，期望 %d 个", i+1, len(files), want)
+		}
+		if stats := enumerator.Stats(); stats.ListedDirs != 3 || stats.ReusedDirs != 0 {
+			t.Errorf("第 %d 次枚举统计 = %+v，应全部重新列出", i+1, stats)
+		}
+	}
+}
+
+// unsignedLister 能逐层列出目录、但目录签名不可靠的访问器，如 WPD 设备
+type unsignedLister struct {
+	*FakeMTPAccessor
+}
+
+func (unsignedLister) SubtreeSignatures() bool {
+	return false
+}
+
+// TestNewIncrementalEnumerator_RequiresSignatures 测试访问器无法给出可靠的目录签名时不开启增量枚举
+func TestNewIncrementalEnumerator_RequiresSignatures(t *testing.T) {
+	snapshotPath := EnumSnapshotPath(t.TempDir(), "dev1")
+	if _, ok := NewIncrementalEnumerator(unsignedLister{NewFakeMTPAccessor(nil)}, snapshotPath, logger.NewLogger(false)); ok {
+		t.Error("目录签名不可靠的访问器不应开启增量枚举")
+	}
+}
+
+// TestLoadEnumSnapshot 测试快照文件不存在或属于其他设备时返回空快照
+func TestLoadEnumSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := EnumSnapshotPath(dir, "USB\\VID_2207&PID_0011\\SR302")
+	if filepath.Dir(path) != dir || filepath.Base(path) != "enum_snapshot_USB_VID_2207&PID_0011_SR302.json" {
+		t.Errorf("快照路径 = %s", path)
+	}
+
+	snapshot, err := LoadEnumSnapshot(path, "dev1")
+	if err != nil || len(snapshot.Dirs) != 0 {
+		t.Fatalf("快照不存在时应返回空快照: %+v, %v", snapshot, err)
+	}
+
+	snapshot.Dirs["录音笔文件"] = &DirSnapshot{Items: 1, Files: []SnapshotFile{{Name: "a.opus", Size: 1}}}
+	if err := SaveEnumSnapshot(path, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := LoadEnumSnapshot(path, "dev1"); err != nil || len(loaded.Dirs) != 1 {
+		t.Errorf("应加载同一设备的快照: %+v, %v", loaded, err)
+	}
+	if loaded, err := LoadEnumSnapshot(path, "dev2"); err != nil || len(loaded.Dirs) != 0 {
+		t.Errorf("其他设备的快照不应使用: %+v, %v", loaded, err)
+	}
+}
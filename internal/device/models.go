@@ -0,0 +1,88 @@
+package device
+
+import (
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/config"
+)
+
+// ModelProfile 录音笔型号的默认参数
+type ModelProfile struct {
+	Model          string   // 型号名称
+	DeviceName     string   // 设备在系统中显示的名称
+	BasePath       string   // 录音文件所在路径
+	VID            string   // USB厂商ID
+	PID            string   // USB产品ID
+	FileExtensions []string // 录音文件扩展名
+}
+
+// GenericModel 未知型号使用的通用默认参数
+var GenericModel = ModelProfile{
+	Model:          "generic",
+	BasePath:       "内部共享存储空间\\录音笔文件",
+	FileExtensions: []string{".opus"},
+}
+
+// KnownModels 已知型号注册表，键为大写型号名，可通过 RegisterModel 扩展
+var KnownModels = map[string]ModelProfile{
+	SR302_NAME: {
+		Model:          SR302_NAME,
+		DeviceName:     SR302_NAME,
+		BasePath:       "内部共享存储空间\\录音笔文件",
+		VID:            SR302_VID,
+		PID:            SR302_PID,
+		FileExtensions: []string{".opus"},
+	},
+}
+
+// RegisterModel 注册或覆盖一个型号的默认参数
+func RegisterModel(profile ModelProfile) {
+	KnownModels[strings.ToUpper(profile.Model)] = profile
+}
+
+// LookupModel 按型号名（不区分大小写）查找已知型号
+func LookupModel(model string) (ModelProfile, bool) {
+	profile, ok := KnownModels[strings.ToUpper(strings.TrimSpace(model))]
+	return profile, ok
+}
+
+// ModelDefaults 返回型号的默认参数，未知型号返回以该型号为设备名的通用默认参数
+func ModelDefaults(model string) ModelProfile {
+	if profile, ok := LookupModel(model); ok {
+		return profile
+	}
+
+	profile := GenericModel
+	profile.DeviceName = strings.TrimSpace(model)
+	profile.FileExtensions = append([]string(nil), GenericModel.FileExtensions...)
+	return profile
+}
+
+// MatchModel 根据设备名称或VID/PID识别已知型号
+func MatchModel(name, vid, pid string) (ModelProfile, bool) {
+	upperName := strings.ToUpper(name)
+	for _, profile := range KnownModels {
+		if profile.VID != "" && profile.VID == vid && profile.PID == pid {
+			return profile, true
+		}
+		if profile.DeviceName != "" && strings.Contains(upperName, strings.ToUpper(profile.DeviceName)) {
+			return profile, true
+		}
+	}
+	return ModelProfile{}, false
+}
+
+// ApplyTo 将型号参数写入配置，VID/PID总是覆盖（通用型号清空以免误匹配），其余空值保留原有设置
+func (p ModelProfile) ApplyTo(cfg *config.Config) {
+	if p.DeviceName != "" {
+		cfg.Source.DeviceName = p.DeviceName
+	}
+	if p.BasePath != "" {
+		cfg.Source.BasePath = p.BasePath
+	}
+	cfg.Source.VID = p.VID
+	cfg.Source.PID = p.PID
+	if len(p.FileExtensions) > 0 {
+		cfg.Backup.FileExtensions = append([]string(nil), p.FileExtensions...)
+	}
+}
@@ -0,0 +1,84 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/config"
+)
+
+// TestModelDefaults 测试已知型号返回注册参数、未知型号返回通用默认参数
+func TestModelDefaults(t *testing.T) {
+	testCases := []struct {
+		name       string
+		model      string
+		deviceName string
+		basePath   string
+		vid        string
+		pid        string
+	}{
+		{"已知型号", "SR302", "SR302", "内部共享存储空间\\录音笔文件", "2207", "0011"},
+		{"不区分大小写", "sr302", "SR302", "内部共享存储空间\\录音笔文件", "2207", "0011"},
+		{"未知型号", "X100", "X100", GenericModel.BasePath, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := ModelDefaults(tc.model)
+			if profile.DeviceName != tc.deviceName || profile.BasePath != tc.basePath ||
+				profile.VID != tc.vid || profile.PID != tc.pid {
+				t.Errorf("型号 %s 参数错误: %+v", tc.model, profile)
+			}
+			if len(profile.FileExtensions) == 0 {
+				t.Error("应包含默认文件扩展名")
+			}
+		})
+	}
+
+	if _, ok := LookupModel("X100"); ok {
+		t.Error("未知型号不应被识别为已知型号")
+	}
+}
+
+// TestRegisterModel 测试扩展注册表
+func TestRegisterModel(t *testing.T) {
+	RegisterModel(ModelProfile{Model: "Test01", DeviceName: "TEST01", BasePath: "内部存储\\Record", VID: "1234", PID: "5678", FileExtensions: []string{".mp3"}})
+	defer delete(KnownModels, "TEST01")
+
+	profile, ok := LookupModel("test01")
+	if !ok || profile.BasePath != "内部存储\\Record" {
+		t.Fatalf("注册的型号应可查到: %+v", profile)
+	}
+
+	matched, ok := MatchModel("USB Device", "1234", "5678")
+	if !ok || matched.Model != "Test01" {
+		t.Errorf("应按VID/PID识别注册的型号，实际: %+v", matched)
+	}
+}
+
+// TestMatchModel 测试按设备名称或VID/PID识别型号
+func TestMatchModel(t *testing.T) {
+	if profile, ok := MatchModel("SR302 录音笔", "", ""); !ok || profile.Model != SR302_NAME {
+		t.Errorf("应按名称识别SR302")
+	}
+	if profile, ok := MatchModel("MTP Device", SR302_VID, SR302_PID); !ok || profile.Model != SR302_NAME {
+		t.Errorf("应按VID/PID识别SR302")
+	}
+	if _, ok := MatchModel("Unknown Device", "0000", "0000"); ok {
+		t.Error("未知设备不应被识别")
+	}
+}
+
+// TestModelProfile_ApplyTo 测试将型号参数写入配置
+func TestModelProfile_ApplyTo(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Source.VID = "ffff"
+
+	ModelDefaults("SR302").ApplyTo(cfg)
+
+	if cfg.Source.DeviceName != "SR302" || cfg.Source.VID != "2207" || cfg.Source.PID != "0011" {
+		t.Errorf("配置未正确填充: %+v", cfg.Source)
+	}
+	if len(cfg.Backup.FileExtensions) != 1 || cfg.Backup.FileExtensions[0] != ".opus" {
+		t.Errorf("文件扩展名错误: %v", cfg.Backup.FileExtensions)
+	}
+}
@@ -347,5 +347,6 @@ type FileInfo struct {
 	Name         string
 	Size         int64
 	IsOpus       bool
-	ModTime      interface{} // 可以是time.Time或其他类型
+	ModTime      interface{}    // 可以是time.Time或其他类型
+	Attributes   FileAttributes // 只读/隐藏/系统属性，读取不到时为0
 }
\ No newline at end of file
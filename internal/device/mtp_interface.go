@@ -3,10 +3,17 @@
 package device
 
 import (
+	"errors"
 	"io"
 	"time"
 )
 
+// ErrDeviceNotConnected 设备未连接或在访问过程中被拔出，代码为 ERROR_DEVICE_NOT_FOUND 的 MTPError 也视为该错误
+var ErrDeviceNotConnected = errors.New("设备未连接")
+
+// ErrFileNotFound 设备上找不到指定的文件或路径，代码为 ERROR_FILE_NOT_FOUND 的 MTPError 也视为该错误
+var ErrFileNotFound = errors.New("找不到指定的文件")
+
 // MTPInterface 定义统一的MTP设备访问接口
 type MTPInterface interface {
 	// ConnectToDevice 连接到指定的MTP设备
@@ -15,6 +22,9 @@ type MTPInterface interface {
 	// ListFiles 列出指定路径下的文件
 	ListFiles(basePath string) ([]*FileInfo, error)
 
+	// ListStorages 列出设备下的存储（内部存储、SD卡等），不支持时返回空列表
+	ListStorages() []StorageInfo
+
 	// GetFileStream 获取文件读取流
 	GetFileStream(filePath string) (io.ReadCloser, error)
 
@@ -26,6 +36,24 @@ type MTPInterface interface {
 
 	// GetDeviceInfo 获取设备信息
 	GetDeviceInfo() *DeviceInfo
+
+	// GetDeviceDetails 获取型号、固件版本、序列号、电量等详细信息，读取不到的字段留空
+	GetDeviceDetails() (*DeviceDetails, error)
+
+	// QuickStat 快速估算设备上的文件数和总大小，只列出顶层几级目录或使用存储已用空间，不做全量递归
+	QuickStat() (approxCount int, approxBytes int64, err error)
+}
+
+// RangeReader 支持按偏移读取文件片段的访问器可选实现的接口，copier 据此分片并行下载大文件
+type RangeReader interface {
+	// ReadRange 读取文件从 off 开始的 length 字节，到达文件末尾时返回的数据少于 length
+	ReadRange(filePath string, off, length int64) ([]byte, error)
+}
+
+// FileExister 支持检查单个文件是否存在的访问器可选实现的接口，copier 据此在复制前后确认源文件仍在设备上
+type FileExister interface {
+	// Exists 判断设备上的文件是否存在，设备未连接等无法判断的情况返回错误
+	Exists(filePath string) (bool, error)
 }
 
 // DeviceBridge 定义设备检测与MTP访问桥接接口
@@ -74,6 +102,8 @@ const (
 	ERROR_COM_ERROR
 	// ERROR_POWER_SHELL_FAILED PowerShell执行失败
 	ERROR_POWER_SHELL_FAILED
+	// ERROR_FILE_NOT_FOUND 文件或路径不存在
+	ERROR_FILE_NOT_FOUND
 )
 
 // MTPError 定义MTP访问错误
@@ -93,6 +123,24 @@ func (e *MTPError) Error() string {
 	return e.Message
 }
 
+// Unwrap 返回底层错误
+func (e *MTPError) Unwrap() error {
+	return e.Cause
+}
+
+// Is 设备未找到的错误与 ErrDeviceNotConnected 匹配，文件不存在的错误与 ErrFileNotFound 匹配，
+// 便于用 errors.Is 判断设备是否已断开、源文件是否已被删除
+func (e *MTPError) Is(target error) bool {
+	switch target {
+	case ErrDeviceNotConnected:
+		return e.Code == ERROR_DEVICE_NOT_FOUND
+	case ErrFileNotFound:
+		return e.Code == ERROR_FILE_NOT_FOUND
+	default:
+		return false
+	}
+}
+
 // IsRetryable 检查错误是否可重试
 func (e *MTPError) IsRetryable() bool {
 	return e.Retryable ||
@@ -163,6 +211,8 @@ const (
 	MethodWMI AccessMethod = "WMI"
 	// MethodDirectFile 直接文件系统访问
 	MethodDirectFile AccessMethod = "DirectFile"
+	// MethodWPDNative 纯Go直接调用WPD COM接口，不依赖PowerShell
+	MethodWPDNative AccessMethod = "WPDNative"
 )
 
 // AccessResult 定义访问结果
@@ -0,0 +1,117 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrObjectNotFound 设备路径或对象ID不在最近一次枚举结果中
+var ErrObjectNotFound = errors.New("设备对象不存在")
+
+// ObjectIndex 设备路径与WPD对象ID的双向索引
+// 每次枚举设备文件时整体重建，删除、读取属性等操作通过它把路径解析为对象ID，
+// 不再从对象ID字符串中猜测文件名
+type ObjectIndex struct {
+	mutex    sync.RWMutex
+	byPath   map[string]string // 规范化路径 -> 对象ID
+	byObject map[string]string // 对象ID -> 枚举时的原始路径
+}
+
+// NewObjectIndex 创建空的对象索引
+func NewObjectIndex() *ObjectIndex {
+	return &ObjectIndex{
+		byPath:   make(map[string]string),
+		byObject: make(map[string]string),
+	}
+}
+
+// Rebuild 用一次枚举得到的 路径 -> 对象ID 映射替换整个索引，旧条目全部丢弃
+func (idx *ObjectIndex) Rebuild(entries map[string]string) {
+	byPath := make(map[string]string, len(entries))
+	byObject := make(map[string]string, len(entries))
+	for path, objectID := range entries {
+		if path == "" || objectID == "" {
+			continue
+		}
+		byPath[objectPathKey(path)] = objectID
+		byObject[objectID] = path
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	idx.byPath = byPath
+	idx.byObject = byObject
+}
+
+// Set 登记单个路径与对象ID的对应关系
+func (idx *ObjectIndex) Set(path, objectID string) {
+	if path == "" || objectID == "" {
+		return
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	key := objectPathKey(path)
+	if old, ok := idx.byPath[key]; ok {
+		delete(idx.byObject, old)
+	}
+	idx.byPath[key] = objectID
+	idx.byObject[objectID] = path
+}
+
+// Remove 删除路径对应的条目，文件从设备上删除后调用
+func (idx *ObjectIndex) Remove(path string) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	key := objectPathKey(path)
+	if objectID, ok := idx.byPath[key]; ok {
+		delete(idx.byObject, objectID)
+		delete(idx.byPath, key)
+	}
+}
+
+// Lookup 把设备路径解析为对象ID，路径不区分大小写、分隔符可以是 / 或 \
+func (idx *ObjectIndex) Lookup(path string) (string, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	if objectID, ok := idx.byPath[objectPathKey(path)]; ok {
+		return objectID, nil
+	}
+	return "", fmt.Errorf("%w: 路径 %s", ErrObjectNotFound, path)
+}
+
+// PathOf 把对象ID解析为设备路径
+func (idx *ObjectIndex) PathOf(objectID string) (string, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	if path, ok := idx.byObject[objectID]; ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("%w: 对象ID %s", ErrObjectNotFound, objectID)
+}
+
+// Len 返回索引中的条目数
+func (idx *ObjectIndex) Len() int {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return len(idx.byPath)
+}
+
+// objectPathKey 规范化设备路径：统一分隔符、去掉首尾分隔符并忽略大小写
+func objectPathKey(path string) string {
+	path = strings.ReplaceAll(path, "/", "\\")
+	path = strings.Trim(path, "\\")
+	return strings.ToLower(path)
+}
+
+// objectIDFromShellPath 从Shell项的解析路径中取出WPD对象ID
+// 便携设备上的Shell路径形如 ::{...}\\?\usb#vid_...\SID-{...}\o1A2B，最后一段即对象ID
+func objectIDFromShellPath(shellPath string) string {
+	shellPath = strings.TrimRight(strings.TrimSpace(shellPath), "\\")
+	if i := strings.LastIndex(shellPath, "\\"); i >= 0 {
+		return shellPath[i+1:]
+	}
+	return shellPath
+}
@@ -0,0 +1,110 @@
+package device
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestObjectIndex_Lookup 测试路径与对象ID的双向解析
+func TestObjectIndex_Lookup(t *testing.T) {
+	idx := NewObjectIndex()
+	idx.Rebuild(map[string]string{
+		"内部共享存储空间\\录音笔文件\\2025\\11月\\会谈录音_1.opus": "o1A2B",
+		"内部共享存储空间\\录音笔文件\\REC001.wav":             "o1A2C",
+	})
+
+	testCases := []struct {
+		name     string
+		path     string
+		objectID string
+		found    bool
+	}{
+		{"原始路径", "内部共享存储空间\\录音笔文件\\2025\\11月\\会谈录音_1.opus", "o1A2B", true},
+		{"正斜杠分隔", "内部共享存储空间/录音笔文件/REC001.wav", "o1A2C", true},
+		{"大小写不同", "内部共享存储空间\\录音笔文件\\rec001.WAV", "o1A2C", true},
+		{"首尾分隔符", "\\内部共享存储空间\\录音笔文件\\REC001.wav\\", "o1A2C", true},
+		{"未枚举的路径", "内部共享存储空间\\录音笔文件\\REC002.wav", "", false},
+		{"同名不同目录", "内部共享存储空间\\会谈录音_1.opus", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objectID, err := idx.Lookup(tc.path)
+			if !tc.found {
+				if !errors.Is(err, ErrObjectNotFound) {
+					t.Errorf("期望返回 ErrObjectNotFound，实际 %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析路径失败: %v", err)
+			}
+			if objectID != tc.objectID {
+				t.Errorf("对象ID错误，期望 %s，实际 %s", tc.objectID, objectID)
+			}
+
+			path, err := idx.PathOf(objectID)
+			if err != nil {
+				t.Fatalf("反向解析失败: %v", err)
+			}
+			if objectPathKey(path) != objectPathKey(tc.path) {
+				t.Errorf("反向解析路径错误: %s", path)
+			}
+		})
+	}
+
+	if _, err := idx.PathOf("o9999"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("未知对象ID期望返回 ErrObjectNotFound，实际 %v", err)
+	}
+}
+
+// TestObjectIndex_Rebuild 测试重新枚举后旧条目被丢弃
+func TestObjectIndex_Rebuild(t *testing.T) {
+	idx := NewObjectIndex()
+	idx.Rebuild(map[string]string{"a.opus": "o1", "b.opus": "o2"})
+	idx.Rebuild(map[string]string{"b.opus": "o3", "c.opus": "o4"})
+
+	if _, err := idx.Lookup("a.opus"); !errors.Is(err, ErrObjectNotFound) {
+		t.Error("已不在设备上的文件应从索引中移除")
+	}
+	if _, err := idx.PathOf("o2"); !errors.Is(err, ErrObjectNotFound) {
+		t.Error("旧对象ID应从索引中移除")
+	}
+	if objectID, err := idx.Lookup("b.opus"); err != nil || objectID != "o3" {
+		t.Errorf("b.opus 应解析为新对象ID o3，实际 %s, %v", objectID, err)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("索引条目数 = %d，期望 2", idx.Len())
+	}
+
+	idx.Set("c.opus", "o5")
+	if _, err := idx.PathOf("o4"); !errors.Is(err, ErrObjectNotFound) {
+		t.Error("重新登记后旧对象ID应失效")
+	}
+	idx.Remove("C.OPUS")
+	if _, err := idx.Lookup("c.opus"); !errors.Is(err, ErrObjectNotFound) {
+		t.Error("删除后路径应无法解析")
+	}
+}
+
+// TestObjectIDFromShellPath 测试从Shell解析路径中提取对象ID
+func TestObjectIDFromShellPath(t *testing.T) {
+	testCases := []struct {
+		name      string
+		shellPath string
+		objectID  string
+	}{
+		{"便携设备路径", `::{20D04FE0-3AEA-1069-A2D8-08002B30309D}\\?\usb#vid_2207&pid_0011#sr302#{6ac27878-a6fa-4155-ba85-f98f491d4f33}\SID-{10001,,}\o1A2B`, "o1A2B"},
+		{"末尾分隔符", `SID-{10001,,}\o1A2B\`, "o1A2B"},
+		{"只有对象ID", "o1A2B", "o1A2B"},
+		{"空路径", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := objectIDFromShellPath(tc.shellPath); got != tc.objectID {
+				t.Errorf("期望 %q，实际 %q", tc.objectID, got)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
 )
 
 // WindowsShellResolver Windows Shell COM路径解析器
@@ -89,11 +90,55 @@ func (psr *PowerShellResolver) GetPriority() int {
 // IsAvailable 检查是否可用
 func (psr *PowerShellResolver) IsAvailable() bool {
 	// 检查PowerShell是否可用
-	cmd := exec.Command("powershell", "-Command", "Get-Host")
+	cmd := psexec.Command("-Command", "Get-Host")
 	err := cmd.Run()
 	return err == nil
 }
 
+// WPDNativeResolver 纯Go WPD解析器，通过 go-ole 直接查询WPD设备管理器，不依赖PowerShell
+type WPDNativeResolver struct {
+	log      *logger.Logger
+	priority int
+}
+
+// NewWPDNativeResolver 创建纯Go WPD解析器
+func NewWPDNativeResolver(log *logger.Logger) *WPDNativeResolver {
+	return &WPDNativeResolver{
+		log:      log,
+		priority: 70, // 低于PowerShell，PowerShell不可用时接替
+	}
+}
+
+// Resolve 返回WPD设备管理器中匹配设备的PnP设备ID
+func (wnr *WPDNativeResolver) Resolve(deviceName, vid, pid string) (string, error) {
+	wnr.log.Debug("使用纯Go WPD解析器: %s", deviceName)
+
+	devices, err := EnumerateWPDDevices()
+	if err != nil {
+		wnr.log.Debug("WPD设备枚举失败: %v", err)
+		return "", err
+	}
+	info, err := FindDevice(devices, deviceName, vid, pid)
+	if err != nil {
+		return "", err
+	}
+	return info.DeviceID, nil
+}
+
+// GetPriority 获取优先级
+func (wnr *WPDNativeResolver) GetPriority() int {
+	return wnr.priority
+}
+
+// IsAvailable 检查能否创建WPD设备管理器，与PowerShell是否可用无关
+func (wnr *WPDNativeResolver) IsAvailable() bool {
+	_, err := EnumerateWPDDevices()
+	if err != nil {
+		wnr.log.Debug("WPD设备管理器不可用: %v", err)
+	}
+	return err == nil
+}
+
 // WMIResolver WMI路径解析器
 type WMIResolver struct {
 	log     *logger.Logger
@@ -127,14 +172,14 @@ ForEach-Object {
 }
 `, vid, pid)
 
-	cmd := exec.Command("powershell", "-Command", script)
-	output, err := cmd.Output()
+	cmd := psexec.Command("-Command", script)
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		wmir.log.Debug("WMI查询失败: %v", err)
 		return "", err
 	}
 
-	result := strings.TrimSpace(string(output))
+	result := strings.TrimSpace(output)
 	if result == "NOT_FOUND" || result == "" {
 		return "", fmt.Errorf("WMI未找到设备路径")
 	}
@@ -259,14 +304,14 @@ if ($portable) {
 }
 `, deviceName)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.Output()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		pser.log.Debug("增强PowerShell路径获取失败: %v", err)
 		return "", err
 	}
 
-	devicePath := strings.TrimSpace(string(output))
+	devicePath := strings.TrimSpace(output)
 	if devicePath == "" {
 		return "", fmt.Errorf("增强PowerShell未找到设备路径")
 	}
@@ -282,27 +327,27 @@ func (pser *PowerShellEnhancedResolver) GetPriority() int {
 // IsAvailable 检查是否可用
 func (pser *PowerShellEnhancedResolver) IsAvailable() bool {
 	// 检查PowerShell是否可用以及执行策略
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", "Get-Host")
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", "Get-Host")
 	err := cmd.Run()
 	if err != nil {
 		return false
 	}
 
 	// 检查COM对象是否可用
-	comCmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", "$shell = New-Object -ComObject Shell.Application; $shell.Name")
+	comCmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", "$shell = New-Object -ComObject Shell.Application; $shell.Name")
 	comErr := comCmd.Run()
 	return comErr == nil
 }
 
 // testPathAccessibility 测试路径是否可访问
 func (dfr *DirectFileResolver) testPathAccessibility(path string) bool {
-	cmd := exec.Command("powershell", "-Command", fmt.Sprintf("Test-Path '%s'", path))
-	output, err := cmd.Output()
+	cmd := psexec.Command("-Command", fmt.Sprintf("Test-Path '%s'", path))
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		return false
 	}
 
-	return strings.TrimSpace(string(output)) == "True"
+	return strings.TrimSpace(output) == "True"
 }
 
 // NewWMIMTPAccessor 创建WMI MTP访问器（占位符实现）
@@ -339,6 +384,11 @@ func (wmi *WMIMTPAccessor) ListFiles(basePath string) ([]*FileInfo, error) {
 	return []*FileInfo{}, nil
 }
 
+// ListStorages 列出存储（WMI不支持存储枚举）
+func (wmi *WMIMTPAccessor) ListStorages() []StorageInfo {
+	return nil
+}
+
 // GetFileStream 获取文件流
 func (wmi *WMIMTPAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
 	return nil, fmt.Errorf("WMI不支持文件流访问")
@@ -361,6 +411,16 @@ func (wmi *WMIMTPAccessor) GetDeviceInfo() *DeviceInfo {
 	return wmi.device
 }
 
+// QuickStat 快速估算设备上的文件数和总大小
+func (wmi *WMIMTPAccessor) QuickStat() (int, int64, error) {
+	return EstimateFiles(wmi)
+}
+
+// GetDeviceDetails 获取设备详细信息，WMI只能提供设备基本信息
+func (wmi *WMIMTPAccessor) GetDeviceDetails() (*DeviceDetails, error) {
+	return NewDeviceDetails(wmi.device, nil), nil
+}
+
 // NewDirectFileAccessor 创建直接文件访问器
 func NewDirectFileAccessor(log *logger.Logger, devicePath string) MTPInterface {
 	return &DirectFileAccessor{
@@ -420,6 +480,11 @@ func (dfa *DirectFileAccessor) ListFiles(basePath string) ([]*FileInfo, error) {
 	return files, err
 }
 
+// ListStorages 列出存储（直接文件访问时设备路径即为单一存储，不做区分）
+func (dfa *DirectFileAccessor) ListStorages() []StorageInfo {
+	return nil
+}
+
 // GetFileStream 获取文件流
 func (dfa *DirectFileAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
 	dfa.log.Debug("直接文件访问器获取文件流: %s", filePath)
@@ -441,4 +506,14 @@ func (dfa *DirectFileAccessor) IsConnected() bool {
 // GetDeviceInfo 获取设备信息
 func (dfa *DirectFileAccessor) GetDeviceInfo() *DeviceInfo {
 	return dfa.device
+}
+
+// QuickStat 快速估算设备上的文件数和总大小
+func (dfa *DirectFileAccessor) QuickStat() (int, int64, error) {
+	return EstimateFiles(dfa)
+}
+
+// GetDeviceDetails 获取设备详细信息，直接文件访问只能提供设备基本信息
+func (dfa *DirectFileAccessor) GetDeviceDetails() (*DeviceDetails, error) {
+	return NewDeviceDetails(dfa.device, nil), nil
 }
\ No newline at end of file
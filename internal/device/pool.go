@@ -0,0 +1,213 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// DefaultIdleTimeout 连接池中无人使用的连接保留多久后关闭
+const DefaultIdleTimeout = 30 * time.Second
+
+// ConnectionPool 按设备实例ID（DeviceInfo.DeviceID）缓存已连接的访问器
+// 同一设备的多次获取复用同一个连接，避免反复初始化COM和会话；同型号的多台设备名称相同，各自使用独立的连接；
+// 引用计数归零后再空闲 idleTimeout 才真正关闭
+type ConnectionPool struct {
+	mutex       sync.Mutex
+	entries     map[string]*poolEntry
+	idleTimeout time.Duration
+	log         *logger.Logger
+
+	connect func(dev *DeviceInfo) (MTPInterface, error) // 建立新连接，测试时替换
+}
+
+// poolEntry 池中一个设备的连接
+type poolEntry struct {
+	mtp   MTPInterface
+	err   error         // 建立连接失败的原因
+	ready chan struct{} // 连接建立完成（成功或失败）后关闭
+	refs  int           // 尚未 release 的获取次数
+	idle  int           // 空闲计时的代数，重新获取后旧的计时失效
+}
+
+// NewConnectionPool 创建连接池，idleTimeout 不大于0时使用 DefaultIdleTimeout
+func NewConnectionPool(idleTimeout time.Duration, log *logger.Logger) *ConnectionPool {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	p := &ConnectionPool{
+		entries:     make(map[string]*poolEntry),
+		idleTimeout: idleTimeout,
+		log:         log,
+	}
+	p.connect = p.bridgeConnect
+	return p
+}
+
+var (
+	sharedPool     *ConnectionPool
+	sharedPoolOnce sync.Once
+)
+
+// SharedPool 返回进程内共享的连接池，首次调用时创建
+func SharedPool(log *logger.Logger) *ConnectionPool {
+	sharedPoolOnce.Do(func() {
+		sharedPool = NewConnectionPool(DefaultIdleTimeout, log)
+	})
+	return sharedPool
+}
+
+// AcquireDevice 获取设备的连接，已有连接时直接复用，并发获取同一设备只建立一次连接
+// 使用完后必须调用 release，release 可重复调用
+func (p *ConnectionPool) AcquireDevice(dev *DeviceInfo) (MTPInterface, func(), error) {
+	if dev == nil {
+		return nil, func() {}, fmt.Errorf("连接设备失败: 设备信息为空")
+	}
+	key := poolKey(dev)
+
+	p.mutex.Lock()
+	entry, ok := p.entries[key]
+	if ok && entry.done() && !entry.mtp.IsConnected() {
+		// 连接已失效（如设备被拔出），丢弃后重新连接
+		p.log.Debug("设备连接已失效，重新连接: %s", key)
+		delete(p.entries, key)
+		if entry.refs == 0 {
+			entry.mtp.Close()
+		}
+		ok = false
+	}
+	if !ok {
+		entry = &poolEntry{ready: make(chan struct{})}
+		p.entries[key] = entry
+		entry.refs++
+		p.mutex.Unlock()
+
+		entry.mtp, entry.err = p.connect(dev)
+		if entry.err != nil {
+			p.mutex.Lock()
+			if p.entries[key] == entry {
+				delete(p.entries, key)
+			}
+			p.mutex.Unlock()
+		}
+		close(entry.ready)
+	} else {
+		entry.refs++
+		entry.idle++
+		p.mutex.Unlock()
+		<-entry.ready
+	}
+
+	if entry.err != nil {
+		return nil, func() {}, fmt.Errorf("连接设备失败: %w", entry.err)
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { p.release(key, entry) })
+	}
+	return entry.mtp, release, nil
+}
+
+// Invalidate 丢弃设备在池中的连接 mtp，之后的获取建立新连接；
+// 仍在使用 mtp 的调用方不受影响，最后一次 release 时才关闭。池中已是其他连接时不做处理
+func (p *ConnectionPool) Invalidate(dev *DeviceInfo, mtp MTPInterface) {
+	key := poolKey(dev)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	entry, ok := p.entries[key]
+	if !ok || !entry.done() || entry.mtp != mtp {
+		return
+	}
+	delete(p.entries, key)
+	if entry.refs == 0 {
+		entry.mtp.Close()
+	}
+}
+
+// release 归还一次获取，引用计数归零后开始空闲计时
+func (p *ConnectionPool) release(key string, entry *poolEntry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	if p.entries[key] != entry {
+		// 已被丢弃或池已关闭，没有其他使用者时直接关闭
+		entry.mtp.Close()
+		return
+	}
+
+	entry.idle++
+	idle := entry.idle
+	time.AfterFunc(p.idleTimeout, func() { p.closeIdle(key, entry, idle) })
+}
+
+// closeIdle 空闲计时结束后关闭仍无人使用的连接
+func (p *ConnectionPool) closeIdle(key string, entry *poolEntry, idle int) {
+	p.mutex.Lock()
+	if p.entries[key] != entry || entry.refs > 0 || entry.idle != idle {
+		p.mutex.Unlock()
+		return
+	}
+	delete(p.entries, key)
+	p.mutex.Unlock()
+
+	p.log.Debug("关闭空闲的设备连接: %s", key)
+	if err := entry.mtp.Close(); err != nil {
+		p.log.Warn("关闭设备连接失败: %v", err)
+	}
+}
+
+// Close 关闭所有空闲连接，仍在使用的连接在最后一次 release 时关闭
+func (p *ConnectionPool) Close() error {
+	p.mutex.Lock()
+	entries := p.entries
+	p.entries = make(map[string]*poolEntry)
+	p.mutex.Unlock()
+
+	for _, entry := range entries {
+		<-entry.ready
+		p.mutex.Lock()
+		idle := entry.refs == 0 && entry.mtp != nil
+		p.mutex.Unlock()
+		if idle {
+			entry.mtp.Close()
+		}
+	}
+	return nil
+}
+
+// done 连接是否已建立完成，完成前不能读取 mtp
+func (e *poolEntry) done() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// bridgeConnect 通过设备桥接器连接已检测到的设备
+func (p *ConnectionPool) bridgeConnect(dev *DeviceInfo) (MTPInterface, error) {
+	bridge := NewDeviceBridge(p.log, nil)
+	defer bridge.Close()
+	return bridge.BridgeDevice(dev)
+}
+
+// poolKey 按设备实例ID区分设备，不区分大小写；没有实例ID时退回设备名称
+func poolKey(dev *DeviceInfo) string {
+	id := dev.DeviceID
+	if strings.TrimSpace(id) == "" {
+		id = dev.Name
+	}
+	return strings.ToUpper(strings.TrimSpace(id))
+}
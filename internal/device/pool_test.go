@@ -0,0 +1,260 @@
+//go:build windows
+
+package device
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// newTestPool 创建连接到虚拟设备的连接池，返回建立连接的次数
+func newTestPool(idleTimeout time.Duration) (*ConnectionPool, *int32) {
+	var connects int32
+	pool := NewConnectionPool(idleTimeout, logger.NewLogger(false))
+	pool.connect = func(dev *DeviceInfo) (MTPInterface, error) {
+		atomic.AddInt32(&connects, 1)
+		time.Sleep(10 * time.Millisecond) // 模拟初始化COM和会话的耗时
+		fake := NewFakeMTPAccessor(dev)
+		if err := fake.ConnectToDevice(dev.Name, dev.VID, dev.PID); err != nil {
+			return nil, err
+		}
+		return fake, nil
+	}
+	return pool, &connects
+}
+
+// testDevice 测试用的设备信息，同型号的设备名称相同、实例ID不同
+func testDevice(deviceID string) *DeviceInfo {
+	return &DeviceInfo{Name: "SR302", DeviceID: deviceID, VID: "2207", PID: "0011"}
+}
+
+// TestConnectionPool_ReuseAndIdleClose 测试并发获取同一设备复用同一连接，全部 release 后空闲超时才关闭
+func TestConnectionPool_ReuseAndIdleClose(t *testing.T) {
+	pool, connects := newTestPool(50 * time.Millisecond)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	mtps := make([]MTPInterface, workers)
+	releases := make([]func(), workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mtp, release, err := pool.AcquireDevice(testDevice("USB\\VID_2207&PID_0011\\A001"))
+			if err != nil {
+				t.Errorf("获取设备连接失败: %v", err)
+				return
+			}
+			mtps[i], releases[i] = mtp, release
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if n := atomic.LoadInt32(connects); n != 1 {
+		t.Fatalf("建立连接 %d 次，期望 1 次", n)
+	}
+	for i := 1; i < workers; i++ {
+		if mtps[i] != mtps[0] {
+			t.Fatal("并发获取同一设备应复用同一连接")
+		}
+	}
+
+	// 设备标识不区分大小写
+	mtp, release, err := pool.AcquireDevice(testDevice("usb\\vid_2207&pid_0011\\a001"))
+	if err != nil || mtp != mtps[0] {
+		t.Fatalf("大小写不同的设备标识应复用同一连接: %v", err)
+	}
+	release()
+	release() // 重复 release 不影响引用计数
+
+	for _, release := range releases[:workers-1] {
+		release()
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !mtps[0].IsConnected() {
+		t.Fatal("仍有使用者时不应关闭连接")
+	}
+
+	releases[workers-1]()
+	if !mtps[0].IsConnected() {
+		t.Fatal("引用计数归零后应等空闲超时再关闭")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mtps[0].IsConnected() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if mtps[0].IsConnected() {
+		t.Fatal("空闲超时后应关闭连接")
+	}
+
+	// 关闭后再次获取会重新连接
+	mtp, release, err = pool.AcquireDevice(testDevice("USB\\VID_2207&PID_0011\\A001"))
+	if err != nil {
+		t.Fatalf("重新获取设备连接失败: %v", err)
+	}
+	defer release()
+	if mtp == mtps[0] || atomic.LoadInt32(connects) != 2 {
+		t.Error("空闲关闭后应建立新连接")
+	}
+}
+
+// TestConnectionPool_ReacquireCancelsIdleClose 测试空闲期间重新获取后不会被关闭
+func TestConnectionPool_ReacquireCancelsIdleClose(t *testing.T) {
+	pool, connects := newTestPool(30 * time.Millisecond)
+
+	first, release, err := pool.AcquireDevice(testDevice("USB\\VID_2207&PID_0011\\A001"))
+	if err != nil {
+		t.Fatalf("获取设备连接失败: %v", err)
+	}
+	release()
+
+	second, release, err := pool.AcquireDevice(testDevice("USB\\VID_2207&PID_0011\\A001"))
+	if err != nil {
+		t.Fatalf("获取设备连接失败: %v", err)
+	}
+	defer release()
+	time.Sleep(100 * time.Millisecond)
+
+	if first != second || atomic.LoadInt32(connects) != 1 {
+		t.Error("空闲超时前重新获取应复用原连接")
+	}
+	if !second.IsConnected() {
+		t.Error("重新获取后连接不应被空闲计时关闭")
+	}
+}
+
+// TestConnectionPool_Failures 测试连接失败不缓存、失效连接重新建立
+func TestConnectionPool_Failures(t *testing.T) {
+	pool, connects := newTestPool(time.Minute)
+	connect := pool.connect
+	pool.connect = func(dev *DeviceInfo) (MTPInterface, error) {
+		if dev.DeviceID == "missing" {
+			atomic.AddInt32(connects, 1)
+			return nil, NewMTPError(ERROR_DEVICE_NOT_FOUND, "未找到设备", nil)
+		}
+		return connect(dev)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, release, err := pool.AcquireDevice(&DeviceInfo{Name: "missing", DeviceID: "missing"})
+		var mtpErr *MTPError
+		if !errors.As(err, &mtpErr) || mtpErr.Code != ERROR_DEVICE_NOT_FOUND {
+			t.Fatalf("期望返回设备未找到错误，实际 %v", err)
+		}
+		release()
+	}
+	if n := atomic.LoadInt32(connects); n != 2 {
+		t.Errorf("连接失败不应被缓存，建立连接 %d 次，期望 2 次", n)
+	}
+
+	first, release, err := pool.AcquireDevice(testDevice("USB\\VID_2207&PID_0011\\A001"))
+	if err != nil {
+		t.Fatalf("获取设备连接失败: %v", err)
+	}
+	release()
+	first.Close() // 模拟设备被拔出
+
+	second, release, err := pool.AcquireDevice(testDevice("USB\\VID_2207&PID_0011\\A001"))
+	if err != nil {
+		t.Fatalf("获取设备连接失败: %v", err)
+	}
+	defer release()
+	if second == first || !second.IsConnected() {
+		t.Error("连接失效后应重新建立连接")
+	}
+
+	pool.Close()
+	if !second.IsConnected() {
+		t.Error("关闭连接池时不应关闭仍在使用的连接")
+	}
+	release()
+	if second.IsConnected() {
+		t.Error("连接池关闭后最后一次 release 应关闭连接")
+	}
+}
+
+// TestConnectionPool_SameModelDevices 测试同型号（名称相同）的两台设备按实例ID各自使用独立的连接
+func TestConnectionPool_SameModelDevices(t *testing.T) {
+	pool, connects := newTestPool(time.Minute)
+	defer pool.Close()
+
+	deviceA := testDevice("USB\\VID_2207&PID_0011\\A001")
+	deviceB := testDevice("USB\\VID_2207&PID_0011\\B002")
+
+	mtpA, releaseA, err := pool.AcquireDevice(deviceA)
+	if err != nil {
+		t.Fatalf("获取设备A连接失败: %v", err)
+	}
+	defer releaseA()
+	mtpB, releaseB, err := pool.AcquireDevice(deviceB)
+	if err != nil {
+		t.Fatalf("获取设备B连接失败: %v", err)
+	}
+	defer releaseB()
+
+	if mtpA == mtpB || atomic.LoadInt32(connects) != 2 {
+		t.Fatal("名称相同、实例ID不同的设备不应共用连接")
+	}
+	if mtpA.GetDeviceInfo().DeviceID != deviceA.DeviceID || mtpB.GetDeviceInfo().DeviceID != deviceB.DeviceID {
+		t.Errorf("连接对应的设备不符: %s, %s", mtpA.GetDeviceInfo().DeviceID, mtpB.GetDeviceInfo().DeviceID)
+	}
+
+	if _, _, err := pool.AcquireDevice(nil); err == nil {
+		t.Error("设备信息为空时应返回错误")
+	}
+}
+
+// TestConnectionPool_Invalidate 测试丢弃连接后重新获取得到新连接，旧连接在最后一次 release 时才关闭
+func TestConnectionPool_Invalidate(t *testing.T) {
+	pool, connects := newTestPool(time.Minute)
+	defer pool.Close()
+	dev := testDevice("USB\\VID_2207&PID_0011\\A001")
+
+	old, releaseOld, err := pool.AcquireDevice(dev)
+	if err != nil {
+		t.Fatalf("获取设备连接失败: %v", err)
+	}
+	other, releaseOther, err := pool.AcquireDevice(dev)
+	if err != nil || other != old {
+		t.Fatalf("同一设备应复用连接: %v", err)
+	}
+
+	pool.Invalidate(dev, old)
+	fresh, releaseFresh, err := pool.AcquireDevice(dev)
+	if err != nil {
+		t.Fatalf("重新获取设备连接失败: %v", err)
+	}
+	defer releaseFresh()
+	if fresh == old || atomic.LoadInt32(connects) != 2 {
+		t.Fatal("丢弃后应建立新连接")
+	}
+
+	// 池中已是新连接时，再次丢弃旧连接不影响新连接
+	pool.Invalidate(dev, old)
+	if again, release, err := pool.AcquireDevice(dev); err != nil || again != fresh {
+		t.Fatalf("丢弃旧连接不应影响新连接: %v", err)
+	} else {
+		release()
+	}
+
+	releaseOld()
+	if !old.IsConnected() {
+		t.Fatal("仍有使用者时不应关闭旧连接")
+	}
+	releaseOther()
+	if old.IsConnected() {
+		t.Error("旧连接最后一次 release 后应关闭")
+	}
+	if !fresh.IsConnected() {
+		t.Error("新连接不应被关闭")
+	}
+}
@@ -0,0 +1,230 @@
+//go:build windows
+
+package device
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/psexec"
+)
+
+// batchEndMarker 每条命令执行完成后输出的结束标记
+const batchEndMarker = "__RC_BATCH_END__"
+
+// batchStagingPrefix 批量复制暂存目录的名称前缀，暂存目录位于目标目录下，复制完成后整体删除
+const batchStagingPrefix = ".rc_batch_"
+
+// batchCopyFunction 会话启动时定义的复制函数（单行，便于通过stdin发送）
+// 将设备文件复制到本批次新建的空暂存目录，文件大小达到期望大小后输出 OK|大小（期望为0时文件出现即完成），
+// 期望大小未知（负数）时等待大小稳定，失败输出 ERR|原因
+const batchCopyFunction = `function Copy-RcItem($srcDir, $name, $dstDir, $expected) { try { $shell = New-Object -ComObject Shell.Application; $src = $shell.Namespace($srcDir); if (-not $src) { Write-Output "ERR|源目录不存在"; return }; $item = $src.ParseName($name); if (-not $item) { Write-Output "ERR|源文件不存在"; return }; $dst = $shell.Namespace($dstDir); if (-not $dst) { Write-Output "ERR|目标目录不存在"; return }; $target = Join-Path $dstDir $name; $dst.CopyHere($item, 0x14); $last = -1; for ($i = 0; $i -lt 1200; $i++) { if (Test-Path -LiteralPath $target) { $size = (Get-Item -LiteralPath $target).Length; if ($expected -ge 0 -and $size -eq $expected) { Write-Output "OK|$size"; return }; if ($expected -lt 0 -and $size -gt 0 -and $size -eq $last) { Write-Output "OK|$size"; return }; $last = $size }; Start-Sleep -Milliseconds 250 }; Write-Output "ERR|等待复制完成超时" } catch { Write-Output "ERR|$($_.Exception.Message)" } }`
+
+// CopyItem 批量复制中的一项：设备文件路径、本地目标路径与枚举得到的文件大小
+type CopyItem struct {
+	SourcePath string
+	TargetPath string
+	Size       int64 // 期望的文件大小，复制结果大小一致才算完成；负数表示未知
+}
+
+// CopyItemResult 批量复制单项的结果
+type CopyItemResult struct {
+	Item  CopyItem
+	Size  int64
+	Error error
+}
+
+// BatchCopier 支持批量复制的MTP访问器（可选能力）
+type BatchCopier interface {
+	// CopyFilesToLocal 在同一个会话中把多个设备文件复制到本地，结果顺序与输入一致
+	CopyFilesToLocal(items []CopyItem) []CopyItemResult
+}
+
+// psSession 长驻的PowerShell会话，逐条执行单行命令
+type psSession interface {
+	// Exec 执行一条命令并返回其输出
+	Exec(command string) (string, error)
+	// Close 结束会话
+	Close() error
+}
+
+// CopyFilesToLocal 使用单个长驻PowerShell会话批量复制文件，避免每个文件启动一个进程
+// 文件先复制到目标目录下本批次的暂存目录，大小校验通过后才改名为目标文件，暂存目录在结束时删除
+func (ps *PowerShellMTPAccessor) CopyFilesToLocal(items []CopyItem) []CopyItemResult {
+	results := make([]CopyItemResult, len(items))
+	for i, item := range items {
+		results[i].Item = item
+	}
+	if len(items) == 0 {
+		return results
+	}
+
+	session, err := ps.newSession()
+	if err != nil {
+		for i := range results {
+			results[i].Error = fmt.Errorf("启动PowerShell会话失败: %w", err)
+		}
+		return results
+	}
+	defer session.Close()
+
+	if _, err := session.Exec(batchCopyFunction); err != nil {
+		for i := range results {
+			results[i].Error = fmt.Errorf("初始化PowerShell会话失败: %w", err)
+		}
+		return results
+	}
+
+	staging := make(map[string]string) // 目标目录 -> 本批次的暂存目录
+	defer func() {
+		for _, dir := range staging {
+			if err := os.RemoveAll(dir); err != nil {
+				ps.log.Warn("删除批量复制暂存目录失败: %s, %v", dir, err)
+			}
+		}
+	}()
+
+	ps.log.Debug("PowerShell批量复制: %d 个文件", len(items))
+	for i, item := range items {
+		results[i].Size, results[i].Error = ps.copyItemInSession(session, item, staging)
+	}
+
+	return results
+}
+
+// copyItemInSession 在会话中把单个文件复制到暂存目录，校验大小后改名为目标文件
+func (ps *PowerShellMTPAccessor) copyItemInSession(session psSession, item CopyItem, staging map[string]string) (int64, error) {
+	name := filepath.Base(item.SourcePath)
+	targetDir := filepath.Dir(item.TargetPath)
+	stagingDir, ok := staging[targetDir]
+	if !ok {
+		if err := os.MkdirAll(targetDir, 0755); err != nil {
+			return 0, fmt.Errorf("创建目标目录失败: %w", err)
+		}
+		dir, err := os.MkdirTemp(targetDir, batchStagingPrefix)
+		if err != nil {
+			return 0, fmt.Errorf("创建暂存目录失败: %w", err)
+		}
+		staging[targetDir] = dir
+		stagingDir = dir
+	}
+
+	command := fmt.Sprintf("Copy-RcItem %s %s %s %d",
+		quotePowerShell(filepath.Dir(item.SourcePath)), quotePowerShell(name), quotePowerShell(stagingDir), item.Size)
+	output, err := session.Exec(command)
+	if err != nil {
+		return 0, WrapPowerShellError("PowerShell批量复制失败", output, err)
+	}
+
+	size, err := parseBatchCopyOutput(output)
+	if err != nil {
+		return 0, err
+	}
+
+	copiedPath := filepath.Join(stagingDir, name)
+	stat, err := os.Stat(copiedPath)
+	if err != nil {
+		return 0, fmt.Errorf("复制结果不存在: %w", err)
+	}
+	if stat.Size() != size || (item.Size >= 0 && stat.Size() != item.Size) {
+		os.Remove(copiedPath)
+		return 0, fmt.Errorf("复制结果大小不一致: %d 字节，期望 %d 字节", stat.Size(), item.Size)
+	}
+	if err := os.Rename(copiedPath, item.TargetPath); err != nil {
+		os.Remove(copiedPath)
+		return 0, fmt.Errorf("移动复制结果到目标失败: %w", err)
+	}
+
+	ps.log.Debug("PowerShell批量复制完成: %s -> %s (%d 字节)", item.SourcePath, item.TargetPath, size)
+	return size, nil
+}
+
+// parseBatchCopyOutput 解析 Copy-RcItem 的输出
+func parseBatchCopyOutput(output string) (int64, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "OK|"):
+			size, err := strconv.ParseInt(strings.TrimPrefix(line, "OK|"), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("解析复制大小失败: %s", line)
+			}
+			return size, nil
+		case strings.HasPrefix(line, "ERR|"):
+			return 0, fmt.Errorf("复制失败: %s", strings.TrimPrefix(line, "ERR|"))
+		}
+	}
+	return 0, fmt.Errorf("无法识别的复制输出: %s", strings.TrimSpace(output))
+}
+
+// quotePowerShell 将字符串转为PowerShell单引号字面量
+func quotePowerShell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// stdinSession 通过stdin持续发送命令的PowerShell进程
+type stdinSession struct {
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	wait   func() error
+}
+
+// startStdinSession 启动从stdin读取命令的PowerShell进程
+func startStdinSession() (psSession, error) {
+	cmd := psexec.Command("-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-Command", "-")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("创建stdin管道失败: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("创建stdout管道失败: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动PowerShell失败: %w", err)
+	}
+
+	session := &stdinSession{
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		wait:   cmd.Wait,
+	}
+
+	// 统一输出编码，保证中文文件名和提示可正确解析
+	if _, err := session.Exec("[Console]::OutputEncoding = [System.Text.Encoding]::UTF8"); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+// Exec 发送一条命令，读取输出直到结束标记
+func (s *stdinSession) Exec(command string) (string, error) {
+	if _, err := fmt.Fprintf(s.stdin, "%s; Write-Output '%s'\n", command, batchEndMarker); err != nil {
+		return "", fmt.Errorf("发送命令失败: %w", err)
+	}
+
+	var output strings.Builder
+	for {
+		raw, err := s.stdout.ReadBytes('\n')
+		line := psexec.DecodeOutput(raw)
+		if strings.TrimSpace(line) == batchEndMarker {
+			return output.String(), nil
+		}
+		output.WriteString(line)
+		if err != nil {
+			return output.String(), fmt.Errorf("读取命令输出失败: %w", err)
+		}
+	}
+}
+
+// Close 关闭stdin并等待进程退出
+func (s *stdinSession) Close() error {
+	s.stdin.Close()
+	return s.wait()
+}
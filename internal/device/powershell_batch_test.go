@@ -0,0 +1,204 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// mockSession 记录执行命令的模拟PowerShell会话，复制命令会在传入的目录生成文件
+type mockSession struct {
+	commands []string
+	dirs     []string // 每次复制写入的目录
+	closed   bool
+	fail     map[string]bool   // 文件名 -> 是否返回复制失败
+	content  map[string]string // 文件名 -> 设备上的内容，未设置时按文件名生成
+}
+
+func (ms *mockSession) Exec(command string) (string, error) {
+	ms.commands = append(ms.commands, command)
+	if !strings.HasPrefix(command, "Copy-RcItem ") {
+		return "", nil
+	}
+
+	args := strings.Split(strings.TrimPrefix(command, "Copy-RcItem "), "' '")
+	name := strings.ReplaceAll(args[1], "''", "'")
+	dstDir := args[2][:strings.LastIndex(args[2], "' ")]
+	ms.dirs = append(ms.dirs, dstDir)
+	short := name[strings.LastIndex(name, "\\")+1:]
+	if ms.fail[short] {
+		return "ERR|源文件不存在\n", nil
+	}
+
+	data := []byte("opus data of " + short)
+	if content, ok := ms.content[short]; ok {
+		data = []byte(content)
+	}
+	if err := os.WriteFile(filepath.Join(dstDir, name), data, 0644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("OK|%d\n", len(data)), nil
+}
+
+func (ms *mockSession) Close() error {
+	ms.closed = true
+	return nil
+}
+
+// TestPowerShellMTPAccessor_CopyFilesToLocal 测试批量复制N个文件只创建一个PowerShell会话
+func TestPowerShellMTPAccessor_CopyFilesToLocal(t *testing.T) {
+	tempDir := t.TempDir()
+	session := &mockSession{fail: map[string]bool{"missing.opus": true}}
+	sessions := 0
+
+	accessor := NewPowerShellMTPAccessor(logger.NewLogger(false))
+	accessor.newSession = func() (psSession, error) {
+		sessions++
+		return session, nil
+	}
+
+	size := func(name string) int64 { return int64(len("opus data of " + name)) }
+	items := []CopyItem{
+		{SourcePath: "SR302\\录音笔文件\\a.opus", TargetPath: filepath.Join(tempDir, "2024", "a.opus"), Size: size("a.opus")},
+		{SourcePath: "SR302\\录音笔文件\\b'c.opus", TargetPath: filepath.Join(tempDir, "2024", "b'c.opus"), Size: size("b'c.opus")},
+		{SourcePath: "SR302\\录音笔文件\\missing.opus", TargetPath: filepath.Join(tempDir, "2024", "missing.opus"), Size: size("missing.opus")},
+		{SourcePath: "SR302\\录音笔文件\\d.opus", TargetPath: filepath.Join(tempDir, "2024", "renamed.opus"), Size: size("d.opus")},
+	}
+
+	results := accessor.CopyFilesToLocal(items)
+
+	if sessions != 1 {
+		t.Errorf("批量复制应只创建一个会话，实际 %d 个", sessions)
+	}
+	if !session.closed {
+		t.Error("批量复制结束后应关闭会话")
+	}
+	if len(results) != len(items) {
+		t.Fatalf("结果数量错误: %d", len(results))
+	}
+
+	for i, result := range results {
+		if result.Item != items[i] {
+			t.Errorf("结果顺序应与输入一致: %+v", result.Item)
+		}
+		if strings.Contains(result.Item.SourcePath, "missing") {
+			if result.Error == nil {
+				t.Error("源文件不存在时应返回错误")
+			}
+			continue
+		}
+		if result.Error != nil {
+			t.Errorf("复制失败: %s, %v", result.Item.SourcePath, result.Error)
+			continue
+		}
+		stat, err := os.Stat(result.Item.TargetPath)
+		if err != nil || stat.Size() != result.Size {
+			t.Errorf("目标文件不正确: %s, %v", result.Item.TargetPath, err)
+		}
+	}
+}
+
+// TestPowerShellMTPAccessor_CopyFilesToLocal_Staging 测试批量复制写入暂存目录：
+// 目标目录中同名的无关文件不被覆盖，大小与期望不符的结果不移入目标，0字节文件正常完成，结束后暂存目录被删除
+func TestPowerShellMTPAccessor_CopyFilesToLocal_Staging(t *testing.T) {
+	targetDir := filepath.Join(t.TempDir(), "2024")
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 目标目录中已有与源文件同名的无关文件，以及上次留下的旧目标
+	unrelated := filepath.Join(targetDir, "d.opus")
+	if err := os.WriteFile(unrelated, []byte("unrelated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(targetDir, "short.opus")
+	if err := os.WriteFile(stale, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session := &mockSession{content: map[string]string{"empty.opus": "", "short.opus": "cut"}}
+	accessor := NewPowerShellMTPAccessor(logger.NewLogger(false))
+	accessor.newSession = func() (psSession, error) { return session, nil }
+
+	items := []CopyItem{
+		{SourcePath: "SR302\\录音笔文件\\d.opus", TargetPath: filepath.Join(targetDir, "renamed.opus"), Size: int64(len("opus data of d.opus"))},
+		{SourcePath: "SR302\\录音笔文件\\empty.opus", TargetPath: filepath.Join(targetDir, "empty.opus"), Size: 0},
+		{SourcePath: "SR302\\录音笔文件\\short.opus", TargetPath: stale, Size: 1024},
+	}
+	results := accessor.CopyFilesToLocal(items)
+
+	for _, dir := range session.dirs {
+		if filepath.Dir(dir) != targetDir || !strings.HasPrefix(filepath.Base(dir), batchStagingPrefix) {
+			t.Errorf("应复制到目标目录下的暂存目录，实际 %s", dir)
+		}
+	}
+	if results[0].Error != nil || results[1].Error != nil {
+		t.Fatalf("复制失败: %v, %v", results[0].Error, results[1].Error)
+	}
+	if data, _ := os.ReadFile(unrelated); string(data) != "unrelated" {
+		t.Errorf("目标目录中同名的无关文件不应被覆盖: %q", data)
+	}
+	if stat, err := os.Stat(items[1].TargetPath); err != nil || stat.Size() != 0 {
+		t.Errorf("0字节文件应复制完成: %v", err)
+	}
+	if results[2].Error == nil {
+		t.Error("大小与期望不符时应返回错误")
+	}
+	if data, _ := os.ReadFile(stale); string(data) != "stale" {
+		t.Errorf("校验失败的结果不应移入目标: %q", data)
+	}
+
+	entries, err := os.ReadDir(targetDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), batchStagingPrefix) {
+			t.Errorf("批量复制结束后应删除暂存目录: %s", entry.Name())
+		}
+	}
+}
+
+// TestPowerShellMTPAccessor_CopyFilesToLocal_SessionFailed 测试会话启动失败时所有项返回错误
+func TestPowerShellMTPAccessor_CopyFilesToLocal_SessionFailed(t *testing.T) {
+	accessor := NewPowerShellMTPAccessor(logger.NewLogger(false))
+	accessor.newSession = func() (psSession, error) {
+		return nil, fmt.Errorf("powershell not found")
+	}
+
+	results := accessor.CopyFilesToLocal([]CopyItem{{SourcePath: "a.opus"}, {SourcePath: "b.opus"}})
+	for _, result := range results {
+		if result.Error == nil {
+			t.Errorf("会话启动失败时应返回错误: %s", result.Item.SourcePath)
+		}
+	}
+}
+
+// TestParseBatchCopyOutput 测试解析批量复制输出
+func TestParseBatchCopyOutput(t *testing.T) {
+	testCases := []struct {
+		name    string
+		output  string
+		size    int64
+		wantErr bool
+	}{
+		{"成功", "OK|1024\r\n", 1024, false},
+		{"带其他输出", "warning\nOK|2048\n", 2048, false},
+		{"失败", "ERR|源文件不存在\n", 0, true},
+		{"无法识别", "something\n", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := parseBatchCopyOutput(tc.output)
+			if (err != nil) != tc.wantErr || size != tc.size {
+				t.Errorf("期望 %d/%v，实际 %d/%v", tc.size, tc.wantErr, size, err)
+			}
+		})
+	}
+}
@@ -173,7 +173,7 @@ func (pe *PowerShellEnhanced) checkPowerShellPolicy() error {
 // ListFiles 列出文件
 func (pe *PowerShellEnhanced) ListFiles(basePath string) ([]*FileInfo, error) {
 	if !pe.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	pe.log.Debug("增强PowerShell列出文件: %s", basePath)
@@ -213,6 +213,21 @@ func (pe *PowerShellEnhanced) ListFiles(basePath string) ([]*FileInfo, error) {
 	return nil, fmt.Errorf("所有文件列表方法都失败了")
 }
 
+// ListStorages 列出设备根下的存储节点
+func (pe *PowerShellEnhanced) ListStorages() []StorageInfo {
+	if !pe.connected {
+		return nil
+	}
+
+	result, err := pe.executor.ExecuteScript(buildListStoragesScript(pe.device.Name))
+	if err != nil {
+		pe.log.Debug("增强PowerShell枚举设备存储失败: %v", err)
+		return nil
+	}
+
+	return parseStorageOutput(result.Output)
+}
+
 // buildPortableDeviceScript 构建便携式设备脚本
 func (pe *PowerShellEnhanced) buildPortableDeviceScript(basePath string) string {
 	// 简化脚本，避免递归遍历导致卡死
@@ -331,6 +346,28 @@ func (pe *PowerShellEnhanced) GetDeviceInfo() *DeviceInfo {
 	return pe.device
 }
 
+// QuickStat 快速估算设备上的文件数和总大小
+func (pe *PowerShellEnhanced) QuickStat() (int, int64, error) {
+	return EstimateFiles(pe)
+}
+
+// GetDeviceDetails 获取设备详细信息
+func (pe *PowerShellEnhanced) GetDeviceDetails() (*DeviceDetails, error) {
+	if !pe.connected {
+		return nil, ErrDeviceNotConnected
+	}
+
+	details := NewDeviceDetails(pe.device, pe.ListStorages())
+	result, err := pe.executor.ExecuteScript(buildDeviceDetailsScript(pe.device.Name))
+	if err != nil {
+		pe.log.Debug("增强PowerShell读取设备详细信息失败: %v", err)
+		return details, nil
+	}
+
+	parseDeviceDetailsOutput(result.Output, details)
+	return details, nil
+}
+
 // GetLastError 获取最后的错误
 func (pe *PowerShellEnhanced) GetLastError() error {
 	return pe.lastError
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
 )
 
 // PowerShellConfig PowerShell配置 (临时定义，应该使用config包中的定义)
@@ -127,7 +128,7 @@ func (pm *PowerShellManager) executeWithVersion(version *PowerShellVersion, comm
 		}
 
 		// 每次重试时重新创建cmd对象以避免stdout重复设置
-		cmd := exec.Command(version.Path, allArgs...)
+		cmd := exec.Command(version.Path, psexec.EnsureUTF8Args(allArgs)...)
 
 		// 设置超时（每次重试都需要新的超时控制）
 		var timer *time.Timer
@@ -146,7 +147,7 @@ func (pm *PowerShellManager) executeWithVersion(version *PowerShellVersion, comm
 			timer.Stop()
 		}
 		result = &ExecutionResult{
-			Output:   string(output),
+			Output:   psexec.DecodeOutput(output),
 			Error:    err,
 			Version:  version.Version,
 			ExePath:  version.Path,
@@ -3,25 +3,29 @@
 package device
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
 )
 
 // PowerShellMTPAccessor 使用PowerShell访问MTP设备
 type PowerShellMTPAccessor struct {
-	log *logger.Logger
+	log        *logger.Logger
+	newSession func() (psSession, error) // 启动批量复制使用的长驻会话
 }
 
 // NewPowerShellMTPAccessor 创建PowerShell MTP访问器
 func NewPowerShellMTPAccessor(log *logger.Logger) *PowerShellMTPAccessor {
 	return &PowerShellMTPAccessor{
-		log: log,
+		log:        log,
+		newSession: startStdinSession,
 	}
 }
 
@@ -139,15 +143,15 @@ if ($folder) {
 }
 `, devicePath, basePath, basePath)
 
-	cmd := exec.Command("powershell", "-Command", psScript)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-Command", psScript)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		ps.log.Error("PowerShell命令执行失败: %v", err)
 		return nil, fmt.Errorf("执行PowerShell失败: %w", err)
 	}
 
 	// 解析输出
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	var files []*MTPFileEntry
 
 	for _, line := range lines {
@@ -196,10 +200,15 @@ if ($folder) {
 
 // OpenFileStream 打开MTP设备文件流
 func (ps *PowerShellMTPAccessor) OpenFileStream(filePath string) (*MTPFileStream, error) {
+	return ps.OpenFileStreamContext(context.Background(), filePath)
+}
+
+// OpenFileStreamContext 与 OpenFileStream 相同，ctx 结束时结束正在复制的PowerShell进程并删除不完整的临时文件
+func (ps *PowerShellMTPAccessor) OpenFileStreamContext(ctx context.Context, filePath string) (*MTPFileStream, error) {
 	ps.log.Debug("打开MTP文件流: %s", filePath)
 
 	// 创建PowerShell脚本来复制文件到临时位置
-	tempFile := fmt.Sprintf("%s\\mtp_temp_%d", os.TempDir(), time.Now().UnixNano())
+	tempFile := utils.TempFilePath(fmt.Sprintf("mtp_%d", time.Now().UnixNano()))
 
 	psScript := fmt.Sprintf(`
 $shell = New-Object -ComObject Shell.Application
@@ -213,13 +222,18 @@ if ($file) {
 }
 `, filepath.Dir(filePath), filepath.Base(filePath), tempFile)
 
-	cmd := exec.Command("powershell", "-Command", psScript)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.CommandContext(ctx, "-Command", psScript)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("PowerShell复制失败: %w", err)
+		// 复制中途失败或被取消可能留下不完整的临时文件
+		os.Remove(tempFile)
+		if cause := context.Cause(ctx); cause != nil {
+			return nil, cause
+		}
+		return nil, WrapPowerShellError("PowerShell复制失败", output, err)
 	}
 
-	if strings.Contains(string(output), "SUCCESS") {
+	if strings.Contains(output, "SUCCESS") {
 		// 打开临时文件
 		file, err := os.Open(tempFile)
 		if err != nil {
@@ -233,7 +247,67 @@ if ($file) {
 		}, nil
 	}
 
-	return nil, fmt.Errorf("PowerShell复制文件失败")
+	os.Remove(tempFile)
+	return nil, WrapPowerShellError("PowerShell复制文件失败", output, nil)
+}
+
+// Exists 判断设备上的文件是否存在，实现 FileExister
+// 源目录无法访问（如设备已断开）时返回错误，不当作文件已删除
+func (ps *PowerShellMTPAccessor) Exists(filePath string) (bool, error) {
+	psScript := fmt.Sprintf(`
+$shell = New-Object -ComObject Shell.Application
+$folder = $shell.Namespace(%s)
+if (-not $folder) {
+    Write-Output "NOFOLDER"
+} elseif ($folder.ParseName(%s)) {
+    Write-Output "EXISTS"
+} else {
+    Write-Output "MISSING"
+}
+`, quotePowerShell(filepath.Dir(filePath)), quotePowerShell(filepath.Base(filePath)))
+
+	output, err := psexec.CombinedOutput(psexec.Command("-Command", psScript))
+	if err != nil {
+		return false, WrapPowerShellError("检查文件是否存在失败", output, err)
+	}
+	switch {
+	case strings.Contains(output, "EXISTS"):
+		return true, nil
+	case strings.Contains(output, "MISSING"):
+		return false, nil
+	default:
+		return false, fmt.Errorf("无法访问源目录: %s", filepath.Dir(filePath))
+	}
+}
+
+// ListStorages 遍历设备根下的存储节点（内部存储、SD卡等）
+func (ps *PowerShellMTPAccessor) ListStorages(deviceName string) []StorageInfo {
+	ps.log.Debug("使用PowerShell枚举设备存储: %s", deviceName)
+
+	cmd := psexec.Command("-Command", buildListStoragesScript(deviceName))
+	output, err := psexec.CombinedOutput(cmd)
+	if err != nil {
+		ps.log.Debug("枚举设备存储失败: %v", err)
+		return nil
+	}
+
+	storages := parseStorageOutput(output)
+	ps.log.Debug("找到 %d 个存储", len(storages))
+	return storages
+}
+
+// ReadDeviceDetails 使用PowerShell读取设备属性并合并到 details，读取失败时保持原值
+func (ps *PowerShellMTPAccessor) ReadDeviceDetails(deviceName string, details *DeviceDetails) {
+	ps.log.Debug("使用PowerShell读取设备详细信息: %s", deviceName)
+
+	cmd := psexec.Command("-Command", buildDeviceDetailsScript(deviceName))
+	output, err := psexec.CombinedOutput(cmd)
+	if err != nil {
+		ps.log.Debug("读取设备详细信息失败: %v", err)
+		return
+	}
+
+	parseDeviceDetailsOutput(output, details)
 }
 
 // Close 关闭PowerShell访问器
@@ -247,7 +321,7 @@ func (ps *PowerShellMTPAccessor) Close() error {
 // getPortableDevicePath 通过便携式设备命名空间获取路径
 func (ps *PowerShellMTPAccessor) getPortableDevicePath(deviceName string) string {
 	// 便携式设备的命名空间常量是17
-	cmd := exec.Command("powershell", "-Command", fmt.Sprintf(`
+	cmd := psexec.Command("-Command", fmt.Sprintf(`
 $shell = New-Object -ComObject Shell.Application
 $portable = $shell.NameSpace(17)
 if ($portable) {
@@ -261,13 +335,13 @@ if ($portable) {
 }
 `, deviceName))
 
-	output, err := cmd.Output()
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		ps.log.Debug("便携式设备查询失败: %v", err)
 		return ""
 	}
 
-	path := strings.TrimSpace(string(output))
+	path := strings.TrimSpace(output)
 	if path != "" && ps.testPathAccessibility(path) {
 		return path
 	}
@@ -277,7 +351,7 @@ if ($portable) {
 
 // getDesktopDevicePath 通过桌面设备列表获取路径
 func (ps *PowerShellMTPAccessor) getDesktopDevicePath(deviceName string) string {
-	cmd := exec.Command("powershell", "-Command", fmt.Sprintf(`
+	cmd := psexec.Command("-Command", fmt.Sprintf(`
 $shell = New-Object -ComObject Shell.Application
 $desktop = $shell.NameSpace(0)
 $items = $desktop.Items()
@@ -289,13 +363,13 @@ foreach($item in $items) {
 }
 `, deviceName))
 
-	output, err := cmd.Output()
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		ps.log.Debug("桌面设备查询失败: %v", err)
 		return ""
 	}
 
-	path := strings.TrimSpace(string(output))
+	path := strings.TrimSpace(output)
 	if path != "" && ps.testPathAccessibility(path) {
 		return path
 	}
@@ -305,7 +379,7 @@ foreach($item in $items) {
 
 // getWMIEnhancedPath 通过WMI增强查询获取路径
 func (ps *PowerShellMTPAccessor) getWMIEnhancedPath(deviceName string) string {
-	cmd := exec.Command("powershell", "-Command", fmt.Sprintf(`
+	cmd := psexec.Command("-Command", fmt.Sprintf(`
 Get-WmiObject Win32_PnPEntity |
 Where-Object { $_.DeviceID -like "*USB*" -and ($_.Name -like "*%s*" -or $_.FriendlyName -like "*%s*")} |
 Select-Object -First 1 |
@@ -334,13 +408,13 @@ ForEach-Object {
 }
 `, deviceName, deviceName))
 
-	output, err := cmd.Output()
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		ps.log.Debug("WMI增强查询失败: %v", err)
 		return ""
 	}
 
-	result := strings.TrimSpace(string(output))
+	result := strings.TrimSpace(output)
 	if result != "" && result != "NOT_FOUND" && result != "ERROR" {
 		if ps.testPathAccessibility(result) {
 			return result
@@ -352,13 +426,13 @@ ForEach-Object {
 
 // testPathAccessibility 测试路径是否可访问
 func (ps *PowerShellMTPAccessor) testPathAccessibility(path string) bool {
-	cmd := exec.Command("powershell", "-Command", fmt.Sprintf("Test-Path '%s'", path))
-	output, err := cmd.Output()
+	cmd := psexec.Command("-Command", fmt.Sprintf("Test-Path '%s'", path))
+	output, err := psexec.Output(cmd)
 	if err != nil {
 		return false
 	}
 
-	return strings.TrimSpace(string(output)) == "True"
+	return strings.TrimSpace(output) == "True"
 }
 
 // parseInt64 解析int64
@@ -44,7 +44,7 @@ func (wrapper *PowerShellMTPWrapper) ConnectToDevice(deviceName, vid, pid string
 // ListFiles 列出文件
 func (wrapper *PowerShellMTPWrapper) ListFiles(basePath string) ([]*FileInfo, error) {
 	if !wrapper.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	wrapper.log.Debug("PowerShell包装器列出文件: %s", basePath)
@@ -84,6 +84,15 @@ func (wrapper *PowerShellMTPWrapper) ListFiles(basePath string) ([]*FileInfo, er
 	return files, nil
 }
 
+// ListStorages 列出设备根下的存储节点
+func (wrapper *PowerShellMTPWrapper) ListStorages() []StorageInfo {
+	if !wrapper.connected {
+		return nil
+	}
+
+	return wrapper.accessor.ListStorages(wrapper.device.Name)
+}
+
 // GetFileStream 获取文件流
 func (wrapper *PowerShellMTPWrapper) GetFileStream(filePath string) (io.ReadCloser, error) {
 	wrapper.log.Debug("PowerShell包装器获取文件流: %s", filePath)
@@ -112,4 +121,20 @@ func (wrapper *PowerShellMTPWrapper) IsConnected() bool {
 // GetDeviceInfo 获取设备信息
 func (wrapper *PowerShellMTPWrapper) GetDeviceInfo() *DeviceInfo {
 	return wrapper.device
-}
\ No newline at end of file
+}
+
+// QuickStat 快速估算设备上的文件数和总大小
+func (wrapper *PowerShellMTPWrapper) QuickStat() (int, int64, error) {
+	return EstimateFiles(wrapper)
+}
+
+// GetDeviceDetails 获取设备详细信息
+func (wrapper *PowerShellMTPWrapper) GetDeviceDetails() (*DeviceDetails, error) {
+	if !wrapper.connected {
+		return nil, ErrDeviceNotConnected
+	}
+
+	details := NewDeviceDetails(wrapper.device, wrapper.ListStorages())
+	wrapper.accessor.ReadDeviceDetails(wrapper.device.Name, details)
+	return details, nil
+}
@@ -0,0 +1,91 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+)
+
+const (
+	// QuickStatDepth 快速估算时逐层列出的目录层数（从存储根目录算起），更深的目录按子项数估算
+	QuickStatDepth = 2
+	// QuickStatAvgFileSize 无法从已列出的文件得到平均大小时，按该典型录音大小估算（8MB）
+	QuickStatAvgFileSize = 8 * 1024 * 1024
+)
+
+// EstimateFiles 快速估算设备上的文件数和总大小，不做全量递归，供各访问器实现 QuickStat
+// 支持逐层列出目录时只列出顶层 QuickStatDepth 层，更深的目录按其子项数计入；
+// 存储报告了已用空间时总大小取已用空间，否则按已列出文件的平均大小推算。
+// 不支持逐层列出时按存储已用空间除以典型录音大小估算文件数
+func EstimateFiles(mtp MTPInterface) (approxCount int, approxBytes int64, err error) {
+	storages := mtp.ListStorages()
+	var used int64
+	usedKnown := false
+	for _, storage := range storages {
+		if storage.Capacity > 0 {
+			used += storage.Capacity - storage.FreeSpace
+			usedKnown = true
+		}
+	}
+
+	if lister, ok := mtp.(DirectoryLister); ok {
+		roots := []string{""}
+		if len(storages) > 0 {
+			roots = roots[:0]
+			for _, storage := range storages {
+				roots = append(roots, storage.Name)
+			}
+		}
+		count, bytes, err := shallowCount(lister, roots, QuickStatDepth)
+		if err == nil {
+			if usedKnown {
+				bytes = used
+			}
+			return count, bytes, nil
+		}
+		if !usedKnown {
+			return 0, 0, err
+		}
+	}
+
+	if !usedKnown {
+		return 0, 0, fmt.Errorf("访问器不支持逐层列出目录，也未报告存储已用空间，无法快速估算")
+	}
+	return int(used / QuickStatAvgFileSize), used, nil
+}
+
+// shallowCount 从各根目录逐层列出 depth 层，统计文件数和大小；到达层数限制的子目录按其子项数计入文件数，
+// 大小按已列出文件的平均大小推算
+func shallowCount(lister DirectoryLister, roots []string, depth int) (int, int64, error) {
+	var files, deferred int
+	var bytes int64
+
+	level := roots
+	for d := 1; d <= depth && len(level) > 0; d++ {
+		var next []string
+		for _, dir := range level {
+			entries, err := lister.ListDirectory(dir)
+			if err != nil {
+				return 0, 0, fmt.Errorf("列出目录 %s 失败: %w", dir, err)
+			}
+			for _, entry := range entries {
+				switch {
+				case !entry.IsDir:
+					files++
+					bytes += entry.Size
+				case d < depth:
+					next = append(next, joinDevicePath(dir, entry.Name))
+				default:
+					deferred += entry.Items
+				}
+			}
+		}
+		level = next
+	}
+
+	avg := int64(QuickStatAvgFileSize)
+	if files > 0 {
+		avg = bytes / int64(files)
+	}
+	return files + deferred, bytes + int64(deferred)*avg, nil
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newQuickStatDevice 创建按日期分目录存放录音的虚拟设备：days 个目录，每个目录 perDay 个 1MB 文件
+func newQuickStatDevice(days, perDay int) *FakeMTPAccessor {
+	fake := NewFakeMTPAccessor(nil)
+	fake.AddStorage(StorageInfo{ID: "s1", Name: "内部共享存储空间", Capacity: 1 << 30})
+	modTime := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	for d := 0; d < days; d++ {
+		for i := 0; i < perDay; i++ {
+			path := fmt.Sprintf("内部共享存储空间\\录音笔文件\\2024-05-%02d\\rec%d.opus", d+1, i)
+			fake.AddFile(path, nil, modTime).Size = 1 << 20
+		}
+	}
+	return fake
+}
+
+// TestFakeMTPAccessor_QuickStat 测试快速估算只列出顶层目录，结果接近实际且明显快于全量枚举
+func TestFakeMTPAccessor_QuickStat(t *testing.T) {
+	const days, perDay = 30, 4
+	fake := newQuickStatDevice(days, perDay)
+	fake.SetListLatency(5 * time.Millisecond)
+
+	start := time.Now()
+	count, bytes, err := fake.QuickStat()
+	quick := time.Since(start)
+	if err != nil {
+		t.Fatalf("快速估算失败: %v", err)
+	}
+	if count != days*perDay {
+		t.Errorf("估算文件数 = %d，期望 %d", count, days*perDay)
+	}
+	if want := int64(days*perDay) << 20; bytes != want {
+		t.Errorf("估算大小 = %d，期望存储已用空间 %d", bytes, want)
+	}
+	if listed := fake.DirectoryListings("内部共享存储空间\\录音笔文件\\2024-05-01"); listed != 0 {
+		t.Errorf("快速估算不应列出日期目录，实际列出 %d 次", listed)
+	}
+
+	start = time.Now()
+	files, err := fake.ListFiles("内部共享存储空间")
+	full := time.Since(start)
+	if err != nil || len(files) != days*perDay {
+		t.Fatalf("全量枚举 = %d 个文件, %v", len(files), err)
+	}
+	if quick*3 > full {
+		t.Errorf("快速估算耗时 %v，应明显快于全量枚举的 %v", quick, full)
+	}
+}
+
+// TestEstimateFiles_StorageOnly 测试不支持逐层列出目录时按存储已用空间估算，没有存储信息时返回错误
+func TestEstimateFiles_StorageOnly(t *testing.T) {
+	m := newMockStorageMTP()
+	if _, _, err := m.QuickStat(); err == nil {
+		t.Error("没有存储已用空间时应返回错误")
+	}
+
+	m.storages[0].Capacity = 1 << 30
+	m.storages[0].FreeSpace = 1<<30 - 10*QuickStatAvgFileSize
+	count, bytes, err := m.QuickStat()
+	if err != nil {
+		t.Fatalf("快速估算失败: %v", err)
+	}
+	if count != 10 || bytes != 10*QuickStatAvgFileSize {
+		t.Errorf("估算 = %d 个 %d 字节，期望 10 个 %d 字节", count, bytes, 10*QuickStatAvgFileSize)
+	}
+}
@@ -0,0 +1,107 @@
+package device
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 大小估算来源，真实读取失败时标识估算所用的分支
+const (
+	SizeSourceOpusEstimate = "Opus_Estimate"
+	SizeSourceWAVEstimate  = "WAV_Estimate"
+	SizeSourceMP3Estimate  = "MP3_Estimate"
+)
+
+const (
+	// wavBytesPerSecond 录音笔常见WAV参数：48kHz、16bit、单声道
+	wavBytesPerSecond = 48000 * 2 * 1
+	// wavHeaderSize 标准WAV文件头长度
+	wavHeaderSize = 44
+	// mp3Bitrate 录音笔常见MP3码率（bps）
+	mp3Bitrate = 128000
+	// defaultEstimateDuration 文件名中无法推断时长时假设的录音时长
+	defaultEstimateDuration = 10 * time.Minute
+)
+
+var (
+	// nameDurationPattern 匹配文件名中的时长，如 1h02m03s、15m30s、45s
+	nameDurationPattern = regexp.MustCompile(`(?:(\d+)h)?(?:(\d+)m)?(\d+)s`)
+	// nameChineseDurationPattern 匹配文件名中的中文时长，如 1小时2分3秒、15分30秒
+	nameChineseDurationPattern = regexp.MustCompile(`(?:(\d+)小时)?(?:(\d+)分)?(\d+)秒`)
+)
+
+// EstimateFileSize 真实大小读取失败时按扩展名估算文件大小，返回估算值和估算来源
+// wav 按采样参数×时长计算，mp3 按码率×时长计算，时长优先从文件名推断；opus 沿用文件名关键字估算
+func EstimateFileSize(filename string) (int64, string) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".wav":
+		duration, ok := ParseDurationFromName(filename)
+		if !ok {
+			duration = defaultEstimateDuration
+		}
+		return wavHeaderSize + int64(duration.Seconds()*wavBytesPerSecond), SizeSourceWAVEstimate
+	case ".mp3":
+		duration, ok := ParseDurationFromName(filename)
+		if !ok {
+			duration = defaultEstimateDuration
+		}
+		return int64(duration.Seconds() * mp3Bitrate / 8), SizeSourceMP3Estimate
+	default:
+		return EstimateFileSizeFromName(filename), SizeSourceOpusEstimate
+	}
+}
+
+// ParseDurationFromName 从文件名中推断录音时长
+func ParseDurationFromName(filename string) (time.Duration, bool) {
+	name := strings.ToLower(strings.TrimSuffix(filename, filepath.Ext(filename)))
+
+	for _, pattern := range []*regexp.Regexp{nameDurationPattern, nameChineseDurationPattern} {
+		match := pattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.Atoi(match[3])
+		duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+		if duration > 0 {
+			return duration, true
+		}
+	}
+
+	return 0, false
+}
+
+// EstimateFileSizeFromName 基于文件名智能估算文件大小
+// 这个函数作为WPD API获取失败时的降级方案
+func EstimateFileSizeFromName(filename string) int64 {
+	filename = strings.ToLower(filename)
+
+	// 基于常见的录音笔文件命名模式进行估算
+	// 例如：REC20240101080000.opus 表示2024年1月1日8点的录音
+
+	// 短录音（1-5分钟）：1-5MB
+	if strings.Contains(filename, "short") || strings.Contains(filename, "brief") {
+		return 3 * 1024 * 1024 // 3MB
+	}
+
+	// 中等录音（5-30分钟）：5-30MB
+	if strings.Contains(filename, "medium") || strings.Contains(filename, "normal") {
+		return 15 * 1024 * 1024 // 15MB
+	}
+
+	// 长录音（30分钟以上）：30-500MB
+	if strings.Contains(filename, "long") || strings.Contains(filename, "meeting") {
+		return 100 * 1024 * 1024 // 100MB
+	}
+
+	// 基于文件大小的通用估算
+	// 对于.opus音频文件，使用平均码率进行估算
+	// .opus典型码率：32-160 kbps，取中间值80 kbps
+	// 假设平均录音时长为10分钟
+	return 6 * 1024 * 1024 // 6MB作为保守估算
+}
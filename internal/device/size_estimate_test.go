@@ -0,0 +1,69 @@
+package device
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEstimateFileSize 测试不同扩展名走不同的估算分支
+func TestEstimateFileSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filename string
+		size     int64
+		source   string
+	}{
+		{"WAV按文件名时长计算", "REC_15m30s.wav", wavHeaderSize + 930*wavBytesPerSecond, SizeSourceWAVEstimate},
+		{"WAV中文时长", "会议_1小时2分3秒.WAV", wavHeaderSize + 3723*wavBytesPerSecond, SizeSourceWAVEstimate},
+		{"WAV无时长使用默认时长", "REC0001.wav", wavHeaderSize + 600*wavBytesPerSecond, SizeSourceWAVEstimate},
+		{"MP3按码率计算", "memo_2m0s.mp3", 120 * mp3Bitrate / 8, SizeSourceMP3Estimate},
+		{"MP3无时长使用默认时长", "song.mp3", 600 * mp3Bitrate / 8, SizeSourceMP3Estimate},
+		{"Opus保留原有估算", "meeting_15m30s.opus", 100 * 1024 * 1024, SizeSourceOpusEstimate},
+		{"Opus默认估算", "REC20240101080000.opus", 6 * 1024 * 1024, SizeSourceOpusEstimate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, source := EstimateFileSize(tc.filename)
+			if size != tc.size {
+				t.Errorf("估算大小错误，期望 %d，实际 %d", tc.size, size)
+			}
+			if source != tc.source {
+				t.Errorf("估算来源错误，期望 %s，实际 %s", tc.source, source)
+			}
+		})
+	}
+}
+
+// TestEstimateFileSize_OpusUnchanged 测试opus估算与原有文件名估算一致
+func TestEstimateFileSize_OpusUnchanged(t *testing.T) {
+	for _, filename := range []string{"short.opus", "normal.opus", "long.opus", "REC001.opus"} {
+		size, _ := EstimateFileSize(filename)
+		if expected := EstimateFileSizeFromName(filename); size != expected {
+			t.Errorf("%s 估算结果变化，期望 %d，实际 %d", filename, expected, size)
+		}
+	}
+}
+
+// TestParseDurationFromName 测试从文件名推断时长
+func TestParseDurationFromName(t *testing.T) {
+	testCases := []struct {
+		filename string
+		duration time.Duration
+		ok       bool
+	}{
+		{"REC_1h02m03s.wav", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"REC_45s.mp3", 45 * time.Second, true},
+		{"访谈_15分30秒.wav", 15*time.Minute + 30*time.Second, true},
+		{"REC20240101080000.wav", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			duration, ok := ParseDurationFromName(tc.filename)
+			if ok != tc.ok || duration != tc.duration {
+				t.Errorf("期望 %s/%v，实际 %s/%v", tc.duration, tc.ok, duration, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// 存储选择常量（对应 config.Source.Storage）
+const (
+	// StorageInternal 内部存储
+	StorageInternal = "internal"
+	// StorageSD SD/TF 卡
+	StorageSD = "sd"
+	// StorageAll 所有存储
+	StorageAll = "all"
+)
+
+// StorageInfo 设备存储信息
+type StorageInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`       // 存储显示名称，如 "内部共享存储空间"、"SD卡"
+	Type      string `json:"type"`       // 存储类型: internal, sd
+	Capacity  int64  `json:"capacity"`   // 总容量（字节），未知时为0
+	FreeSpace int64  `json:"free_space"` // 可用空间（字节），未知时为0
+}
+
+// sdStorageKeywords 识别可移动存储卡的名称关键词
+var sdStorageKeywords = []string{"SD", "TF", "CARD", "卡", "可移动", "REMOVABLE", "EXTERNAL"}
+
+// ClassifyStorage 根据存储名称判断存储类型
+func ClassifyStorage(name string) string {
+	upper := strings.ToUpper(name)
+	for _, keyword := range sdStorageKeywords {
+		if strings.Contains(upper, keyword) {
+			return StorageSD
+		}
+	}
+	return StorageInternal
+}
+
+// SelectStorages 按选择条件过滤存储列表
+func SelectStorages(storages []StorageInfo, selection string) []StorageInfo {
+	selection = strings.ToLower(strings.TrimSpace(selection))
+	if selection == "" {
+		selection = StorageInternal
+	}
+
+	var selected []StorageInfo
+	for _, storage := range storages {
+		if selection == StorageAll || storage.Type == selection {
+			selected = append(selected, storage)
+		}
+	}
+	return selected
+}
+
+// ListFilesInStorages 在选定的存储上列出文件
+// basePath 的第一段如果是某个存储的名称，会被视为存储前缀并在各存储上替换；
+// 访问器不支持存储枚举时退化为直接调用 ListFiles(basePath)
+func ListFilesInStorages(mtp MTPInterface, basePath, selection string, log *logger.Logger) ([]*FileInfo, error) {
+	storages := mtp.ListStorages()
+	if len(storages) == 0 {
+		log.Debug("访问器未返回存储列表，直接列出: %s", basePath)
+		return mtp.ListFiles(basePath)
+	}
+
+	selected := SelectStorages(storages, selection)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("设备上没有符合条件的存储: %s", selection)
+	}
+
+	relativePath := stripStoragePrefix(basePath, storages)
+
+	var allFiles []*FileInfo
+	for _, storage := range selected {
+		storagePath := storage.Name
+		if relativePath != "" {
+			storagePath = storage.Name + "\\" + relativePath
+		}
+
+		log.Debug("在存储 %s (%s) 上列出文件: %s", storage.Name, storage.Type, storagePath)
+		files, err := mtp.ListFiles(storagePath)
+		if err != nil {
+			if len(selected) == 1 {
+				return nil, fmt.Errorf("列出存储 %s 的文件失败: %w", storage.Name, err)
+			}
+			log.Warn("列出存储 %s 的文件失败，跳过: %v", storage.Name, err)
+			continue
+		}
+		allFiles = append(allFiles, files...)
+	}
+
+	return allFiles, nil
+}
+
+// stripStoragePrefix 去掉路径中的存储名称前缀，返回存储内的相对路径
+func stripStoragePrefix(basePath string, storages []StorageInfo) string {
+	trimmed := strings.Trim(strings.ReplaceAll(basePath, "/", "\\"), "\\")
+	first, rest, _ := strings.Cut(trimmed, "\\")
+	for _, storage := range storages {
+		if strings.EqualFold(first, storage.Name) {
+			return rest
+		}
+	}
+	return trimmed
+}
+
+// buildListStoragesScript 构建枚举设备根下存储节点的PowerShell脚本
+func buildListStoragesScript(deviceName string) string {
+	return fmt.Sprintf(`
+[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+$shell = New-Object -ComObject Shell.Application
+$portable = $shell.NameSpace(17)
+if ($portable) {
+    $device = $portable.Items() | Where-Object { $_.Name -like "*%s*" } | Select-Object -First 1
+    if ($device) {
+        foreach ($item in $device.GetFolder.Items()) {
+            if ($item.IsFolder) {
+                $capacity = 0
+                $free = 0
+                try { $capacity = [long]$item.ExtendedProperty("System.Capacity") } catch {}
+                try { $free = [long]$item.ExtendedProperty("System.FreeSpace") } catch {}
+                Write-Output "STORAGE|$($item.Name)|$capacity|$free"
+            }
+        }
+    }
+}
+`, sanitizeDeviceName(deviceName))
+}
+
+// parseStorageOutput 解析存储枚举脚本的输出
+func parseStorageOutput(output string) []StorageInfo {
+	var storages []StorageInfo
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "STORAGE|") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if len(parts) < 2 || strings.TrimSpace(parts[1]) == "" {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[1])
+		storage := StorageInfo{
+			ID:   name,
+			Name: name,
+			Type: ClassifyStorage(name),
+		}
+		if len(parts) >= 3 {
+			storage.Capacity = parseInt64(strings.TrimSpace(parts[2]))
+		}
+		if len(parts) >= 4 {
+			storage.FreeSpace = parseInt64(strings.TrimSpace(parts[3]))
+		}
+		storages = append(storages, storage)
+	}
+	return storages
+}
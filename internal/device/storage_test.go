@@ -0,0 +1,150 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// mockStorageMTP 模拟带有多个存储的MTP设备
+type mockStorageMTP struct {
+	storages []StorageInfo
+	files    map[string][]*FileInfo
+	listed   []string
+}
+
+func (m *mockStorageMTP) ConnectToDevice(deviceName, vid, pid string) error { return nil }
+
+func (m *mockStorageMTP) ListFiles(basePath string) ([]*FileInfo, error) {
+	m.listed = append(m.listed, basePath)
+	files, ok := m.files[basePath]
+	if !ok {
+		return nil, fmt.Errorf("路径不存在: %s", basePath)
+	}
+	return files, nil
+}
+
+func (m *mockStorageMTP) ListStorages() []StorageInfo { return m.storages }
+
+func (m *mockStorageMTP) GetFileStream(filePath string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("不支持")
+}
+
+func (m *mockStorageMTP) Close() error { return nil }
+
+func (m *mockStorageMTP) IsConnected() bool { return true }
+
+func (m *mockStorageMTP) GetDeviceInfo() *DeviceInfo { return &DeviceInfo{Name: "SR302"} }
+
+func (m *mockStorageMTP) GetDeviceDetails() (*DeviceDetails, error) {
+	return NewDeviceDetails(m.GetDeviceInfo(), m.storages), nil
+}
+
+func (m *mockStorageMTP) QuickStat() (int, int64, error) { return EstimateFiles(m) }
+
+func newMockStorageMTP() *mockStorageMTP {
+	return &mockStorageMTP{
+		storages: []StorageInfo{
+			{ID: "内部共享存储空间", Name: "内部共享存储空间", Type: StorageInternal},
+			{ID: "SD卡", Name: "SD卡", Type: StorageSD},
+		},
+		files: map[string][]*FileInfo{
+			"内部共享存储空间\\录音笔文件": {{Name: "internal.opus", Path: "内部共享存储空间\\录音笔文件\\internal.opus"}},
+			"SD卡\\录音笔文件":      {{Name: "sd.opus", Path: "SD卡\\录音笔文件\\sd.opus"}},
+		},
+	}
+}
+
+// TestClassifyStorage 测试存储类型识别
+func TestClassifyStorage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		storage  string
+		expected string
+	}{
+		{"内部存储", "内部共享存储空间", StorageInternal},
+		{"英文内部存储", "Internal shared storage", StorageInternal},
+		{"中文SD卡", "SD卡", StorageSD},
+		{"英文SD卡", "SD card", StorageSD},
+		{"可移动存储", "可移动存储设备", StorageSD},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClassifyStorage(tc.storage); got != tc.expected {
+				t.Errorf("ClassifyStorage(%q) = %q, 期望 %q", tc.storage, got, tc.expected)
+			}
+		})
+	}
+}
+
+// TestListFilesInStorages 测试按配置选择存储枚举文件
+func TestListFilesInStorages(t *testing.T) {
+	log := logger.NewLogger(false)
+
+	testCases := []struct {
+		name      string
+		selection string
+		expected  []string
+	}{
+		{"默认内部存储", "", []string{"internal.opus"}},
+		{"内部存储", StorageInternal, []string{"internal.opus"}},
+		{"SD卡", StorageSD, []string{"sd.opus"}},
+		{"全部存储", StorageAll, []string{"internal.opus", "sd.opus"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mtp := newMockStorageMTP()
+			files, err := ListFilesInStorages(mtp, "内部共享存储空间\\录音笔文件", tc.selection, log)
+			if err != nil {
+				t.Fatalf("列出文件失败: %v", err)
+			}
+
+			var names []string
+			for _, file := range files {
+				names = append(names, file.Name)
+			}
+			sort.Strings(names)
+
+			if fmt.Sprint(names) != fmt.Sprint(tc.expected) {
+				t.Errorf("文件列表 = %v, 期望 %v", names, tc.expected)
+			}
+		})
+	}
+}
+
+// TestListFilesInStorages_NoStorages 测试访问器不支持存储枚举时的回退
+func TestListFilesInStorages_NoStorages(t *testing.T) {
+	mtp := newMockStorageMTP()
+	mtp.storages = nil
+
+	files, err := ListFilesInStorages(mtp, "内部共享存储空间\\录音笔文件", StorageSD, logger.NewLogger(false))
+	if err != nil {
+		t.Fatalf("列出文件失败: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "internal.opus" {
+		t.Errorf("应直接使用原始路径列出文件，实际: %v", mtp.listed)
+	}
+}
+
+// TestParseStorageOutput 测试存储枚举输出解析
+func TestParseStorageOutput(t *testing.T) {
+	output := "STORAGE|内部共享存储空间|8000000000|4000000000\r\nnoise\nSTORAGE|SD卡|32000000000|0\n"
+	storages := parseStorageOutput(output)
+
+	if len(storages) != 2 {
+		t.Fatalf("期望 2 个存储，实际 %d", len(storages))
+	}
+	if storages[0].Type != StorageInternal || storages[0].Capacity != 8000000000 || storages[0].FreeSpace != 4000000000 {
+		t.Errorf("内部存储解析错误: %+v", storages[0])
+	}
+	if storages[1].Type != StorageSD || storages[1].Name != "SD卡" {
+		t.Errorf("SD卡解析错误: %+v", storages[1])
+	}
+}
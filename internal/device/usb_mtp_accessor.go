@@ -6,13 +6,13 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
 )
 
 // USBMTPAccessor USB MTP访问器
@@ -93,13 +93,13 @@ if ($device) {
 }
 `, vid, pid)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("WMI查询失败: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "DEVICE_FOUND|") {
@@ -169,13 +169,13 @@ if (-not $found) {
 }
 `
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("Windows Shell访问失败: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
 	var items []*FileInfo
 
 	for _, line := range lines {
@@ -201,7 +201,7 @@ func (u *USBMTPAccessor) ListFiles(basePath string) ([]*FileInfo, error) {
 	defer u.mutex.RUnlock()
 
 	if !u.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	u.log.Debug("USB MTP列出文件: %s", basePath)
@@ -285,13 +285,13 @@ try {
 }
 `, strings.Replace(devicePath, "'", "''", -1))
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("设备文件枚举失败: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
 	var files []*FileInfo
 
 	for _, line := range lines {
@@ -325,7 +325,7 @@ func (u *USBMTPAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
 	defer u.mutex.RUnlock()
 
 	if !u.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	u.log.Debug("USB MTP获取文件流: %s", filePath)
@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
 )
 
 // WindowsNativeMTP Windows原生MTP访问器
@@ -48,13 +49,13 @@ if ($portable) {
 Write-Output "DEVICE_NOT_FOUND"
 `, deviceName, deviceName)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("设备连接失败: %w", err)
 	}
 
-	if strings.Contains(string(output), "DEVICE_FOUND") {
+	if strings.Contains(output, "DEVICE_FOUND") {
 		w.connected = true
 		w.deviceInfo = &DeviceInfo{
 			Name: deviceName,
@@ -71,7 +72,7 @@ Write-Output "DEVICE_NOT_FOUND"
 // ListFiles 列出设备文件
 func (w *WindowsNativeMTP) ListFiles(basePath string) ([]*FileInfo, error) {
 	if !w.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	w.log.Debug("Windows原生MTP列出文件: %s", basePath)
@@ -138,16 +139,16 @@ if ($portable) {
 Write-Output "DONE"
 `, w.deviceInfo.Name)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
-		w.log.Error("PowerShell文件枚举失败: %v, 输出: %s", err, string(output))
+		w.log.Error("PowerShell文件枚举失败: %v, 输出: %s", err, output)
 		return nil, fmt.Errorf("文件枚举失败: %w", err)
 	}
 
-	w.log.Debug("PowerShell输出: %s", string(output))
+	w.log.Debug("PowerShell输出: %s", output)
 
-	return w.parseFileOutput(string(output))
+	return w.parseFileOutput(output)
 }
 
 // parseFileOutput 解析文件输出
@@ -187,17 +188,32 @@ func (w *WindowsNativeMTP) parseFileOutput(output string) ([]*FileInfo, error) {
 	return files, nil
 }
 
+// ListStorages 列出设备根下的存储节点
+func (w *WindowsNativeMTP) ListStorages() []StorageInfo {
+	if !w.connected {
+		return nil
+	}
+
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", buildListStoragesScript(w.deviceInfo.Name))
+	output, err := psexec.CombinedOutput(cmd)
+	if err != nil {
+		w.log.Debug("枚举设备存储失败: %v", err)
+		return nil
+	}
+
+	return parseStorageOutput(output)
+}
+
 // GetFileStream 获取文件流
 func (w *WindowsNativeMTP) GetFileStream(filePath string) (io.ReadCloser, error) {
 	if !w.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	w.log.Debug("Windows原生MTP获取文件流: %s", filePath)
 
 	// 使用PowerShell复制文件到临时目录
-	tempDir := os.TempDir()
-	tempFile := filepath.Join(tempDir, fmt.Sprintf("mtp_%d.opus", time.Now().UnixNano()))
+	tempFile := utils.TempFilePath(fmt.Sprintf("mtp_%d.opus", time.Now().UnixNano()))
 
 	script := fmt.Sprintf(`
 $shell = New-Object -ComObject Shell.Application
@@ -248,20 +264,24 @@ if ($portable) {
 }
 `, w.deviceInfo.Name, filePath, tempFile)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("文件复制失败: %w", err)
+		os.Remove(tempFile)
+		return nil, WrapPowerShellError("文件复制失败", output, err)
 	}
 
-	if strings.Contains(string(output), "SUCCESS") {
+	if strings.Contains(output, "SUCCESS") {
 		file, err := os.Open(tempFile)
 		if err != nil {
+			os.Remove(tempFile)
 			return nil, fmt.Errorf("打开临时文件失败: %w", err)
 		}
-		return file, nil
+		// 关闭流时删除临时文件
+		return &MTPFileStream{file: file, tempPath: tempFile}, nil
 	}
 
+	os.Remove(tempFile)
 	return nil, fmt.Errorf("文件流访问尚未实现")
 }
 
@@ -282,6 +302,29 @@ func (w *WindowsNativeMTP) GetDeviceInfo() *DeviceInfo {
 	return w.deviceInfo
 }
 
+// QuickStat 快速估算设备上的文件数和总大小
+func (w *WindowsNativeMTP) QuickStat() (int, int64, error) {
+	return EstimateFiles(w)
+}
+
+// GetDeviceDetails 获取设备详细信息
+func (w *WindowsNativeMTP) GetDeviceDetails() (*DeviceDetails, error) {
+	if !w.connected {
+		return nil, ErrDeviceNotConnected
+	}
+
+	details := NewDeviceDetails(w.deviceInfo, w.ListStorages())
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", buildDeviceDetailsScript(w.deviceInfo.Name))
+	output, err := psexec.CombinedOutput(cmd)
+	if err != nil {
+		w.log.Debug("读取设备详细信息失败: %v", err)
+		return details, nil
+	}
+
+	parseDeviceDetailsOutput(output, details)
+	return details, nil
+}
+
 // GetLastError 获取最后的错误
 func (w *WindowsNativeMTP) GetLastError() error {
 	return nil
@@ -134,7 +134,7 @@ func (w *WPDAPIHandler) ConnectToDevice(deviceID string) error {
 // GetContentInterface 获取内容接口
 func (w *WPDAPIHandler) GetContentInterface() error {
 	if !w.connected {
-		return fmt.Errorf("设备未连接")
+		return ErrDeviceNotConnected
 	}
 
 	w.log.Debug("获取IPortableDeviceContent接口")
@@ -229,33 +229,9 @@ func (w *WPDAPIHandler) getWPDObjectSizeDirect(objectID string) (int64, error) {
 	// 2. 直接调用WPD服务获取WPD_OBJECT_SIZE属性
 	// 3. 这就是Windows文件管理器使用的方法
 
-	// 由于Windows API调用的复杂性，这里提供一个增强的估算算法
-	// 基于我们从PowerShell测试中获得的文件名信息
-
-	if w.containsMeetingKeywords(objectID) {
-		// 根据用户反馈，会议录音通常是几百MB
-		// 我们使用一个更符合实际的估算值
-		return 150 * 1024 * 1024, nil // 150MB
-	}
-
-	// 对于其他录音文件，使用中等估算
-	return 50 * 1024 * 1024, nil // 50MB
-}
-
-// containsMeetingKeywords 检查对象ID是否包含会议相关关键词
-func (w *WPDAPIHandler) containsMeetingKeywords(objectID string) bool {
-	keywords := []string{
-		"董总会谈", "会议", "meeting", "谈话", "洽谈", "讨论",
-	}
-
-	for _, keyword := range keywords {
-		if len(objectID) > 0 && objectID[len(objectID)-1] == byte(keyword[0]) {
-			// 简化的关键词检查，实际应该使用strings.Contains
-			return true
-		}
-	}
-
-	return false
+	// 直接调用尚未实现，返回错误以便调用方使用其他真实读取方式，
+	// 避免把估算值当作真实大小
+	return 0, fmt.Errorf("WPD_OBJECT_SIZE直接读取暂不支持: %s", objectID)
 }
 
 // 辅助方法
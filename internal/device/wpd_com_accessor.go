@@ -5,13 +5,14 @@ package device
 import (
 	"fmt"
 	"io"
-	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
 	"github.com/go-ole/go-ole"
 )
 
@@ -24,6 +25,9 @@ type WPDComAccessor struct {
 	mutex             sync.RWMutex
 	wpdAPIHandler     *WPDAPIHandler     // 真正的WPD API处理器
 	windowsWPDService *WindowsWPDService // Windows WPD服务
+	objects           *ObjectIndex       // 设备路径与对象ID的双向索引，每次枚举时重建
+	native            *WPDNativeAccessor // 读取文件内容用的纯Go WPD访问器，连接设备时打开
+	nativeMutex       sync.Mutex
 }
 
 // WPD接口ID常量
@@ -41,6 +45,7 @@ func NewWPDComAccessor(log *logger.Logger) *WPDComAccessor {
 		log:               log,
 		wpdAPIHandler:     NewWPDAPIHandler(log),     // 初始化真正的WPD API处理器
 		windowsWPDService: NewWindowsWPDService(log), // 初始化Windows WPD服务
+		objects:           NewObjectIndex(),
 	}
 }
 
@@ -127,6 +132,16 @@ func (w *WPDComAccessor) ConnectToDevice(deviceName, vid, pid string) error {
 		}
 	}
 
+	// 读取文件内容和逐层列出目录使用同一个纯Go WPD连接，连接失败时只能通过Shell枚举
+	native := NewWPDNativeAccessor(w.log)
+	if err := native.ConnectToDevice(deviceName, vid, pid); err != nil {
+		w.log.Warn("纯Go WPD连接失败，无法直接读取设备文件: %v", err)
+	} else {
+		w.nativeMutex.Lock()
+		w.native = native
+		w.nativeMutex.Unlock()
+	}
+
 	w.log.Info("WPD COM成功连接到设备: %s", w.deviceInfo.Name)
 	return nil
 }
@@ -205,7 +220,7 @@ func (w *WPDComAccessor) ListFiles(basePath string) ([]*FileInfo, error) {
 	defer w.mutex.RUnlock()
 
 	if !w.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	w.log.Debug("WPD COM列出文件: %s", basePath)
@@ -254,7 +269,7 @@ if ($portable) {
         # 获取设备的根文件夹
         $deviceFolder = $device.GetFolder
         if ($deviceFolder) {
-            # 递归枚举所有.opus文件
+            # 递归枚举所有录音文件（opus/wav/mp3）
             function Enumerate-OpusFiles($folder, $path = "") {
                 $files = @()
                 foreach ($item in $folder.Items()) {
@@ -268,8 +283,8 @@ if ($portable) {
                         } catch {
                             # 忽略无法访问的文件夹
                         }
-                    } elseif ($item.Name -like "*.opus") {
-                        # 增强的文件大小获取策略：WPD API → Shell属性 → 智能估算
+                    } elseif ($item.Name -match '\.(opus|wav|mp3)$') {
+                        # 文件大小获取策略：优先读取Shell属性中的真实大小
                         $size = 0
                         $sizeSource = "Unknown"
                         $isEstimated = $true
@@ -321,57 +336,28 @@ if ($portable) {
                                 }
                             }
 
-                            # 方法4: 基于文件名和录音笔特点的智能估算
-                            if ($size -eq 0) {
-                                $filename = $item.Name.ToLower()
-                                $size = EstimateFileSizeFromName $filename
-                                $sizeSource = "Intelligent_Estimate"
-                                $isEstimated = $true
-
-                                # 根据文件名特征调整估算
-                                if ($filename -match '(\d{4})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})') {
-                                    # 解析录音时间戳：YYYYMMDDHHMMSS
-                                    $hour = [int]$matches[4]
-                                    $minute = [int]$matches[5]
-                                    # 工作时间录音通常较长
-                                    if ($hour -ge 9 -and $hour -le 17) {
-                                        $size *= 2  # 工作时间录音可能是会议，时长翻倍
-                                        $sizeSource = "Meeting_Estimate"
-                                    }
-                                    # 深夜录音可能较短
-                                    if ($hour -ge 22 -or $hour -le 6) {
-                                        $size *= 0.5  # 深夜录音可能较短
-                                        $sizeSource = "Night_Estimate"
-                                    }
-                                }
-
-                                # 如果文件名包含特定关键词
-                                if ($filename -match 'meeting|会议|洽谈|讨论') {
-                                    $size = 100 * 1024 * 1024  # 100MB - 会议录音
-                                    $sizeSource = "Meeting_Keyword"
-                                } elseif ($filename -match 'memo|备忘|记录|note') {
-                                    $size = 3 * 1024 * 1024  # 3MB - 简短录音
-                                    $sizeSource = "Memo_Keyword"
-                                } elseif ($filename -match 'long|长|lecture|课程') {
-                                    $size = 200 * 1024 * 1024  # 200MB - 长录音
-                                    $sizeSource = "Long_Keyword"
-                                }
-                            }
-
+                            # 真实大小均读取失败时保留0，由Go端按扩展名估算兜底
                         } catch {
-                            # 出错时使用默认估算
-                            $size = 7 * 1024 * 1024  # 7MB默认估算
+                            $size = 0
                             $sizeSource = "Error_Fallback"
-                            $isEstimated = $true
                         }
 
+                        # 读取只读/隐藏/系统属性，读取失败时为0
+                        $attributes = 0
+                        try {
+                            $attrValue = $item.ExtendedProperty("System.FileAttributes")
+                            if ($attrValue) { $attributes = [long]$attrValue }
+                        } catch {}
+
                         $fileInfo = [PSCustomObject]@{
                             Name = $item.Name
                             Path = $currentPath
                             Size = $size
                             ModifiedDate = if ($item.ModifyDate) { $item.ModifyDate } else { [DateTime]::Now }
-                            SizeSource = "MTP_Estimate"
-                            IsEstimated = $true
+                            SizeSource = $sizeSource
+                            IsEstimated = $isEstimated
+                            ShellPath = $item.Path
+                            Attributes = $attributes
                         }
                         $files += $fileInfo
                     }
@@ -381,7 +367,7 @@ if ($portable) {
 
             $opusFiles = Enumerate-OpusFiles $deviceFolder
             $opusFiles | ForEach-Object {
-                "$($_.Path)|$($_.Name)|$($_.Size)|$($_.ModifiedDate)|$($_.SizeSource)|$($_.IsEstimated)"
+                "$($_.Path)|$($_.Name)|$($_.Size)|$($_.ModifiedDate)|$($_.SizeSource)|$($_.IsEstimated)|$($_.ShellPath)|$($_.Attributes)"
             }
         } else {
             Write-Error "无法获取设备文件夹"
@@ -395,22 +381,23 @@ if ($portable) {
 `, w.deviceInfo.Name)
 
 	// 执行PowerShell脚本，设置UTF-8编码
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command",
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command",
 		"[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; $OutputEncoding = [System.Text.Encoding]::UTF8; " + script)
-	output, err := cmd.CombinedOutput()
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
-		w.log.Error("Shell COM文件枚举失败: %v, 输出: %s", err, string(output))
+		w.log.Error("Shell COM文件枚举失败: %v, 输出: %s", err, output)
 		return nil, fmt.Errorf("Shell COM文件枚举失败: %w", err)
 	}
 
 	// 解析输出
-	return w.parseShellFileOutput(string(output), basePath)
+	return w.parseShellFileOutput(output, basePath)
 }
 
 // parseShellFileOutput 解析Shell文件输出
 func (w *WPDComAccessor) parseShellFileOutput(output, basePath string) ([]*FileInfo, error) {
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	var files []*FileInfo
+	objectIDs := make(map[string]string) // 设备路径 -> 对象ID
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -424,7 +411,7 @@ func (w *WPDComAccessor) parseShellFileOutput(output, basePath string) ([]*FileI
 			continue
 		}
 
-		// 解析文件信息格式：Path|Name|Size|ModifiedDate|SizeSource|IsEstimated
+		// 解析文件信息格式：Path|Name|Size|ModifiedDate|SizeSource|IsEstimated|ShellPath|Attributes
 		parts := strings.Split(line, "|")
 		if len(parts) < 3 {
 			w.log.Debug("解析文件信息失败，格式不正确: %s", line)
@@ -435,8 +422,9 @@ func (w *WPDComAccessor) parseShellFileOutput(output, basePath string) ([]*FileI
 		name := strings.TrimSpace(parts[1])
 		sizeStr := strings.TrimSpace(parts[2])
 
-		// 只处理.opus文件
-		if !strings.HasSuffix(strings.ToLower(name), ".opus") {
+		// 只处理录音文件
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".opus" && ext != ".wav" && ext != ".mp3" {
 			continue
 		}
 
@@ -477,15 +465,23 @@ func (w *WPDComAccessor) parseShellFileOutput(output, basePath string) ([]*FileI
 		if len(parts) >= 6 {
 			isEstimated = strings.TrimSpace(parts[5]) == "True"
 		}
+		if len(parts) >= 7 {
+			if objectID := objectIDFromShellPath(parts[6]); objectID != "" {
+				objectIDs[path] = objectID
+			}
+		}
 
 		file := &FileInfo{
 			Path:         path,
 			Name:         name,
 			RelativePath: path,
 			Size:         size,
-			IsOpus:       true,
+			IsOpus:       ext == ".opus",
 			ModTime:      modTime,
 		}
+		if len(parts) >= 8 {
+			file.Attributes = ParseFileAttributes(parts[7])
+		}
 
 		files = append(files, file)
 
@@ -499,8 +495,11 @@ func (w *WPDComAccessor) parseShellFileOutput(output, basePath string) ([]*FileI
 		}
 	}
 
+	w.objects.Rebuild(objectIDs)
+	w.log.Debug("对象索引已刷新: %d 个文件", w.objects.Len())
+
 	if len(files) > 0 {
-		w.log.Info("Shell COM枚举完成，找到 %d 个.opus文件", len(files))
+		w.log.Info("Shell COM枚举完成，找到 %d 个录音文件", len(files))
 
 		// 统计实际大小和估算大小的文件数量
 		estimatedCount := 0
@@ -559,19 +558,85 @@ func (w *WPDComAccessor) parseShellFileOutput(output, basePath string) ([]*FileI
 	return files, nil
 }
 
+// ListStorages 列出设备根下的存储节点
+func (w *WPDComAccessor) ListStorages() []StorageInfo {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if !w.connected {
+		return nil
+	}
+
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", buildListStoragesScript(w.deviceInfo.Name))
+	output, err := psexec.CombinedOutput(cmd)
+	if err != nil {
+		w.log.Debug("WPD COM枚举设备存储失败: %v", err)
+		return nil
+	}
+
+	storages := parseStorageOutput(output)
+	w.log.Debug("WPD COM找到 %d 个存储", len(storages))
+	return storages
+}
+
 // GetFileStream 获取文件流
 func (w *WPDComAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
 
 	if !w.connected {
-		return nil, fmt.Errorf("设备未连接")
+		return nil, ErrDeviceNotConnected
 	}
 
 	w.log.Debug("WPD COM获取文件流: %s", filePath)
 
-	// 使用Shell COM接口创建文件流
-	return NewWPDFileStream(w, filePath, 0), nil
+	// 通过 IPortableDeviceResources::GetStream 直接读取设备上的文件内容
+	native, err := w.resourceAccessor()
+	if err != nil {
+		return nil, fmt.Errorf("打开设备文件流失败: %w", err)
+	}
+	return native.GetFileStream(filePath)
+}
+
+// ReadRange 通过纯Go WPD访问器按偏移读取文件片段，实现 RangeReader
+func (w *WPDComAccessor) ReadRange(filePath string, off, length int64) ([]byte, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if !w.connected {
+		return nil, ErrDeviceNotConnected
+	}
+	native, err := w.resourceAccessor()
+	if err != nil {
+		return nil, fmt.Errorf("按偏移读取设备文件失败: %w", err)
+	}
+	return native.ReadRange(filePath, off, length)
+}
+
+// ListDirectory 通过纯Go WPD访问器列出目录的直接子项，实现 DirectoryLister
+func (w *WPDComAccessor) ListDirectory(dirPath string) ([]DirEntry, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if !w.connected {
+		return nil, ErrDeviceNotConnected
+	}
+	native, err := w.resourceAccessor()
+	if err != nil {
+		return nil, fmt.Errorf("列出设备目录失败: %w", err)
+	}
+	return native.ListDirectory(dirPath)
+}
+
+// resourceAccessor 返回连接设备时一并打开的纯Go WPD访问器，未打开或连接已断开时返回错误
+func (w *WPDComAccessor) resourceAccessor() (*WPDNativeAccessor, error) {
+	w.nativeMutex.Lock()
+	defer w.nativeMutex.Unlock()
+
+	if w.native == nil || !w.native.IsConnected() {
+		return nil, ErrDeviceNotConnected
+	}
+	return w.native, nil
 }
 
 // Close 关闭连接
@@ -585,6 +650,14 @@ func (w *WPDComAccessor) Close() error {
 		w.windowsWPDService = nil
 	}
 
+	// 关闭读取文件内容的WPD连接，未关闭的文件流随之失效
+	w.nativeMutex.Lock()
+	if w.native != nil {
+		w.native.Close()
+		w.native = nil
+	}
+	w.nativeMutex.Unlock()
+
 	// 清理WPD API处理器
 	if w.wpdAPIHandler != nil {
 		w.wpdAPIHandler.Close()
@@ -625,6 +698,35 @@ func (w *WPDComAccessor) GetDeviceInfo() *DeviceInfo {
 	return w.deviceInfo
 }
 
+// QuickStat 快速估算设备上的文件数和总大小
+func (w *WPDComAccessor) QuickStat() (int, int64, error) {
+	return EstimateFiles(w)
+}
+
+// GetDeviceDetails 获取设备详细信息
+func (w *WPDComAccessor) GetDeviceDetails() (*DeviceDetails, error) {
+	// ListStorages 自行加锁，需在持有读锁之前调用
+	storages := w.ListStorages()
+
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if !w.connected {
+		return nil, ErrDeviceNotConnected
+	}
+
+	details := NewDeviceDetails(w.deviceInfo, storages)
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", buildDeviceDetailsScript(w.deviceInfo.Name))
+	output, err := psexec.CombinedOutput(cmd)
+	if err != nil {
+		w.log.Debug("WPD COM读取设备详细信息失败: %v", err)
+		return details, nil
+	}
+
+	parseDeviceDetailsOutput(output, details)
+	return details, nil
+}
+
 // GetLastError 获取最后的错误
 func (w *WPDComAccessor) GetLastError() error {
 	return nil
@@ -637,12 +739,16 @@ func (w *WPDComAccessor) GetObjectFileSizeUsingWPD(objectID string) (int64, erro
 
 	// 方法1: 使用Windows WPD服务（最优先）
 	if w.windowsWPDService != nil && w.windowsWPDService.IsConnected() {
-		filename := w.extractFilenameFromObjectID(objectID)
-		if size, err := w.windowsWPDService.GetObjectSizeUsingWindowsAPI(objectID, filename); err == nil && size > 0 {
-			w.log.Info("Windows WPD服务成功获取文件大小: %s -> %d 字节", filename, size)
-			return size, nil
+		if path, err := w.objects.PathOf(objectID); err != nil {
+			w.log.Debug("跳过Windows WPD服务: %v", err)
 		} else {
-			w.log.Debug("Windows WPD服务获取文件大小失败: %v", err)
+			filename := filepath.Base(strings.ReplaceAll(path, "\\", "/"))
+			if size, err := w.windowsWPDService.GetObjectSizeUsingWindowsAPI(objectID, filename); err == nil && size > 0 {
+				w.log.Info("Windows WPD服务成功获取文件大小: %s -> %d 字节", filename, size)
+				return size, nil
+			} else {
+				w.log.Debug("Windows WPD服务获取文件大小失败: %v", err)
+			}
 		}
 	}
 
@@ -664,45 +770,38 @@ func (w *WPDComAccessor) GetObjectFileSizeUsingWPD(objectID string) (int64, erro
 func (w *WPDComAccessor) GetObjectPropertiesWithFallback(objectID string, filename string) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
-	// 第1层：尝试使用真正的WPD API
-	if size, err := w.GetObjectFileSizeUsingWPD(objectID); err == nil {
-		result["Size"] = size
-		result["SizeSource"] = "WPD_API"
-		result["IsEstimated"] = false
-		w.log.Debug("使用WPD API获取到文件大小: %d 字节", size)
-		return result, nil
-	} else {
-		w.log.Debug("WPD API获取文件大小失败: %v，降级到估算方法", err)
+	// 第1层：尝试使用真正的WPD API，对象ID未知时直接估算
+	if objectID != "" {
+		if size, err := w.GetObjectFileSizeUsingWPD(objectID); err == nil {
+			result["Size"] = size
+			result["SizeSource"] = "WPD_API"
+			result["IsEstimated"] = false
+			w.log.Debug("使用WPD API获取到文件大小: %d 字节", size)
+			return result, nil
+		} else {
+			w.log.Debug("WPD API获取文件大小失败: %v，降级到估算方法", err)
+		}
 	}
 
-	// 第2层：使用智能文件名估算
-	size := EstimateFileSizeFromName(filename)
+	// 第2层：按扩展名估算，仅作兜底
+	size, source := EstimateFileSize(filename)
 	result["Size"] = size
-	result["SizeSource"] = "Intelligent_Estimate"
+	result["SizeSource"] = source
 	result["IsEstimated"] = true
 
-	w.log.Debug("使用智能估算获取文件大小: %s -> %d 字节", filename, size)
+	w.log.Debug("使用估算获取文件大小: %s -> %d 字节 (来源: %s)", filename, size, source)
 	return result, nil
 }
 
-// extractFilenameFromObjectID 从对象ID中提取文件名
-func (w *WPDComAccessor) extractFilenameFromObjectID(objectID string) string {
-	// 如果objectID包含中文文件名，尝试提取
-	if strings.Contains(objectID, "董总会谈") {
-		return "11月24日董总会谈录音_1.opus"
-	}
-
-	// 通用文件名提取逻辑
-	parts := strings.Split(objectID, "_")
-	if len(parts) > 0 {
-		lastPart := parts[len(parts)-1]
-		if len(lastPart) > 3 {
-			return lastPart + ".opus"
-		}
-	}
+// ResolveObjectID 通过最近一次枚举建立的索引把设备路径解析为对象ID
+// 路径未出现在枚举结果中时返回包装了 ErrObjectNotFound 的错误
+func (w *WPDComAccessor) ResolveObjectID(path string) (string, error) {
+	return w.objects.Lookup(path)
+}
 
-	// 默认文件名
-	return "recording.opus"
+// ResolveObjectPath 通过最近一次枚举建立的索引把对象ID解析为设备路径
+func (w *WPDComAccessor) ResolveObjectPath(objectID string) (string, error) {
+	return w.objects.PathOf(objectID)
 }
 
 // EnhancedFileEnumeration 增强的文件枚举，集成WPD API和智能估算
@@ -720,7 +819,11 @@ func (w *WPDComAccessor) EnhancedFileEnumeration(basePath string) ([]*FileInfo,
 	for i, file := range files {
 		// 只有当Shell COM获取的大小为0或无效时，才使用WPD API
 		if file.Size <= 0 {
-			if properties, err := w.GetObjectPropertiesWithFallback("OBJECT_ID_"+file.Name, file.Name); err == nil {
+			objectID, err := w.objects.Lookup(file.Path)
+			if err != nil {
+				w.log.Debug("%v，使用估算大小", err)
+			}
+			if properties, err := w.GetObjectPropertiesWithFallback(objectID, file.Name); err == nil {
 				if size, ok := properties["Size"].(int64); ok && size > 0 {
 					files[i].Size = size
 					w.log.Info("WPD API更新文件大小: %s -> %d 字节 (来源: %v)",
@@ -0,0 +1,488 @@
+//go:build windows
+
+package device
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// WPDNativeAccessor 纯Go实现的WPD访问器，通过 go-ole 直接调用 IPortableDeviceManager 等COM接口，
+// 不启动PowerShell，用于精简系统或禁用了PowerShell的机器
+type WPDNativeAccessor struct {
+	log        *logger.Logger
+	mutex      sync.Mutex
+	thread     *comThread // 所有COM调用都在该线程上执行
+	device     *ole.IUnknown
+	content    *ole.IUnknown
+	properties *ole.IUnknown
+	resources  *ole.IUnknown
+	keys       *ole.IUnknown // 读取对象属性时请求的属性集合
+	info       *DeviceInfo
+	objects    *ObjectIndex // 设备路径与对象ID的索引，枚举和按路径查找时更新
+}
+
+// NewWPDNativeAccessor 创建纯Go的WPD访问器，需调用 ConnectToDevice 后使用
+func NewWPDNativeAccessor(log *logger.Logger) *WPDNativeAccessor {
+	return &WPDNativeAccessor{log: log, objects: NewObjectIndex()}
+}
+
+// EnumerateWPDDevices 通过WPD设备管理器列出所有便携设备，不依赖PowerShell和WMI
+func EnumerateWPDDevices() ([]*USBDevice, error) {
+	thread, err := startCOMThread()
+	if err != nil {
+		return nil, err
+	}
+	defer thread.stop()
+
+	var devices []*USBDevice
+	err = thread.do(func() error {
+		manager, err := ole.CreateInstance(clsidPortableDeviceManager, iidPortableDeviceManager)
+		if err != nil {
+			return fmt.Errorf("创建WPD设备管理器失败: %w", err)
+		}
+		defer manager.Release()
+
+		ids, err := managerDevices(manager)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			name, err := managerFriendlyName(manager, id)
+			if err != nil || name == "" {
+				name = id
+			}
+			vid, pid := extractVIDPID(id)
+			devices = append(devices, &USBDevice{
+				DeviceID:   id,
+				Name:       name,
+				VID:        vid,
+				PID:        pid,
+				DeviceType: determineDeviceType(name, id),
+			})
+		}
+		return nil
+	})
+	return devices, err
+}
+
+// ConnectToDevice 在WPD设备中查找名称包含 deviceName 且VID/PID一致的设备并以只读方式打开，参数为空时不按该项匹配
+func (w *WPDNativeAccessor) ConnectToDevice(deviceName, vid, pid string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.thread != nil {
+		w.closeLocked()
+	}
+
+	thread, err := startCOMThread()
+	if err != nil {
+		return err
+	}
+
+	err = thread.do(func() error {
+		manager, err := ole.CreateInstance(clsidPortableDeviceManager, iidPortableDeviceManager)
+		if err != nil {
+			return fmt.Errorf("创建WPD设备管理器失败: %w", err)
+		}
+		defer manager.Release()
+
+		ids, err := managerDevices(manager)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			name, _ := managerFriendlyName(manager, id)
+			devices := []*USBDevice{{DeviceID: id, Name: name}}
+			devices[0].VID, devices[0].PID = extractVIDPID(id)
+			info, err := FindDevice(devices, deviceName, vid, pid)
+			if err != nil {
+				continue
+			}
+			info.IsMTP = true
+			return w.open(info)
+		}
+		return NewMTPError(ERROR_DEVICE_NOT_FOUND, fmt.Sprintf("WPD中未找到设备: %s (VID:%s, PID:%s)", deviceName, vid, pid), nil)
+	})
+	if err != nil {
+		thread.stop()
+		return err
+	}
+
+	w.thread = thread
+	w.log.Info("纯Go WPD访问器已连接设备: %s", w.info.Name)
+	return nil
+}
+
+// open 打开设备并获取内容、属性和传输接口，需在COM线程上调用
+func (w *WPDNativeAccessor) open(info *DeviceInfo) error {
+	clientInfo, err := newClientInfo()
+	if err != nil {
+		return err
+	}
+	defer clientInfo.Release()
+
+	device, err := ole.CreateInstance(clsidPortableDeviceFTM, iidPortableDevice)
+	if err != nil {
+		return fmt.Errorf("创建WPD设备对象失败: %w", err)
+	}
+	id := utf16Ptr(info.DeviceID)
+	_, err = comCall(device, vtblDeviceOpen, uintptr(unsafe.Pointer(id)), uintptr(unsafe.Pointer(clientInfo)))
+	runtime.KeepAlive(id)
+	if err != nil {
+		device.Release()
+		return fmt.Errorf("打开设备失败: %w", err)
+	}
+
+	content, err := getInterface(device, vtblDeviceContent)
+	if err != nil {
+		comCall(device, vtblDeviceClose)
+		device.Release()
+		return fmt.Errorf("获取设备内容接口失败: %w", err)
+	}
+	properties, err := getInterface(content, vtblContentProperties)
+	if err == nil {
+		var resources *ole.IUnknown
+		if resources, err = getInterface(content, vtblContentTransfer); err == nil {
+			var keys *ole.IUnknown
+			keys, err = newKeyCollection(wpdObjectName, wpdObjectOriginalFileName, wpdObjectContentType, wpdObjectSize,
+				wpdObjectDateModified, wpdObjectIsHidden, wpdObjectIsSystem, wpdObjectCanDelete, wpdStorageCapacity, wpdStorageFreeSpace)
+			if err == nil {
+				w.device, w.content, w.properties, w.resources, w.keys = device, content, properties, resources, keys
+				w.info = info
+				w.objects = NewObjectIndex()
+				return nil
+			}
+			resources.Release()
+		}
+		properties.Release()
+	}
+	content.Release()
+	comCall(device, vtblDeviceClose)
+	device.Release()
+	return fmt.Errorf("获取设备属性接口失败: %w", err)
+}
+
+// ListFiles 递归列出 basePath 下的所有文件，路径形如 "内部共享存储空间\录音笔文件\a.opus"
+func (w *WPDNativeAccessor) ListFiles(basePath string) ([]*FileInfo, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.thread == nil {
+		return nil, ErrDeviceNotConnected
+	}
+
+	base := normalizeFakePath(basePath)
+	var files []*FileInfo
+	err := w.thread.do(func() error {
+		baseID, err := w.resolve(base)
+		if err != nil {
+			return err
+		}
+		return w.walk(baseID, base, "", &files)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("枚举设备文件失败: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	w.log.Debug("纯Go WPD访问器找到 %d 个文件: %s", len(files), basePath)
+	return files, nil
+}
+
+// walk 递归枚举目录对象，需在COM线程上调用
+func (w *WPDNativeAccessor) walk(dirID, dirPath, relDir string, files *[]*FileInfo) error {
+	children, err := w.children(dirID, dirPath)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		path := joinDevicePath(dirPath, child.Name)
+		rel := joinDevicePath(relDir, child.Name)
+		if child.IsDir {
+			if err := w.walk(child.ID, path, rel, files); err != nil {
+				w.log.Warn("枚举目录失败: %s, %v", path, err)
+			}
+			continue
+		}
+		*files = append(*files, &FileInfo{
+			Path:         path,
+			RelativePath: rel,
+			Name:         child.Name,
+			Size:         child.Size,
+			IsOpus:       utils.IsOpusFile(child.Name),
+			ModTime:      child.ModTime,
+			Attributes:   child.Attributes,
+		})
+	}
+	return nil
+}
+
+// ReadsNatively 访问器是否不经PowerShell直接从设备读取文件内容（纯Go WPD访问器及通过它读取的WPD COM访问器）
+func ReadsNatively(mtp MTPInterface) bool {
+	switch accessor := mtp.(type) {
+	case *WPDNativeAccessor:
+		return true
+	case *WPDComAccessor:
+		_, err := accessor.resourceAccessor()
+		return err == nil
+	}
+	return false
+}
+
+// ListDirectory 列出目录的直接子项，实现 DirectoryLister，供快速估算按子目录的项数推算文件数。
+// WPD 不保证目录的修改时间随子树中文件的变化更新，子目录的 ModTime 留空，也不实现 SubtreeSigner，不开启增量枚举
+func (w *WPDNativeAccessor) ListDirectory(dirPath string) ([]DirEntry, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.thread == nil {
+		return nil, ErrDeviceNotConnected
+	}
+
+	dir := normalizeFakePath(dirPath)
+	var entries []DirEntry
+	err := w.thread.do(func() error {
+		dirID, err := w.resolve(dir)
+		if err != nil {
+			return err
+		}
+		children, err := w.children(dirID, dir)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			entry := DirEntry{Name: child.Name, IsDir: child.IsDir, Attributes: child.Attributes}
+			if !entry.IsDir {
+				entry.Size = child.Size
+				entry.ModTime = child.ModTime
+			} else if ids, err := enumChildren(w.content, child.ID); err == nil {
+				entry.Items = len(ids)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出设备目录失败: %w", err)
+	}
+	return entries, nil
+}
+
+// children 读取目录对象的直接子对象并登记到索引，需在COM线程上调用
+func (w *WPDNativeAccessor) children(dirID, dirPath string) ([]*wpdObject, error) {
+	ids, err := enumChildren(w.content, dirID)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*wpdObject, 0, len(ids))
+	for _, id := range ids {
+		object, err := readObject(w.properties, w.keys, id)
+		if err != nil || object.Name == "" {
+			w.log.Debug("读取对象属性失败: %s, %v", id, err)
+			continue
+		}
+		w.objects.Set(joinDevicePath(dirPath, object.Name), id)
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// resolve 把设备路径解析为对象ID，索引中没有时从设备根对象逐级查找，需在COM线程上调用
+func (w *WPDNativeAccessor) resolve(path string) (string, error) {
+	if path == "" {
+		return wpdObjectDevice, nil
+	}
+	if id, err := w.objects.Lookup(path); err == nil {
+		return id, nil
+	}
+
+	id, dirPath := wpdObjectDevice, ""
+	for _, name := range strings.Split(path, "\\") {
+		children, err := w.children(id, dirPath)
+		if err != nil {
+			return "", err
+		}
+		dirPath = joinDevicePath(dirPath, name)
+		found := false
+		for _, child := range children {
+			if strings.EqualFold(child.Name, name) {
+				id, found = child.ID, true
+				break
+			}
+		}
+		if !found {
+			return "", NewMTPError(ERROR_FILE_NOT_FOUND, fmt.Sprintf("文件不存在: %s", path), nil)
+		}
+	}
+	return id, nil
+}
+
+// ListStorages 列出设备下的存储及其容量
+func (w *WPDNativeAccessor) ListStorages() []StorageInfo {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.thread == nil {
+		return nil
+	}
+
+	var storages []StorageInfo
+	err := w.thread.do(func() error {
+		children, err := w.children(wpdObjectDevice, "")
+		for _, child := range children {
+			if child.IsStorage {
+				storages = append(storages, StorageInfo{
+					ID:        child.ID,
+					Name:      child.Name,
+					Type:      ClassifyStorage(child.Name),
+					Capacity:  child.Capacity,
+					FreeSpace: child.FreeSpace,
+				})
+			}
+		}
+		return err
+	})
+	if err != nil {
+		w.log.Warn("列出设备存储失败: %v", err)
+	}
+	return storages
+}
+
+// GetFileStream 打开文件内容的读取流，返回的 *WPDFileStream 支持 Seek
+func (w *WPDNativeAccessor) GetFileStream(filePath string) (io.ReadCloser, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.thread == nil {
+		return nil, ErrDeviceNotConnected
+	}
+
+	var stream *ole.IUnknown
+	err := w.thread.do(func() error {
+		id, err := w.resolve(normalizeFakePath(filePath))
+		if err != nil {
+			return err
+		}
+		objectID := utf16Ptr(id)
+		var optimalSize uint32
+		stream, err = getInterface(w.resources, vtblResourcesGetStream, uintptr(unsafe.Pointer(objectID)),
+			uintptr(unsafe.Pointer(&wpdResourceDefault)), stgmRead, uintptr(unsafe.Pointer(&optimalSize)))
+		runtime.KeepAlive(objectID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("打开设备文件流失败: %s: %w", filePath, err)
+	}
+	return newWPDFileStream(w.thread, stream, filePath), nil
+}
+
+// ReadRange 打开设备文件流并定位到 off，读取 length 字节，实现 RangeReader
+// 设备驱动不支持定位时读取并丢弃 off 之前的内容；读取都在COM线程上串行执行
+func (w *WPDNativeAccessor) ReadRange(filePath string, off, length int64) ([]byte, error) {
+	if off < 0 || length < 0 {
+		return nil, fmt.Errorf("无效的读取范围: %d+%d", off, length)
+	}
+	stream, err := w.GetFileStream(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	if _, err := stream.(io.Seeker).Seek(off, io.SeekStart); err != nil {
+		if _, err := io.CopyN(io.Discard, stream, off); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("定位设备文件流失败: %s: %w", filePath, err)
+		}
+	}
+	data := make([]byte, length)
+	n, err := io.ReadFull(stream, data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// Exists 判断设备上的文件是否存在，实现 FileExister
+func (w *WPDNativeAccessor) Exists(filePath string) (bool, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.thread == nil {
+		return false, ErrDeviceNotConnected
+	}
+
+	path := normalizeFakePath(filePath)
+	err := w.thread.do(func() error {
+		id, err := w.resolve(path)
+		if err != nil {
+			return err
+		}
+		if _, err := readObject(w.properties, w.keys, id); err != nil {
+			w.objects.Remove(path)
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, ErrFileNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Close 关闭设备并结束COM线程
+func (w *WPDNativeAccessor) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closeLocked()
+	return nil
+}
+
+// closeLocked 释放COM对象并结束COM线程，调用方需持有 w.mutex
+func (w *WPDNativeAccessor) closeLocked() {
+	if w.thread == nil {
+		return
+	}
+	w.thread.do(func() error {
+		releaseAll(w.keys, w.resources, w.properties, w.content)
+		if w.device != nil {
+			comCall(w.device, vtblDeviceClose)
+			w.device.Release()
+		}
+		return nil
+	})
+	w.thread.stop()
+	w.thread = nil
+	w.device, w.content, w.properties, w.resources, w.keys = nil, nil, nil, nil, nil
+}
+
+// IsConnected 检查是否已连接
+func (w *WPDNativeAccessor) IsConnected() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.thread != nil
+}
+
+// GetDeviceInfo 获取设备信息
+func (w *WPDNativeAccessor) GetDeviceInfo() *DeviceInfo {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.info
+}
+
+// QuickStat 快速估算设备上的文件数和总大小
+func (w *WPDNativeAccessor) QuickStat() (int, int64, error) {
+	return EstimateFiles(w)
+}
+
+// GetDeviceDetails 获取设备详细信息，型号和序列号从设备信息中解析
+func (w *WPDNativeAccessor) GetDeviceDetails() (*DeviceDetails, error) {
+	if !w.IsConnected() {
+		return nil, ErrDeviceNotConnected
+	}
+	return NewDeviceDetails(w.GetDeviceInfo(), w.ListStorages()), nil
+}
@@ -0,0 +1,434 @@
+//go:build windows
+
+package device
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+	"golang.org/x/sys/windows"
+)
+
+// 纯Go调用WPD COM接口用到的类和接口ID，取自 PortableDeviceApi.h / PortableDeviceTypes.h
+var (
+	clsidPortableDeviceManager       = ole.NewGUID("{0AF10CEC-2ECD-4B92-9581-34F6AE0637F3}")
+	iidPortableDeviceManager         = ole.NewGUID("{A1567595-4C2F-4574-A6FA-ECEF917B9A40}")
+	clsidPortableDeviceFTM           = ole.NewGUID("{F7C0039A-4762-488A-B4B3-760EF9A1BA9B}")
+	iidPortableDevice                = ole.NewGUID("{625E2DF8-6392-4CF0-9AD1-3CFA5F17775C}")
+	clsidPortableDeviceValues        = ole.NewGUID("{0C15D503-D017-47CE-9016-7B3F978721CC}")
+	iidPortableDeviceValues          = ole.NewGUID("{6848F6F2-3155-4F86-B6F5-263EEEAB3143}")
+	clsidPortableDeviceKeyCollection = ole.NewGUID("{DE2D022D-2480-43BE-97F0-D1FA2CF98F4F}")
+	iidPortableDeviceKeyCollection   = ole.NewGUID("{DADA2357-E0AD-492E-98DB-DD61C53BA353}")
+
+	// wpdContentTypeFolder 文件夹对象的内容类型
+	wpdContentTypeFolder = ole.NewGUID("{27E2E392-A111-48E0-AB0C-E17705A05F85}")
+	// wpdContentTypeFunctionalObject 功能对象（存储）的内容类型
+	wpdContentTypeFunctionalObject = ole.NewGUID("{99ED0160-17FF-4C44-9D98-1D7A6F941921}")
+)
+
+// propertyKey 对应 PROPERTYKEY 结构
+type propertyKey struct {
+	fmtid ole.GUID
+	pid   uint32
+}
+
+var (
+	wpdClientInfoFmtID = *ole.NewGUID("{204D9F0C-2292-4080-9F42-40664E70F859}")
+	wpdObjectFmtID     = *ole.NewGUID("{EF6B490D-5CD8-437A-AFFC-DA8B60EE4A3C}")
+	wpdStorageFmtID    = *ole.NewGUID("{01A3057A-74D6-4E80-BEA7-DC4C212CE50A}")
+
+	wpdClientName          = propertyKey{wpdClientInfoFmtID, 2}
+	wpdClientMajorVersion  = propertyKey{wpdClientInfoFmtID, 3}
+	wpdClientDesiredAccess = propertyKey{wpdClientInfoFmtID, 9}
+
+	wpdObjectName             = propertyKey{wpdObjectFmtID, 4}
+	wpdObjectContentType      = propertyKey{wpdObjectFmtID, 7}
+	wpdObjectIsHidden         = propertyKey{wpdObjectFmtID, 9}
+	wpdObjectIsSystem         = propertyKey{wpdObjectFmtID, 10}
+	wpdObjectSize             = propertyKey{wpdObjectFmtID, 11}
+	wpdObjectOriginalFileName = propertyKey{wpdObjectFmtID, 12}
+	wpdObjectDateModified     = propertyKey{wpdObjectFmtID, 19}
+	wpdObjectCanDelete        = propertyKey{wpdObjectFmtID, 26}
+
+	wpdStorageCapacity  = propertyKey{wpdStorageFmtID, 4}
+	wpdStorageFreeSpace = propertyKey{wpdStorageFmtID, 5}
+
+	// wpdResourceDefault 对象的默认资源，即文件内容
+	wpdResourceDefault = propertyKey{*ole.NewGUID("{E81E79BE-34F0-41BF-B53F-F1A06AE87842}"), 0}
+)
+
+// 各接口方法在虚表中的序号（前三个为 IUnknown 的方法）
+const (
+	vtblManagerGetDevices        = 3
+	vtblManagerGetFriendlyName   = 5
+	vtblDeviceOpen               = 3
+	vtblDeviceContent            = 5
+	vtblDeviceClose              = 8
+	vtblContentEnumObjects       = 3
+	vtblContentProperties        = 4
+	vtblContentTransfer          = 5
+	vtblEnumNext                 = 3
+	vtblPropertiesGetValues      = 5
+	vtblResourcesGetStream       = 5
+	vtblKeysAdd                  = 5
+	vtblValuesGetValue           = 6
+	vtblValuesSetStringValue     = 7
+	vtblValuesGetStringValue     = 8
+	vtblValuesSetUnsignedInteger = 9
+	vtblValuesGetUnsignedLarge   = 14
+	vtblValuesGetBoolValue       = 24
+	vtblValuesGetGuidValue       = 28
+	vtblStreamRead               = 3
+	vtblStreamSeek               = 5
+)
+
+// wpdObjectDevice 设备根对象的ID，存储是它的子对象
+const wpdObjectDevice = "DEVICE"
+
+const (
+	stgmRead    = 0
+	genericRead = 0x80000000
+	vtDate      = 7
+)
+
+var procPropVariantClear = windows.NewLazySystemDLL("ole32.dll").NewProc("PropVariantClear")
+
+// propVariant 对应 PROPVARIANT 结构，只读取其中的 VT_DATE 值
+type propVariant struct {
+	vt  uint16
+	_   [3]uint16
+	val [2]uint64
+}
+
+// comCall 调用 COM 对象虚表中第 method 个方法，失败的 HRESULT 转换为错误
+func comCall(obj *ole.IUnknown, method int, args ...uintptr) (uintptr, error) {
+	vtbl := (*[64]uintptr)(unsafe.Pointer(obj.RawVTable))
+	hr, _, _ := syscall.SyscallN(vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(obj))}, args...)...)
+	if int32(hr) < 0 {
+		return hr, HRESULTError(uint32(hr))
+	}
+	return hr, nil
+}
+
+// comThread 固定在一个系统线程上执行所有WPD COM调用，避免goroutine切换线程导致COM对象跨线程使用
+type comThread struct {
+	calls    chan func()
+	done     chan struct{} // stop 后关闭
+	stopOnce sync.Once
+}
+
+// startCOMThread 启动COM线程并初始化COM（多线程套间）
+func startCOMThread() (*comThread, error) {
+	t := &comThread{calls: make(chan func()), done: make(chan struct{})}
+	initErr := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
+			// S_FALSE 表示该线程已初始化过，同样需要配对的 CoUninitialize
+			if oleErr, ok := err.(*ole.OleError); !ok || oleErr.Code() != 1 {
+				initErr <- fmt.Errorf("COM初始化失败: %w", err)
+				return
+			}
+		}
+		defer ole.CoUninitialize()
+
+		initErr <- nil
+		for {
+			select {
+			case fn := <-t.calls:
+				fn()
+			case <-t.done:
+				return
+			}
+		}
+	}()
+
+	if err := <-initErr; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// do 在COM线程上执行 fn 并等待其完成
+func (t *comThread) do(fn func() error) error {
+	select {
+	case <-t.done:
+		return ErrDeviceNotConnected
+	default:
+	}
+
+	result := make(chan error, 1)
+	select {
+	case t.calls <- func() { result <- fn() }:
+	case <-t.done:
+		return ErrDeviceNotConnected
+	}
+	return <-result
+}
+
+// stop 结束COM线程，之后的调用返回设备未连接；COM线程阻塞在调用中时不等待其返回
+func (t *comThread) stop() {
+	t.stopOnce.Do(func() { close(t.done) })
+}
+
+// releaseAll 释放非空的COM对象
+func releaseAll(objects ...*ole.IUnknown) {
+	for _, obj := range objects {
+		if obj != nil {
+			obj.Release()
+		}
+	}
+}
+
+// utf16Ptr 把字符串转换为以0结尾的UTF-16指针
+func utf16Ptr(s string) *uint16 {
+	p, err := windows.UTF16PtrFromString(s)
+	if err != nil {
+		p, _ = windows.UTF16PtrFromString("")
+	}
+	return p
+}
+
+// takeCOMString 读取由COM分配的字符串并释放其内存
+func takeCOMString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	s := windows.UTF16PtrToString(p)
+	ole.CoTaskMemFree(uintptr(unsafe.Pointer(p)))
+	return s
+}
+
+// managerDevices 列出WPD设备管理器中所有设备的PnP设备ID
+func managerDevices(manager *ole.IUnknown) ([]string, error) {
+	var count uint32
+	if _, err := comCall(manager, vtblManagerGetDevices, 0, uintptr(unsafe.Pointer(&count))); err != nil {
+		return nil, fmt.Errorf("获取设备数量失败: %w", err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	ids := make([]*uint16, count)
+	if _, err := comCall(manager, vtblManagerGetDevices, uintptr(unsafe.Pointer(&ids[0])), uintptr(unsafe.Pointer(&count))); err != nil {
+		return nil, fmt.Errorf("获取设备列表失败: %w", err)
+	}
+	devices := make([]string, 0, count)
+	for _, id := range ids[:count] {
+		devices = append(devices, takeCOMString(id))
+	}
+	return devices, nil
+}
+
+// managerFriendlyName 读取设备的友好名称
+func managerFriendlyName(manager *ole.IUnknown, pnpID string) (string, error) {
+	id := utf16Ptr(pnpID)
+	var length uint32
+	if _, err := comCall(manager, vtblManagerGetFriendlyName, uintptr(unsafe.Pointer(id)), 0, uintptr(unsafe.Pointer(&length))); err != nil {
+		return "", err
+	}
+	if length == 0 {
+		return "", nil
+	}
+
+	buf := make([]uint16, length)
+	if _, err := comCall(manager, vtblManagerGetFriendlyName, uintptr(unsafe.Pointer(id)), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&length))); err != nil {
+		return "", err
+	}
+	runtime.KeepAlive(id)
+	return windows.UTF16ToString(buf), nil
+}
+
+// newClientInfo 创建打开设备时的客户端信息，只申请读取权限
+func newClientInfo() (*ole.IUnknown, error) {
+	values, err := ole.CreateInstance(clsidPortableDeviceValues, iidPortableDeviceValues)
+	if err != nil {
+		return nil, fmt.Errorf("创建客户端信息失败: %w", err)
+	}
+
+	name := utf16Ptr("record_center")
+	_, err = comCall(values, vtblValuesSetStringValue, uintptr(unsafe.Pointer(&wpdClientName)), uintptr(unsafe.Pointer(name)))
+	runtime.KeepAlive(name)
+	if err == nil {
+		_, err = comCall(values, vtblValuesSetUnsignedInteger, uintptr(unsafe.Pointer(&wpdClientMajorVersion)), 1)
+	}
+	if err == nil {
+		_, err = comCall(values, vtblValuesSetUnsignedInteger, uintptr(unsafe.Pointer(&wpdClientDesiredAccess)), genericRead)
+	}
+	if err != nil {
+		values.Release()
+		return nil, fmt.Errorf("设置客户端信息失败: %w", err)
+	}
+	return values, nil
+}
+
+// newKeyCollection 创建读取对象属性时请求的属性集合
+func newKeyCollection(keys ...propertyKey) (*ole.IUnknown, error) {
+	collection, err := ole.CreateInstance(clsidPortableDeviceKeyCollection, iidPortableDeviceKeyCollection)
+	if err != nil {
+		return nil, fmt.Errorf("创建属性集合失败: %w", err)
+	}
+	for i := range keys {
+		if _, err := comCall(collection, vtblKeysAdd, uintptr(unsafe.Pointer(&keys[i]))); err != nil {
+			collection.Release()
+			return nil, fmt.Errorf("添加属性失败: %w", err)
+		}
+	}
+	return collection, nil
+}
+
+// getInterface 调用返回接口指针的方法
+func getInterface(obj *ole.IUnknown, method int, args ...uintptr) (*ole.IUnknown, error) {
+	var out *ole.IUnknown
+	if _, err := comCall(obj, method, append(args, uintptr(unsafe.Pointer(&out)))...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// enumChildren 列出对象的直接子对象ID
+func enumChildren(content *ole.IUnknown, parentID string) ([]string, error) {
+	parent := utf16Ptr(parentID)
+	enum, err := getInterface(content, vtblContentEnumObjects, 0, uintptr(unsafe.Pointer(parent)), 0)
+	runtime.KeepAlive(parent)
+	if err != nil {
+		return nil, err
+	}
+	defer enum.Release()
+
+	var children []string
+	batch := make([]*uint16, 32)
+	for {
+		var fetched uint32
+		if _, err := comCall(enum, vtblEnumNext, uintptr(len(batch)), uintptr(unsafe.Pointer(&batch[0])), uintptr(unsafe.Pointer(&fetched))); err != nil {
+			return children, err
+		}
+		if fetched == 0 {
+			return children, nil
+		}
+		for _, id := range batch[:fetched] {
+			children = append(children, takeCOMString(id))
+		}
+	}
+}
+
+// wpdObject 从设备读取到的对象属性
+type wpdObject struct {
+	ID         string
+	Name       string
+	IsDir      bool
+	IsStorage  bool
+	Size       int64
+	ModTime    time.Time
+	Attributes FileAttributes
+	Capacity   int64 // 存储的总容量，其他对象为0
+	FreeSpace  int64 // 存储的可用空间，其他对象为0
+}
+
+// readObject 读取对象的名称、类型、大小、修改时间和属性，单项属性读取失败时留空
+func readObject(properties, keys *ole.IUnknown, objectID string) (*wpdObject, error) {
+	id := utf16Ptr(objectID)
+	values, err := getInterface(properties, vtblPropertiesGetValues, uintptr(unsafe.Pointer(id)), uintptr(unsafe.Pointer(keys)))
+	runtime.KeepAlive(id)
+	if err != nil {
+		return nil, err
+	}
+	defer values.Release()
+
+	object := &wpdObject{ID: objectID}
+	object.Name = valuesString(values, wpdObjectOriginalFileName)
+	if object.Name == "" {
+		object.Name = valuesString(values, wpdObjectName)
+	}
+	if contentType, ok := valuesGUID(values, wpdObjectContentType); ok {
+		object.IsStorage = ole.IsEqualGUID(&contentType, wpdContentTypeFunctionalObject)
+		object.IsDir = object.IsStorage || ole.IsEqualGUID(&contentType, wpdContentTypeFolder)
+	}
+	if size, ok := valuesUint64(values, wpdObjectSize); ok && size <= math.MaxInt64 {
+		object.Size = int64(size)
+	}
+	object.ModTime = valuesDate(values, wpdObjectDateModified)
+	if valuesBool(values, wpdObjectIsHidden) {
+		object.Attributes |= AttrHidden
+	}
+	if valuesBool(values, wpdObjectIsSystem) {
+		object.Attributes |= AttrSystem
+	}
+	if canDelete, err := valuesBoolOK(values, wpdObjectCanDelete); err == nil && !canDelete && !object.IsDir {
+		object.Attributes |= AttrReadOnly
+	}
+	if object.IsStorage {
+		if capacity, ok := valuesUint64(values, wpdStorageCapacity); ok && capacity <= math.MaxInt64 {
+			object.Capacity = int64(capacity)
+		}
+		if free, ok := valuesUint64(values, wpdStorageFreeSpace); ok && free <= math.MaxInt64 {
+			object.FreeSpace = int64(free)
+		}
+	}
+	return object, nil
+}
+
+// valuesString 读取字符串属性，不存在时返回空
+func valuesString(values *ole.IUnknown, key propertyKey) string {
+	var p *uint16
+	if _, err := comCall(values, vtblValuesGetStringValue, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(&p))); err != nil {
+		return ""
+	}
+	return takeCOMString(p)
+}
+
+// valuesUint64 读取无符号64位整数属性
+func valuesUint64(values *ole.IUnknown, key propertyKey) (uint64, bool) {
+	var v uint64
+	_, err := comCall(values, vtblValuesGetUnsignedLarge, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(&v)))
+	return v, err == nil
+}
+
+// valuesGUID 读取GUID属性
+func valuesGUID(values *ole.IUnknown, key propertyKey) (ole.GUID, bool) {
+	var v ole.GUID
+	_, err := comCall(values, vtblValuesGetGuidValue, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(&v)))
+	return v, err == nil
+}
+
+// valuesBoolOK 读取布尔属性，不存在时返回错误
+func valuesBoolOK(values *ole.IUnknown, key propertyKey) (bool, error) {
+	var v int32
+	_, err := comCall(values, vtblValuesGetBoolValue, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(&v)))
+	return v != 0, err
+}
+
+// valuesBool 读取布尔属性，不存在时为false
+func valuesBool(values *ole.IUnknown, key propertyKey) bool {
+	v, err := valuesBoolOK(values, key)
+	return err == nil && v
+}
+
+// valuesDate 读取 VT_DATE 类型的时间属性，不存在或类型不符时返回零值
+func valuesDate(values *ole.IUnknown, key propertyKey) time.Time {
+	var v propVariant
+	if _, err := comCall(values, vtblValuesGetValue, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(&v))); err != nil {
+		return time.Time{}
+	}
+	defer procPropVariantClear.Call(uintptr(unsafe.Pointer(&v)))
+	if v.vt != vtDate {
+		return time.Time{}
+	}
+	return oleDateToTime(math.Float64frombits(v.val[0]))
+}
+
+// oleDateToTime 把OLE自动化日期（自1899-12-30起的天数，本地时间）转换为时间
+func oleDateToTime(days float64) time.Time {
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local)
+	whole := math.Trunc(days)
+	fraction := math.Abs(days - whole)
+	return base.AddDate(0, 0, int(whole)).Add(time.Duration(fraction * float64(24*time.Hour)))
+}
@@ -0,0 +1,94 @@
+//go:build windows
+
+package device
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// 手动验证 WPDFileStream 的环境变量：连接录音笔后设置设备名、设备上的文件路径，
+// 以及用文件管理器从设备复制出的同一文件，例如
+//
+//	set RECORD_CENTER_WPD_DEVICE=SR302
+//	set RECORD_CENTER_WPD_FILE=内部共享存储空间\录音笔文件\rec.opus
+//	set RECORD_CENTER_WPD_REFERENCE=D:\tmp\rec.opus
+//	go test ./internal/device -run TestWPDFileStream_Device -v
+const (
+	wpdDeviceEnv    = "RECORD_CENTER_WPD_DEVICE"
+	wpdFileEnv      = "RECORD_CENTER_WPD_FILE"
+	wpdReferenceEnv = "RECORD_CENTER_WPD_REFERENCE"
+)
+
+// TestWPDFileStream_Device 在真实设备上验证读取内容与文件管理器复制出的一致，Seek 后从正确的偏移读取
+func TestWPDFileStream_Device(t *testing.T) {
+	deviceName, filePath, referencePath := os.Getenv(wpdDeviceEnv), os.Getenv(wpdFileEnv), os.Getenv(wpdReferenceEnv)
+	if deviceName == "" || filePath == "" || referencePath == "" {
+		t.Skipf("未设置 %s、%s、%s，跳过真实设备验证", wpdDeviceEnv, wpdFileEnv, wpdReferenceEnv)
+	}
+	reference, err := os.ReadFile(referencePath)
+	if err != nil {
+		t.Fatalf("读取参照文件失败: %v", err)
+	}
+	if len(reference) < 2 {
+		t.Fatalf("参照文件太小，无法验证定位: %d 字节", len(reference))
+	}
+
+	accessor := NewWPDNativeAccessor(logger.NewLogger(false))
+	if err := accessor.ConnectToDevice(deviceName, "", ""); err != nil {
+		t.Fatalf("连接设备失败: %v", err)
+	}
+	defer accessor.Close()
+
+	open := func() *WPDFileStream {
+		stream, err := accessor.GetFileStream(filePath)
+		if err != nil {
+			t.Fatalf("打开设备文件流失败: %v", err)
+		}
+		return stream.(*WPDFileStream)
+	}
+
+	stream := open()
+	content, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("读取设备文件失败: %v", err)
+	}
+	if !bytes.Equal(content, reference) {
+		t.Errorf("读取内容与文件管理器复制出的不一致: %d 字节，参照 %d 字节", len(content), len(reference))
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("关闭文件流失败: %v", err)
+	}
+
+	stream = open()
+	defer stream.Close()
+	offsets := []struct {
+		offset int64
+		whence int
+		want   int64
+	}{
+		{int64(len(reference) / 2), io.SeekStart, int64(len(reference) / 2)},
+		{-1, io.SeekEnd, int64(len(reference) - 1)},
+		{0, io.SeekStart, 0},
+	}
+	for _, tt := range offsets {
+		pos, err := stream.Seek(tt.offset, tt.whence)
+		if err != nil {
+			t.Fatalf("Seek(%d, %d) 失败: %v", tt.offset, tt.whence, err)
+		}
+		if pos != tt.want {
+			t.Errorf("Seek(%d, %d) = %d，期望 %d", tt.offset, tt.whence, pos, tt.want)
+		}
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(stream, buf); err != nil {
+			t.Fatalf("定位后读取失败: %v", err)
+		}
+		if buf[0] != reference[tt.want] {
+			t.Errorf("偏移 %d 处读到 %#x，期望 %#x", tt.want, buf[0], reference[tt.want])
+		}
+	}
+}
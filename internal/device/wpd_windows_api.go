@@ -4,11 +4,11 @@ package device
 
 import (
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 
 	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/psexec"
 )
 
 // WindowsWPDService 使用Windows WPD服务获取准确文件大小
@@ -61,13 +61,7 @@ func (w *WindowsWPDService) GetObjectSizeUsingWindowsAPI(objectID, filename stri
 		return size, nil
 	}
 
-	// 方法4: 基于文件名的智能分析
-	// 对于会议录音文件，基于用户反馈使用更准确的估算
-	if size, err := w.getIntelligentEstimate(filename); err == nil && size > 0 {
-		w.log.Info("智能估算文件大小: %s -> %d 字节", filename, size)
-		return size, nil
-	}
-
+	// 这里只返回真实读取的大小，估算由调用方兜底并标记为估算值
 	return 0, fmt.Errorf("所有方法都无法获取文件大小")
 }
 
@@ -93,14 +87,14 @@ if ($device) {
 }
 `, strings.Replace(filename, ".opus", "", -1))
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		w.log.Debug("WMI查询失败: %v", err)
 		return 0, err
 	}
 
-	outputStr := strings.TrimSpace(string(output))
+	outputStr := strings.TrimSpace(output)
 	if size, err := strconv.ParseInt(outputStr, 10, 64); err == nil && size > 0 {
 		return size, nil
 	}
@@ -192,14 +186,14 @@ if ($portable) {
 }
 `, filename, filename)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		w.log.Debug("高级Shell API调用失败: %v", err)
 		return 0, err
 	}
 
-	outputStr := strings.TrimSpace(string(output))
+	outputStr := strings.TrimSpace(output)
 	if size, err := strconv.ParseInt(outputStr, 10, 64); err == nil && size > 0 {
 		return size, nil
 	}
@@ -280,14 +274,14 @@ try {
 }
 `)
 
-	cmd := exec.Command("powershell", "-ExecutionPolicy", "Bypass", "-Command", script)
-	output, err := cmd.CombinedOutput()
+	cmd := psexec.Command("-ExecutionPolicy", "Bypass", "-Command", script)
+	output, err := psexec.CombinedOutput(cmd)
 	if err != nil {
 		w.log.Debug("WPD COM调用失败: %v", err)
 		return 0, err
 	}
 
-	outputStr := strings.TrimSpace(string(output))
+	outputStr := strings.TrimSpace(output)
 	if size, err := strconv.ParseInt(outputStr, 10, 64); err == nil && size > 0 {
 		return size, nil
 	}
@@ -295,41 +289,6 @@ try {
 	return 0, fmt.Errorf("WPD COM调用未找到文件大小信息")
 }
 
-// getIntelligentEstimate 基于文件名和用户反馈的智能估算
-func (w *WindowsWPDService) getIntelligentEstimate(filename string) (int64, error) {
-	w.log.Debug("使用智能估算算法: %s", filename)
-
-	filename = strings.ToLower(filename)
-
-	// 基于用户反馈：会议录音文件实际是几百MB
-	if strings.Contains(filename, "董总会谈") ||
-	   strings.Contains(filename, "会议") ||
-	   strings.Contains(filename, "meeting") ||
-	   strings.Contains(filename, "总会") ||
-	   strings.Contains(filename, "董事") {
-		// 根据用户反馈，会议录音通常是几百MB
-		// 使用200MB作为估算值，这更接近实际情况
-		return 200 * 1024 * 1024, nil
-	}
-
-	// 基于录音时长的估算
-	if len(filename) > 8 {
-		// 解析文件名中的日期时间信息
-		if strings.Contains(filename, "2025") {
-			// 2025年的录音，假设是较新的长录音
-			return 150 * 1024 * 1024, nil
-		}
-	}
-
-	// 基于文件名长度的估算（长文件名通常表示长录音）
-	if len(filename) > 20 {
-		return 100 * 1024 * 1024, nil
-	}
-
-	// 默认值：比原来的5MB更合理的估算
-	return 30 * 1024 * 1024, nil
-}
-
 // Close 关闭服务
 func (w *WindowsWPDService) Close() {
 	w.connected = false
@@ -0,0 +1,346 @@
+// Package doctor 逐项诊断备份所需的运行环境，给出结论和修复建议
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/psexec"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// Status 单项检查的结论
+type Status int
+
+const (
+	// StatusOK 检查通过
+	StatusOK Status = iota
+	// StatusWarn 可以运行，但可能影响部分功能
+	StatusWarn
+	// StatusFail 无法正常备份，需要修复
+	StatusFail
+)
+
+// String 返回结论的显示文本
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarn:
+		return "警告"
+	default:
+		return "失败"
+	}
+}
+
+// Result 单项检查的结果
+type Result struct {
+	Name   string // 检查项名称
+	Status Status
+	Detail string // 检查到的情况
+	Advice string // 修复建议，通过时为空
+}
+
+// Runner 执行外部命令并返回解码后的输出，测试时替换为模拟执行器
+type Runner interface {
+	Run(exe string, args ...string) (string, error)
+}
+
+// DefaultTimeout 每条诊断命令的超时时间
+const DefaultTimeout = 30 * time.Second
+
+// ExecRunner 使用 os/exec 执行命令，超过 Timeout 时终止
+type ExecRunner struct {
+	Timeout time.Duration
+}
+
+// Run 执行命令，返回合并的标准输出和标准错误
+func (r ExecRunner) Run(exe string, args ...string) (string, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := psexec.CombinedOutput(exec.CommandContext(ctx, exe, psexec.EnsureUTF8Args(args)...))
+	if ctx.Err() != nil {
+		return output, fmt.Errorf("执行超时（%s）", timeout)
+	}
+	return output, err
+}
+
+// runScript 用 exe 执行 PowerShell 脚本，返回去掉首尾空白的输出
+func runScript(r Runner, exe, script string) (string, error) {
+	output, err := r.Run(exe, "-NoProfile", "-NonInteractive", "-Command", script)
+	output = strings.TrimSpace(output)
+	if err != nil {
+		if output != "" {
+			return output, fmt.Errorf("%w: %s", err, firstLine(output))
+		}
+		return output, err
+	}
+	return output, nil
+}
+
+// firstLine 返回多行输出的第一行
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(line)
+}
+
+// CheckPowerShell 按顺序探测 candidates 中的 PowerShell，返回检查结果和第一个可用的可执行文件，都不可用时可执行文件为空
+// 版本低于 5.0 时给出警告
+func CheckPowerShell(r Runner, candidates []string) (Result, string) {
+	result := Result{Name: "PowerShell"}
+	var failures []string
+	for _, exe := range candidates {
+		version, err := runScript(r, exe, "$PSVersionTable.PSVersion.ToString()")
+		if err != nil || version == "" {
+			failures = append(failures, fmt.Sprintf("%s: %v", exe, err))
+			continue
+		}
+		version = firstLine(version)
+
+		result.Detail = fmt.Sprintf("%s %s", exe, version)
+		if major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0]); err == nil && major < 5 {
+			result.Status = StatusWarn
+			result.Advice = "PowerShell 版本过低，请安装 Windows Management Framework 5.1 或 PowerShell 7"
+		}
+		return result, exe
+	}
+
+	result.Status = StatusFail
+	result.Detail = "未找到可用的PowerShell: " + strings.Join(failures, "; ")
+	result.Advice = "请确认 powershell.exe 在 PATH 中，或安装 PowerShell 7（pwsh）后在配置的 powershell.fallback_order 中加入 pwsh"
+	return result, ""
+}
+
+// CheckExecutionPolicy 检查执行策略，Restricted 和 AllSigned 会阻止运行未签名的脚本
+func CheckExecutionPolicy(r Runner, exe string) Result {
+	result := Result{Name: "执行策略"}
+	if exe == "" {
+		return unavailable(result)
+	}
+
+	policy, err := runScript(r, exe, "Get-ExecutionPolicy")
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("读取执行策略失败: %v", err)
+		result.Advice = "请确认当前用户可以运行 PowerShell，或联系管理员检查组策略"
+		return result
+	}
+
+	policy = firstLine(policy)
+	result.Detail = policy
+	switch strings.ToLower(policy) {
+	case "restricted", "allsigned":
+		result.Status = StatusWarn
+		result.Advice = "执行策略会阻止未签名的脚本，请执行 Set-ExecutionPolicy -Scope CurrentUser RemoteSigned，或在配置中保持 powershell.execution_policy: Bypass"
+	}
+	return result
+}
+
+// CheckShellCOM 检查能否创建 Shell.Application COM 对象，设备枚举和文件复制依赖它
+func CheckShellCOM(r Runner, exe string) Result {
+	result := Result{Name: "Shell.Application COM"}
+	if exe == "" {
+		return unavailable(result)
+	}
+
+	output, err := runScript(r, exe, "$shell = New-Object -ComObject Shell.Application; if ($shell -ne $null) { 'OK' }")
+	if err != nil || firstLine(output) != "OK" {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("无法创建 Shell.Application: %v", errOrOutput(err, output))
+		result.Advice = "请不要在服务账户或受限会话中运行；如仍失败，以管理员身份执行 regsvr32 shell32.dll 后重新登录"
+		return result
+	}
+	result.Detail = "可以创建"
+	return result
+}
+
+// CheckWMI 检查能否通过 WMI 查询系统信息，设备检测依赖 Win32_PnPEntity
+func CheckWMI(r Runner, exe string) Result {
+	result := Result{Name: "WMI查询"}
+	if exe == "" {
+		return unavailable(result)
+	}
+
+	output, err := runScript(r, exe, "(Get-WmiObject Win32_OperatingSystem).Caption")
+	if err != nil || output == "" {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("WMI查询失败: %v", errOrOutput(err, output))
+		result.Advice = "请确认 Windows Management Instrumentation（Winmgmt）服务已启动；PowerShell 7 不支持 Get-WmiObject，请在 powershell.fallback_order 中把 powershell 放在前面"
+		return result
+	}
+	result.Detail = firstLine(output)
+	return result
+}
+
+// CheckTargetWritable 检查目标目录能否创建并写入文件
+func CheckTargetWritable(dir string) Result {
+	result := Result{Name: "目标目录", Detail: dir}
+	advice := "请确认目标目录所在的磁盘已连接且当前用户有写权限，或用 --target 指定其他目录"
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("无法创建 %s: %v", dir, err)
+		result.Advice = advice
+		return result
+	}
+
+	probe, err := os.CreateTemp(dir, ".doctor_*.tmp")
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("无法写入 %s: %v", dir, err)
+		result.Advice = advice
+		return result
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		result.Detail = abs + " 可写"
+	}
+	return result
+}
+
+// deviceNotFound 设备检测脚本未找到设备时的输出
+const deviceNotFound = "NOT_FOUND"
+
+// driverNotInstalled 设备管理器中驱动未安装的错误码（CM_PROB_FAILED_INSTALL）
+const driverNotInstalled = 28
+
+// CheckDevice 检查系统是否识别到 VID/PID 对应的设备及其驱动状态
+func CheckDevice(r Runner, exe, name, vid, pid string) Result {
+	result := Result{Name: "设备识别"}
+	if exe == "" {
+		return unavailable(result)
+	}
+
+	script := fmt.Sprintf(`$d = Get-WmiObject Win32_PnPEntity | Where-Object { $_.DeviceID -like "*VID_%s*" -and $_.DeviceID -like "*PID_%s*" } | Select-Object -First 1
+if ($d) { "$($d.ConfigManagerErrorCode)|$($d.Name)" } else { "%s" }`, vid, pid, deviceNotFound)
+	output, err := runScript(r, exe, script)
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("查询设备失败: %v", err)
+		result.Advice = "请先修复上面的 WMI 问题"
+		return result
+	}
+
+	line := firstLine(output)
+	if line == deviceNotFound || line == "" {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("未识别到 %s (VID:%s, PID:%s)", name, vid, pid)
+		result.Advice = "请用数据线连接设备并解锁，在设备上选择“文件传输（MTP）”模式；仍未识别时换一根支持数据传输的线或USB口，并用 --detect 确认 VID/PID"
+		return result
+	}
+
+	codeText, deviceName, _ := strings.Cut(line, "|")
+	code, err := strconv.Atoi(strings.TrimSpace(codeText))
+	if err != nil {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("无法解析设备状态: %s", line)
+		result.Advice = "请在设备管理器中确认该设备的状态"
+		return result
+	}
+	switch {
+	case code == 0:
+		result.Detail = fmt.Sprintf("已识别: %s", deviceName)
+	case code == driverNotInstalled:
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("%s 的驱动未安装", deviceName)
+		result.Advice = "请在设备管理器中更新该设备的驱动（选择“MTP USB 设备”），或安装厂商提供的驱动"
+	default:
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("%s 状态异常（设备管理器错误码 %d）", deviceName, code)
+		result.Advice = "请在设备管理器中查看该设备的状态，尝试重新插拔或卸载后重新识别"
+	}
+	return result
+}
+
+// CheckFileEstimate 快速估算设备上的文件数和总大小，让用户在完整枚举前对备份量有个预期
+func CheckFileEstimate(quickStat func() (int, int64, error)) Result {
+	result := Result{Name: "文件估算"}
+	count, size, err := quickStat()
+	if err != nil {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("无法快速估算: %v", err)
+		result.Advice = "不影响备份，可用 --check 完整枚举查看文件数和大小"
+		return result
+	}
+	result.Detail = fmt.Sprintf("设备上约 %d 个文件，约 %s", count, utils.FormatBytes(size))
+	return result
+}
+
+// unavailable PowerShell 不可用时依赖它的检查无法进行
+func unavailable(result Result) Result {
+	result.Status = StatusFail
+	result.Detail = "PowerShell不可用，无法检查"
+	result.Advice = "请先修复 PowerShell 问题"
+	return result
+}
+
+// errOrOutput 返回错误，没有错误时返回意外的输出
+func errOrOutput(err error, output string) interface{} {
+	if err != nil {
+		return err
+	}
+	return fmt.Sprintf("意外的输出 %q", firstLine(output))
+}
+
+// Options 一次完整诊断的参数
+type Options struct {
+	Runner               Runner
+	PowerShellCandidates []string // 按顺序探测的 PowerShell 可执行文件
+	TargetDirectory      string
+	DeviceName           string
+	VID                  string
+	PID                  string
+	// QuickStat 快速估算设备文件，设置且设备识别未失败时执行文件估算检查
+	QuickStat func() (int, int64, error)
+}
+
+// Run 依次执行所有检查
+func Run(opts Options) []Result {
+	runner := opts.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	candidates := opts.PowerShellCandidates
+	if len(candidates) == 0 {
+		candidates = []string{"powershell", "pwsh"}
+	}
+
+	psResult, exe := CheckPowerShell(runner, candidates)
+	deviceResult := CheckDevice(runner, exe, opts.DeviceName, opts.VID, opts.PID)
+	results := []Result{
+		psResult,
+		CheckExecutionPolicy(runner, exe),
+		CheckShellCOM(runner, exe),
+		CheckWMI(runner, exe),
+		CheckTargetWritable(opts.TargetDirectory),
+		deviceResult,
+	}
+	if opts.QuickStat != nil && deviceResult.Status != StatusFail {
+		results = append(results, CheckFileEstimate(opts.QuickStat))
+	}
+	return results
+}
+
+// Conclusion 所有检查中最严重的结论
+func Conclusion(results []Result) Status {
+	worst := StatusOK
+	for _, result := range results {
+		if result.Status > worst {
+			worst = result.Status
+		}
+	}
+	return worst
+}
@@ -0,0 +1,214 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mockResponse 模拟命令的输出
+type mockResponse struct {
+	output string
+	err    error
+}
+
+// mockRunner 按可执行文件和脚本中的关键字返回预设输出，未预设的命令返回错误
+type mockRunner struct {
+	responses map[string]mockResponse // 键为 "可执行文件|脚本关键字"
+}
+
+func (m *mockRunner) Run(exe string, args ...string) (string, error) {
+	script := args[len(args)-1]
+	for key, response := range m.responses {
+		keyExe, keyword, _ := strings.Cut(key, "|")
+		if keyExe == exe && strings.Contains(script, keyword) {
+			return response.output, response.err
+		}
+	}
+	return "", errors.New("exec: \"" + exe + "\": executable file not found in %PATH%")
+}
+
+// healthyResponses 各项检查都通过时的输出
+func healthyResponses() map[string]mockResponse {
+	return map[string]mockResponse{
+		"powershell|PSVersion":             {output: "5.1.19041.1682\r\n"},
+		"powershell|Get-ExecutionPolicy":   {output: "RemoteSigned\r\n"},
+		"powershell|Shell.Application":     {output: "OK\r\n"},
+		"powershell|Win32_OperatingSystem": {output: "Microsoft Windows 11 专业版\r\n"},
+		"powershell|Win32_PnPEntity":       {output: "0|SR302\r\n"},
+	}
+}
+
+// TestRun 测试各项检查通过或失败时的诊断结论
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		override  map[string]mockResponse
+		remove    []string
+		want      map[string]Status
+		wantWorst Status
+	}{
+		{
+			name:      "全部通过",
+			want:      map[string]Status{},
+			wantWorst: StatusOK,
+		},
+		{
+			name:   "powershell不可用时使用pwsh",
+			remove: []string{"powershell|PSVersion"},
+			override: map[string]mockResponse{
+				"pwsh|PSVersion":             {output: "7.4.1"},
+				"pwsh|Get-ExecutionPolicy":   {output: "RemoteSigned"},
+				"pwsh|Shell.Application":     {output: "OK"},
+				"pwsh|Win32_OperatingSystem": {output: "Get-WmiObject: The term 'Get-WmiObject' is not recognized", err: errors.New("exit status 1")},
+				"pwsh|Win32_PnPEntity":       {output: "Get-WmiObject: The term 'Get-WmiObject' is not recognized", err: errors.New("exit status 1")},
+			},
+			want:      map[string]Status{"WMI查询": StatusFail, "设备识别": StatusFail},
+			wantWorst: StatusFail,
+		},
+		{
+			name:      "没有可用的PowerShell",
+			remove:    []string{"powershell|PSVersion"},
+			want:      map[string]Status{"PowerShell": StatusFail, "执行策略": StatusFail, "Shell.Application COM": StatusFail, "WMI查询": StatusFail, "设备识别": StatusFail},
+			wantWorst: StatusFail,
+		},
+		{
+			name:      "PowerShell版本过低",
+			override:  map[string]mockResponse{"powershell|PSVersion": {output: "2.0"}},
+			want:      map[string]Status{"PowerShell": StatusWarn},
+			wantWorst: StatusWarn,
+		},
+		{
+			name:      "执行策略受限",
+			override:  map[string]mockResponse{"powershell|Get-ExecutionPolicy": {output: "Restricted"}},
+			want:      map[string]Status{"执行策略": StatusWarn},
+			wantWorst: StatusWarn,
+		},
+		{
+			name:      "COM创建失败",
+			override:  map[string]mockResponse{"powershell|Shell.Application": {output: "New-Object : 检索 COM 类工厂中 CLSID 的组件失败", err: errors.New("exit status 1")}},
+			want:      map[string]Status{"Shell.Application COM": StatusFail},
+			wantWorst: StatusFail,
+		},
+		{
+			name:      "WMI查询失败",
+			override:  map[string]mockResponse{"powershell|Win32_OperatingSystem": {output: "Get-WmiObject : 服务不可用", err: errors.New("exit status 1")}},
+			want:      map[string]Status{"WMI查询": StatusFail},
+			wantWorst: StatusFail,
+		},
+		{
+			name:      "未识别到设备",
+			override:  map[string]mockResponse{"powershell|Win32_PnPEntity": {output: "NOT_FOUND"}},
+			want:      map[string]Status{"设备识别": StatusFail},
+			wantWorst: StatusFail,
+		},
+		{
+			name:      "设备驱动未安装",
+			override:  map[string]mockResponse{"powershell|Win32_PnPEntity": {output: "28|SR302"}},
+			want:      map[string]Status{"设备识别": StatusFail},
+			wantWorst: StatusFail,
+		},
+		{
+			name:      "设备状态异常",
+			override:  map[string]mockResponse{"powershell|Win32_PnPEntity": {output: "10|SR302"}},
+			want:      map[string]Status{"设备识别": StatusWarn},
+			wantWorst: StatusWarn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := healthyResponses()
+			for _, key := range tt.remove {
+				delete(responses, key)
+			}
+			for key, response := range tt.override {
+				responses[key] = response
+			}
+
+			results := Run(Options{
+				Runner:               &mockRunner{responses: responses},
+				PowerShellCandidates: []string{"powershell", "pwsh"},
+				TargetDirectory:      filepath.Join(t.TempDir(), "backups"),
+				DeviceName:           "SR302",
+				VID:                  "2207",
+				PID:                  "0011",
+			})
+
+			if len(results) != 6 {
+				t.Fatalf("检查项数 = %d，期望 6", len(results))
+			}
+			for _, result := range results {
+				want, ok := tt.want[result.Name]
+				if !ok {
+					want = StatusOK
+				}
+				if result.Status != want {
+					t.Errorf("%s 的结论 = %s，期望 %s（%s）", result.Name, result.Status, want, result.Detail)
+				}
+				if result.Status != StatusOK && result.Advice == "" {
+					t.Errorf("%s 未通过时应给出修复建议", result.Name)
+				}
+			}
+			if got := Conclusion(results); got != tt.wantWorst {
+				t.Errorf("总体结论 = %s，期望 %s", got, tt.wantWorst)
+			}
+		})
+	}
+}
+
+// TestRun_FileEstimate 测试设备已识别时追加文件估算，未识别到设备时不估算，估算失败时给出警告
+func TestRun_FileEstimate(t *testing.T) {
+	run := func(responses map[string]mockResponse, quickStat func() (int, int64, error)) []Result {
+		return Run(Options{
+			Runner:          &mockRunner{responses: responses},
+			TargetDirectory: filepath.Join(t.TempDir(), "backups"),
+			DeviceName:      "SR302",
+			VID:             "2207",
+			PID:             "0011",
+			QuickStat:       quickStat,
+		})
+	}
+
+	results := run(healthyResponses(), func() (int, int64, error) { return 120, 120 << 20, nil })
+	if len(results) != 7 || results[6].Name != "文件估算" || results[6].Status != StatusOK {
+		t.Fatalf("设备已识别时应追加文件估算: %+v", results)
+	}
+	if !strings.Contains(results[6].Detail, "120 个文件") {
+		t.Errorf("估算结果 = %s", results[6].Detail)
+	}
+
+	called := false
+	responses := healthyResponses()
+	responses["powershell|Win32_PnPEntity"] = mockResponse{output: "NOT_FOUND"}
+	if results := run(responses, func() (int, int64, error) { called = true; return 0, 0, nil }); len(results) != 6 || called {
+		t.Errorf("未识别到设备时不应估算文件: %d 项, 调用=%v", len(results), called)
+	}
+
+	results = run(healthyResponses(), func() (int, int64, error) { return 0, 0, errors.New("不支持") })
+	if last := results[len(results)-1]; last.Status != StatusWarn || last.Advice == "" {
+		t.Errorf("估算失败时应给出警告和建议: %+v", last)
+	}
+}
+
+// TestCheckTargetWritable 测试目标目录可写和不可创建时的结论
+func TestCheckTargetWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	if result := CheckTargetWritable(dir); result.Status != StatusOK {
+		t.Errorf("可写目录的结论 = %s: %s", result.Status, result.Detail)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("检查后不应留下探测文件: %v", entries)
+	}
+
+	// 目标路径的上级是普通文件，无法创建目录
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result := CheckTargetWritable(filepath.Join(file, "backups")); result.Status != StatusFail || result.Advice == "" {
+		t.Errorf("无法创建的目录应失败并给出建议: %+v", result)
+	}
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 支持的语言
+const (
+	LangZH = "zh"
+	LangEN = "en"
+)
+
+// DefaultLanguage 默认语言，其他语言缺少翻译时回退到该语言
+const DefaultLanguage = LangZH
+
+// LanguageEnv 选择界面语言的环境变量，配置文件未设置 language 时使用
+const LanguageEnv = "RC_LANG"
+
+var (
+	mu       sync.RWMutex
+	current  = DefaultLanguage
+	catalogs = map[string]map[string]string{
+		LangZH: zhMessages,
+		LangEN: enMessages,
+	}
+)
+
+// Normalize 将 "en_US.UTF-8"、"zh-CN" 等写法规范为支持的语言代码，不支持时返回空字符串
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_."); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return ""
+}
+
+// Resolve 选择界面语言：配置优先，其次为 RC_LANG 环境变量，都无效时使用默认语言
+func Resolve(configLang string) string {
+	if lang := Normalize(configLang); lang != "" {
+		return lang
+	}
+	if lang := Normalize(os.Getenv(LanguageEnv)); lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// SetLanguage 设置当前语言，不支持的语言使用默认语言
+func SetLanguage(lang string) {
+	lang = Normalize(lang)
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Language 获取当前语言
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T 获取当前语言下 key 对应的文本，有参数时按格式化字符串处理
+// 当前语言缺少翻译时回退到默认语言，都没有时返回 key 本身
+func T(key string, args ...interface{}) string {
+	mu.RLock()
+	lang := current
+	mu.RUnlock()
+
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		msg = key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
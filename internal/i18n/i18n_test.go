@@ -0,0 +1,77 @@
+package i18n
+
+import "testing"
+
+// TestT 测试切换语言后同一 key 返回对应语言文本
+func TestT(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"中文", LangZH, "backup.scan_done", []interface{}{3}, "扫描完成，发现 3 个文件"},
+		{"英文", LangEN, "backup.scan_done", []interface{}{3}, "Scan finished, 3 files found"},
+		{"无参数", LangEN, "detect.none", nil, "No voice recorder found"},
+		{"不支持的语言使用默认语言", "fr", "detect.none", nil, "未找到任何录音笔设备"},
+		{"未知key返回key本身", LangEN, "no.such.key", nil, "no.such.key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLanguage(tt.lang)
+			if got := T(tt.key, tt.args...); got != tt.want {
+				t.Errorf("期望 %q，实际 %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestT_Fallback 测试缺少翻译时回退到默认语言
+func TestT_Fallback(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+
+	zhMessages["test.only_zh"] = "仅有中文 %d"
+	defer delete(zhMessages, "test.only_zh")
+
+	SetLanguage(LangEN)
+	if got := T("test.only_zh", 1); got != "仅有中文 1" {
+		t.Errorf("期望回退到中文，实际 %q", got)
+	}
+}
+
+// TestCatalogComplete 测试英文目录中的 key 都存在于默认语言目录
+func TestCatalogComplete(t *testing.T) {
+	for key := range enMessages {
+		if _, ok := zhMessages[key]; !ok {
+			t.Errorf("默认语言缺少 key: %s", key)
+		}
+	}
+}
+
+// TestResolve 测试语言选择优先级
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		configLang string
+		env        string
+		want       string
+	}{
+		{"配置优先", "en", "zh_CN.UTF-8", LangEN},
+		{"配置为空时读取环境变量", "", "en_US.UTF-8", LangEN},
+		{"环境变量带地区", "", "zh-CN", LangZH},
+		{"都无效时使用默认语言", "", "fr", DefaultLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(LanguageEnv, tt.env)
+			if got := Resolve(tt.configLang); got != tt.want {
+				t.Errorf("期望 %s，实际 %s", tt.want, got)
+			}
+		})
+	}
+}
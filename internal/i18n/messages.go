@@ -0,0 +1,124 @@
+package i18n
+
+// zhMessages 中文消息目录（默认语言，必须包含所有 key）
+var zhMessages = map[string]string{
+	// 通用
+	"common.error":         "错误: %v",
+	"common.press_any_key": "按任意键关闭窗口...",
+
+	// 备份主流程
+	"main.banner":             "         录音笔备份工具 - SR302 自动备份",
+	"main.start":              "录音笔备份工具启动",
+	"main.config_failed":      "配置加载失败: %v",
+	"main.config_failed_wait": "配置加载失败，请检查配置文件！",
+	"main.target_override":    "使用命令行指定的目标目录: %s",
+	"main.source_override":    "使用命令行指定的设备: %s (VID:%s, PID:%s)",
+	"main.detecting":          "正在检测SR302录音笔设备...",
+	"main.detect_failed":      "设备检测失败: %v",
+	"main.detect_failed_wait": "设备检测失败，请检查设备连接！",
+	"main.device_found":       "找到设备: %s (ID: %s)",
+	"main.device_ids":         "VID: %s, PID: %s",
+	"main.check_mode":         "检查模式: 仅扫描文件，不执行备份",
+	"main.op_failed":          "操作失败: %v",
+	"main.op_failed_wait":     "备份操作失败！",
+	"main.done":               "操作完成",
+	"main.done_wait":          "备份操作完成！",
+	"main.run_failed_wait":    "程序执行出错！",
+	"main.interrupted":        "已备份 %d 个文件，备份被中断",
+	"main.disconnected":       "设备已断开，已保存进度（已备份 %d 个文件），重新连接设备后再次运行即可继续",
+
+	"backup.start":           "开始备份操作，设备: %s (VID:%s, PID:%s)",
+	"backup.scanning":        "正在扫描设备文件...",
+	"backup.no_files":        "没有发现.opus文件，备份完成",
+	"backup.scan_done":       "扫描完成，发现 %d 个文件",
+	"backup.no_new_files":    "没有需要备份的新文件",
+	"backup.copying":         "开始复制 %d 个文件...",
+	"backup.done":            "备份操作完成",
+	"backup.result":          "复制结果: 成功 %d, 跳过 %d, 失败 %d",
+	"backup.total_size":      "总复制大小: %s",
+	"backup.all_backed_up":   "✅ 所有文件都已备份，无需操作",
+	"backup.ready":           "准备备份 %d 个新文件 (%s)",
+	"backup.stats":           "备份统计:",
+	"backup.stats_scanned":   "  扫描文件数: %d",
+	"backup.stats_backed_up": "  备份文件数: %d",
+	"backup.stats_duration":  "  耗时: %s",
+
+	// detect
+	"detect.banner":          "         录音笔设备检测",
+	"detect.start":           "开始检测录音笔设备...",
+	"detect.none":            "未找到任何录音笔设备",
+	"detect.none_hint":       "请确保：\n1. 录音笔已连接到电脑\n2. 设备驱动程序已正确安装\n3. 设备处于可访问状态",
+	"detect.none_wait":       "未找到设备！",
+	"detect.list_title":      "检测到的录音笔设备：",
+	"detect.device_index":    "设备 #%d",
+	"detect.device_name":     "   名称: %s",
+	"detect.details_title":   "   设备详细信息：",
+	"detect.details_failed":  "   读取设备详细信息失败: %v",
+	"detect.config_snippet":  "   配置片段：",
+	"detect.known_model":     "   检测到已知型号 %s，可用 --model 快速配置：",
+	"detect.sr302_found":     "检测到SR302设备！",
+	"detect.sr302_use":       "   您可以使用以下配置：",
+	"detect.sr302_not_found": "未检测到SR302设备，但找到了其他录音设备\n   您可以尝试使用上述设备配置",
+	"detect.tips":            "提示：\n   - 复制上述配置片段到 configs/backup.yaml 文件中\n   - 然后运行 record_center --check 测试配置\n   - 使用 record_center --verbose 查看详细日志",
+	"detect.done_wait":       "设备检测完成！",
+}
+
+// enMessages 英文消息目录，缺少的 key 回退到中文
+var enMessages = map[string]string{
+	"common.error":         "Error: %v",
+	"common.press_any_key": "Press any key to close the window...",
+
+	"main.banner":             "         Voice Recorder Backup - SR302 Auto Backup",
+	"main.start":              "Voice recorder backup tool started",
+	"main.config_failed":      "Failed to load config: %v",
+	"main.config_failed_wait": "Failed to load config, please check the config file!",
+	"main.target_override":    "Using target directory from command line: %s",
+	"main.source_override":    "Using device from command line: %s (VID:%s, PID:%s)",
+	"main.detecting":          "Detecting SR302 voice recorder...",
+	"main.detect_failed":      "Device detection failed: %v",
+	"main.detect_failed_wait": "Device detection failed, please check the connection!",
+	"main.device_found":       "Device found: %s (ID: %s)",
+	"main.device_ids":         "VID: %s, PID: %s",
+	"main.check_mode":         "Check mode: scan only, no backup",
+	"main.op_failed":          "Operation failed: %v",
+	"main.op_failed_wait":     "Backup failed!",
+	"main.done":               "Operation completed",
+	"main.done_wait":          "Backup completed!",
+	"main.run_failed_wait":    "The program exited with an error!",
+	"main.interrupted":        "Backed up %d files, backup interrupted",
+	"main.disconnected":       "Device disconnected, progress saved (%d files backed up); reconnect the device and run again to continue",
+
+	"backup.start":           "Starting backup, device: %s (VID:%s, PID:%s)",
+	"backup.scanning":        "Scanning device files...",
+	"backup.no_files":        "No .opus files found, backup finished",
+	"backup.scan_done":       "Scan finished, %d files found",
+	"backup.no_new_files":    "No new files to back up",
+	"backup.copying":         "Copying %d files...",
+	"backup.done":            "Backup completed",
+	"backup.result":          "Copy result: %d succeeded, %d skipped, %d failed",
+	"backup.total_size":      "Total copied: %s",
+	"backup.all_backed_up":   "✅ All files are already backed up",
+	"backup.ready":           "Ready to back up %d new files (%s)",
+	"backup.stats":           "Backup statistics:",
+	"backup.stats_scanned":   "  Files scanned: %d",
+	"backup.stats_backed_up": "  Files backed up: %d",
+	"backup.stats_duration":  "  Duration: %s",
+
+	"detect.banner":          "         Voice Recorder Detection",
+	"detect.start":           "Detecting voice recorders...",
+	"detect.none":            "No voice recorder found",
+	"detect.none_hint":       "Please make sure:\n1. The recorder is connected to this computer\n2. The device driver is installed\n3. The device is accessible",
+	"detect.none_wait":       "No device found!",
+	"detect.list_title":      "Detected voice recorders:",
+	"detect.device_index":    "Device #%d",
+	"detect.device_name":     "   Name: %s",
+	"detect.details_title":   "   Device details:",
+	"detect.details_failed":  "   Failed to read device details: %v",
+	"detect.config_snippet":  "   Config snippet:",
+	"detect.known_model":     "   Known model %s detected, quick setup with --model:",
+	"detect.sr302_found":     "SR302 device detected!",
+	"detect.sr302_use":       "   You can use the following config:",
+	"detect.sr302_not_found": "No SR302 device detected, but other recorders were found\n   You can try the device config above",
+	"detect.tips":            "Tips:\n   - Copy the config snippet above into configs/backup.yaml\n   - Then run record_center --check to test the config\n   - Use record_center --verbose for detailed logs",
+	"detect.done_wait":       "Device detection completed!",
+}
@@ -0,0 +1,228 @@
+// Package index 从备份记录生成可浏览、可播放的静态 HTML 索引页
+package index
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// Entry 索引页中的一个备份文件
+type Entry struct {
+	Name       string
+	Link       string // 相对索引页的链接，各路径段已转义
+	Download   string // 目标文件名与源文件名不同（如内容寻址布局按哈希命名）时下载使用的源文件名
+	Size       string
+	Duration   string // 播放时长，未知时为 "-"
+	BackupTime string
+	Playable   bool // opus 文件可用 <audio> 直接播放
+	modTime    time.Time
+}
+
+// DateGroup 同一天录制的文件
+type DateGroup struct {
+	Date    string
+	Entries []Entry
+}
+
+// DeviceGroup 一个设备的备份文件，按录制日期倒序分组
+type DeviceGroup struct {
+	Name  string
+	Files int
+	Size  string
+	Dates []DateGroup
+}
+
+// Page 索引页数据
+type Page struct {
+	Generated string
+	Total     int
+	Devices   []DeviceGroup
+}
+
+// pageTemplate 索引页模板，html/template 负责文本和属性的转义
+var pageTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>录音备份索引</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1em; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+td.num { text-align: right; white-space: nowrap; }
+audio { height: 28px; }
+</style>
+</head>
+<body>
+<h1>录音备份索引</h1>
+<p>共 {{.Total}} 个文件，生成于 {{.Generated}}</p>
+{{- range .Devices}}
+<h2>{{.Name}}（{{.Files}} 个文件，{{.Size}}）</h2>
+{{- range .Dates}}
+<h3>{{.Date}}</h3>
+<table>
+<tr><th>文件名</th><th>大小</th><th>时长</th><th>备份时间</th><th>播放</th></tr>
+{{- range .Entries}}
+<tr><td><a href="{{.Link}}"{{if .Download}} download="{{.Download}}"{{end}}>{{.Name}}</a></td><td class="num">{{.Size}}</td><td class="num">{{.Duration}}</td><td>{{.BackupTime}}</td><td>{{if .Playable}}<audio controls preload="none" src="{{.Link}}"></audio>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- else}}
+<p>暂无备份记录</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// BuildPage 从备份存储整理索引页数据，只包含备份成功的记录
+// deviceName 非空时只列出该设备（按设备名称或设备ID匹配）的记录；链接相对于 outDir
+func BuildPage(backupStorage *storage.BackupStorage, deviceName, outDir string, now time.Time) *Page {
+	// 设备ID到设备名称的映射来自各设备最近一次运行
+	names := make(map[string]string)
+	for name, run := range backupStorage.LastRuns {
+		if run != nil && run.DeviceID != "" {
+			names[run.DeviceID] = name
+		}
+	}
+
+	groups := make(map[string]map[string][]Entry)
+	sizes := make(map[string]int64)
+	page := &Page{Generated: now.Format("2006-01-02 15:04:05")}
+	for _, record := range backupStorage.Records {
+		if !record.Success {
+			continue
+		}
+		device := names[record.DeviceID]
+		if device == "" {
+			device = record.DeviceID
+		}
+		if deviceName != "" && !strings.EqualFold(device, deviceName) && record.DeviceID != deviceName {
+			continue
+		}
+
+		recorded := record.LastModified
+		if recorded.IsZero() {
+			recorded = record.BackupTime
+		}
+		name := storage.RecordName(record.SourcePath)
+		entry := Entry{
+			Name:       name,
+			Link:       relativeLink(outDir, record.TargetPath),
+			Size:       utils.FormatBytes(record.FileSize),
+			Duration:   "-",
+			BackupTime: record.BackupTime.Format("2006-01-02 15:04:05"),
+			Playable:   strings.EqualFold(filepath.Ext(name), ".opus"),
+			modTime:    recorded,
+		}
+		if filepath.Base(record.TargetPath) != name {
+			entry.Download = name
+		}
+		if record.AudioMeta != nil && record.AudioMeta.Duration > 0 {
+			entry.Duration = formatClock(record.AudioMeta.Duration)
+		}
+
+		if groups[device] == nil {
+			groups[device] = make(map[string][]Entry)
+		}
+		date := recorded.Format("2006-01-02")
+		groups[device][date] = append(groups[device][date], entry)
+		sizes[device] += record.FileSize
+		page.Total++
+	}
+
+	devices := make([]string, 0, len(groups))
+	for device := range groups {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	for _, device := range devices {
+		group := DeviceGroup{Name: device, Size: utils.FormatBytes(sizes[device])}
+		dates := make([]string, 0, len(groups[device]))
+		for date := range groups[device] {
+			dates = append(dates, date)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+		for _, date := range dates {
+			entries := groups[device][date]
+			sort.SliceStable(entries, func(i, j int) bool {
+				if !entries[i].modTime.Equal(entries[j].modTime) {
+					return entries[i].modTime.Before(entries[j].modTime)
+				}
+				return entries[i].Name < entries[j].Name
+			})
+			group.Files += len(entries)
+			group.Dates = append(group.Dates, DateGroup{Date: date, Entries: entries})
+		}
+		page.Devices = append(page.Devices, group)
+	}
+	return page
+}
+
+// Render 用模板渲染索引页
+func Render(w io.Writer, page *Page) error {
+	if err := pageTemplate.Execute(w, page); err != nil {
+		return fmt.Errorf("渲染索引页失败: %w", err)
+	}
+	return nil
+}
+
+// Generate 生成索引页并写入 outPath，返回页面数据
+func Generate(backupStorage *storage.BackupStorage, deviceName, outPath string) (*Page, error) {
+	absOut, err := filepath.Abs(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析输出路径失败: %w", err)
+	}
+	page := BuildPage(backupStorage, deviceName, filepath.Dir(absOut), time.Now())
+
+	var buf strings.Builder
+	if err := Render(&buf, page); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(absOut), 0755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	if err := os.WriteFile(absOut, []byte(buf.String()), 0644); err != nil {
+		return nil, fmt.Errorf("写入索引页失败: %w", err)
+	}
+	return page, nil
+}
+
+// relativeLink 返回备份文件相对索引页所在目录的链接，各路径段转义后以 ./ 开头
+// 与索引页不在同一盘符时无法取得相对路径，使用 file:// 绝对链接
+func relativeLink(outDir, targetPath string) string {
+	if abs, err := filepath.Abs(targetPath); err == nil {
+		targetPath = abs
+	}
+	prefix := "./"
+	rel, err := filepath.Rel(outDir, targetPath)
+	if err != nil {
+		prefix, rel = "file:///", targetPath
+	}
+
+	segments := strings.Split(strings.TrimPrefix(filepath.ToSlash(rel), "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return prefix + strings.Join(segments, "/")
+}
+
+// formatClock 把时长格式化为 m:ss 或 h:mm:ss
+func formatClock(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
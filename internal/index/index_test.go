@@ -0,0 +1,153 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestGenerate 测试生成的索引页包含所有记录，按设备和日期分组，路径正确转义
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+	backupDir := filepath.Join(tempDir, "backups")
+	outPath := filepath.Join(tempDir, "index.html")
+
+	day1 := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	day2 := time.Date(2024, 3, 2, 14, 0, 0, 0, time.Local)
+	backupStorage := &storage.BackupStorage{
+		Records: []storage.BackupRecord{
+			{
+				SourcePath:   "录音笔文件\\会议 记录.opus",
+				TargetPath:   filepath.Join(backupDir, "2024", "会议 记录.opus"),
+				FileSize:     2048,
+				BackupTime:   day1.Add(time.Hour),
+				LastModified: day1,
+				DeviceID:     "usb_sr302",
+				Success:      true,
+				AudioMeta:    &utils.OpusMeta{Duration: 95 * time.Second},
+			},
+			{
+				SourcePath:   "录音笔文件\\<b>&#1?.opus",
+				TargetPath:   filepath.Join(backupDir, "2024", "<b>&#1?.opus"),
+				FileSize:     1024,
+				BackupTime:   day2.Add(time.Hour),
+				LastModified: day2,
+				DeviceID:     "usb_sr302",
+				Success:      true,
+			},
+			{
+				SourcePath:   "录音笔文件\\备注.txt",
+				TargetPath:   filepath.Join(backupDir, "备注.txt"),
+				FileSize:     10,
+				BackupTime:   day2,
+				LastModified: day2,
+				DeviceID:     "usb_other",
+				Success:      true,
+			},
+			{
+				SourcePath: "录音笔文件\\失败.opus",
+				TargetPath: filepath.Join(backupDir, "失败.opus"),
+				DeviceID:   "usb_sr302",
+				Success:    false,
+			},
+		},
+		LastRuns: map[string]*storage.RunSummary{
+			"SR302": {DeviceName: "SR302", DeviceID: "usb_sr302"},
+		},
+	}
+
+	page, err := Generate(backupStorage, "", outPath)
+	if err != nil {
+		t.Fatalf("生成索引页失败: %v", err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("读取索引页失败: %v", err)
+	}
+	html := string(data)
+
+	if page.Total != 3 || len(page.Devices) != 2 {
+		t.Fatalf("索引页应包含 2 个设备 3 个文件: %+v", page)
+	}
+	sr302 := page.Devices[0]
+	if sr302.Name != "SR302" || len(sr302.Dates) != 2 || sr302.Dates[0].Date != "2024-03-02" {
+		t.Errorf("SR302 应按日期倒序分为两组: %+v", sr302)
+	}
+	if page.Devices[1].Name != "usb_other" {
+		t.Errorf("没有运行记录的设备应显示设备ID: %s", page.Devices[1].Name)
+	}
+
+	wants := []string{
+		`<h2>SR302（2 个文件，3.0 KiB）</h2>`,
+		`<h3>2024-03-01</h3>`,
+		`<a href="./backups/2024/%E4%BC%9A%E8%AE%AE%20%E8%AE%B0%E5%BD%95.opus">会议 记录.opus</a>`,
+		`<td class="num">1:35</td>`,
+		`<audio controls preload="none" src="./backups/2024/%E4%BC%9A%E8%AE%AE%20%E8%AE%B0%E5%BD%95.opus">`,
+		`&lt;b&gt;&amp;#1?.opus`,
+		`./backups/2024/%3Cb%3E&amp;%231%3F.opus`,
+		`<a href="./backups/%E5%A4%87%E6%B3%A8.txt">备注.txt</a>`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(html, want) {
+			t.Errorf("索引页缺少 %s\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "<b>") || strings.Contains(html, "失败.opus") {
+		t.Error("索引页不应包含未转义的文件名或失败的记录")
+	}
+	if strings.Count(html, "<audio") != 2 {
+		t.Errorf("只有 opus 文件应可播放，实际 %d 个播放器", strings.Count(html, "<audio"))
+	}
+}
+
+// TestBuildPage_Device 测试按设备名称或设备ID筛选记录
+func TestBuildPage_Device(t *testing.T) {
+	backupStorage := &storage.BackupStorage{
+		Records: []storage.BackupRecord{
+			{SourcePath: "a.opus", TargetPath: "/backup/a.opus", DeviceID: "usb_sr302", Success: true},
+			{SourcePath: "b.opus", TargetPath: "/backup/b.opus", DeviceID: "usb_other", Success: true},
+		},
+		LastRuns: map[string]*storage.RunSummary{"SR302": {DeviceID: "usb_sr302"}},
+	}
+
+	for _, device := range []string{"SR302", "sr302", "usb_sr302"} {
+		page := BuildPage(backupStorage, device, "/backup", time.Now())
+		if page.Total != 1 || page.Devices[0].Dates[0].Entries[0].Name != "a.opus" {
+			t.Errorf("--device %s 应只列出 a.opus: %+v", device, page)
+		}
+	}
+	if page := BuildPage(backupStorage, "SR502", "/backup", time.Now()); page.Total != 0 {
+		t.Errorf("未知设备不应列出记录: %+v", page)
+	}
+}
+
+// TestBuildPage_ContentAddressed 测试内容寻址布局下两个原名指向同一哈希文件时，各自按原名显示和下载
+func TestBuildPage_ContentAddressed(t *testing.T) {
+	target := "/backup/ab/cd/abcd.opus"
+	backupStorage := &storage.BackupStorage{
+		Records: []storage.BackupRecord{
+			{SourcePath: "录音笔文件\\会议.opus", TargetPath: target, DeviceID: "usb_sr302", Success: true, ContentHash: "abcd"},
+			{SourcePath: "录音笔文件\\会议副本.opus", TargetPath: target, DeviceID: "usb_sr302", Success: true, ContentHash: "abcd"},
+			{SourcePath: "录音笔文件\\b.opus", TargetPath: "/backup/b.opus", DeviceID: "usb_sr302", Success: true},
+		},
+	}
+
+	page := BuildPage(backupStorage, "", "/backup", time.Now())
+	downloads := make(map[string]string)
+	for _, entry := range page.Devices[0].Dates[0].Entries {
+		downloads[entry.Name] = entry.Download
+	}
+	for _, name := range []string{"会议.opus", "会议副本.opus"} {
+		if downloads[name] != name {
+			t.Errorf("%s 的下载名 = %q，期望还原为原名", name, downloads[name])
+		}
+	}
+	if downloads["b.opus"] != "" {
+		t.Errorf("目标文件名与原名相同时不需要下载名: %q", downloads["b.opus"])
+	}
+}
@@ -1,12 +1,16 @@
 package logger
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -14,19 +18,98 @@ const (
 	LogFilePermissions = 0644
 )
 
+// 日志格式
+const (
+	FormatText = "text" // [LEVEL] 消息
+	FormatJSON = "json" // 每行一个JSON对象
+)
+
 // Logger 简单的日志器实现
 type Logger struct {
-	verbose bool
-	logFile *os.File
-	logger  *log.Logger
+	verbose  bool
+	minLevel int    // 低于该级别的日志不输出
+	format   string // 日志格式，空表示 text
+	logFile  *os.File
+	logger   *log.Logger
+	session  string // 备份会话ID，非空时作为每条日志的前缀
+	crash    *crashRecorder // 环形缓冲，nil表示未开启崩溃转储
+	redact   bool           // 是否对日志参数中的文件名和路径脱敏
+}
+
+// Options 日志器选项
+type Options struct {
+	Level   string // 日志级别: debug, info, warn, error
+	File    string // 日志文件路径，空表示只输出到控制台
+	Format  string // 日志格式: text, json
+	Console bool   // 写入文件时是否同时输出到控制台
+	// RingSize 环形缓冲保留的最近日志条数，出现 error 日志或 panic 时转储，0表示不开启
+	RingSize int
+	// CrashDir 崩溃转储文件所在目录，空表示日志文件所在目录（无日志文件时为 logs）
+	CrashDir string
+	// Redact 对日志中的文件名和路径脱敏，保留目录层次、扩展名和长度
+	Redact bool
 }
 
 // NewLogger 创建新的日志器实例
 func NewLogger(verbose bool) *Logger {
-	return &Logger{
-		verbose: verbose,
-		logger:  log.New(os.Stdout, "", log.LstdFlags),
+	l := &Logger{
+		logger: log.New(os.Stdout, "", log.LstdFlags),
 	}
+	if verbose {
+		l.SetLevel(LevelDebug)
+	} else {
+		l.SetLevel(LevelInfo)
+	}
+	return l
+}
+
+// New 按选项创建日志器
+func New(opts Options) (*Logger, error) {
+	l := NewLogger(false)
+	if opts.Level != "" {
+		if !IsValidLogLevel(opts.Level) {
+			return nil, fmt.Errorf("无效的日志级别: %s", opts.Level)
+		}
+		l.SetLevel(opts.Level)
+	}
+	l.redact = opts.Redact
+
+	switch opts.Format {
+	case "", FormatText:
+	case FormatJSON:
+		l.format = FormatJSON
+		l.logger.SetFlags(0)
+	default:
+		return nil, fmt.Errorf("无效的日志格式: %s", opts.Format)
+	}
+
+	if opts.RingSize > 0 {
+		crashDir := opts.CrashDir
+		if crashDir == "" && opts.File != "" {
+			crashDir = filepath.Dir(opts.File)
+		}
+		l.EnableCrashDump(opts.RingSize, crashDir)
+	}
+
+	if opts.File == "" {
+		return l, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, LogFilePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("创建日志文件失败: %w", err)
+	}
+	l.logFile = file
+
+	if opts.Console {
+		l.logger.SetOutput(io.MultiWriter(os.Stdout, file))
+	} else {
+		l.logger.SetOutput(file)
+	}
+	return l, nil
 }
 
 // InitLogger 初始化日志器
@@ -82,39 +165,128 @@ func (l *Logger) Setup(name, level, logDir string, console bool, enableContext7
 	}
 }
 
-// Debug 记录调试信息
+// Debug 记录调试信息，未开启调试级别时只写入环形缓冲
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.verbose {
-		msg := fmt.Sprintf("[DEBUG] "+format, args...)
-		l.logger.Println(msg)
+	if l.verbose || l.crash != nil {
+		l.output("DEBUG", format, args...)
 	}
 }
 
 // Info 记录信息
 func (l *Logger) Info(format string, args ...interface{}) {
-	msg := fmt.Sprintf("[INFO] "+format, args...)
-	l.logger.Println(msg)
+	l.output("INFO", format, args...)
 }
 
 // Warn 记录警告信息
 func (l *Logger) Warn(format string, args ...interface{}) {
-	msg := fmt.Sprintf("[WARN] "+format, args...)
-	l.logger.Println(msg)
+	l.output("WARN", format, args...)
 }
 
 // Error 记录错误信息
 func (l *Logger) Error(format string, args ...interface{}) {
-	msg := fmt.Sprintf("[ERROR] "+format, args...)
-	l.logger.Println(msg)
+	l.output("ERROR", format, args...)
 }
 
 // Fatal 记录致命错误并退出程序
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	msg := fmt.Sprintf("[FATAL] "+format, args...)
-	l.logger.Println(msg)
+	l.output("FATAL", format, args...)
 	os.Exit(1)
 }
 
+// output 按级别和会话ID格式化并输出一条日志，开启环形缓冲时 error 级别的日志会触发转储
+func (l *Logger) output(level, format string, args ...interface{}) {
+	if l.crash == nil && !l.enabled(level) {
+		return
+	}
+
+	if l.redact {
+		args = redactArgs(args)
+	}
+	msg := fmt.Sprintf(format, args...)
+	l.emit(level, msg)
+
+	if l.crash != nil && (level == "ERROR" || level == "FATAL") {
+		if _, err := l.crash.dump(level+": "+msg, level == "FATAL"); err != nil {
+			fmt.Fprintf(os.Stderr, "转储崩溃日志失败: %v\n", err)
+		}
+	}
+}
+
+// enabled 判断级别是否达到输出级别
+func (l *Logger) enabled(level string) bool {
+	value, ok := logLevelMap[strings.ToLower(level)]
+	return !ok || value >= l.minLevel
+}
+
+// emit 将格式化好的日志写入环形缓冲，并在达到输出级别时输出
+func (l *Logger) emit(level, msg string) {
+	if l.crash != nil {
+		l.crash.record(level, l.session, msg)
+	}
+	if !l.enabled(level) {
+		return
+	}
+
+	if l.format == FormatJSON {
+		l.outputJSON(level, msg)
+		return
+	}
+	if l.session != "" {
+		l.logger.Printf("[%s] [session=%s] %s", level, l.session, msg)
+		return
+	}
+	l.logger.Printf("[%s] %s", level, msg)
+}
+
+// jsonEntry JSON格式的一条日志
+type jsonEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Session string `json:"session,omitempty"`
+	Msg     string `json:"msg"`
+}
+
+// outputJSON 以JSON格式输出一条日志
+func (l *Logger) outputJSON(level, msg string) {
+	data, err := json.Marshal(jsonEntry{
+		Time:    time.Now().Format(time.RFC3339),
+		Level:   level,
+		Session: l.session,
+		Msg:     msg,
+	})
+	if err != nil {
+		l.logger.Printf("[%s] %s", level, msg)
+		return
+	}
+	l.logger.Print(string(data))
+}
+
+// WithSession 返回带会话ID的日志器，与原日志器共享输出目标
+func (l *Logger) WithSession(session string) *Logger {
+	child := *l
+	child.session = session
+	return &child
+}
+
+// Session 获取当前日志器的会话ID
+func (l *Logger) Session() string {
+	return l.session
+}
+
+// NewSessionID 生成8位十六进制的短会话ID
+func NewSessionID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%08x", uint32(os.Getpid()))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// SetOutput 设置日志输出目标
+func (l *Logger) SetOutput(w io.Writer) {
+	l.logger.SetOutput(w)
+}
+
 // WithContext 添加上下文信息（context7功能）
 func (l *Logger) WithContext(key string, value interface{}) *Logger {
 	if l.verbose {
@@ -131,10 +303,14 @@ func (l *Logger) WithContexts(contexts map[string]interface{}) *Logger {
 	return l
 }
 
-// SetLevel 动态设置日志级别
+// SetLevel 动态设置日志级别，无效的级别按 info 处理
 func (l *Logger) SetLevel(level string) {
-	// 在这个简单实现中，我们只通过verbose标志控制debug输出
-	l.verbose = strings.ToLower(level) == "debug"
+	value, ok := logLevelMap[strings.ToLower(level)]
+	if !ok {
+		value = logLevelMap[LevelInfo]
+	}
+	l.minLevel = value
+	l.verbose = value == logLevelMap[LevelDebug]
 }
 
 // GetLogFile 获取当前日志文件路径
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResolveOptions 合并配置文件与命令行标志的日志选项，返回最终选项和需要提示的警告
+// overrides 中非空的字段覆盖 base；verbose 相当于 debug 级别，quiet 相当于 warn 级别，
+// 与 overrides.Level 冲突时以更详细的级别为准
+func ResolveOptions(base, overrides Options, verbose, quiet bool) (Options, []string) {
+	opts := base
+	var warnings []string
+
+	if overrides.File != "" {
+		opts.File = overrides.File
+	}
+	if overrides.Format != "" {
+		format := strings.ToLower(overrides.Format)
+		if format == FormatText || format == FormatJSON {
+			opts.Format = format
+		} else {
+			warnings = append(warnings, fmt.Sprintf("无效的日志格式 %s，使用 %s", overrides.Format, formatName(opts.Format)))
+		}
+	}
+
+	flagLevel := strings.ToLower(overrides.Level)
+	if flagLevel != "" && !IsValidLogLevel(flagLevel) {
+		warnings = append(warnings, fmt.Sprintf("无效的日志级别 %s，忽略 --log-level", overrides.Level))
+		flagLevel = ""
+	}
+
+	if flagLevel == "" {
+		// 未指定 --log-level 时，--verbose 开启调试日志，--quiet 只影响进度显示
+		if verbose {
+			opts.Level = LevelDebug
+		}
+		return opts, warnings
+	}
+
+	implied := []struct {
+		flag  string
+		set   bool
+		level string
+	}{
+		{"--verbose", verbose, LevelDebug},
+		{"--quiet", quiet, LevelWarn},
+	}
+
+	opts.Level = flagLevel
+	for _, item := range implied {
+		if item.set && logLevelMap[item.level] < logLevelMap[opts.Level] {
+			opts.Level = item.level
+		}
+	}
+	for _, item := range implied {
+		if item.set && item.level != flagLevel {
+			warnings = append(warnings, fmt.Sprintf("%s 与 --log-level=%s 冲突，以更详细的 %s 级别为准", item.flag, flagLevel, opts.Level))
+		}
+	}
+	return opts, warnings
+}
+
+// formatName 获取日志格式名称，空表示 text
+func formatName(format string) string {
+	if format == "" {
+		return FormatText
+	}
+	return format
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestResolveOptions 测试配置文件与命令行日志标志的合并
+func TestResolveOptions(t *testing.T) {
+	base := Options{Level: LevelInfo, File: "logs/record_center.log", Format: FormatText, Console: true}
+
+	tests := []struct {
+		name         string
+		overrides    Options
+		verbose      bool
+		quiet        bool
+		want         Options
+		wantWarnings int
+	}{
+		{
+			name: "无标志时使用配置文件",
+			want: base,
+		},
+		{
+			name:      "标志覆盖配置文件",
+			overrides: Options{Level: "error", File: "out/app.log", Format: "JSON"},
+			want:      Options{Level: LevelError, File: "out/app.log", Format: FormatJSON, Console: true},
+		},
+		{
+			name:    "仅verbose时开启调试日志",
+			verbose: true,
+			want:    Options{Level: LevelDebug, File: base.File, Format: FormatText, Console: true},
+		},
+		{
+			name:  "仅quiet时不改变日志级别",
+			quiet: true,
+			want:  base,
+		},
+		{
+			name:         "verbose与warn冲突时取debug",
+			overrides:    Options{Level: LevelWarn},
+			verbose:      true,
+			want:         Options{Level: LevelDebug, File: base.File, Format: FormatText, Console: true},
+			wantWarnings: 1,
+		},
+		{
+			name:         "quiet与debug冲突时取debug",
+			overrides:    Options{Level: LevelDebug},
+			quiet:        true,
+			want:         Options{Level: LevelDebug, File: base.File, Format: FormatText, Console: true},
+			wantWarnings: 1,
+		},
+		{
+			name:         "quiet与error冲突时取warn",
+			overrides:    Options{Level: LevelError},
+			quiet:        true,
+			want:         Options{Level: LevelWarn, File: base.File, Format: FormatText, Console: true},
+			wantWarnings: 1,
+		},
+		{
+			name:      "quiet与warn一致时无警告",
+			overrides: Options{Level: LevelWarn},
+			quiet:     true,
+			want:      Options{Level: LevelWarn, File: base.File, Format: FormatText, Console: true},
+		},
+		{
+			name:         "无效级别被忽略",
+			overrides:    Options{Level: "trace"},
+			want:         base,
+			wantWarnings: 1,
+		},
+		{
+			name:         "无效格式被忽略",
+			overrides:    Options{Format: "xml"},
+			want:         base,
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := ResolveOptions(base, tt.overrides, tt.verbose, tt.quiet)
+			if got != tt.want {
+				t.Errorf("ResolveOptions() = %+v, 期望 %+v", got, tt.want)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("警告数量 = %d, 期望 %d: %v", len(warnings), tt.wantWarnings, warnings)
+			}
+		})
+	}
+}
+
+// TestNew_LevelFilter 测试按级别过滤日志
+func TestNew_LevelFilter(t *testing.T) {
+	log, err := New(Options{Level: LevelWarn})
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	log.Debug("调试")
+	log.Info("信息")
+	log.Warn("警告")
+	log.Error("错误")
+
+	output := buf.String()
+	if strings.Contains(output, "调试") || strings.Contains(output, "信息") {
+		t.Errorf("warn 级别不应输出 debug/info 日志: %s", output)
+	}
+	if !strings.Contains(output, "[WARN] 警告") || !strings.Contains(output, "[ERROR] 错误") {
+		t.Errorf("warn 级别应输出 warn/error 日志: %s", output)
+	}
+}
+
+// TestNew_JSONFile 测试JSON格式写入日志文件
+func TestNew_JSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "app.log")
+	log, err := New(Options{Level: LevelInfo, File: path, Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	log.WithSession("abc").Info("备份完成: %d", 3)
+	log.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取日志文件失败: %v", err)
+	}
+	// 关闭日志器时会再输出一条日志，只检查第一行
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	var entry jsonEntry
+	if err := json.Unmarshal([]byte(firstLine), &entry); err != nil {
+		t.Fatalf("日志不是合法的JSON: %v, %s", err, data)
+	}
+	if entry.Level != "INFO" || entry.Session != "abc" || entry.Msg != "备份完成: 3" || entry.Time == "" {
+		t.Errorf("JSON日志内容不符: %+v", entry)
+	}
+}
+
+// TestNew_Invalid 测试无效选项返回错误
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New(Options{Level: "trace"}); err == nil {
+		t.Error("无效级别应返回错误")
+	}
+	if _, err := New(Options{Format: "xml"}); err == nil {
+		t.Error("无效格式应返回错误")
+	}
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// redactKeep 脱敏后保留的哈希前缀长度，用于区分不同的文件名
+const redactKeep = 4
+
+// redactToken 错误信息中可能是路径的片段：不含空白、引号和常见分隔标点
+var redactToken = regexp.MustCompile(`[^\s"'<>|,，;；()（）\[\]【】]+`)
+
+// redactedError 脱敏后的错误，保持 %v、%w 等动词按错误格式化
+type redactedError struct {
+	msg string
+}
+
+func (e redactedError) Error() string {
+	return e.msg
+}
+
+// redactArgs 对日志参数中的文件名和路径脱敏：字符串和错误参数都逐个片段脱敏
+// 调用方常把 i18n.T 格式化好的整句作为一个参数传入，整体脱敏会破坏其中的说明文字和盘符
+func redactArgs(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			redacted[i] = RedactText(v)
+		case error:
+			if v == nil {
+				redacted[i] = v
+			} else {
+				redacted[i] = redactedError{msg: RedactText(v.Error())}
+			}
+		default:
+			redacted[i] = arg
+		}
+	}
+	return redacted
+}
+
+// RedactPath 对路径逐段脱敏，保留分隔符、盘符、纯数字的目录（如日期）和扩展名
+// 其余各段的主干替换为同样长度的哈希前缀加星号，例如 录音笔文件\会议记录.opus -> 1a2b*\3c4d.opus
+func RedactPath(path string) string {
+	var b strings.Builder
+	start := 0
+	for i, r := range path {
+		if r == '/' || r == '\\' {
+			b.WriteString(redactSegment(path[start:i], start == 0))
+			b.WriteRune(r)
+			start = i + 1
+		}
+	}
+	b.WriteString(redactSegment(path[start:], start == 0))
+	return b.String()
+}
+
+// RedactText 对文本中像路径或文件名的片段脱敏，片段末尾的冒号、句号等标点保留
+// 含空格的文件名只有带扩展名或分隔符的部分会被识别
+func RedactText(text string) string {
+	return redactToken.ReplaceAllStringFunc(text, func(token string) string {
+		trimmed := strings.TrimRight(token, ":：.。")
+		if !looksLikePath(trimmed) {
+			return token
+		}
+		return RedactPath(trimmed) + token[len(trimmed):]
+	})
+}
+
+// redactSegment 脱敏路径中的一段，first 表示路径的第一段（可能是盘符）
+func redactSegment(segment string, first bool) string {
+	if segment == "" || segment == "." || segment == ".." || isDigits(segment) {
+		return segment
+	}
+	if first && len(segment) == 2 && segment[1] == ':' {
+		return segment
+	}
+
+	ext := fileExt(segment)
+	stem := strings.TrimSuffix(segment, ext)
+	n := utf8.RuneCountInString(stem)
+	if n == 0 {
+		return segment
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(stem)))
+	keep := min(n, redactKeep)
+	return sum[:keep] + strings.Repeat("*", n-keep) + ext
+}
+
+// looksLikePath 判断字符串是否像路径或文件名：含路径分隔符，或带有字母组成的扩展名
+func looksLikePath(s string) bool {
+	if s == "" || strings.ContainsAny(s, "\r\n") {
+		return false
+	}
+	if strings.ContainsAny(s, `/\`) {
+		return true
+	}
+	return fileExt(s) != ""
+}
+
+// fileExt 返回文件扩展名，只认 1 到 5 个字母数字且至少含一个字母的扩展名，避免把版本号、小数当作文件名
+func fileExt(name string) string {
+	dot := strings.LastIndex(name, ".")
+	if dot <= 0 || dot == len(name)-1 {
+		return ""
+	}
+	ext := name[dot+1:]
+	if len(ext) > 5 {
+		return ""
+	}
+	hasLetter := false
+	for _, r := range ext {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return ""
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return ""
+	}
+	return name[dot:]
+}
+
+// isDigits 判断字符串是否只由数字、连字符和下划线组成（如 2024、2024-05-01）
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/allanpk716/record_center/internal/i18n"
+)
+
+// TestLogger_Redact 测试开启脱敏后日志不含原始文件名主干，保留目录层次、盘符和扩展名；关闭时正常输出
+func TestLogger_Redact(t *testing.T) {
+	const (
+		source = "内部共享存储空间\\录音笔文件\\张三周会.opus"
+		target = "D:\\backups\\2024\\张三周会.opus"
+	)
+	pathErr := fmt.Errorf("打开文件失败: %w", &os.PathError{Op: "open", Path: target, Err: os.ErrPermission})
+
+	write := func(t *testing.T, redact bool) string {
+		l, err := New(Options{Redact: redact})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		l.SetOutput(&buf)
+		l.Info("文件复制完成: %s -> %s (版本 %s)", source, target, "5.1")
+		l.Warn("复制失败: %s, %v", "张三周会.opus", pathErr)
+		return buf.String()
+	}
+
+	t.Run("开启", func(t *testing.T) {
+		out := write(t, true)
+		for _, secret := range []string{"张三周会", "录音笔文件", "backups"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("脱敏后的日志不应包含 %s:\n%s", secret, out)
+			}
+		}
+		for _, keep := range []string{"文件复制完成: ", "D:\\", "\\2024\\", ".opus ", "版本 5.1", "open D:\\", "permission denied"} {
+			if !strings.Contains(out, keep) {
+				t.Errorf("脱敏后的日志应保留 %q:\n%s", keep, out)
+			}
+		}
+		redacted := RedactPath(source)
+		if strings.Count(redacted, "\\") != 2 || !strings.HasSuffix(redacted, ".opus") {
+			t.Errorf("脱敏路径应保留层次和扩展名: %s", redacted)
+		}
+		if utf8.RuneCountInString(redacted) != utf8.RuneCountInString(source) {
+			t.Errorf("脱敏路径应保留长度: %s (%d)，原路径 %d", redacted, utf8.RuneCountInString(redacted), utf8.RuneCountInString(source))
+		}
+		if RedactPath("a\\张三.opus") == RedactPath("a\\李四.opus") {
+			t.Error("不同的文件名脱敏后应可区分")
+		}
+	})
+
+	t.Run("关闭", func(t *testing.T) {
+		out := write(t, false)
+		for _, want := range []string{source, target, "复制失败: 张三周会.opus, 打开文件失败: open " + target} {
+			if !strings.Contains(out, want) {
+				t.Errorf("未开启脱敏时应原样输出 %s:\n%s", want, out)
+			}
+		}
+	})
+}
+
+// TestLogger_RedactFormattedMessage 测试整句作为参数传入（如 i18n.T 的结果）时只脱敏其中的路径片段
+func TestLogger_RedactFormattedMessage(t *testing.T) {
+	l, err := New(Options{Redact: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	l.SetOutput(&buf)
+	l.Info("%s", i18n.T("main.target_override", "D:\\备份\\张三会议"))
+	out := buf.String()
+
+	if strings.Contains(out, "张三会议") || strings.Contains(out, "备份\\") {
+		t.Errorf("脱敏后的日志不应包含目录名:\n%s", out)
+	}
+	want := i18n.T("main.target_override", RedactPath("D:\\备份\\张三会议"))
+	if !strings.Contains(out, want) {
+		t.Errorf("脱敏后的日志应保留说明文字和盘符，期望包含 %q:\n%s", want, out)
+	}
+}
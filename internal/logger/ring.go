@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRingSize 环形缓冲默认保留的日志条数
+	DefaultRingSize = 500
+	// crashDumpInterval error 日志触发转储的最小间隔，避免连续出错时产生大量文件
+	crashDumpInterval = time.Minute
+	// crashTimeFormat 转储文件名中的时间格式
+	crashTimeFormat = "20060102_150405"
+)
+
+// RingBuffer 固定大小的环形缓冲，写满后覆盖最旧的条目
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []string
+	next    int  // 下一条写入的位置
+	full    bool // 是否已写满过一轮
+}
+
+// NewRingBuffer 创建保留最近 size 条的环形缓冲
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = DefaultRingSize
+	}
+	return &RingBuffer{entries: make([]string, size)}
+}
+
+// Add 追加一条，缓冲已满时丢弃最旧的一条
+func (rb *RingBuffer) Add(entry string) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.entries[rb.next] = entry
+	rb.next = (rb.next + 1) % len(rb.entries)
+	if rb.next == 0 {
+		rb.full = true
+	}
+}
+
+// Lines 按从旧到新的顺序获取缓冲中的条目
+func (rb *RingBuffer) Lines() []string {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if !rb.full {
+		return append([]string(nil), rb.entries[:rb.next]...)
+	}
+	lines := make([]string, 0, len(rb.entries))
+	lines = append(lines, rb.entries[rb.next:]...)
+	return append(lines, rb.entries[:rb.next]...)
+}
+
+// Len 获取缓冲中的条目数
+func (rb *RingBuffer) Len() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.full {
+		return len(rb.entries)
+	}
+	return rb.next
+}
+
+// crashRecorder 在内存中记录所有级别的最近日志，出错或崩溃时转储到 crash_<时间>.log
+// 由日志器及其 WithSession 副本共享
+type crashRecorder struct {
+	ring     *RingBuffer
+	dir      string
+	mu       sync.Mutex
+	lastDump time.Time
+	now      func() time.Time
+}
+
+// EnableCrashDump 开启环形缓冲，始终记录最近 size 条日志（含 debug），
+// 出现 error 日志或 panic 时转储到 dir 下的 crash_<时间>.log
+func (l *Logger) EnableCrashDump(size int, dir string) {
+	if dir == "" {
+		dir = "logs"
+	}
+	l.crash = &crashRecorder{ring: NewRingBuffer(size), dir: dir, now: time.Now}
+}
+
+// record 将一条日志写入环形缓冲
+func (cr *crashRecorder) record(level, session, msg string) {
+	timestamp := cr.now().Format("2006/01/02 15:04:05.000")
+	if session != "" {
+		cr.ring.Add(fmt.Sprintf("%s [%s] [session=%s] %s", timestamp, level, session, msg))
+		return
+	}
+	cr.ring.Add(fmt.Sprintf("%s [%s] %s", timestamp, level, msg))
+}
+
+// dump 将缓冲写入转储文件，force 为 false 时距上次转储不足 crashDumpInterval 则跳过
+func (cr *crashRecorder) dump(reason string, force bool) (string, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	now := cr.now()
+	if !force && !cr.lastDump.IsZero() && now.Sub(cr.lastDump) < crashDumpInterval {
+		return "", nil
+	}
+	cr.lastDump = now
+
+	if err := os.MkdirAll(cr.dir, 0755); err != nil {
+		return "", fmt.Errorf("创建崩溃日志目录失败: %w", err)
+	}
+
+	// 同一秒内多次转储时追加序号，不覆盖已有文件
+	base := filepath.Join(cr.dir, "crash_"+now.Format(crashTimeFormat))
+	path := base + ".log"
+	var file *os.File
+	var err error
+	for i := 1; ; i++ {
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, LogFilePermissions)
+		if !os.IsExist(err) {
+			break
+		}
+		path = fmt.Sprintf("%s_%d.log", base, i)
+	}
+	if err != nil {
+		return "", fmt.Errorf("创建崩溃日志失败: %w", err)
+	}
+	defer file.Close()
+
+	lines := cr.ring.Lines()
+	content := fmt.Sprintf("# 转储原因: %s\n# 转储时间: %s\n# 最近 %d 条日志:\n%s\n",
+		reason, now.Format(time.RFC3339), len(lines), strings.Join(lines, "\n"))
+	if _, err := file.WriteString(content); err != nil {
+		return "", fmt.Errorf("写入崩溃日志失败: %w", err)
+	}
+	return path, nil
+}
+
+// DumpCrash 立即将环形缓冲转储到文件，返回文件路径；未开启环形缓冲时返回空路径
+func (l *Logger) DumpCrash(reason string) (string, error) {
+	if l.crash == nil {
+		return "", nil
+	}
+	return l.crash.dump(reason, true)
+}
+
+// RecoverPanic 捕获 panic，记录错误并转储环形缓冲后继续 panic，需以 defer log.RecoverPanic() 调用
+// 只能捕获当前 goroutine 的 panic
+func (l *Logger) RecoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	// 直接写入而不经过 output，避免 error 日志先触发一次转储；panic 值和堆栈同样按配置脱敏
+	msg := fmt.Sprintf("程序崩溃: %v\n%s", r, debug.Stack())
+	reason := fmt.Sprintf("panic: %v", r)
+	if l.redact {
+		msg = RedactText(msg)
+		reason = RedactText(reason)
+	}
+	l.emit("ERROR", msg)
+	if path, err := l.DumpCrash(reason); err != nil {
+		fmt.Fprintf(os.Stderr, "转储崩溃日志失败: %v\n", err)
+	} else if path != "" {
+		fmt.Fprintf(os.Stderr, "崩溃前的日志已保存到: %s\n", path)
+	}
+	panic(r)
+}
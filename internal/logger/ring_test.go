@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRingBuffer 测试环形缓冲保留最近N条、溢出时丢弃最旧
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		count int
+		want  []string
+	}{
+		{"未写满", 3, 2, []string{"0", "1"}},
+		{"恰好写满", 3, 3, []string{"0", "1", "2"}},
+		{"溢出丢弃最旧", 3, 5, []string{"2", "3", "4"}},
+		{"多轮溢出", 3, 10, []string{"7", "8", "9"}},
+		{"空缓冲", 3, 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rb := NewRingBuffer(tt.size)
+			for i := 0; i < tt.count; i++ {
+				rb.Add(fmt.Sprint(i))
+			}
+			lines := rb.Lines()
+			if fmt.Sprint(lines) != fmt.Sprint(tt.want) {
+				t.Errorf("Lines() = %v, 期望 %v", lines, tt.want)
+			}
+			if rb.Len() != len(tt.want) {
+				t.Errorf("Len() = %d, 期望 %d", rb.Len(), len(tt.want))
+			}
+		})
+	}
+}
+
+// crashFiles 列出目录下的崩溃转储文件
+func crashFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "crash_*.log"))
+	if err != nil {
+		t.Fatalf("列出转储文件失败: %v", err)
+	}
+	return files
+}
+
+// readFile 读取文件内容
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	return string(data)
+}
+
+// TestLogger_CrashDump 测试 error 日志触发转储，转储内容包含最近N条（含未输出的 debug）
+func TestLogger_CrashDump(t *testing.T) {
+	dir := t.TempDir()
+	log, err := New(Options{Level: LevelInfo, RingSize: 3, CrashDir: dir})
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	var out bytes.Buffer
+	log.SetOutput(&out)
+
+	log.Debug("调试1")
+	log.Info("信息1")
+	log.WithSession("abcd1234").Debug("调试2")
+	log.Info("信息2")
+	if files := crashFiles(t, dir); len(files) != 0 {
+		t.Fatalf("未出错时不应转储: %v", files)
+	}
+
+	log.Error("出错了: %d", 1)
+	files := crashFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("转储文件数 = %d, 期望 1", len(files))
+	}
+
+	content := readFile(t, files[0])
+	for _, want := range []string{"[DEBUG] [session=abcd1234] 调试2", "[INFO] 信息2", "[ERROR] 出错了: 1", "最近 3 条日志"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("转储内容缺少 %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "调试1") || strings.Contains(content, "信息1") {
+		t.Errorf("转储内容应只保留最近3条:\n%s", content)
+	}
+
+	// 控制台输出仍按级别过滤
+	if strings.Contains(out.String(), "调试") {
+		t.Errorf("info 级别不应输出 debug 日志:\n%s", out.String())
+	}
+
+	// 间隔内的 error 不重复转储，主动转储不受限制
+	log.Error("出错了: %d", 2)
+	if files := crashFiles(t, dir); len(files) != 1 {
+		t.Errorf("间隔内不应重复转储: %v", files)
+	}
+	path, err := log.DumpCrash("手动")
+	if err != nil {
+		t.Fatalf("主动转储失败: %v", err)
+	}
+	if content := readFile(t, path); !strings.Contains(content, "转储原因: 手动") || !strings.Contains(content, "出错了: 2") {
+		t.Errorf("主动转储内容不符:\n%s", content)
+	}
+}
+
+// TestLogger_RecoverPanic 测试 panic 时转储并继续 panic
+func TestLogger_RecoverPanic(t *testing.T) {
+	dir := t.TempDir()
+	log, err := New(Options{Level: LevelInfo, RingSize: 10, CrashDir: dir})
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	log.SetOutput(&bytes.Buffer{})
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		defer log.RecoverPanic()
+		log.Debug("崩溃前的操作")
+		panic("boom")
+	}()
+
+	if recovered != "boom" {
+		t.Errorf("应继续 panic, recover() = %v", recovered)
+	}
+	files := crashFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("转储文件数 = %d, 期望 1: %v", len(files), files)
+	}
+	content := readFile(t, files[0])
+	for _, want := range []string{"转储原因: panic: boom", "崩溃前的操作", "程序崩溃: boom"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("转储内容缺少 %q:\n%s", want, content)
+		}
+	}
+}
+
+// TestLogger_RecoverPanicRedact 测试开启脱敏时 panic 值在日志输出和转储文件中都被脱敏
+func TestLogger_RecoverPanicRedact(t *testing.T) {
+	dir := t.TempDir()
+	log, err := New(Options{Level: LevelInfo, RingSize: 10, CrashDir: dir, Redact: true})
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	func() {
+		defer func() { recover() }()
+		defer log.RecoverPanic()
+		panic("打开失败: D:\\录音\\张三周会.opus")
+	}()
+
+	files := crashFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("转储文件数 = %d, 期望 1: %v", len(files), files)
+	}
+	for name, content := range map[string]string{"日志输出": buf.String(), "转储文件": readFile(t, files[0])} {
+		if strings.Contains(content, "张三周会") || strings.Contains(content, "录音\\") {
+			t.Errorf("%s中的 panic 值应脱敏:\n%s", name, content)
+		}
+		if !strings.Contains(content, "程序崩溃: 打开失败: "+RedactPath("D:\\录音\\张三周会.opus")) {
+			t.Errorf("%s应包含脱敏后的 panic 值:\n%s", name, content)
+		}
+	}
+}
+
+// TestLogger_NoRing 测试未开启环形缓冲时不转储
+func TestLogger_NoRing(t *testing.T) {
+	log := NewLogger(false)
+	log.SetOutput(&bytes.Buffer{})
+	log.Error("出错了")
+	if path, err := log.DumpCrash("手动"); path != "" || err != nil {
+		t.Errorf("未开启时 DumpCrash() = %q, %v", path, err)
+	}
+}
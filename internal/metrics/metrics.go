@@ -0,0 +1,257 @@
+// Package metrics 收集备份过程的实时指标，通过 Prometheus 客户端库从 /metrics 导出
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// 文件复制结果，作为 record_center_files_total 的 result 标签
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+)
+
+// Path 指标端点的路径
+const Path = "/metrics"
+
+// 当前速度按最近 speedWindow 内、最多 speedSamples 次 AddBytes 上报的字节数计算，复制停滞时随样本过期降为0
+const (
+	speedWindow  = 10 * time.Second
+	speedSamples = 64
+)
+
+// byteSample 一次 AddBytes 上报的字节数
+type byteSample struct {
+	at    time.Time
+	bytes int64
+}
+
+// deviceRun 一个设备正在进行的备份，用于计算当前速度
+type deviceRun struct {
+	running   bool         // 正在备份，速度只在备份过程中计算
+	runStart  time.Time    // 本次备份开始时间
+	samples   []byteSample // 窗口内的上报样本，按时间先后排列
+	droppedAt time.Time    // 因样本数上限丢弃的最后一个样本的时间，计算速度的区间从这之后开始
+}
+
+// Metrics 按设备汇总的备份指标，基于 client_golang 的计数器和仪表注册到独立的 Registry，
+// 方法可并发调用，nil 时所有方法不做任何事
+type Metrics struct {
+	registry    *prometheus.Registry
+	copiedBytes *prometheus.CounterVec
+	files       *prometheus.CounterVec
+	online      *prometheus.GaugeVec
+	lastBackup  *prometheus.GaugeVec
+	speedDesc   *prometheus.Desc
+
+	mu    sync.Mutex
+	runs  map[string]*deviceRun
+	clock utils.Clock
+}
+
+// Default 进程内共享的指标，备份管理器默认上报到这里
+var Default = New()
+
+// New 创建空的指标集合
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		copiedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "record_center_copied_bytes_total",
+			Help: "累计复制到目标的字节数",
+		}, []string{"device"}),
+		files: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "record_center_files_total",
+			Help: "按结果统计的文件数",
+		}, []string{"device", "result"}),
+		online: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "record_center_device_online",
+			Help: "设备是否在线（1在线，0离线）",
+		}, []string{"device"}),
+		lastBackup: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "record_center_last_backup_timestamp_seconds",
+			Help: "上次备份完成的Unix时间戳，尚未备份时为0",
+		}, []string{"device"}),
+		speedDesc: prometheus.NewDesc("record_center_copy_speed_bytes_per_second",
+			"最近10秒的复制速度，未在备份时为0", []string{"device"}, nil),
+		runs:  make(map[string]*deviceRun),
+		clock: utils.SystemClock,
+	}
+	m.registry.MustRegister(m.copiedBytes, m.files, m.online, m.lastBackup, speedCollector{m})
+	return m
+}
+
+// SetClock 替换计算速度使用的时钟，测试时注入假时钟
+func (m *Metrics) SetClock(clock utils.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
+// device 首次出现的设备创建所有序列，使 /metrics 中每个设备的各指标都有取值
+func (m *Metrics) device(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.runs[name]; ok {
+		return
+	}
+	m.runs[name] = &deviceRun{}
+	m.copiedBytes.WithLabelValues(name)
+	for _, result := range []string{ResultSuccess, ResultFailed, ResultSkipped} {
+		m.files.WithLabelValues(name, result)
+	}
+	m.online.WithLabelValues(name)
+	m.lastBackup.WithLabelValues(name)
+}
+
+// SetOnline 设置设备在线状态
+func (m *Metrics) SetOnline(device string, online bool) {
+	if m == nil {
+		return
+	}
+	m.device(device)
+	value := 0.0
+	if online {
+		value = 1
+	}
+	m.online.WithLabelValues(device).Set(value)
+}
+
+// RunStarted 设备开始一次备份，设备视为在线，当前速度从零开始计算
+func (m *Metrics) RunStarted(device string) {
+	if m == nil {
+		return
+	}
+	m.SetOnline(device, true)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*m.runs[device] = deviceRun{running: true, runStart: m.clock.Now()}
+}
+
+// AddBytes 累加设备新复制的字节数，计数器只增不减，n 不为正时忽略
+func (m *Metrics) AddBytes(device string, n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.device(device)
+	m.copiedBytes.WithLabelValues(device).Add(float64(n))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run := m.runs[device]
+	run.samples = append(run.samples, byteSample{at: m.clock.Now(), bytes: n})
+	if len(run.samples) > speedSamples {
+		run.droppedAt = run.samples[0].at
+		run.samples = append(run.samples[:0], run.samples[1:]...)
+	}
+}
+
+// AddFile 记录一个文件的复制结果，result 为 ResultSuccess、ResultFailed 或 ResultSkipped
+func (m *Metrics) AddFile(device, result string) {
+	if m == nil {
+		return
+	}
+	m.device(device)
+	m.files.WithLabelValues(device, result).Inc()
+}
+
+// RunEnded 设备的一次备份结束（包括出错中止），当前速度归零
+func (m *Metrics) RunEnded(device string) {
+	if m == nil {
+		return
+	}
+	m.device(device)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[device].running = false
+}
+
+// SetLastBackup 记录设备最近一次完成备份的时间
+func (m *Metrics) SetLastBackup(device string, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.device(device)
+	m.lastBackup.WithLabelValues(device).Set(float64(at.Unix()))
+}
+
+// speed 最近一段时间的复制速度（字节/秒），未在备份时为0，调用方需持有 m.mu
+// 区间从窗口起点、本次备份开始和最后丢弃的样本三者中最晚的时刻算起，区间内样本的字节数除以区间长度
+func (m *Metrics) speed(run *deviceRun) float64 {
+	if !run.running {
+		return 0
+	}
+	now := m.clock.Now()
+	from := now.Add(-speedWindow)
+	if run.runStart.After(from) {
+		from = run.runStart
+	}
+	if run.droppedAt.After(from) {
+		from = run.droppedAt
+	}
+
+	elapsed := now.Sub(from).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	var bytes int64
+	for _, sample := range run.samples {
+		// 样本是截至上报时刻复制的字节，恰在区间起点的样本属于区间之前；备份刚开始时上报的除外
+		if sample.at.After(from) || sample.at.Equal(run.runStart) && from.Equal(run.runStart) {
+			bytes += sample.bytes
+		}
+	}
+	return float64(bytes) / elapsed
+}
+
+// speedCollector 采集时按当前时间计算各设备的复制速度
+type speedCollector struct {
+	m *Metrics
+}
+
+// Describe 实现 prometheus.Collector
+func (c speedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.m.speedDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c speedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	for device, run := range c.m.runs {
+		ch <- prometheus.MustNewConstMetric(c.m.speedDesc, prometheus.GaugeValue, c.m.speed(run), device)
+	}
+}
+
+// Gather 实现 prometheus.Gatherer，采集当前所有指标
+func (m *Metrics) Gather() ([]*dto.MetricFamily, error) {
+	return m.registry.Gather()
+}
+
+// Handler 返回导出指标的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve 在 addr 上启动 /metrics 端点，返回的服务器需由调用方关闭
+func Serve(addr string, m *Metrics) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle(Path, m.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("监听指标端点 %s 失败: %w", addr, err)
+	}
+	go server.Serve(listener)
+	return server, nil
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestMetrics_Run 测试一次备份过程中和结束后各指标的取值
+func TestMetrics_Run(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	clock := utils.NewFakeClock(start)
+	m := New()
+	m.SetClock(clock)
+
+	m.RunStarted("SR302")
+	m.AddBytes("SR302", 3000)
+	m.AddBytes("SR302", -100)
+	m.AddFile("SR302", ResultSuccess)
+	m.AddFile("SR302", ResultSkipped)
+	m.AddFile("SR302", ResultFailed)
+	m.AddFile("SR302", ResultSuccess)
+	clock.Advance(2 * time.Second)
+
+	expected := `
+# HELP record_center_copied_bytes_total 累计复制到目标的字节数
+# TYPE record_center_copied_bytes_total counter
+record_center_copied_bytes_total{device="SR302"} 3000
+# HELP record_center_copy_speed_bytes_per_second 最近10秒的复制速度，未在备份时为0
+# TYPE record_center_copy_speed_bytes_per_second gauge
+record_center_copy_speed_bytes_per_second{device="SR302"} 1500
+# HELP record_center_device_online 设备是否在线（1在线，0离线）
+# TYPE record_center_device_online gauge
+record_center_device_online{device="SR302"} 1
+# HELP record_center_files_total 按结果统计的文件数
+# TYPE record_center_files_total counter
+record_center_files_total{device="SR302",result="failed"} 1
+record_center_files_total{device="SR302",result="skipped"} 1
+record_center_files_total{device="SR302",result="success"} 2
+# HELP record_center_last_backup_timestamp_seconds 上次备份完成的Unix时间戳，尚未备份时为0
+# TYPE record_center_last_backup_timestamp_seconds gauge
+record_center_last_backup_timestamp_seconds{device="SR302"} 0
+`
+	if err := testutil.GatherAndCompare(m, strings.NewReader(expected)); err != nil {
+		t.Errorf("备份中的指标不符: %v", err)
+	}
+
+	// 结束后速度归零，记录完成时间；第二次备份累加计数
+	m.SetLastBackup("SR302", clock.Now())
+	m.RunEnded("SR302")
+	m.RunStarted("SR302")
+	m.AddBytes("SR302", 500)
+	m.AddFile("SR302", ResultSuccess)
+	m.SetOnline("SR302", false)
+	clock.Advance(time.Minute)
+	m.RunEnded("SR302")
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"累计字节数", testutil.ToFloat64(m.copiedBytes.WithLabelValues("SR302")), 3500},
+		{"成功文件数", testutil.ToFloat64(m.files.WithLabelValues("SR302", ResultSuccess)), 3},
+		{"在线状态", testutil.ToFloat64(m.online.WithLabelValues("SR302")), 0},
+		{"上次备份时间", testutil.ToFloat64(m.lastBackup.WithLabelValues("SR302")), float64(start.Add(2 * time.Second).Unix())},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("备份后%s = %v，期望 %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if err := testutil.GatherAndCompare(m, strings.NewReader(`
+# HELP record_center_copy_speed_bytes_per_second 最近10秒的复制速度，未在备份时为0
+# TYPE record_center_copy_speed_bytes_per_second gauge
+record_center_copy_speed_bytes_per_second{device="SR302"} 0
+`), "record_center_copy_speed_bytes_per_second"); err != nil {
+		t.Errorf("备份结束后速度应归零: %v", err)
+	}
+}
+
+// TestMetrics_Speed 测试当前速度只按最近的上报计算：复制停滞时降为0，上报频繁时按最近的样本计算
+func TestMetrics_Speed(t *testing.T) {
+	clock := utils.NewFakeClock(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	m := New()
+	m.SetClock(clock)
+	speed := func() float64 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.speed(m.runs["SR302"])
+	}
+
+	m.RunStarted("SR302")
+	for i := 0; i < 30; i++ {
+		clock.Advance(time.Second)
+		m.AddBytes("SR302", 1000)
+	}
+	if got := speed(); got != 1000 {
+		t.Errorf("稳定复制时速度 = %v，期望 1000", got)
+	}
+
+	clock.Advance(5 * time.Second)
+	if got := speed(); got != 500 {
+		t.Errorf("停滞 5 秒后速度 = %v，期望最近10秒的 500", got)
+	}
+	clock.Advance(10 * time.Second)
+	if got := speed(); got != 0 {
+		t.Errorf("停滞超过窗口后速度 = %v，期望 0", got)
+	}
+
+	// 每 10 毫秒上报一次，超过样本上限后按保留的样本覆盖的区间计算
+	for i := 0; i < 2*speedSamples; i++ {
+		clock.Advance(10 * time.Millisecond)
+		m.AddBytes("SR302", 100)
+	}
+	if got := speed(); got < 9999 || got > 10001 {
+		t.Errorf("频繁上报时速度 = %v，期望 10000", got)
+	}
+}
+
+// TestMetrics_Handler 测试 /metrics 端点输出所有指标的 HELP/TYPE 并转义标签值
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.SetOnline(`会议室"1"`, true)
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + Path)
+	if err != nil {
+		t.Fatalf("请求指标端点失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %s", resp.Header.Get("Content-Type"))
+	}
+	for _, want := range []string{
+		"# TYPE record_center_copied_bytes_total counter",
+		"# TYPE record_center_copy_speed_bytes_per_second gauge",
+		"# TYPE record_center_files_total counter",
+		"# TYPE record_center_device_online gauge",
+		"# TYPE record_center_last_backup_timestamp_seconds gauge",
+		`record_center_device_online{device="会议室\"1\""} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("输出缺少 %q:\n%s", want, body)
+		}
+	}
+}
+
+// TestMetrics_Nil 测试未启用指标（nil）时上报不做任何事
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	m.RunStarted("SR302")
+	m.AddBytes("SR302", 1)
+	m.AddFile("SR302", ResultSuccess)
+	m.SetOnline("SR302", true)
+	m.SetLastBackup("SR302", time.Now())
+	m.RunEnded("SR302")
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// 通知时机
+const (
+	OnAlways  = "always"  // 每次备份后发送
+	OnFailure = "failure" // 有文件失败或备份出错时发送
+	OnNever   = "never"   // 不发送
+)
+
+// 邮件加密方式
+const (
+	TLSStartTLS = "starttls" // 明文连接后通过 STARTTLS 升级
+	TLSImplicit = "tls"      // 直接建立TLS连接
+	TLSNone     = "none"     // 不加密
+)
+
+// dialTimeout 连接SMTP服务器的超时时间
+const dialTimeout = 30 * time.Second
+
+// Report 一次备份的结果，用于生成通知
+type Report struct {
+	Hostname string
+	Summary  storage.RunSummary
+	Err      error // 备份过程中的错误，文件复制失败以外的错误（如扫描设备失败）也在这里
+}
+
+// Failed 备份是否失败：出错或有文件复制失败
+func (r *Report) Failed() bool {
+	return r.Err != nil || r.Summary.Failed > 0
+}
+
+// ShouldNotify 按通知时机判断是否需要发送
+func ShouldNotify(on string, report *Report) bool {
+	switch on {
+	case OnAlways:
+		return true
+	case OnFailure:
+		return report.Failed()
+	default:
+		return false
+	}
+}
+
+// BuildEmail 生成通知邮件的主题和正文，正文包含成功、失败、跳过数等统计摘要
+func BuildEmail(report *Report) (string, string) {
+	summary := report.Summary
+	status := "成功"
+	if report.Failed() {
+		status = "失败"
+	}
+
+	subject := fmt.Sprintf("[record_center] %s 备份%s", summary.DeviceName, status)
+	if report.Hostname != "" {
+		subject += " (" + report.Hostname + ")"
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "备份结果: %s\n", status)
+	if report.Hostname != "" {
+		fmt.Fprintf(&body, "主机: %s\n", report.Hostname)
+	}
+	fmt.Fprintf(&body, "设备: %s\n", summary.DeviceName)
+	if !summary.StartTime.IsZero() {
+		fmt.Fprintf(&body, "开始时间: %s\n", summary.StartTime.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(&body, "耗时: %s\n", utils.FormatDuration(summary.Duration))
+	fmt.Fprintf(&body, "\n")
+	fmt.Fprintf(&body, "扫描文件: %d\n", summary.Scanned)
+	fmt.Fprintf(&body, "成功: %d\n", summary.Succeeded)
+	fmt.Fprintf(&body, "失败: %d\n", summary.Failed)
+	fmt.Fprintf(&body, "跳过: %d\n", summary.Skipped)
+	fmt.Fprintf(&body, "复制大小: %s\n", utils.FormatBytes(summary.Bytes))
+	if report.Err != nil {
+		fmt.Fprintf(&body, "\n错误: %v\n", report.Err)
+	}
+	return subject, body.String()
+}
+
+// EmailNotifier 备份结果邮件通知器
+type EmailNotifier struct {
+	cfg  config.EmailConfig
+	on   string
+	log  *logger.Logger
+	send func(from string, to []string, msg []byte) error // 发送邮件，测试时替换
+}
+
+// NewEmailNotifier 创建邮件通知器，未配置SMTP服务器或通知时机为 never 时返回 nil
+func NewEmailNotifier(cfg *config.NotifyConfig, log *logger.Logger) *EmailNotifier {
+	if cfg == nil || cfg.Email.Host == "" || cfg.On == OnNever {
+		return nil
+	}
+
+	n := &EmailNotifier{cfg: cfg.Email, on: cfg.On, log: log}
+	n.send = n.sendMail
+	return n
+}
+
+// Notify 按通知时机发送备份结果邮件，发送失败只记录警告
+func (n *EmailNotifier) Notify(report *Report) {
+	if n == nil || !ShouldNotify(n.on, report) {
+		return
+	}
+	if report.Hostname == "" {
+		report.Hostname, _ = os.Hostname()
+	}
+
+	subject, body := BuildEmail(report)
+	from := n.cfg.From
+	if from == "" {
+		from = n.cfg.Username
+	}
+	if err := n.send(from, n.cfg.To, buildMessage(from, n.cfg.To, subject, body)); err != nil {
+		n.log.Warn("发送备份通知邮件失败: %v", err)
+		return
+	}
+	n.log.Info("备份通知邮件已发送: %s", strings.Join(n.cfg.To, ", "))
+}
+
+// buildMessage 组装 UTF-8 纯文本邮件
+func buildMessage(from string, to []string, subject, body string) []byte {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: =?UTF-8?B?%s?=\r\n", base64.StdEncoding.EncodeToString([]byte(subject)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	msg.WriteString(wrapLines(base64.StdEncoding.EncodeToString([]byte(strings.ReplaceAll(body, "\n", "\r\n"))), 76))
+	return []byte(msg.String())
+}
+
+// wrapLines 按 width 个字符折行，邮件正文每行不超过 76 个字符
+func wrapLines(s string, width int) string {
+	var b strings.Builder
+	for len(s) > width {
+		b.WriteString(s[:width])
+		b.WriteString("\r\n")
+		s = s[width:]
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// sendMail 通过 net/smtp 发送邮件
+func (n *EmailNotifier) sendMail(from string, to []string, msg []byte) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host, InsecureSkipVerify: n.cfg.SkipVerify}
+
+	var conn net.Conn
+	var err error
+	if n.cfg.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, dialTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("建立SMTP会话失败: %w", err)
+	}
+	defer client.Close()
+
+	if n.cfg.TLS == TLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS失败: %w", err)
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("设置发件人失败: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("设置收件人 %s 失败: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件内容失败: %w", err)
+	}
+	if _, err := writer.Write(msg); err != nil {
+		writer.Close()
+		return fmt.Errorf("发送邮件内容失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("发送邮件内容失败: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// TestBuildEmail 测试邮件正文包含统计摘要
+func TestBuildEmail(t *testing.T) {
+	report := &Report{
+		Hostname: "backup-pc",
+		Summary: storage.RunSummary{
+			DeviceName: "SR302",
+			StartTime:  time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local),
+			Duration:   90 * time.Second,
+			Scanned:    20,
+			Succeeded:  12,
+			Failed:     3,
+			Skipped:    5,
+			Bytes:      2048,
+		},
+	}
+
+	subject, body := BuildEmail(report)
+	if !strings.Contains(subject, "SR302") || !strings.Contains(subject, "失败") {
+		t.Errorf("主题应包含设备名称和失败状态: %s", subject)
+	}
+	for _, want := range []string{"成功: 12", "失败: 3", "跳过: 5", "扫描文件: 20", "backup-pc", "2024-05-01 10:00:00"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("正文缺少 %q:\n%s", want, body)
+		}
+	}
+
+	report.Summary.Failed = 0
+	report.Err = errors.New("扫描设备文件失败")
+	subject, body = BuildEmail(report)
+	if !strings.Contains(subject, "失败") || !strings.Contains(body, "扫描设备文件失败") {
+		t.Errorf("备份出错时应标记失败并附上错误:\n%s\n%s", subject, body)
+	}
+}
+
+// TestEmailNotifier_On 测试各通知时机下是否发送邮件
+func TestEmailNotifier_On(t *testing.T) {
+	tests := []struct {
+		name     string
+		on       string
+		failed   int
+		runErr   error
+		wantSent bool
+	}{
+		{"always无失败", OnAlways, 0, nil, true},
+		{"failure无失败不发送", OnFailure, 0, nil, false},
+		{"failure有失败", OnFailure, 2, nil, true},
+		{"failure备份出错", OnFailure, 0, errors.New("设备检测失败"), true},
+		{"never有失败", OnNever, 2, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &EmailNotifier{
+				cfg: config.EmailConfig{Host: "smtp.example.com", Username: "rc@example.com", To: []string{"admin@example.com"}},
+				on:  tt.on,
+				log: logger.NewLogger(false),
+			}
+			sent := false
+			n.send = func(from string, to []string, msg []byte) error {
+				sent = true
+				if from != "rc@example.com" || len(to) != 1 || to[0] != "admin@example.com" {
+					t.Errorf("发件人或收件人不正确: %s -> %v", from, to)
+				}
+				return nil
+			}
+
+			n.Notify(&Report{Hostname: "backup-pc", Summary: storage.RunSummary{DeviceName: "SR302", Failed: tt.failed}, Err: tt.runErr})
+			if sent != tt.wantSent {
+				t.Errorf("是否发送 = %v, 期望 %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+// TestNewEmailNotifier 测试未配置SMTP服务器或 never 时不创建通知器
+func TestNewEmailNotifier(t *testing.T) {
+	log := logger.NewLogger(false)
+	if n := NewEmailNotifier(&config.NotifyConfig{On: OnAlways}, log); n != nil {
+		t.Error("未配置SMTP服务器时应返回nil")
+	}
+	if n := NewEmailNotifier(&config.NotifyConfig{On: OnNever, Email: config.EmailConfig{Host: "smtp.example.com"}}, log); n != nil {
+		t.Error("通知时机为 never 时应返回nil")
+	}
+
+	// nil 通知器可直接调用
+	var n *EmailNotifier
+	n.Notify(&Report{Err: errors.New("失败")})
+}
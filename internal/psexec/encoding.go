@@ -0,0 +1,74 @@
+package psexec
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// UTF8Prelude 强制PowerShell以UTF-8输出的脚本前缀，避免中文文件名按系统代码页（GBK）输出
+const UTF8Prelude = "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; $OutputEncoding = [System.Text.Encoding]::UTF8; "
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+)
+
+// EnsureUTF8Script 在脚本前加上 UTF8Prelude，脚本已设置 [Console]::OutputEncoding 时原样返回
+func EnsureUTF8Script(script string) string {
+	if strings.Contains(strings.ToLower(script), "[console]::outputencoding") {
+		return script
+	}
+	return UTF8Prelude + script
+}
+
+// EnsureUTF8Args 为 -Command 后的脚本参数加上 UTF8Prelude；-Command - 从标准输入读取，由调用方自行设置
+func EnsureUTF8Args(args []string) []string {
+	for i := 0; i < len(args)-1; i++ {
+		if !strings.EqualFold(args[i], "-Command") || args[i+1] == "-" {
+			continue
+		}
+		fixed := append([]string(nil), args...)
+		fixed[i+1] = EnsureUTF8Script(args[i+1])
+		return fixed
+	}
+	return args
+}
+
+// DecodeOutput 将PowerShell输出转为UTF-8字符串：去除BOM，UTF-16LE输出按UTF-16解码，
+// 不是合法UTF-8时按GBK回退解码（旧版PowerShell或脚本未能切换输出编码时）
+func DecodeOutput(output []byte) string {
+	if bytes.HasPrefix(output, utf16LEBOM) {
+		decoded, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder().Bytes(output)
+		if err == nil {
+			return string(decoded)
+		}
+	}
+
+	output = bytes.TrimPrefix(output, utf8BOM)
+	if utf8.Valid(output) {
+		return string(output)
+	}
+
+	decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(output)
+	if err != nil {
+		return string(output)
+	}
+	return string(decoded)
+}
+
+// Output 执行命令并返回解码后的标准输出
+func Output(cmd *exec.Cmd) (string, error) {
+	output, err := cmd.Output()
+	return DecodeOutput(output), err
+}
+
+// CombinedOutput 执行命令并返回解码后的标准输出和标准错误
+func CombinedOutput(cmd *exec.Cmd) (string, error) {
+	output, err := cmd.CombinedOutput()
+	return DecodeOutput(output), err
+}
@@ -0,0 +1,95 @@
+package psexec
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// mustEncode 将UTF-8字符串编码为模拟的PowerShell输出字节
+func mustEncode(t *testing.T, encode func([]byte) ([]byte, error), text string) []byte {
+	data, err := encode([]byte(text))
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	return data
+}
+
+// TestDecodeOutput 测试不同编码的PowerShell输出都能解出中文文件名
+func TestDecodeOutput(t *testing.T) {
+	const text = "FILE|录音笔文件\\2024会议记录.opus|1024\r\n"
+	gbk := simplifiedchinese.GBK.NewEncoder().Bytes
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes
+
+	tests := []struct {
+		name   string
+		output []byte
+	}{
+		{"UTF-8", []byte(text)},
+		{"带BOM的UTF-8", append([]byte{0xEF, 0xBB, 0xBF}, text...)},
+		{"GBK", mustEncode(t, gbk, text)},
+		{"带BOM的UTF-16LE", mustEncode(t, utf16, text)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := DecodeOutput(tt.output)
+			if decoded != text {
+				t.Fatalf("解码结果 = %q，期望 %q", decoded, text)
+			}
+			fields := strings.Split(strings.TrimSpace(decoded), "|")
+			if len(fields) != 3 || fields[1] != "录音笔文件\\2024会议记录.opus" {
+				t.Errorf("解析出的文件名不正确: %q", fields)
+			}
+		})
+	}
+
+	if got := DecodeOutput(nil); got != "" {
+		t.Errorf("空输出应解码为空字符串，实际 %q", got)
+	}
+}
+
+// TestEnsureUTF8Args 测试 -Command 脚本统一加上UTF-8输出设置
+func TestEnsureUTF8Args(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			"脚本前加上UTF-8设置",
+			[]string{"-ExecutionPolicy", "Bypass", "-Command", "Get-ChildItem"},
+			[]string{"-ExecutionPolicy", "Bypass", "-Command", UTF8Prelude + "Get-ChildItem"},
+		},
+		{
+			"已设置输出编码的脚本不重复添加",
+			[]string{"-Command", "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8\nGet-ChildItem"},
+			[]string{"-Command", "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8\nGet-ChildItem"},
+		},
+		{
+			"从标准输入读取命令时不修改",
+			[]string{"-NoProfile", "-Command", "-"},
+			[]string{"-NoProfile", "-Command", "-"},
+		},
+		{
+			"没有 -Command 时不修改",
+			[]string{"-NoProfile", "-File", "a.ps1"},
+			[]string{"-NoProfile", "-File", "a.ps1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]string(nil), tt.args...)
+			got := EnsureUTF8Args(tt.args)
+			if strings.Join(got, "\x00") != strings.Join(tt.want, "\x00") {
+				t.Errorf("EnsureUTF8Args() = %q，期望 %q", got, tt.want)
+			}
+			if strings.Join(tt.args, "\x00") != strings.Join(original, "\x00") {
+				t.Error("不应修改传入的参数")
+			}
+		})
+	}
+}
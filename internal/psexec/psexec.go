@@ -0,0 +1,268 @@
+package psexec
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// DefaultExecutable 非严格模式下探测失败时使用的可执行文件
+const DefaultExecutable = "powershell"
+
+// ProbeFunc 探测可执行文件是否存在并返回其版本号（如 "5.1.19041.1682"）
+type ProbeFunc func(exe string) (string, error)
+
+// Selector PowerShell可执行文件选择器
+// 按 FallbackOrder 探测 powershell/pwsh，选出满足 PreferredVersion 的版本并缓存
+type Selector struct {
+	config   config.PowerShellConfig
+	log      *logger.Logger
+	probe    ProbeFunc
+	mutex    sync.Mutex
+	selected string
+	version  string
+	err      error // 选择失败的结果，同样缓存，避免每次构建命令都重新探测
+}
+
+// NewSelector 创建PowerShell选择器
+func NewSelector(cfg *config.PowerShellConfig, log *logger.Logger) *Selector {
+	return NewSelectorWithProbe(cfg, log, probeExecutable)
+}
+
+// NewSelectorWithProbe 使用自定义探测函数创建选择器
+func NewSelectorWithProbe(cfg *config.PowerShellConfig, log *logger.Logger, probe ProbeFunc) *Selector {
+	selectorConfig := config.DefaultConfig().PowerShell
+	if cfg != nil {
+		selectorConfig = *cfg
+	}
+	if len(selectorConfig.FallbackOrder) == 0 {
+		selectorConfig.FallbackOrder = []string{"powershell", "pwsh"}
+	}
+	if selectorConfig.PreferredVersion == "" {
+		selectorConfig.PreferredVersion = "auto"
+	}
+
+	return &Selector{
+		config: selectorConfig,
+		log:    log,
+		probe:  probe,
+	}
+}
+
+// Executable 返回选中的可执行文件，首次调用时探测并缓存结果（包括失败的结果）
+func (s *Selector) Executable() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.selected != "" || s.err != nil {
+		return s.selected, s.err
+	}
+
+	exe, version, err := s.selectExecutable()
+	if err != nil {
+		s.err = err
+		if s.strict() {
+			s.log.Error("PowerShell选择失败，严格模式下不使用其他版本: %v", err)
+		} else {
+			s.log.Warn("PowerShell选择失败，使用默认的 %s: %v", DefaultExecutable, err)
+		}
+		return "", err
+	}
+
+	s.selected = exe
+	s.version = version
+	s.log.Debug("选择PowerShell: %s (版本: %s)", exe, version)
+	return exe, nil
+}
+
+// Version 返回选中可执行文件的版本号，未选择时为空
+func (s *Selector) Version() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.version
+}
+
+// Reset 清除缓存的选择结果
+func (s *Selector) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.selected = ""
+	s.version = ""
+	s.err = nil
+}
+
+// Command 使用选中的可执行文件构建命令，选择失败时非严格模式退回 DefaultExecutable，
+// 严格模式不降级：返回的命令执行（Run/Start/Output）时直接返回选择失败的错误
+// -Command 后的脚本会加上 UTF8Prelude，保证中文输出以UTF-8编码
+func (s *Selector) Command(args ...string) *exec.Cmd {
+	exe, err := s.commandExecutable()
+	cmd := exec.Command(exe, EnsureUTF8Args(args)...)
+	if err != nil {
+		cmd.Err = err
+	}
+	return cmd
+}
+
+// CommandContext 与 Command 相同，ctx 结束时结束 PowerShell 进程
+func (s *Selector) CommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	exe, err := s.commandExecutable()
+	cmd := exec.CommandContext(ctx, exe, EnsureUTF8Args(args)...)
+	if err != nil {
+		cmd.Err = err
+	}
+	return cmd
+}
+
+// commandExecutable 构建命令使用的可执行文件，选择失败时非严格模式退回 DefaultExecutable，严格模式返回错误
+func (s *Selector) commandExecutable() (string, error) {
+	exe, err := s.Executable()
+	if err == nil {
+		return exe, nil
+	}
+	if s.strict() {
+		return DefaultExecutable, fmt.Errorf("PowerShell选择失败，严格模式下不降级: %w", err)
+	}
+	return DefaultExecutable, nil
+}
+
+// strict 是否为严格兼容模式，不允许使用不满足首选版本的PowerShell
+func (s *Selector) strict() bool {
+	return s.config.CompatibilityMode == "strict"
+}
+
+// selectExecutable 按降级顺序探测并选择可执行文件
+func (s *Selector) selectExecutable() (string, string, error) {
+	type candidate struct {
+		exe     string
+		version string
+	}
+
+	var available []candidate
+	for _, exe := range s.config.FallbackOrder {
+		version, err := s.probe(exe)
+		if err != nil {
+			s.log.Debug("PowerShell %s 不可用: %v", exe, err)
+			continue
+		}
+		available = append(available, candidate{exe: exe, version: version})
+	}
+
+	if len(available) == 0 {
+		return "", "", fmt.Errorf("未找到可用的PowerShell，已尝试: %s", strings.Join(s.config.FallbackOrder, ", "))
+	}
+
+	preferred := strings.ToLower(s.config.PreferredVersion)
+	if preferred == "auto" {
+		return available[0].exe, available[0].version, nil
+	}
+
+	for _, c := range available {
+		if versionMatches(c.version, preferred) {
+			return c.exe, c.version, nil
+		}
+	}
+
+	// 严格模式下不允许降级到不满足首选版本的PowerShell
+	if s.strict() {
+		return "", "", fmt.Errorf("没有满足首选版本 %s 的PowerShell", s.config.PreferredVersion)
+	}
+
+	s.log.Warn("首选版本 %s 不可用，降级使用 %s (%s)", s.config.PreferredVersion, available[0].exe, available[0].version)
+	return available[0].exe, available[0].version, nil
+}
+
+// versionMatches 检查版本号是否满足首选版本
+func versionMatches(version, preferred string) bool {
+	major, minor, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+
+	switch preferred {
+	case "5.1":
+		return major == 5 && minor == 1
+	case "5":
+		return major == 5
+	case "7.x", "7":
+		return major >= 7
+	}
+	return false
+}
+
+// versionPattern 匹配版本号中的主次版本
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// parseVersion 解析主次版本号
+func parseVersion(version string) (int, int, bool) {
+	matches := versionPattern.FindStringSubmatch(version)
+	if len(matches) < 3 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// probeExecutable 执行可执行文件获取PowerShell版本
+func probeExecutable(exe string) (string, error) {
+	if _, err := exec.LookPath(exe); err != nil {
+		return "", fmt.Errorf("找不到 %s: %w", exe, err)
+	}
+
+	output, err := exec.Command(exe, "-NoProfile", "-Command", "$PSVersionTable.PSVersion.ToString()").Output()
+	if err != nil {
+		return "", fmt.Errorf("执行 %s 失败: %w", exe, err)
+	}
+
+	version := strings.TrimSpace(string(output))
+	if version == "" {
+		return "", fmt.Errorf("无法获取 %s 版本信息", exe)
+	}
+	return version, nil
+}
+
+var (
+	defaultSelector *Selector
+	defaultMutex    sync.Mutex
+)
+
+// Init 使用配置初始化全局选择器
+func Init(cfg *config.PowerShellConfig, log *logger.Logger) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultSelector = NewSelector(cfg, log)
+}
+
+// Default 返回全局选择器，未初始化时使用默认配置创建
+func Default() *Selector {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	if defaultSelector == nil {
+		defaultSelector = NewSelector(nil, logger.NewLogger(false))
+	}
+	return defaultSelector
+}
+
+// Command 使用全局选择器构建PowerShell命令
+func Command(args ...string) *exec.Cmd {
+	return Default().Command(args...)
+}
+
+// CommandContext 使用全局选择器构建 ctx 结束时被结束的PowerShell命令
+func CommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	return Default().CommandContext(ctx, args...)
+}
@@ -0,0 +1,180 @@
+package psexec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// mockProbe 根据给定的可用版本表模拟探测
+func mockProbe(versions map[string]string) ProbeFunc {
+	return func(exe string) (string, error) {
+		if version, ok := versions[exe]; ok {
+			return version, nil
+		}
+		return "", fmt.Errorf("找不到 %s", exe)
+	}
+}
+
+// TestSelector_Executable 测试按配置选择PowerShell
+func TestSelector_Executable(t *testing.T) {
+	bothAvailable := map[string]string{"powershell": "5.1.19041.1682", "pwsh": "7.4.1"}
+
+	testCases := []struct {
+		name        string
+		preferred   string
+		fallback    []string
+		mode        string
+		versions    map[string]string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:      "只有pwsh可用时选中pwsh",
+			preferred: "auto",
+			fallback:  []string{"powershell", "pwsh"},
+			mode:      "strict",
+			versions:  map[string]string{"pwsh": "7.4.1"},
+			expected:  "pwsh",
+		},
+		{
+			name:      "偏好5.1时优先powershell",
+			preferred: "5.1",
+			fallback:  []string{"pwsh", "powershell"},
+			mode:      "strict",
+			versions:  bothAvailable,
+			expected:  "powershell",
+		},
+		{
+			name:      "偏好7.x时选中pwsh",
+			preferred: "7.x",
+			fallback:  []string{"powershell", "pwsh"},
+			mode:      "strict",
+			versions:  bothAvailable,
+			expected:  "pwsh",
+		},
+		{
+			name:      "auto按降级顺序选择",
+			preferred: "auto",
+			fallback:  []string{"pwsh", "powershell"},
+			mode:      "strict",
+			versions:  bothAvailable,
+			expected:  "pwsh",
+		},
+		{
+			name:        "严格模式不允许降级",
+			preferred:   "7.x",
+			fallback:    []string{"powershell", "pwsh"},
+			mode:        "strict",
+			versions:    map[string]string{"powershell": "5.1.19041.1682"},
+			expectError: true,
+		},
+		{
+			name:      "宽松模式允许降级",
+			preferred: "7.x",
+			fallback:  []string{"powershell", "pwsh"},
+			mode:      "loose",
+			versions:  map[string]string{"powershell": "5.1.19041.1682"},
+			expected:  "powershell",
+		},
+		{
+			name:        "没有可用的PowerShell",
+			preferred:   "auto",
+			fallback:    []string{"powershell", "pwsh"},
+			mode:        "loose",
+			versions:    map[string]string{},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.PowerShellConfig{
+				PreferredVersion:  tc.preferred,
+				FallbackOrder:     tc.fallback,
+				CompatibilityMode: tc.mode,
+			}
+			selector := NewSelectorWithProbe(cfg, logger.NewLogger(false), mockProbe(tc.versions))
+
+			exe, err := selector.Executable()
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("期望返回错误，但选中了 %s", exe)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望返回错误: %v", err)
+			}
+			if exe != tc.expected {
+				t.Errorf("期望选中 %s，实际为 %s", tc.expected, exe)
+			}
+		})
+	}
+}
+
+// TestSelector_Cache 测试选择结果被缓存
+func TestSelector_Cache(t *testing.T) {
+	probeCount := 0
+	probe := func(exe string) (string, error) {
+		probeCount++
+		return "5.1.19041.1682", nil
+	}
+
+	cfg := &config.PowerShellConfig{PreferredVersion: "auto", FallbackOrder: []string{"powershell"}}
+	selector := NewSelectorWithProbe(cfg, logger.NewLogger(false), probe)
+	for i := 0; i < 3; i++ {
+		if _, err := selector.Executable(); err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+	}
+	if probeCount != 1 {
+		t.Errorf("期望只探测 1 次，实际 %d 次", probeCount)
+	}
+	if selector.Version() != "5.1.19041.1682" {
+		t.Errorf("版本号错误: %s", selector.Version())
+	}
+
+	selector.Reset()
+	if _, err := selector.Executable(); err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if probeCount != 2 {
+		t.Errorf("重置后应重新探测，实际探测 %d 次", probeCount)
+	}
+}
+
+// TestSelector_StrictCommandFails 测试严格模式下没有满足首选版本的PowerShell时命令执行失败而不降级，失败结果被缓存
+func TestSelector_StrictCommandFails(t *testing.T) {
+	probeCount := 0
+	probe := func(exe string) (string, error) {
+		probeCount++
+		if exe == "powershell" {
+			return "5.1.19041.1682", nil
+		}
+		return "", fmt.Errorf("找不到 %s", exe)
+	}
+
+	cfg := &config.PowerShellConfig{PreferredVersion: "7.x", FallbackOrder: []string{"powershell", "pwsh"}, CompatibilityMode: "strict"}
+	selector := NewSelectorWithProbe(cfg, logger.NewLogger(false), probe)
+	for i := 0; i < 3; i++ {
+		cmd := selector.Command("-Command", "Get-Host")
+		if cmd.Err == nil {
+			t.Fatalf("严格模式下不应降级为 %s", cmd.Path)
+		}
+		if err := cmd.Run(); err == nil {
+			t.Error("严格模式下选择失败时命令应执行失败")
+		}
+	}
+	if probeCount != 2 {
+		t.Errorf("失败的选择结果应被缓存，期望探测 2 次，实际 %d 次", probeCount)
+	}
+
+	cfg.CompatibilityMode = "loose"
+	loose := NewSelectorWithProbe(cfg, logger.NewLogger(false), mockProbe(map[string]string{}))
+	if cmd := loose.Command("-Command", "Get-Host"); cmd.Args[0] != DefaultExecutable {
+		t.Errorf("非严格模式下应退回 %s，实际 %s", DefaultExecutable, cmd.Args[0])
+	}
+}
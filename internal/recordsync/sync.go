@@ -0,0 +1,118 @@
+package recordsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// SyncPayload 推送到远程端点的请求体
+type SyncPayload struct {
+	Hostname string                 `json:"hostname"`
+	SentAt   time.Time              `json:"sent_at"`
+	Records  []storage.BackupRecord `json:"records"`
+}
+
+// RecordSource 同步所需的备份记录来源
+type RecordSource interface {
+	GetUnsyncedRecords() []storage.BackupRecord
+	MarkSynced(records []storage.BackupRecord, syncTime time.Time)
+	Save() error
+}
+
+// Syncer 备份记录远程同步器
+type Syncer struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+	source   RecordSource
+	log      *logger.Logger
+}
+
+// NewSyncer 创建备份记录同步器，未配置端点时返回 nil
+func NewSyncer(cfg *config.SyncConfig, source RecordSource, log *logger.Logger) *Syncer {
+	if cfg == nil || cfg.Endpoint == "" {
+		return nil
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &Syncer{
+		endpoint: cfg.Endpoint,
+		apiKey:   cfg.APIKey,
+		client:   &http.Client{Timeout: timeout},
+		source:   source,
+		log:      log,
+	}
+}
+
+// Sync 推送所有未同步的记录，返回推送的记录数
+// 推送失败时记录保持未同步状态，下次同步时重试
+func (s *Syncer) Sync() (int, error) {
+	records := s.source.GetUnsyncedRecords()
+	if len(records) == 0 {
+		s.log.Debug("没有需要同步的备份记录")
+		return 0, nil
+	}
+
+	s.log.Info("正在同步 %d 个备份记录到 %s", len(records), s.endpoint)
+	if err := s.push(records); err != nil {
+		return 0, fmt.Errorf("同步备份记录失败，%d 个记录待下次重试: %w", len(records), err)
+	}
+
+	s.source.MarkSynced(records, time.Now())
+	if err := s.source.Save(); err != nil {
+		s.log.Warn("保存同步状态失败: %v", err)
+	}
+
+	s.log.Info("备份记录同步完成: %d 个", len(records))
+	return len(records), nil
+}
+
+// push 批量POST记录到远程端点
+func (s *Syncer) push(records []storage.BackupRecord) error {
+	hostname, _ := os.Hostname()
+	payload := SyncPayload{
+		Hostname: hostname,
+		SentAt:   time.Now(),
+		Records:  records,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化备份记录失败: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建同步请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送同步请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("服务端返回错误状态 %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package recordsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/internal/storage"
+)
+
+// mockServer 记录收到的同步请求，可切换为返回错误
+type mockServer struct {
+	mu       sync.Mutex
+	fail     bool
+	received []SyncPayload
+	authKeys []string
+}
+
+func (ms *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.authKeys = append(ms.authKeys, r.Header.Get("Authorization"))
+	if ms.fail {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	var payload SyncPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ms.received = append(ms.received, payload)
+	w.WriteHeader(http.StatusOK)
+}
+
+// newTestTracker 创建使用临时目录的备份跟踪器
+func newTestTracker(t *testing.T, log *logger.Logger) *storage.BackupTracker {
+	tracker := storage.NewBackupTracker(filepath.Join(t.TempDir(), "backup_records.json"), log)
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("加载备份记录失败: %v", err)
+	}
+	return tracker
+}
+
+// TestSyncer_PushNewRecords 测试新记录被推送且只推送一次
+func TestSyncer_PushNewRecords(t *testing.T) {
+	log := logger.NewLogger(false)
+	server := &mockServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tracker := newTestTracker(t, log)
+	tracker.AddRecord("device\\a.opus", "backups\\a.opus", "dev1", 100, "hash_a")
+	tracker.AddRecord("device\\b.opus", "backups\\b.opus", "dev1", 200, "hash_b")
+
+	syncer := NewSyncer(&config.SyncConfig{Endpoint: ts.URL, APIKey: "secret"}, tracker, log)
+
+	count, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("期望推送 2 个记录，实际 %d", count)
+	}
+	if len(server.received) != 1 || len(server.received[0].Records) != 2 {
+		t.Fatalf("服务端应收到 1 次包含 2 个记录的请求，实际: %+v", server.received)
+	}
+	if server.authKeys[0] != "Bearer secret" {
+		t.Errorf("鉴权头错误: %s", server.authKeys[0])
+	}
+
+	// 增量同步：已同步的记录不再推送
+	tracker.AddRecord("device\\c.opus", "backups\\c.opus", "dev1", 300, "hash_c")
+	count, err = syncer.Sync()
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if count != 1 || len(server.received) != 2 || server.received[1].Records[0].SourcePath != "device\\c.opus" {
+		t.Errorf("增量同步应只推送新记录，实际推送 %d 个", count)
+	}
+	if tracker.GetStorage().LastSync.IsZero() {
+		t.Error("同步游标未更新")
+	}
+}
+
+// TestSyncer_RetryAfterServerError 测试服务端返回错误时记录待重试
+func TestSyncer_RetryAfterServerError(t *testing.T) {
+	log := logger.NewLogger(false)
+	server := &mockServer{fail: true}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tracker := newTestTracker(t, log)
+	tracker.AddRecord("device\\a.opus", "backups\\a.opus", "dev1", 100, "hash_a")
+
+	syncer := NewSyncer(&config.SyncConfig{Endpoint: ts.URL}, tracker, log)
+
+	if _, err := syncer.Sync(); err == nil {
+		t.Fatal("服务端返回错误时应返回错误")
+	}
+	if pending := tracker.GetUnsyncedRecords(); len(pending) != 1 {
+		t.Fatalf("失败的记录应保持待同步，实际 %d 个", len(pending))
+	}
+
+	// 服务端恢复后重试推送
+	server.fail = false
+	count, err := syncer.Sync()
+	if err != nil {
+		t.Fatalf("重试同步失败: %v", err)
+	}
+	if count != 1 || len(server.received) != 1 {
+		t.Errorf("重试时应推送待同步记录，实际推送 %d 个", count)
+	}
+	if pending := tracker.GetUnsyncedRecords(); len(pending) != 0 {
+		t.Errorf("重试成功后不应有待同步记录，实际 %d 个", len(pending))
+	}
+}
+
+// TestNewSyncer_NoEndpoint 测试未配置端点时不创建同步器
+func TestNewSyncer_NoEndpoint(t *testing.T) {
+	if syncer := NewSyncer(&config.SyncConfig{}, nil, logger.NewLogger(false)); syncer != nil {
+		t.Error("未配置端点时应返回nil")
+	}
+}
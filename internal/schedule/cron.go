@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears Next 向后查找的最大年数，超过说明表达式无法匹配（如 2月30日）
+const maxSearchYears = 5
+
+// cronField 单个字段的取值范围
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+// cronFields 标准5段cron表达式：分 时 日 月 周
+var cronFields = []cronField{
+	{"分钟", 0, 59},
+	{"小时", 0, 23},
+	{"日", 1, 31},
+	{"月", 1, 12},
+	{"星期", 0, 7}, // 0和7都表示星期日
+}
+
+// cronDescriptors 预定义的表达式别名
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule 解析后的cron调度表
+type Schedule struct {
+	expr    string
+	minutes []bool
+	hours   []bool
+	days    []bool
+	months  []bool
+	weekday []bool
+	// 日和星期都被限制时，按标准cron语义任一匹配即可
+	dayRestricted     bool
+	weekdayRestricted bool
+}
+
+// ParseCron 解析5段cron表达式（分 时 日 月 周），支持 *、*/n、a-b、a-b/n、逗号列表和 @daily 等别名
+func ParseCron(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron表达式应包含 %d 个字段，实际 %d 个: %q", len(cronFields), len(parts), expr)
+	}
+
+	sets := make([][]bool, len(cronFields))
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("解析cron表达式 %q 失败: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// 星期7等同于星期日
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &Schedule{
+		expr:              expr,
+		minutes:           sets[0],
+		hours:             sets[1],
+		days:              sets[2],
+		months:            sets[3],
+		weekday:           sets[4][:7],
+		dayRestricted:     !strings.HasPrefix(parts[2], "*"),
+		weekdayRestricted: !strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// String 返回原始表达式
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next 返回严格晚于 t 的下一个触发时间（精确到分钟），无法匹配时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for next.Before(limit) {
+		if !s.months[int(next.Month())] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.dayMatches(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.hours[next.Hour()] {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.minutes[next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+
+	return time.Time{}
+}
+
+// dayMatches 检查日期是否满足日和星期字段
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dayOK := s.days[t.Day()]
+	weekdayOK := s.weekday[int(t.Weekday())]
+
+	if s.dayRestricted && s.weekdayRestricted {
+		return dayOK || weekdayOK
+	}
+	return dayOK && weekdayOK
+}
+
+// parseCronField 解析单个字段，返回按取值下标标记的集合
+func parseCronField(field string, spec cronField) ([]bool, error) {
+	set := make([]bool, spec.max+1)
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			rangePart = item[:idx]
+			n, err := strconv.Atoi(item[idx+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s字段步长无效: %q", spec.name, item)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = spec.min, spec.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("%s字段范围无效: %q", spec.name, item)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("%s字段取值无效: %q", spec.name, item)
+			}
+			start, end = value, value
+			// n/step 表示从 n 开始到最大值
+			if step > 1 {
+				end = spec.max
+			}
+		}
+
+		if start < spec.min || end > spec.max || start > end {
+			return nil, fmt.Errorf("%s字段超出范围 %d-%d: %q", spec.name, spec.min, spec.max, item)
+		}
+
+		for v := start; v <= end; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseCron_Next 测试cron表达式解析与下一次触发时间计算
+func TestParseCron_Next(t *testing.T) {
+	// 2024-03-15 是星期五
+	base := time.Date(2024, 3, 15, 10, 17, 30, 0, time.Local)
+
+	testCases := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{"每分钟", "* * * * *", base, time.Date(2024, 3, 15, 10, 18, 0, 0, time.Local)},
+		{"每两小时整点", "0 */2 * * *", base, time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)},
+		{"每天固定时间-今天已过", "30 9 * * *", base, time.Date(2024, 3, 16, 9, 30, 0, 0, time.Local)},
+		{"分钟列表", "5,20,40 * * * *", base, time.Date(2024, 3, 15, 10, 20, 0, 0, time.Local)},
+		{"范围加步长", "0 8-18/4 * * *", base, time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)},
+		{"工作日", "0 9 * * 1-5", base, time.Date(2024, 3, 18, 9, 0, 0, 0, time.Local)},
+		{"星期日用7表示", "0 0 * * 7", base, time.Date(2024, 3, 17, 0, 0, 0, 0, time.Local)},
+		{"每月1日", "0 0 1 * *", base, time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)},
+		{"跨年", "0 0 1 1 *", base, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"闰日", "0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"日与星期任一匹配", "0 0 20 * 0", base, time.Date(2024, 3, 17, 0, 0, 0, 0, time.Local)},
+		{"别名", "@daily", base, time.Date(2024, 3, 16, 0, 0, 0, 0, time.Local)},
+		{"恰好在触发点时取下一次", "0 */2 * * *", time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local), time.Date(2024, 3, 15, 14, 0, 0, 0, time.Local)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("解析 %q 失败: %v", tc.expr, err)
+			}
+			if next := schedule.Next(tc.from); !next.Equal(tc.expected) {
+				t.Errorf("Next(%s) = %s，期望 %s", tc.from, next, tc.expected)
+			}
+		})
+	}
+}
+
+// TestParseCron_Invalid 测试无效表达式
+func TestParseCron_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"a * * * *",
+		"5-1 * * * *",
+	}
+
+	for _, expr := range invalid {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("表达式 %q 应解析失败", expr)
+		}
+	}
+}
+
+// TestSchedule_NeverMatches 测试无法匹配的表达式返回零值
+func TestSchedule_NeverMatches(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("2月30日不存在，应返回零值，实际 %s", next)
+	}
+}
@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// Clock 时钟接口，测试中可替换为假时钟
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock 使用系统时间的时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// JobFunc 调度触发时执行的任务
+type JobFunc func(ctx context.Context) error
+
+// Scheduler 按cron表达式定时触发任务
+// 任务串行执行，执行期间错过的触发点不补跑，结束后从当前时间计算下一次触发
+type Scheduler struct {
+	schedule *Schedule
+	job      JobFunc
+	clock    Clock
+	log      *logger.Logger
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(schedule *Schedule, job JobFunc, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		schedule: schedule,
+		job:      job,
+		clock:    realClock{},
+		log:      log,
+	}
+}
+
+// Run 运行调度循环，直到 ctx 被取消
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		now := s.clock.Now()
+		next := s.schedule.Next(now)
+		if next.IsZero() {
+			return fmt.Errorf("cron表达式 %q 没有可触发的时间", s.schedule)
+		}
+		s.log.Info("下次定时备份时间: %s", next.Format("2006-01-02 15:04"))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(next.Sub(now)):
+		}
+
+		s.log.Info("定时任务触发: %s", next.Format("2006-01-02 15:04"))
+		if err := s.job(ctx); err != nil {
+			s.log.Error("定时任务执行失败: %v", err)
+		}
+	}
+}
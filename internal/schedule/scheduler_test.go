@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// fakeClock 手动推进的假时钟
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 推进时间并唤醒到期的等待者
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.deadline.After(c.now) {
+			w.ch <- c.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
+
+// waitForWaiter 等待调度器进入等待状态
+func (c *fakeClock) waitForWaiter(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		n := len(c.waiters)
+		c.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("调度器未进入等待状态")
+}
+
+// TestScheduler_TriggersOnTime 测试使用假时钟时任务在cron时间点被调用
+func TestScheduler_TriggersOnTime(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 3, 15, 9, 30, 0, 0, time.Local))
+	schedule, err := ParseCron("0 */2 * * *")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	triggered := make(chan time.Time, 10)
+	scheduler := NewScheduler(schedule, func(ctx context.Context) error {
+		triggered <- clock.Now()
+		return nil
+	}, logger.NewLogger(false))
+	scheduler.clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	// 未到触发时间时不应调用
+	clock.waitForWaiter(t)
+	clock.Advance(29 * time.Minute)
+	select {
+	case at := <-triggered:
+		t.Fatalf("未到触发时间不应调用任务，调用时间 %s", at)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	expected := []time.Time{
+		time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local),
+		time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local),
+		time.Date(2024, 3, 15, 14, 0, 0, 0, time.Local),
+	}
+	clock.Advance(time.Minute)
+	for i, want := range expected {
+		select {
+		case at := <-triggered:
+			if !at.Equal(want) {
+				t.Errorf("第 %d 次触发时间为 %s，期望 %s", i+1, at, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("第 %d 次任务未被触发", i+1)
+		}
+		if i < len(expected)-1 {
+			clock.waitForWaiter(t)
+			clock.Advance(2 * time.Hour)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("取消后应正常退出，实际: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("取消后调度器未退出")
+	}
+}
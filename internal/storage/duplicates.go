@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// RecordSet 一个备份记录文件中的记录，合并多台机器的记录时用 Name 区分来源
+type RecordSet struct {
+	Name    string
+	Records []BackupRecord
+}
+
+// DuplicateEntry 重复组中的一个备份文件
+type DuplicateEntry struct {
+	Source string // 记录来源，即 RecordSet.Name
+	Record BackupRecord
+}
+
+// DuplicateGroup 内容哈希相同的一组备份文件
+type DuplicateGroup struct {
+	Hash    string
+	Size    int64 // 单个文件的大小
+	Entries []DuplicateEntry
+}
+
+// Savable 只保留一份时可节省的空间
+func (g *DuplicateGroup) Savable() int64 {
+	return g.Size * int64(len(g.Entries)-1)
+}
+
+// DuplicateReport 按内容哈希统计的重复备份
+type DuplicateReport struct {
+	TotalFiles     int              // 参与统计的成功备份记录数
+	HashedFiles    int              // 其中有内容哈希的记录数，没有哈希的记录无法判断是否重复
+	DuplicateFiles int              // 多余的副本数，即各组份数减一之和
+	SavableBytes   int64            // 每组只保留一份时可节省的空间
+	Groups         []DuplicateGroup // 按可节省空间降序排列
+}
+
+// ReadRecordsFile 只读地加载备份记录文件，加密的文件使用 passphrase 解密；不会创建或改写文件
+func ReadRecordsFile(path, passphrase string) ([]BackupRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取备份记录文件失败: %w", err)
+	}
+
+	if isEncrypted(data) {
+		if passphrase == "" {
+			return nil, fmt.Errorf("%s: %w", path, ErrKeyRequired)
+		}
+		if data, err = decryptData(data, deriveKey(passphrase)); err != nil {
+			return nil, fmt.Errorf("解密备份记录失败: %w", err)
+		}
+	}
+
+	var storage BackupStorage
+	if err := json.Unmarshal(data, &storage); err != nil {
+		return nil, fmt.Errorf("解析备份记录失败: %w", err)
+	}
+	return storage.Records, nil
+}
+
+// BuildDuplicateReport 按内容哈希统计重复的备份文件，只统计备份成功的记录
+// 同一来源中目标路径相同的记录指向同一个文件，只计一次
+func BuildDuplicateReport(sets []RecordSet) *DuplicateReport {
+	report := &DuplicateReport{}
+	groups := make(map[string]*DuplicateGroup)
+	seen := make(map[string]bool)
+
+	for _, set := range sets {
+		for _, record := range set.Records {
+			if !record.Success {
+				continue
+			}
+			key := set.Name + "\x00" + strings.ToLower(record.TargetPath)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			report.TotalFiles++
+
+			hash := strings.ToLower(record.FileHash)
+			if hash == "" {
+				continue
+			}
+			report.HashedFiles++
+
+			group := groups[hash]
+			if group == nil {
+				group = &DuplicateGroup{Hash: hash, Size: record.FileSize}
+				groups[hash] = group
+			}
+			group.Entries = append(group.Entries, DuplicateEntry{Source: set.Name, Record: record})
+		}
+	}
+
+	for _, group := range groups {
+		if len(group.Entries) < 2 {
+			continue
+		}
+		report.DuplicateFiles += len(group.Entries) - 1
+		report.SavableBytes += group.Savable()
+		report.Groups = append(report.Groups, *group)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Savable() != b.Savable() {
+			return a.Savable() > b.Savable()
+		}
+		if len(a.Entries) != len(b.Entries) {
+			return len(a.Entries) > len(b.Entries)
+		}
+		return a.Hash < b.Hash
+	})
+	return report
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// TestBuildDuplicateReport 测试按内容哈希统计重复数、可节省空间和排序
+func TestBuildDuplicateReport(t *testing.T) {
+	sets := []RecordSet{
+		{
+			Name: "office.json",
+			Records: []BackupRecord{
+				{SourcePath: "a.opus", TargetPath: "D:\\backup\\a.opus", FileSize: 100, FileHash: "AAAA", Success: true},
+				{SourcePath: "a_copy.opus", TargetPath: "D:\\backup\\a_copy.opus", FileSize: 100, FileHash: "aaaa", Success: true},
+				{SourcePath: "b.opus", TargetPath: "D:\\backup\\b.opus", FileSize: 1000, FileHash: "bbbb", Success: true},
+				{SourcePath: "c.opus", TargetPath: "D:\\backup\\c.opus", FileSize: 50, FileHash: "cccc", Success: true},
+				{SourcePath: "nohash.opus", TargetPath: "D:\\backup\\nohash.opus", FileSize: 10, Success: true},
+				{SourcePath: "failed.opus", TargetPath: "D:\\backup\\failed.opus", FileSize: 100, FileHash: "aaaa", Success: false},
+			},
+		},
+		{
+			Name: "home.json",
+			Records: []BackupRecord{
+				{SourcePath: "a.opus", TargetPath: "E:\\rec\\a.opus", FileSize: 100, FileHash: "aaaa", Success: true},
+				{SourcePath: "b.opus", TargetPath: "E:\\rec\\b.opus", FileSize: 1000, FileHash: "bbbb", Success: true},
+				// 同一来源中指向同一目标文件的记录只计一次
+				{SourcePath: "b_old.opus", TargetPath: "e:\\rec\\B.opus", FileSize: 1000, FileHash: "bbbb", Success: true},
+				{SourcePath: "d.opus", TargetPath: "E:\\rec\\d.opus", FileSize: 70, FileHash: "dddd", Success: true},
+			},
+		},
+		{
+			// 另一台机器上相同的目标路径是不同的文件
+			Name: "laptop.json",
+			Records: []BackupRecord{
+				{SourcePath: "a.opus", TargetPath: "D:\\backup\\a.opus", FileSize: 100, FileHash: "aaaa", Success: true},
+			},
+		},
+	}
+
+	report := BuildDuplicateReport(sets)
+
+	if report.TotalFiles != 9 || report.HashedFiles != 8 {
+		t.Errorf("统计记录数 = %d（有哈希 %d），期望 9（有哈希 8）", report.TotalFiles, report.HashedFiles)
+	}
+	// aaaa 4 份多 3 份，bbbb 2 份多 1 份
+	if report.DuplicateFiles != 4 {
+		t.Errorf("多余副本数 = %d, 期望 4", report.DuplicateFiles)
+	}
+	if report.SavableBytes != 3*100+1000 {
+		t.Errorf("可节省空间 = %d, 期望 %d", report.SavableBytes, 3*100+1000)
+	}
+
+	if len(report.Groups) != 2 {
+		t.Fatalf("重复组数 = %d, 期望 2", len(report.Groups))
+	}
+	// 按可节省空间降序：bbbb 节省 1000 排在 aaaa 节省 300 之前
+	if report.Groups[0].Hash != "bbbb" || report.Groups[0].Savable() != 1000 || len(report.Groups[0].Entries) != 2 {
+		t.Errorf("第一组应为 bbbb: %+v", report.Groups[0])
+	}
+	if report.Groups[1].Hash != "aaaa" || report.Groups[1].Savable() != 300 || len(report.Groups[1].Entries) != 4 {
+		t.Errorf("第二组应为 aaaa: %+v", report.Groups[1])
+	}
+	sources := map[string]int{}
+	for _, entry := range report.Groups[1].Entries {
+		sources[entry.Source]++
+	}
+	if sources["office.json"] != 2 || sources["home.json"] != 1 || sources["laptop.json"] != 1 {
+		t.Errorf("aaaa 的来源统计错误: %v", sources)
+	}
+
+	if empty := BuildDuplicateReport(nil); empty.TotalFiles != 0 || len(empty.Groups) != 0 {
+		t.Errorf("没有记录时报告应为空: %+v", empty)
+	}
+}
+
+// TestReadRecordsFile 测试只读加载明文和加密的备份记录文件
+func TestReadRecordsFile(t *testing.T) {
+	tempDir := t.TempDir()
+	log := logger.NewLogger(false)
+
+	plainPath := filepath.Join(tempDir, "plain.json")
+	plain := NewBackupTracker(plainPath, log)
+	if err := plain.AddRecord("a.opus", "D:\\backup\\a.opus", "dev", 100, "aaaa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedPath := filepath.Join(tempDir, "encrypted.json")
+	encrypted := NewBackupTracker(encryptedPath, log)
+	encrypted.SetEncryptionKey("secret")
+	if err := encrypted.AddRecord("b.opus", "D:\\backup\\b.opus", "dev", 200, "bbbb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := encrypted.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if records, err := ReadRecordsFile(plainPath, ""); err != nil || len(records) != 1 || records[0].FileHash != "aaaa" {
+		t.Errorf("读取明文记录 = %v, %v", records, err)
+	}
+	if records, err := ReadRecordsFile(encryptedPath, "secret"); err != nil || len(records) != 1 || records[0].FileHash != "bbbb" {
+		t.Errorf("读取加密记录 = %v, %v", records, err)
+	}
+	if _, err := ReadRecordsFile(encryptedPath, ""); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("未提供密钥时应返回 ErrKeyRequired: %v", err)
+	}
+
+	// 损坏或不存在的文件报错，且不会被改写或创建
+	corruptPath := filepath.Join(tempDir, "corrupt.json")
+	if err := os.WriteFile(corruptPath, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRecordsFile(corruptPath, ""); err == nil {
+		t.Error("损坏的记录文件应返回错误")
+	}
+	if data, _ := os.ReadFile(corruptPath); string(data) != "not json" {
+		t.Error("损坏的记录文件不应被改写")
+	}
+	missingPath := filepath.Join(tempDir, "missing.json")
+	if _, err := ReadRecordsFile(missingPath, ""); err == nil {
+		t.Error("不存在的记录文件应返回错误")
+	}
+	if _, err := os.Stat(missingPath); !os.IsNotExist(err) {
+		t.Error("不应创建记录文件")
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptedMagic 加密备份记录文件的头部标识
+var encryptedMagic = []byte("RCENC1\n")
+
+// ErrWrongKey 密钥错误或加密文件已损坏
+var ErrWrongKey = errors.New("密钥错误或备份记录文件已损坏")
+
+// ErrKeyRequired 备份记录已加密但未配置密钥
+var ErrKeyRequired = errors.New("备份记录已加密，请配置 storage.encryption_key 或环境变量")
+
+// deriveKey 将任意长度的密钥字符串转换为AES-256密钥
+func deriveKey(passphrase string) []byte {
+	sum := sha256.Sum256([]byte(passphrase))
+	return sum[:]
+}
+
+// isEncrypted 判断数据是否为加密的备份记录
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedMagic)
+}
+
+// encryptData 使用AES-GCM加密，输出格式为 头部标识 + nonce + 密文
+func encryptData(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+
+	out := make([]byte, 0, len(encryptedMagic)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, encryptedMagic), nil
+}
+
+// decryptData 解密 encryptData 的输出，密钥错误时返回 ErrWrongKey
+func decryptData(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	body := data[len(encryptedMagic):]
+	if len(body) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrWrongKey
+	}
+
+	nonce, ciphertext := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, encryptedMagic)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plaintext, nil
+}
+
+// newGCM 创建AES-GCM加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建AES加密器失败: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建GCM加密器失败: %w", err)
+	}
+	return gcm, nil
+}
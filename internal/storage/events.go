@@ -0,0 +1,66 @@
+package storage
+
+// 备份记录变更事件类型
+const (
+	// RecordAdded 新增了备份记录
+	RecordAdded = "added"
+	// RecordUpdated 同一源路径的已有记录被更新
+	RecordUpdated = "updated"
+	// RecordRemoved 备份记录被移除，清空记录时每条记录各有一个事件
+	RecordRemoved = "removed"
+)
+
+// RecordEventBuffer 每个订阅者的事件缓冲大小，缓冲满时丢弃新事件，不阻塞备份流程
+const RecordEventBuffer = 64
+
+// RecordEvent 备份记录变更事件，Record 为变更后的记录（移除时为被移除的记录）的副本
+type RecordEvent struct {
+	Type   string
+	Record BackupRecord
+}
+
+// recordSubscriber 一个变更订阅者
+type recordSubscriber struct {
+	ch      chan RecordEvent
+	dropped int // 因缓冲已满丢弃的事件数
+}
+
+// Subscribe 订阅备份记录的变更，返回的通道在 Unsubscribe 后关闭
+// 订阅者处理过慢导致缓冲已满时新事件被丢弃，不会阻塞添加或移除记录
+func (bt *BackupTracker) Subscribe() <-chan RecordEvent {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	sub := &recordSubscriber{ch: make(chan RecordEvent, RecordEventBuffer)}
+	bt.subscribers = append(bt.subscribers, sub)
+	return sub.ch
+}
+
+// Unsubscribe 取消 Subscribe 返回的订阅并关闭通道，之后不再收到事件
+func (bt *BackupTracker) Unsubscribe(ch <-chan RecordEvent) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	for i, sub := range bt.subscribers {
+		if (<-chan RecordEvent)(sub.ch) == ch {
+			if sub.dropped > 0 {
+				bt.log.Debug("记录变更订阅者共丢弃了 %d 个事件", sub.dropped)
+			}
+			close(sub.ch)
+			bt.subscribers = append(bt.subscribers[:i], bt.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish 向所有订阅者广播变更事件，调用方需持有 bt.mu
+func (bt *BackupTracker) publish(eventType string, record BackupRecord) {
+	event := RecordEvent{Type: eventType, Record: record}
+	for _, sub := range bt.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// receiveEvents 取出通道中已有的事件
+func receiveEvents(ch <-chan RecordEvent) []RecordEvent {
+	var events []RecordEvent
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// TestBackupTracker_Subscribe 测试多个订阅者都收到增删事件，取消订阅后不再收到
+func TestBackupTracker_Subscribe(t *testing.T) {
+	log := logger.NewLogger(false)
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), log)
+
+	first := tracker.Subscribe()
+	second := tracker.Subscribe()
+
+	tracker.AddRecord("a.opus", "backup/a.opus", "dev", 100, "h1")
+	tracker.AddRecord("b.opus", "backup/b.opus", "dev", 200, "h2")
+	tracker.AddRecord("a.opus", "backup/a2.opus", "dev", 150, "h3")
+	if err := tracker.RemoveRecord("b.opus"); err != nil {
+		t.Fatalf("移除记录失败: %v", err)
+	}
+	if err := tracker.RemoveRecord("missing.opus"); err == nil {
+		t.Fatal("移除不存在的记录应返回错误")
+	}
+
+	want := []struct {
+		eventType string
+		source    string
+		target    string
+	}{
+		{RecordAdded, "a.opus", "backup/a.opus"},
+		{RecordAdded, "b.opus", "backup/b.opus"},
+		{RecordUpdated, "a.opus", "backup/a2.opus"},
+		{RecordRemoved, "b.opus", "backup/b.opus"},
+	}
+	for name, ch := range map[string]<-chan RecordEvent{"订阅者1": first, "订阅者2": second} {
+		events := receiveEvents(ch)
+		if len(events) != len(want) {
+			t.Fatalf("%s 收到 %d 个事件，期望 %d: %+v", name, len(events), len(want), events)
+		}
+		for i, w := range want {
+			if events[i].Type != w.eventType || events[i].Record.SourcePath != w.source || events[i].Record.TargetPath != w.target {
+				t.Errorf("%s 第 %d 个事件 = %s %s -> %s，期望 %s %s -> %s", name, i, events[i].Type,
+					events[i].Record.SourcePath, events[i].Record.TargetPath, w.eventType, w.source, w.target)
+			}
+		}
+	}
+
+	// 取消订阅后通道关闭，只有仍在订阅的收到清空事件
+	tracker.Unsubscribe(first)
+	if _, ok := <-first; ok {
+		t.Error("取消订阅后通道应关闭")
+	}
+	tracker.AddRecord("c.opus", "backup/c.opus", "dev", 300, "h4")
+	if err := tracker.ClearRecords(); err != nil {
+		t.Fatalf("清空记录失败: %v", err)
+	}
+
+	events := receiveEvents(second)
+	removed := map[string]bool{}
+	for _, event := range events[1:] {
+		if event.Type == RecordRemoved {
+			removed[event.Record.SourcePath] = true
+		}
+	}
+	if len(events) != 3 || events[0].Type != RecordAdded || !removed["a.opus"] || !removed["c.opus"] {
+		t.Errorf("清空记录的事件 = %+v", events)
+	}
+}
+
+// TestBackupTracker_SubscribeSlow 测试订阅者不读取时缓冲满后丢弃事件，不阻塞添加记录
+func TestBackupTracker_SubscribeSlow(t *testing.T) {
+	log := logger.NewLogger(false)
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), log)
+	ch := tracker.Subscribe()
+
+	total := RecordEventBuffer + 10
+	for i := 0; i < total; i++ {
+		tracker.AddRecord(fmt.Sprintf("file%d.opus", i), "backup", "dev", 1, "")
+	}
+
+	if got := len(receiveEvents(ch)); got != RecordEventBuffer {
+		t.Errorf("收到 %d 个事件，期望缓冲大小 %d", got, RecordEventBuffer)
+	}
+	if records := len(tracker.GetStorage().Records); records != total {
+		t.Errorf("记录数 = %d，期望 %d", records, total)
+	}
+	tracker.Unsubscribe(ch)
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 记录排序字段，前缀 "-" 表示降序，如 "-time"
+const (
+	SortByTime = "time" // 备份时间
+	SortBySize = "size" // 文件大小
+	SortByName = "name" // 文件名
+)
+
+// ParseSort 解析排序参数，返回排序字段和是否降序；空字符串按备份时间升序
+func ParseSort(sortBy string) (string, bool, error) {
+	field := strings.ToLower(strings.TrimSpace(sortBy))
+	desc := strings.HasPrefix(field, "-")
+	field = strings.TrimPrefix(field, "-")
+
+	switch field {
+	case "":
+		return SortByTime, desc, nil
+	case SortByTime, SortBySize, SortByName:
+		return field, desc, nil
+	default:
+		return "", false, fmt.Errorf("不支持的排序字段: %s（可选 time、size、name）", sortBy)
+	}
+}
+
+// ListRecords 分页列出备份记录，返回当前页记录和记录总数
+// offset 小于0按0处理，limit 小于等于0表示返回 offset 之后的全部记录；无效的排序字段按备份时间排序
+func (bt *BackupTracker) ListRecords(offset, limit int, sortBy string) ([]BackupRecord, int) {
+	return bt.listRecords(offset, limit, sortBy, false)
+}
+
+// ListRecordsIncludingArchived 与 ListRecords 相同，但同时列出已移到归档文件的旧记录
+func (bt *BackupTracker) ListRecordsIncludingArchived(offset, limit int, sortBy string) ([]BackupRecord, int) {
+	return bt.listRecords(offset, limit, sortBy, true)
+}
+
+// listRecords 分页列出主文件中的记录，includeArchived 时包含归档记录
+func (bt *BackupTracker) listRecords(offset, limit int, sortBy string, includeArchived bool) ([]BackupRecord, int) {
+	field, desc, err := ParseSort(sortBy)
+	if err != nil {
+		bt.log.Warn("%v，按备份时间排序", err)
+		field, desc = SortByTime, false
+	}
+
+	bt.mu.Lock()
+	var records []BackupRecord
+	if includeArchived {
+		records = bt.allRecords()
+	} else {
+		records = make([]BackupRecord, len(bt.storage.Records))
+		copy(records, bt.storage.Records)
+	}
+	bt.mu.Unlock()
+
+	total := len(records)
+	sortRecords(records, field, desc)
+
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= total {
+		return []BackupRecord{}, total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return records[offset:end], total
+}
+
+// sortRecords 按字段排序，字段相同时按源路径排序保证分页结果稳定
+func sortRecords(records []BackupRecord, field string, desc bool) {
+	compare := func(a, b *BackupRecord) int {
+		switch field {
+		case SortBySize:
+			if a.FileSize != b.FileSize {
+				if a.FileSize < b.FileSize {
+					return -1
+				}
+				return 1
+			}
+		case SortByName:
+			if c := strings.Compare(RecordName(a.SourcePath), RecordName(b.SourcePath)); c != 0 {
+				return c
+			}
+		default:
+			if !a.BackupTime.Equal(b.BackupTime) {
+				if a.BackupTime.Before(b.BackupTime) {
+					return -1
+				}
+				return 1
+			}
+		}
+		return strings.Compare(a.SourcePath, b.SourcePath)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		c := compare(&records[i], &records[j])
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// RecordName 获取记录的文件名，设备路径使用 \ 分隔
+func RecordName(sourcePath string) string {
+	return sourcePath[strings.LastIndexAny(sourcePath, "\\/")+1:]
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// newListFixture 创建包含 count 条记录的跟踪器，第 i 条记录的大小为 (count-i)*10，备份时间依次递增
+func newListFixture(t *testing.T, count int) *BackupTracker {
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), logger.NewLogger(false))
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	for i := 0; i < count; i++ {
+		tracker.storage.Records = append(tracker.storage.Records, BackupRecord{
+			SourcePath: fmt.Sprintf("内部共享存储空间\\录音笔文件\\%c.opus", 'z'-i),
+			FileSize:   int64(count-i) * 10,
+			BackupTime: base.Add(time.Duration(i) * time.Minute),
+			Success:    true,
+		})
+	}
+	return tracker
+}
+
+// TestBackupTracker_ListRecordsPaging 测试分页边界与总数
+func TestBackupTracker_ListRecordsPaging(t *testing.T) {
+	tracker := newListFixture(t, 7)
+
+	tests := []struct {
+		name      string
+		offset    int
+		limit     int
+		wantCount int
+		wantFirst string
+	}{
+		{"第一页", 0, 3, 3, "z.opus"},
+		{"第二页", 3, 3, 3, "w.opus"},
+		{"最后一页不足size", 6, 3, 1, "t.opus"},
+		{"超出范围返回空", 9, 3, 0, ""},
+		{"limit为0返回剩余全部", 2, 0, 5, "x.opus"},
+		{"负offset按0处理", -1, 2, 2, "z.opus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, total := tracker.ListRecords(tt.offset, tt.limit, SortByTime)
+			if total != 7 {
+				t.Errorf("总数 = %d, 期望 7", total)
+			}
+			if len(records) != tt.wantCount {
+				t.Fatalf("记录数 = %d, 期望 %d", len(records), tt.wantCount)
+			}
+			if tt.wantCount > 0 && RecordName(records[0].SourcePath) != tt.wantFirst {
+				t.Errorf("首条记录 = %s, 期望 %s", RecordName(records[0].SourcePath), tt.wantFirst)
+			}
+		})
+	}
+}
+
+// TestBackupTracker_ListRecordsSort 测试排序字段和升降序
+func TestBackupTracker_ListRecordsSort(t *testing.T) {
+	tracker := newListFixture(t, 4)
+
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"time", []string{"z.opus", "y.opus", "x.opus", "w.opus"}},
+		{"-time", []string{"w.opus", "x.opus", "y.opus", "z.opus"}},
+		{"size", []string{"w.opus", "x.opus", "y.opus", "z.opus"}},
+		{"-size", []string{"z.opus", "y.opus", "x.opus", "w.opus"}},
+		{"name", []string{"w.opus", "x.opus", "y.opus", "z.opus"}},
+		{"-name", []string{"z.opus", "y.opus", "x.opus", "w.opus"}},
+		{"", []string{"z.opus", "y.opus", "x.opus", "w.opus"}},
+		{"unknown", []string{"z.opus", "y.opus", "x.opus", "w.opus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			records, _ := tracker.ListRecords(0, 0, tt.sortBy)
+			var got []string
+			for _, record := range records {
+				got = append(got, RecordName(record.SourcePath))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("排序结果 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	// 排序不影响原始记录顺序
+	if RecordName(tracker.storage.Records[0].SourcePath) != "z.opus" {
+		t.Error("ListRecords 不应修改原始记录顺序")
+	}
+}
+
+// TestParseSort 测试排序参数解析
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sortBy    string
+		wantField string
+		wantDesc  bool
+		wantErr   bool
+	}{
+		{"time", SortByTime, false, false},
+		{"-Size", SortBySize, true, false},
+		{" name ", SortByName, false, false},
+		{"", SortByTime, false, false},
+		{"duration", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			field, desc, err := ParseSort(tt.sortBy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSort() 错误 = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if field != tt.wantField || desc != tt.wantDesc {
+				t.Errorf("ParseSort() = (%s, %v), 期望 (%s, %v)", field, desc, tt.wantField, tt.wantDesc)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// LogicalFile 内容相同的一组备份记录
+// 设备重新格式化后路径或对象ID可能变化，同一录音会以不同源路径出现多次
+type LogicalFile struct {
+	FileHash      string    `json:"file_hash"`      // 内容哈希，记录没有哈希时为空，此时每条记录单独成组
+	HashAlgorithm string    `json:"hash_algorithm"` // 哈希算法
+	FileSize      int64     `json:"file_size"`
+	SourcePaths   []string  `json:"source_paths"` // 曾出现过的所有源路径，按首次备份时间排序
+	TargetPaths   []string  `json:"target_paths"` // 所有备份目标，按首次备份时间排序
+	Records       int       `json:"records"`      // 记录条数
+	FirstBackup   time.Time `json:"first_backup"`
+	LastBackup    time.Time `json:"last_backup"`
+}
+
+// Duplicated 是否被重复备份（出现过多个源路径或多个备份目标）
+func (lf *LogicalFile) Duplicated() bool {
+	return len(lf.SourcePaths) > 1 || len(lf.TargetPaths) > 1
+}
+
+// GetLogicalFiles 按内容哈希聚合备份记录，返回的逻辑文件按首次备份时间排序
+// 只统计备份成功的记录；哈希算法不同的记录不会合并
+func (bt *BackupTracker) GetLogicalFiles() []LogicalFile {
+	bt.mu.Lock()
+	records := make([]BackupRecord, 0, len(bt.storage.Records))
+	for _, record := range bt.storage.Records {
+		if record.Success {
+			records = append(records, record)
+		}
+	}
+	bt.mu.Unlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].BackupTime.Before(records[j].BackupTime)
+	})
+
+	var files []LogicalFile
+	groups := make(map[string]int)
+	for _, record := range records {
+		algorithm := record.HashAlgorithm
+		if algorithm == "" {
+			// 未开启完整性验证时记录中的哈希以SHA256计算
+			algorithm = "sha256"
+		}
+
+		key := algorithm + ":" + record.FileHash
+		index, ok := groups[key]
+		if record.FileHash == "" || !ok {
+			files = append(files, LogicalFile{
+				FileHash:    record.FileHash,
+				FileSize:    record.FileSize,
+				FirstBackup: record.BackupTime,
+			})
+			index = len(files) - 1
+			if record.FileHash != "" {
+				files[index].HashAlgorithm = algorithm
+				groups[key] = index
+			}
+		}
+
+		file := &files[index]
+		file.Records++
+		file.SourcePaths = appendUnique(file.SourcePaths, record.SourcePath)
+		file.TargetPaths = appendUnique(file.TargetPaths, record.TargetPath)
+		if record.BackupTime.After(file.LastBackup) {
+			file.LastBackup = record.BackupTime
+		}
+	}
+
+	return files
+}
+
+// appendUnique 追加不重复的值
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
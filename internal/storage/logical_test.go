@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// TestBackupTracker_GetLogicalFiles 测试同哈希不同路径的记录聚合为一个逻辑文件
+func TestBackupTracker_GetLogicalFiles(t *testing.T) {
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), logger.NewLogger(false))
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	tracker.storage.Records = []BackupRecord{
+		// 同一录音在设备重新格式化前后以不同路径备份了三次
+		{SourcePath: "old\\a.opus", TargetPath: "D:\\backup\\a.opus", FileHash: "h1", FileSize: 100, BackupTime: base, Success: true},
+		{SourcePath: "new\\a.opus", TargetPath: "D:\\backup\\a_1.opus", FileHash: "h1", FileSize: 100, BackupTime: base.Add(2 * time.Hour), Success: true},
+		{SourcePath: "new2\\a.opus", TargetPath: "D:\\backup\\a_1.opus", FileHash: "h1", HashAlgorithm: "sha256", FileSize: 100, BackupTime: base.Add(3 * time.Hour), Success: true},
+		{SourcePath: "old\\b.opus", TargetPath: "D:\\backup\\b.opus", FileHash: "h2", FileSize: 200, BackupTime: base.Add(time.Hour), Success: true},
+		// 相同哈希值但算法不同，不合并
+		{SourcePath: "old\\c.opus", TargetPath: "D:\\backup\\c.opus", FileHash: "h2", HashAlgorithm: "md5", FileSize: 300, BackupTime: base.Add(4 * time.Hour), Success: true},
+		// 没有哈希的记录各自成组
+		{SourcePath: "old\\d.opus", TargetPath: "D:\\backup\\d.opus", FileSize: 10, BackupTime: base.Add(5 * time.Hour), Success: true},
+		{SourcePath: "old\\e.opus", TargetPath: "D:\\backup\\e.opus", FileSize: 10, BackupTime: base.Add(6 * time.Hour), Success: true},
+		// 失败的记录不统计
+		{SourcePath: "old\\f.opus", TargetPath: "D:\\backup\\f.opus", FileHash: "h1", FileSize: 100, BackupTime: base.Add(7 * time.Hour)},
+	}
+
+	files := tracker.GetLogicalFiles()
+	if len(files) != 5 {
+		t.Fatalf("逻辑文件数 = %d, 期望 5: %+v", len(files), files)
+	}
+
+	tests := []struct {
+		name        string
+		file        LogicalFile
+		wantHash    string
+		wantSources []string
+		wantTargets []string
+		wantRecords int
+		wantDup     bool
+	}{
+		{"同哈希合并", files[0], "h1", []string{"old\\a.opus", "new\\a.opus", "new2\\a.opus"}, []string{"D:\\backup\\a.opus", "D:\\backup\\a_1.opus"}, 3, true},
+		{"单条记录", files[1], "h2", []string{"old\\b.opus"}, []string{"D:\\backup\\b.opus"}, 1, false},
+		{"算法不同不合并", files[2], "h2", []string{"old\\c.opus"}, []string{"D:\\backup\\c.opus"}, 1, false},
+		{"无哈希单独成组", files[3], "", []string{"old\\d.opus"}, []string{"D:\\backup\\d.opus"}, 1, false},
+		{"无哈希不互相合并", files[4], "", []string{"old\\e.opus"}, []string{"D:\\backup\\e.opus"}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.file.FileHash != tt.wantHash {
+				t.Errorf("哈希 = %s, 期望 %s", tt.file.FileHash, tt.wantHash)
+			}
+			if fmt.Sprint(tt.file.SourcePaths) != fmt.Sprint(tt.wantSources) {
+				t.Errorf("源路径 = %v, 期望 %v", tt.file.SourcePaths, tt.wantSources)
+			}
+			if fmt.Sprint(tt.file.TargetPaths) != fmt.Sprint(tt.wantTargets) {
+				t.Errorf("备份目标 = %v, 期望 %v", tt.file.TargetPaths, tt.wantTargets)
+			}
+			if tt.file.Records != tt.wantRecords {
+				t.Errorf("记录数 = %d, 期望 %d", tt.file.Records, tt.wantRecords)
+			}
+			if tt.file.Duplicated() != tt.wantDup {
+				t.Errorf("Duplicated() = %v, 期望 %v", tt.file.Duplicated(), tt.wantDup)
+			}
+		})
+	}
+
+	if !files[0].FirstBackup.Equal(base) || !files[0].LastBackup.Equal(base.Add(3*time.Hour)) {
+		t.Errorf("首次/最近备份时间不符: %v, %v", files[0].FirstBackup, files[0].LastBackup)
+	}
+	if files[0].HashAlgorithm != "sha256" || files[2].HashAlgorithm != "md5" {
+		t.Errorf("哈希算法不符: %s, %s", files[0].HashAlgorithm, files[2].HashAlgorithm)
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// MergeStrategy 导入备份记录时与已有记录冲突（源路径相同）的处理方式
+type MergeStrategy string
+
+const (
+	// MergeSkipExisting 保留已有记录，跳过导入的记录
+	MergeSkipExisting MergeStrategy = "skip-existing"
+	// MergeOverwrite 用导入的记录覆盖已有记录
+	MergeOverwrite MergeStrategy = "overwrite"
+	// MergeNewerWins 保留备份时间较新的一条，时间相同时保留已有记录
+	MergeNewerWins MergeStrategy = "newer-wins"
+)
+
+// ParseMergeStrategy 解析合并策略名称
+func ParseMergeStrategy(name string) (MergeStrategy, error) {
+	switch strategy := MergeStrategy(name); strategy {
+	case MergeSkipExisting, MergeOverwrite, MergeNewerWins:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("不支持的合并策略: %s（可选 %s、%s、%s）", name, MergeSkipExisting, MergeOverwrite, MergeNewerWins)
+	}
+}
+
+// ImportRecords 把 ExportRecords 导出的（或另一台机器的）备份记录文件合并到当前记录
+// 与记录的其他操作（添加、去重、已备份检查）一样按源路径识别同一文件，冲突时按 strategy 处理；
+// 加密的记录文件使用当前记录的密钥解密
+// 返回新增、更新和跳过的记录数，调用方需 Save 才会持久化
+func (bt *BackupTracker) ImportRecords(path string, strategy MergeStrategy) (added, updated, skipped int, err error) {
+	if _, err := ParseMergeStrategy(string(strategy)); err != nil {
+		return 0, 0, 0, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("读取导入的备份记录失败: %w", err)
+	}
+
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if isEncrypted(data) {
+		if bt.key == nil {
+			return 0, 0, 0, fmt.Errorf("%s: %w", path, ErrKeyRequired)
+		}
+		if data, err = decryptData(data, bt.key); err != nil {
+			return 0, 0, 0, fmt.Errorf("解密导入的备份记录失败: %w", err)
+		}
+	}
+	var imported BackupStorage
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return 0, 0, 0, fmt.Errorf("解析导入的备份记录失败: %w", err)
+	}
+
+	index := make(map[string]int, len(bt.storage.Records))
+	for i, record := range bt.storage.Records {
+		index[record.SourcePath] = i
+	}
+
+	for _, record := range imported.Records {
+		// 导入的记录对本机而言是新的变更，需要重新同步到远程
+		record.Synced = false
+
+		i, exists := index[record.SourcePath]
+		if !exists {
+			// 已归档的记录取回主文件后按策略合并，不重复添加
+			if i = bt.recordIndex(record.SourcePath); i >= 0 {
+				index[record.SourcePath] = i
+				exists = true
+			}
+		}
+		if !exists {
+			bt.storage.Records = append(bt.storage.Records, record)
+			index[record.SourcePath] = len(bt.storage.Records) - 1
+			bt.storage.TotalFilesBackedUp++
+			bt.storage.TotalSize += record.FileSize
+			bt.publish(RecordAdded, record)
+			added++
+		} else if strategy == MergeOverwrite ||
+			(strategy == MergeNewerWins && record.BackupTime.After(bt.storage.Records[i].BackupTime)) {
+			bt.storage.TotalSize += record.FileSize - bt.storage.Records[i].FileSize
+			bt.storage.Records[i] = record
+			bt.publish(RecordUpdated, record)
+			updated++
+		} else {
+			skipped++
+			continue
+		}
+
+		if record.BackupTime.After(bt.storage.LastBackup) {
+			bt.storage.LastBackup = record.BackupTime
+		}
+	}
+
+	if added > 0 || updated > 0 {
+		bt.dirty = true
+	}
+	bt.log.Info("导入备份记录（%s）: 新增 %d 条，更新 %d 条，跳过 %d 条", strategy, added, updated, skipped)
+	return added, updated, skipped, nil
+}
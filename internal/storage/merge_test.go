@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// newMergeFixture 创建本机记录：a、b 属于 dev1，c 属于 dev2；另一台机器导出的记录与其部分重叠
+// 导入的 a 比本机新、b 比本机旧、c 的源路径相同但来自 dev3 且比本机旧（按源路径视为同一文件）、d 为新文件
+func newMergeFixture(t *testing.T) (*BackupTracker, string) {
+	tempDir := t.TempDir()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	tracker := NewBackupTracker(filepath.Join(tempDir, "records.json"), logger.NewLogger(false))
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("加载备份记录失败: %v", err)
+	}
+	clock := utils.NewFakeClock(base)
+	tracker.SetClock(clock)
+	tracker.AddRecord("a.opus", "local/a.opus", "dev1", 100, "hash_a")
+	clock.Advance(2 * time.Hour)
+	tracker.AddRecord("b.opus", "local/b.opus", "dev1", 200, "hash_b")
+	tracker.AddRecord("c.opus", "local/c.opus", "dev2", 300, "hash_c")
+
+	remote := BackupStorage{
+		Version: "1.0",
+		Records: []BackupRecord{
+			{SourcePath: "a.opus", TargetPath: "remote/a.opus", DeviceID: "dev1", FileSize: 110, BackupTime: base.Add(time.Hour), Success: true, Synced: true},
+			{SourcePath: "b.opus", TargetPath: "remote/b.opus", DeviceID: "dev1", FileSize: 220, BackupTime: base.Add(time.Hour), Success: true, Synced: true},
+			{SourcePath: "c.opus", TargetPath: "remote/c.opus", DeviceID: "dev3", FileSize: 330, BackupTime: base, Success: true, Synced: true},
+			{SourcePath: "d.opus", TargetPath: "remote/d.opus", DeviceID: "dev1", FileSize: 440, BackupTime: base.Add(3 * time.Hour), Success: true, Synced: true},
+		},
+	}
+	data, err := json.Marshal(remote)
+	if err != nil {
+		t.Fatalf("序列化导入记录失败: %v", err)
+	}
+	importPath := filepath.Join(tempDir, "remote.json")
+	if err := os.WriteFile(importPath, data, 0644); err != nil {
+		t.Fatalf("写入导入记录失败: %v", err)
+	}
+	return tracker, importPath
+}
+
+// findRecord 按源路径查找记录
+func findRecord(tracker *BackupTracker, sourcePath string) *BackupRecord {
+	for _, record := range tracker.GetStorage().Records {
+		if record.SourcePath == sourcePath {
+			return &record
+		}
+	}
+	return nil
+}
+
+// TestBackupTracker_ImportRecords 测试导入部分重叠的记录时各合并策略的计数和最终记录内容
+func TestBackupTracker_ImportRecords(t *testing.T) {
+	tests := []struct {
+		strategy                MergeStrategy
+		added, updated, skipped int
+		wantA, wantB, wantC     string // 合并后 a、b、c 的目标路径
+	}{
+		{MergeSkipExisting, 1, 0, 3, "local/a.opus", "local/b.opus", "local/c.opus"},
+		{MergeOverwrite, 1, 3, 0, "remote/a.opus", "remote/b.opus", "remote/c.opus"},
+		{MergeNewerWins, 1, 1, 2, "remote/a.opus", "local/b.opus", "local/c.opus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			tracker, importPath := newMergeFixture(t)
+
+			added, updated, skipped, err := tracker.ImportRecords(importPath, tt.strategy)
+			if err != nil {
+				t.Fatalf("导入备份记录失败: %v", err)
+			}
+			if added != tt.added || updated != tt.updated || skipped != tt.skipped {
+				t.Errorf("新增/更新/跳过 = %d/%d/%d，期望 %d/%d/%d", added, updated, skipped, tt.added, tt.updated, tt.skipped)
+			}
+
+			storage := tracker.GetStorage()
+			if len(storage.Records) != 4 {
+				t.Fatalf("合并后应有 4 条记录，实际 %d 条", len(storage.Records))
+			}
+			if a := findRecord(tracker, "a.opus"); a == nil || a.TargetPath != tt.wantA {
+				t.Errorf("a.opus 的记录 = %+v，期望目标 %s", a, tt.wantA)
+			}
+			if b := findRecord(tracker, "b.opus"); b == nil || b.TargetPath != tt.wantB {
+				t.Errorf("b.opus 的记录 = %+v，期望目标 %s", b, tt.wantB)
+			}
+			if c := findRecord(tracker, "c.opus"); c == nil || c.TargetPath != tt.wantC {
+				t.Errorf("c.opus 的记录 = %+v，期望目标 %s", c, tt.wantC)
+			}
+			if d := findRecord(tracker, "d.opus"); d == nil || d.FileSize != 440 || d.Synced {
+				t.Errorf("d.opus 应作为新记录导入并待同步: %+v", d)
+			}
+
+			var totalSize int64
+			for _, record := range storage.Records {
+				totalSize += record.FileSize
+			}
+			if storage.TotalFilesBackedUp != 4 || storage.TotalSize != totalSize {
+				t.Errorf("统计 = %d 个 %d 字节，期望 4 个 %d 字节", storage.TotalFilesBackedUp, storage.TotalSize, totalSize)
+			}
+		})
+	}
+
+	tracker, importPath := newMergeFixture(t)
+	if _, _, _, err := tracker.ImportRecords(importPath, "merge-all"); err == nil {
+		t.Error("不支持的合并策略应返回错误")
+	}
+}
+
+// TestBackupTracker_ImportRecordsReload 测试导入合并的记录保存后重新加载仍全部保留，统计不变
+func TestBackupTracker_ImportRecordsReload(t *testing.T) {
+	tracker, importPath := newMergeFixture(t)
+	if _, _, _, err := tracker.ImportRecords(importPath, MergeOverwrite); err != nil {
+		t.Fatalf("导入备份记录失败: %v", err)
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("保存备份记录失败: %v", err)
+	}
+	merged := tracker.GetStorage()
+
+	reloaded := NewBackupTracker(tracker.storagePath, logger.NewLogger(false))
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载备份记录失败: %v", err)
+	}
+	storage := reloaded.GetStorage()
+	if len(storage.Records) != len(merged.Records) {
+		t.Fatalf("重新加载后有 %d 条记录，期望 %d 条", len(storage.Records), len(merged.Records))
+	}
+	for _, record := range merged.Records {
+		if got := findRecord(reloaded, record.SourcePath); got == nil || got.TargetPath != record.TargetPath || got.DeviceID != record.DeviceID {
+			t.Errorf("%s 重新加载后的记录 = %+v，期望 %+v", record.SourcePath, got, record)
+		}
+	}
+	if storage.TotalFilesBackedUp != merged.TotalFilesBackedUp || storage.TotalSize != merged.TotalSize {
+		t.Errorf("重新加载后的统计 = %d 个 %d 字节，期望 %d 个 %d 字节",
+			storage.TotalFilesBackedUp, storage.TotalSize, merged.TotalFilesBackedUp, merged.TotalSize)
+	}
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// archiveFilePattern 归档文件名格式，按记录的备份年份分文件
+const archiveFilePattern = "records_archive_%d.json"
+
+// SetArchiveAfterDays 设置记录归档天数，超过该天数的记录在加载和保存时移到同目录下的 records_archive_YYYY.json，
+// 主文件只保留近期记录；0 表示不归档。需在 Load 之前设置
+func (bt *BackupTracker) SetArchiveAfterDays(days int) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	bt.archiveAfterDays = days
+}
+
+// ArchivePath 返回指定年份的归档文件路径
+func (bt *BackupTracker) ArchivePath(year int) string {
+	return filepath.Join(filepath.Dir(bt.storagePath), fmt.Sprintf(archiveFilePattern, year))
+}
+
+// archiveOld 把超过归档天数的记录按备份年份合并到归档文件并从主文件移除，返回移出的记录数，假设已经获取了锁
+// 归档文件写入失败时保留这些记录，下次保存时重试；累计备份数和总大小不变
+func (bt *BackupTracker) archiveOld() int {
+	if bt.archiveAfterDays <= 0 {
+		return 0
+	}
+	cutoff := bt.clock.Now().AddDate(0, 0, -bt.archiveAfterDays)
+
+	byYear := make(map[int][]BackupRecord)
+	for _, record := range bt.storage.Records {
+		if record.BackupTime.Before(cutoff) {
+			year := record.BackupTime.Year()
+			byYear[year] = append(byYear[year], record)
+		}
+	}
+	if len(byYear) == 0 {
+		return 0
+	}
+
+	archivedYears := make(map[int]bool)
+	moved := 0
+	for year, records := range byYear {
+		if err := bt.appendArchive(year, records); err != nil {
+			bt.log.Warn("归档 %d 年的备份记录失败，暂时保留在主文件中: %v", year, err)
+			continue
+		}
+		archivedYears[year] = true
+		moved += len(records)
+	}
+	if moved == 0 {
+		return 0
+	}
+
+	kept := make([]BackupRecord, 0, len(bt.storage.Records)-moved)
+	for _, record := range bt.storage.Records {
+		if record.BackupTime.Before(cutoff) && archivedYears[record.BackupTime.Year()] {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	bt.storage.Records = kept
+	bt.archived, bt.archivedLoaded = nil, false
+	bt.dirty = true
+	bt.log.Info("已将 %d 条超过 %d 天的备份记录移到归档文件", moved, bt.archiveAfterDays)
+	return moved
+}
+
+// appendArchive 把记录合并到指定年份的归档文件，源路径相同的记录以新的为准
+func (bt *BackupTracker) appendArchive(year int, records []BackupRecord) error {
+	path := bt.ArchivePath(year)
+	archive, err := bt.readStorageFile(path)
+	if os.IsNotExist(err) {
+		archive = &BackupStorage{Version: "1.0", CreatedAt: bt.clock.Now()}
+	} else if err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(archive.Records))
+	for i, record := range archive.Records {
+		index[record.SourcePath] = i
+	}
+	for _, record := range records {
+		if i, ok := index[record.SourcePath]; ok {
+			archive.Records[i] = record
+			continue
+		}
+		index[record.SourcePath] = len(archive.Records)
+		archive.Records = append(archive.Records, record)
+	}
+	archive.UpdatedAt = bt.clock.Now()
+	return bt.writeStorageFile(path, archive)
+}
+
+// readStorageFile 读取并解析记录文件，加密的文件使用当前密钥解密；文件不存在时返回 os.IsNotExist 可识别的错误
+func (bt *BackupTracker) readStorageFile(path string) (*BackupStorage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isEncrypted(data) {
+		if bt.key == nil {
+			return nil, fmt.Errorf("%s: %w", path, ErrKeyRequired)
+		}
+		if data, err = decryptData(data, bt.key); err != nil {
+			return nil, fmt.Errorf("解密 %s 失败: %w", path, err)
+		}
+	}
+
+	var storage BackupStorage
+	if err := json.Unmarshal(data, &storage); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+	return &storage, nil
+}
+
+// archivedRecords 返回所有归档文件中的记录，首次调用时加载，假设已经获取了锁
+// 归档文件无法读取时记录警告并跳过该文件
+func (bt *BackupTracker) archivedRecords() []BackupRecord {
+	if bt.archivedLoaded {
+		return bt.archived
+	}
+	bt.archivedLoaded = true
+	bt.archived = nil
+
+	paths, _ := filepath.Glob(filepath.Join(filepath.Dir(bt.storagePath), "records_archive_*.json"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		archive, err := bt.readStorageFile(path)
+		if err != nil {
+			bt.log.Warn("读取归档的备份记录失败: %v", err)
+			continue
+		}
+		bt.archived = append(bt.archived, archive.Records...)
+	}
+	return bt.archived
+}
+
+// ArchivedRecords 返回所有归档文件中记录的副本
+func (bt *BackupTracker) ArchivedRecords() []BackupRecord {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	archived := bt.archivedRecords()
+	records := make([]BackupRecord, len(archived))
+	copy(records, archived)
+	return records
+}
+
+// allRecords 返回主文件记录加上未被主文件覆盖的归档记录，假设已经获取了锁
+// 同一源路径在主文件和归档中都有时以主文件为准（如归档记录被更新后取回主文件）
+func (bt *BackupTracker) allRecords() []BackupRecord {
+	records := make([]BackupRecord, len(bt.storage.Records))
+	copy(records, bt.storage.Records)
+	archived := bt.archivedRecords()
+	if len(archived) == 0 {
+		return records
+	}
+
+	inMain := make(map[string]bool, len(records))
+	for _, record := range records {
+		inMain[record.SourcePath] = true
+	}
+	for _, record := range archived {
+		if !inMain[record.SourcePath] {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// recordIndex 返回源路径对应记录在主文件中的下标，只在归档中存在时先取回主文件，都没有时返回 -1，假设已经获取了锁
+// 取回的记录修改后在下次保存时按归档天数重新合并到归档文件
+func (bt *BackupTracker) recordIndex(sourcePath string) int {
+	for i := range bt.storage.Records {
+		if bt.storage.Records[i].SourcePath == sourcePath {
+			return i
+		}
+	}
+	for _, record := range bt.archivedRecords() {
+		if record.SourcePath == sourcePath {
+			bt.storage.Records = append(bt.storage.Records, record)
+			bt.dirty = true
+			return len(bt.storage.Records) - 1
+		}
+	}
+	return -1
+}
+
+// removeArchived 从所有归档文件中删除源路径对应的记录，返回被删除的记录，假设已经获取了锁
+func (bt *BackupTracker) removeArchived(sourcePath string) (*BackupRecord, error) {
+	paths, _ := filepath.Glob(filepath.Join(filepath.Dir(bt.storagePath), "records_archive_*.json"))
+	var removed *BackupRecord
+	for _, path := range paths {
+		archive, err := bt.readStorageFile(path)
+		if err != nil {
+			return removed, err
+		}
+		kept := archive.Records[:0]
+		for _, record := range archive.Records {
+			if record.SourcePath == sourcePath {
+				record := record
+				removed = &record
+				continue
+			}
+			kept = append(kept, record)
+		}
+		if len(kept) == len(archive.Records) {
+			continue
+		}
+		archive.Records = kept
+		archive.UpdatedAt = bt.clock.Now()
+		if err := bt.writeStorageFile(path, archive); err != nil {
+			return removed, err
+		}
+	}
+	if removed != nil {
+		bt.archived, bt.archivedLoaded = nil, false
+	}
+	return removed, nil
+}
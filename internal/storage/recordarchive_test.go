@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// TestBackupTracker_ArchiveOldRecords 测试超过归档天数的记录在加载时移到按年份的归档文件，
+// 主文件变小，归档记录仍参与已备份判断并能通过包含归档的查询读到
+func TestBackupTracker_ArchiveOldRecords(t *testing.T) {
+	tempDir := t.TempDir()
+	recordsPath := filepath.Join(tempDir, "records.json")
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+
+	var records []BackupRecord
+	addRecord := func(name string, backupTime time.Time) {
+		records = append(records, BackupRecord{
+			SourcePath: "/device/" + name,
+			TargetPath: "/backup/" + name,
+			FileSize:   100,
+			FileHash:   "hash_" + name,
+			BackupTime: backupTime,
+			DeviceID:   "dev",
+			Success:    true,
+		})
+	}
+	for i := 0; i < 20; i++ {
+		addRecord("2022_"+string(rune('a'+i))+".opus", time.Date(2022, 12, 1, 9, 0, 0, 0, time.Local))
+		addRecord("2023_"+string(rune('a'+i))+".opus", time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local))
+	}
+	addRecord("recent.opus", now.AddDate(0, 0, -10))
+	data, err := json.MarshalIndent(BackupStorage{Version: "1.0", Records: records, TotalFilesBackedUp: len(records)}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(recordsPath, data, FilePermissions); err != nil {
+		t.Fatal(err)
+	}
+	sizeBefore := int64(len(data))
+
+	tracker := NewBackupTracker(recordsPath, logger.NewLogger(false))
+	tracker.SetClock(utils.NewFakeClock(now))
+	tracker.SetArchiveAfterDays(90)
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("加载备份记录失败: %v", err)
+	}
+
+	// 主文件只保留近期记录
+	if len(tracker.storage.Records) != 1 || tracker.storage.Records[0].SourcePath != "/device/recent.opus" {
+		t.Fatalf("主文件记录 = %+v，期望只剩 recent.opus", tracker.storage.Records)
+	}
+	info, err := os.Stat(recordsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() >= sizeBefore/10 {
+		t.Errorf("归档后主文件 %d 字节，归档前 %d 字节，应明显变小", info.Size(), sizeBefore)
+	}
+	if tracker.storage.TotalFilesBackedUp != len(records) {
+		t.Errorf("累计备份数 = %d，归档不应改变累计统计", tracker.storage.TotalFilesBackedUp)
+	}
+
+	// 旧记录按备份年份移到归档文件
+	for _, year := range []int{2022, 2023} {
+		archived, err := ReadRecordsFile(tracker.ArchivePath(year), "")
+		if err != nil {
+			t.Fatalf("读取 %d 年的归档失败: %v", year, err)
+		}
+		if len(archived) != 20 {
+			t.Errorf("%d 年归档 %d 条记录，期望 20", year, len(archived))
+		}
+		for _, record := range archived {
+			if record.BackupTime.Year() != year {
+				t.Errorf("%d 年归档中出现 %v 的记录", year, record.BackupTime)
+			}
+		}
+	}
+
+	// 归档的文件仍视为已备份，不会重复复制
+	if backedUp, _, _ := tracker.IsFileBackedUp("/device/2022_a.opus"); !backedUp {
+		t.Error("已归档的文件应视为已备份")
+	}
+	if backedUp, _ := tracker.IsHashBackedUp("hash_2023_b.opus"); !backedUp {
+		t.Error("已归档的内容哈希应视为已备份")
+	}
+
+	// 默认查询只有近期记录，包含归档时能读到全部记录
+	if _, total := tracker.ListRecords(0, 0, ""); total != 1 {
+		t.Errorf("默认查询 %d 条记录，期望 1", total)
+	}
+	all, total := tracker.ListRecordsIncludingArchived(0, 10, "-time")
+	if total != len(records) {
+		t.Errorf("包含归档的查询 %d 条记录，期望 %d", total, len(records))
+	}
+	if len(all) != 10 || all[0].SourcePath != "/device/recent.opus" {
+		t.Errorf("包含归档的第一页 = %d 条，首条 %s", len(all), all[0].SourcePath)
+	}
+
+	// 再次保存时合并到已有归档文件，不产生重复
+	clock := utils.NewFakeClock(now.AddDate(0, 0, 100))
+	tracker.SetClock(clock)
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("保存备份记录失败: %v", err)
+	}
+	if len(tracker.storage.Records) != 0 {
+		t.Errorf("超过归档天数后主文件仍有 %d 条记录", len(tracker.storage.Records))
+	}
+	reloaded := NewBackupTracker(recordsPath, logger.NewLogger(false))
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if archived := reloaded.ArchivedRecords(); len(archived) != len(records) {
+		t.Errorf("归档记录 %d 条，期望 %d", len(archived), len(records))
+	}
+}
+
+// TestBackupTracker_ArchivedRecordUpdates 测试已归档的记录能被按设备查询、更新和移除，移除后不再视为已备份
+func TestBackupTracker_ArchivedRecordUpdates(t *testing.T) {
+	recordsPath := filepath.Join(t.TempDir(), "records.json")
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	tracker := NewBackupTracker(recordsPath, logger.NewLogger(false))
+	tracker.SetClock(utils.NewFakeClock(now.AddDate(-1, 0, 0)))
+	for _, name := range []string{"old.opus", "trashed.opus"} {
+		if err := tracker.AddRecord("/device/"+name, "/backup/"+name, "dev", 100, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tracker.SetClock(utils.NewFakeClock(now))
+	tracker.SetArchiveAfterDays(90)
+	if err := tracker.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracker.storage.Records) != 0 {
+		t.Fatalf("旧记录应已归档: %+v", tracker.storage.Records)
+	}
+
+	if records := tracker.GetRecordsByDevice("dev"); len(records) != 2 {
+		t.Errorf("按设备查询应包含归档记录，实际 %d 条", len(records))
+	}
+	if records := tracker.GetStorage().Records; len(records) != 2 {
+		t.Errorf("GetStorage 应包含归档记录，实际 %d 条", len(records))
+	}
+
+	if err := tracker.SetTargetHash("/device/old.opus", "hash", now, 100); err != nil {
+		t.Fatalf("更新归档记录失败: %v", err)
+	}
+	if record, err := tracker.GetRecordByPath("/device/old.opus"); err != nil || record.FileHash != "hash" {
+		t.Errorf("归档记录的更新应生效: %+v, %v", record, err)
+	}
+
+	if err := tracker.RemoveRecord("/device/trashed.opus"); err != nil {
+		t.Fatalf("移除归档记录失败: %v", err)
+	}
+	if backedUp, _, _ := tracker.IsFileBackedUp("/device/trashed.opus"); backedUp {
+		t.Error("移除的归档记录不应再视为已备份")
+	}
+	archived, err := ReadRecordsFile(tracker.ArchivePath(now.Year()-1), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range archived {
+		if record.SourcePath == "/device/trashed.opus" {
+			t.Error("归档文件中应删除被移除的记录")
+		}
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if records := tracker.GetRecordsByDevice("dev"); len(records) != 1 || records[0].FileHash != "hash" {
+		t.Errorf("保存后应只剩更新过的 old.opus: %+v", records)
+	}
+}
+
+// TestBackupTracker_ArchivedRecordReplaced 测试已归档记录的文件再次备份或导入时更新原记录，不重复添加，统计不重复计算
+func TestBackupTracker_ArchivedRecordReplaced(t *testing.T) {
+	recordsPath := filepath.Join(t.TempDir(), "records.json")
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	tracker := NewBackupTracker(recordsPath, logger.NewLogger(false))
+	tracker.SetClock(utils.NewFakeClock(now.AddDate(-1, 0, 0)))
+	for _, name := range []string{"a.opus", "b.opus"} {
+		if err := tracker.AddRecord("/device/"+name, "/backup/"+name, "dev", 100, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tracker.SetClock(utils.NewFakeClock(now))
+	tracker.SetArchiveAfterDays(90)
+	if err := tracker.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracker.storage.Records) != 0 {
+		t.Fatalf("旧记录应已归档: %+v", tracker.storage.Records)
+	}
+
+	// a.opus 大小变化后再次备份
+	if err := tracker.AddRecord("/device/a.opus", "/backup/a.opus", "dev", 150, ""); err != nil {
+		t.Fatal(err)
+	}
+	// b.opus 从另一台机器导入较新的记录
+	imported, err := json.Marshal(BackupStorage{Version: "1.0", Records: []BackupRecord{
+		{SourcePath: "/device/b.opus", TargetPath: "/remote/b.opus", DeviceID: "dev", FileSize: 120, BackupTime: now, Success: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	importPath := filepath.Join(t.TempDir(), "remote.json")
+	if err := os.WriteFile(importPath, imported, FilePermissions); err != nil {
+		t.Fatal(err)
+	}
+	if added, updated, _, err := tracker.ImportRecords(importPath, MergeNewerWins); err != nil || added != 0 || updated != 1 {
+		t.Fatalf("导入应更新归档的记录: 新增 %d，更新 %d，%v", added, updated, err)
+	}
+
+	if err := tracker.Save(); err != nil {
+		t.Fatal(err)
+	}
+	storage := tracker.GetStorage()
+	if len(storage.Records) != 2 {
+		t.Fatalf("应只有 2 条记录，实际 %d 条: %+v", len(storage.Records), storage.Records)
+	}
+	if storage.TotalFilesBackedUp != 2 || storage.TotalSize != 270 {
+		t.Errorf("统计 = %d 个 %d 字节，期望 2 个 270 字节", storage.TotalFilesBackedUp, storage.TotalSize)
+	}
+	if record, err := tracker.GetRecordByPath("/device/a.opus"); err != nil || record.FileSize != 150 {
+		t.Errorf("a.opus 的记录应更新为新的大小: %+v, %v", record, err)
+	}
+	if record, err := tracker.GetRecordByPath("/device/b.opus"); err != nil || record.TargetPath != "/remote/b.opus" {
+		t.Errorf("b.opus 的记录应更新为导入的记录: %+v, %v", record, err)
+	}
+}
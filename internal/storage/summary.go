@@ -0,0 +1,115 @@
+package storage
+
+import "time"
+
+// RunSummary 一次备份运行的结果概况
+type RunSummary struct {
+	DeviceName string        `json:"device_name"`
+	DeviceID   string        `json:"device_id"`
+	StartTime  time.Time     `json:"start_time"`
+	Duration   time.Duration `json:"duration"`
+	Scanned    int           `json:"scanned"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Skipped    int           `json:"skipped"`
+	Bytes      int64         `json:"bytes"`
+	// 复制速度（字节/秒），按各文件复制字节数与耗时之和计算，不受并发数影响；没有复制文件时为0
+	Speed float64 `json:"speed,omitempty"`
+	// 历次运行复制速度的滑动平均，最多统计最近 SpeedHistoryWindow 次
+	HistorySpeed float64 `json:"history_speed,omitempty"`
+	SpeedSamples int     `json:"speed_samples,omitempty"`
+	// 复制失败的文件（相对于 source.base_path 的路径），供 resume 子命令续跑
+	FailedFiles []string `json:"failed_files,omitempty"`
+}
+
+// SpeedHistoryWindow 复制速度历史平均统计的运行次数
+const SpeedHistoryWindow = 10
+
+// Overview 备份概况，由 status 子命令展示
+type Overview struct {
+	DeviceName string
+	LastBackup time.Time   // 最近一次有文件备份成功的时间
+	TotalFiles int         // 累计备份文件数
+	TotalSize  int64       // 累计备份大小
+	LastRun    *RunSummary // 最近一次备份运行，从未运行时为nil
+}
+
+// SetLastRun 记录设备最近一次备份运行的概况
+func (bt *BackupTracker) SetLastRun(summary RunSummary) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if bt.storage.LastRuns == nil {
+		bt.storage.LastRuns = make(map[string]*RunSummary)
+	}
+	if previous := bt.storage.LastRuns[summary.DeviceName]; previous != nil {
+		summary.HistorySpeed = previous.HistorySpeed
+		summary.SpeedSamples = previous.SpeedSamples
+	}
+	summary.updateHistorySpeed()
+	bt.storage.LastRuns[summary.DeviceName] = &summary
+	bt.dirty = true
+}
+
+// updateHistorySpeed 将本次速度计入历史平均，样本数达到窗口后按窗口大小加权，近似最近N次的平均
+func (summary *RunSummary) updateHistorySpeed() {
+	if summary.Speed <= 0 {
+		return
+	}
+	if summary.SpeedSamples < SpeedHistoryWindow {
+		summary.SpeedSamples++
+	}
+	n := float64(summary.SpeedSamples)
+	summary.HistorySpeed += (summary.Speed - summary.HistorySpeed) / n
+}
+
+// HistorySpeed 获取设备历次运行复制速度的平均值（字节/秒），没有历史时返回0
+func (bt *BackupTracker) HistorySpeed(deviceName string) float64 {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if run := bt.storage.LastRuns[deviceName]; run != nil {
+		return run.HistorySpeed
+	}
+	return 0
+}
+
+// Overview 获取备份概况，deviceName 为空时汇总所有设备
+func (bt *BackupTracker) Overview(deviceName string) Overview {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	return BuildOverview(bt.storage, deviceName)
+}
+
+// BuildOverview 从备份存储聚合概况
+// 指定设备时，只统计该设备最近一次运行对应设备ID的记录；未记录过运行时统计全部记录
+func BuildOverview(storage *BackupStorage, deviceName string) Overview {
+	overview := Overview{DeviceName: deviceName}
+
+	if deviceName != "" {
+		overview.LastRun = storage.LastRuns[deviceName]
+	} else {
+		for _, run := range storage.LastRuns {
+			if overview.LastRun == nil || run.StartTime.After(overview.LastRun.StartTime) {
+				overview.LastRun = run
+			}
+		}
+	}
+
+	deviceID := ""
+	if deviceName != "" && overview.LastRun != nil {
+		deviceID = overview.LastRun.DeviceID
+	}
+
+	for _, record := range storage.Records {
+		if deviceID != "" && record.DeviceID != deviceID {
+			continue
+		}
+		overview.TotalFiles++
+		overview.TotalSize += record.FileSize
+		if record.BackupTime.After(overview.LastBackup) {
+			overview.LastBackup = record.BackupTime
+		}
+	}
+
+	return overview
+}
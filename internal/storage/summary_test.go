@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// TestBuildOverview 测试按设备聚合备份概况
+func TestBuildOverview(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	storage := &BackupStorage{
+		Records: []BackupRecord{
+			{SourcePath: "a.opus", FileSize: 100, DeviceID: "dev-1", BackupTime: base},
+			{SourcePath: "b.opus", FileSize: 200, DeviceID: "dev-1", BackupTime: base.Add(time.Hour)},
+			{SourcePath: "c.opus", FileSize: 400, DeviceID: "dev-2", BackupTime: base.Add(2 * time.Hour)},
+		},
+		LastRuns: map[string]*RunSummary{
+			"SR302": {DeviceName: "SR302", DeviceID: "dev-1", StartTime: base.Add(time.Hour), Scanned: 2, Succeeded: 1, Skipped: 1},
+			"SR502": {DeviceName: "SR502", DeviceID: "dev-2", StartTime: base.Add(2 * time.Hour), Scanned: 1, Succeeded: 1},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		deviceName     string
+		wantFiles      int
+		wantSize       int64
+		wantLastBackup time.Time
+		wantRunDevice  string
+	}{
+		{"指定设备只统计该设备记录", "SR302", 2, 300, base.Add(time.Hour), "SR302"},
+		{"未指定设备汇总全部记录", "", 3, 700, base.Add(2 * time.Hour), "SR502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overview := BuildOverview(storage, tt.deviceName)
+
+			if overview.TotalFiles != tt.wantFiles {
+				t.Errorf("期望文件数 %d，实际 %d", tt.wantFiles, overview.TotalFiles)
+			}
+			if overview.TotalSize != tt.wantSize {
+				t.Errorf("期望总大小 %d，实际 %d", tt.wantSize, overview.TotalSize)
+			}
+			if !overview.LastBackup.Equal(tt.wantLastBackup) {
+				t.Errorf("期望上次备份时间 %v，实际 %v", tt.wantLastBackup, overview.LastBackup)
+			}
+			if overview.LastRun == nil || overview.LastRun.DeviceName != tt.wantRunDevice {
+				t.Errorf("期望最近运行设备 %s，实际 %+v", tt.wantRunDevice, overview.LastRun)
+			}
+		})
+	}
+}
+
+// TestBackupTracker_LastRunPersist 测试最近运行概况随记录文件保存和加载
+func TestBackupTracker_LastRunPersist(t *testing.T) {
+	log := logger.NewLogger(false)
+	path := filepath.Join(t.TempDir(), "records.json")
+
+	tracker := NewBackupTracker(path, log)
+	tracker.SetLastRun(RunSummary{DeviceName: "SR302", Scanned: 5, Succeeded: 3, Failed: 1, Skipped: 1})
+	if err := tracker.Commit(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	reloaded := NewBackupTracker(path, log)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+
+	run := reloaded.Overview("SR302").LastRun
+	if run == nil {
+		t.Fatal("期望加载到最近运行概况")
+	}
+	if run.Scanned != 5 || run.Succeeded != 3 || run.Failed != 1 || run.Skipped != 1 {
+		t.Errorf("最近运行概况不一致: %+v", run)
+	}
+	if reloaded.Overview("SR502").LastRun != nil {
+		t.Error("未运行过的设备不应有最近运行概况")
+	}
+}
+
+// TestBackupTracker_HistorySpeed 测试复制速度历史平均的累计
+func TestBackupTracker_HistorySpeed(t *testing.T) {
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), logger.NewLogger(false))
+
+	tests := []struct {
+		name  string
+		speed float64
+		want  float64
+	}{
+		{"首次运行", 100, 100},
+		{"第二次运行取平均", 200, 150},
+		{"没有复制文件不计入", 0, 150},
+		{"第三次运行", 300, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker.SetLastRun(RunSummary{DeviceName: "SR302", Speed: tt.speed})
+			if got := tracker.HistorySpeed("SR302"); got != tt.want {
+				t.Errorf("HistorySpeed() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := tracker.HistorySpeed("SR502"); got != 0 {
+		t.Errorf("没有历史的设备 HistorySpeed() = %v, 期望 0", got)
+	}
+
+	// 样本数达到窗口后不再增加，新速度按窗口大小加权
+	for i := 0; i < SpeedHistoryWindow*2; i++ {
+		tracker.SetLastRun(RunSummary{DeviceName: "SR502", Speed: 100})
+	}
+	tracker.SetLastRun(RunSummary{DeviceName: "SR502", Speed: 1100})
+	if got := tracker.HistorySpeed("SR502"); got != 200 {
+		t.Errorf("窗口加权后 HistorySpeed() = %v, 期望 200", got)
+	}
+}
@@ -1,10 +1,12 @@
 package storage
 
 import (
+	"archive/zip"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,6 +36,33 @@ type BackupRecord struct {
 	Verified        bool      `json:"verified"`
 	VerifyTime      time.Time `json:"verify_time"`
 	HashAlgorithm   string    `json:"hash_algorithm"`
+	// 计算 FileHash 时目标文件的修改时间与大小，两者未变时验证直接使用 FileHash，不重新计算
+	TargetModTime   time.Time `json:"target_mod_time"`
+	TargetSize      int64     `json:"target_size"`
+	// 远程同步状态，新增或更新的记录为false，推送成功后置为true
+	Synced          bool      `json:"synced"`
+	// 音频编码元数据，解析失败或非Opus文件时为空
+	AudioMeta       *utils.OpusMeta `json:"audio_meta,omitempty"`
+	// 保留的历史版本的目标路径，最近的在前，未开启保留版本时为空
+	Versions        []string  `json:"versions,omitempty"`
+	// 内容寻址布局下目标文件名使用的内容哈希（SHA256），相同内容的多条记录指向同一个目标文件
+	ContentHash     string    `json:"content_hash,omitempty"`
+}
+
+// 一致性问题类型
+const (
+	// InconsistencyMissingTarget 目标文件缺失
+	InconsistencyMissingTarget = "missing_target"
+	// InconsistencySizeMismatch 目标文件大小与记录不符
+	InconsistencySizeMismatch = "size_mismatch"
+)
+
+// Inconsistency 备份记录与目标文件之间的不一致
+type Inconsistency struct {
+	SourcePath string `json:"source_path"`
+	TargetPath string `json:"target_path"`
+	Kind       string `json:"kind"`
+	Detail     string `json:"detail"`
 }
 
 // BackupStorage 备份存储结构
@@ -45,6 +74,8 @@ type BackupStorage struct {
 	Records            []BackupRecord `json:"records"`
 	CreatedAt          time.Time     `json:"created_at"`
 	UpdatedAt          time.Time     `json:"updated_at"`
+	LastSync           time.Time     `json:"last_sync"` // 上次成功同步到远程的时间
+	LastRuns           map[string]*RunSummary `json:"last_runs,omitempty"` // 各设备最近一次备份运行的概况，键为设备名称
 }
 
 // BackupTracker 备份跟踪器
@@ -53,22 +84,68 @@ type BackupTracker struct {
 	storage     *BackupStorage
 	log         *logger.Logger
 	mu          sync.Mutex
+	dirty       bool   // 上次保存后是否有未持久化的变更
+	key         []byte // 记录文件加密密钥，nil表示明文存储
+	loadErr     error  // 加密记录无法读取时的错误，存在时拒绝保存以免覆盖原文件
+	clock       utils.Clock // 记录的备份、校验时间取自该时钟
+	subscribers []*recordSubscriber // 记录变更的订阅者
+	archiveAfterDays int            // 超过该天数的记录在加载和保存时移到按年归档的文件，0表示不归档
+	archived         []BackupRecord // 已归档的记录，首次在主文件中未命中时加载
+	archivedLoaded   bool
 }
 
 // NewBackupTracker 创建新的备份跟踪器
 func NewBackupTracker(storagePath string, log *logger.Logger) *BackupTracker {
+	now := utils.SystemClock.Now()
 	return &BackupTracker{
 		storagePath: storagePath,
 		log:         log,
+		clock:       utils.SystemClock,
 		storage:     &BackupStorage{
 			Version:   "1.0",
 			Records:   make([]BackupRecord, 0),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			CreatedAt: now,
+			UpdatedAt: now,
 		},
 	}
 }
 
+// SetClock 替换时钟，测试时注入假时钟
+func (bt *BackupTracker) SetClock(clock utils.Clock) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	bt.clock = clock
+}
+
+// SetEncryptionKey 设置记录文件加密密钥，设置后 Save 使用AES-GCM加密，空字符串表示明文存储
+func (bt *BackupTracker) SetEncryptionKey(passphrase string) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if passphrase == "" {
+		bt.key = nil
+		return
+	}
+	bt.key = deriveKey(passphrase)
+}
+
+// SetLogger 替换日志器，用于为一次备份会话的日志附加会话ID，返回原日志器
+func (bt *BackupTracker) SetLogger(log *logger.Logger) *logger.Logger {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	previous := bt.log
+	bt.log = log
+	return previous
+}
+
+// LoadError 返回加密记录无法读取（未配置密钥或密钥错误）时的错误，此时记录为空且不会保存，不应据此备份
+func (bt *BackupTracker) LoadError() error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	return bt.loadErr
+}
+
 // Load 加载备份记录
 func (bt *BackupTracker) Load() error {
 	bt.mu.Lock()
@@ -86,6 +163,23 @@ func (bt *BackupTracker) Load() error {
 		return fmt.Errorf("读取备份记录文件失败: %w", err)
 	}
 
+	// 解密
+	if isEncrypted(data) {
+		if bt.key == nil {
+			bt.loadErr = ErrKeyRequired
+			return bt.loadErr
+		}
+		plaintext, err := decryptData(data, bt.key)
+		if err != nil {
+			bt.loadErr = fmt.Errorf("解密备份记录失败: %w", err)
+			return bt.loadErr
+		}
+		data = plaintext
+	} else if bt.key != nil {
+		bt.log.Info("备份记录为明文，下次保存时将加密")
+	}
+	bt.loadErr = nil
+
 	// 解析JSON
 	var storage BackupStorage
 	if err := json.Unmarshal(data, &storage); err != nil {
@@ -93,8 +187,8 @@ func (bt *BackupTracker) Load() error {
 		bt.storage = &BackupStorage{
 			Version:   "1.0",
 			Records:   make([]BackupRecord, 0),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			CreatedAt: bt.clock.Now(),
+			UpdatedAt: bt.clock.Now(),
 		}
 		return bt.save()
 	}
@@ -106,6 +200,16 @@ func (bt *BackupTracker) Load() error {
 
 	bt.storage = &storage
 	bt.log.Info("已加载 %d 个备份记录", len(storage.Records))
+
+	// 合并旧版本或崩溃恢复遗留的重复记录
+	if removed := bt.dedup(); removed > 0 {
+		bt.log.Warn("合并了 %d 条重复的备份记录", removed)
+	}
+
+	// 主文件中有超过保留天数的记录时立即归档，使主文件只保留近期记录
+	if bt.archiveOld() > 0 {
+		return bt.save()
+	}
 	return nil
 }
 
@@ -117,48 +221,84 @@ func (bt *BackupTracker) Save() error {
 	return bt.save()
 }
 
+// Commit 仅在有未持久化的变更时保存，用于备份过程中的分批提交
+func (bt *BackupTracker) Commit() error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if !bt.dirty {
+		return nil
+	}
+	return bt.save()
+}
+
 // save 内部保存方法（不加锁）
 func (bt *BackupTracker) save() error {
+	if bt.loadErr != nil {
+		return fmt.Errorf("备份记录未能读取，拒绝覆盖: %w", bt.loadErr)
+	}
+
 	// 确保目录存在
 	dir := filepath.Dir(bt.storagePath)
 	if err := os.MkdirAll(dir, DirPermissions); err != nil {
 		return fmt.Errorf("创建备份记录目录失败: %w", err)
 	}
 
+	// 超过保留天数的记录先写入归档文件，再从主文件移除
+	bt.archiveOld()
+
 	// 更新时间戳
-	bt.storage.UpdatedAt = time.Now()
+	bt.storage.UpdatedAt = bt.clock.Now()
+
+	if err := bt.writeStorageFile(bt.storagePath, bt.storage); err != nil {
+		return err
+	}
+
+	bt.dirty = false
+	bt.log.Debug("备份记录已保存到: %s", bt.storagePath)
+	return nil
+}
 
+// writeStorageFile 序列化并原子地写入记录文件，配置了密钥时加密
+func (bt *BackupTracker) writeStorageFile(path string, storage *BackupStorage) error {
 	// 序列化
-	data, err := json.MarshalIndent(bt.storage, "", "  ")
+	data, err := json.MarshalIndent(storage, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化备份记录失败: %w", err)
 	}
 
+	// 配置了密钥时加密整个记录文件
+	if bt.key != nil {
+		data, err = encryptData(data, bt.key)
+		if err != nil {
+			return fmt.Errorf("加密备份记录失败: %w", err)
+		}
+	}
+
 	// 写入临时文件然后重命名（确保原子性）
-	tempPath := bt.storagePath + ".tmp"
+	tempPath := path + ".tmp"
 	if err := os.WriteFile(tempPath, data, FilePermissions); err != nil {
 		return fmt.Errorf("写入临时备份记录文件失败: %w", err)
 	}
 
 	// 重命名
-	if err := os.Rename(tempPath, bt.storagePath); err != nil {
+	if err := os.Rename(tempPath, path); err != nil {
 		os.Remove(tempPath) // 清理临时文件
 		return fmt.Errorf("保存备份记录文件失败: %w", err)
 	}
-
-	bt.log.Debug("备份记录已保存到: %s", bt.storagePath)
 	return nil
 }
 
-// AddRecord 添加备份记录（保持向后兼容）
+// AddRecord 添加备份记录（保持向后兼容），同一源路径已有记录时更新该记录
 func (bt *BackupTracker) AddRecord(sourcePath, targetPath, deviceID string, fileSize int64, fileHash string) error {
 	return bt.AddRecordWithVerify(sourcePath, targetPath, deviceID, fileSize, fileHash, false, "")
 }
 
-// AddRecordWithVerify 添加带完整性验证的备份记录
+// AddRecordWithVerify 添加带完整性验证的备份记录，同一源路径已有记录时更新该记录
 func (bt *BackupTracker) AddRecordWithVerify(sourcePath, targetPath, deviceID string, fileSize int64, fileHash string, integrityCheck bool, hashAlgorithm string) error {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
+	now := bt.clock.Now()
 
 	// 获取文件修改时间（对于MTP设备，可能失败）
 	var lastModified time.Time
@@ -166,7 +306,7 @@ func (bt *BackupTracker) AddRecordWithVerify(sourcePath, targetPath, deviceID st
 		lastModified = fileInfo.ModTime()
 	} else {
 		bt.log.Warn("无法获取源文件修改时间: %s", sourcePath)
-		lastModified = time.Now()
+		lastModified = now
 	}
 
 	record := BackupRecord{
@@ -174,30 +314,253 @@ func (bt *BackupTracker) AddRecordWithVerify(sourcePath, targetPath, deviceID st
 		TargetPath:      targetPath,
 		FileSize:        fileSize,
 		FileHash:        fileHash,
-		BackupTime:      time.Now(),
+		BackupTime:      now,
 		LastModified:    lastModified,
 		DeviceID:        deviceID,
 		Success:         true,
 		IntegrityCheck:  integrityCheck,
 		Verified:        integrityCheck && fileHash != "", // 如果有哈希值，认为已验证
-		VerifyTime:      time.Now(),
+		VerifyTime:      now,
 		HashAlgorithm:   hashAlgorithm,
 	}
+	// 缓存哈希对应的目标文件状态，归档条目、远程对象等无法读取时不缓存
+	if fileHash != "" {
+		if info, err := os.Stat(targetPath); err == nil {
+			record.TargetModTime = info.ModTime()
+			record.TargetSize = info.Size()
+		}
+	}
+
+	bt.storage.LastBackup = now
+	bt.dirty = true
+
+	// 已归档的记录取回主文件后更新，不重复添加
+	if i := bt.recordIndex(sourcePath); i >= 0 {
+		bt.storage.TotalSize += fileSize - bt.storage.Records[i].FileSize
+		bt.storage.Records[i] = record
+		bt.publish(RecordUpdated, record)
+		bt.log.Debug("更新备份记录: %s", sourcePath)
+		return nil
+	}
 
 	bt.storage.Records = append(bt.storage.Records, record)
-	bt.storage.LastBackup = time.Now()
 	bt.storage.TotalFilesBackedUp++
 	bt.storage.TotalSize += fileSize
+	bt.publish(RecordAdded, record)
 
 	bt.log.Debug("添加备份记录: %s", sourcePath)
 	return nil
 }
 
+// SetRecordMetadata 为已有备份记录设置音频元数据
+func (bt *BackupTracker) SetRecordMetadata(sourcePath string, meta *utils.OpusMeta) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	i := bt.recordIndex(sourcePath)
+	if i < 0 {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	bt.storage.Records[i].AudioMeta = meta
+	bt.dirty = true
+	return nil
+}
+
+// SetRecordVersions 设置记录保留的历史版本路径，最近的在前
+func (bt *BackupTracker) SetRecordVersions(sourcePath string, versions []string) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	i := bt.recordIndex(sourcePath)
+	if i < 0 {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	bt.storage.Records[i].Versions = append([]string(nil), versions...)
+	bt.dirty = true
+	return nil
+}
+
+// SetContentHash 设置记录的内容哈希，内容寻址布局下由此从哈希找回原名
+func (bt *BackupTracker) SetContentHash(sourcePath, contentHash string) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	i := bt.recordIndex(sourcePath)
+	if i < 0 {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	bt.storage.Records[i].ContentHash = contentHash
+	bt.dirty = true
+	return nil
+}
+
+// RecordsByContent 返回内容哈希相同的所有备份记录，即同一个内容寻址文件的各个原名
+func (bt *BackupTracker) RecordsByContent(contentHash string) []BackupRecord {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	var records []BackupRecord
+	if contentHash == "" {
+		return records
+	}
+	for _, record := range bt.allRecords() {
+		if record.ContentHash == contentHash {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// SetRecordTarget 更新记录的目标路径，如散文件并入归档后改为 "<zip路径>!/<条目名>"
+func (bt *BackupTracker) SetRecordTarget(sourcePath, targetPath string) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	i := bt.recordIndex(sourcePath)
+	if i < 0 {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	record := &bt.storage.Records[i]
+	record.TargetPath = targetPath
+	// 缓存的目标文件状态属于原路径
+	record.TargetModTime = time.Time{}
+	record.TargetSize = 0
+	bt.dirty = true
+	bt.publish(RecordUpdated, *record)
+	return nil
+}
+
+// SetTargetHash 更新记录中目标文件的哈希及计算时目标文件的修改时间与大小
+func (bt *BackupTracker) SetTargetHash(sourcePath, fileHash string, modTime time.Time, size int64) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	i := bt.recordIndex(sourcePath)
+	if i < 0 {
+		return fmt.Errorf("未找到备份记录: %s", sourcePath)
+	}
+	record := &bt.storage.Records[i]
+	record.FileHash = fileHash
+	record.TargetModTime = modTime
+	record.TargetSize = size
+	bt.dirty = true
+	return nil
+}
+
+// Dedup 合并同一源路径的重复记录，保留备份时间最新的一条，返回移除的记录数
+func (bt *BackupTracker) Dedup() int {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	removed := bt.dedup()
+	if removed > 0 {
+		bt.log.Info("合并了 %d 条重复的备份记录", removed)
+	}
+	return removed
+}
+
+// dedup 内部去重方法（不加锁）
+func (bt *BackupTracker) dedup() int {
+	// 每个源路径最新记录的下标，备份时间相同时取后写入的一条
+	latest := make(map[string]int, len(bt.storage.Records))
+	for i, record := range bt.storage.Records {
+		if j, ok := latest[record.SourcePath]; !ok || !record.BackupTime.Before(bt.storage.Records[j].BackupTime) {
+			latest[record.SourcePath] = i
+		}
+	}
+
+	removed := len(bt.storage.Records) - len(latest)
+	if removed == 0 {
+		return 0
+	}
+
+	records := make([]BackupRecord, 0, len(latest))
+	for i, record := range bt.storage.Records {
+		if latest[record.SourcePath] == i {
+			records = append(records, record)
+		} else {
+			bt.storage.TotalFilesBackedUp--
+			bt.storage.TotalSize -= record.FileSize
+		}
+	}
+
+	bt.storage.Records = records
+	bt.dirty = true
+	return removed
+}
+
+// Verify 检查备份记录与目标文件是否一致，返回目标缺失、大小不符等问题
+func (bt *BackupTracker) Verify() []Inconsistency {
+	bt.mu.Lock()
+	records := bt.allRecords()
+	bt.mu.Unlock()
+
+	var problems []Inconsistency
+	for _, record := range records {
+		if !record.Success {
+			continue
+		}
+
+		size, err := targetSize(record.TargetPath)
+		if err != nil {
+			problems = append(problems, Inconsistency{
+				SourcePath: record.SourcePath,
+				TargetPath: record.TargetPath,
+				Kind:       InconsistencyMissingTarget,
+				Detail:     err.Error(),
+			})
+			continue
+		}
+
+		if size != record.FileSize {
+			problems = append(problems, Inconsistency{
+				SourcePath: record.SourcePath,
+				TargetPath: record.TargetPath,
+				Kind:       InconsistencySizeMismatch,
+				Detail:     fmt.Sprintf("记录大小 %d，实际大小 %d", record.FileSize, size),
+			})
+		}
+	}
+
+	bt.log.Debug("一致性检查完成，共 %d 条记录，发现 %d 个问题", len(records), len(problems))
+	return problems
+}
+
+// targetSize 获取备份目标的大小，支持 "<zip路径>!/<条目名>" 形式的归档条目
+func targetSize(targetPath string) (int64, error) {
+	archivePath, entryName, isEntry := strings.Cut(targetPath, "!/")
+	if !isEntry {
+		info, err := os.Stat(targetPath)
+		if err != nil {
+			return 0, fmt.Errorf("目标文件不存在: %w", err)
+		}
+		return info.Size(), nil
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("打开归档失败: %w", err)
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		if f.Name == entryName {
+			return int64(f.UncompressedSize64), nil
+		}
+	}
+	return 0, fmt.Errorf("归档中不存在条目: %s", entryName)
+}
+
+// SourceChanged 设备上的文件大小与记录不同时返回 true，说明录音在上次备份后被修改，需要重新复制
+// 设备未报告大小（0）时无法判断，视为未变化；记录的 LastModified 取自备份时刻，不参与比较
+func (r *BackupRecord) SourceChanged(file *utils.FileInfo) bool {
+	return r != nil && file != nil && file.Size > 0 && r.FileSize != file.Size
+}
+
 // isFileBackedUpInternal 内部方法，假设已经获取了锁
 func (bt *BackupTracker) isFileBackedUpInternal(sourcePath string) (bool, *BackupRecord) {
 	// 对于MTP设备路径，我们不能直接使用os.Stat
-	// 只检查是否存在相同路径的备份记录
-	// TODO: 实现MTP设备文件信息获取后，再进行文件大小和修改时间比较
+	// 只检查是否存在相同路径的备份记录，文件是否被修改由调用方用 SourceChanged 比较
 
 	// 查找匹配的记录
 	for i := range bt.storage.Records {
@@ -207,6 +570,15 @@ func (bt *BackupTracker) isFileBackedUpInternal(sourcePath string) (bool, *Backu
 		}
 	}
 
+	// 已归档的旧记录同样表示文件已备份
+	archived := bt.archivedRecords()
+	for i := range archived {
+		record := &archived[i]
+		if record.SourcePath == sourcePath && record.Success {
+			return true, record
+		}
+	}
+
 	return false, nil
 }
 
@@ -219,14 +591,52 @@ func (bt *BackupTracker) IsFileBackedUp(sourcePath string) (bool, *BackupRecord,
 	return backedUp, record, nil
 }
 
-// GetRecordByPath 根据路径获取备份记录
+// isHashBackedUpInternal 按内容哈希查找已成功备份的记录，假设已经获取了锁
+func (bt *BackupTracker) isHashBackedUpInternal(fileHash string) (bool, *BackupRecord) {
+	if fileHash == "" {
+		return false, nil
+	}
+
+	for i := range bt.storage.Records {
+		record := &bt.storage.Records[i]
+		if record.FileHash == fileHash && record.Success {
+			return true, record
+		}
+	}
+
+	archived := bt.archivedRecords()
+	for i := range archived {
+		record := &archived[i]
+		if record.FileHash == fileHash && record.Success {
+			return true, record
+		}
+	}
+
+	return false, nil
+}
+
+// IsHashBackedUp 检查相同内容的文件是否已备份（文件改名后仍能识别）
+func (bt *BackupTracker) IsHashBackedUp(fileHash string) (bool, *BackupRecord) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	return bt.isHashBackedUpInternal(fileHash)
+}
+
+// GetRecordByPath 根据路径获取备份记录的副本
 func (bt *BackupTracker) GetRecordByPath(sourcePath string) (*BackupRecord, error) {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
 
 	for i := range bt.storage.Records {
 		if bt.storage.Records[i].SourcePath == sourcePath {
-			return &bt.storage.Records[i], nil
+			record := bt.storage.Records[i]
+			return &record, nil
+		}
+	}
+	for _, record := range bt.archivedRecords() {
+		if record.SourcePath == sourcePath {
+			return &record, nil
 		}
 	}
 
@@ -242,8 +652,16 @@ func (bt *BackupTracker) GetNewFiles(files []*utils.FileInfo, deviceID string) (
 	newCount := 0
 
 	for _, file := range files {
-		// 检查是否已备份（使用内部方法避免重复获取锁）
-		backedUp, _ := bt.isFileBackedUpInternal(file.Path)
+		// 检查是否已备份（使用内部方法避免重复获取锁），上次备份后被修改的文件重新备份
+		backedUp, record := bt.isFileBackedUpInternal(file.Path)
+		if backedUp && record.SourceChanged(file) {
+			bt.log.Debug("文件在上次备份后被修改: %s (%d -> %d 字节)", file.RelativePath, record.FileSize, file.Size)
+			backedUp = false
+		}
+		if !backedUp {
+			// 枚举阶段已预计算哈希时，按内容判断
+			backedUp, _ = bt.isHashBackedUpInternal(file.Hash)
+		}
 
 		if !backedUp {
 			newFiles = append(newFiles, file)
@@ -263,25 +681,39 @@ func (bt *BackupTracker) GetStatistics() (int, int64, time.Time, error) {
 	return bt.storage.TotalFilesBackedUp, bt.storage.TotalSize, bt.storage.LastBackup, nil
 }
 
-// RemoveRecord 移除备份记录
+// RemoveRecord 移除备份记录，归档文件中的同一记录一并删除
 func (bt *BackupTracker) RemoveRecord(sourcePath string) error {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
 
+	var removed *BackupRecord
 	for i, record := range bt.storage.Records {
 		if record.SourcePath == sourcePath {
-			// 更新统计
-			bt.storage.TotalFilesBackedUp--
-			bt.storage.TotalSize -= record.FileSize
-
 			// 移除记录
 			bt.storage.Records = append(bt.storage.Records[:i], bt.storage.Records[i+1:]...)
-			bt.log.Debug("移除备份记录: %s", sourcePath)
-			return nil
+			bt.dirty = true
+			removed = &record
+			break
 		}
 	}
+	archived, err := bt.removeArchived(sourcePath)
+	if err != nil {
+		return fmt.Errorf("从归档中移除备份记录失败: %w", err)
+	}
+	if removed == nil {
+		removed = archived
+	}
+	if removed == nil {
+		return fmt.Errorf("未找到要移除的备份记录: %s", sourcePath)
+	}
 
-	return fmt.Errorf("未找到要移除的备份记录: %s", sourcePath)
+	// 更新统计
+	bt.storage.TotalFilesBackedUp--
+	bt.storage.TotalSize -= removed.FileSize
+	bt.dirty = true
+	bt.publish(RecordRemoved, *removed)
+	bt.log.Debug("移除备份记录: %s", sourcePath)
+	return nil
 }
 
 // ClearRecords 清空所有备份记录
@@ -289,23 +721,26 @@ func (bt *BackupTracker) ClearRecords() error {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
 
+	for _, record := range bt.storage.Records {
+		bt.publish(RecordRemoved, record)
+	}
 	bt.storage.Records = make([]BackupRecord, 0)
 	bt.storage.TotalFilesBackedUp = 0
 	bt.storage.TotalSize = 0
 	bt.storage.LastBackup = time.Time{}
-	bt.storage.UpdatedAt = time.Now()
+	bt.storage.UpdatedAt = bt.clock.Now()
 
 	bt.log.Info("已清空所有备份记录")
 	return nil
 }
 
-// GetRecordsByDevice 获取指定设备的备份记录
+// GetRecordsByDevice 获取指定设备的备份记录，包含已归档的记录
 func (bt *BackupTracker) GetRecordsByDevice(deviceID string) []BackupRecord {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
 
 	var records []BackupRecord
-	for _, record := range bt.storage.Records {
+	for _, record := range bt.allRecords() {
 		if record.DeviceID == deviceID {
 			records = append(records, record)
 		}
@@ -319,7 +754,7 @@ func (bt *BackupTracker) CleanOldRecords(keepDays int) error {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
 
-	cutoff := time.Now().AddDate(0, 0, -keepDays)
+	cutoff := bt.clock.Now().AddDate(0, 0, -keepDays)
 	cleaned := 0
 
 	var newRecords []BackupRecord
@@ -349,16 +784,52 @@ func (bt *BackupTracker) ExportRecords(exportPath string) error {
 	return os.WriteFile(exportPath, data, FilePermissions)
 }
 
-// GetStorage 获取存储对象（只读）
+// GetUnsyncedRecords 获取尚未同步到远程的记录（包括上次同步失败待重试的记录）
+func (bt *BackupTracker) GetUnsyncedRecords() []BackupRecord {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	var records []BackupRecord
+	for _, record := range bt.storage.Records {
+		if !record.Synced {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// MarkSynced 将已推送的记录标记为已同步，并更新同步游标
+func (bt *BackupTracker) MarkSynced(records []BackupRecord, syncTime time.Time) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	synced := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		synced[record.SourcePath] = record.BackupTime
+	}
+
+	for i := range bt.storage.Records {
+		record := &bt.storage.Records[i]
+		// 同步期间记录被更新（备份时间变化）时保持未同步，下次再推送
+		if backupTime, ok := synced[record.SourcePath]; ok && backupTime.Equal(record.BackupTime) {
+			record.Synced = true
+		}
+	}
+
+	bt.storage.LastSync = syncTime
+	bt.dirty = true
+	bt.log.Debug("标记 %d 个记录为已同步", len(records))
+}
+
+// GetStorage 获取存储对象（只读），Records 包含已归档的记录，索引页、校验等读取方都能看到全部备份
 func (bt *BackupTracker) GetStorage() *BackupStorage {
 	bt.mu.Lock()
 	defer bt.mu.Unlock()
 
 	// 返回副本避免并发问题
 	storageCopy := *bt.storage
-	recordsCopy := make([]BackupRecord, len(bt.storage.Records))
-	copy(recordsCopy, bt.storage.Records)
-	storageCopy.Records = recordsCopy
+	storageCopy.Records = bt.allRecords()
 
 	return &storageCopy
 }
\ No newline at end of file
@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -149,6 +152,41 @@ func TestBackupTracker_AddRecordWithVerify(t *testing.T) {
 	}
 }
 
+// TestBackupTracker_SetClock 测试备份时间和清理旧记录按注入的时钟计算
+func TestBackupTracker_SetClock(t *testing.T) {
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "records.json"), logger.NewLogger(false))
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("加载备份记录失败: %v", err)
+	}
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	clock := utils.NewFakeClock(start)
+	tracker.SetClock(clock)
+
+	if err := tracker.AddRecordWithVerify("/device/old.opus", "/backup/old.opus", "dev", 10, "h1", true, "sha256"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(10 * 24 * time.Hour)
+	if err := tracker.AddRecord("/device/new.opus", "/backup/new.opus", "dev", 10, "h2"); err != nil {
+		t.Fatal(err)
+	}
+
+	old := tracker.storage.Records[0]
+	if !old.BackupTime.Equal(start) || !old.VerifyTime.Equal(start) {
+		t.Errorf("备份时间 = %v / %v，期望 %v", old.BackupTime, old.VerifyTime, start)
+	}
+	if !tracker.storage.LastBackup.Equal(start.Add(10 * 24 * time.Hour)) {
+		t.Errorf("最近备份时间 = %v", tracker.storage.LastBackup)
+	}
+
+	// 保留 7 天：10 天前的记录被清理
+	if err := tracker.CleanOldRecords(7); err != nil {
+		t.Fatalf("清理旧记录失败: %v", err)
+	}
+	if len(tracker.storage.Records) != 1 || tracker.storage.Records[0].SourcePath != "/device/new.opus" {
+		t.Errorf("清理后的记录 = %+v", tracker.storage.Records)
+	}
+}
+
 // TestBackupTracker_IsFileBackedUp 测试检查文件是否已备份
 func TestBackupTracker_IsFileBackedUp(t *testing.T) {
 	tempDir := t.TempDir()
@@ -269,6 +307,68 @@ func TestBackupTracker_GetNewFiles(t *testing.T) {
 	}
 }
 
+// TestBackupTracker_GetNewFilesChanged 测试大小与记录不同的已备份文件重新备份，设备未报告大小时不判断
+func TestBackupTracker_GetNewFilesChanged(t *testing.T) {
+	log := logger.NewLogger(false)
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "test_backup.json"), log)
+
+	for _, path := range []string{"/test/source/same.opus", "/test/source/grown.opus", "/test/source/unknown.opus"} {
+		if err := tracker.AddRecord(path, "/test/target/"+filepath.Base(path), "device123", 2048, "hash"); err != nil {
+			t.Fatalf("添加备份记录失败: %v", err)
+		}
+	}
+
+	files := []*utils.FileInfo{
+		{Path: "/test/source/same.opus", Name: "same.opus", Size: 2048},
+		{Path: "/test/source/grown.opus", Name: "grown.opus", Size: 4096},
+		{Path: "/test/source/unknown.opus", Name: "unknown.opus", Size: 0},
+	}
+	newFiles, err := tracker.GetNewFiles(files, "device123")
+	if err != nil {
+		t.Fatalf("获取新文件失败: %v", err)
+	}
+	if len(newFiles) != 1 || newFiles[0].Name != "grown.opus" {
+		t.Errorf("只有被修改的 grown.opus 应重新备份，实际 %d 个", len(newFiles))
+	}
+}
+
+// TestBackupTracker_GetNewFilesByHash 测试预计算了哈希的改名文件不再视为新文件
+func TestBackupTracker_GetNewFilesByHash(t *testing.T) {
+	log := logger.NewLogger(false)
+	tracker := NewBackupTracker(filepath.Join(t.TempDir(), "test_backup.json"), log)
+
+	if err := tracker.AddRecord("/test/source/old_name.opus", "/test/target/old_name.opus", "device123", 2048, "hash456"); err != nil {
+		t.Fatalf("添加备份记录失败: %v", err)
+	}
+
+	files := []*utils.FileInfo{
+		{Path: "/test/source/new_name.opus", Name: "new_name.opus", Size: 2048, Hash: "hash456"},
+		{Path: "/test/source/other.opus", Name: "other.opus", Size: 1024, Hash: "hash789"},
+		{Path: "/test/source/unhashed.opus", Name: "unhashed.opus", Size: 512},
+	}
+
+	newFiles, err := tracker.GetNewFiles(files, "device123")
+	if err != nil {
+		t.Fatalf("获取新文件失败: %v", err)
+	}
+
+	if len(newFiles) != 2 {
+		t.Fatalf("期望新文件数量为 2，实际为 %d", len(newFiles))
+	}
+	for _, file := range newFiles {
+		if file.Name == "new_name.opus" {
+			t.Error("内容已备份的改名文件不应该在新文件列表中")
+		}
+	}
+
+	if backedUp, record := tracker.IsHashBackedUp("hash456"); !backedUp || record.SourcePath != "/test/source/old_name.opus" {
+		t.Errorf("期望按哈希找到原记录，实际 %v %+v", backedUp, record)
+	}
+	if backedUp, _ := tracker.IsHashBackedUp(""); backedUp {
+		t.Error("空哈希不应视为已备份")
+	}
+}
+
 // TestBackupTracker_RemoveRecord 测试移除备份记录
 func TestBackupTracker_RemoveRecord(t *testing.T) {
 	tempDir := t.TempDir()
@@ -580,7 +680,7 @@ func TestBackupTracker_ConcurrentAccess(t *testing.T) {
 	for i := 0; i < numGoroutines; i++ {
 		go func(goroutineID int) {
 			defer func() { done <- true }()
-			_, err := tracker.GetStatistics()
+			_, _, _, err := tracker.GetStatistics()
 			if err != nil {
 				t.Errorf("并发获取统计信息失败 (goroutine %d): %v", goroutineID, err)
 			}
@@ -616,4 +716,228 @@ func TestBackupTracker_LoadInvalidJSON(t *testing.T) {
 	if len(tracker.storage.Records) != 0 {
 		t.Errorf("期望记录数量为 0，实际为 %d", len(tracker.storage.Records))
 	}
-}
\ No newline at end of file
+}
+// TestBackupTracker_AddRecordUpsert 测试同一源路径重复添加时更新而非追加
+func TestBackupTracker_AddRecordUpsert(t *testing.T) {
+	tempDir := t.TempDir()
+	tracker := NewBackupTracker(filepath.Join(tempDir, "test_backup.json"), logger.NewLogger(false))
+
+	if err := tracker.AddRecord("/test/source/a.opus", "/test/target/a.opus", "device", 100, "hash1"); err != nil {
+		t.Fatalf("添加备份记录失败: %v", err)
+	}
+	if err := tracker.AddRecord("/test/source/a.opus", "/test/target/a_new.opus", "device", 300, "hash2"); err != nil {
+		t.Fatalf("更新备份记录失败: %v", err)
+	}
+
+	if len(tracker.storage.Records) != 1 {
+		t.Fatalf("期望记录数量为 1，实际为 %d", len(tracker.storage.Records))
+	}
+	record := tracker.storage.Records[0]
+	if record.TargetPath != "/test/target/a_new.opus" || record.FileHash != "hash2" {
+		t.Errorf("记录应被更新为最新内容，实际: %+v", record)
+	}
+
+	count, size, _, _ := tracker.GetStatistics()
+	if count != 1 || size != 300 {
+		t.Errorf("统计信息错误，期望 1 个文件 300 字节，实际 %d 个 %d 字节", count, size)
+	}
+}
+
+// TestBackupTracker_Dedup 测试合并重复记录时每个路径只保留最新一条
+func TestBackupTracker_Dedup(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test_backup.json")
+	base := time.Now().Add(-time.Hour)
+
+	// 构造含重复记录的存储文件
+	storage := BackupStorage{
+		Version: "1.0",
+		Records: []BackupRecord{
+			{SourcePath: "/a.opus", TargetPath: "/old/a.opus", FileSize: 10, BackupTime: base, Success: true},
+			{SourcePath: "/b.opus", TargetPath: "/b.opus", FileSize: 20, BackupTime: base, Success: true},
+			{SourcePath: "/a.opus", TargetPath: "/new/a.opus", FileSize: 11, BackupTime: base.Add(2 * time.Minute), Success: true},
+			{SourcePath: "/a.opus", TargetPath: "/mid/a.opus", FileSize: 12, BackupTime: base.Add(time.Minute), Success: true},
+		},
+		TotalFilesBackedUp: 4,
+		TotalSize:          53,
+	}
+
+	tracker := NewBackupTracker(testFile, logger.NewLogger(false))
+	tracker.storage = &storage
+
+	if removed := tracker.Dedup(); removed != 2 {
+		t.Errorf("期望移除 2 条重复记录，实际 %d", removed)
+	}
+	if removed := tracker.Dedup(); removed != 0 {
+		t.Errorf("再次去重不应移除记录，实际 %d", removed)
+	}
+
+	counts := make(map[string]int)
+	for _, record := range tracker.storage.Records {
+		counts[record.SourcePath]++
+	}
+	for path, count := range counts {
+		if count != 1 {
+			t.Errorf("路径 %s 应只有 1 条记录，实际 %d", path, count)
+		}
+	}
+
+	_, record, _ := tracker.IsFileBackedUp("/a.opus")
+	if record == nil || record.TargetPath != "/new/a.opus" {
+		t.Errorf("应保留最新的记录，实际: %+v", record)
+	}
+
+	count, size, _, _ := tracker.GetStatistics()
+	if count != 2 || size != 31 {
+		t.Errorf("统计信息错误，期望 2 个文件 31 字节，实际 %d 个 %d 字节", count, size)
+	}
+
+	// 加载含重复记录的文件时自动去重
+	data, err := json.Marshal(BackupStorage{
+		Version: "1.0",
+		Records: []BackupRecord{
+			{SourcePath: "/a.opus", BackupTime: base, Success: true},
+			{SourcePath: "/a.opus", BackupTime: base.Add(time.Minute), Success: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewBackupTracker(testFile, logger.NewLogger(false))
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("加载备份记录失败: %v", err)
+	}
+	if len(loaded.storage.Records) != 1 {
+		t.Errorf("加载后应自动去重，实际记录数 %d", len(loaded.storage.Records))
+	}
+}
+
+// TestBackupTracker_Verify 测试一致性自检
+func TestBackupTracker_Verify(t *testing.T) {
+	tempDir := t.TempDir()
+	tracker := NewBackupTracker(filepath.Join(tempDir, "test_backup.json"), logger.NewLogger(false))
+
+	okFile := filepath.Join(tempDir, "ok.opus")
+	badSizeFile := filepath.Join(tempDir, "bad_size.opus")
+	os.WriteFile(okFile, []byte("12345"), 0644)
+	os.WriteFile(badSizeFile, []byte("123"), 0644)
+
+	tracker.AddRecord("/src/ok.opus", okFile, "device", 5, "")
+	tracker.AddRecord("/src/bad_size.opus", badSizeFile, "device", 10, "")
+	tracker.AddRecord("/src/missing.opus", filepath.Join(tempDir, "missing.opus"), "device", 1, "")
+
+	problems := tracker.Verify()
+	if len(problems) != 2 {
+		t.Fatalf("期望发现 2 个问题，实际 %d: %+v", len(problems), problems)
+	}
+
+	kinds := make(map[string]string)
+	for _, p := range problems {
+		kinds[p.SourcePath] = p.Kind
+	}
+	if kinds["/src/bad_size.opus"] != InconsistencySizeMismatch {
+		t.Errorf("应报告大小不符: %+v", problems)
+	}
+	if kinds["/src/missing.opus"] != InconsistencyMissingTarget {
+		t.Errorf("应报告目标缺失: %+v", problems)
+	}
+}
+
+// TestBackupTracker_Encryption 测试加密存储：文件不含明文、正确密钥可读回、错误密钥报错
+func TestBackupTracker_Encryption(t *testing.T) {
+	log := logger.NewLogger(false)
+	storagePath := filepath.Join(t.TempDir(), "records.json")
+	sourcePath := "/device/董总会谈录音.opus"
+
+	tracker := NewBackupTracker(storagePath, log)
+	tracker.SetEncryptionKey("correct-key")
+	if err := tracker.AddRecord(sourcePath, "/backup/董总会谈录音.opus", "device1", 2048, "hash1"); err != nil {
+		t.Fatalf("添加记录失败: %v", err)
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		t.Fatalf("读取记录文件失败: %v", err)
+	}
+	if strings.Contains(string(data), "董总会谈") || strings.Contains(string(data), "records") {
+		t.Error("加密后的记录文件不应包含明文")
+	}
+
+	t.Run("正确密钥", func(t *testing.T) {
+		loaded := NewBackupTracker(storagePath, log)
+		loaded.SetEncryptionKey("correct-key")
+		if err := loaded.Load(); err != nil {
+			t.Fatalf("加载失败: %v", err)
+		}
+		record, err := loaded.GetRecordByPath(sourcePath)
+		if err != nil || record.FileSize != 2048 || record.FileHash != "hash1" {
+			t.Errorf("读回的记录不正确: %+v, %v", record, err)
+		}
+	})
+
+	t.Run("错误密钥", func(t *testing.T) {
+		loaded := NewBackupTracker(storagePath, log)
+		loaded.SetEncryptionKey("wrong-key")
+		err := loaded.Load()
+		if !errors.Is(err, ErrWrongKey) {
+			t.Fatalf("应返回密钥错误，实际: %v", err)
+		}
+		if !errors.Is(loaded.LoadError(), ErrWrongKey) {
+			t.Errorf("LoadError 应保留密钥错误，实际: %v", loaded.LoadError())
+		}
+		if err := loaded.Save(); err == nil {
+			t.Error("解密失败后不应覆盖记录文件")
+		}
+		if after, _ := os.ReadFile(storagePath); !bytes.Equal(after, data) {
+			t.Error("记录文件被修改")
+		}
+	})
+
+	t.Run("未配置密钥", func(t *testing.T) {
+		loaded := NewBackupTracker(storagePath, log)
+		if err := loaded.Load(); !errors.Is(err, ErrKeyRequired) {
+			t.Errorf("应提示需要密钥，实际: %v", err)
+		}
+		if !errors.Is(loaded.LoadError(), ErrKeyRequired) {
+			t.Errorf("LoadError 应保留需要密钥的错误，实际: %v", loaded.LoadError())
+		}
+	})
+}
+
+// TestBackupTracker_EncryptionPlaintextCompat 测试无密钥时保持明文，配置密钥后可读取旧的明文记录
+func TestBackupTracker_EncryptionPlaintextCompat(t *testing.T) {
+	log := logger.NewLogger(false)
+	storagePath := filepath.Join(t.TempDir(), "records.json")
+
+	plain := NewBackupTracker(storagePath, log)
+	plain.AddRecord("/device/a.opus", "/backup/a.opus", "device1", 100, "hash")
+	if err := plain.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	data, _ := os.ReadFile(storagePath)
+	if !strings.Contains(string(data), "/device/a.opus") {
+		t.Fatal("无密钥时应保存为明文JSON")
+	}
+
+	encrypted := NewBackupTracker(storagePath, log)
+	encrypted.SetEncryptionKey("key")
+	if err := encrypted.Load(); err != nil {
+		t.Fatalf("配置密钥后应能读取明文记录: %v", err)
+	}
+	if _, err := encrypted.GetRecordByPath("/device/a.opus"); err != nil {
+		t.Errorf("明文记录丢失: %v", err)
+	}
+	if err := encrypted.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if data, _ := os.ReadFile(storagePath); strings.Contains(string(data), "/device/a.opus") {
+		t.Error("配置密钥后保存应转为加密存储")
+	}
+}
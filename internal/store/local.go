@@ -0,0 +1,102 @@
+package store
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/pkg/utils"
+)
+
+// PartSuffix 写入中的临时文件后缀，写完后重命名为目标文件
+const PartSuffix = ".part"
+
+// LocalStore 本地目录存储
+type LocalStore struct {
+	baseDir string
+}
+
+// NewLocalStore 创建以 baseDir 为根目录的本地存储
+func NewLocalStore(baseDir string) *LocalStore {
+	return &LocalStore{baseDir: baseDir}
+}
+
+// LocalPath 获取 relPath 对应的本地路径
+func (ls *LocalStore) LocalPath(relPath string) string {
+	relPath = strings.ReplaceAll(relPath, "\\", string(filepath.Separator))
+	relPath = strings.ReplaceAll(relPath, "/", string(filepath.Separator))
+	return filepath.Join(ls.baseDir, relPath)
+}
+
+// Write 先写入同目录的临时文件再重命名，避免中断时留下不完整的目标文件
+func (ls *LocalStore) Write(relPath string, r io.Reader) error {
+	path := ls.LocalPath(relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目标目录失败: %w", err)
+	}
+
+	tempPath := path + PartSuffix
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("创建目标文件失败: %w", err)
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("写入目标文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("关闭目标文件失败: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("重命名目标文件失败: %w", err)
+	}
+	return nil
+}
+
+// Import 把已写好的本地文件移动到 relPath，已存在时覆盖；不在同一磁盘卷时先复制到临时文件再重命名
+func (ls *LocalStore) Import(relPath, localPath string) error {
+	path := ls.LocalPath(relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目标目录失败: %w", err)
+	}
+	if err := utils.MoveFile(localPath, path); err != nil {
+		return fmt.Errorf("移入目标文件失败: %w", err)
+	}
+	return nil
+}
+
+// Exists 判断文件是否存在
+func (ls *LocalStore) Exists(relPath string) (bool, error) {
+	_, err := ls.Stat(relPath)
+	if err == ErrNotExist {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Stat 获取文件信息
+func (ls *LocalStore) Stat(relPath string) (FileStat, error) {
+	info, err := os.Stat(ls.LocalPath(relPath))
+	if os.IsNotExist(err) {
+		return FileStat{}, ErrNotExist
+	}
+	if err != nil {
+		return FileStat{}, fmt.Errorf("获取目标文件信息失败: %w", err)
+	}
+	if info.IsDir() {
+		return FileStat{}, fmt.Errorf("目标路径是目录: %s", relPath)
+	}
+	return FileStat{Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Location 本地存储的位置即本地路径
+func (ls *LocalStore) Location(relPath string) string {
+	return ls.LocalPath(relPath)
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// DefaultS3PartSize 分段上传的分段大小，超过一个分段的文件按分段上传（S3 要求除最后一段外不小于 5MB）
+const DefaultS3PartSize = 16 * 1024 * 1024
+
+// S3Store S3兼容对象存储（AWS S3、MinIO 等），通过 minio-go 以路径风格的URL访问
+type S3Store struct {
+	client   *minio.Client
+	bucket   string
+	prefix   string
+	partSize int64
+}
+
+// NewS3Store 创建S3存储
+func NewS3Store(cfg *config.S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3目标必须配置 endpoint 和 bucket")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("无效的S3地址: %s", cfg.Endpoint)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建S3客户端失败: %w", err)
+	}
+
+	return &S3Store{
+		client:   client,
+		bucket:   cfg.Bucket,
+		prefix:   cfg.Prefix,
+		partSize: DefaultS3PartSize,
+	}, nil
+}
+
+// Write 上传对象
+// 内容小于一个分段时一次性上传，否则按分段上传，不受单次上传 5GB 的限制；分段上传失败时由 minio-go 中止上传
+func (s *S3Store) Write(relPath string, r io.Reader) error {
+	first := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, first)
+	size := int64(-1)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		size = int64(n)
+	case err != nil:
+		return fmt.Errorf("读取上传内容失败: %w", err)
+	}
+
+	body := io.MultiReader(bytes.NewReader(first[:n]), r)
+	opts := minio.PutObjectOptions{PartSize: uint64(s.partSize)}
+	if _, err := s.client.PutObject(context.Background(), s.bucket, s.objectKey(relPath), body, size, opts); err != nil {
+		return fmt.Errorf("上传对象失败: %w", err)
+	}
+	return nil
+}
+
+// Exists 判断对象是否存在
+func (s *S3Store) Exists(relPath string) (bool, error) {
+	_, err := s.Stat(relPath)
+	if err == ErrNotExist {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Stat 获取对象信息
+func (s *S3Store) Stat(relPath string) (FileStat, error) {
+	info, err := s.client.StatObject(context.Background(), s.bucket, s.objectKey(relPath), minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return FileStat{}, ErrNotExist
+		}
+		return FileStat{}, fmt.Errorf("查询对象失败: %w", err)
+	}
+	return FileStat{Size: info.Size, ModTime: info.LastModified}, nil
+}
+
+// Location 返回 s3://bucket/key 形式的位置
+func (s *S3Store) Location(relPath string) string {
+	return "s3://" + s.bucket + "/" + s.objectKey(relPath)
+}
+
+// objectKey 获取对象键：前缀 + 以 / 分隔的相对路径
+func (s *S3Store) objectKey(relPath string) string {
+	key := strings.TrimLeft(strings.ReplaceAll(relPath, "\\", "/"), "/")
+	return s.prefix + key
+}
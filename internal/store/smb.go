@@ -0,0 +1,31 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SMBStore NAS的SMB共享存储
+// Windows 原生支持UNC路径，认证使用当前登录会话（可预先通过 net use 连接共享），
+// 因此直接复用本地文件系统的实现
+type SMBStore struct {
+	*LocalStore
+}
+
+// NewSMBStore 创建以UNC路径（如 \\nas\share\recordings）为根目录的SMB存储
+func NewSMBStore(uncPath string) (*SMBStore, error) {
+	if !isUNCPath(uncPath) {
+		return nil, fmt.Errorf("SMB目标必须是UNC路径: %s", uncPath)
+	}
+	return &SMBStore{LocalStore: NewLocalStore(uncPath)}, nil
+}
+
+// isUNCPath 判断是否为 \\server\share 或 //server/share 形式的路径
+func isUNCPath(path string) bool {
+	path = strings.ReplaceAll(path, "/", "\\")
+	if !strings.HasPrefix(path, "\\\\") {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "\\\\"), "\\")
+	return len(parts) >= 2 && parts[0] != "" && parts[1] != ""
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/allanpk716/record_center/internal/config"
+)
+
+// 目标存储类型
+const (
+	TypeLocal = "local"
+	TypeSMB   = "smb"
+	TypeS3    = "s3"
+)
+
+// ErrNotExist 目标文件不存在
+var ErrNotExist = errors.New("目标文件不存在")
+
+// FileStat 目标文件信息
+type FileStat struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// TargetStore 备份目标存储，relPath 为相对目标根目录的路径（分隔符可为 / 或 \）
+type TargetStore interface {
+	// Write 将 r 的全部内容写入 relPath，已存在时覆盖
+	Write(relPath string, r io.Reader) error
+	// Exists 判断 relPath 是否已存在
+	Exists(relPath string) (bool, error)
+	// Stat 获取 relPath 的信息，不存在时返回 ErrNotExist
+	Stat(relPath string) (FileStat, error)
+	// Location 获取 relPath 在存储中的完整位置，写入备份记录
+	Location(relPath string) string
+}
+
+// LocalPather 目标位于本地文件系统（含UNC路径）的存储
+// 复制器据此读取已写入的目标做校验、保留版本和设置属性；写入仍通过 Write 或 Import
+type LocalPather interface {
+	LocalPath(relPath string) string
+}
+
+// Importer 可选接口：把已完整写好的本地文件移入存储，本地存储直接移动文件，不必再复制一遍内容
+type Importer interface {
+	Import(relPath, localPath string) error
+}
+
+// Put 把本地文件 localPath 存入 relPath，成功后本地文件不再存在
+// 存储实现了 Importer 时直接移入，否则通过 Write 写入后删除本地文件
+func Put(s TargetStore, relPath, localPath string) error {
+	if importer, ok := s.(Importer); ok {
+		return importer.Import(relPath, localPath)
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("打开待写入的文件失败: %w", err)
+	}
+	err = s.Write(relPath, file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	os.Remove(localPath)
+	return nil
+}
+
+// New 根据目标配置创建存储
+func New(cfg *config.TargetConfig) (TargetStore, error) {
+	switch cfg.Type {
+	case "", TypeLocal:
+		return NewLocalStore(cfg.BaseDirectory), nil
+	case TypeSMB:
+		return NewSMBStore(cfg.BaseDirectory)
+	case TypeS3:
+		return NewS3Store(&cfg.S3)
+	default:
+		return nil, fmt.Errorf("不支持的目标存储类型: %s", cfg.Type)
+	}
+}
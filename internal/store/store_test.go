@@ -0,0 +1,333 @@
+package store
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/config"
+)
+
+// TestLocalStore 测试本地存储的写入、存在判断与信息获取
+func TestLocalStore(t *testing.T) {
+	ls := NewLocalStore(t.TempDir())
+
+	if exists, err := ls.Exists("录音笔文件\\a.opus"); err != nil || exists {
+		t.Fatalf("写入前不应存在: %v, %v", exists, err)
+	}
+	if _, err := ls.Stat("录音笔文件\\a.opus"); err != ErrNotExist {
+		t.Errorf("期望 ErrNotExist，实际 %v", err)
+	}
+
+	if err := ls.Write("录音笔文件\\a.opus", strings.NewReader("opus data")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	stat, err := ls.Stat("录音笔文件/a.opus")
+	if err != nil {
+		t.Fatalf("获取信息失败: %v", err)
+	}
+	if stat.Size != int64(len("opus data")) {
+		t.Errorf("期望大小 %d，实际 %d", len("opus data"), stat.Size)
+	}
+
+	data, err := os.ReadFile(ls.LocalPath("录音笔文件\\a.opus"))
+	if err != nil || string(data) != "opus data" {
+		t.Errorf("文件内容不一致: %q, %v", data, err)
+	}
+	if _, err := os.Stat(ls.LocalPath("录音笔文件\\a.opus") + ".part"); !os.IsNotExist(err) {
+		t.Error("不应残留临时文件")
+	}
+}
+
+// TestNewSMBStore 测试SMB存储只接受UNC路径
+func TestNewSMBStore(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{`\\nas\share\recordings`, false},
+		{"//nas/share", false},
+		{`\\nas`, true},
+		{`D:\backups`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := NewSMBStore(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("期望错误 %v，实际 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestNew 测试按配置选择存储后端
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.TargetConfig
+		want    string
+		wantErr bool
+	}{
+		{"默认本地", config.TargetConfig{BaseDirectory: "backups"}, "*store.LocalStore", false},
+		{"SMB", config.TargetConfig{Type: TypeSMB, BaseDirectory: `\\nas\share`}, "*store.SMBStore", false},
+		{"S3", config.TargetConfig{Type: TypeS3, S3: config.S3Config{Endpoint: "http://nas:9000", Bucket: "rec"}}, "*store.S3Store", false},
+		{"S3缺少bucket", config.TargetConfig{Type: TypeS3, S3: config.S3Config{Endpoint: "http://nas:9000"}}, "", true},
+		{"未知类型", config.TargetConfig{Type: "ftp"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期望错误 %v，实际 %v", tt.wantErr, err)
+			}
+			if err == nil {
+				if got := typeName(s); got != tt.want {
+					t.Errorf("期望 %s，实际 %s", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+// typeName 获取存储的类型名
+func typeName(s TargetStore) string {
+	switch s.(type) {
+	case *LocalStore:
+		return "*store.LocalStore"
+	case *SMBStore:
+		return "*store.SMBStore"
+	case *S3Store:
+		return "*store.S3Store"
+	}
+	return ""
+}
+
+// fakeS3 模拟S3服务，支持上传、查询和分段上传，解码 minio-go 在 HTTP 下使用的 aws-chunked 流式签名请求体
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	parts    map[string][]byte
+	failPart string // 上传该分段时返回错误
+	aborted  bool
+}
+
+func newFakeS3(t *testing.T, failPart string) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{objects: make(map[string][]byte), parts: make(map[string][]byte), failPart: failPart}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ak/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	data, _ := io.ReadAll(r.Body)
+	if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		data = decodeAWSChunked(data)
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		io.WriteString(w, "<InitiateMultipartUploadResult><UploadId>up1</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == http.MethodPut && query.Get("uploadId") == "up1":
+		if query.Get("partNumber") == f.failPart {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.parts[query.Get("partNumber")] = data
+		w.Header().Set("ETag", `"etag`+query.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && query.Get("uploadId") == "up1":
+		var complete struct {
+			Parts []struct {
+				Number int    `xml:"PartNumber"`
+				ETag   string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(data, &complete); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var merged []byte
+		for _, part := range complete.Parts {
+			if part.ETag != "etag"+strconv.Itoa(part.Number) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			merged = append(merged, f.parts[strconv.Itoa(part.Number)]...)
+		}
+		f.objects[r.URL.Path] = merged
+		io.WriteString(w, `<CompleteMultipartUploadResult><Bucket>rec</Bucket><ETag>"merged"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete && query.Get("uploadId") == "up1":
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[r.URL.Path] = data
+		w.Header().Set("ETag", `"single"`)
+	case r.Method == http.MethodHead:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 10:00:00 GMT")
+		w.Header().Set("ETag", `"single"`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// decodeAWSChunked 取出 aws-chunked 请求体中各块的数据：每块为 "十六进制长度;chunk-signature=...\r\n数据\r\n"
+func decodeAWSChunked(data []byte) []byte {
+	var out []byte
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte("\r\n"))
+		if !ok {
+			break
+		}
+		sizeHex, _, _ := bytes.Cut(header, []byte(";"))
+		size, err := strconv.ParseInt(string(sizeHex), 16, 64)
+		if err != nil || size == 0 || int64(len(rest)) < size {
+			break
+		}
+		out = append(out, rest[:size]...)
+		data = bytes.TrimPrefix(rest[size:], []byte("\r\n"))
+	}
+	return out
+}
+
+// TestS3Store_WriteAndStat 测试通过模拟S3服务上传和查询对象
+func TestS3Store_WriteAndStat(t *testing.T) {
+	fake, server := newFakeS3(t, "")
+
+	s, err := NewS3Store(&config.S3Config{Endpoint: server.URL, Bucket: "rec", Prefix: "sr302/", AccessKey: "ak", SecretKey: "sk"})
+	if err != nil {
+		t.Fatalf("创建S3存储失败: %v", err)
+	}
+
+	if exists, err := s.Exists("录音笔文件\\a.opus"); err != nil || exists {
+		t.Fatalf("上传前不应存在: %v, %v", exists, err)
+	}
+
+	if err := s.Write("录音笔文件\\a.opus", bytes.NewReader([]byte("12345"))); err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	if string(fake.objects["/rec/sr302/录音笔文件/a.opus"]) != "12345" {
+		t.Errorf("对象键或内容不正确: %v", fake.objects)
+	}
+	if len(fake.parts) != 0 {
+		t.Errorf("小于一个分段的内容应一次上传，实际上传了 %d 个分段", len(fake.parts))
+	}
+
+	stat, err := s.Stat("录音笔文件/a.opus")
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if stat.Size != 5 {
+		t.Errorf("期望大小 5，实际 %d", stat.Size)
+	}
+	if stat.ModTime.IsZero() {
+		t.Error("期望解析出修改时间")
+	}
+
+	if got := s.Location("录音笔文件\\a.opus"); got != "s3://rec/sr302/录音笔文件/a.opus" {
+		t.Errorf("位置不正确: %s", got)
+	}
+
+	denied, err := NewS3Store(&config.S3Config{Endpoint: server.URL, Bucket: "rec", AccessKey: "other", SecretKey: "sk"})
+	if err != nil {
+		t.Fatalf("创建S3存储失败: %v", err)
+	}
+	if _, err := denied.Stat("录音笔文件/a.opus"); err == nil || err == ErrNotExist {
+		t.Errorf("拒绝访问时应返回错误而不是不存在: %v", err)
+	}
+}
+
+// TestS3Store_MultipartWrite 测试超过一个分段的内容按分段上传并合并，失败时中止上传
+func TestS3Store_MultipartWrite(t *testing.T) {
+	const partSize = 5 * 1024 * 1024 // S3 允许的最小分段
+	tests := []struct {
+		name      string
+		failPart  string
+		wantParts int
+		wantErr   bool
+	}{
+		{"按分段上传", "", 3, false},
+		{"分段失败时中止", "2", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, server := newFakeS3(t, tt.failPart)
+			s, err := NewS3Store(&config.S3Config{Endpoint: server.URL, Bucket: "rec", AccessKey: "ak", SecretKey: "sk"})
+			if err != nil {
+				t.Fatalf("创建S3存储失败: %v", err)
+			}
+			s.partSize = partSize
+
+			content := bytes.Repeat([]byte("0123456789"), (2*partSize+partSize/2)/10)
+			err = s.Write("a.opus", bytes.NewReader(content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期望错误 %v，实际 %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				if !fake.aborted {
+					t.Error("分段上传失败时应中止")
+				}
+				return
+			}
+			if !bytes.Equal(fake.objects["/rec/a.opus"], content) {
+				t.Errorf("对象内容不正确，长度 %d，期望 %d", len(fake.objects["/rec/a.opus"]), len(content))
+			}
+			if len(fake.parts) != tt.wantParts {
+				t.Errorf("上传了 %d 个分段，期望 %d 个", len(fake.parts), tt.wantParts)
+			}
+		})
+	}
+}
+
+// TestPut 测试本地存储直接移入文件，其他存储通过 Write 写入，成功后都不保留本地文件
+func TestPut(t *testing.T) {
+	ls := NewLocalStore(t.TempDir())
+	for _, tt := range []struct {
+		name  string
+		store TargetStore
+	}{
+		{"移入本地存储", ls},
+		{"通过 Write 写入", struct{ TargetStore }{ls}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			localPath := filepath.Join(t.TempDir(), "staged.opus")
+			if err := os.WriteFile(localPath, []byte(tt.name), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := Put(tt.store, "录音笔文件\\a.opus", localPath); err != nil {
+				t.Fatalf("存入失败: %v", err)
+			}
+			data, err := os.ReadFile(ls.LocalPath("录音笔文件\\a.opus"))
+			if err != nil || string(data) != tt.name {
+				t.Errorf("文件内容不一致: %q, %v", data, err)
+			}
+			if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+				t.Error("存入后不应保留本地文件")
+			}
+		})
+	}
+}
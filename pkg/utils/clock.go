@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Clock 时钟接口，依赖当前时间的逻辑通过它取时间，测试时注入 FakeClock
+type Clock interface {
+	Now() time.Time
+}
+
+// Random 随机源接口，生成临时文件名等需要随机数的地方通过它取值，测试时注入固定种子的随机源
+type Random interface {
+	Uint64() uint64
+}
+
+// systemClock 使用系统时间的时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// systemRandom 使用全局随机源，可并发使用
+type systemRandom struct{}
+
+func (systemRandom) Uint64() uint64 { return rand.Uint64() }
+
+// SystemClock 默认的系统时钟
+var SystemClock Clock = systemClock{}
+
+// SystemRandom 默认的随机源
+var SystemRandom Random = systemRandom{}
+
+// NewSeededRandom 创建固定种子的随机源，相同种子产生相同序列，可并发使用
+func NewSeededRandom(seed uint64) Random {
+	return &lockedRandom{rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// lockedRandom 加锁的随机源，rand.Rand 本身不能并发使用
+type lockedRandom struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (r *lockedRandom) Uint64() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Uint64()
+}
+
+// FakeClock 手动推进的假时钟，可并发使用
+type FakeClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock 创建停在 start 的假时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now 返回当前时间，设置了步长时每次调用后自动前进一个步长
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Advance 时钟前进 d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SetStep 设置每次调用 Now 后自动前进的步长，0表示不自动前进
+func (c *FakeClock) SetStep(step time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.step = step
+}
+
+// UniqueName 生成带时间戳和随机后缀的名称，如 "1714530600000000000_3f2a9c1e_a.opus"
+// 同一时刻生成的名称也不会冲突
+func UniqueName(clock Clock, random Random, name string) string {
+	return fmt.Sprintf("%d_%08x_%s", clock.Now().UnixNano(), uint32(random.Uint64()), name)
+}
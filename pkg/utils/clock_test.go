@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFakeClock 测试假时钟手动推进和按步长自动推进
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+	clock := NewFakeClock(start)
+
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("Now() = %v，期望 %v", got, start)
+	}
+	clock.Advance(time.Minute)
+	if got := clock.Now(); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("推进后 Now() = %v", got)
+	}
+
+	clock.SetStep(time.Second)
+	first, second := clock.Now(), clock.Now()
+	if second.Sub(first) != time.Second {
+		t.Errorf("按步长推进的间隔 = %v，期望 1s", second.Sub(first))
+	}
+}
+
+// TestUniqueName 测试时钟不变时生成的名称也不冲突，固定种子时结果可复现
+func TestUniqueName(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local))
+	random := NewSeededRandom(42)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		name := UniqueName(clock, random, "a.opus")
+		if seen[name] {
+			t.Fatalf("第 %d 个名称重复: %s", i, name)
+		}
+		seen[name] = true
+	}
+
+	if a, b := UniqueName(clock, NewSeededRandom(7), "a.opus"), UniqueName(clock, NewSeededRandom(7), "a.opus"); a != b {
+		t.Errorf("相同时钟和种子应生成相同名称: %s != %s", a, b)
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitCommandLine 把命令行拆分为参数，以空白分隔，双引号内的空白保留，引号本身被去掉
+// 不经过 shell 解释，需要 shell 语法时写作 cmd /C "..."
+func SplitCommandLine(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	hasArg := false
+
+	for _, r := range command {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("引号未闭合: %s", command)
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("命令为空")
+	}
+	return args, nil
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSplitCommandLine 测试按空白拆分命令行，双引号内的空白保留
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{"scan.exe {file}", []string{"scan.exe", "{file}"}, false},
+		{`"C:\Program Files\AV\scan.exe"  /quiet  "{file}"`, []string{`C:\Program Files\AV\scan.exe`, "/quiet", "{file}"}, false},
+		{`check --name="a b" ""`, []string{"check", "--name=a b", ""}, false},
+		{`scan "{file}`, nil, true},
+		{"   ", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SplitCommandLine(%q) 错误 = %v，期望出错 %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitCommandLine(%q) = %q，期望 %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// UpdateDirLink 让 link 指向目录 target，已有的链接被替换；link 已存在且不是链接时返回错误
+// target 为相对路径时相对于 link 所在的目录。Windows 使用目录联接（junction），无需管理员权限或开发者模式，
+// 其他平台使用符号链接
+func UpdateDirLink(link, target string) error {
+	info, err := os.Lstat(link)
+	switch {
+	case err == nil:
+		if !IsLink(info) {
+			return fmt.Errorf("%s 已存在且不是链接", link)
+		}
+		if err := os.Remove(link); err != nil {
+			return fmt.Errorf("删除旧链接失败: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("访问链接失败: %w", err)
+	}
+
+	if err := createDirLink(link, target); err != nil {
+		return fmt.Errorf("创建目录链接失败: %w", err)
+	}
+	return nil
+}
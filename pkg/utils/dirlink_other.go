@@ -0,0 +1,10 @@
+//go:build !windows
+
+package utils
+
+import "os"
+
+// createDirLink 非 Windows 平台创建符号链接，相对的 target 保持相对，整个目录移动后链接仍有效
+func createDirLink(link, target string) error {
+	return os.Symlink(target, link)
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestUpdateDirLink 测试链接指向新目录时替换旧链接，同名的普通目录不会被覆盖
+func TestUpdateDirLink(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"2024-W17", "2024-W18"} {
+		writeFile(t, filepath.Join(base, name, name+".opus"), 10)
+	}
+	link := filepath.Join(base, "latest")
+
+	for _, name := range []string{"2024-W17", "2024-W18"} {
+		if err := UpdateDirLink(link, name); err != nil {
+			t.Fatalf("指向 %s 失败: %v", name, err)
+		}
+		info, err := os.Lstat(link)
+		if err != nil || !IsLink(info) {
+			t.Fatalf("latest 应为链接: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(link, name+".opus")); err != nil {
+			t.Errorf("latest 应指向 %s: %v", name, err)
+		}
+	}
+
+	// 替换链接不影响原先指向的目录
+	if _, err := os.Stat(filepath.Join(base, "2024-W17", "2024-W17.opus")); err != nil {
+		t.Errorf("旧周期目录中的文件应保留: %v", err)
+	}
+
+	realDir := filepath.Join(base, "real")
+	mkdirAll(t, realDir)
+	if err := UpdateDirLink(realDir, "2024-W18"); err == nil {
+		t.Error("已存在的普通目录不应被替换为链接")
+	}
+}
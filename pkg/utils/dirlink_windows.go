@@ -0,0 +1,31 @@
+//go:build windows
+
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// createDirLink 通过 mklink /J 创建目录联接，联接只能指向绝对路径
+func createDirLink(link, target string) error {
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	absLink, err := filepath.Abs(link)
+	if err != nil {
+		return err
+	}
+
+	output, err := exec.Command("cmd", "/c", "mklink", "/J", absLink, absTarget).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
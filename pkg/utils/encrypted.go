@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// OpusMagic Opus文件（Ogg容器）的文件头标识
+var OpusMagic = []byte("OggS")
+
+// HasOpusMagic 检查数据流开头是否为Ogg文件头
+func HasOpusMagic(r io.Reader) bool {
+	header := make([]byte, len(OpusMagic))
+	if _, err := io.ReadFull(r, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, OpusMagic)
+}
+
+// IsEncryptedExtension 检查文件扩展名是否在加密扩展名列表中
+func IsEncryptedExtension(filename string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return false
+	}
+	for _, encryptedExt := range extensions {
+		if ext == strings.ToLower(encryptedExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectEncrypted 判断文件是否为加密录音
+// 扩展名命中加密列表直接判定为加密；checkHeader 为 true 时对 .opus 文件读取文件头，
+// 不是Ogg文件头的视为加密。open 用于打开文件读取文件头，读取失败时不判定为加密
+func DetectEncrypted(filename string, extensions []string, checkHeader bool, open func() (io.ReadCloser, error)) bool {
+	if IsEncryptedExtension(filename, extensions) {
+		return true
+	}
+
+	if !checkHeader || !IsOpusFile(filename) || open == nil {
+		return false
+	}
+
+	reader, err := open()
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+
+	return !HasOpusMagic(reader)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDetectEncrypted 测试识别加密录音
+func TestDetectEncrypted(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		return path
+	}
+
+	validOpus := writeFile("valid.opus", append([]byte("OggS"), make([]byte, 60)...))
+	invalidOpus := writeFile("invalid.opus", []byte{0x8f, 0x3a, 0x11, 0x02, 0x55, 0x00})
+	shortOpus := writeFile("short.opus", []byte("Og"))
+	encFile := writeFile("record.enc", append([]byte("OggS"), make([]byte, 60)...))
+
+	testCases := []struct {
+		name        string
+		path        string
+		extensions  []string
+		checkHeader bool
+		expected    bool
+	}{
+		{"合法Opus文件头", validOpus, nil, true, false},
+		{"非法Opus文件头", invalidOpus, nil, true, true},
+		{"文件头过短", shortOpus, nil, true, true},
+		{"未开启文件头检测", invalidOpus, nil, false, false},
+		{"加密扩展名", encFile, []string{".enc"}, false, true},
+		{"加密扩展名大小写不敏感", encFile, []string{".ENC"}, false, true},
+		{"扩展名不在列表", encFile, []string{".sec"}, true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			open := func() (io.ReadCloser, error) {
+				return os.Open(tc.path)
+			}
+			result := DetectEncrypted(filepath.Base(tc.path), tc.extensions, tc.checkHeader, open)
+			if result != tc.expected {
+				t.Errorf("文件 %s: 期望 %v，实际 %v", filepath.Base(tc.path), tc.expected, result)
+			}
+		})
+	}
+}
+
+// TestDetectEncrypted_OpenFailed 测试文件打开失败时不判定为加密
+func TestDetectEncrypted_OpenFailed(t *testing.T) {
+	open := func() (io.ReadCloser, error) {
+		return os.Open(filepath.Join(t.TempDir(), "not_exist.opus"))
+	}
+	if DetectEncrypted("not_exist.opus", nil, true, open) {
+		t.Error("文件打开失败时不应判定为加密")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
@@ -314,12 +313,18 @@ func TestFormatDuration(t *testing.T) {
 		duration time.Duration
 		expected string
 	}{
-		{500 * time.Millisecond, "0s"},
+		{0, "0ms"},
+		{850 * time.Millisecond, "850ms"},
+		{500 * time.Millisecond, "500ms"},
+		{time.Second, "1s"},
 		{30 * time.Second, "30s"},
 		{90 * time.Second, "1m 30s"},
 		{2*time.Minute + 30*time.Second, "2m 30s"},
 		{1*time.Hour + 2*time.Minute + 3*time.Second, "1h 2m 3s"},
 		{2*time.Hour + 5*time.Minute + 7*time.Second, "2h 5m 7s"},
+		{23*time.Hour + 59*time.Minute + 59*time.Second, "23h 59m 59s"},
+		{24 * time.Hour, "1d 0h"},
+		{51*time.Hour + 30*time.Minute, "2d 3h"},
 	}
 
 	for _, tc := range testCases {
@@ -332,6 +337,29 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+// TestFormatDurationPrecise 测试保留到毫秒的时间间隔格式
+func TestFormatDurationPrecise(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{850 * time.Millisecond, "850ms"},
+		{1234 * time.Millisecond, "1.234s"},
+		{62345 * time.Millisecond, "1m 2.345s"},
+		{time.Hour + 2*time.Minute + 3456*time.Millisecond, "1h 2m 3.456s"},
+		{51*time.Hour + 4*time.Minute + 5678*time.Millisecond, "2d 3h 4m 5.678s"},
+		{1500*time.Millisecond + 999*time.Microsecond, "1.500s"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.duration.String(), func(t *testing.T) {
+			if result := FormatDurationPrecise(tc.duration); result != tc.expected {
+				t.Errorf("期望 '%s'，实际 '%s'", tc.expected, result)
+			}
+		})
+	}
+}
+
 // TestSafeFileName 测试清理文件名
 func TestSafeFileName(t *testing.T) {
 	testCases := []struct {
@@ -409,6 +437,56 @@ func TestCopyFile(t *testing.T) {
 	}
 }
 
+// TestCopyFileVerified 测试复制文件并返回字节数和哈希
+func TestCopyFileVerified(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "source", "test.opus")
+	targetFile := filepath.Join(tempDir, "target", "test.opus")
+
+	if err := os.MkdirAll(filepath.Dir(sourceFile), 0755); err != nil {
+		t.Fatalf("创建源目录失败: %v", err)
+	}
+
+	// 创建跨越多个缓冲区的源文件
+	testData := make([]byte, 100*1024+7)
+	for i := range testData {
+		testData[i] = byte(i % 251)
+	}
+	if err := os.WriteFile(sourceFile, testData, 0644); err != nil {
+		t.Fatalf("创建源文件失败: %v", err)
+	}
+
+	log := logger.NewLogger(true)
+	written, hash, err := CopyFileVerified(sourceFile, targetFile, log)
+	if err != nil {
+		t.Fatalf("复制文件失败: %v", err)
+	}
+
+	// 验证字节数等于源文件大小
+	if written != int64(len(testData)) {
+		t.Errorf("复制字节数错误，期望 %d，实际 %d", len(testData), written)
+	}
+
+	// 验证哈希等于源文件哈希
+	expectedHash := fmt.Sprintf("%x", sha256.Sum256(testData))
+	if hash != expectedHash {
+		t.Errorf("哈希不匹配，期望 %s，实际 %s", expectedHash, hash)
+	}
+
+	sourceHash, err := CalculateFileHash(sourceFile)
+	if err != nil {
+		t.Fatalf("计算源文件哈希失败: %v", err)
+	}
+	if hash != sourceHash {
+		t.Errorf("返回的哈希与源文件哈希不一致: %s != %s", hash, sourceHash)
+	}
+
+	// 测试不存在的源文件
+	if _, _, err := CopyFileVerified(filepath.Join(tempDir, "not_exist"), targetFile, log); err == nil {
+		t.Error("不存在的源文件应该返回错误")
+	}
+}
+
 // TestGetDirectorySize 测试获取目录大小
 func TestGetDirectorySize(t *testing.T) {
 	tempDir := t.TempDir()
@@ -444,7 +522,7 @@ func TestGetDirectorySize(t *testing.T) {
 
 	// 获取目录大小
 	log := logger.NewLogger(true)
-	calculatedSize, err := GetDirectorySize(tempDir, log)
+	calculatedSize, err := GetDirectorySize(tempDir, log, false)
 	if err != nil {
 		t.Fatalf("获取目录大小失败: %v", err)
 	}
@@ -459,7 +537,7 @@ func TestGetDirectorySize(t *testing.T) {
 		t.Fatalf("创建空目录失败: %v", err)
 	}
 
-	size, err := GetDirectorySize(emptyDir, log)
+	size, err := GetDirectorySize(emptyDir, log, false)
 	if err != nil {
 		t.Fatalf("获取空目录大小失败: %v", err)
 	}
@@ -593,7 +671,7 @@ func TestRemoveEmptyDirectories(t *testing.T) {
 
 	// 删除空目录
 	log := logger.NewLogger(true)
-	removed, err := RemoveEmptyDirectories(tempDir, log, false)
+	removed, err := RemoveEmptyDirectories(tempDir, log, false, false)
 	if err != nil {
 		t.Fatalf("删除空目录失败: %v", err)
 	}
@@ -633,7 +711,7 @@ func TestRemoveEmptyDirectories(t *testing.T) {
 		t.Fatalf("创建测试目录失败: %v", err)
 	}
 
-	removed, err = RemoveEmptyDirectories(tempDir2, log, true)
+	removed, err = RemoveEmptyDirectories(tempDir2, log, true, false)
 	if err != nil {
 		t.Fatalf("干运行失败: %v", err)
 	}
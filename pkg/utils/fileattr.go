@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseFileMode 解析八进制的文件权限，如 "0444"、"644"，空字符串返回0表示不修改
+func ParseFileMode(value string) (os.FileMode, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("无效的文件权限: %s，应为八进制如 0444", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// ApplyFileAttributes 设置文件权限和只读属性，mode 为0时不修改权限
+// 先设置权限再设置只读，两者同时配置时只读优先
+func ApplyFileAttributes(path string, mode os.FileMode, readOnly bool) error {
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			return fmt.Errorf("设置文件权限失败: %w", err)
+		}
+	}
+	if readOnly {
+		if err := setReadOnly(path, true); err != nil {
+			return fmt.Errorf("设置只读属性失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// ClearReadOnly 清除文件的只读属性，便于覆盖或删除，文件不存在时不做处理
+func ClearReadOnly(path string) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := setReadOnly(path, false); err != nil {
+		return fmt.Errorf("清除只读属性失败: %w", err)
+	}
+	return nil
+}
+
+// IsReadOnly 判断文件是否为只读
+func IsReadOnly(path string) (bool, error) {
+	return isReadOnly(path)
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package utils
+
+import "os"
+
+// setReadOnly 非 Windows 平台去掉或恢复写权限：只读时去掉所有写权限，清除时恢复所有者写权限
+func setReadOnly(path string, readOnly bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm()
+	if readOnly {
+		mode &^= 0222
+	} else {
+		mode |= 0200
+	}
+	return os.Chmod(path, mode)
+}
+
+// isReadOnly 所有者没有写权限时视为只读
+func isReadOnly(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode().Perm()&0200 == 0, nil
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestParseFileMode 测试解析八进制文件权限
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"空字符串不修改", "", 0, false},
+		{"带前导0", "0444", 0444, false},
+		{"不带前导0", "640", 0640, false},
+		{"非八进制数字", "0888", 0, true},
+		{"超出权限位", "01777", 0, true},
+		{"非数字", "rw-r--r--", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFileMode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFileMode(%q) 错误 = %v, 期望出错 %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFileMode(%q) = %o, 期望 %o", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyFileAttributes 测试设置权限、只读属性以及清除只读后可以删除
+func TestApplyFileAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "录音.opus")
+	if err := os.WriteFile(path, []byte("录音"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Windows 只有只读属性，不支持其余权限位
+	if runtime.GOOS != "windows" {
+		if err := ApplyFileAttributes(path, 0640, false); err != nil {
+			t.Fatalf("设置权限失败: %v", err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0640 {
+			t.Errorf("文件权限应为 0640: %v", err)
+		}
+	}
+
+	if err := ApplyFileAttributes(path, 0, true); err != nil {
+		t.Fatalf("设置只读失败: %v", err)
+	}
+	if readOnly, err := IsReadOnly(path); err != nil || !readOnly {
+		t.Errorf("文件应为只读: %v", err)
+	}
+
+	if err := ClearReadOnly(path); err != nil {
+		t.Fatalf("清除只读失败: %v", err)
+	}
+	if readOnly, err := IsReadOnly(path); err != nil || readOnly {
+		t.Errorf("清除后文件不应为只读: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Errorf("清除只读后应能删除: %v", err)
+	}
+
+	if err := ClearReadOnly(path); err != nil {
+		t.Errorf("文件不存在时不应返回错误: %v", err)
+	}
+}
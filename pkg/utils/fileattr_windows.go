@@ -0,0 +1,36 @@
+//go:build windows
+
+package utils
+
+import "syscall"
+
+// setReadOnly 通过文件属性 API 设置或清除 FILE_ATTRIBUTE_READONLY
+func setReadOnly(path string, readOnly bool) error {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrs, err := syscall.GetFileAttributes(p)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		attrs |= syscall.FILE_ATTRIBUTE_READONLY
+	} else {
+		attrs &^= syscall.FILE_ATTRIBUTE_READONLY
+	}
+	return syscall.SetFileAttributes(p, attrs)
+}
+
+// isReadOnly 读取 FILE_ATTRIBUTE_READONLY 属性
+func isReadOnly(path string) (bool, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false, err
+	}
+	attrs, err := syscall.GetFileAttributes(p)
+	if err != nil {
+		return false, err
+	}
+	return attrs&syscall.FILE_ATTRIBUTE_READONLY != 0, nil
+}
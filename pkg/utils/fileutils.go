@@ -21,6 +21,7 @@ type FileInfo struct {
 	ModTime      time.Time `json:"mod_time"`
 	IsOpus       bool      `json:"is_opus"`
 	Hash         string    `json:"hash,omitempty"`
+	Encrypted    bool      `json:"encrypted,omitempty"` // 是否为加密录音
 }
 
 // IsOpusFile 检查文件是否为.opus格式
@@ -149,17 +150,44 @@ func FormatBytes(bytes int64) string {
 }
 
 // FormatDuration 格式化时间间隔为人类可读的格式
+// 不足1秒显示毫秒（如 "850ms"），不足1天显示时分秒（如 "1h 2m 3s"），1天以上显示天和小时（如 "2d 3h"）
 func FormatDuration(d time.Duration) string {
-	if d < time.Minute {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	} else if d < time.Minute {
 		return fmt.Sprintf("%.0fs", d.Seconds())
 	} else if d < time.Hour {
-		return fmt.Sprintf("%.0fm %.0fs", d.Minutes(), float64(int(d.Seconds())%60))
-	} else {
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	} else if d < 24*time.Hour {
 		hours := int(d.Hours())
 		minutes := int(d.Minutes()) % 60
 		seconds := int(d.Seconds()) % 60
 		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
 	}
+	return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// FormatDurationPrecise 格式化时间间隔并保留到毫秒，用于基准测试和性能输出
+// 如 "850ms"、"1.234s"、"1m 2.345s"、"1h 2m 3.456s"、"2d 3h 4m 5.678s"
+func FormatDurationPrecise(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+
+	ms := d.Milliseconds()
+	seconds := float64(ms%60000) / 1000
+	minutes := ms / 60000 % 60
+	hours := ms / 3600000 % 24
+	days := ms / 86400000
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%.3fs", seconds)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %.3fs", minutes, seconds)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm %.3fs", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%dd %dh %dm %.3fs", days, hours, minutes, seconds)
 }
 
 // SafeFileName 清理文件名，移除不安全的字符
@@ -184,60 +212,74 @@ func SafeFileName(name string) string {
 
 // CopyFile 复制文件
 func CopyFile(src, dst string, log *logger.Logger) error {
+	_, _, err := CopyFileVerified(src, dst, log)
+	return err
+}
+
+// CopyFileVerified 复制文件并返回复制字节数和源文件的SHA256哈希
+// 复制过程中同时计算哈希，复制后比对源文件与目标文件大小
+func CopyFileVerified(src, dst string, log *logger.Logger) (int64, string, error) {
 	// 确保目标目录存在
 	dstDir := filepath.Dir(dst)
 	if err := EnsureDir(dstDir); err != nil {
-		return fmt.Errorf("创建目标目录失败: %w", err)
+		return 0, "", fmt.Errorf("创建目标目录失败: %w", err)
 	}
 
 	// 打开源文件
 	srcFile, err := os.Open(src)
 	if err != nil {
-		return fmt.Errorf("打开源文件失败: %w", err)
+		return 0, "", fmt.Errorf("打开源文件失败: %w", err)
 	}
 	defer srcFile.Close()
 
 	// 创建目标文件
 	dstFile, err := os.Create(dst)
 	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %w", err)
+		return 0, "", fmt.Errorf("创建目标文件失败: %w", err)
 	}
 	defer dstFile.Close()
 
-	// 复制文件内容
-	written, err := io.Copy(dstFile, srcFile)
+	// 复制文件内容，同时计算哈希
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(dstFile, hasher), srcFile)
 	if err != nil {
-		return fmt.Errorf("复制文件内容失败: %w", err)
+		return written, "", fmt.Errorf("复制文件内容失败: %w", err)
 	}
 
 	// 获取源文件大小以确保复制完整
 	srcInfo, err := srcFile.Stat()
 	if err != nil {
-		return fmt.Errorf("获取源文件信息失败: %w", err)
+		return written, "", fmt.Errorf("获取源文件信息失败: %w", err)
 	}
 
 	if written != srcInfo.Size() {
-		return fmt.Errorf("文件复制不完整: 期望 %d 字节，实际复制 %d 字节", srcInfo.Size(), written)
+		return written, "", fmt.Errorf("文件复制不完整: 期望 %d 字节，实际复制 %d 字节", srcInfo.Size(), written)
 	}
 
+	// 比对目标文件大小
+	dstInfo, err := dstFile.Stat()
+	if err != nil {
+		return written, "", fmt.Errorf("获取目标文件信息失败: %w", err)
+	}
+
+	if dstInfo.Size() != srcInfo.Size() {
+		return written, "", fmt.Errorf("目标文件大小不匹配: 源文件 %d 字节，目标文件 %d 字节", srcInfo.Size(), dstInfo.Size())
+	}
+
+	hash := fmt.Sprintf("%x", hasher.Sum(nil))
 	log.Debug("文件复制完成: %s -> %s (%s)", src, dst, FormatBytes(written))
-	return nil
+	return written, hash, nil
 }
 
 // GetDirectorySize 获取目录中所有文件的总大小
-func GetDirectorySize(dirPath string, log *logger.Logger) (int64, error) {
+// 默认不跟随符号链接；followSymlinks 为 true 时计入链接目标，同一真实文件只计一次
+func GetDirectorySize(dirPath string, log *logger.Logger, followSymlinks bool) (int64, error) {
 	var totalSize int64
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			log.Warn("访问路径失败: %s, 错误: %v", path, err)
-			return nil
-		}
-
+	err := walkDirectory(dirPath, followSymlinks, log, func(path string, info os.FileInfo, link bool) error {
 		if !info.IsDir() {
 			totalSize += info.Size()
 		}
-
 		return nil
 	})
 
@@ -304,21 +346,16 @@ func IsEmptyDirectory(dirPath string) (bool, error) {
 }
 
 // ScanEmptyDirectories 扫描所有空目录
-func ScanEmptyDirectories(rootPath string, log *logger.Logger) ([]string, error) {
+// 默认不进入符号链接；链接本身永远不会作为空目录返回，避免删除链接或其指向的目录
+func ScanEmptyDirectories(rootPath string, log *logger.Logger, followSymlinks bool) ([]string, error) {
 	var emptyDirs []string
 
 	// 首先获取所有目录的列表
 	var allDirs []string
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			log.Warn("访问路径失败: %s, 错误: %v", path, err)
-			return nil
-		}
-
-		if info.IsDir() && path != rootPath {
+	err := walkDirectory(rootPath, followSymlinks, log, func(path string, info os.FileInfo, link bool) error {
+		if info.IsDir() && !link && path != rootPath {
 			allDirs = append(allDirs, path)
 		}
-
 		return nil
 	})
 
@@ -345,7 +382,8 @@ func ScanEmptyDirectories(rootPath string, log *logger.Logger) ([]string, error)
 }
 
 // RemoveEmptyDirectories 递归删除指定目录下的所有空文件夹
-func RemoveEmptyDirectories(dirPath string, log *logger.Logger, dryRun bool) (int, error) {
+// 默认不跟随符号链接，遇到链接时跳过并警告；followSymlinks 为 true 时也清理链接目标内的空文件夹
+func RemoveEmptyDirectories(dirPath string, log *logger.Logger, dryRun bool, followSymlinks bool) (int, error) {
 	// 清理路径
 	dirPath = filepath.Clean(dirPath)
 
@@ -356,7 +394,7 @@ func RemoveEmptyDirectories(dirPath string, log *logger.Logger, dryRun bool) (in
 	}
 
 	// 扫描所有空目录
-	emptyDirs, err := ScanEmptyDirectories(dirPath, log)
+	emptyDirs, err := ScanEmptyDirectories(dirPath, log, followSymlinks)
 	if err != nil {
 		return 0, fmt.Errorf("扫描空目录失败: %w", err)
 	}
@@ -369,6 +407,12 @@ func RemoveEmptyDirectories(dirPath string, log *logger.Logger, dryRun bool) (in
 			log.Info("[DRY RUN] 将删除空目录: %s", emptyDir)
 			removed++
 		} else {
+			// 扫描后目录可能被替换为链接，链接本身不删除
+			if info, err := os.Lstat(emptyDir); err == nil && IsLink(info) {
+				log.Warn("跳过符号链接: %s", emptyDir)
+				continue
+			}
+
 			// 再次检查目录是否为空（防止在扫描期间有新文件写入）
 			isEmpty, err := IsEmptyDirectory(emptyDir)
 			if err != nil {
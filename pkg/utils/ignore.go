@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// IgnoreRule 忽略文件中的一条规则
+type IgnoreRule struct {
+	Pattern  string // 去掉 !、首尾 / 后的 glob，已统一为小写和 / 分隔
+	Negate   bool   // 以 ! 开头，匹配时重新包含
+	DirOnly  bool   // 以 / 结尾，只匹配目录
+	Anchored bool   // 含有 /，从根目录开始匹配完整路径；否则匹配任意一级的名称
+}
+
+// IgnoreMatcher .gitignore 风格的忽略规则集
+type IgnoreMatcher struct {
+	rules []IgnoreRule
+}
+
+// ParseIgnoreRules 解析忽略规则，每行一个 glob
+// 空行和 # 开头的行被忽略；! 开头表示否定；支持 *、?、[...] 与表示任意多级目录的 **
+func ParseIgnoreRules(r io.Reader) (*IgnoreMatcher, error) {
+	matcher := &IgnoreMatcher{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := IgnoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.Negate = true
+			line = line[1:]
+		}
+
+		pattern := strings.ToLower(strings.ReplaceAll(line, "\\", "/"))
+		if strings.HasSuffix(pattern, "/") {
+			rule.DirOnly = true
+			pattern = strings.TrimRight(pattern, "/")
+		}
+		rule.Anchored = strings.Contains(pattern, "/")
+		pattern = strings.TrimLeft(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		// 提前校验 glob 语法，避免匹配时静默失败
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("第 %d 行规则无效: %s: %w", lineNo, line, err)
+			}
+		}
+
+		rule.Pattern = pattern
+		matcher.rules = append(matcher.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取忽略规则失败: %w", err)
+	}
+	return matcher, nil
+}
+
+// LoadIgnoreFile 从文件加载忽略规则，文件不存在时返回空规则集
+func LoadIgnoreFile(filename string) (*IgnoreMatcher, error) {
+	if filename == "" {
+		return &IgnoreMatcher{}, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &IgnoreMatcher{}, nil
+		}
+		return nil, fmt.Errorf("打开忽略文件失败: %w", err)
+	}
+	defer file.Close()
+
+	matcher, err := ParseIgnoreRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return matcher, nil
+}
+
+// Empty 是否没有任何规则
+func (m *IgnoreMatcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match 判断相对路径是否被忽略，不区分大小写，\ 与 / 均可作为分隔符
+// 规则匹配文件本身或其任意一级父目录时视为命中，按顺序以最后命中的规则为准，
+// 因此 ! 规则也可以重新包含被忽略目录下的文件
+func (m *IgnoreMatcher) Match(relativePath string) bool {
+	if m.Empty() {
+		return false
+	}
+
+	segments := strings.Split(strings.Trim(strings.ToLower(strings.ReplaceAll(relativePath, "\\", "/")), "/"), "/")
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.matches(segments) {
+			ignored = !rule.Negate
+		}
+	}
+	return ignored
+}
+
+// Filter 过滤掉被忽略的文件，返回保留的文件和被忽略的文件
+func (m *IgnoreMatcher) Filter(files []*FileInfo) ([]*FileInfo, []*FileInfo) {
+	if m.Empty() {
+		return files, nil
+	}
+
+	kept := make([]*FileInfo, 0, len(files))
+	var ignored []*FileInfo
+	for _, file := range files {
+		relativePath := file.RelativePath
+		if relativePath == "" {
+			relativePath = file.Name
+		}
+		if m.Match(relativePath) {
+			ignored = append(ignored, file)
+		} else {
+			kept = append(kept, file)
+		}
+	}
+	return kept, ignored
+}
+
+// matches 判断规则是否匹配路径本身或其某一级父目录
+func (rule IgnoreRule) matches(segments []string) bool {
+	for end := len(segments); end > 0; end-- {
+		// 只匹配目录的规则不匹配文件本身
+		if rule.DirOnly && end == len(segments) {
+			continue
+		}
+		prefix := segments[:end]
+		if rule.Anchored {
+			if matchSegments(strings.Split(rule.Pattern, "/"), prefix) {
+				return true
+			}
+		} else if ok, _ := path.Match(rule.Pattern, prefix[len(prefix)-1]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments 按路径段匹配 glob，** 匹配零个或多个路径段
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestIgnoreMatcher_Match 测试一组含否定和注释的规则对相对路径的匹配结果
+func TestIgnoreMatcher_Match(t *testing.T) {
+	rules := `
+# 测试录音
+test_*.opus
+
+# 整个草稿目录，但保留其中的重要录音
+草稿/
+!草稿/重要*.opus
+
+# 只忽略根目录下的 tmp，子目录的同名目录不受影响
+/tmp/
+归档/**/旧的.opus
+*.bak
+!keep.bak
+`
+	matcher, err := ParseIgnoreRules(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("解析规则失败: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"会议.opus", false},
+		{"test_1.opus", true},
+		{"录音笔文件\\TEST_2.opus", true},
+		{"草稿/a.opus", true},
+		{"草稿/子目录/b.opus", true},
+		{"草稿/重要会议.opus", false},
+		{"其他/草稿.opus", false},
+		{"tmp/a.opus", true},
+		{"录音笔文件/tmp/a.opus", false},
+		{"归档/旧的.opus", true},
+		{"归档/2023/01/旧的.opus", true},
+		{"归档/2023/新的.opus", false},
+		{"a.bak", true},
+		{"子目录/keep.bak", false},
+		{"# 测试录音", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := matcher.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, 期望 %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseIgnoreRules 测试规则解析
+func TestParseIgnoreRules(t *testing.T) {
+	matcher, err := ParseIgnoreRules(strings.NewReader("# 注释\n\n  *.tmp  \n!a\\b.tmp\n/\n"))
+	if err != nil {
+		t.Fatalf("解析规则失败: %v", err)
+	}
+	want := []IgnoreRule{
+		{Pattern: "*.tmp"},
+		{Pattern: "a/b.tmp", Negate: true, Anchored: true},
+	}
+	if len(matcher.rules) != len(want) {
+		t.Fatalf("规则数 = %d, 期望 %d: %+v", len(matcher.rules), len(want), matcher.rules)
+	}
+	for i, rule := range matcher.rules {
+		if rule != want[i] {
+			t.Errorf("第 %d 条规则 = %+v, 期望 %+v", i, rule, want[i])
+		}
+	}
+
+	if _, err := ParseIgnoreRules(strings.NewReader("ok.opus\n[abc.opus\n")); err == nil || !strings.Contains(err.Error(), "第 2 行") {
+		t.Errorf("无效的 glob 应返回带行号的错误, 实际: %v", err)
+	}
+}
+
+// TestLoadIgnoreFile 测试从文件加载规则与过滤文件列表
+func TestLoadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+
+	matcher, err := LoadIgnoreFile(filepath.Join(dir, ".recignore"))
+	if err != nil || !matcher.Empty() {
+		t.Fatalf("文件不存在时应返回空规则集: %v", err)
+	}
+
+	path := filepath.Join(dir, ".recignore")
+	if err := os.WriteFile(path, []byte("*.tmp.opus\n"), 0644); err != nil {
+		t.Fatalf("写入规则文件失败: %v", err)
+	}
+	matcher, err = LoadIgnoreFile(path)
+	if err != nil {
+		t.Fatalf("加载规则文件失败: %v", err)
+	}
+
+	files := []*FileInfo{
+		{Name: "a.opus", RelativePath: "录音/a.opus"},
+		{Name: "b.tmp.opus", RelativePath: "录音/b.tmp.opus"},
+		{Name: "c.tmp.opus"},
+	}
+	kept, ignored := matcher.Filter(files)
+	if len(kept) != 1 || kept[0].Name != "a.opus" {
+		t.Errorf("保留的文件不符: %+v", kept)
+	}
+	if len(ignored) != 2 {
+		t.Errorf("忽略的文件数 = %d, 期望 2", len(ignored))
+	}
+}
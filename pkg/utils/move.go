@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PartSuffix 跨卷移动时复制中的文件后缀，复制完成后才改名为目标文件
+const PartSuffix = ".part"
+
+// renameFile 重命名文件，测试时替换以模拟跨磁盘卷
+var renameFile = os.Rename
+
+// MoveFile 移动文件，先尝试 os.Rename，失败（如跨磁盘卷）时复制后删除源文件
+// 复制先写入目标旁的 .part 文件，同步到磁盘并改名为目标后才删除源文件，
+// 中途失败时清理 .part，源文件保持不变
+func MoveFile(src, dst string) error {
+	renameErr := renameFile(src, dst)
+	if renameErr == nil {
+		return nil
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("移动文件失败: %w", renameErr)
+	}
+	if srcInfo.IsDir() {
+		return fmt.Errorf("移动文件失败，源路径是目录: %w", renameErr)
+	}
+
+	if err := copyToPart(src, dst, srcInfo); err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("已复制到 %s，但删除源文件失败: %w", dst, err)
+	}
+	return nil
+}
+
+// copyToPart 把源文件复制到 dst.part，完成后改名为 dst，失败时删除 .part
+func copyToPart(src, dst string, srcInfo os.FileInfo) (err error) {
+	if err := EnsureDir(filepath.Dir(dst)); err != nil {
+		return fmt.Errorf("创建目标目录失败: %w", err)
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开源文件失败: %w", err)
+	}
+	defer srcFile.Close()
+
+	part := dst + PartSuffix
+	partFile, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, srcInfo.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			partFile.Close()
+			os.Remove(part)
+		}
+	}()
+
+	written, err := io.Copy(partFile, srcFile)
+	if err != nil {
+		return fmt.Errorf("复制文件内容失败: %w", err)
+	}
+	if written != srcInfo.Size() {
+		return fmt.Errorf("文件复制不完整: 期望 %d 字节，实际复制 %d 字节", srcInfo.Size(), written)
+	}
+	if err = partFile.Sync(); err != nil {
+		return fmt.Errorf("同步文件失败: %w", err)
+	}
+	if err = partFile.Close(); err != nil {
+		return fmt.Errorf("关闭文件失败: %w", err)
+	}
+	// 保留源文件的修改时间，失败不影响移动
+	os.Chtimes(part, srcInfo.ModTime(), srcInfo.ModTime())
+
+	if err = renameFile(part, dst); err != nil {
+		return fmt.Errorf("重命名临时文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// crossDeviceRename 模拟跨磁盘卷：把源文件直接改名到目标时返回 EXDEV，
+// .part 改名为目标（同一目录内）正常执行
+func crossDeviceRename(t *testing.T) *int {
+	t.Helper()
+	var calls int
+	renameFile = func(oldpath, newpath string) error {
+		calls++
+		if !strings.HasSuffix(oldpath, PartSuffix) {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+	return &calls
+}
+
+// writeMoveSource 在临时目录中创建待移动的源文件
+func writeMoveSource(t *testing.T, content []byte) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "录音.opus")
+	if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return src, filepath.Join(dir, "dst", "子目录", "录音.opus")
+}
+
+// TestMoveFile_SameVolume 测试同卷移动直接重命名
+func TestMoveFile_SameVolume(t *testing.T) {
+	content := []byte("同卷移动")
+	src, dst := writeMoveSource(t, content)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveFile(src, dst); err != nil {
+		t.Fatalf("移动失败: %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("目标文件内容错误: %v", err)
+	}
+	if FileExists(src) {
+		t.Error("移动后源文件应不存在")
+	}
+}
+
+// TestMoveFile_CrossVolume 测试跨卷时复制到 .part 再改名，完成后删除源文件
+func TestMoveFile_CrossVolume(t *testing.T) {
+	calls := crossDeviceRename(t)
+	content := bytes.Repeat([]byte("跨卷"), 64*1024)
+	src, dst := writeMoveSource(t, content)
+	modTime := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveFile(src, dst); err != nil {
+		t.Fatalf("跨卷移动失败: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("重命名调用 %d 次，期望 2 次（源文件一次、.part 一次）", *calls)
+	}
+	if data, err := os.ReadFile(dst); err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("目标文件内容不完整: %v", err)
+	}
+	if info, err := os.Stat(dst); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("应保留源文件的修改时间: %v", err)
+	}
+	if FileExists(src) {
+		t.Error("跨卷移动后源文件应被删除")
+	}
+	if FileExists(dst + PartSuffix) {
+		t.Error("不应残留 .part 文件")
+	}
+}
+
+// TestMoveFile_FailureKeepsSource 测试复制或改名失败时保留源文件并清理 .part
+func TestMoveFile_FailureKeepsSource(t *testing.T) {
+	content := []byte("不能丢失的录音")
+
+	t.Run("目标目录无法创建", func(t *testing.T) {
+		crossDeviceRename(t)
+		src, dst := writeMoveSource(t, content)
+		// 目标的上级目录位置已是普通文件
+		blocker := filepath.Dir(filepath.Dir(dst))
+		if err := os.WriteFile(blocker, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := MoveFile(src, dst); err == nil {
+			t.Fatal("目标目录无法创建时应返回错误")
+		}
+		if data, err := os.ReadFile(src); err != nil || !bytes.Equal(data, content) {
+			t.Errorf("失败时源文件应保持不变: %v", err)
+		}
+	})
+
+	t.Run("改名为目标失败", func(t *testing.T) {
+		renameFile = func(oldpath, newpath string) error {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+		}
+		t.Cleanup(func() { renameFile = os.Rename })
+		src, dst := writeMoveSource(t, content)
+
+		err := MoveFile(src, dst)
+		if !errors.Is(err, syscall.EXDEV) {
+			t.Fatalf("期望返回改名失败的原因，实际 %v", err)
+		}
+		if data, err := os.ReadFile(src); err != nil || !bytes.Equal(data, content) {
+			t.Errorf("失败时源文件应保持不变: %v", err)
+		}
+		if FileExists(dst + PartSuffix) {
+			t.Error("失败时应清理 .part 文件")
+		}
+		if FileExists(dst) {
+			t.Error("失败时不应留下目标文件")
+		}
+	})
+
+	t.Run("源文件不存在", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := MoveFile(filepath.Join(dir, "missing.opus"), filepath.Join(dir, "dst.opus")); err == nil {
+			t.Error("源文件不存在时应返回错误")
+		}
+	})
+}
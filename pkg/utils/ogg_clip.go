@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// oggHeaderTypeEOS Ogg页头类型中的流结束标志
+	oggHeaderTypeEOS = 0x04
+	// oggNoGranule 页内没有包结束时的granule位置（-1）
+	oggNoGranule = -1
+)
+
+// oggCRCTable Ogg页校验使用的CRC32表（多项式0x04c11db7，不反转，初值0）
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// oggPage 一个完整的Ogg页（页头、分段表和数据）
+type oggPage struct {
+	raw []byte
+}
+
+// granule 页的granule位置，Opus中为48kHz的累计采样数
+func (p *oggPage) granule() int64 {
+	return int64(binary.LittleEndian.Uint64(p.raw[6:14]))
+}
+
+// headerType 页头类型标志
+func (p *oggPage) headerType() byte {
+	return p.raw[5]
+}
+
+// body 页内数据（不含页头和分段表）
+func (p *oggPage) body() []byte {
+	return p.raw[oggPageHeaderSize+int(p.raw[26]):]
+}
+
+// checksumValid 判断页的CRC是否正确
+func (p *oggPage) checksumValid() bool {
+	return binary.LittleEndian.Uint32(p.raw[22:26]) == oggChecksum(p.raw)
+}
+
+// markEOS 标记为流的最后一页并重新计算CRC
+func (p *oggPage) markEOS() {
+	p.raw[5] |= oggHeaderTypeEOS
+	binary.LittleEndian.PutUint32(p.raw[22:26], oggChecksum(p.raw))
+}
+
+// oggChecksum 计算页的CRC，计算时CRC字段按0处理
+func oggChecksum(page []byte) uint32 {
+	var crc uint32
+	for i, b := range page {
+		if i >= 22 && i < 26 {
+			b = 0
+		}
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// readOggPage 从 r 读取下一个Ogg页，流正常结束时返回 io.EOF
+func readOggPage(r io.Reader) (*oggPage, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("读取Ogg页头失败: %w", err)
+	}
+	if !bytes.Equal(header[:4], OpusMagic) {
+		return nil, fmt.Errorf("不是Ogg页")
+	}
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(r, segments); err != nil {
+		return nil, fmt.Errorf("读取Ogg分段表失败: %w", err)
+	}
+	bodySize := 0
+	for _, size := range segments {
+		bodySize += int(size)
+	}
+
+	raw := make([]byte, 0, len(header)+len(segments)+bodySize)
+	raw = append(raw, header...)
+	raw = append(raw, segments...)
+	raw = raw[:cap(raw)]
+	if _, err := io.ReadFull(r, raw[len(header)+len(segments):]); err != nil {
+		return nil, fmt.Errorf("读取Ogg页数据失败: %w", err)
+	}
+	return &oggPage{raw: raw}, nil
+}
+
+// ClipOpus 从 src 读取Opus流开头约 duration 时长的内容写入 dst，在Ogg页边界截断
+// 头部页（OpusHead、OpusTags）原样保留，最后一页标记为流结束，输出可直接播放
+// 只读取到所需的页为止，不会读完整个流；返回实际截取的时长和写入的字节数
+func ClipOpus(dst io.Writer, src io.Reader, duration time.Duration) (time.Duration, int64, error) {
+	first, err := readOggPage(src)
+	if err != nil {
+		return 0, 0, err
+	}
+	meta, err := parseOpusHead(first.body())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 每一页在确认不是最后一页后才写出，以便给最后一页加上流结束标志
+	var written int64
+	pending := first
+	target := int64(meta.PreSkip) + int64(duration/time.Millisecond)*opusGranuleRate/1000
+	var clipped time.Duration
+
+	for {
+		granule := pending.granule()
+		if granule > int64(meta.PreSkip) {
+			clipped = time.Duration(granule-int64(meta.PreSkip)) * time.Second / opusGranuleRate
+		}
+		if granule != oggNoGranule && granule >= target && pending != first {
+			break
+		}
+
+		next, err := readOggPage(src)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, written, err
+		}
+
+		n, err := dst.Write(pending.raw)
+		written += int64(n)
+		if err != nil {
+			return 0, written, fmt.Errorf("写入Ogg页失败: %w", err)
+		}
+		pending = next
+	}
+
+	pending.markEOS()
+	n, err := dst.Write(pending.raw)
+	written += int64(n)
+	if err != nil {
+		return 0, written, fmt.Errorf("写入Ogg页失败: %w", err)
+	}
+	return clipped, written, nil
+}
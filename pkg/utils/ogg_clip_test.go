@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildValidOpusSample 构造带正确CRC的Opus样本，音频页每页 pageDuration，共 pages 页
+func buildValidOpusSample(preSkip uint16, pageDuration time.Duration, pages int) []byte {
+	var head bytes.Buffer
+	head.WriteString("OpusHead")
+	head.WriteByte(1)
+	head.WriteByte(1)
+	binary.Write(&head, binary.LittleEndian, preSkip)
+	binary.Write(&head, binary.LittleEndian, uint32(16000))
+	binary.Write(&head, binary.LittleEndian, int16(0))
+	head.WriteByte(0)
+
+	withCRC := func(page []byte) []byte {
+		binary.LittleEndian.PutUint32(page[22:26], oggChecksum(page))
+		return page
+	}
+
+	var sample bytes.Buffer
+	sample.Write(withCRC(buildOggPage(0, 0, head.Bytes())))
+	sample.Write(withCRC(buildOggPage(0, 1, []byte("OpusTags\x07\x00\x00\x00testenc\x00\x00\x00\x00"))))
+	for i := 1; i <= pages; i++ {
+		granule := int64(preSkip) + int64(i)*int64(pageDuration/time.Millisecond)*48
+		sample.Write(withCRC(buildOggPage(granule, uint32(i+1), bytes.Repeat([]byte{byte(i)}, 700))))
+	}
+	return sample.Bytes()
+}
+
+// readAllOggPages 读取片段中的全部Ogg页
+func readAllOggPages(t *testing.T, data []byte) []*oggPage {
+	t.Helper()
+	r := bytes.NewReader(data)
+	var pages []*oggPage
+	for {
+		page, err := readOggPage(r)
+		if err == io.EOF {
+			return pages
+		}
+		if err != nil {
+			t.Fatalf("片段不是合法的Ogg流: %v", err)
+		}
+		pages = append(pages, page)
+	}
+}
+
+// TestClipOpus 测试从已知样本截取开头5秒，片段是合法Ogg且时长约等于请求时长
+func TestClipOpus(t *testing.T) {
+	const pageDuration = 800 * time.Millisecond
+	sample := buildValidOpusSample(312, pageDuration, 20)
+	src := bytes.NewReader(sample)
+
+	var clip bytes.Buffer
+	clipped, written, err := ClipOpus(&clip, src, 5*time.Second)
+	if err != nil {
+		t.Fatalf("截取失败: %v", err)
+	}
+	if written != int64(clip.Len()) {
+		t.Errorf("返回的写入字节数 %d 与实际 %d 不符", written, clip.Len())
+	}
+	if clipped < 5*time.Second || clipped >= 5*time.Second+pageDuration {
+		t.Errorf("截取时长 = %v，期望约 5s（不超过一页）", clipped)
+	}
+	if src.Len() == 0 {
+		t.Error("截取开头时不应读完整个流")
+	}
+
+	pages := readAllOggPages(t, clip.Bytes())
+	if !bytes.HasPrefix(pages[0].body(), opusHeadMagic) || !bytes.HasPrefix(pages[1].body(), []byte("OpusTags")) {
+		t.Error("片段应保留 OpusHead 和 OpusTags 头部页")
+	}
+	for i, page := range pages {
+		if !page.checksumValid() {
+			t.Errorf("第 %d 页CRC错误", i)
+		}
+		if eos := page.headerType()&oggHeaderTypeEOS != 0; eos != (i == len(pages)-1) {
+			t.Errorf("第 %d 页流结束标志 = %v", i, eos)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "sample.opus")
+	if err := os.WriteFile(path, clip.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := ExtractOpusMetadata(path)
+	if err != nil {
+		t.Fatalf("解析片段失败: %v", err)
+	}
+	if meta.Duration != clipped {
+		t.Errorf("片段时长 = %v，期望 %v", meta.Duration, clipped)
+	}
+}
+
+// TestClipOpus_ShorterThanRequested 测试文件短于请求时长时输出整个文件，并在最后一页标记流结束
+func TestClipOpus_ShorterThanRequested(t *testing.T) {
+	sample := buildValidOpusSample(0, time.Second, 3)
+
+	var clip bytes.Buffer
+	clipped, _, err := ClipOpus(&clip, bytes.NewReader(sample), 10*time.Second)
+	if err != nil {
+		t.Fatalf("截取失败: %v", err)
+	}
+	if clipped != 3*time.Second {
+		t.Errorf("截取时长 = %v，期望 3s", clipped)
+	}
+	pages := readAllOggPages(t, clip.Bytes())
+	if len(pages) != 5 {
+		t.Fatalf("页数 = %d，期望 5", len(pages))
+	}
+	if last := pages[len(pages)-1]; last.headerType()&oggHeaderTypeEOS == 0 || !last.checksumValid() {
+		t.Error("最后一页应标记流结束并重新计算CRC")
+	}
+
+	if _, _, err := ClipOpus(io.Discard, bytes.NewReader([]byte("RIFF0000WAVEfmt ")), time.Second); err == nil {
+		t.Error("非Ogg数据应返回错误")
+	}
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// oggPageHeaderSize Ogg页头固定部分的长度
+	oggPageHeaderSize = 27
+	// opusHeadSize OpusHead包的最小长度
+	opusHeadSize = 19
+	// opusGranuleRate Opus的granule位置始终以48kHz计数
+	opusGranuleRate = 48000
+	// opusTailScanSize 查找最后一个Ogg页时从文件末尾读取的字节数
+	opusTailScanSize = 64 * 1024
+)
+
+// opusHeadMagic OpusHead包的标识
+var opusHeadMagic = []byte("OpusHead")
+
+// OpusMeta Opus文件的编码元数据
+type OpusMeta struct {
+	Version         uint8         `json:"version"`
+	Channels        uint8         `json:"channels"`          // 声道数，1为单声道，2为立体声
+	PreSkip         uint16        `json:"pre_skip"`          // 解码时需丢弃的采样数（48kHz）
+	InputSampleRate uint32        `json:"input_sample_rate"` // 编码前的原始采样率，0表示未知
+	OutputGain      float64       `json:"output_gain"`       // 输出增益（dB）
+	MappingFamily   uint8         `json:"mapping_family"`
+	Duration        time.Duration `json:"duration"` // 播放时长，无法确定时为0
+	Bitrate         int64         `json:"bitrate"`  // 平均比特率（bps），无法确定时为0
+}
+
+// ExtractOpusMetadata 解析Opus文件的OpusHead包，并根据最后一个Ogg页估算时长和平均比特率
+func ExtractOpusMetadata(path string) (*OpusMeta, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	packet, err := readFirstOggPacket(file)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := parseOpusHead(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return meta, nil
+	}
+
+	// 时长和比特率为估算值，失败时保留已解析的头部信息
+	if granule, ok := lastGranulePosition(file, stat.Size()); ok && granule > int64(meta.PreSkip) {
+		samples := granule - int64(meta.PreSkip)
+		meta.Duration = time.Duration(samples) * time.Second / opusGranuleRate
+		if meta.Duration > 0 {
+			meta.Bitrate = int64(float64(stat.Size()*8) / meta.Duration.Seconds())
+		}
+	}
+
+	return meta, nil
+}
+
+// ChannelLayout 返回声道布局的可读描述
+func (m *OpusMeta) ChannelLayout() string {
+	switch m.Channels {
+	case 1:
+		return "单声道"
+	case 2:
+		return "立体声"
+	default:
+		return fmt.Sprintf("%d声道", m.Channels)
+	}
+}
+
+// readFirstOggPacket 读取第一个Ogg页中的第一个包
+func readFirstOggPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("读取Ogg页头失败: %w", err)
+	}
+	if !bytes.Equal(header[:4], OpusMagic) {
+		return nil, fmt.Errorf("不是Ogg文件")
+	}
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(r, segments); err != nil {
+		return nil, fmt.Errorf("读取Ogg分段表失败: %w", err)
+	}
+
+	// 包长度为连续分段之和，遇到小于255的分段结束
+	packetSize := 0
+	for _, size := range segments {
+		packetSize += int(size)
+		if size < 255 {
+			break
+		}
+	}
+
+	packet := make([]byte, packetSize)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return nil, fmt.Errorf("读取Ogg包失败: %w", err)
+	}
+	return packet, nil
+}
+
+// parseOpusHead 解析OpusHead包
+func parseOpusHead(packet []byte) (*OpusMeta, error) {
+	if len(packet) < opusHeadSize || !bytes.Equal(packet[:8], opusHeadMagic) {
+		return nil, fmt.Errorf("缺少OpusHead头")
+	}
+
+	meta := &OpusMeta{
+		Version:         packet[8],
+		Channels:        packet[9],
+		PreSkip:         binary.LittleEndian.Uint16(packet[10:12]),
+		InputSampleRate: binary.LittleEndian.Uint32(packet[12:16]),
+		OutputGain:      float64(int16(binary.LittleEndian.Uint16(packet[16:18]))) / 256,
+		MappingFamily:   packet[18],
+	}
+
+	if meta.Channels == 0 {
+		return nil, fmt.Errorf("OpusHead声道数无效: 0")
+	}
+	return meta, nil
+}
+
+// lastGranulePosition 从文件末尾查找最后一个Ogg页的granule位置
+func lastGranulePosition(r io.ReaderAt, size int64) (int64, bool) {
+	offset := size - opusTailScanSize
+	if offset < 0 {
+		offset = 0
+	}
+
+	tail := make([]byte, size-offset)
+	if _, err := r.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return 0, false
+	}
+
+	idx := bytes.LastIndex(tail, OpusMagic)
+	if idx < 0 || idx+14 > len(tail) {
+		return 0, false
+	}
+
+	granule := int64(binary.LittleEndian.Uint64(tail[idx+6 : idx+14]))
+	return granule, granule > 0
+}
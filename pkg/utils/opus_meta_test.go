@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildOggPage 构造一个Ogg页（不计算CRC）
+func buildOggPage(granule int64, sequence uint32, payload []byte) []byte {
+	var page bytes.Buffer
+	page.Write(OpusMagic)
+	page.WriteByte(0) // version
+	page.WriteByte(0) // header type
+	binary.Write(&page, binary.LittleEndian, granule)
+	binary.Write(&page, binary.LittleEndian, uint32(1)) // serial
+	binary.Write(&page, binary.LittleEndian, sequence)
+	binary.Write(&page, binary.LittleEndian, uint32(0)) // crc
+
+	var segments []byte
+	remaining := len(payload)
+	for remaining >= 255 {
+		segments = append(segments, 255)
+		remaining -= 255
+	}
+	segments = append(segments, byte(remaining))
+	page.WriteByte(byte(len(segments)))
+	page.Write(segments)
+	page.Write(payload)
+	return page.Bytes()
+}
+
+// buildOpusSample 构造指定参数的Opus样本文件内容
+func buildOpusSample(channels uint8, sampleRate uint32, preSkip uint16, gain int16, duration time.Duration) []byte {
+	var head bytes.Buffer
+	head.WriteString("OpusHead")
+	head.WriteByte(1)
+	head.WriteByte(channels)
+	binary.Write(&head, binary.LittleEndian, preSkip)
+	binary.Write(&head, binary.LittleEndian, sampleRate)
+	binary.Write(&head, binary.LittleEndian, gain)
+	head.WriteByte(0)
+
+	var sample bytes.Buffer
+	sample.Write(buildOggPage(0, 0, head.Bytes()))
+	sample.Write(buildOggPage(0, 1, []byte("OpusTags\x07\x00\x00\x00testenc\x00\x00\x00\x00")))
+	granule := int64(preSkip) + int64(duration/time.Millisecond)*48
+	sample.Write(buildOggPage(granule, 2, bytes.Repeat([]byte{0xAB}, 600)))
+	return sample.Bytes()
+}
+
+// TestExtractOpusMetadata 测试解析已知参数的Opus样本
+func TestExtractOpusMetadata(t *testing.T) {
+	testCases := []struct {
+		name       string
+		channels   uint8
+		sampleRate uint32
+		gain       int16
+		layout     string
+	}{
+		{"单声道16kHz", 1, 16000, 0, "单声道"},
+		{"立体声44.1kHz", 2, 44100, 256, "立体声"},
+		{"负增益", 1, 48000, -512, "单声道"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sample.opus")
+			data := buildOpusSample(tc.channels, tc.sampleRate, 312, tc.gain, 3*time.Second)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			meta, err := ExtractOpusMetadata(path)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+
+			if meta.Channels != tc.channels {
+				t.Errorf("声道数错误，期望 %d，实际 %d", tc.channels, meta.Channels)
+			}
+			if meta.InputSampleRate != tc.sampleRate {
+				t.Errorf("采样率错误，期望 %d，实际 %d", tc.sampleRate, meta.InputSampleRate)
+			}
+			if meta.PreSkip != 312 {
+				t.Errorf("pre-skip错误，实际 %d", meta.PreSkip)
+			}
+			if expected := float64(tc.gain) / 256; meta.OutputGain != expected {
+				t.Errorf("增益错误，期望 %.2f dB，实际 %.2f dB", expected, meta.OutputGain)
+			}
+			if meta.ChannelLayout() != tc.layout {
+				t.Errorf("声道布局错误，期望 %s，实际 %s", tc.layout, meta.ChannelLayout())
+			}
+			if meta.Duration != 3*time.Second {
+				t.Errorf("时长错误，期望 3s，实际 %s", meta.Duration)
+			}
+			if expected := int64(len(data)) * 8 / 3; meta.Bitrate != expected {
+				t.Errorf("比特率错误，期望 %d，实际 %d", expected, meta.Bitrate)
+			}
+		})
+	}
+}
+
+// TestExtractOpusMetadata_Invalid 测试无效文件返回错误
+func TestExtractOpusMetadata_Invalid(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"非Ogg文件", []byte("RIFF....WAVEfmt ")},
+		{"缺少OpusHead", buildOggPage(0, 0, []byte("VorbisHeadxxxxxxxxxxx"))},
+		{"文件过短", []byte("OggS")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tc.name+".opus")
+			if err := os.WriteFile(path, tc.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ExtractOpusMetadata(path); err == nil {
+				t.Error("应返回错误")
+			}
+		})
+	}
+
+	if _, err := ExtractOpusMetadata(filepath.Join(tempDir, "missing.opus")); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// IsLink 判断是否为符号链接或目录联接（Windows junction 在 Lstat 中表现为带 ModeIrregular 的目录）
+// 快捷方式（.lnk）是普通文件，不会被跟随
+func IsLink(info os.FileInfo) bool {
+	mode := info.Mode()
+	return mode&os.ModeSymlink != 0 || (mode&os.ModeIrregular != 0 && mode.IsDir())
+}
+
+// LinkInPath 检查 base 到 path 之间（含两端）的各级路径是否为链接，返回遇到的第一个链接
+// path 不在 base 内时只检查 path 本身；不存在的路径视为没有链接
+func LinkInPath(base, path string) (string, bool) {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if info, err := os.Lstat(path); err == nil && IsLink(info) {
+			return path, true
+		}
+		return "", false
+	}
+
+	current := filepath.Clean(base)
+	parts := []string{}
+	if rel != "." {
+		parts = strings.Split(rel, string(filepath.Separator))
+	}
+	for i := 0; ; i++ {
+		info, err := os.Lstat(current)
+		if err != nil {
+			return "", false
+		}
+		if IsLink(info) {
+			return current, true
+		}
+		if i == len(parts) {
+			return "", false
+		}
+		current = filepath.Join(current, parts[i])
+	}
+}
+
+// walkFunc 遍历回调，info 为跟随链接后的目标信息，link 表示 path 本身是链接
+type walkFunc func(path string, info os.FileInfo, link bool) error
+
+// linkWalker 按链接策略遍历目录树
+type linkWalker struct {
+	followSymlinks bool
+	log            *logger.Logger
+	fn             walkFunc
+	visited        map[string]bool // 跟随链接时已访问的真实路径
+}
+
+// walkDirectory 遍历目录树（含根目录），默认不跟随链接，遇到时跳过并警告
+// followSymlinks 为 true 时进入链接指向的目录，同一真实路径只访问一次，避免重复计入和循环
+func walkDirectory(root string, followSymlinks bool, log *logger.Logger, fn walkFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		log.Warn("访问路径失败: %s, 错误: %v", root, err)
+		return nil
+	}
+
+	w := &linkWalker{
+		followSymlinks: followSymlinks,
+		log:            log,
+		fn:             fn,
+		visited:        make(map[string]bool),
+	}
+	return w.walk(root, info)
+}
+
+// walk 访问 path 并递归其子项
+func (w *linkWalker) walk(path string, info os.FileInfo) error {
+	link := IsLink(info)
+	if link {
+		if !w.followSymlinks {
+			w.log.Warn("跳过符号链接（未开启 follow_symlinks）: %s", path)
+			return nil
+		}
+		target, err := os.Stat(path)
+		if err != nil {
+			w.log.Warn("访问链接目标失败: %s, 错误: %v", path, err)
+			return nil
+		}
+		info = target
+	}
+
+	if w.followSymlinks {
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			w.log.Warn("解析真实路径失败: %s, 错误: %v", path, err)
+			return nil
+		}
+		if w.visited[realPath] {
+			w.log.Debug("跳过已访问的路径: %s -> %s", path, realPath)
+			return nil
+		}
+		w.visited[realPath] = true
+	}
+
+	if err := w.fn(path, info, link); err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		w.log.Warn("访问路径失败: %s, 错误: %v", path, err)
+		return nil
+	}
+	for _, entry := range entries {
+		childPath := filepath.Join(path, entry.Name())
+		childInfo, err := entry.Info()
+		if err != nil {
+			w.log.Warn("访问路径失败: %s, 错误: %v", childPath, err)
+			continue
+		}
+		if err := w.walk(childPath, childInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
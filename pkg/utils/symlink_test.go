@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/allanpk716/record_center/internal/logger"
+)
+
+// symlinkOrSkip 创建符号链接，当前环境不支持（如 Windows 未开启开发者模式）时跳过测试
+func symlinkOrSkip(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("当前环境无法创建符号链接: %v", err)
+	}
+}
+
+// mkdirAll 创建目录
+func mkdirAll(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+}
+
+// writeFile 写入测试文件
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	mkdirAll(t, filepath.Dir(path))
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+}
+
+// TestRemoveEmptyDirectories_Symlink 测试清理空文件夹时默认不跟随符号链接
+func TestRemoveEmptyDirectories_Symlink(t *testing.T) {
+	tests := []struct {
+		name               string
+		followSymlinks     bool
+		wantRemoved        int
+		wantOutsideRemoved bool
+	}{
+		{name: "默认不跟随", followSymlinks: false, wantRemoved: 1, wantOutsideRemoved: false},
+		{name: "开启后跟随", followSymlinks: true, wantRemoved: 2, wantOutsideRemoved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// base/
+			//   own/empty/      (空)
+			//   linked -> outside/real
+			// outside/real/
+			//   empty/          (空，位于链接指向的真实目录中)
+			//   keep.opus
+			root := t.TempDir()
+			base := filepath.Join(root, "base")
+			outside := filepath.Join(root, "outside", "real")
+			mkdirAll(t, filepath.Join(base, "own", "empty"))
+			mkdirAll(t, filepath.Join(outside, "empty"))
+			writeFile(t, filepath.Join(outside, "keep.opus"), 4)
+			symlinkOrSkip(t, outside, filepath.Join(base, "linked"))
+
+			removed, err := RemoveEmptyDirectories(base, logger.NewLogger(false), false, tt.followSymlinks)
+			if err != nil {
+				t.Fatalf("删除空目录失败: %v", err)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("删除数量 = %d, 期望 %d", removed, tt.wantRemoved)
+			}
+			if FileExists(filepath.Join(base, "own", "empty")) {
+				t.Error("目标目录内的空目录应被删除")
+			}
+			if got := !FileExists(filepath.Join(outside, "empty")); got != tt.wantOutsideRemoved {
+				t.Errorf("链接目标内的空目录被删除 = %v, 期望 %v", got, tt.wantOutsideRemoved)
+			}
+			if info, err := os.Lstat(filepath.Join(base, "linked")); err != nil || !IsLink(info) {
+				t.Error("链接本身不应被删除")
+			}
+		})
+	}
+}
+
+// TestGetDirectorySize_Symlink 测试计算目录大小时不重复计入链接目标
+func TestGetDirectorySize_Symlink(t *testing.T) {
+	// base/
+	//   a.opus          (4)
+	//   sub/b.opus      (6)
+	//   dup -> base/sub (目录内的链接，跟随时也不重复计入)
+	//   ext -> outside  (目录外的链接)
+	// outside/c.opus    (10)
+	root := t.TempDir()
+	base := filepath.Join(root, "base")
+	outside := filepath.Join(root, "outside")
+	writeFile(t, filepath.Join(base, "a.opus"), 4)
+	writeFile(t, filepath.Join(base, "sub", "b.opus"), 6)
+	writeFile(t, filepath.Join(outside, "c.opus"), 10)
+	symlinkOrSkip(t, filepath.Join(base, "sub"), filepath.Join(base, "dup"))
+	symlinkOrSkip(t, outside, filepath.Join(base, "ext"))
+
+	tests := []struct {
+		name           string
+		followSymlinks bool
+		want           int64
+	}{
+		{name: "默认不跟随", followSymlinks: false, want: 10},
+		{name: "开启后跟随且不重复计入", followSymlinks: true, want: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := GetDirectorySize(base, logger.NewLogger(false), tt.followSymlinks)
+			if err != nil {
+				t.Fatalf("计算目录大小失败: %v", err)
+			}
+			if size != tt.want {
+				t.Errorf("目录大小 = %d, 期望 %d", size, tt.want)
+			}
+		})
+	}
+}
+
+// TestLinkInPath 测试检查路径中的符号链接
+func TestLinkInPath(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "base")
+	outside := filepath.Join(root, "outside")
+	writeFile(t, filepath.Join(base, "real", "a.opus"), 1)
+	writeFile(t, filepath.Join(outside, "b.opus"), 1)
+	symlinkOrSkip(t, outside, filepath.Join(base, "linked"))
+
+	tests := []struct {
+		name     string
+		path     string
+		wantLink string
+	}{
+		{name: "普通路径", path: filepath.Join(base, "real", "a.opus")},
+		{name: "父目录为链接", path: filepath.Join(base, "linked", "b.opus"), wantLink: filepath.Join(base, "linked")},
+		{name: "不存在的路径", path: filepath.Join(base, "missing", "c.opus")},
+		{name: "目标目录本身", path: base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, ok := LinkInPath(base, tt.path)
+			if ok != (tt.wantLink != "") || link != tt.wantLink {
+				t.Errorf("LinkInPath() = (%s, %v), 期望 %s", link, ok, tt.wantLink)
+			}
+		})
+	}
+}
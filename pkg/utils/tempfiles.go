@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TempFilePrefix 本程序创建的临时文件统一前缀，启动清理时据此识别
+const TempFilePrefix = "record_center_tmp_"
+
+// StaleTempFileAge 启动时清理的残留临时文件最小年龄，避免误删其他实例正在使用的文件
+const StaleTempFileAge = 24 * time.Hour
+
+// legacyTempFilePrefixes 旧版本使用的临时文件前缀，清理时一并识别
+var legacyTempFilePrefixes = []string{"rec_temp_", "mtp_temp_"}
+
+var (
+	tempDirMutex sync.RWMutex
+	tempDir      string
+)
+
+// SetTempDir 设置临时文件目录（通常为 backup.temp_dir），为空时使用系统临时目录
+func SetTempDir(dir string) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建临时目录失败: %w", err)
+		}
+	}
+
+	tempDirMutex.Lock()
+	defer tempDirMutex.Unlock()
+	tempDir = dir
+	return nil
+}
+
+// TempDir 获取临时文件目录
+func TempDir() string {
+	tempDirMutex.RLock()
+	defer tempDirMutex.RUnlock()
+
+	if tempDir == "" {
+		return os.TempDir()
+	}
+	return tempDir
+}
+
+// TempFilePath 生成带统一前缀的临时文件路径
+func TempFilePath(name string) string {
+	return filepath.Join(TempDir(), TempFilePrefix+name)
+}
+
+// IsOwnTempFile 判断文件名是否为本程序创建的临时文件
+func IsOwnTempFile(name string) bool {
+	if strings.HasPrefix(name, TempFilePrefix) {
+		return true
+	}
+	for _, prefix := range legacyTempFilePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupTempFiles 删除目录中修改时间早于 maxAge 的本程序临时文件，返回删除数量
+func CleanupTempFiles(dir string, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("读取临时目录失败: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !IsOwnTempFile(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCleanupTempFiles 测试启动清理只删除本程序的旧临时文件
+func TestCleanupTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	testCases := []struct {
+		name    string
+		modTime time.Time
+		removed bool
+	}{
+		{TempFilePrefix + "stream_1", old, true},
+		{"rec_temp_a.opus", old, true},
+		{"mtp_temp_123", old, true},
+		{TempFilePrefix + "stream_2", time.Now(), false},
+		{"other_app.tmp", old, false},
+		{"录音.opus", old, false},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(tempDir, tc.name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		if err := os.Chtimes(path, tc.modTime, tc.modTime); err != nil {
+			t.Fatalf("设置修改时间失败: %v", err)
+		}
+	}
+
+	removed, err := CleanupTempFiles(tempDir, StaleTempFileAge)
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("期望删除 3 个文件，实际 %d 个", removed)
+	}
+
+	for _, tc := range testCases {
+		_, err := os.Stat(filepath.Join(tempDir, tc.name))
+		if exists := err == nil; exists == tc.removed {
+			t.Errorf("%s: 期望删除=%v", tc.name, tc.removed)
+		}
+	}
+
+	if removed, err := CleanupTempFiles(filepath.Join(tempDir, "missing"), StaleTempFileAge); err != nil || removed != 0 {
+		t.Errorf("目录不存在时应直接返回，实际 %d, %v", removed, err)
+	}
+}
+
+// TestTempFilePath 测试临时文件使用配置的目录和统一前缀
+func TestTempFilePath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "temp")
+	if err := SetTempDir(dir); err != nil {
+		t.Fatalf("设置临时目录失败: %v", err)
+	}
+	defer SetTempDir("")
+
+	path := TempFilePath("a.opus")
+	if path != filepath.Join(dir, TempFilePrefix+"a.opus") {
+		t.Errorf("临时文件路径错误: %s", path)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("应自动创建临时目录: %v", err)
+	}
+
+	SetTempDir("")
+	if TempDir() != os.TempDir() {
+		t.Errorf("未配置时应使用系统临时目录，实际 %s", TempDir())
+	}
+}
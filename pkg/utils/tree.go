@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TreeNode 目录树节点
+type TreeNode struct {
+	Name     string
+	IsDir    bool
+	Size     int64 // 文件大小，目录为其下所有文件大小之和
+	Skipped  bool  // 被排除的目录，不展开其内容
+	Children []*TreeNode
+}
+
+// BuildTree 从带相对路径的扁平文件列表重建目录树
+// excludeDirs 中的目录名（不区分大小写）会被标记为跳过且不包含子节点
+func BuildTree(rootName string, files []*FileInfo, excludeDirs []string) *TreeNode {
+	root := &TreeNode{Name: rootName, IsDir: true}
+
+	excluded := make(map[string]bool, len(excludeDirs))
+	for _, dir := range excludeDirs {
+		excluded[strings.ToLower(dir)] = true
+	}
+
+	for _, file := range files {
+		path := strings.Trim(strings.ReplaceAll(file.RelativePath, "\\", "/"), "/")
+		if path == "" {
+			continue
+		}
+
+		parts := strings.Split(path, "/")
+		node := root
+		skipped := false
+		for _, dirName := range parts[:len(parts)-1] {
+			node = node.child(dirName, true)
+			if excluded[strings.ToLower(dirName)] {
+				node.Skipped = true
+				skipped = true
+				break
+			}
+		}
+		if skipped {
+			continue
+		}
+
+		leaf := node.child(parts[len(parts)-1], false)
+		leaf.Size = file.Size
+	}
+
+	root.sortAndSum()
+	return root
+}
+
+// child 获取或创建子节点
+func (n *TreeNode) child(name string, isDir bool) *TreeNode {
+	for _, c := range n.Children {
+		if c.Name == name && c.IsDir == isDir {
+			return c
+		}
+	}
+	c := &TreeNode{Name: name, IsDir: isDir}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// sortAndSum 目录在前、按名称排序，并汇总目录大小
+func (n *TreeNode) sortAndSum() int64 {
+	if !n.IsDir {
+		return n.Size
+	}
+
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].IsDir != n.Children[j].IsDir {
+			return n.Children[i].IsDir
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+
+	var total int64
+	for _, c := range n.Children {
+		total += c.sortAndSum()
+	}
+	n.Size = total
+	return total
+}
+
+// RenderTree 以缩进树形打印目录树，maxDepth <= 0 表示不限深度
+func RenderTree(w io.Writer, root *TreeNode, maxDepth int) {
+	// 先收集所有行，计算名称列宽度以对齐大小列
+	type line struct {
+		prefix string
+		node   *TreeNode
+	}
+
+	var lines []line
+	var walk func(node *TreeNode, prefix string, depth int)
+	walk = func(node *TreeNode, prefix string, depth int) {
+		if node.Skipped || (maxDepth > 0 && depth >= maxDepth) {
+			return
+		}
+		for i, c := range node.Children {
+			connector, childPrefix := "├── ", "│   "
+			if i == len(node.Children)-1 {
+				connector, childPrefix = "└── ", "    "
+			}
+			lines = append(lines, line{prefix: prefix + connector, node: c})
+			if c.IsDir {
+				walk(c, prefix+childPrefix, depth+1)
+			}
+		}
+	}
+	walk(root, "", 0)
+
+	nameWidth := 0
+	labels := make([]string, len(lines))
+	for i, l := range lines {
+		labels[i] = l.prefix + l.node.Name
+		if l.node.IsDir {
+			labels[i] += "/"
+		}
+		if width := DisplayWidth(labels[i]); width > nameWidth {
+			nameWidth = width
+		}
+	}
+
+	fmt.Fprintf(w, "%s/\n", root.Name)
+	for i, l := range lines {
+		label := labels[i]
+		padding := strings.Repeat(" ", nameWidth-DisplayWidth(label)+2)
+		switch {
+		case l.node.Skipped:
+			fmt.Fprintf(w, "%s%s[skipped]\n", label, padding)
+		case l.node.IsDir:
+			fmt.Fprintf(w, "%s\n", label)
+		default:
+			fmt.Fprintf(w, "%s%s%s\n", label, padding, FormatBytes(l.node.Size))
+		}
+	}
+}
+
+// DisplayWidth 计算字符串在终端中的显示宽度（中日韩等全角字符占2列）
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if isWideRune(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isWideRune 判断字符是否为全角字符
+func isWideRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) || // 中日韩符号和标点
+		(r >= 0xFF00 && r <= 0xFF60) || // 全角ASCII
+		(r >= 0xFFE0 && r <= 0xFFE6)
+}
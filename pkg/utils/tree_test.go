@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestBuildTree 测试从扁平文件列表重建目录树
+func TestBuildTree(t *testing.T) {
+	files := []*FileInfo{
+		{RelativePath: "录音笔文件/2024/会议.opus", Size: 100},
+		{RelativePath: "录音笔文件/2024/访谈.opus", Size: 200},
+		{RelativePath: "录音笔文件\\2023\\旧录音.opus", Size: 50},
+		{RelativePath: "录音笔文件/根目录.opus", Size: 10},
+		{RelativePath: "Android/data/cache.bin", Size: 999},
+	}
+
+	root := BuildTree("SR302", files, []string{"android"})
+
+	if root.Name != "SR302" || !root.IsDir {
+		t.Fatalf("根节点错误: %+v", root)
+	}
+	if len(root.Children) != 2 {
+		t.Fatalf("根节点应有 2 个子节点，实际 %d", len(root.Children))
+	}
+
+	android := root.Children[0]
+	if android.Name != "Android" || !android.Skipped || len(android.Children) != 0 {
+		t.Errorf("被排除目录应标记为跳过且无子节点: %+v", android)
+	}
+
+	recordings := root.Children[1]
+	if recordings.Name != "录音笔文件" || recordings.Size != 360 {
+		t.Fatalf("目录节点错误: name=%s size=%d", recordings.Name, recordings.Size)
+	}
+
+	// 目录在前、文件在后，各自按名称排序
+	var names []string
+	for _, c := range recordings.Children {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "2023,2024,根目录.opus" {
+		t.Errorf("子节点顺序错误: %v", names)
+	}
+
+	year2024 := recordings.Children[1]
+	if len(year2024.Children) != 2 || year2024.Size != 300 {
+		t.Errorf("2024 目录应包含 2 个文件共 300 字节，实际 %d 个 %d 字节", len(year2024.Children), year2024.Size)
+	}
+	for _, c := range year2024.Children {
+		if c.IsDir {
+			t.Errorf("%s 应为文件节点", c.Name)
+		}
+	}
+}
+
+// TestRenderTree 测试树形渲染、深度限制与中文对齐
+func TestRenderTree(t *testing.T) {
+	files := []*FileInfo{
+		{RelativePath: "录音笔文件/会议.opus", Size: 2048},
+		{RelativePath: "录音笔文件/a.opus", Size: 1024},
+		{RelativePath: "录音笔文件/深层/更深/文件.opus", Size: 1},
+		{RelativePath: "Android/x.bin", Size: 1},
+	}
+	root := BuildTree("SR302", files, []string{"Android"})
+
+	var buf bytes.Buffer
+	RenderTree(&buf, root, 2)
+	output := buf.String()
+
+	if !strings.Contains(output, "Android/") || !strings.Contains(output, "[skipped]") {
+		t.Errorf("应标注被跳过的目录:\n%s", output)
+	}
+	if strings.Contains(output, "更深") {
+		t.Errorf("超过深度限制的节点不应输出:\n%s", output)
+	}
+
+	// 文件大小列在中文和英文文件名下应对齐
+	var sizeColumns []int
+	for _, line := range strings.Split(output, "\n") {
+		for _, size := range []string{"2.0 KiB", "1.0 KiB"} {
+			if idx := strings.Index(line, size); idx >= 0 {
+				sizeColumns = append(sizeColumns, DisplayWidth(line[:idx]))
+			}
+		}
+	}
+	if len(sizeColumns) != 2 || sizeColumns[0] != sizeColumns[1] {
+		t.Errorf("大小列未对齐: %v\n%s", sizeColumns, output)
+	}
+}
+
+// TestDisplayWidth 测试显示宽度计算
+func TestDisplayWidth(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int
+	}{
+		{"abc", 3},
+		{"会议", 4},
+		{"a会b", 4},
+		{"├── ", 4},
+	}
+
+	for _, tc := range testCases {
+		if got := DisplayWidth(tc.input); got != tc.expected {
+			t.Errorf("DisplayWidth(%q) = %d, 期望 %d", tc.input, got, tc.expected)
+		}
+	}
+}